@@ -15,6 +15,10 @@ import (
 
 	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+
+	// embed the time zone database for systems without one, so that the
+	// configured timezone can be loaded
+	_ "time/tzdata"
 )
 
 //go:embed ui/v2.5/build
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stashapp/stash/pkg/audit"
+	"github.com/stashapp/stash/pkg/manager"
+	"github.com/stashapp/stash/pkg/session"
+)
+
+// auditConfigChange records a change to a section of the configuration.
+// The names of the changed fields are recorded, but not their values.
+func auditConfigChange(ctx context.Context, section string) {
+	recordConfigChange(ctx, manager.GetInstance().Audit, section)
+}
+
+func recordConfigChange(ctx context.Context, l *audit.Log, section string) {
+	data := map[string]string{
+		"section": section,
+	}
+
+	if graphql.GetFieldContext(ctx) != nil {
+		var fields []string
+		for k := range getUpdateInputMap(ctx) {
+			fields = append(fields, k)
+		}
+		sort.Strings(fields)
+		data["fields"] = strings.Join(fields, ",")
+	}
+
+	l.Record(session.NewAuditEntry(ctx, audit.EventConfigChange, fmt.Sprintf("%s configuration changed", section), data))
+}
+
+// auditDelete records the deletion of objects of a type.
+func auditDelete(ctx context.Context, objectType string, ids []string, deleteFile bool) {
+	data := map[string]string{
+		"type": objectType,
+		"ids":  strings.Join(ids, ","),
+	}
+
+	message := fmt.Sprintf("deleted %d %s", len(ids), objectType)
+	if deleteFile {
+		data["delete_file"] = "true"
+		message += " and files"
+	}
+
+	manager.GetInstance().AuditEvent(ctx, audit.EventDelete, message, data)
+}
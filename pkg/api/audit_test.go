@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stashapp/stash/pkg/audit"
+	"github.com/stashapp/stash/pkg/session"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+func TestRecordConfigChange(t *testing.T) {
+	l := audit.NewLog(filepath.Join(t.TempDir(), "audit.log"))
+
+	ctx := session.SetCurrentUserID(context.Background(), "user")
+	ctx = session.SetRemoteAddr(ctx, "192.168.1.2:1234")
+	ctx = graphql.WithOperationContext(ctx, &graphql.OperationContext{
+		Variables: map[string]interface{}{
+			updateInputField: map[string]interface{}{
+				"username": "user",
+				"password": "secret",
+			},
+		},
+	})
+	ctx = graphql.WithFieldContext(ctx, &graphql.FieldContext{
+		Field: graphql.CollectedField{
+			Field: &ast.Field{
+				Definition: &ast.FieldDefinition{
+					Arguments: ast.ArgumentDefinitionList{{Name: updateInputField}},
+				},
+				Arguments: ast.ArgumentList{{
+					Name:  updateInputField,
+					Value: &ast.Value{Kind: ast.Variable, Raw: updateInputField},
+				}},
+			},
+		},
+	})
+
+	recordConfigChange(ctx, l, "general")
+
+	entries, count, err := l.Query(audit.Query{PerPage: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Fatalf("got %d audit entries, want 1", count)
+	}
+
+	e := entries[0]
+	if e.Type != audit.EventConfigChange || e.User != "user" || e.RemoteAddr != "192.168.1.2:1234" {
+		t.Errorf("unexpected audit entry %+v", e)
+	}
+	if e.Data["section"] != "general" {
+		t.Errorf("section = %q, want general", e.Data["section"])
+	}
+	// field names are recorded, but not their values
+	if e.Data["fields"] != "password,username" {
+		t.Errorf("fields = %q, want password,username", e.Data["fields"])
+	}
+	for _, v := range e.Data {
+		if v == "secret" {
+			t.Error("audit entry contains a changed value")
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"net/url"
 	"strings"
 
+	"github.com/stashapp/stash/pkg/audit"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager"
 	"github.com/stashapp/stash/pkg/manager/config"
@@ -96,6 +97,7 @@ func authenticateHandler() func(http.Handler) http.Handler {
 			}
 
 			ctx = session.SetCurrentUserID(ctx, userID)
+			ctx = session.SetRemoteAddr(ctx, r.RemoteAddr)
 
 			r = r.WithContext(ctx)
 
@@ -119,6 +121,11 @@ func checkSecurityTripwireActivated(c *config.Instance, w http.ResponseWriter) b
 
 func securityActivateTripwireAccessedFromInternetWithoutAuth(c *config.Instance, accessErr session.ExternalAccessError, w http.ResponseWriter) {
 	session.LogExternalAccessError(accessErr)
+	manager.GetInstance().Audit.Record(audit.Entry{
+		Type:       audit.EventExternalAccess,
+		RemoteAddr: net.IP(accessErr).String(),
+		Message:    "accessed from the internet without authentication",
+	})
 
 	err := c.ActivatePublicAccessTripwire(net.IP(accessErr).String())
 	if err != nil {
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// routeBodySizeLimits returns the maximum request body size of a request:
+// the limit of its route in overrides, which is keyed by path, or
+// defaultLimit for other routes.
+func routeBodySizeLimits(defaultLimit func() int64, overrides map[string]func() int64) func(r *http.Request) int64 {
+	return func(r *http.Request) int64 {
+		if limit, ok := overrides[r.URL.Path]; ok {
+			return limit()
+		}
+		return defaultLimit()
+	}
+}
+
+// maxBodySizeMiddleware limits the size of request bodies to the number of
+// bytes returned by limit, or does not limit them if limit returns zero.
+// Requests declaring a larger body are refused with 413 Request Entity Too
+// Large. Reading past the limit of a body without a declared length returns
+// an error, and error responses to the request are sent as 413 Request
+// Entity Too Large.
+func maxBodySizeMiddleware(limit func(r *http.Request) int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := limit(r)
+			if n > 0 && r.Body != nil && r.Body != http.NoBody {
+				if r.ContentLength > n {
+					http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+					return
+				}
+
+				limited := &limitedBody{ReadCloser: r.Body, remaining: n}
+				r.Body = limited
+				w = &limitedBodyResponseWriter{ResponseWriter: w, body: limited}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// errBodyTooLarge is returned when reading past the limit of a request body.
+var errBodyTooLarge = errors.New("request body too large")
+
+// limitedBody is a request body that returns errBodyTooLarge when reading
+// past its limit.
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+	tooLarge  bool
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.tooLarge {
+		return 0, errBodyTooLarge
+	}
+
+	// read one byte past the limit to detect larger bodies
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	if int64(n) > b.remaining {
+		n = int(b.remaining)
+		b.remaining = 0
+		b.tooLarge = true
+		return n, errBodyTooLarge
+	}
+
+	b.remaining -= int64(n)
+	return n, err
+}
+
+// limitedBodyResponseWriter sends error responses as 413 Request Entity Too
+// Large if the request body was larger than its limit, since handlers
+// report the read error as a generic error.
+type limitedBodyResponseWriter struct {
+	http.ResponseWriter
+	body *limitedBody
+}
+
+func (w *limitedBodyResponseWriter) WriteHeader(statusCode int) {
+	if w.body.tooLarge {
+		// the rest of the body is not read, so the connection cannot be
+		// reused
+		w.Header().Set("Connection", "close")
+		if statusCode >= http.StatusBadRequest {
+			statusCode = http.StatusRequestEntityTooLarge
+		}
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
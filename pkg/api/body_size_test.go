@@ -0,0 +1,90 @@
+package api
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxBodySizeMiddleware(t *testing.T) {
+	const (
+		defaultLimit = 10
+		uploadLimit  = 100
+	)
+
+	limits := routeBodySizeLimits(func() int64 { return defaultLimit }, map[string]func() int64{
+		"/upload": func() int64 { return uploadLimit },
+	})
+
+	handler := maxBodySizeMiddleware(limits)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		name    string
+		path    string
+		size    int
+		chunked bool
+		want    int
+	}{
+		{"under limit", "/graphql", defaultLimit, false, http.StatusOK},
+		{"over limit", "/graphql", defaultLimit + 1, false, http.StatusRequestEntityTooLarge},
+		{"under limit without length", "/graphql", defaultLimit, true, http.StatusOK},
+		{"over limit without length", "/graphql", defaultLimit + 1, true, http.StatusRequestEntityTooLarge},
+		{"over route limit without length", "/upload", uploadLimit + 1, true, http.StatusRequestEntityTooLarge},
+		{"under route limit", "/upload", uploadLimit, false, http.StatusOK},
+		{"over route limit", "/upload", uploadLimit + 1, false, http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(strings.Repeat("a", tt.size)))
+			if tt.chunked {
+				r.ContentLength = -1
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, tt.want, w.Code)
+		})
+	}
+}
+
+func TestMaxBodySizeMiddlewareUnlimited(t *testing.T) {
+	called := false
+	handler := maxBodySizeMiddleware(func(r *http.Request) int64 { return 0 })(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := io.ReadAll(r.Body)
+		assert.Nil(t, err)
+		assert.Len(t, b, 1000)
+		called = true
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(strings.Repeat("a", 1000)))
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.True(t, called)
+}
+
+func TestLimitedBody(t *testing.T) {
+	b := &limitedBody{ReadCloser: io.NopCloser(strings.NewReader("0123456789")), remaining: 5}
+
+	got, err := io.ReadAll(b)
+	assert.True(t, errors.Is(err, errBodyTooLarge))
+	assert.Equal(t, "01234", string(got))
+
+	_, err = b.Read(make([]byte, 1))
+	assert.True(t, errors.Is(err, errBodyTooLarge))
+
+	b = &limitedBody{ReadCloser: io.NopCloser(strings.NewReader("01234")), remaining: 5}
+	got, err = io.ReadAll(b)
+	assert.Nil(t, err)
+	assert.Equal(t, "01234", string(got))
+}
@@ -134,6 +134,21 @@ func (t changesetTranslator) nullInt64FromString(value *string, field string) *s
 	return ret
 }
 
+func (t changesetTranslator) nullFloat64(value *float64, field string) *sql.NullFloat64 {
+	if !t.hasField(field) {
+		return nil
+	}
+
+	ret := &sql.NullFloat64{}
+
+	if value != nil {
+		ret.Float64 = *value
+		ret.Valid = true
+	}
+
+	return ret
+}
+
 func (t changesetTranslator) nullBool(value *bool, field string) *sql.NullBool {
 	if !t.hasField(field) {
 		return nil
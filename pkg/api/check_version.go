@@ -14,6 +14,7 @@ import (
 	"golang.org/x/sys/cpu"
 
 	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager"
 )
 
 // we use the github REST V3 API as no login is required
@@ -159,10 +160,11 @@ func GetLatestVersion(ctx context.Context, shortHash bool) (latestVersion string
 	platform := fmt.Sprintf("%s/%s", runtime.GOOS, arch)
 	wantedRelease := stashReleases()[platform]
 
-	version, _, _ := GetVersion()
-	if version == "" {
+	buildInfo := manager.GetBuildInfo()
+	if !buildInfo.HasVersion() {
 		return "", "", ErrNoVersion
 	}
+	version := buildInfo.Version
 
 	// if the version is suffixed with -x-xxxx, then we are running a development build
 	usePreRelease := false
@@ -216,7 +218,7 @@ func getReleaseHash(ctx context.Context, release githubReleasesResponse, shortHa
 
 	if shortHash {
 		last := defaultSHLength                                // default length of git short hash
-		_, gitShort, _ := GetVersion()                         // retrieve it to check actual length
+		gitShort := manager.GetBuildInfo().GitHash             // retrieve it to check actual length
 		if len(gitShort) > last && len(gitShort) < shaLength { // sometimes short hash is longer
 			last = len(gitShort)
 		}
@@ -227,7 +229,7 @@ func getReleaseHash(ctx context.Context, release githubReleasesResponse, shortHa
 }
 
 func printLatestVersion(ctx context.Context) {
-	_, githash, _ = GetVersion()
+	githash := manager.GetBuildInfo().GitHash
 	latest, _, err := GetLatestVersion(ctx, true)
 	if err != nil {
 		logger.Errorf("Couldn't find latest version: %s", err)
@@ -259,7 +261,7 @@ func getShaFromTags(ctx context.Context, shortHash bool, name string) string {
 		}
 		return ""
 	}
-	_, gitShort, _ := GetVersion() // retrieve short hash to check actual length
+	gitShort := manager.GetBuildInfo().GitHash // retrieve short hash to check actual length
 
 	for _, tag := range tags {
 		if tag.Name == name {
@@ -8,6 +8,7 @@ import (
 
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager"
+	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/plugin"
 	"github.com/stashapp/stash/pkg/scraper"
@@ -71,6 +72,12 @@ func (r *Resolver) Subscription() models.SubscriptionResolver {
 func (r *Resolver) Tag() models.TagResolver {
 	return &tagResolver{r}
 }
+func (r *Resolver) QuarantinedFile() models.QuarantinedFileResolver {
+	return &quarantinedFileResolver{r}
+}
+func (r *Resolver) GenerateFailure() models.GenerateFailureResolver {
+	return &generateFailureResolver{r}
+}
 
 type mutationResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
@@ -84,6 +91,8 @@ type imageResolver struct{ *Resolver }
 type studioResolver struct{ *Resolver }
 type movieResolver struct{ *Resolver }
 type tagResolver struct{ *Resolver }
+type quarantinedFileResolver struct{ *Resolver }
+type generateFailureResolver struct{ *Resolver }
 
 func (r *Resolver) withTxn(ctx context.Context, fn func(r models.Repository) error) error {
 	return r.txnManager.WithTxn(ctx, fn)
@@ -167,14 +176,29 @@ func (r *queryResolver) Stats(ctx context.Context) (*models.StatsResultType, err
 	return &ret, nil
 }
 
-func (r *queryResolver) Version(ctx context.Context) (*models.Version, error) {
-	version, hash, buildtime := GetVersion()
+func (r *queryResolver) GeneratedUsage(ctx context.Context) ([]*models.GeneratedDirectoryUsage, error) {
+	if config.GetInstance().GetGeneratedPath() == "" {
+		return []*models.GeneratedDirectoryUsage{}, nil
+	}
+
+	mgr := manager.GetInstance()
+	usage := mgr.GeneratedUsage.Get(mgr.Paths)
+
+	ret := make([]*models.GeneratedDirectoryUsage, len(usage))
+	for i, u := range usage {
+		ret[i] = &models.GeneratedDirectoryUsage{
+			Category: u.Category,
+			Path:     u.Path,
+			Size:     float64(u.Size),
+			Files:    u.Files,
+		}
+	}
+
+	return ret, nil
+}
 
-	return &models.Version{
-		Version:   &version,
-		Hash:      hash,
-		BuildTime: buildtime,
-	}, nil
+func (r *queryResolver) Version(ctx context.Context) (*models.Version, error) {
+	return manager.GetBuildInfo().VersionModel(), nil
 }
 
 // Latestversion returns the latest git shorthash commit.
@@ -4,7 +4,8 @@ import (
 	"context"
 	"time"
 
-	"github.com/stashapp/stash/pkg/image"
+	"github.com/stashapp/stash/pkg/gallery"
+	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
 )
@@ -37,22 +38,19 @@ func (r *galleryResolver) Images(ctx context.Context, obj *models.Gallery) (ret
 
 func (r *galleryResolver) Cover(ctx context.Context, obj *models.Gallery) (ret *models.Image, err error) {
 	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
-		imgs, err := repo.Image().FindByGalleryID(obj.ID)
-		if err != nil {
+		if obj.CoverImageID.Valid {
+			var err error
+			ret, err = repo.Image().Find(int(obj.CoverImageID.Int64))
 			return err
 		}
 
-		if len(imgs) > 0 {
-			ret = imgs[0]
-		}
-
-		for _, img := range imgs {
-			if image.IsCover(img) {
-				ret = img
-				break
-			}
+		// cover has not been resolved yet - select one using the configured strategy
+		imgs, err := repo.Image().FindByGalleryID(obj.ID)
+		if err != nil {
+			return err
 		}
 
+		ret = gallery.SelectCover(imgs, config.GetInstance().GetGalleryCoverStrategy(), obj.Checksum)
 		return nil
 	}); err != nil {
 		return nil, err
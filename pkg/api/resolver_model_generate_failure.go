@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *generateFailureResolver) Scene(ctx context.Context, obj *models.GenerateFailure) (ret *models.Scene, err error) {
+	if !obj.SceneID.Valid {
+		return nil, nil
+	}
+
+	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
+		ret, err = repo.Scene().Find(int(obj.SceneID.Int64))
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *generateFailureResolver) SceneMarker(ctx context.Context, obj *models.GenerateFailure) (ret *models.SceneMarker, err error) {
+	if !obj.SceneMarkerID.Valid {
+		return nil, nil
+	}
+
+	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
+		ret, err = repo.SceneMarker().Find(int(obj.SceneMarkerID.Int64))
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (r *generateFailureResolver) RetryAt(ctx context.Context, obj *models.GenerateFailure) (*time.Time, error) {
+	return &obj.RetryAt.Timestamp, nil
+}
+
+func (r *generateFailureResolver) CreatedAt(ctx context.Context, obj *models.GenerateFailure) (*time.Time, error) {
+	return &obj.CreatedAt.Timestamp, nil
+}
+
+func (r *generateFailureResolver) UpdatedAt(ctx context.Context, obj *models.GenerateFailure) (*time.Time, error) {
+	return &obj.UpdatedAt.Timestamp, nil
+}
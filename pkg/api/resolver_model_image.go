@@ -33,6 +33,29 @@ func (r *imageResolver) File(ctx context.Context, obj *models.Image) (*models.Im
 	}, nil
 }
 
+func (r *imageResolver) Exif(ctx context.Context, obj *models.Image) (*models.ImageExifType, error) {
+	if !obj.DateTaken.Valid && !obj.CameraMake.Valid && !obj.CameraModel.Valid && !obj.GPSLatitude.Valid {
+		return nil, nil
+	}
+
+	ret := &models.ImageExifType{}
+	if obj.DateTaken.Valid {
+		ret.DateTaken = &obj.DateTaken.Timestamp
+	}
+	if obj.CameraMake.Valid {
+		ret.CameraMake = &obj.CameraMake.String
+	}
+	if obj.CameraModel.Valid {
+		ret.CameraModel = &obj.CameraModel.String
+	}
+	if obj.GPSLatitude.Valid && obj.GPSLongitude.Valid {
+		ret.Latitude = &obj.GPSLatitude.Float64
+		ret.Longitude = &obj.GPSLongitude.Float64
+	}
+
+	return ret, nil
+}
+
 func (r *imageResolver) Paths(ctx context.Context, obj *models.Image) (*models.ImagePathsType, error) {
 	baseURL, _ := ctx.Value(BaseURLCtxKey).(string)
 	builder := urlbuilders.NewImageURLBuilder(baseURL, obj)
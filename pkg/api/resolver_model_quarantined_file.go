@@ -0,0 +1,12 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *quarantinedFileResolver) CreatedAt(ctx context.Context, obj *models.QuarantinedFile) (*time.Time, error) {
+	return &obj.CreatedAt.Timestamp, nil
+}
@@ -61,6 +61,57 @@ func (r *sceneResolver) Rating(ctx context.Context, obj *models.Scene) (*int, er
 	return nil, nil
 }
 
+func (r *sceneResolver) ScreenshotAt(ctx context.Context, obj *models.Scene) (*float64, error) {
+	if obj.ScreenshotAt.Valid {
+		return &obj.ScreenshotAt.Float64, nil
+	}
+	return nil, nil
+}
+
+func (r *sceneResolver) ResumeTime(ctx context.Context, obj *models.Scene) (*float64, error) {
+	if obj.ResumeTime.Valid {
+		return &obj.ResumeTime.Float64, nil
+	}
+	return nil, nil
+}
+
+func (r *sceneResolver) PreviewSegments(ctx context.Context, obj *models.Scene) (*int, error) {
+	if obj.PreviewSegments.Valid {
+		segments := int(obj.PreviewSegments.Int64)
+		return &segments, nil
+	}
+	return nil, nil
+}
+
+func (r *sceneResolver) PreviewSegmentDuration(ctx context.Context, obj *models.Scene) (*float64, error) {
+	if obj.PreviewSegmentDuration.Valid {
+		return &obj.PreviewSegmentDuration.Float64, nil
+	}
+	return nil, nil
+}
+
+func (r *sceneResolver) PreviewExcludeStart(ctx context.Context, obj *models.Scene) (*string, error) {
+	if obj.PreviewExcludeStart.Valid {
+		return &obj.PreviewExcludeStart.String, nil
+	}
+	return nil, nil
+}
+
+func (r *sceneResolver) PreviewExcludeEnd(ctx context.Context, obj *models.Scene) (*string, error) {
+	if obj.PreviewExcludeEnd.Valid {
+		return &obj.PreviewExcludeEnd.String, nil
+	}
+	return nil, nil
+}
+
+func (r *sceneResolver) SpriteCount(ctx context.Context, obj *models.Scene) (*int, error) {
+	if obj.SpriteCount.Valid {
+		count := int(obj.SpriteCount.Int64)
+		return &count, nil
+	}
+	return nil, nil
+}
+
 func (r *sceneResolver) InteractiveSpeed(ctx context.Context, obj *models.Scene) (*int, error) {
 	if obj.InteractiveSpeed.Valid {
 		interactive_speed := int(obj.InteractiveSpeed.Int64)
@@ -95,9 +146,11 @@ func (r *sceneResolver) Paths(ctx context.Context, obj *models.Scene) (*models.S
 	webpPath := builder.GetStreamPreviewImageURL()
 	vttPath := builder.GetSpriteVTTURL()
 	spritePath := builder.GetSpriteURL()
+	contactSheetPath := builder.GetContactSheetURL()
 	chaptersVttPath := builder.GetChaptersVTTURL()
 	funscriptPath := builder.GetFunscriptURL()
 	interactiveHeatmap := builder.GetInteractiveHeatmapURL()
+	subtitlesPath := builder.GetSubtitlesURL()
 
 	return &models.ScenePathsType{
 		Screenshot:         &screenshotPath,
@@ -107,8 +160,10 @@ func (r *sceneResolver) Paths(ctx context.Context, obj *models.Scene) (*models.S
 		Vtt:                &vttPath,
 		ChaptersVtt:        &chaptersVttPath,
 		Sprite:             &spritePath,
+		ContactSheet:       &contactSheetPath,
 		Funscript:          &funscriptPath,
 		InteractiveHeatmap: &interactiveHeatmap,
+		Subtitles:          &subtitlesPath,
 	}, nil
 }
 
@@ -218,6 +273,17 @@ func (r *sceneResolver) StashIds(ctx context.Context, obj *models.Scene) (ret []
 	return ret, nil
 }
 
+func (r *sceneResolver) Subtitles(ctx context.Context, obj *models.Scene) (ret []*models.SceneSubtitle, err error) {
+	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
+		ret, err = repo.Scene().GetSubtitles(obj.ID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
 func (r *sceneResolver) Phash(ctx context.Context, obj *models.Scene) (*string, error) {
 	if obj.Phash.Valid {
 		hexval := utils.PhashToString(obj.Phash.Int64)
@@ -226,6 +292,14 @@ func (r *sceneResolver) Phash(ctx context.Context, obj *models.Scene) (*string,
 	return nil, nil
 }
 
+func (r *sceneResolver) HashAlgorithm(ctx context.Context, obj *models.Scene) (*models.HashAlgorithm, error) {
+	if obj.HashAlgorithm.Valid {
+		ret := models.HashAlgorithm(obj.HashAlgorithm.String)
+		return &ret, nil
+	}
+	return nil, nil
+}
+
 func (r *sceneResolver) CreatedAt(ctx context.Context, obj *models.Scene) (*time.Time, error) {
 	return &obj.CreatedAt.Timestamp, nil
 }
@@ -237,3 +311,27 @@ func (r *sceneResolver) UpdatedAt(ctx context.Context, obj *models.Scene) (*time
 func (r *sceneResolver) FileModTime(ctx context.Context, obj *models.Scene) (*time.Time, error) {
 	return &obj.FileModTime.Timestamp, nil
 }
+
+func (r *sceneResolver) MissingAt(ctx context.Context, obj *models.Scene) (*time.Time, error) {
+	if !obj.MissingAt.Valid {
+		return nil, nil
+	}
+
+	return &obj.MissingAt.Timestamp, nil
+}
+
+func (r *sceneResolver) LastPlayedAt(ctx context.Context, obj *models.Scene) (*time.Time, error) {
+	if !obj.LastPlayedAt.Valid {
+		return nil, nil
+	}
+
+	return &obj.LastPlayedAt.Timestamp, nil
+}
+
+func (r *sceneResolver) DeletedAt(ctx context.Context, obj *models.Scene) (*time.Time, error) {
+	if !obj.DeletedAt.Valid {
+		return nil, nil
+	}
+
+	return &obj.DeletedAt.Timestamp, nil
+}
@@ -6,18 +6,23 @@ import (
 	"fmt"
 	"path/filepath"
 
+	"github.com/stashapp/stash/pkg/audit"
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/identify"
+	"github.com/stashapp/stash/pkg/job"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager"
 	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene"
+	"github.com/stashapp/stash/pkg/session"
 	"github.com/stashapp/stash/pkg/utils"
 )
 
 var ErrOverriddenConfig = errors.New("cannot set overridden value")
 
-func (r *mutationResolver) Setup(ctx context.Context, input models.SetupInput) (bool, error) {
-	err := manager.GetInstance().Setup(ctx, input)
-	return err == nil, err
+func (r *mutationResolver) Setup(ctx context.Context, input models.SetupInput) ([]*models.SelfTestResult, error) {
+	return manager.GetInstance().Setup(ctx, input)
 }
 
 func (r *mutationResolver) Migrate(ctx context.Context, input models.MigrateInput) (bool, error) {
@@ -25,6 +30,19 @@ func (r *mutationResolver) Migrate(ctx context.Context, input models.MigrateInpu
 	return err == nil, err
 }
 
+func (r *mutationResolver) RefreshFfmpeg(ctx context.Context) (bool, error) {
+	if err := manager.GetInstance().RefreshFFMPEG(); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *mutationResolver) SetMaintenance(ctx context.Context, enabled bool) (bool, error) {
+	manager.GetInstance().SetMaintenance(enabled)
+	return manager.GetInstance().IsMaintenance(), nil
+}
+
 func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.ConfigGeneralInput) (*models.ConfigGeneralResult, error) {
 	c := config.GetInstance()
 
@@ -39,12 +57,23 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 					break
 				}
 			}
-			if isNew {
+			// remote paths may require credentials set in this request, so
+			// are validated when scanned
+			if isNew && !file.IsRemotePath(s.Path) {
 				exists, err := utils.DirExists(s.Path)
 				if !exists {
 					return makeConfigGeneralResult(), err
 				}
 			}
+
+			if err := manager.ValidateStashExclude(s.Exclude); err != nil {
+				return makeConfigGeneralResult(), err
+			}
+			if s.ScanSchedule != nil && *s.ScanSchedule != "" {
+				if _, err := job.ParseSchedule(*s.ScanSchedule); err != nil {
+					return makeConfigGeneralResult(), fmt.Errorf("invalid scan schedule for %s: %w", s.Path, err)
+				}
+			}
 		}
 		c.Set(config.Stash, input.Stashes)
 	}
@@ -84,6 +113,10 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 		c.Set(config.Database, input.DatabasePath)
 	}
 
+	if input.VerifyMigration != nil {
+		c.Set(config.VerifyMigration, *input.VerifyMigration)
+	}
+
 	existingGeneratedPath := c.GetGeneratedPath()
 	if input.GeneratedPath != nil && existingGeneratedPath != *input.GeneratedPath {
 		if err := validateDir(config.Generated, *input.GeneratedPath, false); err != nil {
@@ -130,6 +163,9 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 		if !calculateMD5 && *input.VideoFileNamingAlgorithm == models.HashAlgorithmMd5 {
 			return makeConfigGeneralResult(), errors.New("calculateMD5 must be true if using MD5")
 		}
+		if c.GetFullHashMaxSize() > 0 && *input.VideoFileNamingAlgorithm == models.HashAlgorithmMd5 {
+			return makeConfigGeneralResult(), fmt.Errorf("%s must not be set if using MD5", config.FullHashMaxSize)
+		}
 
 		// validate changing VideoFileNamingAlgorithm
 		if err := manager.ValidateVideoFileNamingAlgorithm(r.txnManager, *input.VideoFileNamingAlgorithm); err != nil {
@@ -146,17 +182,117 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 	if input.ParallelTasks != nil {
 		c.Set(config.ParallelTasks, *input.ParallelTasks)
 	}
+	if input.AutoTagMatchWorkers != nil {
+		c.Set(config.AutoTagMatchWorkers, *input.AutoTagMatchWorkers)
+	}
+	if input.AutoTagWriteBatchSize != nil {
+		if *input.AutoTagWriteBatchSize < 1 {
+			return makeConfigGeneralResult(), fmt.Errorf("autoTagWriteBatchSize must be at least 1")
+		}
+		c.Set(config.AutoTagWriteBatchSize, *input.AutoTagWriteBatchSize)
+	}
+	if input.AutoTagApplyTagAncestors != nil {
+		c.Set(config.AutoTagApplyTagAncestors, *input.AutoTagApplyTagAncestors)
+	}
+	if input.AutoTagFuzzyDistance != nil {
+		if *input.AutoTagFuzzyDistance < 0 {
+			return makeConfigGeneralResult(), fmt.Errorf("autoTagFuzzyDistance must not be negative")
+		}
+		c.Set(config.AutoTagFuzzyDistance, *input.AutoTagFuzzyDistance)
+	}
+
+	if input.ResourceLimitCPU != nil {
+		if *input.ResourceLimitCPU < 0 {
+			return makeConfigGeneralResult(), errors.New("resourceLimitCPU must not be negative")
+		}
+		c.Set(config.ResourceLimitCPU, *input.ResourceLimitCPU)
+	}
+	if input.ResourceLimitIo != nil {
+		if *input.ResourceLimitIo < 0 {
+			return makeConfigGeneralResult(), errors.New("resourceLimitIO must not be negative")
+		}
+		c.Set(config.ResourceLimitIO, *input.ResourceLimitIo)
+	}
+	if input.ResourceLimitMemory != nil {
+		if *input.ResourceLimitMemory < 0 {
+			return makeConfigGeneralResult(), errors.New("resourceLimitMemory must not be negative")
+		}
+		c.Set(config.ResourceLimitMemory, *input.ResourceLimitMemory)
+	}
+
+	if input.FfprobeTimeout != nil {
+		if *input.FfprobeTimeout < 0 {
+			return makeConfigGeneralResult(), errors.New("ffprobeTimeout must not be negative")
+		}
+		c.Set(config.FFProbeTimeout, *input.FfprobeTimeout)
+	}
+
+	if input.WatchLibrary != nil {
+		c.Set(config.WatchLibrary, *input.WatchLibrary)
+	}
 
 	if input.PreviewAudio != nil {
 		c.Set(config.PreviewAudio, *input.PreviewAudio)
 	}
 
 	if input.PreviewSegments != nil {
+		if *input.PreviewSegments < 1 {
+			return makeConfigGeneralResult(), errors.New("previewSegments must be at least 1")
+		}
 		c.Set(config.PreviewSegments, *input.PreviewSegments)
 	}
 	if input.PreviewSegmentDuration != nil {
+		if *input.PreviewSegmentDuration <= 0 {
+			return makeConfigGeneralResult(), errors.New("previewSegmentDuration must be greater than 0")
+		}
 		c.Set(config.PreviewSegmentDuration, *input.PreviewSegmentDuration)
 	}
+	if input.PreviewWidth != nil {
+		if *input.PreviewWidth < 1 {
+			return makeConfigGeneralResult(), errors.New("previewWidth must be greater than 0")
+		}
+		c.Set(config.PreviewWidth, *input.PreviewWidth)
+	}
+
+	if input.SpriteCount != nil {
+		if *input.SpriteCount < 1 {
+			return makeConfigGeneralResult(), errors.New("spriteCount must be at least 1")
+		}
+		c.Set(config.SpriteCount, *input.SpriteCount)
+	}
+	if input.SpriteColumns != nil {
+		if *input.SpriteColumns < 1 {
+			return makeConfigGeneralResult(), errors.New("spriteColumns must be at least 1")
+		}
+		c.Set(config.SpriteColumns, *input.SpriteColumns)
+	}
+	if input.SpriteWidth != nil {
+		if *input.SpriteWidth < 1 {
+			return makeConfigGeneralResult(), errors.New("spriteWidth must be greater than 0")
+		}
+		c.Set(config.SpriteWidth, *input.SpriteWidth)
+	}
+	if input.ContactSheetColumns != nil {
+		if *input.ContactSheetColumns < 1 {
+			return makeConfigGeneralResult(), errors.New("contactSheetColumns must be at least 1")
+		}
+		c.Set(config.ContactSheetColumns, *input.ContactSheetColumns)
+	}
+	if input.ContactSheetRows != nil {
+		if *input.ContactSheetRows < 1 {
+			return makeConfigGeneralResult(), errors.New("contactSheetRows must be at least 1")
+		}
+		c.Set(config.ContactSheetRows, *input.ContactSheetRows)
+	}
+	if input.ContactSheetWidth != nil {
+		if *input.ContactSheetWidth < 1 {
+			return makeConfigGeneralResult(), errors.New("contactSheetWidth must be greater than 0")
+		}
+		c.Set(config.ContactSheetWidth, *input.ContactSheetWidth)
+	}
+	if input.ContactSheetHeader != nil {
+		c.Set(config.ContactSheetHeader, *input.ContactSheetHeader)
+	}
 	if input.PreviewExcludeStart != nil {
 		c.Set(config.PreviewExcludeStart, *input.PreviewExcludeStart)
 	}
@@ -175,10 +311,102 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 		c.Set(config.MaxStreamingTranscodeSize, input.MaxStreamingTranscodeSize.String())
 	}
 
+	if input.TranscodeCacheSize != nil {
+		if *input.TranscodeCacheSize < 0 {
+			return makeConfigGeneralResult(), errors.New("transcodeCacheSize must not be negative")
+		}
+		c.Set(config.TranscodeCacheSize, *input.TranscodeCacheSize)
+	}
+
+	if input.TranscodeCleanupSchedule != nil {
+		if *input.TranscodeCleanupSchedule != "" {
+			if _, err := job.ParseSchedule(*input.TranscodeCleanupSchedule); err != nil {
+				return makeConfigGeneralResult(), fmt.Errorf("invalid transcode cleanup schedule: %w", err)
+			}
+		}
+		c.Set(config.TranscodeCleanupSchedule, *input.TranscodeCleanupSchedule)
+	}
+
+	if input.ImageThumbnailMaxSize != nil {
+		if *input.ImageThumbnailMaxSize <= 0 {
+			return makeConfigGeneralResult(), errors.New("imageThumbnailMaxSize must be positive")
+		}
+		c.Set(config.ImageThumbnailMaxSize, *input.ImageThumbnailMaxSize)
+	}
+
+	if input.ImageThumbnailCacheSize != nil {
+		if *input.ImageThumbnailCacheSize < 0 {
+			return makeConfigGeneralResult(), errors.New("imageThumbnailCacheSize must not be negative")
+		}
+		c.Set(config.ImageThumbnailCacheSize, *input.ImageThumbnailCacheSize)
+	}
+
+	if input.GeneratedCacheMaxAge != nil {
+		if *input.GeneratedCacheMaxAge < 0 {
+			return makeConfigGeneralResult(), errors.New("generatedCacheMaxAge must not be negative")
+		}
+		c.Set(config.GeneratedCacheMaxAge, *input.GeneratedCacheMaxAge)
+	}
+
+	if input.ShutdownGracePeriod != nil {
+		if *input.ShutdownGracePeriod < 0 {
+			return makeConfigGeneralResult(), errors.New("shutdownGracePeriod must not be negative")
+		}
+		c.Set(config.ShutdownGracePeriod, *input.ShutdownGracePeriod)
+	}
+
+	if input.GenerateMaxRetries != nil {
+		if *input.GenerateMaxRetries < 0 {
+			return makeConfigGeneralResult(), errors.New("generateMaxRetries must not be negative")
+		}
+		c.Set(config.GenerateMaxRetries, *input.GenerateMaxRetries)
+	}
+
+	if input.GenerateRetryBackoff != nil {
+		if *input.GenerateRetryBackoff < 0 {
+			return makeConfigGeneralResult(), errors.New("generateRetryBackoff must not be negative")
+		}
+		c.Set(config.GenerateRetryBackoff, *input.GenerateRetryBackoff)
+	}
+
 	if input.WriteImageThumbnails != nil {
 		c.Set(config.WriteImageThumbnails, *input.WriteImageThumbnails)
 	}
 
+	// covers of existing galleries are resolved again if the strategy changes
+	resolveGalleryCovers := false
+	if input.GalleryCoverStrategy != nil {
+		resolveGalleryCovers = *input.GalleryCoverStrategy != c.GetGalleryCoverStrategy()
+		c.Set(config.GalleryCoverStrategy, input.GalleryCoverStrategy.String())
+	}
+
+	if input.MissingFilePolicy != nil {
+		c.Set(config.MissingFilePolicy, input.MissingFilePolicy.String())
+	}
+
+	if input.WriteSceneSidecars != nil {
+		c.Set(config.WriteSceneSidecars, *input.WriteSceneSidecars)
+	}
+
+	if input.ReadSceneSidecars != nil {
+		c.Set(config.ReadSceneSidecars, *input.ReadSceneSidecars)
+	}
+
+	if input.SceneSidecarFormat != nil {
+		c.Set(config.SceneSidecarFormat, input.SceneSidecarFormat.String())
+	}
+
+	if input.SceneSidecarPrecedence != nil {
+		c.Set(config.SceneSidecarPrecedence, input.SceneSidecarPrecedence.String())
+	}
+
+	if input.OrganizeTemplate != nil {
+		if err := scene.ValidateOrganizeTemplate(*input.OrganizeTemplate); err != nil {
+			return makeConfigGeneralResult(), fmt.Errorf("invalid organizeTemplate: %w", err)
+		}
+		c.Set(config.OrganizeTemplate, *input.OrganizeTemplate)
+	}
+
 	if input.Username != nil {
 		c.Set(config.Username, input.Username)
 	}
@@ -189,7 +417,9 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 		currentPWHash := c.GetPasswordHash()
 
 		if *input.Password != currentPWHash {
-			c.SetPassword(*input.Password)
+			if err := session.SetPassword(c, *input.Password); err != nil {
+				return makeConfigGeneralResult(), err
+			}
 		}
 	}
 
@@ -197,6 +427,17 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 		c.Set(config.MaxSessionAge, *input.MaxSessionAge)
 	}
 
+	if input.LoginDelay != nil {
+		if *input.LoginDelay < 0 {
+			return makeConfigGeneralResult(), errors.New("login delay must not be negative")
+		}
+		c.Set(config.LoginDelay, *input.LoginDelay)
+	}
+
+	if input.LoginDelaySuccess != nil {
+		c.Set(config.LoginDelaySuccess, *input.LoginDelaySuccess)
+	}
+
 	if input.LogFile != nil {
 		c.Set(config.LogFile, input.LogFile)
 	}
@@ -209,6 +450,13 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 		c.Set(config.LogAccess, *input.LogAccess)
 	}
 
+	if input.Timezone != nil {
+		if err := config.ValidateTimezone(*input.Timezone); err != nil {
+			return makeConfigGeneralResult(), err
+		}
+		c.Set(config.Timezone, *input.Timezone)
+	}
+
 	if input.LogLevel != nil && *input.LogLevel != c.GetLogLevel() {
 		c.Set(config.LogLevel, input.LogLevel)
 		logger.SetLogLevel(*input.LogLevel)
@@ -222,6 +470,15 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 		c.Set(config.ImageExclude, input.ImageExcludes)
 	}
 
+	if input.JunkFilePatterns != nil {
+		for _, p := range input.JunkFilePatterns {
+			if _, err := filepath.Match(p, ""); err != nil {
+				return makeConfigGeneralResult(), fmt.Errorf("invalid junk file pattern %q: %w", p, err)
+			}
+		}
+		c.Set(config.JunkFilePatterns, input.JunkFilePatterns)
+	}
+
 	if input.VideoExtensions != nil {
 		c.Set(config.VideoExtensions, input.VideoExtensions)
 	}
@@ -238,6 +495,10 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 		c.Set(config.CreateGalleriesFromFolders, input.CreateGalleriesFromFolders)
 	}
 
+	if input.ScanHiddenFiles != nil {
+		c.Set(config.ScanHiddenFiles, *input.ScanHiddenFiles)
+	}
+
 	if input.CustomPerformerImageLocation != nil {
 		c.Set(config.CustomPerformerImageLocation, *input.CustomPerformerImageLocation)
 		initialiseCustomImages()
@@ -264,14 +525,42 @@ func (r *mutationResolver) ConfigureGeneral(ctx context.Context, input models.Co
 		c.Set(config.StashBoxes, input.StashBoxes)
 	}
 
+	if input.RemoteStashEndpoint != nil {
+		c.Set(config.RemoteStashEndpoint, *input.RemoteStashEndpoint)
+	}
+
+	if input.RemoteStashAPIKey != nil {
+		c.Set(config.RemoteStashAPIKey, *input.RemoteStashAPIKey)
+	}
+
+	if input.RemoteStashMergePolicy != nil {
+		c.Set(config.RemoteStashMergePolicy, input.RemoteStashMergePolicy.String())
+	}
+
+	if input.WebdavUsername != nil {
+		c.Set(config.WebDAVUsername, *input.WebdavUsername)
+	}
+
+	// the configuration query returns a placeholder in place of the WebDAV
+	// password - only set the password if it was changed
+	if input.WebdavPassword != nil && *input.WebdavPassword != webdavPasswordMask {
+		c.Set(config.WebDAVPassword, *input.WebdavPassword)
+	}
+
 	if err := c.Write(); err != nil {
 		return makeConfigGeneralResult(), err
 	}
 
+	auditConfigChange(ctx, "general")
+
 	manager.GetInstance().RefreshConfig()
+	manager.GetInstance().RefreshLibraryWatcher()
 	if refreshScraperCache {
 		manager.GetInstance().RefreshScraperCache()
 	}
+	if resolveGalleryCovers {
+		manager.GetInstance().ResolveGalleryCovers(ctx)
+	}
 
 	return makeConfigGeneralResult(), nil
 }
@@ -342,6 +631,8 @@ func (r *mutationResolver) ConfigureInterface(ctx context.Context, input models.
 		return makeConfigInterfaceResult(), err
 	}
 
+	auditConfigChange(ctx, "interface")
+
 	return makeConfigInterfaceResult(), nil
 }
 
@@ -375,10 +666,27 @@ func (r *mutationResolver) ConfigureDlna(ctx context.Context, input models.Confi
 		c.Set(config.DLNAInterfaces, input.Interfaces)
 	}
 
+	if input.TranscodeMode != nil {
+		c.Set(config.DLNATranscodeMode, input.TranscodeMode.String())
+	}
+
+	if input.MaxTranscodes != nil {
+		if *input.MaxTranscodes < 0 {
+			return makeConfigDLNAResult(), errors.New("maxTranscodes must not be negative")
+		}
+		c.Set(config.DLNAMaxTranscodes, *input.MaxTranscodes)
+	}
+
+	if input.RejectExcessTranscodes != nil {
+		c.Set(config.DLNARejectExcessTranscodes, *input.RejectExcessTranscodes)
+	}
+
 	if err := c.Write(); err != nil {
 		return makeConfigDLNAResult(), err
 	}
 
+	auditConfigChange(ctx, "dlna")
+
 	return makeConfigDLNAResult(), nil
 }
 
@@ -411,6 +719,8 @@ func (r *mutationResolver) ConfigureScraping(ctx context.Context, input models.C
 		return makeConfigScrapingResult(), err
 	}
 
+	auditConfigChange(ctx, "scraping")
+
 	return makeConfigScrapingResult(), nil
 }
 
@@ -418,6 +728,9 @@ func (r *mutationResolver) ConfigureDefaults(ctx context.Context, input models.C
 	c := config.GetInstance()
 
 	if input.Identify != nil {
+		if err := identify.ValidateTitleThreshold(input.Identify.TitleThreshold); err != nil {
+			return makeConfigDefaultsResult(), err
+		}
 		c.Set(config.DefaultIdentifySettings, input.Identify)
 	}
 
@@ -445,6 +758,8 @@ func (r *mutationResolver) ConfigureDefaults(ctx context.Context, input models.C
 		return makeConfigDefaultsResult(), err
 	}
 
+	auditConfigChange(ctx, "defaults")
+
 	return makeConfigDefaultsResult(), nil
 }
 
@@ -468,5 +783,10 @@ func (r *mutationResolver) GenerateAPIKey(ctx context.Context, input models.Gene
 		return newAPIKey, err
 	}
 
+	manager.GetInstance().AuditEvent(ctx, audit.EventConfigChange, "API key changed", map[string]string{
+		"section": "general",
+		"fields":  "apiKey",
+	})
+
 	return newAPIKey, nil
 }
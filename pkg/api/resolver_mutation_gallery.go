@@ -10,8 +10,10 @@ import (
 	"time"
 
 	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/gallery"
 	"github.com/stashapp/stash/pkg/image"
 	"github.com/stashapp/stash/pkg/manager"
+	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/plugin"
 	"github.com/stashapp/stash/pkg/utils"
@@ -482,6 +484,7 @@ func (r *mutationResolver) GalleryDestroy(ctx context.Context, input models.Gall
 
 	// perform the post-commit actions
 	fileDeleter.Commit()
+	auditDelete(ctx, "gallery", input.Ids, deleteFile)
 
 	for _, gallery := range galleries {
 		// don't delete stash library paths
@@ -523,6 +526,47 @@ func isStashPath(path string) bool {
 	return false
 }
 
+func (r *mutationResolver) GalleriesResolveCover(ctx context.Context, input models.GalleriesResolveCoverInput) (ret []*models.Gallery, err error) {
+	galleryIDs, err := utils.StringSliceToIntSlice(input.Ids)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy := config.GetInstance().GetGalleryCoverStrategy()
+	if input.Strategy != nil {
+		strategy = *input.Strategy
+	}
+
+	if err := r.withTxn(ctx, func(repo models.Repository) error {
+		qb := repo.Gallery()
+		iqb := repo.Image()
+
+		for _, id := range galleryIDs {
+			g, err := qb.Find(id)
+			if err != nil {
+				return err
+			}
+
+			if g == nil {
+				return fmt.Errorf("gallery with id %d not found", id)
+			}
+
+			updated, err := gallery.ResolveCover(qb, iqb, g, strategy)
+			if err != nil {
+				return err
+			}
+
+			ret = append(ret, updated)
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
 func (r *mutationResolver) AddGalleryImages(ctx context.Context, input models.GalleryAddInput) (bool, error) {
 	galleryID, err := strconv.Atoi(input.GalleryID)
 	if err != nil {
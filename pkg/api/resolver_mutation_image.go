@@ -308,6 +308,7 @@ func (r *mutationResolver) ImageDestroy(ctx context.Context, input models.ImageD
 
 	// perform the post-commit actions
 	fileDeleter.Commit()
+	auditDelete(ctx, "image", []string{input.ID}, utils.IsTrue(input.DeleteFile))
 
 	// call post hook after performing the other actions
 	r.hookExecutor.ExecutePostHooks(ctx, i.ID, plugin.ImageDestroyPost, plugin.ImageDestroyInput{
@@ -359,6 +360,7 @@ func (r *mutationResolver) ImagesDestroy(ctx context.Context, input models.Image
 
 	// perform the post-commit actions
 	fileDeleter.Commit()
+	auditDelete(ctx, "image", input.Ids, utils.IsTrue(input.DeleteFile))
 
 	for _, image := range images {
 		// call post hook after performing the other actions
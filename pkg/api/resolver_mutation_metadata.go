@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/identify"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager"
 	"github.com/stashapp/stash/pkg/manager/config"
@@ -27,6 +28,15 @@ func (r *mutationResolver) MetadataScan(ctx context.Context, input models.ScanMe
 	return strconv.Itoa(jobID), nil
 }
 
+func (r *mutationResolver) MetadataScanPath(ctx context.Context, path string) (string, error) {
+	jobID, err := manager.GetInstance().ScanPath(ctx, path)
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.Itoa(jobID), nil
+}
+
 func (r *mutationResolver) MetadataImport(ctx context.Context) (string, error) {
 	jobID, err := manager.GetInstance().Import(ctx)
 	if err != nil {
@@ -85,23 +95,105 @@ func (r *mutationResolver) MetadataGenerate(ctx context.Context, input models.Ge
 	return strconv.Itoa(jobID), nil
 }
 
+func (r *mutationResolver) MetadataGenerateRetry(ctx context.Context) (string, error) {
+	jobID, err := manager.GetInstance().RetryGenerate(ctx)
+
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) GenerateFailuresClear(ctx context.Context, input models.GenerateFailuresClearInput) (bool, error) {
+	var ids []int
+	if input.Ids != nil {
+		var err error
+		ids, err = utils.StringSliceToIntSlice(input.Ids)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if err := r.withTxn(ctx, func(repo models.Repository) error {
+		qb := repo.GenerateFailure()
+
+		if input.Ids == nil {
+			return qb.DestroyAll()
+		}
+
+		for _, id := range ids {
+			if err := qb.Destroy(id); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 func (r *mutationResolver) MetadataAutoTag(ctx context.Context, input models.AutoTagMetadataInput) (string, error) {
 	jobID := manager.GetInstance().AutoTag(ctx, input)
 	return strconv.Itoa(jobID), nil
 }
 
 func (r *mutationResolver) MetadataIdentify(ctx context.Context, input models.IdentifyMetadataInput) (string, error) {
+	if err := identify.ValidateTitleThreshold(input.TitleThreshold); err != nil {
+		return "", err
+	}
+
 	t := manager.CreateIdentifyJob(input)
 	jobID := manager.GetInstance().JobManager.Add(ctx, "Identifying...", t)
 
 	return strconv.Itoa(jobID), nil
 }
 
+func (r *mutationResolver) MetadataRemoteStashSync(ctx context.Context, input models.RemoteStashSyncInput) (string, error) {
+	t, err := manager.CreateRemoteStashSyncJob(input)
+	if err != nil {
+		return "", err
+	}
+
+	jobID := manager.GetInstance().JobManager.Add(ctx, "Syncing from remote stash...", t)
+
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) MetadataBatchScrape(ctx context.Context, input models.BatchScrapeInput) (string, error) {
+	t, err := manager.CreateBatchScrapeJob(input)
+	if err != nil {
+		return "", err
+	}
+
+	jobID := manager.GetInstance().JobManager.Add(ctx, "Batch scraping...", t)
+
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) MetadataPurgeDeleted(ctx context.Context) (string, error) {
+	jobID := manager.GetInstance().PurgeDeletedScenes(ctx)
+	return strconv.Itoa(jobID), nil
+}
+
 func (r *mutationResolver) MetadataClean(ctx context.Context, input models.CleanMetadataInput) (string, error) {
 	jobID := manager.GetInstance().Clean(ctx, input)
 	return strconv.Itoa(jobID), nil
 }
 
+func (r *mutationResolver) MetadataCleanJunkFiles(ctx context.Context, input models.CleanJunkFilesInput) (string, error) {
+	jobID := manager.GetInstance().CleanJunkFiles(ctx, input)
+	return strconv.Itoa(jobID), nil
+}
+
+func (r *mutationResolver) MetadataCleanGenerated(ctx context.Context, input models.CleanGeneratedInput) (string, error) {
+	jobID := manager.GetInstance().CleanGenerated(ctx, input)
+	return strconv.Itoa(jobID), nil
+}
+
 func (r *mutationResolver) MigrateHashNaming(ctx context.Context) (string, error) {
 	jobID := manager.GetInstance().MigrateHash(ctx)
 	return strconv.Itoa(jobID), nil
@@ -147,3 +239,16 @@ func (r *mutationResolver) BackupDatabase(ctx context.Context, input models.Back
 
 	return nil, nil
 }
+
+func (r *mutationResolver) ResetQueryStats(ctx context.Context) (bool, error) {
+	manager.GetInstance().QueryStats.Reset()
+	return true, nil
+}
+
+func (r *mutationResolver) RelocateDatabase(ctx context.Context, input models.RelocateDatabaseInput) (bool, error) {
+	if err := manager.GetInstance().RelocateDatabase(input.Path); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -232,6 +232,8 @@ func (r *mutationResolver) MovieDestroy(ctx context.Context, input models.MovieD
 		return false, err
 	}
 
+	auditDelete(ctx, "movie", []string{input.ID}, false)
+
 	r.hookExecutor.ExecutePostHooks(ctx, id, plugin.MovieDestroyPost, input, nil)
 
 	return true, nil
@@ -256,6 +258,8 @@ func (r *mutationResolver) MoviesDestroy(ctx context.Context, movieIDs []string)
 		return false, err
 	}
 
+	auditDelete(ctx, "movie", movieIDs, false)
+
 	for _, id := range ids {
 		r.hookExecutor.ExecutePostHooks(ctx, id, plugin.MovieDestroyPost, movieIDs, nil)
 	}
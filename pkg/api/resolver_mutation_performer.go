@@ -402,6 +402,22 @@ func (r *mutationResolver) BulkPerformerUpdate(ctx context.Context, input models
 	return newRet, nil
 }
 
+func (r *mutationResolver) BulkPerformerSetFavorite(ctx context.Context, input models.BulkPerformerFavoriteInput) (ret int, err error) {
+	performerIDs, err := utils.StringSliceToIntSlice(input.Ids)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := r.withTxn(ctx, func(repo models.Repository) error {
+		ret, err = performer.BulkSetFavorite(repo.Performer(), performerIDs, input.PerformerFilter, input.Favorite)
+		return err
+	}); err != nil {
+		return 0, err
+	}
+
+	return ret, nil
+}
+
 func (r *mutationResolver) PerformerDestroy(ctx context.Context, input models.PerformerDestroyInput) (bool, error) {
 	id, err := strconv.Atoi(input.ID)
 	if err != nil {
@@ -414,6 +430,8 @@ func (r *mutationResolver) PerformerDestroy(ctx context.Context, input models.Pe
 		return false, err
 	}
 
+	auditDelete(ctx, "performer", []string{input.ID}, false)
+
 	r.hookExecutor.ExecutePostHooks(ctx, id, plugin.PerformerDestroyPost, input, nil)
 
 	return true, nil
@@ -438,9 +456,45 @@ func (r *mutationResolver) PerformersDestroy(ctx context.Context, performerIDs [
 		return false, err
 	}
 
+	auditDelete(ctx, "performer", performerIDs, false)
+
 	for _, id := range ids {
 		r.hookExecutor.ExecutePostHooks(ctx, id, plugin.PerformerDestroyPost, performerIDs, nil)
 	}
 
 	return true, nil
 }
+
+func (r *mutationResolver) PerformersMerge(ctx context.Context, input models.PerformersMergeInput) (*models.Performer, error) {
+	source, err := utils.StringSliceToIntSlice(input.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	destination, err := strconv.Atoi(input.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(source) == 0 {
+		return nil, nil
+	}
+
+	var ret *models.Performer
+	if err := r.withTxn(ctx, func(repo models.Repository) error {
+		qb := repo.Performer()
+
+		if err := qb.Merge(source, destination); err != nil {
+			return err
+		}
+
+		var err error
+		ret, err = qb.Find(destination)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	r.hookExecutor.ExecutePostHooks(ctx, ret.ID, plugin.PerformerMergePost, input, nil)
+	return ret, nil
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+func (r *mutationResolver) QuarantineClear(ctx context.Context, input models.QuarantineClearInput) (bool, error) {
+	var ids []int
+	if input.Ids != nil {
+		var err error
+		ids, err = utils.StringSliceToIntSlice(input.Ids)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if err := r.withTxn(ctx, func(repo models.Repository) error {
+		qb := repo.Quarantine()
+
+		if input.Ids == nil {
+			return qb.DestroyAll()
+		}
+
+		for _, id := range ids {
+			if err := qb.Destroy(id); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -54,6 +54,8 @@ func (r *mutationResolver) DestroySavedFilter(ctx context.Context, input models.
 		return false, err
 	}
 
+	auditDelete(ctx, "saved filter", []string{input.ID}, false)
+
 	return true, nil
 }
 
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
@@ -41,6 +42,7 @@ func (r *mutationResolver) SceneUpdate(ctx context.Context, input models.SceneUp
 	}
 
 	r.hookExecutor.ExecutePostHooks(ctx, ret.ID, plugin.SceneUpdatePost, input, translator.getFields())
+	manager.WriteSceneSidecars(ctx, r.txnManager, []int{ret.ID})
 	return r.getScene(ctx, ret.ID)
 }
 
@@ -67,6 +69,8 @@ func (r *mutationResolver) ScenesUpdate(ctx context.Context, input []*models.Sce
 		return nil, err
 	}
 
+	manager.WriteSceneSidecars(ctx, r.txnManager, scene.GetIDs(ret))
+
 	// execute post hooks outside of txn
 	var newRet []*models.Scene
 	for i, scene := range ret {
@@ -87,6 +91,22 @@ func (r *mutationResolver) ScenesUpdate(ctx context.Context, input []*models.Sce
 	return newRet, nil
 }
 
+// validateSceneGenerateOverrides returns an error if the generate overrides
+// of the input are invalid. Overrides that are not set are not validated.
+func validateSceneGenerateOverrides(input models.SceneUpdateInput) error {
+	if input.PreviewSegments != nil && *input.PreviewSegments < 1 {
+		return errors.New("preview_segments must be at least 1")
+	}
+	if input.PreviewSegmentDuration != nil && *input.PreviewSegmentDuration <= 0 {
+		return errors.New("preview_segment_duration must be greater than 0")
+	}
+	if input.SpriteCount != nil && *input.SpriteCount < 1 {
+		return errors.New("sprite_count must be at least 1")
+	}
+
+	return nil
+}
+
 func (r *mutationResolver) sceneUpdate(ctx context.Context, input models.SceneUpdateInput, translator changesetTranslator, repo models.Repository) (*models.Scene, error) {
 	// Populate scene from the input
 	sceneID, err := strconv.Atoi(input.ID)
@@ -94,6 +114,10 @@ func (r *mutationResolver) sceneUpdate(ctx context.Context, input models.SceneUp
 		return nil, err
 	}
 
+	if err := validateSceneGenerateOverrides(input); err != nil {
+		return nil, err
+	}
+
 	var coverImageData []byte
 
 	updatedTime := time.Now()
@@ -107,6 +131,13 @@ func (r *mutationResolver) sceneUpdate(ctx context.Context, input models.SceneUp
 	updatedScene.URL = translator.nullString(input.URL, "url")
 	updatedScene.Date = translator.sqliteDate(input.Date, "date")
 	updatedScene.Rating = translator.nullInt64(input.Rating, "rating")
+	updatedScene.ScreenshotAt = translator.nullFloat64(input.ScreenshotAt, "screenshot_at")
+	updatedScene.ResumeTime = translator.nullFloat64(input.ResumeTime, "resume_time")
+	updatedScene.PreviewSegments = translator.nullInt64(input.PreviewSegments, "preview_segments")
+	updatedScene.PreviewSegmentDuration = translator.nullFloat64(input.PreviewSegmentDuration, "preview_segment_duration")
+	updatedScene.PreviewExcludeStart = translator.nullString(input.PreviewExcludeStart, "preview_exclude_start")
+	updatedScene.PreviewExcludeEnd = translator.nullString(input.PreviewExcludeEnd, "preview_exclude_end")
+	updatedScene.SpriteCount = translator.nullInt64(input.SpriteCount, "sprite_count")
 	updatedScene.StudioID = translator.nullInt64FromString(input.StudioID, "studio_id")
 	updatedScene.Organized = input.Organized
 
@@ -325,6 +356,8 @@ func (r *mutationResolver) BulkSceneUpdate(ctx context.Context, input models.Bul
 		return nil, err
 	}
 
+	manager.WriteSceneSidecars(ctx, r.txnManager, scene.GetIDs(ret))
+
 	// execute post hooks outside of txn
 	var newRet []*models.Scene
 	for _, scene := range ret {
@@ -468,6 +501,7 @@ func (r *mutationResolver) SceneDestroy(ctx context.Context, input models.SceneD
 
 	deleteGenerated := utils.IsTrue(input.DeleteGenerated)
 	deleteFile := utils.IsTrue(input.DeleteFile)
+	softDelete := manager.GetInstance().Config.IsSoftDeleteScenes()
 
 	if err := r.withTxn(ctx, func(repo models.Repository) error {
 		qb := repo.Scene()
@@ -484,6 +518,11 @@ func (r *mutationResolver) SceneDestroy(ctx context.Context, input models.SceneD
 		// kill any running encoders
 		manager.KillRunningStreams(s, fileNamingAlgo)
 
+		// soft-deleted scenes keep their files until they are purged
+		if softDelete {
+			return qb.SoftDestroy(s.ID, deleteFile, deleteGenerated)
+		}
+
 		return scene.Destroy(s, repo, fileDeleter, deleteGenerated, deleteFile)
 	}); err != nil {
 		fileDeleter.Rollback()
@@ -492,6 +531,7 @@ func (r *mutationResolver) SceneDestroy(ctx context.Context, input models.SceneD
 
 	// perform the post-commit actions
 	fileDeleter.Commit()
+	auditDelete(ctx, "scene", []string{input.ID}, utils.IsTrue(input.DeleteFile))
 
 	// call post hook after performing the other actions
 	r.hookExecutor.ExecutePostHooks(ctx, s.ID, plugin.SceneDestroyPost, plugin.SceneDestroyInput{
@@ -516,6 +556,7 @@ func (r *mutationResolver) ScenesDestroy(ctx context.Context, input models.Scene
 
 	deleteGenerated := utils.IsTrue(input.DeleteGenerated)
 	deleteFile := utils.IsTrue(input.DeleteFile)
+	softDelete := manager.GetInstance().Config.IsSoftDeleteScenes()
 
 	if err := r.withTxn(ctx, func(repo models.Repository) error {
 		qb := repo.Scene()
@@ -534,6 +575,13 @@ func (r *mutationResolver) ScenesDestroy(ctx context.Context, input models.Scene
 			// kill any running encoders
 			manager.KillRunningStreams(s, fileNamingAlgo)
 
+			if softDelete {
+				if err := qb.SoftDestroy(s.ID, deleteFile, deleteGenerated); err != nil {
+					return err
+				}
+				continue
+			}
+
 			if err := scene.Destroy(s, repo, fileDeleter, deleteGenerated, deleteFile); err != nil {
 				return err
 			}
@@ -547,6 +595,7 @@ func (r *mutationResolver) ScenesDestroy(ctx context.Context, input models.Scene
 
 	// perform the post-commit actions
 	fileDeleter.Commit()
+	auditDelete(ctx, "scene", input.Ids, utils.IsTrue(input.DeleteFile))
 
 	for _, scene := range scenes {
 		// call post hook after performing the other actions
@@ -561,6 +610,39 @@ func (r *mutationResolver) ScenesDestroy(ctx context.Context, input models.Scene
 	return true, nil
 }
 
+func (r *mutationResolver) ScenesRestore(ctx context.Context, ids []string) (bool, error) {
+	sceneIDs, err := utils.StringSliceToIntSlice(ids)
+	if err != nil {
+		return false, err
+	}
+
+	if err := r.withTxn(ctx, func(repo models.Repository) error {
+		qb := repo.Scene()
+		for _, id := range sceneIDs {
+			if err := qb.Restore(id); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *mutationResolver) ScenesOrganize(ctx context.Context, input models.ScenesOrganizeInput) (string, error) {
+	t, err := manager.CreateOrganizeJob(input)
+	if err != nil {
+		return "", err
+	}
+
+	jobID := manager.GetInstance().JobManager.Add(ctx, "Organizing scenes...", t)
+
+	return strconv.Itoa(jobID), nil
+}
+
 func (r *mutationResolver) getSceneMarker(ctx context.Context, id int) (ret *models.SceneMarker, err error) {
 	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
 		ret, err = repo.SceneMarker().Find(id)
@@ -691,6 +773,7 @@ func (r *mutationResolver) SceneMarkerDestroy(ctx context.Context, id string) (b
 
 	// perform the post-commit actions
 	fileDeleter.Commit()
+	auditDelete(ctx, "scene marker", []string{id}, false)
 
 	r.hookExecutor.ExecutePostHooks(ctx, markerID, plugin.SceneMarkerDestroyPost, id, nil)
 
@@ -812,6 +895,51 @@ func (r *mutationResolver) SceneResetO(ctx context.Context, id string) (ret int,
 	return ret, nil
 }
 
+func (r *mutationResolver) SceneAddPlay(ctx context.Context, id string) (ret int, err error) {
+	sceneID, err := strconv.Atoi(id)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := r.withTxn(ctx, func(repo models.Repository) error {
+		qb := repo.Scene()
+
+		ret, err = qb.IncrementPlayCount(sceneID, time.Now())
+		return err
+	}); err != nil {
+		return 0, err
+	}
+
+	return ret, nil
+}
+
+func (r *mutationResolver) BulkSceneResetCounters(ctx context.Context, input models.BulkSceneResetCountersInput) (ret int, err error) {
+	sceneIDs, err := utils.StringSliceToIntSlice(input.Ids)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := r.withTxn(ctx, func(repo models.Repository) error {
+		ret, err = scene.BulkResetCounters(repo.Scene(), sceneIDs, input.SceneFilter, input.Counters)
+		return err
+	}); err != nil {
+		return 0, err
+	}
+
+	return ret, nil
+}
+
+func (r *mutationResolver) ScenesReconcileStashIDs(ctx context.Context) (ret int, err error) {
+	if err := r.withTxn(ctx, func(repo models.Repository) error {
+		ret, err = scene.ReconcileStashIDs(repo.Scene())
+		return err
+	}); err != nil {
+		return 0, err
+	}
+
+	return ret, nil
+}
+
 func (r *mutationResolver) SceneGenerateScreenshot(ctx context.Context, id string, at *float64) (string, error) {
 	if at != nil {
 		manager.GetInstance().GenerateScreenshot(ctx, id, *at)
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestValidateSceneGenerateOverrides(t *testing.T) {
+	intPtr := func(v int) *int { return &v }
+	floatPtr := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name    string
+		input   models.SceneUpdateInput
+		wantErr bool
+	}{
+		{"none", models.SceneUpdateInput{}, false},
+		{"valid", models.SceneUpdateInput{PreviewSegments: intPtr(12), PreviewSegmentDuration: floatPtr(0.75), SpriteCount: intPtr(81)}, false},
+		{"zero segments", models.SceneUpdateInput{PreviewSegments: intPtr(0)}, true},
+		{"negative segments", models.SceneUpdateInput{PreviewSegments: intPtr(-1)}, true},
+		{"zero duration", models.SceneUpdateInput{PreviewSegmentDuration: floatPtr(0)}, true},
+		{"negative duration", models.SceneUpdateInput{PreviewSegmentDuration: floatPtr(-0.5)}, true},
+		{"zero sprites", models.SceneUpdateInput{SpriteCount: intPtr(0)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSceneGenerateOverrides(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateSceneGenerateOverrides() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -213,6 +213,8 @@ func (r *mutationResolver) StudioDestroy(ctx context.Context, input models.Studi
 		return false, err
 	}
 
+	auditDelete(ctx, "studio", []string{input.ID}, false)
+
 	r.hookExecutor.ExecutePostHooks(ctx, id, plugin.StudioDestroyPost, input, nil)
 
 	return true, nil
@@ -237,9 +239,45 @@ func (r *mutationResolver) StudiosDestroy(ctx context.Context, studioIDs []strin
 		return false, err
 	}
 
+	auditDelete(ctx, "studio", studioIDs, false)
+
 	for _, id := range ids {
 		r.hookExecutor.ExecutePostHooks(ctx, id, plugin.StudioDestroyPost, studioIDs, nil)
 	}
 
 	return true, nil
 }
+
+func (r *mutationResolver) StudiosMerge(ctx context.Context, input models.StudiosMergeInput) (*models.Studio, error) {
+	source, err := utils.StringSliceToIntSlice(input.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	destination, err := strconv.Atoi(input.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(source) == 0 {
+		return nil, nil
+	}
+
+	var ret *models.Studio
+	if err := r.withTxn(ctx, func(repo models.Repository) error {
+		qb := repo.Studio()
+
+		if err := qb.Merge(source, destination); err != nil {
+			return err
+		}
+
+		var err error
+		ret, err = qb.Find(destination)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	r.hookExecutor.ExecutePostHooks(ctx, ret.ID, plugin.StudioMergePost, input, nil)
+	return ret, nil
+}
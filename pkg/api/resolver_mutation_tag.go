@@ -32,6 +32,9 @@ func (r *mutationResolver) TagCreate(ctx context.Context, input models.TagCreate
 		CreatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
 		UpdatedAt: models.SQLiteTimestamp{Timestamp: currentTime},
 	}
+	if input.GenerateAliases != nil {
+		newTag.GenerateAliases = *input.GenerateAliases
+	}
 
 	var imageData []byte
 	var err error
@@ -93,6 +96,12 @@ func (r *mutationResolver) TagCreate(ctx context.Context, input models.TagCreate
 			}
 		}
 
+		if t.GenerateAliases {
+			if err := tag.RegenerateAliases(qb, t); err != nil {
+				return err
+			}
+		}
+
 		if len(parentIDs) > 0 {
 			if err := qb.UpdateParentTags(t.ID, parentIDs); err != nil {
 				return err
@@ -189,6 +198,8 @@ func (r *mutationResolver) TagUpdate(ctx context.Context, input models.TagUpdate
 			updatedTag.Name = input.Name
 		}
 
+		updatedTag.GenerateAliases = input.GenerateAliases
+
 		t, err = qb.Update(updatedTag)
 		if err != nil {
 			return err
@@ -216,6 +227,12 @@ func (r *mutationResolver) TagUpdate(ctx context.Context, input models.TagUpdate
 			}
 		}
 
+		// regenerate the generated aliases, since the name or aliases may
+		// have changed
+		if err := tag.RegenerateAliases(qb, t); err != nil {
+			return err
+		}
+
 		if parentIDs != nil {
 			if err := qb.UpdateParentTags(tagID, parentIDs); err != nil {
 				return err
@@ -258,6 +275,8 @@ func (r *mutationResolver) TagDestroy(ctx context.Context, input models.TagDestr
 		return false, err
 	}
 
+	auditDelete(ctx, "tag", []string{input.ID}, false)
+
 	r.hookExecutor.ExecutePostHooks(ctx, tagID, plugin.TagDestroyPost, input, nil)
 
 	return true, nil
@@ -282,6 +301,8 @@ func (r *mutationResolver) TagsDestroy(ctx context.Context, tagIDs []string) (bo
 		return false, err
 	}
 
+	auditDelete(ctx, "tag", tagIDs, false)
+
 	for _, id := range ids {
 		r.hookExecutor.ExecutePostHooks(ctx, id, plugin.TagDestroyPost, tagIDs, nil)
 	}
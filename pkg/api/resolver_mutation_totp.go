@@ -0,0 +1,60 @@
+package api
+
+import (
+	"context"
+	"errors"
+
+	"github.com/stashapp/stash/pkg/audit"
+	"github.com/stashapp/stash/pkg/manager"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/session"
+)
+
+func (r *mutationResolver) GenerateTOTPSecret(ctx context.Context) (*models.TOTPSecret, error) {
+	secret, err := session.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TOTPSecret{
+		Secret: secret,
+		URI:    session.TOTPURI(secret, config.GetInstance().GetUsername()),
+	}, nil
+}
+
+func (r *mutationResolver) EnableTotp(ctx context.Context, input models.EnableTOTPInput) ([]string, error) {
+	c := config.GetInstance()
+	if !c.HasCredentials() {
+		return nil, errors.New("a username and password must be set before enabling two-factor authentication")
+	}
+
+	codes, err := session.EnableTOTP(c, input.Secret, input.Code)
+	if errors.Is(err, session.ErrInvalidCredentials) {
+		return nil, errors.New("invalid authentication code")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Write(); err != nil {
+		return nil, err
+	}
+
+	manager.GetInstance().AuditEvent(ctx, audit.EventConfigChange, "two-factor authentication enabled", nil)
+
+	return codes, nil
+}
+
+func (r *mutationResolver) DisableTotp(ctx context.Context) (bool, error) {
+	c := config.GetInstance()
+	session.DisableTOTP(c)
+
+	if err := c.Write(); err != nil {
+		return false, err
+	}
+
+	manager.GetInstance().AuditEvent(ctx, audit.EventConfigChange, "two-factor authentication disabled", nil)
+
+	return true, nil
+}
@@ -0,0 +1,17 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/manager"
+)
+
+func (r *mutationResolver) StopTranscode(ctx context.Context, id string) (bool, error) {
+	idInt, err := strconv.Atoi(id)
+	if err != nil {
+		return false, err
+	}
+
+	return manager.GetInstance().Transcodes.Cancel(idInt), nil
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"sort"
+
+	"github.com/stashapp/stash/pkg/audit"
+	"github.com/stashapp/stash/pkg/manager"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *queryResolver) FindAuditEntries(ctx context.Context, types []models.AuditEventType, filter *models.FindFilterType) (*models.FindAuditEntriesResultType, error) {
+	if filter == nil {
+		filter = &models.FindFilterType{}
+	}
+	filter = filter.CapPageSize()
+
+	q := audit.Query{
+		Page:    filter.GetPage(),
+		PerPage: filter.GetPageSize(),
+	}
+	if filter.IsGetAll() {
+		q.PerPage = -1
+	}
+	if filter.Q != nil {
+		q.Text = *filter.Q
+	}
+	for _, t := range types {
+		q.Types = append(q.Types, audit.EventType(t))
+	}
+
+	entries, count, err := manager.GetInstance().Audit.Query(q)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.FindAuditEntriesResultType{
+		Count:   count,
+		Entries: make([]*models.AuditEntry, len(entries)),
+	}
+	for i, e := range entries {
+		ret.Entries[i] = auditEntryToGraphQL(e)
+	}
+
+	return ret, nil
+}
+
+func auditEntryToGraphQL(e audit.Entry) *models.AuditEntry {
+	ret := &models.AuditEntry{
+		Time:    e.Time,
+		Type:    models.AuditEventType(e.Type),
+		Message: e.Message,
+		Data:    []*models.AuditData{},
+	}
+
+	if e.User != "" {
+		user := e.User
+		ret.User = &user
+	}
+	if e.RemoteAddr != "" {
+		remoteAddr := e.RemoteAddr
+		ret.RemoteAddr = &remoteAddr
+	}
+
+	keys := make([]string, 0, len(e.Data))
+	for k := range e.Data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		ret.Data = append(ret.Data, &models.AuditData{
+			Key:   k,
+			Value: e.Data[k],
+		})
+	}
+
+	return ret
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
@@ -12,6 +13,9 @@ import (
 	"golang.org/x/text/collate"
 )
 
+// webdavPasswordMask is returned in place of a set WebDAV password.
+const webdavPasswordMask = "********"
+
 func (r *queryResolver) Configuration(ctx context.Context) (*models.ConfigResult, error) {
 	return makeConfigResult(), nil
 }
@@ -62,9 +66,20 @@ func makeConfigGeneralResult() *models.ConfigGeneralResult {
 	scraperUserAgent := config.GetScraperUserAgent()
 	scraperCDPPath := config.GetScraperCDPPath()
 
+	remoteStashEndpoint := config.GetRemoteStashEndpoint()
+	remoteStashAPIKey := config.GetRemoteStashAPIKey()
+
+	webdavUsername := config.GetWebDAVUsername()
+	// the WebDAV password is stored in clear, so only return a placeholder
+	webdavPassword := ""
+	if config.GetWebDAVPassword() != "" {
+		webdavPassword = webdavPasswordMask
+	}
+
 	return &models.ConfigGeneralResult{
 		Stashes:                      config.GetStashPaths(),
 		DatabasePath:                 config.GetDatabasePath(),
+		VerifyMigration:              config.GetVerifyMigration(),
 		GeneratedPath:                config.GetGeneratedPath(),
 		MetadataPath:                 config.GetMetadataPath(),
 		ConfigFilePath:               config.GetConfigFile(),
@@ -73,34 +88,77 @@ func makeConfigGeneralResult() *models.ConfigGeneralResult {
 		CalculateMd5:                 config.IsCalculateMD5(),
 		VideoFileNamingAlgorithm:     config.GetVideoFileNamingAlgorithm(),
 		ParallelTasks:                config.GetParallelTasks(),
+		AutoTagMatchWorkers:          config.GetAutoTagMatchWorkers(),
+		AutoTagWriteBatchSize:        config.GetAutoTagWriteBatchSize(),
+		AutoTagApplyTagAncestors:     config.GetAutoTagApplyTagAncestors(),
+		AutoTagFuzzyDistance:         config.GetAutoTagFuzzyDistance(),
+		FfprobeTimeout:               int(config.GetFFProbeTimeout().Seconds()),
+		ResourceLimitCPU:             config.GetResourceLimitCPU(),
+		ResourceLimitIo:              config.GetResourceLimitIO(),
+		ResourceLimitMemory:          config.GetResourceLimitMemory(),
+		WatchLibrary:                 config.IsWatchLibrary(),
 		PreviewAudio:                 config.GetPreviewAudio(),
 		PreviewSegments:              config.GetPreviewSegments(),
 		PreviewSegmentDuration:       config.GetPreviewSegmentDuration(),
+		PreviewWidth:                 config.GetPreviewWidth(),
+		SpriteCount:                  config.GetSpriteCount(),
+		SpriteColumns:                config.GetSpriteColumns(),
+		SpriteWidth:                  config.GetSpriteWidth(),
+		ContactSheetColumns:          config.GetContactSheetColumns(),
+		ContactSheetRows:             config.GetContactSheetRows(),
+		ContactSheetWidth:            config.GetContactSheetWidth(),
+		ContactSheetHeader:           config.GetContactSheetHeader(),
 		PreviewExcludeStart:          config.GetPreviewExcludeStart(),
 		PreviewExcludeEnd:            config.GetPreviewExcludeEnd(),
 		PreviewPreset:                config.GetPreviewPreset(),
 		MaxTranscodeSize:             &maxTranscodeSize,
 		MaxStreamingTranscodeSize:    &maxStreamingTranscodeSize,
+		TranscodeCacheSize:           config.GetTranscodeCacheSize(),
+		TranscodeCleanupSchedule:     config.GetTranscodeCleanupSchedule(),
 		WriteImageThumbnails:         config.IsWriteImageThumbnails(),
+		ImageThumbnailMaxSize:        config.GetImageThumbnailMaxSize(),
+		ImageThumbnailCacheSize:      config.GetImageThumbnailCacheSize(),
+		GeneratedCacheMaxAge:         config.GetGeneratedCacheMaxAge(),
+		ShutdownGracePeriod:          config.GetShutdownGracePeriod(),
+		GenerateMaxRetries:           config.GetGenerateMaxRetries(),
+		GenerateRetryBackoff:         config.GetGenerateRetryBackoff(),
+		GalleryCoverStrategy:         config.GetGalleryCoverStrategy(),
+		MissingFilePolicy:            config.GetMissingFilePolicy(),
+		WriteSceneSidecars:           config.GetWriteSceneSidecars(),
+		ReadSceneSidecars:            config.GetReadSceneSidecars(),
+		SceneSidecarFormat:           config.GetSceneSidecarFormat(),
+		SceneSidecarPrecedence:       config.GetSceneSidecarPrecedence(),
+		OrganizeTemplate:             config.GetOrganizeTemplate(),
 		APIKey:                       config.GetAPIKey(),
+		TotpEnabled:                  config.IsTOTPEnabled(),
 		Username:                     config.GetUsername(),
 		Password:                     config.GetPasswordHash(),
 		MaxSessionAge:                config.GetMaxSessionAge(),
+		LoginDelay:                   int(config.GetLoginDelay() / time.Millisecond),
+		LoginDelaySuccess:            config.GetLoginDelaySuccess(),
 		LogFile:                      &logFile,
 		LogOut:                       config.GetLogOut(),
 		LogLevel:                     config.GetLogLevel(),
 		LogAccess:                    config.GetLogAccess(),
+		Timezone:                     config.GetTimezone(),
 		VideoExtensions:              config.GetVideoExtensions(),
 		ImageExtensions:              config.GetImageExtensions(),
 		GalleryExtensions:            config.GetGalleryExtensions(),
 		CreateGalleriesFromFolders:   config.GetCreateGalleriesFromFolders(),
+		ScanHiddenFiles:              config.IsScanHiddenFiles(),
 		Excludes:                     config.GetExcludes(),
 		ImageExcludes:                config.GetImageExcludes(),
+		JunkFilePatterns:             config.GetJunkFilePatterns(),
 		CustomPerformerImageLocation: &customPerformerImageLocation,
 		ScraperUserAgent:             &scraperUserAgent,
 		ScraperCertCheck:             config.GetScraperCertCheck(),
 		ScraperCDPPath:               &scraperCDPPath,
 		StashBoxes:                   config.GetStashBoxes(),
+		RemoteStashEndpoint:          &remoteStashEndpoint,
+		RemoteStashAPIKey:            &remoteStashAPIKey,
+		RemoteStashMergePolicy:       config.GetRemoteStashMergePolicy(),
+		WebdavUsername:               &webdavUsername,
+		WebdavPassword:               &webdavPassword,
 	}
 }
 
@@ -157,10 +215,13 @@ func makeConfigDLNAResult() *models.ConfigDLNAResult {
 	config := config.GetInstance()
 
 	return &models.ConfigDLNAResult{
-		ServerName:     config.GetDLNAServerName(),
-		Enabled:        config.GetDLNADefaultEnabled(),
-		WhitelistedIPs: config.GetDLNADefaultIPWhitelist(),
-		Interfaces:     config.GetDLNAInterfaces(),
+		ServerName:             config.GetDLNAServerName(),
+		Enabled:                config.GetDLNADefaultEnabled(),
+		WhitelistedIPs:         config.GetDLNADefaultIPWhitelist(),
+		Interfaces:             config.GetDLNAInterfaces(),
+		TranscodeMode:          config.GetDLNATranscodeMode(),
+		MaxTranscodes:          config.GetDLNAMaxTranscodes(),
+		RejectExcessTranscodes: config.GetDLNARejectExcessTranscodes(),
 	}
 }
 
@@ -24,6 +24,7 @@ func (r *queryResolver) FindGallery(ctx context.Context, id string) (ret *models
 }
 
 func (r *queryResolver) FindGalleries(ctx context.Context, galleryFilter *models.GalleryFilterType, filter *models.FindFilterType) (ret *models.FindGalleriesResultType, err error) {
+	filter = filter.CapPageSize()
 	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
 		galleries, total, err := repo.Gallery().Query(galleryFilter, filter)
 		if err != nil {
@@ -39,6 +39,7 @@ func (r *queryResolver) FindImage(ctx context.Context, id *string, checksum *str
 }
 
 func (r *queryResolver) FindImages(ctx context.Context, imageFilter *models.ImageFilterType, imageIds []int, filter *models.FindFilterType) (ret *models.FindImagesResultType, err error) {
+	filter = filter.CapPageSize()
 	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
 		qb := repo.Image()
 
@@ -24,6 +24,7 @@ func (r *queryResolver) FindMovie(ctx context.Context, id string) (ret *models.M
 }
 
 func (r *queryResolver) FindMovies(ctx context.Context, movieFilter *models.MovieFilterType, filter *models.FindFilterType) (ret *models.FindMoviesResultType, err error) {
+	filter = filter.CapPageSize()
 	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
 		movies, total, err := repo.Movie().Query(movieFilter, filter)
 		if err != nil {
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
 )
 
 func (r *queryResolver) FindPerformer(ctx context.Context, id string) (ret *models.Performer, err error) {
@@ -24,6 +25,7 @@ func (r *queryResolver) FindPerformer(ctx context.Context, id string) (ret *mode
 }
 
 func (r *queryResolver) FindPerformers(ctx context.Context, performerFilter *models.PerformerFilterType, filter *models.FindFilterType) (ret *models.FindPerformersResultType, err error) {
+	filter = filter.CapPageSize()
 	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
 		performers, total, err := repo.Performer().Query(performerFilter, filter)
 		if err != nil {
@@ -52,3 +54,24 @@ func (r *queryResolver) AllPerformers(ctx context.Context) (ret []*models.Perfor
 
 	return ret, nil
 }
+
+func (r *queryResolver) PerformersMergeDryRun(ctx context.Context, input models.PerformersMergeInput) (ret *models.MergeCounts, err error) {
+	source, err := utils.StringSliceToIntSlice(input.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	destination, err := strconv.Atoi(input.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
+		ret, err = repo.Performer().MergeCounts(source, destination)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 
 	"github.com/99designs/gqlgen/graphql"
@@ -65,6 +66,7 @@ func (r *queryResolver) FindSceneByHash(ctx context.Context, input models.SceneH
 }
 
 func (r *queryResolver) FindScenes(ctx context.Context, sceneFilter *models.SceneFilterType, sceneIDs []int, filter *models.FindFilterType) (ret *models.FindScenesResultType, err error) {
+	filter = filter.CapPageSize()
 	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
 		var scenes []*models.Scene
 		var err error
@@ -116,7 +118,12 @@ func (r *queryResolver) FindScenes(ctx context.Context, sceneFilter *models.Scen
 	return ret, nil
 }
 
+func (r *queryResolver) ScenesOrganizePreview(ctx context.Context, input models.ScenesOrganizeInput) ([]*models.SceneOrganizeResult, error) {
+	return manager.OrganizePreview(ctx, r.txnManager, input)
+}
+
 func (r *queryResolver) FindScenesByPathRegex(ctx context.Context, filter *models.FindFilterType) (ret *models.FindScenesResultType, err error) {
+	filter = filter.CapPageSize()
 	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
 
 		sceneFilter := &models.SceneFilterType{}
@@ -172,6 +179,7 @@ func (r *queryResolver) FindScenesByPathRegex(ctx context.Context, filter *model
 }
 
 func (r *queryResolver) ParseSceneFilenames(ctx context.Context, filter *models.FindFilterType, config models.SceneParserInput) (ret *models.SceneParserResultType, err error) {
+	filter = filter.CapPageSize()
 	parser := manager.NewSceneFilenameParser(filter, config)
 
 	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
@@ -208,3 +216,23 @@ func (r *queryResolver) FindDuplicateScenes(ctx context.Context, distance *int)
 
 	return ret, nil
 }
+
+func (r *queryResolver) FindSimilarScenes(ctx context.Context, phash string, distance *int) (ret []*models.Scene, err error) {
+	hash, err := utils.StringToPhash(phash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid phash %q: %w", phash, err)
+	}
+
+	dist := 0
+	if distance != nil {
+		dist = *distance
+	}
+	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
+		ret, err = repo.Scene().FindSimilar(hash, dist)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
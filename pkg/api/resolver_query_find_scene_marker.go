@@ -7,6 +7,7 @@ import (
 )
 
 func (r *queryResolver) FindSceneMarkers(ctx context.Context, sceneMarkerFilter *models.SceneMarkerFilterType, filter *models.FindFilterType) (ret *models.FindSceneMarkersResultType, err error) {
+	filter = filter.CapPageSize()
 	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
 		sceneMarkers, total, err := repo.SceneMarker().Query(sceneMarkerFilter, filter)
 		if err != nil {
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
 )
 
 func (r *queryResolver) FindStudio(ctx context.Context, id string) (ret *models.Studio, err error) {
@@ -25,6 +26,7 @@ func (r *queryResolver) FindStudio(ctx context.Context, id string) (ret *models.
 }
 
 func (r *queryResolver) FindStudios(ctx context.Context, studioFilter *models.StudioFilterType, filter *models.FindFilterType) (ret *models.FindStudiosResultType, err error) {
+	filter = filter.CapPageSize()
 	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
 		studios, total, err := repo.Studio().Query(studioFilter, filter)
 		if err != nil {
@@ -54,3 +56,24 @@ func (r *queryResolver) AllStudios(ctx context.Context) (ret []*models.Studio, e
 
 	return ret, nil
 }
+
+func (r *queryResolver) StudiosMergeDryRun(ctx context.Context, input models.StudiosMergeInput) (ret *models.MergeCounts, err error) {
+	source, err := utils.StringSliceToIntSlice(input.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	destination, err := strconv.Atoi(input.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
+		ret, err = repo.Studio().MergeCounts(source, destination)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
 )
 
 func (r *queryResolver) FindTag(ctx context.Context, id string) (ret *models.Tag, err error) {
@@ -24,6 +25,7 @@ func (r *queryResolver) FindTag(ctx context.Context, id string) (ret *models.Tag
 }
 
 func (r *queryResolver) FindTags(ctx context.Context, tagFilter *models.TagFilterType, filter *models.FindFilterType) (ret *models.FindTagsResultType, err error) {
+	filter = filter.CapPageSize()
 	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
 		tags, total, err := repo.Tag().Query(tagFilter, filter)
 		if err != nil {
@@ -53,3 +55,24 @@ func (r *queryResolver) AllTags(ctx context.Context) (ret []*models.Tag, err err
 
 	return ret, nil
 }
+
+func (r *queryResolver) TagsMergeDryRun(ctx context.Context, input models.TagsMergeInput) (ret *models.MergeCounts, err error) {
+	source, err := utils.StringSliceToIntSlice(input.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	destination, err := strconv.Atoi(input.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
+		ret, err = repo.Tag().MergeCounts(source, destination)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
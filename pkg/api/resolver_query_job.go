@@ -42,11 +42,23 @@ func jobToJobModel(j job.Job) *models.Job {
 		StartTime:   j.StartTime,
 		EndTime:     j.EndTime,
 		AddTime:     j.AddTime,
+		Logs:        logEntriesFromLogItems(j.Logs),
 	}
 
 	if j.Progress != -1 {
 		ret.Progress = &j.Progress
 	}
 
+	if j.Error != "" {
+		ret.Error = &j.Error
+	}
+
+	switch result := j.Result.(type) {
+	case *models.ScanChanges:
+		ret.ScanChanges = result
+	case []*models.SceneOrganizeResult:
+		ret.OrganizeResults = result
+	}
+
 	return ret
 }
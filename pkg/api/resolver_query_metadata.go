@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 
+	"github.com/stashapp/stash/pkg/autotag"
 	"github.com/stashapp/stash/pkg/manager"
 	"github.com/stashapp/stash/pkg/models"
 )
@@ -10,3 +11,41 @@ import (
 func (r *queryResolver) SystemStatus(ctx context.Context) (*models.SystemStatus, error) {
 	return manager.GetInstance().GetSystemStatus(), nil
 }
+
+func (r *queryResolver) SelfTest(ctx context.Context) ([]*models.SelfTestResult, error) {
+	return manager.GetInstance().SelfTest(ctx), nil
+}
+
+func (r *queryResolver) QueryStats(ctx context.Context) (*models.QueryStats, error) {
+	stats := manager.GetInstance().QueryStats
+	return &models.QueryStats{
+		Enabled: stats.Enabled(),
+		Queries: stats.Stats(),
+	}, nil
+}
+
+func (r *queryResolver) MetadataGenerateEstimate(ctx context.Context, input models.GenerateMetadataInput) (*models.GenerateEstimate, error) {
+	return manager.GetInstance().EstimateGenerate(ctx, input)
+}
+
+func (r *queryResolver) GenerateFailures(ctx context.Context) (ret []*models.GenerateFailure, err error) {
+	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
+		ret, err = repo.GenerateFailure().All()
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return ret, err
+}
+
+func (r *queryResolver) AutoTagUnmatched(ctx context.Context, input models.AutoTagUnmatchedInput, filter *models.FindFilterType) (ret *models.AutoTagUnmatchedResult, err error) {
+	filter = filter.CapPageSize()
+	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
+		ret, err = autotag.UnmatchedScenes(repo.Scene(), input.Type, input.Paths, filter)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,17 @@
+package api
+
+import (
+	"context"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *queryResolver) QuarantinedFiles(ctx context.Context) (ret []*models.QuarantinedFile, err error) {
+	if err := r.withReadTxn(ctx, func(repo models.ReaderRepository) error {
+		ret, err = repo.Quarantine().All()
+		return err
+	}); err != nil {
+		return nil, err
+	}
+	return ret, err
+}
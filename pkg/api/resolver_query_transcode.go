@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/manager"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func (r *queryResolver) RunningTranscodes(ctx context.Context) ([]*models.RunningTranscode, error) {
+	transcodes := manager.GetInstance().Transcodes.List()
+
+	ret := make([]*models.RunningTranscode, len(transcodes))
+	for i, t := range transcodes {
+		ret[i] = runningTranscodeToModel(t)
+	}
+
+	return ret, nil
+}
+
+func runningTranscodeToModel(t manager.RunningTranscode) *models.RunningTranscode {
+	ret := &models.RunningTranscode{
+		ID:        strconv.Itoa(t.ID),
+		SceneID:   strconv.Itoa(t.SceneID),
+		Codec:     t.Codec,
+		MimeType:  t.MimeType,
+		StartTime: t.StartTime,
+	}
+
+	if t.Resolution != "" {
+		resolution := t.Resolution
+		ret.Resolution = &resolution
+	}
+	if t.Client != "" {
+		client := t.Client
+		ret.Client = &client
+	}
+
+	return ret
+}
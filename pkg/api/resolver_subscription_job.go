@@ -15,22 +15,27 @@ func makeJobStatusUpdate(t models.JobStatusUpdateType, j job.Job) *models.JobSta
 	}
 }
 
+var jobEventUpdateTypes = map[manager.JobEventType]models.JobStatusUpdateType{
+	manager.JobAdded:     models.JobStatusUpdateTypeAdd,
+	manager.JobProgress:  models.JobStatusUpdateTypeUpdate,
+	manager.JobCompleted: models.JobStatusUpdateTypeRemove,
+}
+
 func (r *subscriptionResolver) JobsSubscribe(ctx context.Context) (<-chan *models.JobStatusUpdate, error) {
 	msg := make(chan *models.JobStatusUpdate, 100)
 
-	subscription := manager.GetInstance().JobManager.Subscribe(ctx)
+	events := manager.GetInstance().Subscribe(ctx, manager.TopicJob)
 
 	go func() {
-		for {
+		defer close(msg)
+
+		// the events channel is closed when the client disconnects
+		for e := range events {
+			je := e.Payload.(manager.JobEvent)
+
 			select {
-			case j := <-subscription.NewJob:
-				msg <- makeJobStatusUpdate(models.JobStatusUpdateTypeAdd, j)
-			case j := <-subscription.RemovedJob:
-				msg <- makeJobStatusUpdate(models.JobStatusUpdateTypeRemove, j)
-			case j := <-subscription.UpdatedJob:
-				msg <- makeJobStatusUpdate(models.JobStatusUpdateTypeUpdate, j)
+			case msg <- makeJobStatusUpdate(jobEventUpdateTypes[je.Type], je.Job):
 			case <-ctx.Done():
-				close(msg)
 				return
 			}
 		}
@@ -34,35 +34,58 @@ func (rs imageRoutes) Routes() chi.Router {
 
 func (rs imageRoutes) Thumbnail(w http.ResponseWriter, r *http.Request) {
 	img := r.Context().Value(imageKey).(*models.Image)
-	filepath := manager.GetInstance().Paths.Generated.GetThumbnailPath(img.Checksum, models.DefaultGthumbWidth)
+	mgr := manager.GetInstance()
+	maxSize := mgr.Config.GetImageThumbnailMaxSize()
 
 	w.Header().Add("Cache-Control", "max-age=604800000")
 
-	// if the thumbnail doesn't exist, encode on the fly
-	exists, _ := utils.FileExists(filepath)
-	if exists {
-		http.ServeFile(w, r, filepath)
-	} else {
-		encoder := image.NewThumbnailEncoder(manager.GetInstance().FFMPEG)
-		data, err := encoder.GetThumbnail(img, models.DefaultGthumbWidth)
-		if err != nil {
-			logger.Errorf("error generating thumbnail for image: %s", err.Error())
-
-			// backwards compatibility - fallback to original image instead
-			rs.Image(w, r)
+	// thumbnails of the default size may have been generated during scanning
+	filepath := mgr.Paths.Generated.GetThumbnailPath(img.Checksum, models.DefaultGthumbWidth)
+	if maxSize == models.DefaultGthumbWidth {
+		if exists, _ := utils.FileExists(filepath); exists {
+			http.ServeFile(w, r, filepath)
 			return
 		}
+	}
 
-		// write the generated thumbnail to disk if enabled
-		if manager.GetInstance().Config.IsWriteImageThumbnails() {
-			if err := utils.WriteFile(filepath, data); err != nil {
-				logger.Errorf("error writing thumbnail for image %s: %s", img.Path, err)
-			}
+	key := manager.NewThumbnailCacheKey(img, maxSize)
+	if cached := mgr.ThumbnailCache.Get(key); cached != "" {
+		http.ServeFile(w, r, cached)
+		return
+	}
+
+	// the thumbnail doesn't exist, encode on the fly once resources are free
+	release, err := mgr.Resources.Acquire(r.Context(), manager.ThumbnailResources)
+	if err != nil {
+		return
+	}
+
+	encoder := image.NewThumbnailEncoder(mgr.FFMPEG())
+	data, err := encoder.GetThumbnail(img, maxSize)
+	release()
+	if err != nil {
+		logger.Errorf("error generating thumbnail for image: %s", err.Error())
+
+		// backwards compatibility - fallback to original image instead
+		rs.Image(w, r)
+		return
+	}
+
+	// write the generated thumbnail to disk if enabled, otherwise cache it
+	switch {
+	case maxSize == models.DefaultGthumbWidth && mgr.Config.IsWriteImageThumbnails():
+		if err := utils.WriteFile(filepath, data); err != nil {
+			logger.Errorf("error writing thumbnail for image %s: %s", img.Path, err)
 		}
-		if n, err := w.Write(data); err != nil {
-			logger.Errorf("error writing thumbnail response. Wrote %v bytes: %v", n, err)
+	case mgr.ThumbnailCache.Enabled():
+		if err := mgr.ThumbnailCache.Put(key, data); err != nil {
+			logger.Warnf("error caching thumbnail for image %s: %v", img.Path, err)
 		}
 	}
+
+	if n, err := w.Write(data); err != nil {
+		logger.Errorf("error writing thumbnail response. Wrote %v bytes: %v", n, err)
+	}
 }
 
 func (rs imageRoutes) Image(w http.ResponseWriter, r *http.Request) {
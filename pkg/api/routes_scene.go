@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -32,6 +33,11 @@ func (rs sceneRoutes) Routes() chi.Router {
 		r.Get("/stream.m3u8", rs.StreamHLS)
 		r.Get("/stream.ts", rs.StreamTS)
 		r.Get("/stream.mp4", rs.StreamMp4)
+		r.Route("/stream/hls", func(r chi.Router) {
+			r.Get("/master.m3u8", rs.StreamHLSMaster)
+			r.Get("/{hlsSession}/{resolution}/index.m3u8", rs.StreamHLSVariant)
+			r.Get("/{hlsSession}/{resolution}/{segment:[0-9]+}.ts", rs.StreamHLSSegment)
+		})
 
 		r.Get("/screenshot", rs.Screenshot)
 		r.Get("/preview", rs.Preview)
@@ -39,6 +45,8 @@ func (rs sceneRoutes) Routes() chi.Router {
 		r.Get("/vtt/chapter", rs.ChapterVtt)
 		r.Get("/funscript", rs.Funscript)
 		r.Get("/interactive_heatmap", rs.InteractiveHeatmap)
+		r.Get("/contact_sheet", rs.ContactSheet)
+		r.Get("/subtitles", rs.Subtitles)
 
 		r.Get("/scene_marker/{sceneMarkerId}/stream", rs.SceneMarkerStream)
 		r.Get("/scene_marker/{sceneMarkerId}/preview", rs.SceneMarkerPreview)
@@ -58,7 +66,7 @@ func getSceneFileContainer(scene *models.Scene) ffmpeg.Container {
 		container = ffmpeg.Container(scene.Format.String)
 	} else { // container isn't in the DB
 		// shouldn't happen, fallback to ffprobe
-		ffprobe := manager.GetInstance().FFProbe
+		ffprobe := manager.GetInstance().FFProbe()
 		tmpVideoFile, err := ffprobe.NewVideoFile(scene.Path, false)
 		if err != nil {
 			logger.Errorf("[transcode] error reading video file: %v", err)
@@ -73,6 +81,7 @@ func getSceneFileContainer(scene *models.Scene) ffmpeg.Container {
 
 func (rs sceneRoutes) StreamDirect(w http.ResponseWriter, r *http.Request) {
 	scene := r.Context().Value(sceneKey).(*models.Scene)
+	rs.recordPlay(r, scene)
 
 	ss := manager.SceneServer{
 		TXNManager: rs.txnManager,
@@ -93,21 +102,24 @@ func (rs sceneRoutes) StreamMKV(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	rs.recordPlay(r, scene)
 	rs.streamTranscode(w, r, ffmpeg.CodecMKVAudio)
 }
 
 func (rs sceneRoutes) StreamWebM(w http.ResponseWriter, r *http.Request) {
+	rs.recordPlay(r, r.Context().Value(sceneKey).(*models.Scene))
 	rs.streamTranscode(w, r, ffmpeg.CodecVP9)
 }
 
 func (rs sceneRoutes) StreamMp4(w http.ResponseWriter, r *http.Request) {
+	rs.recordPlay(r, r.Context().Value(sceneKey).(*models.Scene))
 	rs.streamTranscode(w, r, ffmpeg.CodecH264)
 }
 
 func (rs sceneRoutes) StreamHLS(w http.ResponseWriter, r *http.Request) {
 	scene := r.Context().Value(sceneKey).(*models.Scene)
 
-	ffprobe := manager.GetInstance().FFProbe
+	ffprobe := manager.GetInstance().FFProbe()
 	videoFile, err := ffprobe.NewVideoFile(scene.Path, false)
 	if err != nil {
 		logger.Errorf("[stream] error reading video file: %v", err)
@@ -115,6 +127,7 @@ func (rs sceneRoutes) StreamHLS(w http.ResponseWriter, r *http.Request) {
 	}
 
 	logger.Debug("Returning HLS playlist")
+	rs.recordPlay(r, scene)
 
 	// getting the playlist manifest only
 	w.Header().Set("Content-Type", ffmpeg.MimeHLS)
@@ -140,53 +153,95 @@ func (rs sceneRoutes) StreamTS(w http.ResponseWriter, r *http.Request) {
 	rs.streamTranscode(w, r, ffmpeg.CodecHLS)
 }
 
-func (rs sceneRoutes) streamTranscode(w http.ResponseWriter, r *http.Request, videoCodec ffmpeg.Codec) {
-	logger.Debugf("Streaming as %s", videoCodec.MimeType)
+// hlsURL returns the URL of a HLS resource relative to the requested
+// playlist, retaining the query parameters of the request.
+func hlsURL(r *http.Request, path string) string {
+	if r.URL.RawQuery != "" {
+		return path + "?" + r.URL.RawQuery
+	}
+
+	return path
+}
+
+func (rs sceneRoutes) StreamHLSMaster(w http.ResponseWriter, r *http.Request) {
 	scene := r.Context().Value(sceneKey).(*models.Scene)
 
-	// needs to be transcoded
-	ffprobe := manager.GetInstance().FFProbe
-	videoFile, err := ffprobe.NewVideoFile(scene.Path, false)
+	session, err := manager.StartSceneHLSSession(scene, r.RemoteAddr)
+	if errors.Is(err, manager.ErrStreamsDraining) {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
 	if err != nil {
-		logger.Errorf("[stream] error reading video file: %v", err)
+		logger.Errorf("[stream] error starting HLS session: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	// start stream based on query param, if provided
-	if err = r.ParseForm(); err != nil {
-		logger.Warnf("[stream] error parsing query form: %v", err)
-	}
+	rs.recordPlay(r, scene)
+
+	w.Header().Set("Content-Type", ffmpeg.MimeHLS)
+	ffmpeg.WriteHLSMasterPlaylist(session.ProbeResult, session.Resolutions, func(resolution models.StreamingResolutionEnum) string {
+		return hlsURL(r, session.ID+"/"+resolution.String()+"/index.m3u8")
+	}, w)
+}
+
+// getHLSSession returns the session of the request, or nil after writing a
+// not found response if the session has ended.
+func getHLSSession(w http.ResponseWriter, r *http.Request) *manager.HLSSession {
+	scene := r.Context().Value(sceneKey).(*models.Scene)
 
-	startTime := r.Form.Get("start")
-	requestedSize := r.Form.Get("resolution")
+	session := manager.GetInstance().HLSStore.Get(chi.URLParam(r, "hlsSession"))
+	if session == nil || session.SceneID != scene.ID {
+		http.NotFound(w, r)
+		return nil
+	}
 
-	var stream *ffmpeg.Stream
+	return session
+}
 
-	audioCodec := ffmpeg.MissingUnsupported
-	if scene.AudioCodec.Valid {
-		audioCodec = ffmpeg.AudioCodec(scene.AudioCodec.String)
+func (rs sceneRoutes) StreamHLSVariant(w http.ResponseWriter, r *http.Request) {
+	session := getHLSSession(w, r)
+	if session == nil {
+		return
 	}
 
-	options := ffmpeg.GetTranscodeStreamOptions(*videoFile, videoCodec, audioCodec)
-	options.StartTime = startTime
-	options.MaxTranscodeSize = config.GetInstance().GetMaxStreamingTranscodeSize()
-	if requestedSize != "" {
-		options.MaxTranscodeSize = models.StreamingResolutionEnum(requestedSize)
+	w.Header().Set("Content-Type", ffmpeg.MimeHLS)
+	ffmpeg.WriteHLSMediaPlaylist(session.ProbeResult, func(index int) string {
+		return hlsURL(r, strconv.Itoa(index)+".ts")
+	}, w)
+}
+
+func (rs sceneRoutes) StreamHLSSegment(w http.ResponseWriter, r *http.Request) {
+	session := getHLSSession(w, r)
+	if session == nil {
+		return
 	}
 
-	encoder := manager.GetInstance().FFMPEG
-	stream, err = encoder.GetTranscodeStream(options)
+	resolution := models.StreamingResolutionEnum(chi.URLParam(r, "resolution"))
+	index, _ := strconv.Atoi(chi.URLParam(r, "segment"))
 
+	path, err := session.Segment(resolution, index)
+	if errors.Is(err, manager.ErrHLSSegmentNotFound) {
+		http.NotFound(w, r)
+		return
+	}
 	if err != nil {
-		logger.Errorf("[stream] error transcoding video file: %v", err)
-		w.WriteHeader(http.StatusBadRequest)
-		if _, err := w.Write([]byte(err.Error())); err != nil {
-			logger.Warnf("[stream] error writing response: %v", err)
-		}
+		logger.Errorf("[stream] error transcoding HLS segment: %v", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
-	stream.Serve(w, r)
+	w.Header().Set("Content-Type", ffmpeg.MimeMpegts)
+	http.ServeFile(w, r, path)
+}
+
+func (rs sceneRoutes) streamTranscode(w http.ResponseWriter, r *http.Request, videoCodec ffmpeg.Codec) {
+	scene := r.Context().Value(sceneKey).(*models.Scene)
+
+	ss := manager.SceneServer{
+		TXNManager: rs.txnManager,
+	}
+	ss.StreamSceneTranscode(scene, w, r, videoCodec)
 }
 
 func (rs sceneRoutes) Screenshot(w http.ResponseWriter, r *http.Request) {
@@ -201,13 +256,13 @@ func (rs sceneRoutes) Screenshot(w http.ResponseWriter, r *http.Request) {
 func (rs sceneRoutes) Preview(w http.ResponseWriter, r *http.Request) {
 	scene := r.Context().Value(sceneKey).(*models.Scene)
 	filepath := manager.GetInstance().Paths.Scene.GetStreamPreviewPath(scene.GetHash(config.GetInstance().GetVideoFileNamingAlgorithm()))
-	utils.ServeFileNoCache(w, r, filepath)
+	serveGeneratedFile(w, r, filepath)
 }
 
 func (rs sceneRoutes) Webp(w http.ResponseWriter, r *http.Request) {
 	scene := r.Context().Value(sceneKey).(*models.Scene)
 	filepath := manager.GetInstance().Paths.Scene.GetStreamPreviewImagePath(scene.GetHash(config.GetInstance().GetVideoFileNamingAlgorithm()))
-	http.ServeFile(w, r, filepath)
+	serveGeneratedFile(w, r, filepath)
 }
 
 func (rs sceneRoutes) getChapterVttTitle(ctx context.Context, marker *models.SceneMarker) string {
@@ -278,21 +333,65 @@ func (rs sceneRoutes) InteractiveHeatmap(w http.ResponseWriter, r *http.Request)
 	scene := r.Context().Value(sceneKey).(*models.Scene)
 	w.Header().Set("Content-Type", "image/png")
 	filepath := manager.GetInstance().Paths.Scene.GetInteractiveHeatmapPath(scene.GetHash(config.GetInstance().GetVideoFileNamingAlgorithm()))
-	http.ServeFile(w, r, filepath)
+	serveGeneratedFile(w, r, filepath)
+}
+
+func (rs sceneRoutes) Subtitles(w http.ResponseWriter, r *http.Request) {
+	s := r.Context().Value(sceneKey).(*models.Scene)
+	lang := r.URL.Query().Get("lang")
+	subtitleType := r.URL.Query().Get("type")
+
+	var subtitle *models.SceneSubtitle
+	if err := rs.txnManager.WithReadTxn(r.Context(), func(repo models.ReaderRepository) error {
+		subtitles, err := repo.Scene().GetSubtitles(s.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, sub := range subtitles {
+			if sub.LanguageCode == lang && sub.SubtitleType == subtitleType {
+				subtitle = sub
+				break
+			}
+		}
+		return nil
+	}); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if subtitle == nil {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+
+	if subtitle.SubtitleType == "vtt" {
+		w.Header().Set("Content-Type", "text/vtt")
+	} else {
+		w.Header().Set("Content-Type", "application/x-subrip")
+	}
+	utils.ServeFileNoCache(w, r, subtitle.Path)
 }
 
 func (rs sceneRoutes) VttThumbs(w http.ResponseWriter, r *http.Request) {
 	scene := r.Context().Value(sceneKey).(*models.Scene)
 	w.Header().Set("Content-Type", "text/vtt")
 	filepath := manager.GetInstance().Paths.Scene.GetSpriteVttFilePath(scene.GetHash(config.GetInstance().GetVideoFileNamingAlgorithm()))
-	http.ServeFile(w, r, filepath)
+	serveGeneratedFile(w, r, filepath)
 }
 
 func (rs sceneRoutes) VttSprite(w http.ResponseWriter, r *http.Request) {
 	scene := r.Context().Value(sceneKey).(*models.Scene)
 	w.Header().Set("Content-Type", "image/jpeg")
 	filepath := manager.GetInstance().Paths.Scene.GetSpriteImageFilePath(scene.GetHash(config.GetInstance().GetVideoFileNamingAlgorithm()))
-	http.ServeFile(w, r, filepath)
+	serveGeneratedFile(w, r, filepath)
+}
+
+func (rs sceneRoutes) ContactSheet(w http.ResponseWriter, r *http.Request) {
+	scene := r.Context().Value(sceneKey).(*models.Scene)
+	w.Header().Set("Content-Type", "image/jpeg")
+	filepath := manager.GetInstance().Paths.Scene.GetContactSheetPath(scene.GetHash(config.GetInstance().GetVideoFileNamingAlgorithm()))
+	serveGeneratedFile(w, r, filepath)
 }
 
 func (rs sceneRoutes) SceneMarkerStream(w http.ResponseWriter, r *http.Request) {
@@ -315,7 +414,7 @@ func (rs sceneRoutes) SceneMarkerStream(w http.ResponseWriter, r *http.Request)
 	}
 
 	filepath := manager.GetInstance().Paths.SceneMarkers.GetStreamPath(scene.GetHash(config.GetInstance().GetVideoFileNamingAlgorithm()), int(sceneMarker.Seconds))
-	http.ServeFile(w, r, filepath)
+	serveGeneratedFile(w, r, filepath)
 }
 
 func (rs sceneRoutes) SceneMarkerPreview(w http.ResponseWriter, r *http.Request) {
@@ -348,7 +447,7 @@ func (rs sceneRoutes) SceneMarkerPreview(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	http.ServeFile(w, r, filepath)
+	serveGeneratedFile(w, r, filepath)
 }
 
 func (rs sceneRoutes) SceneMarkerScreenshot(w http.ResponseWriter, r *http.Request) {
@@ -381,11 +480,17 @@ func (rs sceneRoutes) SceneMarkerScreenshot(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	http.ServeFile(w, r, filepath)
+	serveGeneratedFile(w, r, filepath)
 }
 
 // endregion
 
+// serveGeneratedFile serves a generated file with cache headers, so that
+// clients only download it again once it has been regenerated.
+func serveGeneratedFile(w http.ResponseWriter, r *http.Request, path string) {
+	utils.ServeFileCached(w, r, path, config.GetInstance().GetGeneratedCacheMaxAge())
+}
+
 func SceneCtx(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		sceneIdentifierQueryParam := chi.URLParam(r, "sceneId")
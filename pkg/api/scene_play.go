@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// playDebounce is the time after a play of a scene is recorded during which
+// streams from the start of the scene are not recorded as plays, since
+// players may request the start of a stream more than once.
+const playDebounce = time.Minute
+
+// isPlaybackStart returns true if the stream request is for the start of the
+// scene, rather than for a seek within it.
+func isPlaybackStart(r *http.Request) bool {
+	if start := r.URL.Query().Get("start"); start != "" {
+		if seconds, err := strconv.ParseFloat(start, 64); err != nil || seconds > 0 {
+			return false
+		}
+	}
+
+	byteRange := r.Header.Get("Range")
+	return byteRange == "" || strings.HasPrefix(byteRange, "bytes=0-")
+}
+
+// recordPlay increments the play count of the scene if the request is for
+// the start of the scene.
+func (rs sceneRoutes) recordPlay(r *http.Request, scene *models.Scene) {
+	if !isPlaybackStart(r) {
+		return
+	}
+
+	now := time.Now()
+	if scene.LastPlayedAt.Valid && now.Sub(scene.LastPlayedAt.Timestamp) < playDebounce {
+		return
+	}
+
+	if err := rs.txnManager.WithTxn(r.Context(), func(repo models.Repository) error {
+		_, err := repo.Scene().IncrementPlayCount(scene.ID, now)
+		return err
+	}); err != nil {
+		logger.Warnf("[stream] error recording play of scene %d: %v", scene.ID, err)
+	}
+}
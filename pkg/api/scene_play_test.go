@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestIsPlaybackStart(t *testing.T) {
+	tests := []struct {
+		name      string
+		url       string
+		byteRange string
+		want      bool
+	}{
+		{"no range", "/scene/1/stream", "", true},
+		{"range from start", "/scene/1/stream", "bytes=0-", true},
+		{"seek", "/scene/1/stream", "bytes=1024-", false},
+		{"transcode from start", "/scene/1/stream.mp4?start=0", "", true},
+		{"transcode seek", "/scene/1/stream.mp4?start=12.5", "", false},
+		{"invalid start", "/scene/1/stream.mp4?start=abc", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tt.url, nil)
+			if tt.byteRange != "" {
+				r.Header.Set("Range", tt.byteRange)
+			}
+			assert.Equal(t, tt.want, isPlaybackStart(r))
+		})
+	}
+}
+
+func TestRecordPlay(t *testing.T) {
+	const sceneID = 1
+
+	mockTxn := mocks.NewTransactionManager()
+	mockTxn.SceneMock().On("IncrementPlayCount", sceneID, mock.Anything).Return(1, nil).Once()
+
+	rs := sceneRoutes{txnManager: mockTxn}
+	scene := &models.Scene{ID: sceneID}
+
+	rs.recordPlay(httptest.NewRequest("GET", "/scene/1/stream", nil), scene)
+
+	// seeks are not plays
+	seek := httptest.NewRequest("GET", "/scene/1/stream", nil)
+	seek.Header.Set("Range", "bytes=1024-")
+	rs.recordPlay(seek, scene)
+
+	// repeated requests for the start of a recently played scene are not
+	// plays
+	scene.LastPlayedAt = models.NullSQLiteTimestamp{Timestamp: time.Now(), Valid: true}
+	rs.recordPlay(httptest.NewRequest("GET", "/scene/1/stream", nil), scene)
+
+	mockTxn.SceneMock().AssertExpectations(t)
+}
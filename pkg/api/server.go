@@ -35,10 +35,6 @@ import (
 	"github.com/vearutop/statigz"
 )
 
-var version string
-var buildstamp string
-var githash string
-
 func Start(uiBox embed.FS, loginUIBox embed.FS) {
 	initialiseImages()
 
@@ -63,6 +59,10 @@ func Start(uiBox embed.FS, loginUIBox embed.FS) {
 	r.Use(middleware.StripSlashes)
 	r.Use(cors.AllowAll().Handler)
 	r.Use(BaseURLMiddleware)
+	r.Use(maxBodySizeMiddleware(routeBodySizeLimits(c.GetMaxRequestBodySize, map[string]func() int64{
+		// graphql requests include file uploads
+		"/graphql": c.GetMaxUploadSize,
+	})))
 
 	recoverFunc := func(ctx context.Context, err interface{}) error {
 		logger.Error(err)
@@ -116,25 +116,31 @@ func Start(uiBox embed.FS, loginUIBox embed.FS) {
 
 	r.Get(loginEndPoint, getLoginHandler(loginUIBox))
 
-	r.Mount("/performer", performerRoutes{
-		txnManager: txnManager,
-	}.Routes())
-	r.Mount("/scene", sceneRoutes{
-		txnManager: txnManager,
-	}.Routes())
-	r.Mount("/image", imageRoutes{
-		txnManager: txnManager,
-	}.Routes())
-	r.Mount("/studio", studioRoutes{
-		txnManager: txnManager,
-	}.Routes())
-	r.Mount("/movie", movieRoutes{
-		txnManager: txnManager,
-	}.Routes())
-	r.Mount("/tag", tagRoutes{
-		txnManager: txnManager,
-	}.Routes())
-	r.Mount("/downloads", downloadsRoutes{}.Routes())
+	// media routes are refused in maintenance mode. The graphql endpoint
+	// remains available to run maintenance and to leave maintenance mode.
+	r.Group(func(r chi.Router) {
+		r.Use(maintenanceMiddleware(manager.GetInstance().IsMaintenance))
+
+		r.Mount("/performer", performerRoutes{
+			txnManager: txnManager,
+		}.Routes())
+		r.Mount("/scene", sceneRoutes{
+			txnManager: txnManager,
+		}.Routes())
+		r.Mount("/image", imageRoutes{
+			txnManager: txnManager,
+		}.Routes())
+		r.Mount("/studio", studioRoutes{
+			txnManager: txnManager,
+		}.Routes())
+		r.Mount("/movie", movieRoutes{
+			txnManager: txnManager,
+		}.Routes())
+		r.Mount("/tag", tagRoutes{
+			txnManager: txnManager,
+		}.Routes())
+		r.Mount("/downloads", downloadsRoutes{}.Routes())
+	})
 
 	r.HandleFunc("/css", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/css")
@@ -273,20 +279,7 @@ func Start(uiBox embed.FS, loginUIBox embed.FS) {
 }
 
 func printVersion() {
-	versionString := githash
-	if config.IsOfficialBuild() {
-		versionString += " - Official Build"
-	} else {
-		versionString += " - Unofficial Build"
-	}
-	if version != "" {
-		versionString = version + " (" + versionString + ")"
-	}
-	fmt.Printf("stash version: %s - %s\n", versionString, buildstamp)
-}
-
-func GetVersion() (string, string, string) {
-	return version, githash, buildstamp
+	fmt.Printf("stash version: %s\n", manager.GetBuildInfo())
 }
 
 func makeTLSConfig(c *config.Instance) (*tls.Config, error) {
@@ -366,6 +359,26 @@ func SecurityHeadersMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(fn)
 }
 
+// maintenanceRetryAfter is the number of seconds after which clients are
+// asked to retry requests refused in maintenance mode.
+const maintenanceRetryAfter = "60"
+
+// maintenanceMiddleware responds with 503 Service Unavailable while
+// isMaintenance returns true.
+func maintenanceMiddleware(isMaintenance func() bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isMaintenance() {
+				w.Header().Set("Retry-After", maintenanceRetryAfter)
+				http.Error(w, "stash is in maintenance mode", http.StatusServiceUnavailable)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 func BaseURLMiddleware(next http.Handler) http.Handler {
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
@@ -26,6 +26,8 @@ func getLoginPage(loginUIBox embed.FS) []byte {
 type loginTemplateData struct {
 	URL   string
 	Error string
+	// TOTP shows the field of the two-factor authentication code
+	TOTP bool
 }
 
 func redirectToLogin(loginUIBox embed.FS, w http.ResponseWriter, returnURL string, loginError string) {
@@ -36,7 +38,11 @@ func redirectToLogin(loginUIBox embed.FS, w http.ResponseWriter, returnURL strin
 		return
 	}
 
-	err = templ.Execute(w, loginTemplateData{URL: returnURL, Error: loginError})
+	err = templ.Execute(w, loginTemplateData{
+		URL:   returnURL,
+		Error: loginError,
+		TOTP:  config.GetInstance().IsTOTPEnabled(),
+	})
 	if err != nil {
 		http.Error(w, fmt.Sprintf("error: %s", err), http.StatusInternalServerError)
 	}
@@ -63,7 +69,11 @@ func handleLogin(loginUIBox embed.FS) http.HandlerFunc {
 		err := manager.GetInstance().SessionStore.Login(w, r)
 		if errors.Is(err, session.ErrInvalidCredentials) {
 			// redirect back to the login page with an error
-			redirectToLogin(loginUIBox, w, url, "Username or password is invalid")
+			loginError := "Username or password is invalid"
+			if config.GetInstance().IsTOTPEnabled() {
+				loginError = "Username, password or authentication code is invalid"
+			}
+			redirectToLogin(loginUIBox, w, url, loginError)
 			return
 		}
 
@@ -43,6 +43,10 @@ func (b SceneURLBuilder) GetSpriteURL() string {
 	return b.BaseURL + "/scene/" + b.SceneID + "_sprite.jpg"
 }
 
+func (b SceneURLBuilder) GetContactSheetURL() string {
+	return b.BaseURL + "/scene/" + b.SceneID + "/contact_sheet"
+}
+
 func (b SceneURLBuilder) GetScreenshotURL(updateTime time.Time) string {
 	return b.BaseURL + "/scene/" + b.SceneID + "/screenshot?" + strconv.FormatInt(updateTime.Unix(), 10)
 }
@@ -67,6 +71,10 @@ func (b SceneURLBuilder) GetFunscriptURL() string {
 	return b.BaseURL + "/scene/" + b.SceneID + "/funscript"
 }
 
+func (b SceneURLBuilder) GetSubtitlesURL() string {
+	return b.BaseURL + "/scene/" + b.SceneID + "/subtitles"
+}
+
 func (b SceneURLBuilder) GetInteractiveHeatmapURL() string {
 	return b.BaseURL + "/scene/" + b.SceneID + "/interactive_heatmap"
 }
@@ -0,0 +1,216 @@
+// Package audit provides an append-only log of security-relevant events.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+type EventType string
+
+const (
+	EventLogin          EventType = "LOGIN"
+	EventLoginFailed    EventType = "LOGIN_FAILED"
+	EventLogout         EventType = "LOGOUT"
+	EventExternalAccess EventType = "EXTERNAL_ACCESS"
+	EventConfigChange   EventType = "CONFIG_CHANGE"
+	EventDelete         EventType = "DELETE"
+)
+
+// Entry is an event in the audit log.
+type Entry struct {
+	Time time.Time `json:"time"`
+	Type EventType `json:"type"`
+	// User is the user that caused the event, if known. For failed logins,
+	// this is the username that was attempted.
+	User       string            `json:"user,omitempty"`
+	RemoteAddr string            `json:"remote_addr,omitempty"`
+	Message    string            `json:"message"`
+	Data       map[string]string `json:"data,omitempty"`
+}
+
+func (e Entry) matches(q Query) bool {
+	if len(q.Types) > 0 {
+		found := false
+		for _, t := range q.Types {
+			if e.Type == t {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if q.Text != "" {
+		text := strings.ToLower(q.Text)
+		if !strings.Contains(strings.ToLower(e.Message), text) && !strings.Contains(strings.ToLower(e.User), text) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Log appends entries to a file, with one JSON encoded entry per line. A
+// nil Log discards entries.
+type Log struct {
+	mutex sync.Mutex
+	path  string
+}
+
+// NewLog returns a log writing to the file at path.
+func NewLog(path string) *Log {
+	return &Log{
+		path: path,
+	}
+}
+
+// SetPath sets the path of the log file. Existing entries are not moved.
+func (l *Log) SetPath(path string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.path = path
+}
+
+// Record appends the entry to the log, setting the time of the entry if it
+// is not set. Errors are logged, so that failing to write the audit log
+// does not prevent the audited action.
+func (l *Log) Record(e Entry) {
+	if l == nil {
+		return
+	}
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+
+	if err := l.append(e); err != nil {
+		logger.Errorf("error writing audit log: %v", err)
+	}
+}
+
+func (l *Log) append(e Entry) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.path == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// Query filters and paginates the entries of the log.
+type Query struct {
+	// Types returns entries of the types. All types are returned if empty.
+	Types []EventType
+	// Text returns entries with a message or user containing the text.
+	Text string
+	// Page is the 1-based page number.
+	Page int
+	// PerPage is the number of entries per page. All entries are returned
+	// if negative.
+	PerPage int
+}
+
+// Query returns a page of the entries matching the query, from newest to
+// oldest, and the total number of matching entries.
+func (l *Log) Query(q Query) ([]Entry, int, error) {
+	if l == nil {
+		return nil, 0, nil
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.path == "" {
+		return nil, 0, nil
+	}
+
+	f, err := os.Open(l.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	defer f.Close()
+
+	var matched []Entry
+	scanner := bufio.NewScanner(f)
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			logger.Warnf("ignoring invalid audit log entry on line %d: %v", line, err)
+			continue
+		}
+
+		if e.matches(q) {
+			matched = append(matched, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("reading audit log: %w", err)
+	}
+
+	// newest first
+	for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+		matched[i], matched[j] = matched[j], matched[i]
+	}
+
+	count := len(matched)
+	if q.PerPage < 0 {
+		return matched, count, nil
+	}
+
+	page := q.Page
+	if page < 1 {
+		page = 1
+	}
+
+	start := (page - 1) * q.PerPage
+	if start >= count {
+		return nil, count, nil
+	}
+
+	end := start + q.PerPage
+	if end > count {
+		end = count
+	}
+
+	return matched[start:end], count, nil
+}
@@ -0,0 +1,121 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLogQuery(t *testing.T) {
+	l := NewLog(filepath.Join(t.TempDir(), "audit", "audit.log"))
+
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := []Entry{
+		{Type: EventLogin, User: "alice", Message: "login succeeded"},
+		{Type: EventLoginFailed, User: "bob", Message: "login failed: invalid username or password"},
+		{Type: EventConfigChange, User: "alice", Message: "general configuration changed"},
+		{Type: EventDelete, User: "alice", Message: "deleted 2 scene", Data: map[string]string{"ids": "1,2"}},
+		{Type: EventLogout, User: "alice", Message: "logged out"},
+	}
+	for i, e := range entries {
+		e.Time = start.Add(time.Duration(i) * time.Minute)
+		l.Record(e)
+	}
+
+	tests := []struct {
+		name      string
+		q         Query
+		wantCount int
+		wantUsers []string
+		wantTypes []EventType
+	}{
+		{"all", Query{PerPage: -1}, 5, nil, []EventType{EventLogout, EventDelete, EventConfigChange, EventLoginFailed, EventLogin}},
+		{"first page", Query{Page: 1, PerPage: 2}, 5, nil, []EventType{EventLogout, EventDelete}},
+		{"last page", Query{Page: 3, PerPage: 2}, 5, nil, []EventType{EventLogin}},
+		{"past last page", Query{Page: 4, PerPage: 2}, 5, nil, []EventType{}},
+		{"by type", Query{Types: []EventType{EventLogin, EventLoginFailed}, PerPage: -1}, 2, nil, []EventType{EventLoginFailed, EventLogin}},
+		{"by user", Query{Text: "BOB", PerPage: -1}, 1, []string{"bob"}, nil},
+		{"by message", Query{Text: "configuration", PerPage: -1}, 1, nil, []EventType{EventConfigChange}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, count, err := l.Query(tt.q)
+			if err != nil {
+				t.Fatalf("Query() error = %v", err)
+			}
+			if count != tt.wantCount {
+				t.Errorf("Query() count = %d, want %d", count, tt.wantCount)
+			}
+
+			if tt.wantTypes != nil {
+				if len(got) != len(tt.wantTypes) {
+					t.Fatalf("Query() returned %d entries, want %d", len(got), len(tt.wantTypes))
+				}
+				for i, e := range got {
+					if e.Type != tt.wantTypes[i] {
+						t.Errorf("entry %d type = %s, want %s", i, e.Type, tt.wantTypes[i])
+					}
+				}
+			}
+
+			for i, u := range tt.wantUsers {
+				if got[i].User != u {
+					t.Errorf("entry %d user = %s, want %s", i, got[i].User, u)
+				}
+			}
+		})
+	}
+
+	got, _, _ := l.Query(Query{Types: []EventType{EventDelete}, PerPage: -1})
+	if len(got) != 1 || got[0].Data["ids"] != "1,2" || !got[0].Time.Equal(start.Add(3*time.Minute)) {
+		t.Errorf("delete entry was not read back correctly: %+v", got)
+	}
+}
+
+func TestLogAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	NewLog(path).Record(Entry{Type: EventLogin, Message: "first"})
+	// a new log with the same path must not truncate the file
+	l := NewLog(path)
+	l.Record(Entry{Type: EventLogin, Message: "second"})
+
+	got, count, err := l.Query(Query{PerPage: -1})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if count != 2 || got[0].Message != "second" || got[1].Message != "first" {
+		t.Errorf("Query() = %+v, want second and first entries", got)
+	}
+	if got[0].Time.IsZero() {
+		t.Error("Record() did not set the entry time")
+	}
+
+	// invalid lines are skipped
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("not json\n")
+	f.Close()
+
+	if _, count, err := l.Query(Query{PerPage: -1}); err != nil || count != 2 {
+		t.Errorf("Query() = %d, %v; want 2 entries", count, err)
+	}
+}
+
+func TestLogNoPath(t *testing.T) {
+	var nilLog *Log
+	nilLog.Record(Entry{Type: EventLogin})
+	if _, count, err := nilLog.Query(Query{}); count != 0 || err != nil {
+		t.Errorf("nil Log Query() = %d, %v", count, err)
+	}
+
+	l := NewLog("")
+	l.Record(Entry{Type: EventLogin})
+	if _, count, err := l.Query(Query{}); count != 0 || err != nil {
+		t.Errorf("Query() with no path = %d, %v", count, err)
+	}
+}
@@ -6,14 +6,19 @@ package autotag
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
 
 	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/match"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/sqlite"
 	"github.com/stashapp/stash/pkg/utils"
+	"github.com/stretchr/testify/assert"
 
 	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
@@ -469,7 +474,7 @@ func TestParseTagScenes(t *testing.T) {
 				return err
 			}
 
-			return TagScenes(s, nil, aliases, r.Scene())
+			return TagScenes(s, nil, aliases, nil, r.Scene())
 		}); err != nil {
 			t.Errorf("Error auto-tagging performers: %s", err)
 		}
@@ -624,7 +629,7 @@ func TestParseTagImages(t *testing.T) {
 				return err
 			}
 
-			return TagImages(s, nil, aliases, r.Image())
+			return TagImages(s, nil, aliases, nil, r.Image())
 		}); err != nil {
 			t.Errorf("Error auto-tagging performers: %s", err)
 		}
@@ -779,7 +784,7 @@ func TestParseTagGalleries(t *testing.T) {
 				return err
 			}
 
-			return TagGalleries(s, nil, aliases, r.Gallery())
+			return TagGalleries(s, nil, aliases, nil, r.Gallery())
 		}); err != nil {
 			t.Errorf("Error auto-tagging performers: %s", err)
 		}
@@ -812,3 +817,114 @@ func TestParseTagGalleries(t *testing.T) {
 		return nil
 	})
 }
+
+func TestUnmatchedScenes(t *testing.T) {
+	errRollback := errors.New("rollback")
+
+	// perform in a transaction that is rolled back so that other tests are
+	// unaffected
+	err := withTxn(func(r models.Repository) error {
+		sqb := r.Scene()
+
+		dir := filepath.Join("unmatched", "dir")
+		untagged, err := sqb.Create(*makeScene(filepath.Join(dir, "untagged.mp4"), false))
+		if err != nil {
+			return err
+		}
+
+		tagged, err := sqb.Create(*makeScene(filepath.Join(dir, "tagged.mp4"), false))
+		if err != nil {
+			return err
+		}
+
+		tag, err := r.Tag().Create(models.Tag{Name: "unmatched tag"})
+		if err != nil {
+			return err
+		}
+
+		if err := sqb.UpdateTags(tagged.ID, []int{tag.ID}); err != nil {
+			return err
+		}
+
+		result, err := UnmatchedScenes(sqb, models.AutoTagUnmatchedTypeTags, []string{dir}, nil)
+		if err != nil {
+			t.Errorf("Error getting unmatched scenes: %s", err.Error())
+			return errRollback
+		}
+
+		assert.Equal(t, 1, result.Count)
+		if assert.Len(t, result.Scenes, 1) {
+			assert.Equal(t, strconv.Itoa(untagged.ID), result.Scenes[0].ID)
+			assert.Equal(t, untagged.Path, result.Scenes[0].Path)
+		}
+
+		// neither scene has performers
+		result, err = UnmatchedScenes(sqb, models.AutoTagUnmatchedTypePerformers, []string{dir}, nil)
+		if err != nil {
+			t.Errorf("Error getting unmatched scenes: %s", err.Error())
+			return errRollback
+		}
+
+		assert.Equal(t, 2, result.Count)
+
+		return errRollback
+	})
+
+	if !errors.Is(err, errRollback) {
+		t.Error(err)
+	}
+}
+
+func TestFuzzyMatchPerformers(t *testing.T) {
+	const name = "Sarah Connor"
+	errRollback := errors.New("rollback")
+
+	tests := []struct {
+		path          string
+		fuzzyDistance int
+		want          bool
+	}{
+		{"/videos/sarah.connor.mp4", 0, true},
+		{"/videos/sarah.conor.mp4", 0, false},
+		{"/videos/sarah.conor.mp4", 1, true},
+		// candidates are queried by the first two characters of path words,
+		// so names misspelled at the start are not matched
+		{"/videos/asrah.connor.mp4", 1, false},
+	}
+
+	if err := withTxn(func(r models.Repository) error {
+		if _, err := r.Performer().Create(models.Performer{
+			Checksum: name,
+			Name:     sql.NullString{Valid: true, String: name},
+			Favorite: sql.NullBool{Valid: true, Bool: false},
+		}); err != nil {
+			return err
+		}
+
+		for _, tt := range tests {
+			tagger := tagger{
+				Path: tt.path,
+				PathMatcher: match.PathMatcher{
+					FuzzyDistance: tt.fuzzyDistance,
+				},
+			}
+
+			performers, err := tagger.matchPerformers(r.Performer())
+			if err != nil {
+				return err
+			}
+
+			found := false
+			for _, p := range performers {
+				if p.Name.String == name {
+					found = true
+				}
+			}
+			assert.Equal(t, tt.want, found, "path %s with fuzzy distance %d", tt.path, tt.fuzzyDistance)
+		}
+
+		return errRollback
+	}); !errors.Is(err, errRollback) {
+		t.Errorf("Error matching performers: %v", err)
+	}
+}
@@ -0,0 +1,413 @@
+package autotag
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/gallery"
+	"github.com/stashapp/stash/pkg/image"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/match"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene"
+)
+
+// Matches are the performers, studio and tags whose names match the path of
+// a scene, image or gallery.
+type Matches struct {
+	Performers []*models.Performer
+	Studio     *models.Studio
+	Tags       []*models.Tag
+}
+
+func (m *Matches) empty() bool {
+	return len(m.Performers) == 0 && m.Studio == nil && len(m.Tags) == 0
+}
+
+// Matcher finds the performers, studios and tags that match a path.
+type Matcher struct {
+	Performers bool
+	Studios    bool
+	Tags       bool
+	// TagAncestors is whether the ancestors of the matched tags are also
+	// matched.
+	TagAncestors bool
+	// FuzzyDistance is the maximum edit distance between the words of a name
+	// and the words of the path for names that do not match exactly. Fuzzy
+	// matching is disabled if zero. Only names starting with the first two
+	// characters of a path word are candidates, so the first two characters
+	// of a name must not be misspelled.
+	FuzzyDistance int
+}
+
+// match returns the objects matching the path. Studios are not matched if
+// the object already has a studio.
+func (m Matcher) match(path string, hasStudio bool, r models.ReaderRepository) (*Matches, error) {
+	ret := &Matches{}
+	var err error
+
+	t := tagger{
+		Path: path,
+		PathMatcher: match.PathMatcher{
+			FuzzyDistance: m.FuzzyDistance,
+		},
+	}
+
+	if m.Performers {
+		ret.Performers, err = t.matchPerformers(r.Performer())
+		if err != nil {
+			return nil, fmt.Errorf("error matching performers: %w", err)
+		}
+	}
+
+	if m.Studios && !hasStudio {
+		ret.Studio, err = t.matchStudio(r.Studio())
+		if err != nil {
+			return nil, fmt.Errorf("error matching studio: %w", err)
+		}
+	}
+
+	if m.Tags {
+		ret.Tags, err = t.matchTags(r.Tag())
+		if err != nil {
+			return nil, fmt.Errorf("error matching tags: %w", err)
+		}
+
+		if m.TagAncestors {
+			ret.Tags, err = withTagAncestors(ret.Tags, r.Tag())
+			if err != nil {
+				return nil, fmt.Errorf("error matching tag ancestors: %w", err)
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// Target is a scene, image or gallery to be auto-tagged.
+type Target interface {
+	// Path returns the path of the target, used for logging.
+	Path() string
+	// Match returns the objects matching the target's path.
+	Match(m Matcher, r models.ReaderRepository) (*Matches, error)
+	// Apply adds the matched objects to the target, returning true if any
+	// were added.
+	Apply(r models.Repository, matches *Matches) (bool, error)
+}
+
+type sceneTarget struct {
+	s *models.Scene
+}
+
+// SceneTarget returns a Target for the scene.
+func SceneTarget(s *models.Scene) Target {
+	return sceneTarget{s: s}
+}
+
+func (t sceneTarget) Path() string {
+	return t.s.Path
+}
+
+func (t sceneTarget) Match(m Matcher, r models.ReaderRepository) (*Matches, error) {
+	return m.match(t.s.Path, t.s.StudioID.Valid, r)
+}
+
+func (t sceneTarget) Apply(r models.Repository, matches *Matches) (bool, error) {
+	tt := getSceneFileTagger(t.s)
+	rw := r.Scene()
+
+	performersAdded, err := tt.addPerformers(matches.Performers, func(subjectID, otherID int) (bool, error) {
+		return scene.AddPerformer(rw, subjectID, otherID)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	studioAdded, err := tt.addStudio(matches.Studio, func(subjectID, otherID int) (bool, error) {
+		return addSceneStudio(rw, subjectID, otherID)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	tagsAdded, err := tt.addTags(matches.Tags, func(subjectID, otherID int) (bool, error) {
+		return scene.AddTag(rw, subjectID, otherID)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return performersAdded || studioAdded || tagsAdded, nil
+}
+
+type imageTarget struct {
+	i *models.Image
+}
+
+// ImageTarget returns a Target for the image.
+func ImageTarget(i *models.Image) Target {
+	return imageTarget{i: i}
+}
+
+func (t imageTarget) Path() string {
+	return t.i.Path
+}
+
+func (t imageTarget) Match(m Matcher, r models.ReaderRepository) (*Matches, error) {
+	return m.match(t.i.Path, t.i.StudioID.Valid, r)
+}
+
+func (t imageTarget) Apply(r models.Repository, matches *Matches) (bool, error) {
+	tt := getImageFileTagger(t.i)
+	rw := r.Image()
+
+	performersAdded, err := tt.addPerformers(matches.Performers, func(subjectID, otherID int) (bool, error) {
+		return image.AddPerformer(rw, subjectID, otherID)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	studioAdded, err := tt.addStudio(matches.Studio, func(subjectID, otherID int) (bool, error) {
+		return addImageStudio(rw, subjectID, otherID)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	tagsAdded, err := tt.addTags(matches.Tags, func(subjectID, otherID int) (bool, error) {
+		return image.AddTag(rw, subjectID, otherID)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return performersAdded || studioAdded || tagsAdded, nil
+}
+
+type galleryTarget struct {
+	g *models.Gallery
+}
+
+// GalleryTarget returns a Target for the gallery.
+func GalleryTarget(g *models.Gallery) Target {
+	return galleryTarget{g: g}
+}
+
+func (t galleryTarget) Path() string {
+	return t.g.Path.String
+}
+
+func (t galleryTarget) Match(m Matcher, r models.ReaderRepository) (*Matches, error) {
+	return m.match(t.g.Path.String, t.g.StudioID.Valid, r)
+}
+
+func (t galleryTarget) Apply(r models.Repository, matches *Matches) (bool, error) {
+	tt := getGalleryFileTagger(t.g)
+	rw := r.Gallery()
+
+	performersAdded, err := tt.addPerformers(matches.Performers, func(subjectID, otherID int) (bool, error) {
+		return gallery.AddPerformer(rw, subjectID, otherID)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	studioAdded, err := tt.addStudio(matches.Studio, func(subjectID, otherID int) (bool, error) {
+		return addGalleryStudio(rw, subjectID, otherID)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	tagsAdded, err := tt.addTags(matches.Tags, func(subjectID, otherID int) (bool, error) {
+		return gallery.AddTag(rw, subjectID, otherID)
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return performersAdded || studioAdded || tagsAdded, nil
+}
+
+// Pipeline auto-tags targets in two phases. Targets are matched
+// concurrently, each in its own read transaction, since matching only reads
+// from the database. The matches are then written by a single writer in the
+// order of the targets, batching multiple targets into each write
+// transaction.
+type Pipeline struct {
+	TxnManager models.TransactionManager
+	Matcher    Matcher
+
+	// Workers is the number of targets matched concurrently. Targets are
+	// matched one at a time if less than 1.
+	Workers int
+	// BatchSize is the maximum number of targets written in a single
+	// transaction. Each target is written in its own transaction if less
+	// than 1.
+	BatchSize int
+}
+
+type matchResult struct {
+	target  Target
+	matches *Matches
+	err     error
+}
+
+// Run auto-tags the targets, calling done with whether the target was tagged
+// after each target is processed. done is called for each target in order,
+// once its matches have been written. Errors are logged and do not stop the
+// remaining targets. If the context is cancelled, matches that have already
+// been found are written and the remaining targets are skipped.
+func (p Pipeline) Run(ctx context.Context, targets []Target, done func(tagged bool)) {
+	workers := p.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	batchSize := p.BatchSize
+	if batchSize < 1 {
+		batchSize = 1
+	}
+
+	// each result has its own buffered channel, so that workers never block
+	// and the writer can consume the results in order
+	results := make([]chan matchResult, len(targets))
+	for i := range results {
+		results[i] = make(chan matchResult, 1)
+	}
+
+	indexes := make(chan int)
+	go func() {
+		defer close(indexes)
+		for i := range targets {
+			select {
+			case indexes <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range indexes {
+				results[i] <- p.match(ctx, targets[i])
+			}
+		}()
+	}
+
+	// pending holds the results that have not been written, in order. Results
+	// without matches are kept while earlier results are pending, so that
+	// done is called in order.
+	var pending []matchResult
+	writes := 0
+
+	for i := range targets {
+		if ctx.Err() != nil {
+			break
+		}
+
+		var res matchResult
+		select {
+		case res = <-results[i]:
+		case <-ctx.Done():
+		}
+		if res.target == nil {
+			break
+		}
+
+		if res.err != nil {
+			logger.Errorf("error auto-tagging %s: %v", res.target.Path(), res.err)
+			res.matches = nil
+		}
+
+		if !res.hasMatches() {
+			if len(pending) == 0 {
+				done(false)
+			} else {
+				pending = append(pending, res)
+			}
+			continue
+		}
+
+		pending = append(pending, res)
+		writes++
+		if writes == batchSize {
+			p.write(pending, done)
+			pending = pending[:0]
+			writes = 0
+		}
+	}
+
+	p.write(pending, done)
+}
+
+func (r matchResult) hasMatches() bool {
+	return r.matches != nil && !r.matches.empty()
+}
+
+func (p Pipeline) match(ctx context.Context, t Target) matchResult {
+	ret := matchResult{target: t}
+	ret.err = p.TxnManager.WithReadTxn(ctx, func(r models.ReaderRepository) error {
+		var err error
+		ret.matches, err = t.Match(p.Matcher, r)
+		return err
+	})
+
+	return ret
+}
+
+// write applies the matches of the results in a single transaction, then
+// calls done for each result. If the transaction fails, each result is
+// retried in its own transaction, so that a single failing target does not
+// prevent the rest of the batch from being written.
+func (p Pipeline) write(pending []matchResult, done func(tagged bool)) {
+	if len(pending) == 0 {
+		return
+	}
+
+	tagged := make([]bool, len(pending))
+	writes := 0
+	for _, res := range pending {
+		if res.hasMatches() {
+			writes++
+		}
+	}
+
+	if writes > 0 {
+		if err := p.TxnManager.WithTxn(context.TODO(), func(r models.Repository) error {
+			for i, res := range pending {
+				if !res.hasMatches() {
+					continue
+				}
+
+				added, err := res.target.Apply(r, res.matches)
+				if err != nil {
+					return fmt.Errorf("error auto-tagging %s: %w", res.target.Path(), err)
+				}
+				tagged[i] = added
+			}
+
+			return nil
+		}); err != nil {
+			if writes == 1 {
+				logger.Error(err.Error())
+				for range pending {
+					done(false)
+				}
+				return
+			}
+
+			logger.Warnf("Error writing auto-tag batch, writing individually: %v", err)
+			for i := range pending {
+				p.write(pending[i:i+1], done)
+			}
+			return
+		}
+	}
+
+	for _, t := range tagged {
+		done(t)
+	}
+}
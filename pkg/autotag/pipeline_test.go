@@ -0,0 +1,251 @@
+package autotag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	pipelinePerformerID = 2
+	pipelineStudioID    = 3
+	pipelineTagID       = 4
+)
+
+// newPipelineTxnManager returns a transaction manager that matches the
+// performer, studio and tag names, taking latency to query each of them.
+func newPipelineTxnManager(latency time.Duration) *mocks.TransactionManager {
+	m := mocks.NewTransactionManager()
+
+	m.PerformerMock().On("QueryForAutoTag", mock.Anything).Return([]*models.Performer{
+		{ID: pipelinePerformerID, Name: models.NullString("performer name")},
+	}, nil).After(latency)
+	m.StudioMock().On("QueryForAutoTag", mock.Anything).Return([]*models.Studio{
+		{ID: pipelineStudioID, Name: models.NullString("studio name")},
+	}, nil).After(latency)
+	m.StudioMock().On("GetAliases", mock.Anything).Return([]string{}, nil)
+	m.TagMock().On("QueryForAutoTag", mock.Anything).Return([]*models.Tag{
+		{ID: pipelineTagID, Name: "tag name"},
+	}, nil).After(latency)
+	m.TagMock().On("GetAliases", mock.Anything).Return([]string{}, nil)
+
+	sceneQB := m.SceneMock()
+	sceneQB.On("GetPerformerIDs", mock.Anything).Return(nil, nil)
+	sceneQB.On("UpdatePerformers", mock.Anything, mock.Anything).Return(nil)
+	sceneQB.On("Find", mock.Anything).Return(&models.Scene{}, nil)
+	sceneQB.On("Update", mock.Anything).Return(nil, nil)
+	sceneQB.On("GetTagIDs", mock.Anything).Return(nil, nil)
+	sceneQB.On("UpdateTags", mock.Anything, mock.Anything).Return(nil)
+
+	return m
+}
+
+func makePipelineScenes(n int) []*models.Scene {
+	names := []string{"performer name", "studio name", "tag name", "unmatched"}
+
+	var ret []*models.Scene
+	for i := 0; i < n; i++ {
+		s := &models.Scene{
+			ID:   i + 1,
+			Path: fmt.Sprintf("dir/%s.%s.%d.%s", names[i%len(names)], names[(i/len(names))%len(names)], i, sceneExt),
+		}
+		if i%5 == 0 {
+			s.StudioID = models.NullInt64(1)
+		}
+		ret = append(ret, s)
+	}
+
+	return ret
+}
+
+func tagScenesSerial(m *mocks.TransactionManager, scenes []*models.Scene) error {
+	for _, s := range scenes {
+		if err := m.WithTxn(context.TODO(), func(r models.Repository) error {
+			if err := ScenePerformers(s, r.Scene(), r.Performer()); err != nil {
+				return err
+			}
+			if err := SceneStudios(s, r.Scene(), r.Studio()); err != nil {
+				return err
+			}
+			return SceneTags(s, r.Scene(), r.Tag())
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func tagScenesPipeline(m *mocks.TransactionManager, scenes []*models.Scene, workers, batchSize int, done func(tagged bool)) {
+	targets := make([]Target, len(scenes))
+	for i, s := range scenes {
+		targets[i] = SceneTarget(s)
+	}
+
+	p := Pipeline{
+		TxnManager: m,
+		Matcher: Matcher{
+			Performers: true,
+			Studios:    true,
+			Tags:       true,
+		},
+		Workers:   workers,
+		BatchSize: batchSize,
+	}
+
+	p.Run(context.Background(), targets, done)
+}
+
+// writeCalls returns the calls that write to the database, in order.
+func writeCalls(qb *mocks.SceneReaderWriter) []mock.Call {
+	var ret []mock.Call
+	for _, c := range qb.Calls {
+		switch c.Method {
+		case "UpdatePerformers", "Update", "UpdateTags":
+			ret = append(ret, mock.Call{Method: c.Method, Arguments: c.Arguments})
+		}
+	}
+
+	return ret
+}
+
+func TestPipelineMatchesSerial(t *testing.T) {
+	scenes := makePipelineScenes(50)
+
+	serial := newPipelineTxnManager(0)
+	if err := tagScenesSerial(serial, scenes); err != nil {
+		t.Fatal(err)
+	}
+
+	want := writeCalls(serial.SceneMock())
+	assert.NotEmpty(t, want)
+
+	// scenes are tagged if they were written to
+	var wantTagged []bool
+	for _, s := range scenes {
+		written := false
+		for _, c := range want {
+			if c.Arguments.Get(0) == s.ID || c.Method == "Update" && c.Arguments.Get(0).(models.ScenePartial).ID == s.ID {
+				written = true
+			}
+		}
+		wantTagged = append(wantTagged, written)
+	}
+
+	for _, workers := range []int{0, 1, 4} {
+		for _, batchSize := range []int{0, 1, 7, 100} {
+			t.Run(fmt.Sprintf("workers %d batch %d", workers, batchSize), func(t *testing.T) {
+				m := newPipelineTxnManager(0)
+
+				var tagged []bool
+				tagScenesPipeline(m, scenes, workers, batchSize, func(v bool) {
+					tagged = append(tagged, v)
+				})
+
+				assert.Equal(t, wantTagged, tagged)
+				assert.Equal(t, want, writeCalls(m.SceneMock()))
+			})
+		}
+	}
+}
+
+func TestPipelineBatchError(t *testing.T) {
+	const failID = 2
+	m := mocks.NewTransactionManager()
+	m.TagMock().On("QueryForAutoTag", mock.Anything).Return([]*models.Tag{
+		{ID: pipelineTagID, Name: "tag name"},
+	}, nil)
+	m.TagMock().On("GetAliases", mock.Anything).Return([]string{}, nil)
+
+	sceneQB := m.SceneMock()
+	sceneQB.On("GetTagIDs", mock.Anything).Return(nil, nil)
+	sceneQB.On("UpdateTags", failID, mock.Anything).Return(errors.New("update error"))
+	sceneQB.On("UpdateTags", mock.Anything, mock.Anything).Return(nil)
+
+	var targets []Target
+	for id := 1; id <= 3; id++ {
+		targets = append(targets, SceneTarget(&models.Scene{ID: id, Path: "tag name." + sceneExt}))
+	}
+
+	var tagged []bool
+	Pipeline{
+		TxnManager: m,
+		Matcher:    Matcher{Tags: true},
+		BatchSize:  10,
+	}.Run(context.Background(), targets, func(v bool) {
+		tagged = append(tagged, v)
+	})
+
+	// the failing scene does not prevent the others from being written
+	assert.Equal(t, []bool{true, false, true}, tagged)
+	sceneQB.AssertCalled(t, "UpdateTags", 3, []int{pipelineTagID})
+}
+
+func TestPipelineCancelled(t *testing.T) {
+	m := newPipelineTxnManager(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var targets []Target
+	for _, s := range makePipelineScenes(10) {
+		targets = append(targets, SceneTarget(s))
+	}
+
+	Pipeline{
+		TxnManager: m,
+		Matcher:    Matcher{Performers: true, Studios: true, Tags: true},
+		Workers:    2,
+		BatchSize:  100,
+	}.Run(ctx, targets, func(bool) {})
+
+	assert.Empty(t, writeCalls(m.SceneMock()))
+}
+
+// matchLatency simulates the time taken to query the database when matching.
+const matchLatency = 200 * time.Microsecond
+
+func BenchmarkAutoTagSerial(b *testing.B) {
+	scenes := makePipelineScenes(100)
+	for i := 0; i < b.N; i++ {
+		if err := tagScenesSerial(newPipelineTxnManager(matchLatency), scenes); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAutoTagPipeline(b *testing.B) {
+	scenes := makePipelineScenes(100)
+	for i := 0; i < b.N; i++ {
+		tagScenesPipeline(newPipelineTxnManager(matchLatency), scenes, runtime.NumCPU()*2, 100, func(bool) {})
+	}
+}
+
+func TestPipelineTagAncestors(t *testing.T) {
+	const parentID = 5
+
+	m := newPipelineTxnManager(0)
+	m.TagMock().On("FindByChildTagID", pipelineTagID).Return([]*models.Tag{
+		{ID: parentID, Name: "parent"},
+	}, nil)
+	m.TagMock().On("FindByChildTagID", parentID).Return(nil, nil)
+
+	targets := []Target{SceneTarget(&models.Scene{ID: 1, Path: "tag name." + sceneExt})}
+
+	Pipeline{
+		TxnManager: m,
+		Matcher:    Matcher{Tags: true, TagAncestors: true},
+	}.Run(context.Background(), targets, func(bool) {})
+
+	sceneQB := m.SceneMock()
+	sceneQB.AssertCalled(t, "UpdateTags", 1, []int{pipelineTagID})
+	sceneQB.AssertCalled(t, "UpdateTags", 1, []int{parentID})
+}
@@ -1,6 +1,8 @@
 package autotag
 
 import (
+	"fmt"
+
 	"github.com/stashapp/stash/pkg/gallery"
 	"github.com/stashapp/stash/pkg/image"
 	"github.com/stashapp/stash/pkg/models"
@@ -25,13 +27,112 @@ func getTagTaggers(p *models.Tag, aliases []string) []tagger {
 	return ret
 }
 
-// TagScenes searches for scenes whose path matches the provided tag name and tags the scene with the tag.
-func TagScenes(p *models.Tag, paths []string, aliases []string, rw models.SceneReaderWriter) error {
+// TagAliases returns the aliases of the tag used by auto-tag, including the
+// generated aliases if the tag generates aliases.
+func TagAliases(t *models.Tag, r models.TagReader) ([]string, error) {
+	aliases, err := r.GetAliases(t.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.GenerateAliases {
+		generated, err := r.GetGeneratedAliases(t.ID)
+		if err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, generated...)
+	}
+
+	return aliases, nil
+}
+
+// TagAncestors returns the ancestors of the tag, nearest first. Each
+// ancestor is returned once, and cycles in the hierarchy are ignored.
+func TagAncestors(tagID int, r models.TagReader) ([]*models.Tag, error) {
+	visited := map[int]bool{tagID: true}
+	queue := []int{tagID}
+	var ret []*models.Tag
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		parents, err := r.FindByChildTagID(id)
+		if err != nil {
+			return nil, fmt.Errorf("error finding parents of tag %d: %w", id, err)
+		}
+
+		for _, p := range parents {
+			if visited[p.ID] {
+				continue
+			}
+			visited[p.ID] = true
+			ret = append(ret, p)
+			queue = append(queue, p.ID)
+		}
+	}
+
+	return ret, nil
+}
+
+// withTagAncestors returns the tags followed by their ancestors. Tags that
+// are ancestors of more than one tag are returned once.
+func withTagAncestors(tags []*models.Tag, r models.TagReader) ([]*models.Tag, error) {
+	seen := make(map[int]bool)
+	var ret []*models.Tag
+	for _, t := range tags {
+		if !seen[t.ID] {
+			seen[t.ID] = true
+			ret = append(ret, t)
+		}
+	}
+
+	for _, t := range tags {
+		ancestors, err := TagAncestors(t.ID, r)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, a := range ancestors {
+			if !seen[a.ID] {
+				seen[a.ID] = true
+				ret = append(ret, a)
+			}
+		}
+	}
+
+	return ret, nil
+}
+
+// addTagWithAncestors adds the tag and then its ancestors using addFunc,
+// returning true if any were added.
+func addTagWithAncestors(tagID int, ancestors []*models.Tag, addFunc func(tagID int) (bool, error)) (bool, error) {
+	ret, err := addFunc(tagID)
+	if err != nil {
+		return false, err
+	}
+
+	for _, a := range ancestors {
+		added, err := addFunc(a.ID)
+		if err != nil {
+			return ret, err
+		}
+		ret = ret || added
+	}
+
+	return ret, nil
+}
+
+// TagScenes searches for scenes whose path matches the provided tag name and tags the scene with the tag
+// and the provided ancestors.
+func TagScenes(p *models.Tag, paths []string, aliases []string, ancestors []*models.Tag, rw models.SceneReaderWriter) error {
 	t := getTagTaggers(p, aliases)
 
 	for _, tt := range t {
 		if err := tt.tagScenes(paths, rw, func(subjectID, otherID int) (bool, error) {
-			return scene.AddTag(rw, otherID, subjectID)
+			return addTagWithAncestors(subjectID, ancestors, func(tagID int) (bool, error) {
+				return scene.AddTag(rw, otherID, tagID)
+			})
 		}); err != nil {
 			return err
 		}
@@ -39,13 +140,16 @@ func TagScenes(p *models.Tag, paths []string, aliases []string, rw models.SceneR
 	return nil
 }
 
-// TagImages searches for images whose path matches the provided tag name and tags the image with the tag.
-func TagImages(p *models.Tag, paths []string, aliases []string, rw models.ImageReaderWriter) error {
+// TagImages searches for images whose path matches the provided tag name and tags the image with the tag
+// and the provided ancestors.
+func TagImages(p *models.Tag, paths []string, aliases []string, ancestors []*models.Tag, rw models.ImageReaderWriter) error {
 	t := getTagTaggers(p, aliases)
 
 	for _, tt := range t {
 		if err := tt.tagImages(paths, rw, func(subjectID, otherID int) (bool, error) {
-			return image.AddTag(rw, otherID, subjectID)
+			return addTagWithAncestors(subjectID, ancestors, func(tagID int) (bool, error) {
+				return image.AddTag(rw, otherID, tagID)
+			})
 		}); err != nil {
 			return err
 		}
@@ -53,13 +157,16 @@ func TagImages(p *models.Tag, paths []string, aliases []string, rw models.ImageR
 	return nil
 }
 
-// TagGalleries searches for galleries whose path matches the provided tag name and tags the gallery with the tag.
-func TagGalleries(p *models.Tag, paths []string, aliases []string, rw models.GalleryReaderWriter) error {
+// TagGalleries searches for galleries whose path matches the provided tag name and tags the gallery with the tag
+// and the provided ancestors.
+func TagGalleries(p *models.Tag, paths []string, aliases []string, ancestors []*models.Tag, rw models.GalleryReaderWriter) error {
 	t := getTagTaggers(p, aliases)
 
 	for _, tt := range t {
 		if err := tt.tagGalleries(paths, rw, func(subjectID, otherID int) (bool, error) {
-			return gallery.AddTag(rw, otherID, subjectID)
+			return addTagWithAncestors(subjectID, ancestors, func(tagID int) (bool, error) {
+				return gallery.AddTag(rw, otherID, tagID)
+			})
 		}); err != nil {
 			return err
 		}
@@ -8,6 +8,7 @@ import (
 	"github.com/stashapp/stash/pkg/models/mocks"
 	"github.com/stashapp/stash/pkg/scene"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 type testTagCase struct {
@@ -137,7 +138,7 @@ func testTagScenes(t *testing.T, tc testTagCase) {
 		mockSceneReader.On("UpdateTags", sceneID, []int{tagID}).Return(nil).Once()
 	}
 
-	err := TagScenes(&tag, nil, aliases, mockSceneReader)
+	err := TagScenes(&tag, nil, aliases, nil, mockSceneReader)
 
 	assert := assert.New(t)
 
@@ -225,7 +226,7 @@ func testTagImages(t *testing.T, tc testTagCase) {
 		mockImageReader.On("UpdateTags", imageID, []int{tagID}).Return(nil).Once()
 	}
 
-	err := TagImages(&tag, nil, aliases, mockImageReader)
+	err := TagImages(&tag, nil, aliases, nil, mockImageReader)
 
 	assert := assert.New(t)
 
@@ -312,10 +313,65 @@ func testTagGalleries(t *testing.T, tc testTagCase) {
 		mockGalleryReader.On("UpdateTags", galleryID, []int{tagID}).Return(nil).Once()
 	}
 
-	err := TagGalleries(&tag, nil, aliases, mockGalleryReader)
+	err := TagGalleries(&tag, nil, aliases, nil, mockGalleryReader)
 
 	assert := assert.New(t)
 
 	assert.Nil(err)
 	mockGalleryReader.AssertExpectations(t)
 }
+
+// mockTagHierarchy sets up a hierarchy where tag 1 is a child of tags 2 and
+// 3, tag 2 is a child of tag 3 and tag 3 is a child of tag 1, forming a
+// cycle.
+func mockTagHierarchy(tagReader *mocks.TagReaderWriter) {
+	tag2 := &models.Tag{ID: 2, Name: "parent"}
+	tag3 := &models.Tag{ID: 3, Name: "grandparent"}
+	tag1 := &models.Tag{ID: 1, Name: "child"}
+
+	tagReader.On("FindByChildTagID", 1).Return([]*models.Tag{tag2, tag3}, nil)
+	tagReader.On("FindByChildTagID", 2).Return([]*models.Tag{tag3}, nil)
+	tagReader.On("FindByChildTagID", 3).Return([]*models.Tag{tag1}, nil)
+}
+
+func TestTagAncestors(t *testing.T) {
+	tagReader := &mocks.TagReaderWriter{}
+	mockTagHierarchy(tagReader)
+
+	ancestors, err := TagAncestors(1, tagReader)
+
+	assert := assert.New(t)
+	assert.Nil(err)
+
+	var ids []int
+	for _, a := range ancestors {
+		ids = append(ids, a.ID)
+	}
+	// each ancestor is returned once and the cycle back to the tag is ignored
+	assert.Equal([]int{2, 3}, ids)
+}
+
+func TestTagScenesWithAncestors(t *testing.T) {
+	const (
+		sceneID  = 1
+		tagID    = 1
+		parentID = 2
+	)
+
+	mockSceneReader := &mocks.SceneReaderWriter{}
+	mockSceneReader.On("Query", mock.Anything).Return(mocks.SceneQueryResult([]*models.Scene{
+		{ID: sceneID, Path: "child.mp4"},
+	}, 1), nil).Once()
+
+	// the scene already has the parent tag, so it is not applied twice
+	mockSceneReader.On("GetTagIDs", sceneID).Return([]int{parentID}, nil)
+	mockSceneReader.On("UpdateTags", sceneID, []int{parentID, tagID}).Return(nil).Once()
+
+	tag := models.Tag{ID: tagID, Name: "child"}
+	ancestors := []*models.Tag{{ID: parentID, Name: "parent"}}
+
+	err := TagScenes(&tag, nil, nil, ancestors, mockSceneReader)
+
+	assert.Nil(t, err)
+	mockSceneReader.AssertExpectations(t)
+}
@@ -26,6 +26,25 @@ type tagger struct {
 	Type string
 	Name string
 	Path string
+
+	// PathMatcher matches the names of performers, studios and tags against
+	// Path. Names are matched exactly if it is not set.
+	PathMatcher match.PathMatcher
+}
+
+// matchPerformers returns the performers whose name matches the path.
+func (t *tagger) matchPerformers(performerReader models.PerformerReader) ([]*models.Performer, error) {
+	return t.PathMatcher.PathToPerformers(t.Path, performerReader)
+}
+
+// matchStudio returns the studio whose name or aliases match the path.
+func (t *tagger) matchStudio(studioReader models.StudioReader) (*models.Studio, error) {
+	return t.PathMatcher.PathToStudio(t.Path, studioReader)
+}
+
+// matchTags returns the tags whose name or aliases match the path.
+func (t *tagger) matchTags(tagReader models.TagReader) ([]*models.Tag, error) {
+	return t.PathMatcher.PathToTags(t.Path, tagReader)
 }
 
 type addLinkFunc func(subjectID, otherID int) (bool, error)
@@ -39,66 +58,90 @@ func (t *tagger) addLog(otherType, otherName string) {
 }
 
 func (t *tagger) tagPerformers(performerReader models.PerformerReader, addFunc addLinkFunc) error {
-	others, err := match.PathToPerformers(t.Path, performerReader)
+	others, err := t.matchPerformers(performerReader)
 	if err != nil {
 		return err
 	}
 
+	_, err = t.addPerformers(others, addFunc)
+	return err
+}
+
+// addPerformers adds the performers, returning true if any were added.
+func (t *tagger) addPerformers(others []*models.Performer, addFunc addLinkFunc) (bool, error) {
+	ret := false
 	for _, p := range others {
 		added, err := addFunc(t.ID, p.ID)
 
 		if err != nil {
-			return t.addError("performer", p.Name.String, err)
+			return ret, t.addError("performer", p.Name.String, err)
 		}
 
 		if added {
 			t.addLog("performer", p.Name.String)
+			ret = true
 		}
 	}
 
-	return nil
+	return ret, nil
 }
 
 func (t *tagger) tagStudios(studioReader models.StudioReader, addFunc addLinkFunc) error {
-	studio, err := match.PathToStudio(t.Path, studioReader)
+	studio, err := t.matchStudio(studioReader)
 	if err != nil {
 		return err
 	}
 
-	if studio != nil {
-		added, err := addFunc(t.ID, studio.ID)
+	_, err = t.addStudio(studio, addFunc)
+	return err
+}
 
-		if err != nil {
-			return t.addError("studio", studio.Name.String, err)
-		}
+// addStudio adds the studio if not nil, returning true if it was added.
+func (t *tagger) addStudio(studio *models.Studio, addFunc addLinkFunc) (bool, error) {
+	if studio == nil {
+		return false, nil
+	}
 
-		if added {
-			t.addLog("studio", studio.Name.String)
-		}
+	added, err := addFunc(t.ID, studio.ID)
+
+	if err != nil {
+		return false, t.addError("studio", studio.Name.String, err)
 	}
 
-	return nil
+	if added {
+		t.addLog("studio", studio.Name.String)
+	}
+
+	return added, nil
 }
 
 func (t *tagger) tagTags(tagReader models.TagReader, addFunc addLinkFunc) error {
-	others, err := match.PathToTags(t.Path, tagReader)
+	others, err := t.matchTags(tagReader)
 	if err != nil {
 		return err
 	}
 
+	_, err = t.addTags(others, addFunc)
+	return err
+}
+
+// addTags adds the tags, returning true if any were added.
+func (t *tagger) addTags(others []*models.Tag, addFunc addLinkFunc) (bool, error) {
+	ret := false
 	for _, p := range others {
 		added, err := addFunc(t.ID, p.ID)
 
 		if err != nil {
-			return t.addError("tag", p.Name, err)
+			return ret, t.addError("tag", p.Name, err)
 		}
 
 		if added {
 			t.addLog("tag", p.Name)
+			ret = true
 		}
 	}
 
-	return nil
+	return ret, nil
 }
 
 func (t *tagger) tagScenes(paths []string, sceneReader models.SceneReader, addFunc addLinkFunc) error {
@@ -0,0 +1,52 @@
+package autotag
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene"
+)
+
+// UnmatchedScenes returns the scenes that have no objects of the provided
+// type, such as those that autotag could not match to any performer. If
+// paths is not empty, only scenes within the paths are included. Returns
+// the page of scenes requested by findFilter and the total count.
+func UnmatchedScenes(qb scene.Queryer, unmatchedType models.AutoTagUnmatchedType, paths []string, findFilter *models.FindFilterType) (*models.AutoTagUnmatchedResult, error) {
+	var isMissing string
+	switch unmatchedType {
+	case models.AutoTagUnmatchedTypePerformers:
+		isMissing = "performers"
+	case models.AutoTagUnmatchedTypeStudio:
+		isMissing = "studio"
+	case models.AutoTagUnmatchedTypeTags:
+		isMissing = "tags"
+	default:
+		return nil, fmt.Errorf("invalid unmatched type: %s", unmatchedType)
+	}
+
+	sceneFilter := &models.SceneFilterType{
+		IsMissing: &isMissing,
+	}
+	if len(paths) > 0 {
+		sceneFilter.And = scene.FilterFromPaths(paths)
+	}
+
+	scenes, count, err := scene.QueryWithCount(qb, sceneFilter, findFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.AutoTagUnmatchedResult{
+		Count:  count,
+		Scenes: []*models.AutoTagUnmatchedScene{},
+	}
+	for _, s := range scenes {
+		ret.Scenes = append(ret.Scenes, &models.AutoTagUnmatchedScene{
+			ID:   strconv.Itoa(s.ID),
+			Path: s.Path,
+		})
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,364 @@
+-- Baseline schema at schema version 39, applied to new databases instead of
+-- migrations 1 to 39. Generated from a database migrated to version 39, so
+-- that it is identical to the schema created by the migrations.
+CREATE TABLE `tags` (
+  `id` integer not null primary key autoincrement,
+  `name` varchar(255),
+  `created_at` datetime not null,
+  `updated_at` datetime not null
+);
+CREATE TABLE `studios` (
+  `id` integer not null primary key autoincrement,
+  `checksum` varchar(255) not null,
+  `name` varchar(255),
+  `url` varchar(255),
+  `parent_id` integer DEFAULT NULL CHECK ( id IS NOT parent_id ) REFERENCES studios(id) on delete set null,
+  `created_at` datetime not null,
+  `updated_at` datetime not null
+, `details` text, `rating` tinyint);
+CREATE TABLE `performers` (
+  `id` integer not null primary key autoincrement,
+  `checksum` varchar(255) not null,
+  `name` varchar(255),
+  `gender` varchar(20),
+  `url` varchar(255),
+  `twitter` varchar(255),
+  `instagram` varchar(255),
+  `birthdate` date,
+  `ethnicity` varchar(255),
+  `country` varchar(255),
+  `eye_color` varchar(255),
+  `height` varchar(255),
+  `measurements` varchar(255),
+  `fake_tits` varchar(255),
+  `career_length` varchar(255),
+  `tattoos` varchar(255),
+  `piercings` varchar(255),
+  `aliases` varchar(255),
+  `favorite` boolean not null default '0',
+  `created_at` datetime not null,
+  `updated_at` datetime not null
+, `details` text, `death_date` date, `hair_color` varchar(255), `weight` integer, `rating` tinyint);
+CREATE TABLE `movies` (
+  `id` integer not null primary key autoincrement,
+  `name` varchar(255) not null,
+  `aliases` varchar(255),
+  `duration` integer,
+  `date` date,
+  `rating` tinyint,
+  `studio_id` integer,
+  `director` varchar(255),
+  `synopsis` text,
+  `checksum` varchar(255) not null,
+  `url` varchar(255),
+  `created_at` datetime not null,
+  `updated_at` datetime not null,
+  foreign key(`studio_id`) references `studios`(`id`) on delete set null
+);
+CREATE TABLE `scraped_items` (
+  `id` integer not null primary key autoincrement,
+  `title` varchar(255),
+  `description` text,
+  `url` varchar(255),
+  `date` date,
+  `rating` varchar(255),
+  `tags` varchar(510),
+  `models` varchar(510),
+  `episode` integer,
+  `gallery_filename` varchar(255),
+  `gallery_url` varchar(510),
+  `video_filename` varchar(255),
+  `video_url` varchar(255),
+  `studio_id` integer,
+  `created_at` datetime not null,
+  `updated_at` datetime not null,
+  foreign key(`studio_id`) references `studios`(`id`)
+);
+CREATE TABLE `performers_image` (
+  `performer_id` integer,
+  `image` blob not null,
+  foreign key(`performer_id`) references `performers`(`id`) on delete CASCADE
+);
+CREATE TABLE `studios_image` (
+  `studio_id` integer,
+  `image` blob not null,
+  foreign key(`studio_id`) references `studios`(`id`) on delete CASCADE
+);
+CREATE TABLE `movies_images` (
+  `movie_id` integer,
+  `front_image` blob not null,
+  `back_image` blob,
+  foreign key(`movie_id`) references `movies`(`id`) on delete CASCADE
+);
+CREATE TABLE `tags_image` (
+  `tag_id` integer,
+  `image` blob not null,
+  foreign key(`tag_id`) references `tags`(`id`) on delete CASCADE
+);
+CREATE TABLE `scenes` (
+  `id` integer not null primary key autoincrement,
+  `path` varchar(510) not null,
+  -- nullable
+  `checksum` varchar(255),
+  -- add oshash
+  `oshash` varchar(255),
+  `title` varchar(255),
+  `details` text,
+  `url` varchar(255),
+  `date` date,
+  `rating` tinyint,
+  `size` varchar(255),
+  `duration` float,
+  `video_codec` varchar(255),
+  `audio_codec` varchar(255),
+  `width` tinyint,
+  `height` tinyint,
+  `framerate` float,
+  `bitrate` integer,
+  `studio_id` integer,
+  `o_counter` tinyint not null default 0,
+  `format` varchar(255),
+  `created_at` datetime not null,
+  `updated_at` datetime not null, `file_mod_time` datetime, `organized` boolean not null default '0', `phash` blob, `interactive` boolean not null default '0', `interactive_speed` int, `deleted_at` datetime, `screenshot_at` real, `hash_algorithm` varchar(10), `resume_time` float,
+  foreign key(`studio_id`) references `studios`(`id`) on delete SET NULL,
+  -- add check to ensure at least one hash is set
+  CHECK (`checksum` is not null or `oshash` is not null)
+);
+CREATE TABLE `performers_scenes` (
+  `performer_id` integer,
+  `scene_id` integer,
+  foreign key(`performer_id`) references `performers`(`id`),
+  foreign key(`scene_id`) references `scenes`(`id`)
+);
+CREATE TABLE `scene_markers` (
+  `id` integer not null primary key autoincrement,
+  `title` varchar(255) not null,
+  `seconds` float not null,
+  `primary_tag_id` integer not null,
+  `scene_id` integer,
+  `created_at` datetime not null,
+  `updated_at` datetime not null,
+  foreign key(`primary_tag_id`) references `tags`(`id`),
+  foreign key(`scene_id`) references `scenes`(`id`)
+);
+CREATE TABLE `scene_markers_tags` (
+  `scene_marker_id` integer,
+  `tag_id` integer,
+  foreign key(`scene_marker_id`) references `scene_markers`(`id`) on delete CASCADE,
+  foreign key(`tag_id`) references `tags`(`id`)
+);
+CREATE TABLE `scenes_tags` (
+  `scene_id` integer,
+  `tag_id` integer,
+  foreign key(`scene_id`) references `scenes`(`id`) on delete CASCADE,
+  foreign key(`tag_id`) references `tags`(`id`)
+);
+CREATE TABLE `movies_scenes` (
+  `movie_id` integer,
+  `scene_id` integer,
+  `scene_index` tinyint,
+  foreign key(`movie_id`) references `movies`(`id`) on delete cascade,
+  foreign key(`scene_id`) references `scenes`(`id`) on delete cascade
+);
+CREATE TABLE `scenes_cover` (
+  `scene_id` integer,
+  `cover` blob not null,
+  foreign key(`scene_id`) references `scenes`(`id`) on delete CASCADE
+);
+CREATE TABLE `images` (
+  `id` integer not null primary key autoincrement,
+  `path` varchar(510) not null,
+  `checksum` varchar(255) not null,
+  `title` varchar(255),
+  `rating` tinyint,
+  `size` integer,
+  `width` tinyint,
+  `height` tinyint,
+  `studio_id` integer,
+  `o_counter` tinyint not null default 0,
+  `created_at` datetime not null,
+  `updated_at` datetime not null, `file_mod_time` datetime, `organized` boolean not null default '0', `date_taken` datetime, `camera_make` varchar(255), `camera_model` varchar(255), `gps_latitude` real, `gps_longitude` real,
+  foreign key(`studio_id`) references `studios`(`id`) on delete SET NULL
+);
+CREATE TABLE `performers_images` (
+  `performer_id` integer,
+  `image_id` integer,
+  foreign key(`performer_id`) references `performers`(`id`) on delete CASCADE,
+  foreign key(`image_id`) references `images`(`id`) on delete CASCADE
+);
+CREATE TABLE `images_tags` (
+  `image_id` integer,
+  `tag_id` integer,
+  foreign key(`image_id`) references `images`(`id`) on delete CASCADE,
+  foreign key(`tag_id`) references `tags`(`id`) on delete CASCADE
+);
+CREATE TABLE `scene_stash_ids` (
+  `scene_id` integer,
+  `endpoint` varchar(255),
+  `stash_id` varchar(36),
+  foreign key(`scene_id`) references `scenes`(`id`) on delete CASCADE
+);
+CREATE TABLE `performer_stash_ids` (
+  `performer_id` integer,
+  `endpoint` varchar(255),
+  `stash_id` varchar(36),
+  foreign key(`performer_id`) references `performers`(`id`) on delete CASCADE
+);
+CREATE TABLE `studio_stash_ids` (
+  `studio_id` integer,
+  `endpoint` varchar(255),
+  `stash_id` varchar(36),
+  foreign key(`studio_id`) references `studios`(`id`) on delete CASCADE
+);
+CREATE TABLE `galleries` (
+  `id` integer not null primary key autoincrement,
+  `path` varchar(510),
+  `checksum` varchar(255) not null,
+  `zip` boolean not null default '0',
+  `title` varchar(255),
+  `url` varchar(255),
+  `date` date,
+  `details` text,
+  `studio_id` integer,
+  `rating` tinyint,
+  `file_mod_time` datetime,
+  `organized` boolean not null default '0',
+  `created_at` datetime not null,
+  `updated_at` datetime not null, `cover_image_id` integer REFERENCES `images`(`id`) ON DELETE SET NULL,
+  foreign key(`studio_id`) references `studios`(`id`) on delete SET NULL
+);
+CREATE TABLE `scenes_galleries` (
+  `scene_id` integer,
+  `gallery_id` integer,
+  foreign key(`scene_id`) references `scenes`(`id`) on delete CASCADE,
+  foreign key(`gallery_id`) references `galleries`(`id`) on delete CASCADE
+);
+CREATE TABLE `galleries_images` (
+  `gallery_id` integer,
+  `image_id` integer,
+  foreign key(`gallery_id`) references `galleries`(`id`) on delete CASCADE,
+  foreign key(`image_id`) references `images`(`id`) on delete CASCADE
+);
+CREATE TABLE `performers_galleries` (
+  `performer_id` integer,
+  `gallery_id` integer,
+  foreign key(`performer_id`) references `performers`(`id`) on delete CASCADE,
+  foreign key(`gallery_id`) references `galleries`(`id`) on delete CASCADE
+);
+CREATE TABLE `galleries_tags` (
+  `gallery_id` integer,
+  `tag_id` integer,
+  foreign key(`gallery_id`) references `galleries`(`id`) on delete CASCADE,
+  foreign key(`tag_id`) references `tags`(`id`) on delete CASCADE
+);
+CREATE TABLE `performers_tags` (
+  `performer_id` integer NOT NULL,
+  `tag_id` integer NOT NULL,
+  foreign key(`performer_id`) references `performers`(`id`) on delete CASCADE,
+  foreign key(`tag_id`) references `tags`(`id`) on delete CASCADE
+);
+CREATE TABLE `tag_aliases` (
+  `tag_id` integer,
+  `alias` varchar(255) NOT NULL,
+  foreign key(`tag_id`) references `tags`(`id`) on delete CASCADE
+);
+CREATE TABLE `saved_filters` (
+  `id` integer not null primary key autoincrement,
+  `name` varchar(510) not null,
+  `mode` varchar(255) not null,
+  `filter` blob not null
+);
+CREATE TABLE tags_relations (
+  parent_id integer,
+  child_id integer,
+  primary key (parent_id, child_id),
+  foreign key (parent_id) references tags(id) on delete cascade,
+  foreign key (child_id) references tags(id) on delete cascade
+);
+CREATE TABLE `studio_aliases` (
+  `studio_id` integer,
+  `alias` varchar(255) NOT NULL,
+  foreign key(`studio_id`) references `studios`(`id`) on delete CASCADE
+);
+CREATE TABLE `quarantined_files` (
+  `id` integer not null primary key autoincrement,
+  `path` varchar(510) not null,
+  `reason` text not null,
+  `created_at` datetime not null
+);
+CREATE TABLE `scene_subtitles` (
+  `scene_id` integer not null,
+  `language_code` varchar(255) not null,
+  `subtitle_type` varchar(255) not null,
+  `path` varchar(510) not null,
+  foreign key(`scene_id`) references `scenes`(`id`) on delete CASCADE
+);
+CREATE TABLE `scan_checkpoints` (
+  `id` integer not null primary key autoincrement,
+  `paths` text not null,
+  `last_path` text not null,
+  `updated_at` datetime not null
+);
+CREATE TABLE `scan_snapshots` (
+  `id` integer not null primary key autoincrement,
+  `path` varchar(510) not null,
+  `data` blob not null,
+  `created_at` datetime not null
+);
+CREATE INDEX `index_tags_on_name` on `tags` (`name`);
+CREATE UNIQUE INDEX `studios_checksum_unique` on `studios` (`checksum`);
+CREATE INDEX `index_studios_on_name` on `studios` (`name`);
+CREATE INDEX `index_studios_on_checksum` on `studios` (`checksum`);
+CREATE UNIQUE INDEX `performers_checksum_unique` on `performers` (`checksum`);
+CREATE INDEX `index_performers_on_name` on `performers` (`name`);
+CREATE UNIQUE INDEX `movies_name_unique` on `movies` (`name`);
+CREATE UNIQUE INDEX `movies_checksum_unique` on `movies` (`checksum`);
+CREATE INDEX `index_movies_on_studio_id` on `movies` (`studio_id`);
+CREATE INDEX `index_scraped_items_on_studio_id` on `scraped_items` (`studio_id`);
+CREATE UNIQUE INDEX `index_performer_image_on_performer_id` on `performers_image` (`performer_id`);
+CREATE UNIQUE INDEX `index_studio_image_on_studio_id` on `studios_image` (`studio_id`);
+CREATE UNIQUE INDEX `index_movie_images_on_movie_id` on `movies_images` (`movie_id`);
+CREATE UNIQUE INDEX `index_tag_image_on_tag_id` on `tags_image` (`tag_id`);
+CREATE UNIQUE INDEX `scenes_path_unique` on `scenes` (`path`);
+CREATE UNIQUE INDEX `scenes_checksum_unique` on `scenes` (`checksum`);
+CREATE UNIQUE INDEX `scenes_oshash_unique` on `scenes` (`oshash`);
+CREATE INDEX `index_scenes_on_studio_id` on `scenes` (`studio_id`);
+CREATE INDEX `index_performers_scenes_on_scene_id` on `performers_scenes` (`scene_id`);
+CREATE INDEX `index_performers_scenes_on_performer_id` on `performers_scenes` (`performer_id`);
+CREATE INDEX `index_scene_markers_on_scene_id` on `scene_markers` (`scene_id`);
+CREATE INDEX `index_scene_markers_on_primary_tag_id` on `scene_markers` (`primary_tag_id`);
+CREATE INDEX `index_scene_markers_tags_on_tag_id` on `scene_markers_tags` (`tag_id`);
+CREATE INDEX `index_scene_markers_tags_on_scene_marker_id` on `scene_markers_tags` (`scene_marker_id`);
+CREATE INDEX `index_scenes_tags_on_tag_id` on `scenes_tags` (`tag_id`);
+CREATE INDEX `index_scenes_tags_on_scene_id` on `scenes_tags` (`scene_id`);
+CREATE INDEX `index_movies_scenes_on_movie_id` on `movies_scenes` (`movie_id`);
+CREATE INDEX `index_movies_scenes_on_scene_id` on `movies_scenes` (`scene_id`);
+CREATE UNIQUE INDEX `index_scene_covers_on_scene_id` on `scenes_cover` (`scene_id`);
+CREATE INDEX `index_images_on_studio_id` on `images` (`studio_id`);
+CREATE INDEX `index_performers_images_on_image_id` on `performers_images` (`image_id`);
+CREATE INDEX `index_performers_images_on_performer_id` on `performers_images` (`performer_id`);
+CREATE INDEX `index_images_tags_on_tag_id` on `images_tags` (`tag_id`);
+CREATE INDEX `index_images_tags_on_image_id` on `images_tags` (`image_id`);
+CREATE UNIQUE INDEX `galleries_path_unique` on `galleries` (`path`);
+CREATE UNIQUE INDEX `galleries_checksum_unique` on `galleries` (`checksum`);
+CREATE INDEX `index_galleries_on_studio_id` on `galleries` (`studio_id`);
+CREATE INDEX `index_scenes_galleries_on_scene_id` on `scenes_galleries` (`scene_id`);
+CREATE INDEX `index_scenes_galleries_on_gallery_id` on `scenes_galleries` (`gallery_id`);
+CREATE INDEX `index_galleries_images_on_image_id` on `galleries_images` (`image_id`);
+CREATE INDEX `index_galleries_images_on_gallery_id` on `galleries_images` (`gallery_id`);
+CREATE INDEX `index_performers_galleries_on_gallery_id` on `performers_galleries` (`gallery_id`);
+CREATE INDEX `index_performers_galleries_on_performer_id` on `performers_galleries` (`performer_id`);
+CREATE INDEX `index_galleries_tags_on_tag_id` on `galleries_tags` (`tag_id`);
+CREATE INDEX `index_galleries_tags_on_gallery_id` on `galleries_tags` (`gallery_id`);
+CREATE INDEX `index_performers_tags_on_tag_id` on `performers_tags` (`tag_id`);
+CREATE INDEX `index_performers_tags_on_performer_id` on `performers_tags` (`performer_id`);
+CREATE UNIQUE INDEX `tag_aliases_alias_unique` on `tag_aliases` (`alias`);
+CREATE UNIQUE INDEX `index_saved_filters_on_mode_name_unique` on `saved_filters` (`mode`, `name`);
+CREATE UNIQUE INDEX `studio_aliases_alias_unique` on `studio_aliases` (`alias`);
+CREATE UNIQUE INDEX `images_path_unique` ON `images` (`path`);
+CREATE INDEX `index_scenes_on_deleted_at` ON `scenes` (`deleted_at`);
+CREATE UNIQUE INDEX `index_quarantined_files_on_path_unique` on `quarantined_files` (`path`);
+CREATE INDEX `index_scene_subtitles_on_scene_id` on `scene_subtitles` (`scene_id`);
+CREATE UNIQUE INDEX `index_scene_subtitles_on_path_unique` on `scene_subtitles` (`scene_id`, `path`);
+CREATE UNIQUE INDEX `index_scan_checkpoints_on_paths_unique` on `scan_checkpoints` (`paths`);
+CREATE UNIQUE INDEX `index_scan_snapshots_on_path_unique` on `scan_snapshots` (`path`);
@@ -5,6 +5,7 @@ import (
 	"embed"
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"sync"
 	"time"
@@ -21,14 +22,30 @@ import (
 )
 
 var DB *sqlx.DB
+
+// ReadDB is a connection pool used only for read operations. Connections in
+// this pool are opened in query-only mode so that, with WAL journaling, long
+// running reads do not block writes on DB.
+var ReadDB *sqlx.DB
 var WriteMu sync.Mutex
 var dbPath string
-var appSchemaVersion uint = 29
+var appSchemaVersion uint = 47
 var databaseSchemaVersion uint
 
+// baselineVersion is the schema version of the baseline schema. New
+// databases are created with the baseline schema, then migrated from
+// baselineVersion to appSchemaVersion.
+var baselineVersion uint = 39
+
 //go:embed migrations/*.sql
 var migrationsBox embed.FS
 
+// migrationsFS is the file system containing the migrations directory.
+var migrationsFS fs.FS = migrationsBox
+
+//go:embed baseline.sql
+var baselineSchema string
+
 var (
 	// ErrMigrationNeeded indicates that a database migration is needed
 	// before the database can be initialized
@@ -50,14 +67,25 @@ func Ready() error {
 	return nil
 }
 
+// ReadConn returns the connection pool to be used for read-only operations.
+// Falls back to DB if a separate read pool is not open.
+func ReadConn() *sqlx.DB {
+	if ReadDB != nil {
+		return ReadDB
+	}
+
+	return DB
+}
+
 func init() {
 	// register custom driver with regexp function
 	registerCustomDriver()
 }
 
 // Initialize initializes the database. If the database is new, then it
-// performs a full migration to the latest schema version. Otherwise, any
-// necessary migrations must be run separately using RunMigrations.
+// creates the baseline schema and migrates it to the latest schema version.
+// Otherwise, any necessary migrations must be run separately using
+// RunMigrations.
 // Returns true if the database is new.
 func Initialize(databasePath string) error {
 	dbPath = databasePath
@@ -88,6 +116,11 @@ func Initialize(databasePath string) error {
 	const disableForeignKeys = false
 	DB = open(databasePath, disableForeignKeys)
 
+	if ReadDB != nil {
+		ReadDB.Close()
+	}
+	ReadDB = openRead(databasePath)
+
 	if err := runCustomMigrations(); err != nil {
 		return err
 	}
@@ -99,6 +132,14 @@ func Close() error {
 	WriteMu.Lock()
 	defer WriteMu.Unlock()
 
+	if ReadDB != nil {
+		if err := ReadDB.Close(); err != nil {
+			return err
+		}
+
+		ReadDB = nil
+	}
+
 	if DB != nil {
 		if err := DB.Close(); err != nil {
 			return err
@@ -128,7 +169,30 @@ func open(databasePath string, disableForeignKeys bool) *sqlx.DB {
 	return conn
 }
 
+// openRead opens a query-only connection pool to the database. Writes
+// attempted using this pool will fail.
+func openRead(databasePath string) *sqlx.DB {
+	url := "file:" + databasePath + "?_journal=WAL&_query_only=true&_fk=true"
+
+	conn, err := sqlx.Open(sqlite3Driver, url)
+	if err != nil {
+		logger.Fatalf("db.Open(): %q\n", err)
+	}
+	conn.SetMaxOpenConns(25)
+	conn.SetMaxIdleConns(4)
+	conn.SetConnMaxLifetime(30 * time.Second)
+
+	return conn
+}
+
 func Reset(databasePath string) error {
+	if ReadDB != nil {
+		if err := ReadDB.Close(); err != nil {
+			return errors.New("Error closing database: " + err.Error())
+		}
+		ReadDB = nil
+	}
+
 	err := DB.Close()
 
 	if err != nil {
@@ -140,15 +204,8 @@ func Reset(databasePath string) error {
 		return errors.New("Error removing database: " + err.Error())
 	}
 
-	// remove the -shm, -wal files ( if they exist )
-	walFiles := []string{databasePath + "-shm", databasePath + "-wal"}
-	for _, wf := range walFiles {
-		if exists, _ := utils.FileExists(wf); exists {
-			err = os.Remove(wf)
-			if err != nil {
-				return errors.New("Error removing database: " + err.Error())
-			}
-		}
+	if err := removeWALFiles(databasePath); err != nil {
+		return errors.New("Error removing database: " + err.Error())
 	}
 
 	if err := Initialize(databasePath); err != nil {
@@ -179,9 +236,66 @@ func Backup(db *sqlx.DB, backupPath string) error {
 	return nil
 }
 
+// removeWALFiles removes the -shm and -wal files of the database, if they
+// exist.
+func removeWALFiles(databasePath string) error {
+	walFiles := []string{databasePath + "-shm", databasePath + "-wal"}
+	for _, wf := range walFiles {
+		if exists, _ := utils.FileExists(wf); exists {
+			if err := os.Remove(wf); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RestoreFromBackup replaces the database with the backup at backupPath. The
+// database is closed before it is replaced, then initialized from the
+// restored file.
 func RestoreFromBackup(backupPath string) error {
+	if err := Close(); err != nil {
+		return fmt.Errorf("error closing database: %w", err)
+	}
+
 	logger.Infof("Restoring backup database %s into %s", backupPath, dbPath)
-	return os.Rename(backupPath, dbPath)
+	if err := os.Rename(backupPath, dbPath); err != nil {
+		return err
+	}
+
+	// the journal files of the replaced database must not be applied to the
+	// restored file
+	if err := removeWALFiles(dbPath); err != nil {
+		return fmt.Errorf("error removing database journal: %w", err)
+	}
+
+	return Initialize(dbPath)
+}
+
+// CheckWritable returns an error if the database cannot be written. The
+// write is made in a transaction that is rolled back.
+func CheckWritable() error {
+	if err := Ready(); err != nil {
+		return err
+	}
+
+	WriteMu.Lock()
+	defer WriteMu.Unlock()
+
+	tx, err := DB.Beginx()
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.Exec("CREATE TABLE self_test (id INTEGER)"); err != nil {
+		return fmt.Errorf("error writing to database: %w", err)
+	}
+
+	return nil
 }
 
 // Migrate the database
@@ -206,7 +320,7 @@ func Version() uint {
 }
 
 func getMigrate() (*migrate.Migrate, error) {
-	migrations, err := iofs.New(migrationsBox, "migrations")
+	migrations, err := iofs.New(migrationsFS, "migrations")
 	if err != nil {
 		panic(err.Error())
 	}
@@ -248,6 +362,18 @@ func RunMigrations() error {
 	defer m.Close()
 
 	databaseSchemaVersion, _, _ = m.Version()
+	if databaseSchemaVersion == 0 {
+		applied, err := applyBaseline()
+		if err != nil {
+			return fmt.Errorf("error applying baseline schema: %w", err)
+		}
+
+		if applied {
+			logger.Infof("Created database with baseline schema version %d", baselineVersion)
+			databaseSchemaVersion, _, _ = m.Version()
+		}
+	}
+
 	stepNumber := appSchemaVersion - databaseSchemaVersion
 	if stepNumber != 0 {
 		logger.Infof("Migrating database from version %d to %d", databaseSchemaVersion, appSchemaVersion)
@@ -273,6 +399,63 @@ func RunMigrations() error {
 	return nil
 }
 
+// applyBaseline creates the baseline schema if the database is empty, so
+// that new databases do not need to run every migration. Returns true if the
+// baseline schema was created. Databases that are not empty are left
+// unchanged, so that they are migrated incrementally.
+func applyBaseline() (bool, error) {
+	const disableForeignKeys = true
+	conn := open(dbPath, disableForeignKeys)
+	defer conn.Close()
+
+	empty, err := isEmpty(conn)
+	if err != nil || !empty {
+		return false, err
+	}
+
+	driver, err := sqlite3mig.WithInstance(conn.DB, &sqlite3mig.Config{})
+	if err != nil {
+		return false, err
+	}
+
+	// mark the version as dirty until the schema is created, so that a
+	// failure is detected in the same way as a failed migration
+	if err := driver.SetVersion(int(baselineVersion), true); err != nil {
+		return false, err
+	}
+
+	tx, err := conn.Begin()
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := tx.Exec(baselineSchema); err != nil {
+		_ = tx.Rollback()
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+
+	if err := driver.SetVersion(int(baselineVersion), false); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// isEmpty returns true if the database has no tables other than the
+// migration version table.
+func isEmpty(conn *sqlx.DB) (bool, error) {
+	var count int
+	if err := conn.Get(&count, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' AND name != 'schema_migrations'"); err != nil {
+		return false, err
+	}
+
+	return count == 0, nil
+}
+
 func registerCustomDriver() {
 	sql.Register(sqlite3Driver,
 		&sqlite3.SQLiteDriver{
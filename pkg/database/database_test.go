@@ -0,0 +1,389 @@
+//go:build integration
+// +build integration
+
+package database
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stashapp/stash/pkg/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// schema returns the schema of the database at path.
+func schema(t *testing.T, path string) []string {
+	t.Helper()
+
+	conn := open(path, true)
+	defer conn.Close()
+
+	var ret []string
+	if err := conn.Select(&ret, "SELECT type || ' ' || name || ': ' || sql FROM sqlite_master WHERE sql IS NOT NULL ORDER BY type, name"); err != nil {
+		t.Fatal(err)
+	}
+
+	return ret
+}
+
+// migrateTo migrates a new database at path to version using the
+// migrations.
+func migrateTo(t *testing.T, path string, version uint) {
+	t.Helper()
+
+	dbPath = path
+	m, err := getMigrate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if err := m.Steps(int(version)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// withBaselineMarker adds a table to the baseline schema for the duration of
+// the test, so that databases created from the baseline can be identified.
+func withBaselineMarker(t *testing.T) {
+	original := baselineSchema
+	baselineSchema += "CREATE TABLE baseline_marker (id integer);\n"
+	t.Cleanup(func() {
+		baselineSchema = original
+	})
+}
+
+func hasTable(t *testing.T, path string, name string) bool {
+	t.Helper()
+
+	conn := open(path, true)
+	defer conn.Close()
+
+	var count int
+	if err := conn.Get(&count, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", name); err != nil {
+		t.Fatal(err)
+	}
+
+	return count > 0
+}
+
+func TestBaselineMatchesMigrations(t *testing.T) {
+	dir := t.TempDir()
+
+	migrated := filepath.Join(dir, "migrated.sqlite")
+	migrateTo(t, migrated, baselineVersion)
+
+	baseline := filepath.Join(dir, "baseline.sqlite")
+	dbPath = baseline
+	applied, err := applyBaseline()
+	assert.Nil(t, err)
+	assert.True(t, applied)
+
+	assert.Nil(t, getDatabaseSchemaVersion())
+	assert.Equal(t, baselineVersion, Version())
+	assert.Equal(t, schema(t, migrated), schema(t, baseline))
+}
+
+func TestInitializeNewDatabaseUsesBaseline(t *testing.T) {
+	withBaselineMarker(t)
+
+	path := filepath.Join(t.TempDir(), "new.sqlite")
+	if err := Initialize(path); err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+
+	assert.Equal(t, appSchemaVersion, Version())
+	assert.True(t, hasTable(t, path, "baseline_marker"))
+}
+
+func TestRunMigrationsExistingDatabaseIsIncremental(t *testing.T) {
+	withBaselineMarker(t)
+
+	path := filepath.Join(t.TempDir(), "existing.sqlite")
+	migrateTo(t, path, appSchemaVersion-1)
+
+	if err := Initialize(path); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, NeedsMigration())
+
+	if err := RunMigrations(); err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+
+	assert.Equal(t, appSchemaVersion, Version())
+	assert.False(t, hasTable(t, path, "baseline_marker"))
+}
+
+func TestApplyBaselineNonEmptyDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.sqlite")
+	dbPath = path
+
+	conn := open(path, true)
+	if _, err := conn.Exec("CREATE TABLE scenes (id integer)"); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	applied, err := applyBaseline()
+	assert.Nil(t, err)
+	assert.False(t, applied)
+	assert.False(t, hasTable(t, path, "tags"))
+}
+
+// withMigration adds a migration containing sql to the migrations for the
+// duration of the test, incrementing the application schema version.
+func withMigration(t *testing.T, sql string) {
+	t.Helper()
+
+	originalFS := migrationsFS
+	originalVersion := appSchemaVersion
+	t.Cleanup(func() {
+		migrationsFS = originalFS
+		appSchemaVersion = originalVersion
+	})
+
+	overlay := fstest.MapFS{}
+	entries, err := fs.ReadDir(migrationsBox, "migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		name := "migrations/" + e.Name()
+		data, err := fs.ReadFile(migrationsBox, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		overlay[name] = &fstest.MapFile{Data: data}
+	}
+
+	appSchemaVersion++
+	overlay[fmt.Sprintf("migrations/%d_test.up.sql", appSchemaVersion)] = &fstest.MapFile{Data: []byte(sql)}
+	migrationsFS = overlay
+}
+
+func countRows(t *testing.T, path string, table string) int {
+	t.Helper()
+
+	conn := open(path, true)
+	defer conn.Close()
+
+	var count int
+	if err := conn.Get(&count, "SELECT COUNT(*) FROM "+table); err != nil {
+		t.Fatal(err)
+	}
+
+	return count
+}
+
+func TestVerifyMigration(t *testing.T) {
+	tests := []struct {
+		name      string
+		migration string
+		wantErr   bool
+	}{
+		{
+			"valid",
+			"CREATE TABLE verify_test (id integer);",
+			false,
+		},
+		{
+			"corrupt index",
+			`CREATE INDEX verify_test ON tags (name);
+PRAGMA writable_schema = ON;
+UPDATE sqlite_master SET sql = 'CREATE INDEX verify_test ON tags (created_at)' WHERE name = 'verify_test';
+PRAGMA writable_schema = OFF;`,
+			true,
+		},
+		{
+			"foreign key violation",
+			"INSERT INTO scenes_tags (scene_id, tag_id) VALUES (100, 100);",
+			true,
+		},
+		{
+			"lost rows",
+			"DELETE FROM tags WHERE name = 'tag 2';",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "verify.sqlite")
+			if err := Initialize(path); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := DB.Exec("INSERT INTO tags (name, created_at, updated_at) VALUES ('tag 1', '', ''), ('tag 2', '', '')"); err != nil {
+				t.Fatal(err)
+			}
+			if err := Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			previousVersion := appSchemaVersion
+			withMigration(t, tt.migration)
+			if err := Initialize(path); err != nil {
+				t.Fatal(err)
+			}
+			assert.True(t, NeedsMigration())
+
+			backupPath := path + ".backup"
+			if err := Backup(DB, backupPath); err != nil {
+				t.Fatal(err)
+			}
+			if err := RunMigrations(); err != nil {
+				t.Fatal(err)
+			}
+			defer Close()
+
+			err := VerifyMigration(backupPath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("VerifyMigration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+
+			// the database is restored to the pre-migration version
+			if err := RestoreFromBackup(backupPath); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, previousVersion, Version())
+			assert.True(t, NeedsMigration())
+			assert.False(t, hasTable(t, path, "verify_test"))
+			assert.Equal(t, 2, countRows(t, path, "tags"))
+			assert.Equal(t, 0, countRows(t, path, "scenes_tags"))
+
+			exists, _ := utils.FileExists(backupPath)
+			assert.False(t, exists)
+		})
+	}
+}
+
+// initializeWithTags initializes a new database at path containing two tags.
+func initializeWithTags(t *testing.T, path string) {
+	t.Helper()
+
+	if err := Initialize(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DB.Exec("INSERT INTO tags (name, created_at, updated_at) VALUES ('tag 1', '', ''), ('tag 2', '', '')"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func dbTagCount(t *testing.T) int {
+	t.Helper()
+
+	var count int
+	if err := DB.Get(&count, "SELECT COUNT(*) FROM tags"); err != nil {
+		t.Fatal(err)
+	}
+
+	return count
+}
+
+func TestRelocate(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.sqlite")
+	newPath := filepath.Join(dir, "new", "new.sqlite")
+	if err := utils.EnsureDir(filepath.Dir(newPath)); err != nil {
+		t.Fatal(err)
+	}
+
+	initializeWithTags(t, oldPath)
+	defer Close()
+
+	var updated []string
+	err := Relocate(newPath, func(path string) error {
+		updated = append(updated, path)
+		return nil
+	})
+
+	assert := assert.New(t)
+	if !assert.NoError(err) {
+		return
+	}
+	assert.Equal([]string{newPath}, updated)
+	assert.Equal(newPath, DatabasePath())
+	assert.Equal(2, dbTagCount(t))
+	assert.FileExists(oldPath)
+}
+
+func TestRelocateCopyFailure(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.sqlite")
+	newPath := filepath.Join(dir, "new.sqlite")
+
+	original := copyDatabaseFile
+	t.Cleanup(func() {
+		copyDatabaseFile = original
+	})
+	errCopy := errors.New("disk full")
+	copyDatabaseFile = func(src, dst string) error {
+		// leave a partial copy behind
+		if err := os.WriteFile(dst, []byte("partial"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return errCopy
+	}
+
+	initializeWithTags(t, oldPath)
+	defer Close()
+
+	updated := false
+	err := Relocate(newPath, func(path string) error {
+		updated = true
+		return nil
+	})
+
+	assert := assert.New(t)
+	assert.ErrorIs(err, errCopy)
+	assert.False(updated)
+	assert.NoFileExists(newPath)
+
+	// the database is reopened at the original path
+	assert.NoError(Ready())
+	assert.Equal(oldPath, DatabasePath())
+	assert.Equal(2, dbTagCount(t))
+}
+
+func TestRelocateUpdateFailure(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.sqlite")
+	newPath := filepath.Join(dir, "new.sqlite")
+
+	initializeWithTags(t, oldPath)
+	defer Close()
+
+	errUpdate := errors.New("read-only")
+	err := Relocate(newPath, func(path string) error {
+		return errUpdate
+	})
+
+	assert := assert.New(t)
+	assert.ErrorIs(err, errUpdate)
+	assert.NoFileExists(newPath)
+	assert.NoError(Ready())
+	assert.Equal(oldPath, DatabasePath())
+	assert.Equal(2, dbTagCount(t))
+}
+
+func TestCheckWritable(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "writable.sqlite")
+	if err := Initialize(path); err != nil {
+		t.Fatal(err)
+	}
+	defer Close()
+
+	assert.Nil(t, CheckWritable())
+
+	// the write is rolled back
+	assert.False(t, hasTable(t, path, "self_test"))
+}
@@ -0,0 +1,3 @@
+ALTER TABLE `scenes` ADD COLUMN `deleted_at` datetime;
+
+CREATE INDEX `index_scenes_on_deleted_at` ON `scenes` (`deleted_at`);
@@ -0,0 +1 @@
+ALTER TABLE `scenes` ADD COLUMN `screenshot_at` real;
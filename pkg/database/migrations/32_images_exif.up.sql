@@ -0,0 +1,5 @@
+ALTER TABLE `images` ADD COLUMN `date_taken` datetime;
+ALTER TABLE `images` ADD COLUMN `camera_make` varchar(255);
+ALTER TABLE `images` ADD COLUMN `camera_model` varchar(255);
+ALTER TABLE `images` ADD COLUMN `gps_latitude` real;
+ALTER TABLE `images` ADD COLUMN `gps_longitude` real;
@@ -0,0 +1 @@
+ALTER TABLE `galleries` ADD COLUMN `cover_image_id` integer REFERENCES `images`(`id`) ON DELETE SET NULL;
@@ -0,0 +1,8 @@
+CREATE TABLE `quarantined_files` (
+  `id` integer not null primary key autoincrement,
+  `path` varchar(510) not null,
+  `reason` text not null,
+  `created_at` datetime not null
+);
+
+CREATE UNIQUE INDEX `index_quarantined_files_on_path_unique` on `quarantined_files` (`path`);
@@ -0,0 +1,10 @@
+CREATE TABLE `scene_subtitles` (
+  `scene_id` integer not null,
+  `language_code` varchar(255) not null,
+  `subtitle_type` varchar(255) not null,
+  `path` varchar(510) not null,
+  foreign key(`scene_id`) references `scenes`(`id`) on delete CASCADE
+);
+
+CREATE INDEX `index_scene_subtitles_on_scene_id` on `scene_subtitles` (`scene_id`);
+CREATE UNIQUE INDEX `index_scene_subtitles_on_path_unique` on `scene_subtitles` (`scene_id`, `path`);
@@ -0,0 +1,3 @@
+ALTER TABLE `scenes` ADD COLUMN `hash_algorithm` varchar(10);
+
+UPDATE `scenes` SET `hash_algorithm` = CASE WHEN `checksum` IS NOT NULL THEN 'MD5' ELSE 'OSHASH' END;
@@ -0,0 +1,8 @@
+CREATE TABLE `scan_checkpoints` (
+  `id` integer not null primary key autoincrement,
+  `paths` text not null,
+  `last_path` text not null,
+  `updated_at` datetime not null
+);
+
+CREATE UNIQUE INDEX `index_scan_checkpoints_on_paths_unique` on `scan_checkpoints` (`paths`);
@@ -0,0 +1,8 @@
+CREATE TABLE `scan_snapshots` (
+  `id` integer not null primary key autoincrement,
+  `path` varchar(510) not null,
+  `data` blob not null,
+  `created_at` datetime not null
+);
+
+CREATE UNIQUE INDEX `index_scan_snapshots_on_path_unique` on `scan_snapshots` (`path`);
@@ -0,0 +1 @@
+ALTER TABLE `scenes` ADD COLUMN `resume_time` float;
@@ -0,0 +1 @@
+ALTER TABLE `scenes` ADD COLUMN `missing_at` datetime;
@@ -0,0 +1,8 @@
+ALTER TABLE `tags` ADD COLUMN `generate_aliases` boolean not null default '0';
+
+CREATE TABLE `tag_generated_aliases` (
+  `tag_id` integer,
+  `alias` varchar(255) NOT NULL,
+  foreign key(`tag_id`) references `tags`(`id`) on delete CASCADE,
+  PRIMARY KEY(`tag_id`, `alias`)
+);
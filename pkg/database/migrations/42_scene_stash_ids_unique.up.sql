@@ -0,0 +1,7 @@
+-- keep the first stash id of each endpoint for each scene
+DELETE FROM `scene_stash_ids` WHERE `rowid` NOT IN (
+  SELECT MIN(`rowid`) FROM `scene_stash_ids` GROUP BY `scene_id`, `endpoint`
+);
+
+CREATE UNIQUE INDEX `index_scene_stash_ids_on_scene_id_endpoint` on `scene_stash_ids` (`scene_id`, `endpoint`);
+CREATE INDEX `index_scene_stash_ids_on_stash_id` on `scene_stash_ids` (`stash_id`);
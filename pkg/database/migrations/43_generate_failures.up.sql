@@ -0,0 +1,16 @@
+CREATE TABLE `generate_failures` (
+  `id` integer not null primary key autoincrement,
+  `artifact` varchar(255) not null,
+  `scene_id` integer,
+  `scene_marker_id` integer,
+  `error` text not null,
+  `attempts` integer not null,
+  `retry_at` datetime not null,
+  `created_at` datetime not null,
+  `updated_at` datetime not null,
+  foreign key(`scene_id`) references `scenes`(`id`) on delete CASCADE,
+  foreign key(`scene_marker_id`) references `scene_markers`(`id`) on delete CASCADE
+);
+
+CREATE UNIQUE INDEX `index_generate_failures_on_artifact_scene_id` on `generate_failures` (`artifact`, `scene_id`) WHERE `scene_id` IS NOT NULL;
+CREATE UNIQUE INDEX `index_generate_failures_on_artifact_scene_marker_id` on `generate_failures` (`artifact`, `scene_marker_id`) WHERE `scene_marker_id` IS NOT NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE `scenes` ADD COLUMN `preview_segments` integer;
+ALTER TABLE `scenes` ADD COLUMN `preview_segment_duration` float;
+ALTER TABLE `scenes` ADD COLUMN `preview_exclude_start` varchar(255);
+ALTER TABLE `scenes` ADD COLUMN `preview_exclude_end` varchar(255);
+ALTER TABLE `scenes` ADD COLUMN `sprite_count` integer;
@@ -0,0 +1,2 @@
+ALTER TABLE `scenes` ADD COLUMN `play_count` integer not null default 0;
+ALTER TABLE `scenes` ADD COLUMN `last_played_at` datetime;
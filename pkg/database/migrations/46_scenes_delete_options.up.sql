@@ -0,0 +1,6 @@
+ALTER TABLE `scenes` ADD COLUMN `delete_file` boolean not null default '0';
+ALTER TABLE `scenes` ADD COLUMN `delete_generated` boolean not null default '0';
+
+-- scenes deleted before the options were stored had their generated files
+-- deleted when purged
+UPDATE `scenes` SET `delete_generated` = 1 WHERE `deleted_at` IS NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE `scenes` ADD COLUMN `sidecar_mod_time` datetime;
@@ -0,0 +1,146 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// copyDatabaseFile copies the database file at src to dst. It is a variable
+// so that tests can simulate a failed copy.
+var copyDatabaseFile = copyFile
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// verifyCopy returns an error if the database file at dst does not match the
+// file at src, or fails the sqlite integrity check.
+func verifyCopy(src, dst string) error {
+	srcChecksum, err := utils.MD5FromFilePath(src)
+	if err != nil {
+		return err
+	}
+
+	dstChecksum, err := utils.MD5FromFilePath(dst)
+	if err != nil {
+		return err
+	}
+
+	if srcChecksum != dstChecksum {
+		return fmt.Errorf("checksum of %s does not match %s", dst, src)
+	}
+
+	conn, err := sqlx.Open(sqlite3Driver, "file:"+dst+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return checkIntegrity(conn)
+}
+
+// Relocate moves the database to newPath, which must not exist. The database
+// is closed, copied to newPath and verified, then update is called with
+// newPath before the database is opened at newPath. If any step fails, the
+// copy is removed, update is called with the original path if it was
+// called with newPath, and the database is opened at the original path.
+// The original database file is not removed.
+func Relocate(newPath string, update func(path string) error) error {
+	if err := Ready(); err != nil {
+		return err
+	}
+
+	oldPath := dbPath
+	if newPath == oldPath {
+		return errors.New("database is already at " + newPath)
+	}
+
+	if exists, _ := utils.FileExists(newPath); exists {
+		return fmt.Errorf("%s already exists", newPath)
+	}
+
+	// write the journal into the database file so that the copy contains
+	// all committed data
+	if _, err := DB.Exec("PRAGMA wal_checkpoint(TRUNCATE)"); err != nil {
+		return fmt.Errorf("error checkpointing database: %w", err)
+	}
+
+	if err := Close(); err != nil {
+		return fmt.Errorf("error closing database: %w", err)
+	}
+
+	updated := false
+	rollback := func(cause error) error {
+		if err := Close(); err != nil {
+			logger.Warnf("error closing relocated database: %v", err)
+		}
+
+		if err := os.Remove(newPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.Warnf("error removing relocated database %s: %v", newPath, err)
+		}
+		if err := removeWALFiles(newPath); err != nil {
+			logger.Warnf("error removing relocated database journal: %v", err)
+		}
+
+		if updated {
+			if err := update(oldPath); err != nil {
+				return fmt.Errorf("%v; error restoring database path %s: %w", cause, oldPath, err)
+			}
+		}
+
+		if err := Initialize(oldPath); err != nil {
+			return fmt.Errorf("%v; error reopening database %s: %w", cause, oldPath, err)
+		}
+
+		return cause
+	}
+
+	logger.Infof("Copying database %s to %s", oldPath, newPath)
+	if err := copyDatabaseFile(oldPath, newPath); err != nil {
+		return rollback(fmt.Errorf("error copying database: %w", err))
+	}
+
+	if err := verifyCopy(oldPath, newPath); err != nil {
+		return rollback(fmt.Errorf("error verifying database copy: %w", err))
+	}
+
+	if err := update(newPath); err != nil {
+		return rollback(fmt.Errorf("error updating database path: %w", err))
+	}
+	updated = true
+
+	if err := Initialize(newPath); err != nil {
+		return rollback(fmt.Errorf("error opening relocated database: %w", err))
+	}
+
+	logger.Infof("Database relocated to %s. %s is no longer used and may be deleted", newPath, oldPath)
+	return nil
+}
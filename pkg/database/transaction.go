@@ -2,19 +2,26 @@ package database
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/stashapp/stash/pkg/logger"
 )
 
 // WithTxn executes the provided function within a transaction. It rolls back
-// the transaction if the function returns an error, otherwise the transaction
-// is committed.
+// the transaction if the function returns an error or panics, otherwise the
+// transaction is committed. Panics are re-raised after the rollback.
 func WithTxn(fn func(tx *sqlx.Tx) error) error {
+	if err := Ready(); err != nil {
+		return err
+	}
+
 	ctx := context.TODO()
-	tx := DB.MustBeginTx(ctx, nil)
+	tx, err := DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
 
-	var err error
 	defer func() {
 		if p := recover(); p != nil {
 			// a panic occurred, rollback and repanic
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// verifiedTables are the tables whose rows must not be lost by a migration.
+var verifiedTables = []string{
+	"scenes",
+	"scene_markers",
+	"images",
+	"galleries",
+	"performers",
+	"studios",
+	"tags",
+	"movies",
+}
+
+// maxVerifyErrors is the maximum number of problems reported by a failed
+// check.
+const maxVerifyErrors = 10
+
+// VerifyMigration checks the database after a migration. It returns an error
+// if the sqlite integrity check or foreign key check fails, or if any of the
+// main tables have fewer rows than in the pre-migration backup at
+// backupPath.
+func VerifyMigration(backupPath string) error {
+	if err := Ready(); err != nil {
+		return err
+	}
+
+	if err := checkIntegrity(DB); err != nil {
+		return err
+	}
+
+	if err := checkForeignKeys(DB); err != nil {
+		return err
+	}
+
+	backup, err := sqlx.Open(sqlite3Driver, "file:"+backupPath+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("error opening backup database %s: %w", backupPath, err)
+	}
+	defer backup.Close()
+
+	return checkRowCounts(DB, backup)
+}
+
+func checkIntegrity(conn *sqlx.DB) error {
+	var results []string
+	if err := conn.Select(&results, fmt.Sprintf("PRAGMA integrity_check(%d)", maxVerifyErrors)); err != nil {
+		return fmt.Errorf("error running integrity check: %w", err)
+	}
+
+	if len(results) == 1 && results[0] == "ok" {
+		return nil
+	}
+
+	return fmt.Errorf("integrity check failed: %s", strings.Join(results, "; "))
+}
+
+func checkForeignKeys(conn *sqlx.DB) error {
+	rows, err := conn.Query("PRAGMA foreign_key_check")
+	if err != nil {
+		return fmt.Errorf("error running foreign key check: %w", err)
+	}
+	defer rows.Close()
+
+	var violations []string
+	count := 0
+	for rows.Next() {
+		var table, parent string
+		var rowID *int64
+		var fkID int
+		if err := rows.Scan(&table, &rowID, &parent, &fkID); err != nil {
+			return fmt.Errorf("error running foreign key check: %w", err)
+		}
+
+		count++
+		if len(violations) < maxVerifyErrors {
+			v := fmt.Sprintf("%s references missing %s", table, parent)
+			if rowID != nil {
+				v = fmt.Sprintf("%s row %d references missing %s", table, *rowID, parent)
+			}
+			violations = append(violations, v)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error running foreign key check: %w", err)
+	}
+
+	if count > 0 {
+		return fmt.Errorf("foreign key check failed with %d violations: %s", count, strings.Join(violations, "; "))
+	}
+
+	return nil
+}
+
+// checkRowCounts returns an error if any of the verified tables have fewer
+// rows in conn than in backup. Tables missing from either database are not
+// compared.
+func checkRowCounts(conn *sqlx.DB, backup *sqlx.DB) error {
+	for _, table := range verifiedTables {
+		before, err := rowCount(backup, table)
+		if err != nil {
+			return fmt.Errorf("error counting rows of %s in backup: %w", table, err)
+		}
+
+		after, err := rowCount(conn, table)
+		if err != nil {
+			return fmt.Errorf("error counting rows of %s: %w", table, err)
+		}
+
+		if before >= 0 && after >= 0 && after < before {
+			return fmt.Errorf("%s has %d rows after migration, %d before", table, after, before)
+		}
+	}
+
+	return nil
+}
+
+// rowCount returns the number of rows in table, or -1 if the table does not
+// exist.
+func rowCount(conn *sqlx.DB, table string) (int, error) {
+	var exists int
+	if err := conn.Get(&exists, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?", table); err != nil {
+		return 0, err
+	}
+	if exists == 0 {
+		return -1, nil
+	}
+
+	var ret int
+	if err := conn.Get(&ret, `SELECT COUNT(*) FROM "`+table+`"`); err != nil {
+		return 0, err
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,107 @@
+package dlna
+
+import (
+	"context"
+	"database/sql"
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anacrolix/dms/upnpav"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const (
+	// resumeMinimumPosition is the position before which playback is not
+	// resumed, since the client stopped near the start.
+	resumeMinimumPosition = 10
+	// resumeMaximumProgress is the fraction of the duration after which
+	// the scene is treated as watched, and playback is not resumed.
+	resumeMaximumProgress = 0.95
+)
+
+// setBookmark are the arguments of the Samsung X_SetBookmark action, which
+// clients call with the playback position when playback stops.
+type setBookmark struct {
+	ObjectID  string
+	PosSecond string
+}
+
+// videoItem is a scene item with the position to resume playback from.
+type videoItem struct {
+	upnpav.Item
+	// DcmInfo is the Samsung extension setting the bookmark of the item.
+	DcmInfo string `xml:"sec:dcmInfo,omitempty"`
+	// LastPlaybackPosition is the resume position of ContentDirectory v4.
+	LastPlaybackPosition string `xml:"upnp:lastPlaybackPosition,omitempty"`
+}
+
+// resumeTime returns the resume time to store for a playback position.
+// Playback positions near the start or end of the scene clear the resume
+// time.
+func resumeTime(position float64, duration float64) sql.NullFloat64 {
+	if position < resumeMinimumPosition {
+		return sql.NullFloat64{}
+	}
+
+	if duration > 0 && position >= duration*resumeMaximumProgress {
+		return sql.NullFloat64{}
+	}
+
+	return sql.NullFloat64{Float64: position, Valid: true}
+}
+
+// setResumeInfo sets the resume position of the item from the scene.
+func setResumeInfo(item *videoItem, scene *models.Scene) {
+	if !scene.ResumeTime.Valid {
+		return
+	}
+
+	seconds := int64(scene.ResumeTime.Float64)
+	item.DcmInfo = fmt.Sprintf("BM=%d", seconds)
+	item.LastPlaybackPosition = formatDurationSexagesimal(time.Duration(seconds) * time.Second)
+}
+
+// handleSetBookmark stores the reported playback position as the resume
+// time of the scene. Invalid arguments are ignored, since clients report
+// positions inconsistently, and not reporting a position is harmless.
+func (me *contentDirectoryService) handleSetBookmark(argsXML []byte) map[string]string {
+	var args setBookmark
+	if err := xml.Unmarshal(argsXML, &args); err != nil {
+		logger.Debugf("[dlna] ignoring invalid bookmark: %v", err)
+		return map[string]string{}
+	}
+
+	sceneID, err := strconv.Atoi(args.ObjectID)
+	if err != nil {
+		logger.Debugf("[dlna] ignoring bookmark of object %q", args.ObjectID)
+		return map[string]string{}
+	}
+
+	position, err := strconv.ParseFloat(strings.TrimSpace(args.PosSecond), 64)
+	if err != nil || position < 0 {
+		logger.Debugf("[dlna] ignoring invalid bookmark position %q", args.PosSecond)
+		return map[string]string{}
+	}
+
+	if err := me.txnManager.WithTxn(context.TODO(), func(r models.Repository) error {
+		scene, err := r.Scene().Find(sceneID)
+		if err != nil || scene == nil {
+			return err
+		}
+
+		resume := resumeTime(position, scene.Duration.Float64)
+		_, err = r.Scene().Update(models.ScenePartial{
+			ID:         sceneID,
+			ResumeTime: &resume,
+		})
+		return err
+	}); err != nil {
+		logger.Warnf("[dlna] error setting resume time of scene %d: %v", sceneID, err)
+	}
+
+	return map[string]string{}
+}
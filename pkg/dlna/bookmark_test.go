@@ -0,0 +1,109 @@
+package dlna
+
+import (
+	"database/sql"
+	"encoding/xml"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResumeTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		position float64
+		duration float64
+		want     sql.NullFloat64
+	}{
+		{"middle", 600, 1200, sql.NullFloat64{Float64: 600, Valid: true}},
+		{"near start", 5, 1200, sql.NullFloat64{}},
+		{"near end", 1190, 1200, sql.NullFloat64{}},
+		{"unknown duration", 600, 0, sql.NullFloat64{Float64: 600, Valid: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, resumeTime(tt.position, tt.duration))
+		})
+	}
+}
+
+func testSetBookmark(repo *mocks.TransactionManager, objectID string, pos string) (map[string]string, error) {
+	cds := contentDirectoryService{
+		Server:     &Server{},
+		txnManager: repo,
+	}
+
+	argsXML := `<u:X_SetBookmark xmlns:u="urn:schemas-upnp-org:service:ContentDirectory:1"><CategoryType>1</CategoryType><RID>0</RID><ObjectID>` + objectID + `</ObjectID><PosSecond>` + pos + `</PosSecond></u:X_SetBookmark>`
+	return cds.Handle("X_SetBookmark", []byte(argsXML), &http.Request{})
+}
+
+func TestSetBookmark(t *testing.T) {
+	const sceneID = 3
+
+	repo := mocks.NewTransactionManager()
+	qb := repo.Scene().(*mocks.SceneReaderWriter)
+
+	scene := &models.Scene{
+		ID:       sceneID,
+		Duration: sql.NullFloat64{Float64: 1200, Valid: true},
+	}
+	resume := sql.NullFloat64{Float64: 600, Valid: true}
+
+	qb.On("Find", sceneID).Return(scene, nil).Once()
+	qb.On("Update", models.ScenePartial{
+		ID:         sceneID,
+		ResumeTime: &resume,
+	}).Return(scene, nil).Once()
+
+	if _, err := testSetBookmark(repo, "3", "600"); err != nil {
+		t.Fatalf("X_SetBookmark error = %v", err)
+	}
+
+	qb.AssertExpectations(t)
+}
+
+func TestSetBookmarkInvalid(t *testing.T) {
+	repo := mocks.NewTransactionManager()
+	qb := repo.Scene().(*mocks.SceneReaderWriter)
+
+	// invalid bookmarks are ignored without error
+	for _, args := range [][2]string{
+		{"all", "600"},
+		{"3", ""},
+		{"3", "abc"},
+		{"3", "-1"},
+	} {
+		if _, err := testSetBookmark(repo, args[0], args[1]); err != nil {
+			t.Errorf("X_SetBookmark(%q, %q) error = %v", args[0], args[1], err)
+		}
+	}
+
+	qb.AssertNotCalled(t, "Update")
+}
+
+func TestSceneResumeInfo(t *testing.T) {
+	scene := &models.Scene{
+		ID:         3,
+		ResumeTime: sql.NullFloat64{Float64: 3725.5, Valid: true},
+	}
+
+	data, err := xml.Marshal(sceneToContainer(scene, "all", "localhost"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := string(data)
+	assert.True(t, strings.HasPrefix(result, "<item "))
+	assert.Contains(t, result, "<sec:dcmInfo>BM=3725</sec:dcmInfo>")
+	assert.Contains(t, result, "<upnp:lastPlaybackPosition>1:02:05</upnp:lastPlaybackPosition>")
+
+	// no resume information without a resume time
+	scene.ResumeTime = sql.NullFloat64{}
+	data, _ = xml.Marshal(sceneToContainer(scene, "all", "localhost"))
+	assert.NotContains(t, string(data), "dcmInfo")
+}
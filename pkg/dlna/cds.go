@@ -39,8 +39,6 @@ import (
 	"github.com/anacrolix/dms/upnpav"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
-	"github.com/stashapp/stash/pkg/scene"
-	"github.com/stashapp/stash/pkg/utils"
 )
 
 var pageSize = 100
@@ -102,10 +100,13 @@ func sceneToContainer(scene *models.Scene, parent string, host string) interface
 	}
 
 	// Wrap up
-	item := upnpav.Item{
-		Object: obj,
-		Res:    make([]upnpav.Resource, 0, 1),
+	item := videoItem{
+		Item: upnpav.Item{
+			Object: obj,
+			Res:    make([]upnpav.Resource, 0, 1),
+		},
 	}
+	setResumeInfo(&item, scene)
 
 	mimeType := "video/mp4"
 	size, _ := strconv.Atoi(scene.Size.String)
@@ -158,14 +159,6 @@ func (me *contentDirectoryService) objectFromID(id string) (o object, err error)
 	return
 }
 
-func childPath(paths []string) []string {
-	if len(paths) > 1 {
-		return paths[1:]
-	}
-
-	return nil
-}
-
 func (me *contentDirectoryService) Handle(action string, argsXML []byte, r *http.Request) (map[string]string, error) {
 	host := r.Host
 	// userAgent := r.UserAgent()
@@ -191,7 +184,11 @@ func (me *contentDirectoryService) Handle(action string, argsXML []byte, r *http
 
 		switch browse.BrowseFlag {
 		case "BrowseDirectChildren":
-			return me.handleBrowseDirectChildren(obj, host)
+			br := browseRange{
+				start: browse.StartingIndex,
+				count: browse.RequestedCount,
+			}
+			return me.handleBrowseDirectChildren(obj, host, br)
 		case "BrowseMetadata":
 			return me.handleBrowseMetadata(obj, host)
 		default:
@@ -199,8 +196,15 @@ func (me *contentDirectoryService) Handle(action string, argsXML []byte, r *http
 		}
 	case "GetSearchCapabilities":
 		return map[string]string{
-			"SearchCaps": "",
+			"SearchCaps": searchCapabilities,
 		}, nil
+	case "Search":
+		var search search
+		if err := xml.Unmarshal([]byte(argsXML), &search); err != nil {
+			return nil, upnp.Errorf(upnp.ArgumentValueInvalidErrorCode, "cannot unmarshal search argument: %s", err.Error())
+		}
+
+		return me.handleSearch(search, host)
 	// from https://github.com/rclone/rclone/blob/master/cmd/serve/dlna/cds.go
 	// Samsung Extensions
 	case "X_GetFeatureList":
@@ -213,36 +217,33 @@ func (me *contentDirectoryService) Handle(action string, argsXML []byte, r *http
 	</Feature>
 	</Features>`}, nil
 	case "X_SetBookmark":
-		// just ignore
-		return map[string]string{}, nil
+		return me.handleSetBookmark(argsXML), nil
 	default:
 		return nil, upnp.InvalidActionError
 	}
 }
 
-func (me *contentDirectoryService) handleBrowseDirectChildren(obj object, host string) (map[string]string, error) {
+func (me *contentDirectoryService) handleBrowseDirectChildren(obj object, host string, br browseRange) (map[string]string, error) {
 	// Read folder and return children
 	// TODO: check if obj == 0 and return root objects
 	// TODO: check if special path and return files
 
-	var objs []interface{}
+	objs, total := me.getChildren(obj, host, br)
+	return makeBrowseResult(objs, total, me.updateIDString())
+}
 
+// getChildren returns the children of obj in the range, and the total
+// number of children. Scenes are queried by page, so that only the scenes
+// in the range are loaded.
+func (me *contentDirectoryService) getChildren(obj object, host string, br browseRange) ([]interface{}, int) {
 	if obj.IsRoot() {
-		objs = getRootObjects()
-	}
-
-	paths := strings.Split(obj.Path, "/")
-
-	// All videos
-	if obj.Path == "all" {
-		objs = me.getAllScenes(host)
+		return br.slice(getRootObjects())
 	}
 
-	if strings.HasPrefix(obj.Path, "all/") {
-		page := getPageFromID(paths)
-		if page != nil {
-			objs = me.getPageVideos(&models.SceneFilterType{}, "all", *page, host)
-		}
+	c, err := parseContainerID(obj.Path)
+	if err != nil {
+		logger.Debugf("[dlna] %v", err)
+		return nil, 0
 	}
 
 	// Saved searches
@@ -283,52 +284,73 @@ func (me *contentDirectoryService) handleBrowseDirectChildren(obj object, host s
 	// 	}
 	// }
 
-	// Studios
-	if obj.Path == "studios" {
-		objs = me.getStudios()
+	if c.isFolder() {
+		return br.slice(me.getFolders(c.kind))
 	}
 
-	if strings.HasPrefix(obj.Path, "studios/") {
-		objs = me.getStudioScenes(childPath(paths), host)
+	if c.page > 0 {
+		return me.getPageVideos(c.sceneFilter(), c.String(), c.page, br, host)
 	}
 
-	// Tags
-	if obj.Path == "tags" {
-		objs = me.getTags()
-	}
+	return me.getVideos(c.sceneFilter(), c.String(), br, host)
+}
 
-	if strings.HasPrefix(obj.Path, "tags/") {
-		objs = me.getTagScenes(childPath(paths), host)
+// getFolders returns the containers in the folder of a kind of container.
+func (me *contentDirectoryService) getFolders(kind string) []interface{} {
+	switch kind {
+	case containerStudios:
+		return me.getStudios()
+	case containerTags:
+		return me.getTags()
+	case containerPerformers:
+		return me.getPerformers()
+	case containerMovies:
+		return me.getMovies()
+	case containerRating:
+		return me.getRating()
+	case containerDates:
+		return me.getDates()
 	}
 
-	// Performers
-	if obj.Path == "performers" {
-		objs = me.getPerformers()
-	}
+	return nil
+}
 
-	if strings.HasPrefix(obj.Path, "performers/") {
-		objs = me.getPerformerScenes(childPath(paths), host)
+func (me *contentDirectoryService) handleSearch(search search, host string) (map[string]string, error) {
+	filter, err := parseSearchCriteria(search.SearchCriteria)
+	if err != nil {
+		return nil, upnp.Errorf(unsupportedSearchCriteriaErrorCode, err.Error())
 	}
 
-	// Movies
-	if obj.Path == "movies" {
-		objs = me.getMovies()
+	obj, err := me.objectFromID(search.ContainerID)
+	if err != nil {
+		return nil, upnp.Errorf(upnpav.NoSuchObjectErrorCode, err.Error())
 	}
 
-	if strings.HasPrefix(obj.Path, "movies/") {
-		objs = me.getMovieScenes(childPath(paths), host)
-	}
+	// search the scenes of the container
+	if !obj.IsRoot() {
+		c, err := parseContainerID(obj.Path)
+		if err != nil {
+			return nil, upnp.Errorf(upnpav.NoSuchObjectErrorCode, err.Error())
+		}
 
-	// Rating
-	if obj.Path == "rating" {
-		objs = me.getRating()
+		if c.kind != containerAll && !c.isFolder() {
+			filter, err = searchFilter{filter: c.sceneFilter()}.and(filter)
+			if err != nil {
+				return nil, upnp.Errorf(unsupportedSearchCriteriaErrorCode, err.Error())
+			}
+		}
 	}
 
-	if strings.HasPrefix(obj.Path, "rating/") {
-		objs = me.getRatingScenes(childPath(paths), host)
+	if filter.none {
+		return makeBrowseResult(nil, 0, me.updateIDString())
 	}
 
-	return makeBrowseResult(objs, me.updateIDString())
+	br := browseRange{
+		start: search.StartingIndex,
+		count: search.RequestedCount,
+	}
+	objs, total := me.searchScenes(filter.filter, obj.ID(), br, host)
+	return makeBrowseResult(objs, total, me.updateIDString())
 }
 
 func (me *contentDirectoryService) handleBrowseMetadata(obj object, host string) (map[string]string, error) {
@@ -376,17 +398,19 @@ func (me *contentDirectoryService) handleBrowseMetadata(obj object, host string)
 		}
 	}
 
-	return makeBrowseResult(objs, updateID)
+	return makeBrowseResult(objs, len(objs), updateID)
 }
 
-func makeBrowseResult(objs []interface{}, updateID string) (map[string]string, error) {
+// makeBrowseResult returns the result of a Browse action returning objs,
+// which are in a container with total objects.
+func makeBrowseResult(objs []interface{}, total int, updateID string) (map[string]string, error) {
 	result, err := xml.Marshal(objs)
 	if err != nil {
 		return nil, upnp.Errorf(upnp.ActionFailedErrorCode, "could not marshal objects: %s", err.Error())
 	}
 
 	return map[string]string{
-		"TotalMatches":   fmt.Sprint(len(objs)),
+		"TotalMatches":   fmt.Sprint(total),
 		"NumberReturned": fmt.Sprint(len(objs)),
 		"Result":         didl_lite(string(result)),
 		"UpdateID":       updateID,
@@ -418,42 +442,45 @@ func getRootObjects() []interface{} {
 
 	var objs []interface{}
 
-	objs = append(objs, makeStorageFolder("all", "all", rootID))
-	objs = append(objs, makeStorageFolder("performers", "performers", rootID))
-	objs = append(objs, makeStorageFolder("tags", "tags", rootID))
-	objs = append(objs, makeStorageFolder("studios", "studios", rootID))
-	objs = append(objs, makeStorageFolder("movies", "movies", rootID))
-	objs = append(objs, makeStorageFolder("rating", "rating", rootID))
+	objs = append(objs, makeStorageFolder(containerAll, "all", rootID))
+	objs = append(objs, makeStorageFolder(containerPerformers, "performers", rootID))
+	objs = append(objs, makeStorageFolder(containerTags, "tags", rootID))
+	objs = append(objs, makeStorageFolder(containerStudios, "studios", rootID))
+	objs = append(objs, makeStorageFolder(containerMovies, "movies", rootID))
+	objs = append(objs, makeStorageFolder(containerRating, "rating", rootID))
+	objs = append(objs, makeStorageFolder(containerDates, "dates", rootID))
 
 	return objs
 }
 
-func (me *contentDirectoryService) getVideos(sceneFilter *models.SceneFilterType, parentID string, host string) []interface{} {
+// getVideos returns the videos matching the filter in the range, and the
+// total number of videos. If there are more than pageSize videos, page
+// folders are returned instead.
+func (me *contentDirectoryService) getVideos(sceneFilter *models.SceneFilterType, parentID string, br browseRange, host string) ([]interface{}, int) {
 	var objs []interface{}
+	total := 0
 
 	if err := me.txnManager.WithReadTxn(context.TODO(), func(r models.ReaderRepository) error {
-		sort := "title"
-		findFilter := &models.FindFilterType{
-			PerPage: &pageSize,
-			Sort:    &sort,
+		pager := scenePager{
+			sceneFilter: sceneFilter,
+			parentID:    parentID,
 		}
 
-		scenes, total, err := scene.QueryWithCount(r.Scene(), sceneFilter, findFilter)
+		scenes, count, err := pager.getScenes(r, sceneWindow{
+			offset: br.start,
+			limit:  br.limit(pageSize),
+		})
 		if err != nil {
 			return err
 		}
 
-		if total > pageSize {
-			pager := scenePager{
-				sceneFilter: sceneFilter,
-				parentID:    parentID,
-			}
-
-			objs, err = pager.getPages(r, total)
+		if count > pageSize {
+			objs, total, err = pager.getPages(r, count, br)
 			if err != nil {
 				return err
 			}
 		} else {
+			total = count
 			for _, s := range scenes {
 				objs = append(objs, sceneToContainer(s, parentID, host))
 			}
@@ -464,11 +491,18 @@ func (me *contentDirectoryService) getVideos(sceneFilter *models.SceneFilterType
 		logger.Error(err.Error())
 	}
 
-	return objs
+	return objs, total
 }
 
-func (me *contentDirectoryService) getPageVideos(sceneFilter *models.SceneFilterType, parentID string, page int, host string) []interface{} {
+// searchScenes returns the scenes matching the filter in the range, and the
+// total number of matching scenes.
+func (me *contentDirectoryService) searchScenes(sceneFilter *models.SceneFilterType, parentID string, br browseRange, host string) ([]interface{}, int) {
 	var objs []interface{}
+	total := 0
+
+	if sceneFilter == nil {
+		sceneFilter = &models.SceneFilterType{}
+	}
 
 	if err := me.txnManager.WithReadTxn(context.TODO(), func(r models.ReaderRepository) error {
 		pager := scenePager{
@@ -476,36 +510,49 @@ func (me *contentDirectoryService) getPageVideos(sceneFilter *models.SceneFilter
 			parentID:    parentID,
 		}
 
-		var err error
-		objs, err = pager.getPageVideos(r, page, host)
+		scenes, count, err := pager.getScenes(r, sceneWindow{
+			offset: br.start,
+			limit:  br.limit(pageSize),
+		})
 		if err != nil {
 			return err
 		}
 
+		total = count
+		for _, s := range scenes {
+			objs = append(objs, sceneToContainer(s, parentID, host))
+		}
+
 		return nil
 	}); err != nil {
 		logger.Error(err.Error())
 	}
 
-	return objs
+	return objs, total
 }
 
-func getPageFromID(paths []string) *int {
-	i := utils.StrIndex(paths, "page")
-	if i == -1 || i+1 >= len(paths) {
-		return nil
-	}
+func (me *contentDirectoryService) getPageVideos(sceneFilter *models.SceneFilterType, parentID string, page int, br browseRange, host string) ([]interface{}, int) {
+	var objs []interface{}
+	total := 0
+
+	if err := me.txnManager.WithReadTxn(context.TODO(), func(r models.ReaderRepository) error {
+		pager := scenePager{
+			sceneFilter: sceneFilter,
+			parentID:    parentID,
+		}
+
+		var err error
+		objs, total, err = pager.getPageVideos(r, page, br, host)
+		if err != nil {
+			return err
+		}
 
-	ret, err := strconv.Atoi(paths[i+1])
-	if err != nil {
 		return nil
+	}); err != nil {
+		logger.Error(err.Error())
 	}
 
-	return &ret
-}
-
-func (me *contentDirectoryService) getAllScenes(host string) []interface{} {
-	return me.getVideos(&models.SceneFilterType{}, "all", host)
+	return objs, total
 }
 
 func (me *contentDirectoryService) getStudios() []interface{} {
@@ -518,7 +565,7 @@ func (me *contentDirectoryService) getStudios() []interface{} {
 		}
 
 		for _, s := range studios {
-			objs = append(objs, makeStorageFolder("studios/"+strconv.Itoa(s.ID), s.Name.String, "studios"))
+			objs = append(objs, makeStorageFolder(containerID{kind: containerStudios, value: strconv.Itoa(s.ID)}.String(), s.Name.String, containerStudios))
 		}
 
 		return nil
@@ -529,24 +576,6 @@ func (me *contentDirectoryService) getStudios() []interface{} {
 	return objs
 }
 
-func (me *contentDirectoryService) getStudioScenes(paths []string, host string) []interface{} {
-	sceneFilter := &models.SceneFilterType{
-		Studios: &models.HierarchicalMultiCriterionInput{
-			Modifier: models.CriterionModifierIncludes,
-			Value:    []string{paths[0]},
-		},
-	}
-
-	parentID := "studios/" + strings.Join(paths, "/")
-
-	page := getPageFromID(paths)
-	if page != nil {
-		return me.getPageVideos(sceneFilter, parentID, *page, host)
-	}
-
-	return me.getVideos(sceneFilter, parentID, host)
-}
-
 func (me *contentDirectoryService) getTags() []interface{} {
 	var objs []interface{}
 
@@ -557,7 +586,7 @@ func (me *contentDirectoryService) getTags() []interface{} {
 		}
 
 		for _, s := range tags {
-			objs = append(objs, makeStorageFolder("tags/"+strconv.Itoa(s.ID), s.Name, "tags"))
+			objs = append(objs, makeStorageFolder(containerID{kind: containerTags, value: strconv.Itoa(s.ID)}.String(), s.Name, containerTags))
 		}
 
 		return nil
@@ -568,24 +597,6 @@ func (me *contentDirectoryService) getTags() []interface{} {
 	return objs
 }
 
-func (me *contentDirectoryService) getTagScenes(paths []string, host string) []interface{} {
-	sceneFilter := &models.SceneFilterType{
-		Tags: &models.HierarchicalMultiCriterionInput{
-			Modifier: models.CriterionModifierIncludes,
-			Value:    []string{paths[0]},
-		},
-	}
-
-	parentID := "tags/" + strings.Join(paths, "/")
-
-	page := getPageFromID(paths)
-	if page != nil {
-		return me.getPageVideos(sceneFilter, parentID, *page, host)
-	}
-
-	return me.getVideos(sceneFilter, parentID, host)
-}
-
 func (me *contentDirectoryService) getPerformers() []interface{} {
 	var objs []interface{}
 
@@ -596,7 +607,7 @@ func (me *contentDirectoryService) getPerformers() []interface{} {
 		}
 
 		for _, s := range performers {
-			objs = append(objs, makeStorageFolder("performers/"+strconv.Itoa(s.ID), s.Name.String, "performers"))
+			objs = append(objs, makeStorageFolder(containerID{kind: containerPerformers, value: strconv.Itoa(s.ID)}.String(), s.Name.String, containerPerformers))
 		}
 
 		return nil
@@ -607,24 +618,6 @@ func (me *contentDirectoryService) getPerformers() []interface{} {
 	return objs
 }
 
-func (me *contentDirectoryService) getPerformerScenes(paths []string, host string) []interface{} {
-	sceneFilter := &models.SceneFilterType{
-		Performers: &models.MultiCriterionInput{
-			Modifier: models.CriterionModifierIncludes,
-			Value:    []string{paths[0]},
-		},
-	}
-
-	parentID := "performers/" + strings.Join(paths, "/")
-
-	page := getPageFromID(paths)
-	if page != nil {
-		return me.getPageVideos(sceneFilter, parentID, *page, host)
-	}
-
-	return me.getVideos(sceneFilter, parentID, host)
-}
-
 func (me *contentDirectoryService) getMovies() []interface{} {
 	var objs []interface{}
 
@@ -635,7 +628,7 @@ func (me *contentDirectoryService) getMovies() []interface{} {
 		}
 
 		for _, s := range movies {
-			objs = append(objs, makeStorageFolder("movies/"+strconv.Itoa(s.ID), s.Name.String, "movies"))
+			objs = append(objs, makeStorageFolder(containerID{kind: containerMovies, value: strconv.Itoa(s.ID)}.String(), s.Name.String, containerMovies))
 		}
 
 		return nil
@@ -646,56 +639,39 @@ func (me *contentDirectoryService) getMovies() []interface{} {
 	return objs
 }
 
-func (me *contentDirectoryService) getMovieScenes(paths []string, host string) []interface{} {
-	sceneFilter := &models.SceneFilterType{
-		Movies: &models.MultiCriterionInput{
-			Modifier: models.CriterionModifierIncludes,
-			Value:    []string{paths[0]},
-		},
-	}
-
-	parentID := "movies/" + strings.Join(paths, "/")
-
-	page := getPageFromID(paths)
-	if page != nil {
-		return me.getPageVideos(sceneFilter, parentID, *page, host)
-	}
-
-	return me.getVideos(sceneFilter, parentID, host)
-}
-
 func (me *contentDirectoryService) getRating() []interface{} {
 	var objs []interface{}
 
 	for r := 1; r <= 5; r++ {
 		rStr := strconv.Itoa(r)
-		objs = append(objs, makeStorageFolder("rating/"+rStr, rStr, "rating"))
+		objs = append(objs, makeStorageFolder(containerID{kind: containerRating, value: rStr}.String(), rStr, containerRating))
 	}
 
 	return objs
 }
 
-func (me *contentDirectoryService) getRatingScenes(paths []string, host string) []interface{} {
-	r, err := strconv.Atoi(paths[0])
-	if err != nil {
-		return nil
-	}
+// getDates returns a folder for each year with dated scenes, from newest to
+// oldest.
+func (me *contentDirectoryService) getDates() []interface{} {
+	var objs []interface{}
 
-	sceneFilter := &models.SceneFilterType{
-		Rating: &models.IntCriterionInput{
-			Modifier: models.CriterionModifierEquals,
-			Value:    r,
-		},
-	}
+	if err := me.txnManager.WithReadTxn(context.TODO(), func(r models.ReaderRepository) error {
+		years, err := sceneYears(r.Scene())
+		if err != nil {
+			return err
+		}
 
-	parentID := "rating/" + strings.Join(paths, "/")
+		for _, year := range years {
+			yStr := fmt.Sprintf("%04d", year)
+			objs = append(objs, makeStorageFolder(containerID{kind: containerDates, value: yStr}.String(), yStr, containerDates))
+		}
 
-	page := getPageFromID(paths)
-	if page != nil {
-		return me.getPageVideos(sceneFilter, parentID, *page, host)
+		return nil
+	}); err != nil {
+		logger.Errorf(err.Error())
 	}
 
-	return me.getVideos(sceneFilter, parentID, host)
+	return objs
 }
 
 // Represents a ContentDirectory object.
@@ -0,0 +1,179 @@
+package dlna
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// Containers are identified by slash-separated paths. The children of the
+// root container (object ID 0) are folders of each kind of container:
+//
+//	all                   all scenes
+//	studios               a folder for each studio
+//	studios/<id>          scenes of the studio
+//	tags/<id>             scenes with the tag
+//	performers/<id>       scenes with the performer
+//	movies/<id>           scenes in the movie
+//	rating/<rating>       scenes with the rating
+//	dates/<year>          scenes with a date in the year
+//
+// Containers with more than pageSize scenes contain page folders instead,
+// with the path of the container followed by /page/<page>. Scenes are
+// identified by their scene ID.
+const (
+	containerAll        = "all"
+	containerStudios    = "studios"
+	containerTags       = "tags"
+	containerPerformers = "performers"
+	containerMovies     = "movies"
+	containerRating     = "rating"
+	containerDates      = "dates"
+)
+
+var errInvalidContainerID = errors.New("invalid container id")
+
+// containerID is the parsed path of a container.
+type containerID struct {
+	kind string
+	// value is the id of the object of the kind, such as the tag ID. It is
+	// empty for the folder of the kind.
+	value string
+	// page is the 1-based page of a container with page folders, or 0.
+	page int
+}
+
+// parseContainerID parses a container path. Values are validated, but the
+// objects they refer to are not checked.
+func parseContainerID(path string) (containerID, error) {
+	parts := strings.Split(path, "/")
+	ret := containerID{
+		kind: parts[0],
+	}
+
+	switch ret.kind {
+	case containerAll:
+		parts = parts[1:]
+	case containerStudios, containerTags, containerPerformers, containerMovies, containerRating, containerDates:
+		if len(parts) > 1 {
+			ret.value = parts[1]
+			if _, err := strconv.Atoi(ret.value); err != nil {
+				return containerID{}, fmt.Errorf("%w: %s", errInvalidContainerID, path)
+			}
+			parts = parts[2:]
+		} else {
+			parts = nil
+		}
+	default:
+		return containerID{}, fmt.Errorf("%w: %s", errInvalidContainerID, path)
+	}
+
+	switch {
+	case len(parts) == 0:
+	case len(parts) == 2 && parts[0] == "page" && (ret.value != "" || ret.kind == containerAll):
+		page, err := strconv.Atoi(parts[1])
+		if err != nil || page < 1 {
+			return containerID{}, fmt.Errorf("%w: %s", errInvalidContainerID, path)
+		}
+		ret.page = page
+	default:
+		return containerID{}, fmt.Errorf("%w: %s", errInvalidContainerID, path)
+	}
+
+	return ret, nil
+}
+
+// String returns the path of the container.
+func (c containerID) String() string {
+	ret := c.kind
+	if c.value != "" {
+		ret += "/" + c.value
+	}
+	if c.page > 0 {
+		ret += "/page/" + strconv.Itoa(c.page)
+	}
+	return ret
+}
+
+// isFolder returns true if the container is the folder of a kind of
+// container, which does not contain scenes.
+func (c containerID) isFolder() bool {
+	return c.kind != containerAll && c.value == ""
+}
+
+// sceneFilter returns the filter of the scenes in the container.
+func (c containerID) sceneFilter() *models.SceneFilterType {
+	switch c.kind {
+	case containerStudios:
+		return &models.SceneFilterType{
+			Studios: &models.HierarchicalMultiCriterionInput{
+				Modifier: models.CriterionModifierIncludes,
+				Value:    []string{c.value},
+			},
+		}
+	case containerTags:
+		return &models.SceneFilterType{
+			Tags: &models.HierarchicalMultiCriterionInput{
+				Modifier: models.CriterionModifierIncludes,
+				Value:    []string{c.value},
+			},
+		}
+	case containerPerformers:
+		return &models.SceneFilterType{
+			Performers: &models.MultiCriterionInput{
+				Modifier: models.CriterionModifierIncludes,
+				Value:    []string{c.value},
+			},
+		}
+	case containerMovies:
+		return &models.SceneFilterType{
+			Movies: &models.MultiCriterionInput{
+				Modifier: models.CriterionModifierIncludes,
+				Value:    []string{c.value},
+			},
+		}
+	case containerRating:
+		rating, _ := strconv.Atoi(c.value)
+		return &models.SceneFilterType{
+			Rating: &models.IntCriterionInput{
+				Modifier: models.CriterionModifierEquals,
+				Value:    rating,
+			},
+		}
+	case containerDates:
+		return yearSceneFilter(c.value)
+	}
+
+	return &models.SceneFilterType{}
+}
+
+func yearSceneFilter(year string) *models.SceneFilterType {
+	return &models.SceneFilterType{
+		Date: &models.StringCriterionInput{
+			Modifier: models.CriterionModifierMatchesRegex,
+			Value:    "^" + year + "-",
+		},
+	}
+}
+
+// sceneYears returns the years with dated scenes, from newest to oldest.
+// The zero date 0001-01-01 is used for missing dates, so years before 1000
+// are not returned.
+func sceneYears(qb models.SceneReader) ([]int, error) {
+	years, err := qb.Years()
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []int
+	for i := len(years) - 1; i >= 0; i-- {
+		if years[i] >= 1000 {
+			ret = append(ret, years[i])
+		}
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,126 @@
+package dlna
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParseContainerID(t *testing.T) {
+	tests := []struct {
+		path string
+		want containerID
+	}{
+		{"all", containerID{kind: containerAll}},
+		{"all/page/3", containerID{kind: containerAll, page: 3}},
+		{"tags", containerID{kind: containerTags}},
+		{"tags/12", containerID{kind: containerTags, value: "12"}},
+		{"studios/4/page/2", containerID{kind: containerStudios, value: "4", page: 2}},
+		{"dates/2019", containerID{kind: containerDates, value: "2019"}},
+		{"rating/5", containerID{kind: containerRating, value: "5"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := parseContainerID(tt.path)
+			if err != nil {
+				t.Fatalf("parseContainerID() error = %v", err)
+			}
+			assert.Equal(t, tt.want, got)
+
+			// encoding the parsed id returns the original path
+			assert.Equal(t, tt.path, got.String())
+		})
+	}
+}
+
+func TestParseContainerIDInvalid(t *testing.T) {
+	for _, path := range []string{
+		"",
+		"unknown",
+		"tags/abc",
+		"tags/page/2",
+		"tags/1/page",
+		"tags/1/page/0",
+		"tags/1/page/x",
+		"tags/1/other/2",
+	} {
+		if _, err := parseContainerID(path); !errors.Is(err, errInvalidContainerID) {
+			t.Errorf("parseContainerID(%q) error = %v, want %v", path, err, errInvalidContainerID)
+		}
+	}
+}
+
+func TestBrowseTagScenes(t *testing.T) {
+	repo := mocks.NewTransactionManager()
+	qb := repo.Scene().(*mocks.SceneReaderWriter)
+
+	isTagFilter := mock.MatchedBy(func(options models.SceneQueryOptions) bool {
+		tags := options.SceneFilter.Tags
+		return tags != nil && len(tags.Value) == 1 && tags.Value[0] == "12"
+	})
+
+	result := models.NewSceneQueryResult(qb)
+	result.IDs = []int{3, 4}
+	result.Count = 2
+	qb.On("Query", isTagFilter).Return(result, nil).Once()
+	qb.On("FindMany", []int{3, 4}).Return([]*models.Scene{{ID: 3}, {ID: 4}}, nil).Once()
+
+	ret, ids := browseChildren(t, repo, "tags/12", 0, 0)
+
+	assert.Equal(t, "2", ret["TotalMatches"])
+	assert.Equal(t, []string{"3", "4"}, ids)
+	qb.AssertExpectations(t)
+}
+
+func TestBrowseDates(t *testing.T) {
+	repo := mocks.NewTransactionManager()
+	qb := repo.Scene().(*mocks.SceneReaderWriter)
+
+	// scenes dated 2018 and 2020
+	dates := []string{"2018-05-01", "2020-01-02"}
+
+	// the zero date is used for missing dates
+	qb.On("Years").Return([]int{1, 2018, 2020}, nil).Once()
+
+	qb.On("Query", mock.Anything).Return(func(options models.SceneQueryOptions) *models.SceneQueryResult {
+		pattern := options.SceneFilter.Date.Value
+		var ids []int
+		for i, d := range dates {
+			if strings.HasPrefix(d, strings.TrimPrefix(pattern, "^")) {
+				ids = append(ids, i+1)
+			}
+		}
+
+		result := models.NewSceneQueryResult(qb)
+		result.IDs = ids
+		result.Count = len(ids)
+		return result
+	}, nil)
+
+	qb.On("FindMany", mock.Anything).Return(func(ids []int) []*models.Scene {
+		var ret []*models.Scene
+		for _, id := range ids {
+			ret = append(ret, &models.Scene{
+				ID:   id,
+				Date: models.SQLiteDate{String: dates[id-1], Valid: true},
+			})
+		}
+		return ret
+	}, nil)
+
+	ret, ids := browseChildren(t, repo, containerDates, 0, 0)
+
+	assert.Equal(t, "2", ret["TotalMatches"])
+	assert.Equal(t, []string{"dates/2020", "dates/2018"}, ids)
+
+	// the year folder lists the scenes of the year
+	ret, ids = browseChildren(t, repo, "dates/2018", 0, 0)
+	assert.Equal(t, "1", ret["TotalMatches"])
+	assert.Equal(t, []string{"1"}, ids)
+}
@@ -31,6 +31,7 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -46,6 +47,7 @@ import (
 	"github.com/anacrolix/dms/ssdp"
 	"github.com/anacrolix/dms/upnp"
 
+	"github.com/stashapp/stash/pkg/ffmpeg"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
 )
@@ -252,6 +254,8 @@ type Server struct {
 	txnManager         models.TransactionManager
 	sceneServer        sceneServer
 	ipWhitelistManager *ipWhitelistManager
+	transcodeMode      func() models.DLNATranscodeMode
+	transcodeLimiter   *transcodeLimiter
 }
 
 // UPnP SOAP service.
@@ -536,6 +540,24 @@ func (me *Server) contentDirectoryEventSubHandler(w http.ResponseWriter, r *http
 	}
 }
 
+// streamSceneTranscode transcodes the scene for the client, once the number
+// of concurrent DLNA transcodes is within the limit. Responds with service
+// unavailable if the transcode is rejected.
+func (me *Server) streamSceneTranscode(scene *models.Scene, w http.ResponseWriter, r *http.Request) {
+	release, err := me.transcodeLimiter.acquire(r.Context())
+	if err != nil {
+		if errors.Is(err, errTooManyTranscodes) {
+			logger.Infof("[dlna] rejecting transcode of %s for %s: %v", scene.Path, r.RemoteAddr, err)
+			w.Header().Set("Retry-After", "30")
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		}
+		return
+	}
+	defer release()
+
+	me.sceneServer.StreamSceneTranscode(scene, w, r, ffmpeg.CodecH264)
+}
+
 func (me *Server) initMux(mux *http.ServeMux) {
 	mux.HandleFunc("/", func(resp http.ResponseWriter, req *http.Request) {
 		resp.Header().Set("content-type", "text/html")
@@ -571,6 +593,11 @@ func (me *Server) initMux(mux *http.ServeMux) {
 			return
 		}
 
+		if needsTranscode(me.transcodeMode(), scene, me.sceneServer.HasTranscode(scene), getClientFormats(r)) {
+			me.streamSceneTranscode(scene, w, r)
+			return
+		}
+
 		me.sceneServer.StreamSceneDirect(scene, w, r)
 	})
 	mux.HandleFunc(rootDescPath, func(w http.ResponseWriter, r *http.Request) {
@@ -688,7 +715,8 @@ func didl_lite(chardata string) string {
 		` xmlns:dc="http://purl.org/dc/elements/1.1/"` +
 		` xmlns:upnp="urn:schemas-upnp-org:metadata-1-0/upnp/"` +
 		` xmlns="urn:schemas-upnp-org:metadata-1-0/DIDL-Lite/"` +
-		` xmlns:dlna="urn:schemas-dlna-org:metadata-1-0/">` +
+		` xmlns:dlna="urn:schemas-dlna-org:metadata-1-0/"` +
+		` xmlns:sec="http://www.sec.co.kr/">` +
 		chardata +
 		`</DIDL-Lite>`
 }
@@ -9,6 +9,74 @@ import (
 	"github.com/stashapp/stash/pkg/scene"
 )
 
+// browseRange is the range of children requested by a Browse action. A
+// count of 0 requests all children from start.
+type browseRange struct {
+	start int
+	count int
+}
+
+// limit returns the number of children requested from the range, which is
+// at most max.
+func (br browseRange) limit(max int) int {
+	if br.count <= 0 || br.count > max {
+		return max
+	}
+	return br.count
+}
+
+// bounds returns the indexes of the first and last children of the range,
+// for a container with total children.
+func (br browseRange) bounds(total int) (int, int) {
+	start := br.start
+	if start < 0 {
+		start = 0
+	}
+	if start > total {
+		start = total
+	}
+
+	end := total
+	if br.count > 0 && start+br.count < end {
+		end = start + br.count
+	}
+
+	return start, end
+}
+
+// slice returns the children of objs in the range, and the total number of
+// children.
+func (br browseRange) slice(objs []interface{}) ([]interface{}, int) {
+	start, end := br.bounds(len(objs))
+	return objs[start:end], len(objs)
+}
+
+// sceneWindow is a range of scenes in the results of a scene query.
+type sceneWindow struct {
+	offset int
+	limit  int
+}
+
+// pages returns the size and numbers of the pages of query results that
+// contain the window, and the number of scenes before the window in the
+// first page. At most two pages are queried.
+func (w sceneWindow) pages() (perPage int, pages []int, skip int) {
+	if w.limit <= 0 {
+		return 0, nil, 0
+	}
+
+	perPage = w.limit
+	first := w.offset/perPage + 1
+	skip = w.offset % perPage
+
+	pages = []int{first}
+	if skip > 0 {
+		pages = append(pages, first+1)
+	}
+
+	return perPage, pages, skip
+}
+
 type scenePager struct {
 	sceneFilter *models.SceneFilterType
 	parentID    string
@@ -18,11 +86,68 @@ func (p *scenePager) getPageID(page int) string {
 	return p.parentID + "/page/" + strconv.Itoa(page)
 }
 
-func (p *scenePager) getPages(r models.ReaderRepository, total int) ([]interface{}, error) {
+// getScenes returns the scenes in the window, and the total number of
+// scenes matching the filter.
+func (p *scenePager) getScenes(r models.ReaderRepository, w sceneWindow) ([]*models.Scene, int, error) {
+	perPage, pages, skip := w.pages()
+	if len(pages) == 0 {
+		// query a single scene for the count
+		perPage = 1
+		pages = []int{1}
+	}
+
+	sort := "title"
+	var scenes []*models.Scene
+	total := 0
+	for i, page := range pages {
+		page := page
+		findFilter := &models.FindFilterType{
+			PerPage: &perPage,
+			Page:    &page,
+			Sort:    &sort,
+		}
+
+		if i == 0 {
+			found, count, err := scene.QueryWithCount(r.Scene(), p.sceneFilter, findFilter)
+			if err != nil {
+				return nil, 0, err
+			}
+			scenes = append(scenes, found...)
+			total = count
+
+			// no scenes in the next page
+			if page*perPage >= total {
+				break
+			}
+		} else {
+			found, err := scene.Query(r.Scene(), p.sceneFilter, findFilter)
+			if err != nil {
+				return nil, 0, err
+			}
+			scenes = append(scenes, found...)
+		}
+	}
+
+	if w.limit <= 0 || skip >= len(scenes) {
+		return nil, total, nil
+	}
+
+	scenes = scenes[skip:]
+	if len(scenes) > w.limit {
+		scenes = scenes[:w.limit]
+	}
+
+	return scenes, total, nil
+}
+
+// getPages returns the page folders in the range, and the total number of
+// pages.
+func (p *scenePager) getPages(r models.ReaderRepository, total int, br browseRange) ([]interface{}, int, error) {
 	var objs []interface{}
 
 	// get the first scene of each page to set an appropriate title
 	pages := int(math.Ceil(float64(total) / float64(pageSize)))
+	start, end := br.bounds(pages)
 
 	singlePageSize := 1
 	sort := "title"
@@ -31,7 +156,7 @@ func (p *scenePager) getPages(r models.ReaderRepository, total int) ([]interface
 		Sort:    &sort,
 	}
 
-	for page := 1; page <= pages; page++ {
+	for page := start + 1; page <= end; page++ {
 		// TODO - this is really slow. Not sure if there's a better way
 		title := fmt.Sprintf("Page %d", page)
 		if pages <= 10 || (page-1)%(pages/10) == 0 {
@@ -39,43 +164,66 @@ func (p *scenePager) getPages(r models.ReaderRepository, total int) ([]interface
 			findFilter.Page = &thisPage
 			scenes, err := scene.Query(r.Scene(), p.sceneFilter, findFilter)
 			if err != nil {
-				return nil, err
+				return nil, 0, err
 			}
 
-			sceneTitle := scenes[0].GetTitle()
+			if len(scenes) > 0 {
+				sceneTitle := scenes[0].GetTitle()
 
-			// use the first three letters as a prefix
-			if len(sceneTitle) > 3 {
-				sceneTitle = sceneTitle[0:3]
-			}
+				// use the first three letters as a prefix
+				if len(sceneTitle) > 3 {
+					sceneTitle = sceneTitle[0:3]
+				}
 
-			title += fmt.Sprintf(" (%s...)", sceneTitle)
+				title += fmt.Sprintf(" (%s...)", sceneTitle)
+			}
 		}
 
 		objs = append(objs, makeStorageFolder(p.getPageID(page), title, p.parentID))
 	}
 
-	return objs, nil
+	return objs, pages, nil
 }
 
-func (p *scenePager) getPageVideos(r models.ReaderRepository, page int, host string) ([]interface{}, error) {
+// getPageVideos returns the videos of the page in the range, and the total
+// number of videos in the page.
+func (p *scenePager) getPageVideos(r models.ReaderRepository, page int, br browseRange, host string) ([]interface{}, int, error) {
+	if page < 1 {
+		return nil, 0, nil
+	}
+
 	var objs []interface{}
 
-	sort := "title"
-	findFilter := &models.FindFilterType{
-		PerPage: &pageSize,
-		Page:    &page,
-		Sort:    &sort,
+	pageStart := (page - 1) * pageSize
+	start := br.start
+	if start < 0 {
+		start = 0
+	}
+
+	limit := br.limit(pageSize)
+	if start+limit > pageSize {
+		limit = pageSize - start
 	}
 
-	scenes, err := scene.Query(r.Scene(), p.sceneFilter, findFilter)
+	scenes, total, err := p.getScenes(r, sceneWindow{
+		offset: pageStart + start,
+		limit:  limit,
+	})
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	for _, s := range scenes {
 		objs = append(objs, sceneToContainer(s, p.parentID, host))
 	}
 
-	return objs, nil
+	pageTotal := total - pageStart
+	if pageTotal > pageSize {
+		pageTotal = pageSize
+	}
+	if pageTotal < 0 {
+		pageTotal = 0
+	}
+
+	return objs, pageTotal, nil
 }
@@ -0,0 +1,192 @@
+package dlna
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSceneWindowPages(t *testing.T) {
+	tests := []struct {
+		name        string
+		window      sceneWindow
+		wantPerPage int
+		wantPages   []int
+		wantSkip    int
+	}{
+		{"first page", sceneWindow{0, 10}, 10, []int{1}, 0},
+		{"aligned", sceneWindow{20, 10}, 10, []int{3}, 0},
+		{"unaligned", sceneWindow{25, 10}, 10, []int{3, 4}, 5},
+		{"single", sceneWindow{7, 1}, 1, []int{8}, 0},
+		{"empty", sceneWindow{5, 0}, 0, nil, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			perPage, pages, skip := tt.window.pages()
+			assert.Equal(t, tt.wantPerPage, perPage)
+			assert.Equal(t, tt.wantPages, pages)
+			assert.Equal(t, tt.wantSkip, skip)
+		})
+	}
+}
+
+func TestBrowseRangeBounds(t *testing.T) {
+	tests := []struct {
+		br        browseRange
+		total     int
+		wantStart int
+		wantEnd   int
+	}{
+		{browseRange{0, 0}, 10, 0, 10},
+		{browseRange{2, 3}, 10, 2, 5},
+		{browseRange{8, 5}, 10, 8, 10},
+		{browseRange{12, 5}, 10, 10, 10},
+		{browseRange{-1, 0}, 10, 0, 10},
+	}
+
+	for _, tt := range tests {
+		start, end := tt.br.bounds(tt.total)
+		if start != tt.wantStart || end != tt.wantEnd {
+			t.Errorf("%+v.bounds(%d) = %d, %d, want %d, %d", tt.br, tt.total, start, end, tt.wantStart, tt.wantEnd)
+		}
+	}
+}
+
+// mockScenes sets up the scene reader to return totalScenes scenes, with
+// IDs from 1, recording the find filters of the queries.
+func mockScenes(repo *mocks.TransactionManager, totalScenes int) *[]models.FindFilterType {
+	var queries []models.FindFilterType
+	qb := repo.Scene().(*mocks.SceneReaderWriter)
+
+	qb.On("Query", mock.Anything).Return(func(options models.SceneQueryOptions) *models.SceneQueryResult {
+		queries = append(queries, *options.FindFilter)
+
+		page := options.FindFilter.GetPage()
+		perPage := options.FindFilter.GetPageSize()
+
+		result := models.NewSceneQueryResult(qb)
+		for id := (page-1)*perPage + 1; id <= page*perPage && id <= totalScenes; id++ {
+			result.IDs = append(result.IDs, id)
+		}
+		if options.Count {
+			result.Count = totalScenes
+		}
+		return result
+	}, nil)
+
+	qb.On("FindMany", mock.Anything).Return(func(ids []int) []*models.Scene {
+		var ret []*models.Scene
+		for _, id := range ids {
+			ret = append(ret, &models.Scene{ID: id})
+		}
+		return ret
+	}, nil)
+
+	return &queries
+}
+
+func browseChildren(t *testing.T, repo *mocks.TransactionManager, objectID string, start, count int) (map[string]string, []string) {
+	t.Helper()
+
+	cds := contentDirectoryService{
+		Server:     &Server{},
+		txnManager: repo,
+	}
+
+	argsXML := `<u:Browse xmlns:u="urn:schemas-upnp-org:service:ContentDirectory:1"><ObjectID>` + objectID + `</ObjectID><BrowseFlag>BrowseDirectChildren</BrowseFlag><Filter>*</Filter><StartingIndex>` + strconv.Itoa(start) + `</StartingIndex><RequestedCount>` + strconv.Itoa(count) + `</RequestedCount><SortCriteria></SortCriteria></u:Browse>`
+	ret, err := cds.Handle("Browse", []byte(argsXML), &http.Request{})
+	if err != nil {
+		t.Fatalf("Browse error = %v", err)
+	}
+
+	var result struct {
+		Items []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"item"`
+		Containers []struct {
+			ID string `xml:"id,attr"`
+		} `xml:"container"`
+	}
+	if err := xml.Unmarshal([]byte(ret["Result"]), &result); err != nil {
+		t.Fatalf("unmarshalling result: %v", err)
+	}
+
+	var ids []string
+	for _, i := range result.Items {
+		ids = append(ids, i.ID)
+	}
+	for _, c := range result.Containers {
+		ids = append(ids, c.ID)
+	}
+
+	return ret, ids
+}
+
+func TestBrowseScenesPaginated(t *testing.T) {
+	repo := mocks.NewTransactionManager()
+	queries := mockScenes(repo, 50)
+
+	ret, ids := browseChildren(t, repo, "all", 10, 5)
+
+	assert.Equal(t, "50", ret["TotalMatches"])
+	assert.Equal(t, "5", ret["NumberReturned"])
+	assert.Equal(t, []string{"11", "12", "13", "14", "15"}, ids)
+
+	// scenes 11-15 are the third page of 5 scenes
+	if assert.Len(t, *queries, 1) {
+		assert.Equal(t, 3, (*queries)[0].GetPage())
+		assert.Equal(t, 5, (*queries)[0].GetPageSize())
+	}
+}
+
+func TestBrowseScenesUnalignedRange(t *testing.T) {
+	repo := mocks.NewTransactionManager()
+	queries := mockScenes(repo, 50)
+
+	ret, ids := browseChildren(t, repo, "all", 48, 4)
+
+	assert.Equal(t, "50", ret["TotalMatches"])
+	assert.Equal(t, "2", ret["NumberReturned"])
+	assert.Equal(t, []string{"49", "50"}, ids)
+
+	// the next page is not queried past the last scene
+	if assert.Len(t, *queries, 1) {
+		assert.Equal(t, 13, (*queries)[0].GetPage())
+		assert.Equal(t, 4, (*queries)[0].GetPageSize())
+	}
+}
+
+func TestBrowsePageFolders(t *testing.T) {
+	repo := mocks.NewTransactionManager()
+	mockScenes(repo, 10*pageSize+1)
+
+	ret, ids := browseChildren(t, repo, "all", 2, 3)
+
+	assert.Equal(t, "11", ret["TotalMatches"])
+	assert.Equal(t, "3", ret["NumberReturned"])
+	assert.Equal(t, []string{"all/page/3", "all/page/4", "all/page/5"}, ids)
+}
+
+func TestBrowsePageScenes(t *testing.T) {
+	repo := mocks.NewTransactionManager()
+	queries := mockScenes(repo, 2*pageSize+10)
+
+	ret, ids := browseChildren(t, repo, "all/page/3", 0, 0)
+
+	assert.Equal(t, "10", ret["TotalMatches"])
+	assert.Equal(t, "10", ret["NumberReturned"])
+	assert.Len(t, ids, 10)
+	assert.Equal(t, strconv.Itoa(2*pageSize+1), ids[0])
+
+	if assert.Len(t, *queries, 1) {
+		assert.Equal(t, 3, (*queries)[0].GetPage())
+		assert.Equal(t, pageSize, (*queries)[0].GetPageSize())
+	}
+}
@@ -0,0 +1,430 @@
+package dlna
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// unsupportedSearchCriteriaErrorCode is the ContentDirectory error code
+// returned for search criteria that cannot be handled.
+const unsupportedSearchCriteriaErrorCode = 708
+
+// searchCapabilities are the properties that can be used in search
+// criteria.
+const searchCapabilities = "dc:title,upnp:class"
+
+const videoItemClass = "object.item.videoItem"
+
+var errUnsupportedSearch = errors.New("unsupported search criteria")
+
+type search struct {
+	ContainerID    string
+	SearchCriteria string
+	Filter         string
+	StartingIndex  int
+	RequestedCount int
+}
+
+// searchFilter is the translation of search criteria into a scene filter.
+// A nil filter matches all scenes, unless none is true, in which case no
+// scenes match.
+type searchFilter struct {
+	filter *models.SceneFilterType
+	none   bool
+}
+
+func hasSubFilter(f *models.SceneFilterType) bool {
+	return f.And != nil || f.Or != nil || f.Not != nil
+}
+
+// and returns the filter matching scenes matching both filters.
+func (s searchFilter) and(o searchFilter) (searchFilter, error) {
+	switch {
+	case s.none || o.none:
+		return searchFilter{none: true}, nil
+	case s.filter == nil:
+		return o, nil
+	case o.filter == nil:
+		return s, nil
+	}
+
+	return combineFilters(s.filter, o.filter, func(f, sub *models.SceneFilterType) {
+		f.And = sub
+	})
+}
+
+// or returns the filter matching scenes matching either filter.
+func (s searchFilter) or(o searchFilter) (searchFilter, error) {
+	switch {
+	case s.filter == nil && !s.none, o.filter == nil && !o.none:
+		return searchFilter{}, nil
+	case s.none:
+		return o, nil
+	case o.none:
+		return s, nil
+	}
+
+	if f := appendOr(s.filter, o.filter); f != nil {
+		return searchFilter{filter: f}, nil
+	}
+
+	if f := appendOr(o.filter, s.filter); f != nil {
+		return searchFilter{filter: f}, nil
+	}
+
+	return searchFilter{}, fmt.Errorf("%w: too many nested expressions", errUnsupportedSearch)
+}
+
+// appendOr returns a copy of a with b set as the sub-filter of its last
+// sub-filter. Returns nil if a has sub-filters that are not or-ed.
+func appendOr(a, b *models.SceneFilterType) *models.SceneFilterType {
+	if a.And != nil || a.Not != nil {
+		return nil
+	}
+
+	ret := *a
+	if a.Or == nil {
+		ret.Or = b
+		return &ret
+	}
+
+	if ret.Or = appendOr(a.Or, b); ret.Or == nil {
+		return nil
+	}
+	return &ret
+}
+
+// combineFilters sets one filter as the sub-filter of the other. A filter
+// has a single sub-filter, so filters that both have sub-filters cannot be
+// combined.
+func combineFilters(a, b *models.SceneFilterType, setSub func(f, sub *models.SceneFilterType)) (searchFilter, error) {
+	if !hasSubFilter(a) {
+		ret := *a
+		setSub(&ret, b)
+		return searchFilter{filter: &ret}, nil
+	}
+
+	if !hasSubFilter(b) {
+		ret := *b
+		setSub(&ret, a)
+		return searchFilter{filter: &ret}, nil
+	}
+
+	return searchFilter{}, fmt.Errorf("%w: too many nested expressions", errUnsupportedSearch)
+}
+
+// parseSearchCriteria translates UPnP ContentDirectory search criteria into
+// a scene filter. The dc:title and upnp:class properties are supported,
+// combined with and, or and parentheses.
+func parseSearchCriteria(criteria string) (searchFilter, error) {
+	criteria = strings.TrimSpace(criteria)
+	if criteria == "*" || criteria == "" {
+		return searchFilter{}, nil
+	}
+
+	tokens, err := tokenizeSearchCriteria(criteria)
+	if err != nil {
+		return searchFilter{}, err
+	}
+
+	p := &searchParser{tokens: tokens}
+	ret, err := p.parseOr()
+	if err != nil {
+		return searchFilter{}, err
+	}
+
+	if !p.done() {
+		return searchFilter{}, fmt.Errorf("%w: unexpected %q", errUnsupportedSearch, p.peek().value)
+	}
+
+	return ret, nil
+}
+
+type searchToken struct {
+	value  string
+	quoted bool
+}
+
+func isSearchOperator(r rune) bool {
+	return r == '=' || r == '!' || r == '<' || r == '>'
+}
+
+func tokenizeSearchCriteria(s string) ([]searchToken, error) {
+	var ret []searchToken
+	runes := []rune(s)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			ret = append(ret, searchToken{value: string(r)})
+			i++
+		case r == '"':
+			var value strings.Builder
+			i++
+			closed := false
+			for i < len(runes) {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					value.WriteRune(runes[i+1])
+					i += 2
+					continue
+				}
+				if runes[i] == '"' {
+					closed = true
+					i++
+					break
+				}
+				value.WriteRune(runes[i])
+				i++
+			}
+			if !closed {
+				return nil, fmt.Errorf("%w: unterminated string", errUnsupportedSearch)
+			}
+			ret = append(ret, searchToken{value: value.String(), quoted: true})
+		case isSearchOperator(r):
+			start := i
+			for i < len(runes) && isSearchOperator(runes[i]) {
+				i++
+			}
+			ret = append(ret, searchToken{value: string(runes[start:i])})
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !isSearchOperator(runes[i]) && runes[i] != '(' && runes[i] != ')' && runes[i] != '"' {
+				i++
+			}
+			ret = append(ret, searchToken{value: string(runes[start:i])})
+		}
+	}
+
+	return ret, nil
+}
+
+type searchParser struct {
+	tokens []searchToken
+	pos    int
+}
+
+func (p *searchParser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *searchParser) peek() searchToken {
+	if p.done() {
+		return searchToken{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *searchParser) next() (searchToken, error) {
+	if p.done() {
+		return searchToken{}, fmt.Errorf("%w: unexpected end of criteria", errUnsupportedSearch)
+	}
+	ret := p.tokens[p.pos]
+	p.pos++
+	return ret, nil
+}
+
+// peekKeyword returns true if the next token is the unquoted keyword.
+func (p *searchParser) peekKeyword(keyword string) bool {
+	t := p.peek()
+	return !t.quoted && strings.EqualFold(t.value, keyword)
+}
+
+// parseOr parses expressions separated by or, which has a lower precedence
+// than and.
+func (p *searchParser) parseOr() (searchFilter, error) {
+	ret, err := p.parseAnd()
+	if err != nil {
+		return searchFilter{}, err
+	}
+
+	for p.peekKeyword("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return searchFilter{}, err
+		}
+		if ret, err = ret.or(right); err != nil {
+			return searchFilter{}, err
+		}
+	}
+
+	return ret, nil
+}
+
+func (p *searchParser) parseAnd() (searchFilter, error) {
+	ret, err := p.parsePrimary()
+	if err != nil {
+		return searchFilter{}, err
+	}
+
+	for p.peekKeyword("and") {
+		p.pos++
+		right, err := p.parsePrimary()
+		if err != nil {
+			return searchFilter{}, err
+		}
+		if ret, err = ret.and(right); err != nil {
+			return searchFilter{}, err
+		}
+	}
+
+	return ret, nil
+}
+
+func (p *searchParser) parsePrimary() (searchFilter, error) {
+	t := p.peek()
+	if !t.quoted && t.value == "(" {
+		p.pos++
+		ret, err := p.parseOr()
+		if err != nil {
+			return searchFilter{}, err
+		}
+
+		closing, err := p.next()
+		if err != nil {
+			return searchFilter{}, err
+		}
+		if closing.quoted || closing.value != ")" {
+			return searchFilter{}, fmt.Errorf("%w: expected ) but found %q", errUnsupportedSearch, closing.value)
+		}
+
+		return ret, nil
+	}
+
+	return p.parseRelation()
+}
+
+// parseRelation parses a property, an operator and a value.
+func (p *searchParser) parseRelation() (searchFilter, error) {
+	var tokens [3]searchToken
+	for i := range tokens {
+		t, err := p.next()
+		if err != nil {
+			return searchFilter{}, err
+		}
+		tokens[i] = t
+	}
+
+	property := tokens[0].value
+	op := strings.ToLower(tokens[1].value)
+	value := tokens[2]
+
+	if tokens[0].quoted || tokens[1].quoted {
+		return searchFilter{}, fmt.Errorf("%w: invalid expression", errUnsupportedSearch)
+	}
+
+	if op == "exists" {
+		if value.quoted {
+			return searchFilter{}, fmt.Errorf("%w: exists requires true or false", errUnsupportedSearch)
+		}
+		return existsFilter(property, strings.EqualFold(value.value, "true"))
+	}
+
+	if !value.quoted {
+		return searchFilter{}, fmt.Errorf("%w: %s requires a quoted value", errUnsupportedSearch, op)
+	}
+
+	switch property {
+	case "dc:title":
+		return titleFilter(op, value.value)
+	case "upnp:class":
+		return classFilter(op, value.value)
+	}
+
+	return searchFilter{}, fmt.Errorf("%w: unsupported property %s", errUnsupportedSearch, property)
+}
+
+// titleFilter returns a filter matching the displayed title of scenes,
+// which is the file name of scenes without a title.
+func titleFilter(op string, value string) (searchFilter, error) {
+	// matches the value in the file name of the path
+	contains := "(?i)" + regexp.QuoteMeta(value) + `[^/\\]*$`
+	equals := `(?i)(^|[/\\])` + regexp.QuoteMeta(value) + "$"
+
+	switch op {
+	case "contains":
+		return displayedTitleFilter(models.CriterionModifierIncludes, value, models.CriterionModifierMatchesRegex, contains), nil
+	case "doesnotcontain":
+		return displayedTitleFilter(models.CriterionModifierExcludes, value, models.CriterionModifierNotMatchesRegex, contains), nil
+	case "=":
+		return displayedTitleFilter(models.CriterionModifierEquals, value, models.CriterionModifierMatchesRegex, equals), nil
+	case "!=":
+		return displayedTitleFilter(models.CriterionModifierNotEquals, value, models.CriterionModifierNotMatchesRegex, equals), nil
+	}
+
+	return searchFilter{}, fmt.Errorf("%w: unsupported operator %s for dc:title", errUnsupportedSearch, op)
+}
+
+// displayedTitleFilter returns a filter matching the title criterion for
+// scenes with a title, and the path criterion for scenes without a title.
+func displayedTitleFilter(titleModifier models.CriterionModifier, title string, pathModifier models.CriterionModifier, path string) searchFilter {
+	titled := &models.SceneFilterType{
+		Title: &models.StringCriterionInput{
+			Value:    title,
+			Modifier: titleModifier,
+		},
+	}
+	untitled := &models.SceneFilterType{
+		Title: &models.StringCriterionInput{
+			Modifier: models.CriterionModifierIsNull,
+		},
+		Path: &models.StringCriterionInput{
+			Value:    path,
+			Modifier: pathModifier,
+		},
+	}
+
+	if titleModifier == models.CriterionModifierIncludes || titleModifier == models.CriterionModifierEquals {
+		titled.Or = untitled
+		return searchFilter{filter: titled}
+	}
+
+	// negated criteria match empty titles, so they are restricted to
+	// scenes with a title
+	titled.And = &models.SceneFilterType{
+		Title: &models.StringCriterionInput{
+			Modifier: models.CriterionModifierNotNull,
+		},
+	}
+	untitled.Or = titled
+	return searchFilter{filter: untitled}
+}
+
+// classFilter returns a filter matching all scenes if scenes are of the
+// class, otherwise a filter matching no scenes. Only scenes are searched.
+func classFilter(op string, value string) (searchFilter, error) {
+	var matches bool
+	switch op {
+	case "=":
+		matches = value == videoItemClass
+	case "!=":
+		matches = value != videoItemClass
+	case "derivedfrom":
+		matches = value == videoItemClass || strings.HasPrefix(videoItemClass, value+".")
+	default:
+		return searchFilter{}, fmt.Errorf("%w: unsupported operator %s for upnp:class", errUnsupportedSearch, op)
+	}
+
+	return searchFilter{none: !matches}, nil
+}
+
+func existsFilter(property string, exists bool) (searchFilter, error) {
+	switch property {
+	case "dc:title":
+		// all scenes have a displayed title
+		return searchFilter{none: !exists}, nil
+	case "upnp:class":
+		// all scenes have a class
+		return searchFilter{none: !exists}, nil
+	}
+
+	return searchFilter{}, fmt.Errorf("%w: unsupported property %s", errUnsupportedSearch, property)
+}
@@ -0,0 +1,230 @@
+package dlna
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/anacrolix/dms/upnp"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func titleCriterion(modifier models.CriterionModifier, value string) *models.SceneFilterType {
+	return &models.SceneFilterType{
+		Title: &models.StringCriterionInput{
+			Modifier: modifier,
+			Value:    value,
+		},
+	}
+}
+
+// containsTitle returns the filter matching scenes with a displayed title
+// containing value.
+func containsTitle(value string) *models.SceneFilterType {
+	ret := titleCriterion(models.CriterionModifierIncludes, value)
+	ret.Or = untitledPath(models.CriterionModifierMatchesRegex, "(?i)"+value+`[^/\\]*$`)
+	return ret
+}
+
+func untitledPath(modifier models.CriterionModifier, value string) *models.SceneFilterType {
+	return &models.SceneFilterType{
+		Title: &models.StringCriterionInput{
+			Modifier: models.CriterionModifierIsNull,
+		},
+		Path: &models.StringCriterionInput{
+			Modifier: modifier,
+			Value:    value,
+		},
+	}
+}
+
+func TestParseSearchCriteria(t *testing.T) {
+	withOr := func(f *models.SceneFilterType, sub *models.SceneFilterType) *models.SceneFilterType {
+		f.Or.Or = sub
+		return f
+	}
+
+	doesNotContain := untitledPath(models.CriterionModifierNotMatchesRegex, `(?i)foo[^/\\]*$`)
+	doesNotContain.Or = titleCriterion(models.CriterionModifierExcludes, "foo")
+	doesNotContain.Or.And = titleCriterion(models.CriterionModifierNotNull, "")
+
+	equals := titleCriterion(models.CriterionModifierEquals, `foo "bar"`)
+	equals.Or = untitledPath(models.CriterionModifierMatchesRegex, `(?i)(^|[/\\])foo "bar"$`)
+
+	tests := []struct {
+		name     string
+		criteria string
+		want     searchFilter
+	}{
+		{"all", "*", searchFilter{}},
+		{"title contains", `dc:title contains "foo"`, searchFilter{filter: containsTitle("foo")}},
+		{"title does not contain", `dc:title doesNotContain "foo"`, searchFilter{filter: doesNotContain}},
+		{"title equals", `dc:title="foo \"bar\""`, searchFilter{filter: equals}},
+		{"title exists", `dc:title exists true`, searchFilter{}},
+		{"title does not exist", `dc:title exists false`, searchFilter{none: true}},
+		{"video class", `upnp:class derivedfrom "object.item.videoItem"`, searchFilter{}},
+		{"item class", `upnp:class derivedfrom "object.item"`, searchFilter{}},
+		{"audio class", `upnp:class derivedfrom "object.item.audioItem"`, searchFilter{none: true}},
+		{"class and title", `upnp:class derivedfrom "object.item.videoItem" and dc:title contains "foo"`, searchFilter{filter: containsTitle("foo")}},
+		{"audio class and title", `upnp:class = "object.item.audioItem" and dc:title contains "foo"`, searchFilter{none: true}},
+		{"audio class or title", `upnp:class = "object.item.audioItem" or dc:title contains "foo"`, searchFilter{filter: containsTitle("foo")}},
+		{
+			"title or title",
+			`dc:title contains "foo" or dc:title contains "bar"`,
+			searchFilter{filter: withOr(containsTitle("foo"), containsTitle("bar"))},
+		},
+		{
+			"and binds tighter than or",
+			`dc:title contains "a" or upnp:class = "object.item.audioItem" and dc:title contains "b"`,
+			searchFilter{filter: containsTitle("a")},
+		},
+		{
+			"parentheses",
+			`upnp:class = "object.item.audioItem" and (dc:title contains "a" or dc:title contains "b")`,
+			searchFilter{none: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSearchCriteria(tt.criteria)
+			if err != nil {
+				t.Fatalf("parseSearchCriteria() error = %v", err)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTitleFilterPath(t *testing.T) {
+	tests := []struct {
+		name  string
+		op    string
+		value string
+		path  string
+		want  bool
+	}{
+		{"contains file name", "contains", "holiday", "/videos/Summer Holiday.mp4", true},
+		{"contains directory", "contains", "videos", "/videos/Summer Holiday.mp4", false},
+		{"contains windows file name", "contains", "holiday", `C:\videos\Summer Holiday.mp4`, true},
+		{"equals file name", "=", "summer holiday.mp4", "/videos/Summer Holiday.mp4", true},
+		{"equals part of file name", "=", "holiday.mp4", "/videos/Summer Holiday.mp4", false},
+		{"special characters", "contains", "(1)", "/videos/clip (1).mp4", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := titleFilter(tt.op, tt.value)
+			if err != nil {
+				t.Fatalf("titleFilter() error = %v", err)
+			}
+			untitled := got.filter.Or
+			assert.Equal(t, models.CriterionModifierIsNull, untitled.Title.Modifier)
+			assert.Equal(t, tt.want, regexp.MustCompile(untitled.Path.Value).MatchString(tt.path))
+		})
+	}
+}
+
+func TestParseSearchCriteriaUnsupported(t *testing.T) {
+	for _, criteria := range []string{
+		`upnp:artist contains "foo"`,
+		`dc:title < "foo"`,
+		`dc:title contains foo`,
+		`dc:title contains "foo`,
+		`dc:title contains`,
+		`(dc:title contains "foo"`,
+		`dc:title contains "foo" dc:title contains "bar"`,
+		`(dc:title contains "a" or dc:title contains "b") and (dc:title contains "c" or dc:title contains "d")`,
+		`dc:title contains "a" and dc:title contains "b"`,
+	} {
+		if _, err := parseSearchCriteria(criteria); !errors.Is(err, errUnsupportedSearch) {
+			t.Errorf("parseSearchCriteria(%q) error = %v, want %v", criteria, err, errUnsupportedSearch)
+		}
+	}
+}
+
+func testHandleSearch(repo *mocks.TransactionManager, containerID string, criteria string) (map[string]string, error) {
+	cds := contentDirectoryService{
+		Server:     &Server{},
+		txnManager: repo,
+	}
+
+	argsXML := `<u:Search xmlns:u="urn:schemas-upnp-org:service:ContentDirectory:1"><ContainerID>` + containerID + `</ContainerID><SearchCriteria>` + criteria + `</SearchCriteria><Filter>*</Filter><StartingIndex>0</StartingIndex><RequestedCount>10</RequestedCount><SortCriteria></SortCriteria></u:Search>`
+	return cds.Handle("Search", []byte(argsXML), &http.Request{})
+}
+
+func TestSearchInContainer(t *testing.T) {
+	repo := mocks.NewTransactionManager()
+	qb := repo.Scene().(*mocks.SceneReaderWriter)
+
+	isTagTitleFilter := mock.MatchedBy(func(options models.SceneQueryOptions) bool {
+		f := options.SceneFilter
+		return f.Tags != nil && f.Tags.Value[0] == "12" && f.And != nil && f.And.Title != nil && f.And.Title.Value == "foo"
+	})
+
+	result := models.NewSceneQueryResult(qb)
+	result.IDs = []int{3}
+	result.Count = 1
+	qb.On("Query", isTagTitleFilter).Return(result, nil).Once()
+	qb.On("FindMany", []int{3}).Return([]*models.Scene{{ID: 3}}, nil).Once()
+
+	ret, err := testHandleSearch(repo, "tags%2F12", `dc:title contains &quot;foo&quot;`)
+	if err != nil {
+		t.Fatalf("Search error = %v", err)
+	}
+
+	assert.Equal(t, "1", ret["TotalMatches"])
+	assert.Equal(t, "1", ret["NumberReturned"])
+	qb.AssertExpectations(t)
+}
+
+func TestSearchUnsupported(t *testing.T) {
+	_, err := testHandleSearch(mocks.NewTransactionManager(), "0", `upnp:artist contains &quot;foo&quot;`)
+
+	var upnpErr *upnp.Error
+	if !errors.As(err, &upnpErr) || upnpErr.Code != unsupportedSearchCriteriaErrorCode {
+		t.Errorf("Search error = %v, want error code %d", err, unsupportedSearchCriteriaErrorCode)
+	}
+}
+
+func TestSearchOtherClass(t *testing.T) {
+	ret, err := testHandleSearch(mocks.NewTransactionManager(), "0", `upnp:class derivedfrom &quot;object.item.audioItem&quot;`)
+	if err != nil {
+		t.Fatalf("Search error = %v", err)
+	}
+
+	assert.Equal(t, "0", ret["TotalMatches"])
+}
+
+func TestSearchUntitledScene(t *testing.T) {
+	repo := mocks.NewTransactionManager()
+	qb := repo.Scene().(*mocks.SceneReaderWriter)
+
+	const path = "/videos/Summer Holiday.mp4"
+
+	// the scene has no title, so is displayed with its file name
+	matchesUntitled := mock.MatchedBy(func(options models.SceneQueryOptions) bool {
+		untitled := options.SceneFilter.Or
+		return untitled != nil && untitled.Title.Modifier == models.CriterionModifierIsNull &&
+			regexp.MustCompile(untitled.Path.Value).MatchString(path)
+	})
+
+	result := models.NewSceneQueryResult(qb)
+	result.IDs = []int{4}
+	result.Count = 1
+	qb.On("Query", matchesUntitled).Return(result, nil).Once()
+	qb.On("FindMany", []int{4}).Return([]*models.Scene{{ID: 4, Path: path}}, nil).Once()
+
+	ret, err := testHandleSearch(repo, "0", `dc:title contains &quot;holiday&quot;`)
+	if err != nil {
+		t.Fatalf("Search error = %v", err)
+	}
+
+	assert.Equal(t, "1", ret["TotalMatches"])
+	assert.Contains(t, ret["Result"], "Summer Holiday.mp4")
+	qb.AssertExpectations(t)
+}
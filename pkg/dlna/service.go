@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/stashapp/stash/pkg/ffmpeg"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
@@ -25,6 +26,8 @@ type dmsConfig struct {
 
 type sceneServer interface {
 	StreamSceneDirect(scene *models.Scene, w http.ResponseWriter, r *http.Request)
+	StreamSceneTranscode(scene *models.Scene, w http.ResponseWriter, r *http.Request, videoCodec ffmpeg.Codec)
+	HasTranscode(scene *models.Scene) bool
 	ServeScreenshot(scene *models.Scene, w http.ResponseWriter, r *http.Request)
 }
 
@@ -103,6 +106,8 @@ func (s *Service) init() error {
 		txnManager:         s.txnManager,
 		sceneServer:        s.sceneServer,
 		ipWhitelistManager: s.ipWhitelistMgr,
+		transcodeMode:      s.config.GetDLNATranscodeMode,
+		transcodeLimiter:   newTranscodeLimiter(s.config.GetDLNAMaxTranscodes, s.config.GetDLNARejectExcessTranscodes),
 		Interfaces:         interfaces,
 		HTTPConn: func() net.Listener {
 			conn, err := net.Listen("tcp", dmsConfig.Http)
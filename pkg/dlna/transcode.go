@@ -0,0 +1,139 @@
+package dlna
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// clientFormats are the formats that a DLNA client can play directly.
+type clientFormats struct {
+	mimeTypes   []string
+	videoCodecs []string
+}
+
+// defaultClientFormats are assumed to be playable by clients that do not
+// list the content types they accept. H.264 in mp4 or matroska is
+// supported by almost all DLNA renderers.
+var defaultClientFormats = clientFormats{
+	mimeTypes:   []string{ffmpeg.MimeMp4, ffmpeg.MimeMkv},
+	videoCodecs: []string{ffmpeg.H264},
+}
+
+// dlnaProfileMimeTypes maps the prefixes of DLNA.ORG_PN media profiles to
+// the content type of the profile.
+var dlnaProfileMimeTypes = map[string]string{
+	"AVC_MP4":  ffmpeg.MimeMp4,
+	"HEVC_MP4": ffmpeg.MimeMp4,
+	"AVC_MKV":  ffmpeg.MimeMkv,
+	"HEVC_MKV": ffmpeg.MimeMkv,
+	"MPEG_TS":  ffmpeg.MimeMpegts,
+	"AVC_TS":   ffmpeg.MimeMpegts,
+}
+
+// getClientFormats returns the formats that the client of the request can
+// play. Clients may list content types in the Accept header, either as
+// content types or as protocolInfo entries with DLNA.ORG_PN profiles.
+// Defaults are returned if the client does not list any video content types.
+func getClientFormats(r *http.Request) clientFormats {
+	var mimeTypes []string
+	videoCodecs := []string{ffmpeg.H264}
+
+	add := func(mimeType string) {
+		for _, m := range mimeTypes {
+			if strings.EqualFold(m, mimeType) {
+				return
+			}
+		}
+		mimeTypes = append(mimeTypes, mimeType)
+	}
+
+	for _, header := range r.Header.Values("Accept") {
+		for _, entry := range strings.Split(header, ",") {
+			entry = strings.TrimSpace(entry)
+
+			// protocolInfo entries: http-get:*:video/mp4:DLNA.ORG_PN=AVC_MP4_MP_SD
+			if fields := strings.Split(entry, ":"); len(fields) == 4 {
+				entry = fields[2]
+				for _, param := range strings.Split(fields[3], ";") {
+					profile := strings.TrimPrefix(param, "DLNA.ORG_PN=")
+					if profile == param {
+						continue
+					}
+					for prefix, mimeType := range dlnaProfileMimeTypes {
+						if strings.HasPrefix(profile, prefix) {
+							add(mimeType)
+						}
+					}
+					if strings.HasPrefix(profile, "HEVC_") {
+						videoCodecs = append(videoCodecs, ffmpeg.Hevc, ffmpeg.H265)
+					}
+				}
+			}
+
+			mimeType := strings.ToLower(strings.TrimSpace(strings.Split(entry, ";")[0]))
+			if strings.HasPrefix(mimeType, "video/") && mimeType != "video/*" {
+				add(mimeType)
+			}
+		}
+	}
+
+	if len(mimeTypes) == 0 {
+		return defaultClientFormats
+	}
+
+	return clientFormats{
+		mimeTypes:   mimeTypes,
+		videoCodecs: videoCodecs,
+	}
+}
+
+// canPlay returns true if the client can play the scene file directly.
+func (f clientFormats) canPlay(scene *models.Scene) bool {
+	container := ffmpeg.Container(scene.Format.String)
+	mimeType := ffmpeg.ContainerMimeType(container)
+	if mimeType == "" {
+		return false
+	}
+
+	supported := false
+	for _, m := range f.mimeTypes {
+		if strings.EqualFold(m, mimeType) {
+			supported = true
+			break
+		}
+	}
+
+	if !supported || !ffmpeg.IsValidCodec(scene.VideoCodec.String, f.videoCodecs) {
+		return false
+	}
+
+	audioCodec := ffmpeg.MissingUnsupported
+	if scene.AudioCodec.Valid {
+		audioCodec = ffmpeg.AudioCodec(scene.AudioCodec.String)
+	}
+
+	// containers without audio restrictions in ffmpeg are accepted
+	switch container {
+	case ffmpeg.Mp4, ffmpeg.M4v, ffmpeg.Matroska, ffmpeg.Webm:
+		return ffmpeg.IsValidAudioForContainer(audioCodec, container)
+	}
+
+	return true
+}
+
+// needsTranscode returns true if the scene should be transcoded for a
+// client that can play formats. Scenes with a generated transcode are
+// streamed directly, since the transcode is playable by all clients.
+func needsTranscode(mode models.DLNATranscodeMode, scene *models.Scene, hasTranscode bool, formats clientFormats) bool {
+	switch mode {
+	case models.DLNATranscodeModeAlways:
+		return true
+	case models.DLNATranscodeModeNever:
+		return false
+	}
+
+	return !hasTranscode && !formats.canPlay(scene)
+}
@@ -0,0 +1,86 @@
+package dlna
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// errTooManyTranscodes is returned when a transcode is rejected because the
+// maximum number of DLNA transcodes are running.
+var errTooManyTranscodes = errors.New("too many concurrent DLNA transcodes")
+
+// transcodeLimiter limits the number of concurrent transcodes for DLNA
+// clients. Transcodes are also limited by the resources shared with other
+// heavy work, which are acquired by the scene server.
+type transcodeLimiter struct {
+	// limit returns the maximum number of concurrent transcodes, or zero if
+	// not limited
+	limit func() int
+	// reject returns true if transcodes beyond the limit are rejected,
+	// rather than waiting for a running transcode to finish
+	reject func() bool
+
+	mutex   sync.Mutex
+	running int
+	// released is closed when a transcode finishes, waking waiting
+	// transcodes
+	released chan struct{}
+}
+
+func newTranscodeLimiter(limit func() int, reject func() bool) *transcodeLimiter {
+	return &transcodeLimiter{
+		limit:    limit,
+		reject:   reject,
+		released: make(chan struct{}),
+	}
+}
+
+// acquire reserves a transcode, waiting for a running transcode to finish if
+// the limit is reached. The returned function must be called once the
+// transcode is finished. Returns errTooManyTranscodes if the limit is
+// reached and excess transcodes are rejected, or the context's error if it
+// is cancelled while waiting. A nil transcodeLimiter has no limit.
+func (l *transcodeLimiter) acquire(ctx context.Context) (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+
+	l.mutex.Lock()
+	for {
+		if limit := l.limit(); limit <= 0 || l.running < limit {
+			l.running++
+			l.mutex.Unlock()
+
+			var once sync.Once
+			return func() {
+				once.Do(l.release)
+			}, nil
+		}
+
+		if l.reject() {
+			l.mutex.Unlock()
+			return nil, errTooManyTranscodes
+		}
+
+		released := l.released
+		l.mutex.Unlock()
+
+		select {
+		case <-released:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		l.mutex.Lock()
+	}
+}
+
+func (l *transcodeLimiter) release() {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	l.running--
+	close(l.released)
+	l.released = make(chan struct{})
+}
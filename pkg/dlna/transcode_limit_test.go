@@ -0,0 +1,162 @@
+package dlna
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const transcodeWaitTime = 20 * time.Millisecond
+
+func newTestTranscodeLimiter(limit int, reject bool) *transcodeLimiter {
+	return newTranscodeLimiter(func() int { return limit }, func() bool { return reject })
+}
+
+// acquireAsync acquires a transcode in a goroutine, sending the result of
+// the acquisition on the returned channel.
+func acquireAsync(ctx context.Context, l *transcodeLimiter) <-chan error {
+	ret := make(chan error, 1)
+	go func() {
+		release, err := l.acquire(ctx)
+		if err == nil {
+			defer release()
+		}
+		ret <- err
+	}()
+	return ret
+}
+
+func acquireN(t *testing.T, l *transcodeLimiter, n int) []func() {
+	var ret []func()
+	for i := 0; i < n; i++ {
+		release, err := l.acquire(context.Background())
+		if err != nil {
+			t.Fatalf("acquire %d: %v", i, err)
+		}
+		ret = append(ret, release)
+	}
+	return ret
+}
+
+func TestTranscodeLimiterQueue(t *testing.T) {
+	l := newTestTranscodeLimiter(2, false)
+	releases := acquireN(t, l, 2)
+
+	queued := acquireAsync(context.Background(), l)
+
+	select {
+	case err := <-queued:
+		t.Fatalf("transcode beyond the limit was not queued: %v", err)
+	case <-time.After(transcodeWaitTime):
+	}
+
+	// releasing twice only frees one transcode
+	releases[0]()
+	releases[0]()
+
+	select {
+	case err := <-queued:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("queued transcode did not start after a transcode finished")
+	}
+
+	releases[1]()
+	assert.Equal(t, 0, l.running)
+}
+
+func TestTranscodeLimiterQueueCancelled(t *testing.T) {
+	l := newTestTranscodeLimiter(1, false)
+	release := acquireN(t, l, 1)[0]
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	queued := acquireAsync(ctx, l)
+	cancel()
+
+	select {
+	case err := <-queued:
+		assert.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("queued transcode was not cancelled")
+	}
+}
+
+func TestTranscodeLimiterReject(t *testing.T) {
+	l := newTestTranscodeLimiter(2, true)
+	releases := acquireN(t, l, 2)
+
+	_, err := l.acquire(context.Background())
+	assert.Equal(t, errTooManyTranscodes, err)
+
+	releases[0]()
+
+	release, err := l.acquire(context.Background())
+	if assert.Nil(t, err) {
+		release()
+	}
+	releases[1]()
+}
+
+func TestTranscodeLimiterUnlimited(t *testing.T) {
+	l := newTestTranscodeLimiter(0, true)
+	for _, release := range acquireN(t, l, 10) {
+		release()
+	}
+
+	var nilLimiter *transcodeLimiter
+	release, err := nilLimiter.acquire(context.Background())
+	if assert.Nil(t, err) {
+		release()
+	}
+}
+
+type testSceneServer struct {
+	transcodes int
+}
+
+func (s *testSceneServer) StreamSceneDirect(scene *models.Scene, w http.ResponseWriter, r *http.Request) {
+}
+
+func (s *testSceneServer) StreamSceneTranscode(scene *models.Scene, w http.ResponseWriter, r *http.Request, videoCodec ffmpeg.Codec) {
+	s.transcodes++
+}
+
+func (s *testSceneServer) HasTranscode(scene *models.Scene) bool {
+	return false
+}
+
+func (s *testSceneServer) ServeScreenshot(scene *models.Scene, w http.ResponseWriter, r *http.Request) {
+}
+
+func TestStreamSceneTranscodeRejected(t *testing.T) {
+	sceneServer := &testSceneServer{}
+	server := &Server{
+		sceneServer:      sceneServer,
+		transcodeLimiter: newTestTranscodeLimiter(1, true),
+	}
+
+	release := acquireN(t, server.transcodeLimiter, 1)[0]
+
+	scene := &models.Scene{Path: "scene.mkv"}
+	w := httptest.NewRecorder()
+	server.streamSceneTranscode(scene, w, httptest.NewRequest(http.MethodGet, "/res", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Equal(t, 0, sceneServer.transcodes)
+
+	release()
+
+	w = httptest.NewRecorder()
+	server.streamSceneTranscode(scene, w, httptest.NewRequest(http.MethodGet, "/res", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 1, sceneServer.transcodes)
+	assert.Equal(t, 0, server.transcodeLimiter.running)
+}
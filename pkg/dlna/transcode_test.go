@@ -0,0 +1,86 @@
+package dlna
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeFormatScene(format, videoCodec, audioCodec string) *models.Scene {
+	return &models.Scene{
+		Format:     sql.NullString{String: format, Valid: true},
+		VideoCodec: sql.NullString{String: videoCodec, Valid: true},
+		AudioCodec: sql.NullString{String: audioCodec, Valid: audioCodec != ""},
+	}
+}
+
+func TestNeedsTranscode(t *testing.T) {
+	compatible := makeFormatScene("mp4", "h264", "aac")
+	incompatibleCodec := makeFormatScene("mp4", "mpeg4", "aac")
+	incompatibleContainer := makeFormatScene("avi", "h264", "mp3")
+	incompatibleAudio := makeFormatScene("mp4", "h264", "opus")
+	unknown := &models.Scene{}
+
+	tests := []struct {
+		name         string
+		mode         models.DLNATranscodeMode
+		scene        *models.Scene
+		hasTranscode bool
+		want         bool
+	}{
+		{"compatible", models.DLNATranscodeModeAuto, compatible, false, false},
+		{"incompatible codec", models.DLNATranscodeModeAuto, incompatibleCodec, false, true},
+		{"incompatible container", models.DLNATranscodeModeAuto, incompatibleContainer, false, true},
+		{"incompatible audio", models.DLNATranscodeModeAuto, incompatibleAudio, false, true},
+		{"unknown format", models.DLNATranscodeModeAuto, unknown, false, true},
+		{"generated transcode", models.DLNATranscodeModeAuto, incompatibleCodec, true, false},
+		{"always compatible", models.DLNATranscodeModeAlways, compatible, false, true},
+		{"never incompatible", models.DLNATranscodeModeNever, incompatibleCodec, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := needsTranscode(tt.mode, tt.scene, tt.hasTranscode, defaultClientFormats)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNeedsTranscodeClientFormats(t *testing.T) {
+	mkv := makeFormatScene("matroska", "h264", "aac")
+	hevc := makeFormatScene("mp4", "hevc", "aac")
+
+	request := func(accept string) *http.Request {
+		r, _ := http.NewRequest(http.MethodGet, "/res", nil)
+		if accept != "" {
+			r.Header.Set("Accept", accept)
+		}
+		return r
+	}
+
+	tests := []struct {
+		name   string
+		accept string
+		scene  *models.Scene
+		want   bool
+	}{
+		{"default mkv", "", mkv, false},
+		{"wildcard mkv", "*/*, video/*", mkv, false},
+		{"mp4 only mkv", "video/mp4", mkv, true},
+		{"mkv accepted", "video/mp4, video/x-matroska;q=0.9", mkv, false},
+		{"default hevc", "", hevc, true},
+		{"mp4 profile hevc", "http-get:*:video/mp4:DLNA.ORG_PN=AVC_MP4_MP_SD", hevc, true},
+		{"hevc profile", "http-get:*:video/mp4:DLNA.ORG_PN=HEVC_MP4_MP_L51", hevc, false},
+		{"mkv profile", "http-get:*:video/x-matroska:DLNA.ORG_PN=AVC_MKV_HP_HD", mkv, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := needsTranscode(models.DLNATranscodeModeAuto, tt.scene, false, getClientFormats(request(tt.accept)))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/desktop"
+)
+
+// Capabilities are the version, encoders, muxers and filters of an ffmpeg
+// binary.
+type Capabilities struct {
+	Version  string
+	Encoders map[string]bool
+	Muxers   map[string]bool
+	Filters  map[string]bool
+}
+
+// ProbeCapabilities runs the ffmpeg executable at path to find its version,
+// encoders, muxers and filters.
+func ProbeCapabilities(path string) (*Capabilities, error) {
+	version, err := GetVersion(path)
+	if err != nil {
+		return nil, err
+	}
+
+	encoders, err := probeList(path, "-encoders")
+	if err != nil {
+		return nil, err
+	}
+
+	muxers, err := probeList(path, "-muxers")
+	if err != nil {
+		return nil, err
+	}
+
+	filters, err := probeList(path, "-filters")
+	if err != nil {
+		return nil, err
+	}
+
+	return &Capabilities{
+		Version:  version,
+		Encoders: parseEncoders(encoders),
+		Muxers:   parseMuxers(muxers),
+		Filters:  parseFilters(filters),
+	}, nil
+}
+
+func probeList(path string, flag string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, "-hide_banner", flag)
+	desktop.HideExecShell(cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("error running %s %s: %w", path, flag, err)
+	}
+
+	return string(out), nil
+}
+
+// parseEncoders parses the output of ffmpeg -encoders. Encoders are listed
+// after a line of dashes, as "<flags> <name> <description>".
+func parseEncoders(out string) map[string]bool {
+	ret := make(map[string]bool)
+	started := false
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if !started {
+			started = len(fields) == 1 && strings.HasPrefix(fields[0], "---")
+			continue
+		}
+
+		if len(fields) >= 2 {
+			ret[fields[1]] = true
+		}
+	}
+
+	return ret
+}
+
+// parseMuxers parses the output of ffmpeg -muxers. Muxers are listed after
+// a line of dashes, as "<flags> <names> <description>", where names are
+// separated by commas.
+func parseMuxers(out string) map[string]bool {
+	ret := make(map[string]bool)
+	started := false
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if !started {
+			started = len(fields) == 1 && strings.HasPrefix(fields[0], "--")
+			continue
+		}
+
+		if len(fields) >= 2 {
+			for _, name := range strings.Split(fields[1], ",") {
+				ret[name] = true
+			}
+		}
+	}
+
+	return ret
+}
+
+// parseFilters parses the output of ffmpeg -filters. Filters are listed as
+// "<flags> <name> <inputs>-><outputs> <description>", following a legend
+// of the flags.
+func parseFilters(out string) map[string]bool {
+	ret := make(map[string]bool)
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && strings.Contains(fields[2], "->") {
+			ret[fields[1]] = true
+		}
+	}
+
+	return ret
+}
+
+// SupportsFilter returns true if ffmpeg has the filter. The drawtext filter
+// is only available if ffmpeg is built with libfreetype. Returns false if c
+// is nil.
+func (c *Capabilities) SupportsFilter(name string) bool {
+	if c == nil {
+		return false
+	}
+
+	return c.Filters[name]
+}
+
+// SupportsImageFormat returns true if images of the format can be encoded.
+// Returns false if c is nil.
+func (c *Capabilities) SupportsImageFormat(format ImageFormat) bool {
+	if c == nil {
+		return false
+	}
+
+	args, ok := imageFormats[format]
+	if !ok {
+		return false
+	}
+
+	return c.Encoders[args.encoder] && c.Muxers[args.muxer]
+}
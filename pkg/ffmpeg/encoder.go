@@ -96,6 +96,10 @@ func (e *Encoder) runTranscode(probeResult VideoFile, args []string) (string, er
 		return "", err
 	}
 
+	// register before reading the output so that the process can be
+	// killed while it is running
+	registerRunningEncoder(probeResult.Path, cmd.Process)
+
 	buf := make([]byte, 80)
 	lastProgress := 0.0
 	var errBuilder strings.Builder
@@ -123,7 +127,6 @@ func (e *Encoder) runTranscode(probeResult VideoFile, args []string) (string, er
 	stdoutData, _ := io.ReadAll(stdout)
 	stdoutString := string(stdoutData)
 
-	registerRunningEncoder(probeResult.Path, cmd.Process)
 	err = waitAndDeregister(probeResult.Path, cmd)
 
 	if err != nil {
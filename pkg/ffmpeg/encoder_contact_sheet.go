@@ -0,0 +1,120 @@
+package ffmpeg
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// contactSheetHeaderHeight is the height in pixels of the header above the
+// frames of a contact sheet.
+const contactSheetHeaderHeight = 40
+
+type ContactSheetOptions struct {
+	OutputPath string
+	// Columns and Rows are the dimensions of the grid of frames.
+	Columns int
+	Rows    int
+	// Width is the width of each frame in pixels.
+	Width int
+	// Header is the text drawn above the frames. No header is drawn if
+	// empty. Drawing the header requires the drawtext filter.
+	Header string
+}
+
+// escapeChars prefixes each of the chars in s with a backslash.
+func escapeChars(s string, chars string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(chars, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// escapeDrawText escapes text for use as the text option of the drawtext
+// filter in a filtergraph. The text is escaped for the drawtext expansion,
+// then the filter option and then the filtergraph.
+func escapeDrawText(text string) string {
+	text = escapeChars(text, `\%`)
+	text = escapeChars(text, `\':`)
+	return escapeChars(text, `\',;[]`)
+}
+
+// filter returns the filtergraph that tiles the frames of the inputs, where
+// each input is seeked to the time of its frame.
+func (o ContactSheetOptions) filter(frames int) string {
+	var filters []string
+	var labels strings.Builder
+	for i := 0; i < frames; i++ {
+		filters = append(filters, fmt.Sprintf("[%d:v]trim=end_frame=1,setpts=PTS-STARTPTS,scale=%d:-2[f%d]", i, o.Width, i))
+		fmt.Fprintf(&labels, "[f%d]", i)
+	}
+
+	tile := []string{
+		fmt.Sprintf("%sconcat=n=%d:v=1:a=0", labels.String(), frames),
+		fmt.Sprintf("tile=%dx%d", o.Columns, o.Rows),
+	}
+
+	if o.Header != "" {
+		tile = append(tile,
+			fmt.Sprintf("pad=iw:ih+%d:0:%d:color=black", contactSheetHeaderHeight, contactSheetHeaderHeight),
+			fmt.Sprintf("drawtext=text=%s:x=10:y=(%d-th)/2:fontsize=%d:fontcolor=white", escapeDrawText(o.Header), contactSheetHeaderHeight, contactSheetHeaderHeight/2),
+		)
+	}
+
+	return strings.Join(append(filters, strings.Join(tile, ",")), ";")
+}
+
+// args returns the ffmpeg arguments to sample Columns*Rows frames evenly
+// across the video and tile them into a single image. Each frame is taken
+// from the middle of its interval, which skips the first frame of the
+// video that is often black. The video is opened as an input for each
+// frame, seeked to the frame's time, so that the video is not decoded
+// between frames.
+func (o ContactSheetOptions) args(probeResult VideoFile) ([]string, error) {
+	if o.Columns < 1 || o.Rows < 1 {
+		return nil, fmt.Errorf("invalid contact sheet dimensions %dx%d", o.Columns, o.Rows)
+	}
+	if probeResult.Duration <= 0 {
+		return nil, errors.New("video duration is required for a contact sheet")
+	}
+
+	frames := o.Columns * o.Rows
+	interval := probeResult.Duration / float64(frames)
+
+	args := []string{
+		"-v", "error",
+		"-y",
+	}
+	for i := 0; i < frames; i++ {
+		at := interval*float64(i) + interval/2
+		args = append(args,
+			"-ss", strconv.FormatFloat(at, 'f', -1, 64),
+			"-i", probeResult.Path,
+		)
+	}
+
+	return append(args,
+		"-filter_complex", o.filter(frames),
+		"-frames:v", "1",
+		"-q:v", "2",
+		"-f", "image2",
+		o.OutputPath,
+	), nil
+}
+
+// ContactSheet writes an image of a grid of frames sampled evenly across the
+// video to the output path.
+func (e *Encoder) ContactSheet(probeResult VideoFile, options ContactSheetOptions) error {
+	args, err := options.args(probeResult)
+	if err != nil {
+		return err
+	}
+
+	_, err = e.run(probeResult.Path, args, nil)
+	return err
+}
@@ -0,0 +1,86 @@
+package ffmpeg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContactSheetOptionsArgs(t *testing.T) {
+	probe := VideoFile{
+		Path:     "in.mp4",
+		Duration: 160,
+	}
+
+	output := []string{"-frames:v", "1", "-q:v", "2", "-f", "image2", "out.jpg"}
+	withOutput := func(args ...string) []string {
+		return append(args, output...)
+	}
+
+	tests := []struct {
+		name    string
+		options ContactSheetOptions
+		want    []string
+	}{
+		{
+			"no header",
+			ContactSheetOptions{OutputPath: "out.jpg", Columns: 2, Rows: 1, Width: 320},
+			withOutput("-v", "error", "-y",
+				"-ss", "40", "-i", "in.mp4",
+				"-ss", "120", "-i", "in.mp4",
+				"-filter_complex", "[0:v]trim=end_frame=1,setpts=PTS-STARTPTS,scale=320:-2[f0];[1:v]trim=end_frame=1,setpts=PTS-STARTPTS,scale=320:-2[f1];[f0][f1]concat=n=2:v=1:a=0,tile=2x1",
+			),
+		},
+		{
+			"uneven grid",
+			ContactSheetOptions{OutputPath: "out.jpg", Columns: 1, Rows: 4, Width: 200},
+			withOutput("-v", "error", "-y",
+				"-ss", "20", "-i", "in.mp4",
+				"-ss", "60", "-i", "in.mp4",
+				"-ss", "100", "-i", "in.mp4",
+				"-ss", "140", "-i", "in.mp4",
+				"-filter_complex", "[0:v]trim=end_frame=1,setpts=PTS-STARTPTS,scale=200:-2[f0];[1:v]trim=end_frame=1,setpts=PTS-STARTPTS,scale=200:-2[f1];[2:v]trim=end_frame=1,setpts=PTS-STARTPTS,scale=200:-2[f2];[3:v]trim=end_frame=1,setpts=PTS-STARTPTS,scale=200:-2[f3];[f0][f1][f2][f3]concat=n=4:v=1:a=0,tile=1x4",
+			),
+		},
+		{
+			"header",
+			ContactSheetOptions{OutputPath: "out.jpg", Columns: 1, Rows: 1, Width: 320, Header: "Title - 00:02:40"},
+			withOutput("-v", "error", "-y",
+				"-ss", "80", "-i", "in.mp4",
+				"-filter_complex", `[0:v]trim=end_frame=1,setpts=PTS-STARTPTS,scale=320:-2[f0];[f0]concat=n=1:v=1:a=0,tile=1x1,pad=iw:ih+40:0:40:color=black,drawtext=text=Title - 00\\:02\\:40:x=10:y=(40-th)/2:fontsize=20:fontcolor=white`,
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.options.args(probe)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestContactSheetOptionsArgsInvalid(t *testing.T) {
+	_, err := ContactSheetOptions{Columns: 0, Rows: 4}.args(VideoFile{Duration: 10})
+	assert.NotNil(t, err)
+
+	_, err = ContactSheetOptions{Columns: 4, Rows: 4}.args(VideoFile{})
+	assert.NotNil(t, err)
+}
+
+func TestEscapeDrawText(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"plain", "plain"},
+		{"a:b", `a\\:b`},
+		{"it's", `it\\\'s`},
+		{"100%", `100\\\\%`},
+		{"a,b", `a\,b`},
+		{"[a]", `\[a\]`},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, escapeDrawText(tt.text), tt.text)
+	}
+}
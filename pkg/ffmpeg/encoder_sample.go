@@ -0,0 +1,22 @@
+package ffmpeg
+
+// sampleArgs returns the arguments to encode a one second H.264 video of a
+// test pattern to outputPath.
+func sampleArgs(outputPath string) []string {
+	return []string{
+		"-v", "error",
+		"-f", "lavfi",
+		"-i", "testsrc=size=64x64:rate=10:duration=1",
+		"-c:v", "libx264",
+		"-pix_fmt", "yuv420p",
+		"-y",
+		outputPath,
+	}
+}
+
+// EncodeSample encodes a short sample video to outputPath, to check that
+// ffmpeg can encode videos.
+func (e *Encoder) EncodeSample(outputPath string) error {
+	_, err := e.run("", sampleArgs(outputPath), nil)
+	return err
+}
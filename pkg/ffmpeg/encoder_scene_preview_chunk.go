@@ -16,6 +16,12 @@ type ScenePreviewChunkOptions struct {
 }
 
 func (e *Encoder) ScenePreviewVideoChunk(probeResult VideoFile, options ScenePreviewChunkOptions, preset string, fallback bool) error {
+	args := scenePreviewVideoChunkArgs(probeResult, options, preset, fallback)
+	_, err := e.run(probeResult.Path, args, nil)
+	return err
+}
+
+func scenePreviewVideoChunkArgs(probeResult VideoFile, options ScenePreviewChunkOptions, preset string, fallback bool) []string {
 	var fastSeek float64
 	var slowSeek float64
 	fallbackMinSlowSeek := 20.0
@@ -89,8 +95,7 @@ func (e *Encoder) ScenePreviewVideoChunk(probeResult VideoFile, options ScenePre
 	args = append(args, argsAudio...)
 	args = append(args, options.OutputPath)
 
-	_, err := e.run(probeResult.Path, args, nil)
-	return err
+	return args
 }
 
 func (e *Encoder) ScenePreviewVideoChunkCombine(probeResult VideoFile, concatFilePath string, outputPath string) error {
@@ -107,7 +112,13 @@ func (e *Encoder) ScenePreviewVideoChunkCombine(probeResult VideoFile, concatFil
 }
 
 func (e *Encoder) ScenePreviewVideoToImage(probeResult VideoFile, width int, videoPreviewPath string, outputPath string) error {
-	args := []string{
+	args := scenePreviewVideoToImageArgs(width, videoPreviewPath, outputPath)
+	_, err := e.run(probeResult.Path, args, nil)
+	return err
+}
+
+func scenePreviewVideoToImageArgs(width int, videoPreviewPath string, outputPath string) []string {
+	return []string{
 		"-v", "error",
 		"-i", videoPreviewPath,
 		"-y",
@@ -122,6 +133,4 @@ func (e *Encoder) ScenePreviewVideoToImage(probeResult VideoFile, width int, vid
 		"-an",
 		outputPath,
 	}
-	_, err := e.run(probeResult.Path, args, nil)
-	return err
 }
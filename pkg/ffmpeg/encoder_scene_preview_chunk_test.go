@@ -0,0 +1,130 @@
+package ffmpeg
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func argValue(args []string, flag string) []string {
+	var ret []string
+	for i, a := range args {
+		if a == flag && i+1 < len(args) {
+			ret = append(ret, args[i+1])
+		}
+	}
+	return ret
+}
+
+func TestScenePreviewVideoChunkArgs(t *testing.T) {
+	const (
+		path       = "video.mp4"
+		outputPath = "out.mp4"
+		preset     = "slow"
+	)
+
+	probeResult := VideoFile{Path: path}
+
+	tests := []struct {
+		name      string
+		options   ScenePreviewChunkOptions
+		fallback  bool
+		wantSeeks []string
+		wantScale string
+		wantAudio bool
+	}{
+		{
+			"fast seek",
+			ScenePreviewChunkOptions{StartTime: 42.5, Duration: 0.75, Width: 640, Audio: true},
+			false,
+			[]string{"42.50"},
+			"scale=640:-2",
+			true,
+		},
+		{
+			"start of video",
+			ScenePreviewChunkOptions{StartTime: 0, Duration: 2, Width: 320},
+			false,
+			nil,
+			"scale=320:-2",
+			false,
+		},
+		{
+			"fallback long seek",
+			ScenePreviewChunkOptions{StartTime: 50, Duration: 1, Width: 480},
+			true,
+			[]string{"30.00", "20.00"},
+			"scale=480:-2",
+			false,
+		},
+		{
+			"fallback short seek",
+			ScenePreviewChunkOptions{StartTime: 10, Duration: 1, Width: 480},
+			true,
+			[]string{"10.00"},
+			"scale=480:-2",
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			tt.options.OutputPath = outputPath
+			args := scenePreviewVideoChunkArgs(probeResult, tt.options, preset, tt.fallback)
+
+			assert.Equal(tt.wantSeeks, argValue(args, "-ss"))
+			assert.Equal([]string{tt.wantScale}, argValue(args, "-vf"))
+			assert.Equal([]string{preset}, argValue(args, "-preset"))
+			assert.Equal([]string{path}, argValue(args, "-i"))
+			assert.Equal(outputPath, args[len(args)-1])
+			assert.Equal(!tt.fallback, contains(args, "-xerror"))
+			assert.Equal(!tt.wantAudio, contains(args, "-an"))
+
+			wantDuration := []string{formatSeconds(tt.options.Duration)}
+			assert.Equal(wantDuration, argValue(args, "-t"))
+
+			// fast seek must come before the input, slow seek after
+			if tt.fallback && len(tt.wantSeeks) == 2 {
+				assert.Less(indexOf(args, "-ss"), indexOf(args, "-i"))
+				assert.Greater(lastIndexOf(args, "-ss"), indexOf(args, "-i"))
+			}
+		})
+	}
+}
+
+func TestScenePreviewVideoToImageArgs(t *testing.T) {
+	args := scenePreviewVideoToImageArgs(320, "preview.mp4", "preview.webp")
+
+	assert.Equal(t, []string{"scale=320:-2,fps=12"}, argValue(args, "-vf"))
+	assert.Equal(t, []string{"preview.mp4"}, argValue(args, "-i"))
+	assert.Equal(t, "preview.webp", args[len(args)-1])
+}
+
+func formatSeconds(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+func contains(args []string, v string) bool {
+	return indexOf(args, v) != -1
+}
+
+func indexOf(args []string, v string) int {
+	for i, a := range args {
+		if a == v {
+			return i
+		}
+	}
+	return -1
+}
+
+func lastIndexOf(args []string, v string) int {
+	for i := len(args) - 1; i >= 0; i-- {
+		if args[i] == v {
+			return i
+		}
+	}
+	return -1
+}
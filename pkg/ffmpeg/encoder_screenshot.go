@@ -1,34 +1,159 @@
 package ffmpeg
 
-import "fmt"
+import (
+	"fmt"
+	"strconv"
+)
+
+// ImageFormat is the format of images output by ffmpeg.
+type ImageFormat string
+
+const (
+	ImageFormatJpeg ImageFormat = "jpeg"
+	ImageFormatWebp ImageFormat = "webp"
+	ImageFormatAvif ImageFormat = "avif"
+)
+
+func (f ImageFormat) IsValid() bool {
+	_, ok := imageFormats[f]
+	return ok
+}
+
+// Extension returns the file extension of images in the format, such as
+// .jpg. Returns an empty string if the format is not valid.
+func (f ImageFormat) Extension() string {
+	return imageFormats[f].extension
+}
+
+// MimeType returns the MIME type of images in the format. Returns an empty
+// string if the format is not valid.
+func (f ImageFormat) MimeType() string {
+	return imageFormats[f].mimeType
+}
+
+type imageFormatArgs struct {
+	extension string
+	mimeType  string
+	encoder   string
+	muxer     string
+	// defaultQuality is the quality used if none is set
+	defaultQuality int
+	// qualityArgs returns the encoder arguments for a quality from 1 to 100
+	qualityArgs func(quality int) []string
+}
+
+// scaleQuality maps a quality from 1 to 100 (best) to an encoder scale from
+// worst to best.
+func scaleQuality(quality int, worst int, best int) int {
+	return worst + (best-worst)*(quality-1)/99
+}
+
+var imageFormats = map[ImageFormat]imageFormatArgs{
+	ImageFormatJpeg: {
+		extension: ".jpg",
+		mimeType:  "image/jpeg",
+		encoder:   "mjpeg",
+		muxer:     "image2",
+		// qscale 2
+		defaultQuality: 100,
+		qualityArgs: func(quality int) []string {
+			return []string{"-q:v", strconv.Itoa(scaleQuality(quality, 31, 2))}
+		},
+	},
+	ImageFormatWebp: {
+		extension:      ".webp",
+		mimeType:       "image/webp",
+		encoder:        "libwebp",
+		muxer:          "image2",
+		defaultQuality: 80,
+		qualityArgs: func(quality int) []string {
+			return []string{"-lossless", "0", "-quality", strconv.Itoa(quality)}
+		},
+	},
+	ImageFormatAvif: {
+		extension:      ".avif",
+		mimeType:       "image/avif",
+		encoder:        "libaom-av1",
+		muxer:          "avif",
+		defaultQuality: 60,
+		qualityArgs: func(quality int) []string {
+			return []string{"-still-picture", "1", "-crf", strconv.Itoa(scaleQuality(quality, 63, 0)), "-b:v", "0"}
+		},
+	},
+}
+
+// ImageOutputArgs returns the ffmpeg output arguments to encode an image in
+// the format with a quality from 1 to 100 (best). The format's default
+// quality is used if quality is zero or less. Returns nil if the format is
+// not valid.
+func ImageOutputArgs(format ImageFormat, quality int) []string {
+	args, ok := imageFormats[format]
+	if !ok {
+		return nil
+	}
+
+	if quality <= 0 {
+		quality = args.defaultQuality
+	}
+	if quality > 100 {
+		quality = 100
+	}
+
+	ret := []string{"-c:v", args.encoder}
+	ret = append(ret, args.qualityArgs(quality)...)
+	return append(ret, "-f", args.muxer)
+}
 
 type ScreenshotOptions struct {
 	OutputPath string
-	Quality    int
-	Time       float64
-	Width      int
-	Verbosity  string
+	// Quality is the ffmpeg JPEG qscale, from 2 (best) to 31, if Format is
+	// not set. Otherwise it is the quality of the format, from 1 to 100
+	// (best), or zero for the format's default.
+	Quality int
+	// Format is the format of the screenshot. A JPEG is output using the
+	// ffmpeg defaults if not set.
+	Format    ImageFormat
+	Time      float64
+	Width     int
+	Verbosity string
 }
 
-func (e *Encoder) Screenshot(probeResult VideoFile, options ScreenshotOptions) error {
-	if options.Verbosity == "" {
-		options.Verbosity = "error"
+func (o ScreenshotOptions) outputArgs() []string {
+	if o.Format != "" {
+		return ImageOutputArgs(o.Format, o.Quality)
 	}
-	if options.Quality == 0 {
-		options.Quality = 1
+
+	quality := o.Quality
+	if quality == 0 {
+		quality = 1
+	}
+
+	return []string{
+		"-q:v", fmt.Sprintf("%v", quality),
+		"-f", "image2",
 	}
+}
+
+func (o ScreenshotOptions) args(inputPath string) []string {
+	verbosity := o.Verbosity
+	if verbosity == "" {
+		verbosity = "error"
+	}
+
 	args := []string{
-		"-v", options.Verbosity,
-		"-ss", fmt.Sprintf("%v", options.Time),
+		"-v", verbosity,
+		"-ss", fmt.Sprintf("%v", o.Time),
 		"-y",
-		"-i", probeResult.Path,
+		"-i", inputPath,
 		"-vframes", "1",
-		"-q:v", fmt.Sprintf("%v", options.Quality),
-		"-vf", fmt.Sprintf("scale=%v:-1", options.Width),
-		"-f", "image2",
-		options.OutputPath,
+		"-vf", fmt.Sprintf("scale=%v:-1", o.Width),
 	}
-	_, err := e.run(probeResult.Path, args, nil)
+	args = append(args, o.outputArgs()...)
+	return append(args, o.OutputPath)
+}
+
+func (e *Encoder) Screenshot(probeResult VideoFile, options ScreenshotOptions) error {
+	_, err := e.run(probeResult.Path, options.args(probeResult.Path), nil)
 
 	return err
 }
@@ -0,0 +1,115 @@
+package ffmpeg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImageOutputArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		format  ImageFormat
+		quality int
+		want    []string
+	}{
+		{"jpeg default", ImageFormatJpeg, 0, []string{"-c:v", "mjpeg", "-q:v", "2", "-f", "image2"}},
+		{"jpeg best", ImageFormatJpeg, 100, []string{"-c:v", "mjpeg", "-q:v", "2", "-f", "image2"}},
+		{"jpeg worst", ImageFormatJpeg, 1, []string{"-c:v", "mjpeg", "-q:v", "31", "-f", "image2"}},
+		{"jpeg clamped", ImageFormatJpeg, 150, []string{"-c:v", "mjpeg", "-q:v", "2", "-f", "image2"}},
+		{"webp default", ImageFormatWebp, 0, []string{"-c:v", "libwebp", "-lossless", "0", "-quality", "80", "-f", "image2"}},
+		{"webp", ImageFormatWebp, 65, []string{"-c:v", "libwebp", "-lossless", "0", "-quality", "65", "-f", "image2"}},
+		{"avif default", ImageFormatAvif, 0, []string{"-c:v", "libaom-av1", "-still-picture", "1", "-crf", "26", "-b:v", "0", "-f", "avif"}},
+		{"avif best", ImageFormatAvif, 100, []string{"-c:v", "libaom-av1", "-still-picture", "1", "-crf", "0", "-b:v", "0", "-f", "avif"}},
+		{"invalid", ImageFormat("png"), 50, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ImageOutputArgs(tt.format, tt.quality))
+		})
+	}
+}
+
+func TestScreenshotOptionsArgs(t *testing.T) {
+	input := []string{"-v", "error", "-ss", "12.5", "-y", "-i", "in.mp4", "-vframes", "1", "-vf", "scale=640:-1"}
+
+	tests := []struct {
+		name    string
+		options ScreenshotOptions
+		want    []string
+	}{
+		{
+			"unset format",
+			ScreenshotOptions{OutputPath: "out.jpg", Quality: 5, Time: 12.5, Width: 640},
+			append(append([]string{}, input...), "-q:v", "5", "-f", "image2", "out.jpg"),
+		},
+		{
+			"webp",
+			ScreenshotOptions{OutputPath: "out.jpg", Format: ImageFormatWebp, Quality: 90, Time: 12.5, Width: 640},
+			append(append([]string{}, input...), "-c:v", "libwebp", "-lossless", "0", "-quality", "90", "-f", "image2", "out.jpg"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.options.args("in.mp4"))
+		})
+	}
+}
+
+const testEncodersOutput = `Encoders:
+ V..... = Video
+ A..... = Audio
+ ------
+ V....D mjpeg                MJPEG (Motion JPEG)
+ V....D libwebp              libwebp WebP image (codec webp)
+ A....D aac                  AAC (Advanced Audio Coding)
+`
+
+const testMuxersOutput = `File formats:
+ D. = Demuxing supported
+ .E = Muxing supported
+ --
+  E image2          image2 sequence
+  E matroska,webm   Matroska
+`
+
+const testFiltersOutput = `Filters:
+  T.. = Timeline support
+  .S. = Slice threading
+  A = Audio input/output
+  V = Video input/output
+  | = Source or sink filter
+ ... aformat           A->A       Convert the input audio to one of the specified formats.
+ T.C drawtext          V->V       Draw text on top of video frames using libfreetype library.
+ ... tile              V->V       Tile several successive frames together.
+`
+
+func TestCapabilitiesSupportsFilter(t *testing.T) {
+	c := &Capabilities{
+		Filters: parseFilters(testFiltersOutput),
+	}
+
+	assert.Equal(t, map[string]bool{"aformat": true, "drawtext": true, "tile": true}, c.Filters)
+	assert.True(t, c.SupportsFilter("drawtext"))
+	assert.False(t, c.SupportsFilter("subtitles"))
+
+	var unknown *Capabilities
+	assert.False(t, unknown.SupportsFilter("drawtext"))
+}
+
+func TestCapabilitiesSupportsImageFormat(t *testing.T) {
+	c := &Capabilities{
+		Encoders: parseEncoders(testEncodersOutput),
+		Muxers:   parseMuxers(testMuxersOutput),
+	}
+
+	assert.Equal(t, map[string]bool{"mjpeg": true, "libwebp": true, "aac": true}, c.Encoders)
+	assert.Equal(t, map[string]bool{"image2": true, "matroska": true, "webm": true}, c.Muxers)
+
+	assert.True(t, c.SupportsImageFormat(ImageFormatJpeg))
+	assert.True(t, c.SupportsImageFormat(ImageFormatWebp))
+	assert.False(t, c.SupportsImageFormat(ImageFormatAvif))
+
+	var unknown *Capabilities
+	assert.False(t, unknown.SupportsImageFormat(ImageFormatJpeg))
+}
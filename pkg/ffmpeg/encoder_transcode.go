@@ -1,6 +1,7 @@
 package ffmpeg
 
 import (
+	"math"
 	"strconv"
 
 	"github.com/stashapp/stash/pkg/models"
@@ -11,21 +12,48 @@ type TranscodeOptions struct {
 	MaxTranscodeSize models.StreamingResolutionEnum
 }
 
-func calculateTranscodeScale(probeResult VideoFile, maxTranscodeSize models.StreamingResolutionEnum) string {
-	maxSize := 0
-	switch maxTranscodeSize {
+// streamingResolutionSize returns the maximum size of the smaller dimension
+// of videos transcoded to the resolution. Returns 0 for the original
+// resolution.
+func streamingResolutionSize(resolution models.StreamingResolutionEnum) int {
+	switch resolution {
 	case models.StreamingResolutionEnumLow:
-		maxSize = 240
+		return 240
 	case models.StreamingResolutionEnumStandard:
-		maxSize = 480
+		return 480
 	case models.StreamingResolutionEnumStandardHd:
-		maxSize = 720
+		return 720
 	case models.StreamingResolutionEnumFullHd:
-		maxSize = 1080
+		return 1080
 	case models.StreamingResolutionEnumFourK:
-		maxSize = 2160
+		return 2160
+	}
+
+	return 0
+}
+
+// transcodeDimensions returns the width and height of the video when
+// transcoded using the scale returned by calculateTranscodeScale.
+func transcodeDimensions(probeResult VideoFile, maxTranscodeSize models.StreamingResolutionEnum) (int, int) {
+	maxSize := streamingResolutionSize(maxTranscodeSize)
+	width := probeResult.Width
+	height := probeResult.Height
+
+	if width == 0 || height == 0 || maxSize == 0 || maxSize >= width || maxSize >= height {
+		return width, height
 	}
 
+	// the other dimension is scaled to the nearest even number
+	if width > height {
+		return int(math.Round(float64(width*maxSize)/float64(height)/2)) * 2, maxSize
+	}
+
+	return maxSize, int(math.Round(float64(height*maxSize)/float64(width)/2)) * 2
+}
+
+func calculateTranscodeScale(probeResult VideoFile, maxTranscodeSize models.StreamingResolutionEnum) string {
+	maxSize := streamingResolutionSize(maxTranscodeSize)
+
 	// get the smaller dimension of the video file
 	videoSize := probeResult.Height
 	if probeResult.Width < videoSize {
@@ -49,7 +77,7 @@ func calculateTranscodeScale(probeResult VideoFile, maxTranscodeSize models.Stre
 	return strconv.Itoa(maxSize) + ":-2"
 }
 
-func (e *Encoder) Transcode(probeResult VideoFile, options TranscodeOptions) {
+func (e *Encoder) Transcode(probeResult VideoFile, options TranscodeOptions) error {
 	scale := calculateTranscodeScale(probeResult, options.MaxTranscodeSize)
 	args := []string{
 		"-i", probeResult.Path,
@@ -64,13 +92,14 @@ func (e *Encoder) Transcode(probeResult VideoFile, options TranscodeOptions) {
 		"-strict", "-2",
 		options.OutputPath,
 	}
-	_, _ = e.runTranscode(probeResult, args)
+	_, err := e.runTranscode(probeResult, args)
+	return err
 }
 
 // TranscodeVideo transcodes the video, and removes the audio.
 // In some videos where the audio codec is not supported by ffmpeg,
 // ffmpeg fails if you try to transcode the audio
-func (e *Encoder) TranscodeVideo(probeResult VideoFile, options TranscodeOptions) {
+func (e *Encoder) TranscodeVideo(probeResult VideoFile, options TranscodeOptions) error {
 	scale := calculateTranscodeScale(probeResult, options.MaxTranscodeSize)
 	args := []string{
 		"-i", probeResult.Path,
@@ -84,11 +113,12 @@ func (e *Encoder) TranscodeVideo(probeResult VideoFile, options TranscodeOptions
 		"-vf", "scale=" + scale,
 		options.OutputPath,
 	}
-	_, _ = e.runTranscode(probeResult, args)
+	_, err := e.runTranscode(probeResult, args)
+	return err
 }
 
 // TranscodeAudio will copy the video stream as is, and transcode audio.
-func (e *Encoder) TranscodeAudio(probeResult VideoFile, options TranscodeOptions) {
+func (e *Encoder) TranscodeAudio(probeResult VideoFile, options TranscodeOptions) error {
 	args := []string{
 		"-i", probeResult.Path,
 		"-c:v", "copy",
@@ -96,16 +126,18 @@ func (e *Encoder) TranscodeAudio(probeResult VideoFile, options TranscodeOptions
 		"-strict", "-2",
 		options.OutputPath,
 	}
-	_, _ = e.runTranscode(probeResult, args)
+	_, err := e.runTranscode(probeResult, args)
+	return err
 }
 
 // CopyVideo will copy the video stream as is, and drop the audio stream.
-func (e *Encoder) CopyVideo(probeResult VideoFile, options TranscodeOptions) {
+func (e *Encoder) CopyVideo(probeResult VideoFile, options TranscodeOptions) error {
 	args := []string{
 		"-i", probeResult.Path,
 		"-an",
 		"-c:v", "copy",
 		options.OutputPath,
 	}
-	_, _ = e.runTranscode(probeResult, args)
+	_, err := e.runTranscode(probeResult, args)
+	return err
 }
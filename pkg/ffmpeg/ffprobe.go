@@ -1,17 +1,21 @@
 package ffmpeg
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/stashapp/stash/pkg/desktop"
+	"github.com/stashapp/stash/pkg/file"
 	"github.com/stashapp/stash/pkg/logger"
 )
 
@@ -96,6 +100,26 @@ var FfprobeToContainer = map[string]Container{
 	MatroskaFfmpeg: Matroska,
 }
 
+// containerMimeTypes maps containers to the content type used when serving
+// files of the container directly.
+var containerMimeTypes = map[Container]string{
+	Mp4:      MimeMp4,
+	M4v:      MimeMp4,
+	Mov:      "video/quicktime",
+	Wmv:      "video/x-ms-wmv",
+	Webm:     MimeWebm,
+	Matroska: MimeMkv,
+	Avi:      "video/x-msvideo",
+	Flv:      "video/x-flv",
+	Mpegts:   MimeMpegts,
+}
+
+// ContainerMimeType returns the content type of files of the container.
+// Returns an empty string if the container is unknown.
+func ContainerMimeType(container Container) string {
+	return containerMimeTypes[container]
+}
+
 func MatchContainer(format string, filePath string) Container { // match ffprobe string to our Container
 
 	container := FfprobeToContainer[format]
@@ -222,20 +246,69 @@ type VideoFile struct {
 	FrameCount   int64
 
 	AudioCodec string
+
+	Chapters []Chapter
 }
 
+// Chapter is a chapter embedded in a video container.
+type Chapter struct {
+	Title string
+	// Start and End are the chapter boundaries in seconds.
+	Start float64
+	End   float64
+}
+
+// ErrProbeTimeout is returned when an FFProbe invocation does not complete
+// before the configured timeout.
+var ErrProbeTimeout = errors.New("ffprobe timed out")
+
 // FFProbe
-type FFProbe string
+type FFProbe struct {
+	// Path is the path to the ffprobe executable.
+	Path string
+
+	// Timeout is the maximum duration of an ffprobe invocation. The probe
+	// is killed if it has not completed after this duration. Zero means no
+	// timeout.
+	Timeout time.Duration
+}
 
-// Execute exec command and bind result to struct.
-func (f *FFProbe) NewVideoFile(videoPath string, stripExt bool) (*VideoFile, error) {
-	args := []string{"-v", "quiet", "-print_format", "json", "-show_format", "-show_streams", "-show_error", videoPath}
-	cmd := exec.Command(string(*f), args...)
+// run executes ffprobe with the provided arguments, returning the output.
+// Returns an error wrapping ErrProbeTimeout if the timeout is exceeded.
+func (f *FFProbe) run(filePath string, args []string) ([]byte, error) {
+	ctx := context.Background()
+	if f.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, f.Timeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, f.Path, args...)
 	desktop.HideExecShell(cmd)
 	out, err := cmd.Output()
 
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w after %v with <%s>", ErrProbeTimeout, f.Timeout, filePath)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("FFProbe encountered an error with <%s>.\nError JSON:\n%s\nError: %s", filePath, string(out), err.Error())
+	}
+
+	return out, nil
+}
+
+// Execute exec command and bind result to struct.
+func (f *FFProbe) NewVideoFile(videoPath string, stripExt bool) (*VideoFile, error) {
+	// remote files are only read by ffmpeg through file.LocalURL
+	if !file.IsLocalPath(videoPath) {
+		return nil, fmt.Errorf("probing %s: %w", videoPath, file.ErrRemoteUnsupported)
+	}
+
+	args := []string{"-v", "quiet", "-print_format", "json", "-show_format", "-show_streams", "-show_chapters", "-show_error", videoPath}
+	out, err := f.run(videoPath, args)
 	if err != nil {
-		return nil, fmt.Errorf("FFProbe encountered an error with <%s>.\nError JSON:\n%s\nError: %s", videoPath, string(out), err.Error())
+		return nil, err
 	}
 
 	probeJSON := &FFProbeJSON{}
@@ -249,10 +322,9 @@ func (f *FFProbe) NewVideoFile(videoPath string, stripExt bool) (*VideoFile, err
 // GetReadFrameCount counts the actual frames of the video file
 func (f *FFProbe) GetReadFrameCount(vf *VideoFile) (int64, error) {
 	args := []string{"-v", "quiet", "-print_format", "json", "-count_frames", "-show_format", "-show_streams", "-show_error", vf.Path}
-	out, err := exec.Command(string(*f), args...).Output()
-
+	out, err := f.run(vf.Path, args)
 	if err != nil {
-		return 0, fmt.Errorf("FFProbe encountered an error with <%s>.\nError JSON:\n%s\nError: %s", vf.Path, string(out), err.Error())
+		return 0, err
 	}
 
 	probeJSON := &FFProbeJSON{}
@@ -264,6 +336,10 @@ func (f *FFProbe) GetReadFrameCount(vf *VideoFile) (int64, error) {
 	return fc.FrameCount, err
 }
 
+func isURL(filePath string) bool {
+	return strings.Contains(filePath, "://")
+}
+
 func parse(filePath string, probeJSON *FFProbeJSON, stripExt bool) (*VideoFile, error) {
 	if probeJSON == nil {
 		return nil, fmt.Errorf("failed to get ffprobe json for <%s>", filePath)
@@ -290,15 +366,21 @@ func parse(filePath string, probeJSON *FFProbeJSON, stripExt bool) (*VideoFile,
 	result.Container = probeJSON.Format.FormatName
 	duration, _ := strconv.ParseFloat(probeJSON.Format.Duration, 64)
 	result.Duration = math.Round(duration*100) / 100
-	fileStat, err := os.Stat(filePath)
-	if err != nil {
-		statErr := fmt.Errorf("error statting file <%s>: %w", filePath, err)
-		logger.Errorf("%v", statErr)
-		return nil, statErr
+	if isURL(filePath) {
+		// ffprobe reads remote files from a URL, which cannot be statted
+		result.Size, _ = strconv.ParseInt(probeJSON.Format.Size, 10, 64)
+	} else {
+		fileStat, err := os.Stat(filePath)
+		if err != nil {
+			statErr := fmt.Errorf("error statting file <%s>: %w", filePath, err)
+			logger.Errorf("%v", statErr)
+			return nil, statErr
+		}
+		result.Size = fileStat.Size()
 	}
-	result.Size = fileStat.Size()
 	result.StartTime, _ = strconv.ParseFloat(probeJSON.Format.StartTime, 64)
 	result.CreationTime = probeJSON.Format.Tags.CreationTime.Time
+	result.Chapters = parseChapters(probeJSON)
 
 	audioStream := result.GetAudioStream()
 	if audioStream != nil {
@@ -342,6 +424,31 @@ func parse(filePath string, probeJSON *FFProbeJSON, stripExt bool) (*VideoFile,
 	return result, nil
 }
 
+// parseChapters returns the chapters from the ffprobe output, ordered by
+// start time. Chapters with invalid start times are ignored.
+func parseChapters(probeJSON *FFProbeJSON) []Chapter {
+	var ret []Chapter
+	for _, c := range probeJSON.Chapters {
+		start, err := strconv.ParseFloat(c.StartTime, 64)
+		if err != nil || start < 0 {
+			continue
+		}
+		end, _ := strconv.ParseFloat(c.EndTime, 64)
+
+		ret = append(ret, Chapter{
+			Title: strings.TrimSpace(c.Tags.Title),
+			Start: start,
+			End:   end,
+		})
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Start < ret[j].Start
+	})
+
+	return ret
+}
+
 func (v *VideoFile) GetAudioStream() *FFProbeStream {
 	index := v.getStreamIndex("audio", v.JSON)
 	if index != -1 {
@@ -0,0 +1,130 @@
+package ffmpeg
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeStubProbe writes an executable shell script to a temporary directory
+// and returns its path.
+func writeStubProbe(t *testing.T, script string) string {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("stub probe requires a POSIX shell")
+	}
+
+	path := filepath.Join(t.TempDir(), "ffprobe")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatalf("writing stub probe: %v", err)
+	}
+
+	return path
+}
+
+func TestFFProbeTimeout(t *testing.T) {
+	f := FFProbe{
+		Path:    writeStubProbe(t, "exec sleep 10"),
+		Timeout: 100 * time.Millisecond,
+	}
+
+	start := time.Now()
+	_, err := f.NewVideoFile("test.mp4", false)
+
+	assert.ErrorIs(t, err, ErrProbeTimeout)
+	assert.Less(t, time.Since(start), 5*time.Second)
+
+	_, err = f.GetReadFrameCount(&VideoFile{Path: "test.mp4"})
+	assert.ErrorIs(t, err, ErrProbeTimeout)
+}
+
+func TestFFProbeError(t *testing.T) {
+	f := FFProbe{
+		Path:    writeStubProbe(t, "exit 1"),
+		Timeout: 10 * time.Second,
+	}
+
+	_, err := f.NewVideoFile("test.mp4", false)
+
+	if assert.Error(t, err) {
+		assert.False(t, errors.Is(err, ErrProbeTimeout))
+	}
+}
+
+const chaptersJSON = `{
+	"chapters": [
+		{
+			"id": 1,
+			"time_base": "1/1000000000",
+			"start": 90500000000,
+			"start_time": "90.500000",
+			"end": 200000000000,
+			"end_time": "200.000000",
+			"tags": {
+				"title": " Part 2 "
+			}
+		},
+		{
+			"id": 0,
+			"time_base": "1/1000000000",
+			"start": 0,
+			"start_time": "0.000000",
+			"end": 90500000000,
+			"end_time": "90.500000",
+			"tags": {
+				"title": "Intro"
+			}
+		},
+		{
+			"id": 2,
+			"time_base": "1/1000000000",
+			"start": 200000000000,
+			"start_time": "200.000000",
+			"end": 300000000000,
+			"end_time": "300.000000"
+		},
+		{
+			"id": 3,
+			"start_time": "N/A"
+		}
+	]
+}`
+
+func TestParseChapters(t *testing.T) {
+	var probeJSON FFProbeJSON
+	if err := json.Unmarshal([]byte(chaptersJSON), &probeJSON); err != nil {
+		t.Fatalf("unmarshalling chapters: %v", err)
+	}
+
+	assert.Equal(t, []Chapter{
+		{Title: "Intro", Start: 0, End: 90.5},
+		{Title: "Part 2", Start: 90.5, End: 200},
+		{Title: "", Start: 200, End: 300},
+	}, parseChapters(&probeJSON))
+
+	assert.Nil(t, parseChapters(&FFProbeJSON{}))
+}
+
+func TestFFProbeRemoteUnsupported(t *testing.T) {
+	calledPath := filepath.Join(t.TempDir(), "called")
+	f := FFProbe{
+		Path:    writeStubProbe(t, `touch "`+calledPath+`"; exit 1`),
+		Timeout: 10 * time.Second,
+	}
+
+	file.RegisterFileSystem(file.WebDAVScheme, file.NewWebDAVFileSystem(false, "user", "password", nil))
+
+	_, err := f.NewVideoFile("webdav://host/test.mp4", false)
+	assert.True(t, errors.Is(err, file.ErrRemoteUnsupported))
+
+	_, err = os.Stat(calledPath)
+	assert.True(t, os.IsNotExist(err), "ffprobe should not be run for remote files")
+}
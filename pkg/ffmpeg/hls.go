@@ -3,7 +3,11 @@ package ffmpeg
 import (
 	"fmt"
 	"io"
+	"math"
+	"strconv"
 	"strings"
+
+	"github.com/stashapp/stash/pkg/models"
 )
 
 const hlsSegmentLength = 10.0
@@ -39,3 +43,130 @@ func WriteHLSPlaylist(probeResult VideoFile, baseUrl string, w io.Writer) {
 
 	fmt.Fprint(w, "#EXT-X-ENDLIST\n")
 }
+
+// hlsBandwidths are the peak bit rates of HLS renditions, in bits per
+// second.
+var hlsBandwidths = map[models.StreamingResolutionEnum]int{
+	models.StreamingResolutionEnumLow:        400000,
+	models.StreamingResolutionEnumStandard:   1200000,
+	models.StreamingResolutionEnumStandardHd: 2500000,
+	models.StreamingResolutionEnumFullHd:     5000000,
+	models.StreamingResolutionEnumFourK:      16000000,
+}
+
+// HLSBandwidth returns the peak bit rate of the rendition in bits per
+// second. The bit rate of the original resolution is that of the file.
+func HLSBandwidth(probeResult VideoFile, resolution models.StreamingResolutionEnum) int {
+	if ret, found := hlsBandwidths[resolution]; found {
+		return ret
+	}
+
+	if probeResult.Bitrate > 0 {
+		return int(probeResult.Bitrate)
+	}
+
+	return hlsBandwidths[models.StreamingResolutionEnumFourK]
+}
+
+// HLSSegmentCount returns the number of segments of the video.
+func HLSSegmentCount(probeResult VideoFile) int {
+	return int(math.Ceil(probeResult.Duration / hlsSegmentLength))
+}
+
+// hlsSegmentBounds returns the start time and duration of the segment.
+func hlsSegmentBounds(probeResult VideoFile, index int) (float64, float64) {
+	start := float64(index) * hlsSegmentLength
+	return start, math.Min(hlsSegmentLength, probeResult.Duration-start)
+}
+
+// WriteHLSMasterPlaylist writes a playlist of the variant playlists of the
+// resolutions, so that the client may select the rendition that suits its
+// connection. variantURL returns the URL of the variant playlist of a
+// resolution.
+func WriteHLSMasterPlaylist(probeResult VideoFile, resolutions []models.StreamingResolutionEnum, variantURL func(resolution models.StreamingResolutionEnum) string, w io.Writer) {
+	fmt.Fprint(w, "#EXTM3U\n")
+	fmt.Fprint(w, "#EXT-X-VERSION:3\n")
+
+	for _, res := range resolutions {
+		fmt.Fprintf(w, "#EXT-X-STREAM-INF:BANDWIDTH=%d", HLSBandwidth(probeResult, res))
+		if width, height := transcodeDimensions(probeResult, res); width > 0 && height > 0 {
+			fmt.Fprintf(w, ",RESOLUTION=%dx%d", width, height)
+		}
+		fmt.Fprintf(w, ",NAME=\"%s\"\n", res)
+		fmt.Fprintf(w, "%s\n", variantURL(res))
+	}
+}
+
+// WriteHLSMediaPlaylist writes a playlist of the segments of a rendition.
+// segmentURL returns the URL of a segment.
+func WriteHLSMediaPlaylist(probeResult VideoFile, segmentURL func(index int) string, w io.Writer) {
+	fmt.Fprint(w, "#EXTM3U\n")
+	fmt.Fprint(w, "#EXT-X-VERSION:3\n")
+	fmt.Fprint(w, "#EXT-X-MEDIA-SEQUENCE:0\n")
+	fmt.Fprintf(w, "#EXT-X-TARGETDURATION:%d\n", int(hlsSegmentLength))
+	fmt.Fprint(w, "#EXT-X-PLAYLIST-TYPE:VOD\n")
+
+	for i := 0; i < HLSSegmentCount(probeResult); i++ {
+		_, duration := hlsSegmentBounds(probeResult, i)
+		fmt.Fprintf(w, "#EXTINF:%f,\n", duration)
+		fmt.Fprintf(w, "%s\n", segmentURL(i))
+	}
+
+	fmt.Fprint(w, "#EXT-X-ENDLIST\n")
+}
+
+type HLSSegmentOptions struct {
+	OutputPath string
+	Index      int
+	Resolution models.StreamingResolutionEnum
+	// transcode the video, remove the audio
+	VideoOnly bool
+}
+
+func (o HLSSegmentOptions) getArgs(probeResult VideoFile) []string {
+	start, duration := hlsSegmentBounds(probeResult, o.Index)
+	bandwidth := HLSBandwidth(probeResult, o.Resolution)
+
+	args := []string{
+		"-hide_banner",
+		"-v", "error",
+		"-ss", fmt.Sprintf("%f", start),
+		"-t", fmt.Sprintf("%f", duration),
+		"-i", probeResult.Path,
+	}
+
+	if o.VideoOnly {
+		args = append(args, "-an")
+	} else {
+		args = append(args,
+			"-c:a", "aac",
+			// this is needed for 5-channel ac3 files
+			"-ac", "2",
+		)
+	}
+
+	args = append(args,
+		"-c:v", "libx264",
+		"-vf", "scale="+calculateTranscodeScale(probeResult, o.Resolution),
+		"-pix_fmt", "yuv420p",
+		"-preset", "veryfast",
+		"-crf", "25",
+		"-maxrate", strconv.Itoa(bandwidth),
+		"-bufsize", strconv.Itoa(bandwidth*2),
+		// segments are transcoded separately, so the timestamps must
+		// continue from the previous segment
+		"-output_ts_offset", fmt.Sprintf("%f", start),
+		"-f", "mpegts",
+		"-y",
+		o.OutputPath,
+	)
+
+	return args
+}
+
+// TranscodeHLSSegment transcodes a segment of a HLS rendition to an MPEG-TS
+// file.
+func (e *Encoder) TranscodeHLSSegment(probeResult VideoFile, options HLSSegmentOptions) error {
+	_, err := e.run(probeResult.Path, options.getArgs(probeResult), nil)
+	return err
+}
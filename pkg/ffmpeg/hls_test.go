@@ -0,0 +1,105 @@
+package ffmpeg
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteHLSMasterPlaylist(t *testing.T) {
+	probeResult := VideoFile{
+		Width:    1920,
+		Height:   1080,
+		Duration: 25,
+		Bitrate:  8000000,
+	}
+
+	resolutions := []models.StreamingResolutionEnum{
+		models.StreamingResolutionEnumStandardHd,
+		models.StreamingResolutionEnumLow,
+		models.StreamingResolutionEnumOriginal,
+	}
+
+	var b strings.Builder
+	WriteHLSMasterPlaylist(probeResult, resolutions, func(resolution models.StreamingResolutionEnum) string {
+		return "session/" + resolution.String() + "/index.m3u8"
+	}, &b)
+
+	want := `#EXTM3U
+#EXT-X-VERSION:3
+#EXT-X-STREAM-INF:BANDWIDTH=2500000,RESOLUTION=1280x720,NAME="STANDARD_HD"
+session/STANDARD_HD/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=400000,RESOLUTION=426x240,NAME="LOW"
+session/LOW/index.m3u8
+#EXT-X-STREAM-INF:BANDWIDTH=8000000,RESOLUTION=1920x1080,NAME="ORIGINAL"
+session/ORIGINAL/index.m3u8
+`
+	assert.Equal(t, want, b.String())
+}
+
+func TestWriteHLSMediaPlaylist(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration float64
+		want     []string
+	}{
+		{"partial last segment", 25, []string{"10.000000", "10.000000", "5.000000"}},
+		{"whole segments", 20, []string{"10.000000", "10.000000"}},
+		{"short", 3.5, []string{"3.500000"}},
+		{"empty", 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			WriteHLSMediaPlaylist(VideoFile{Duration: tt.duration}, func(index int) string {
+				return strconv.Itoa(index) + ".ts"
+			}, &b)
+
+			lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+			assert.Equal(t, "#EXTM3U", lines[0])
+			assert.Equal(t, "#EXT-X-ENDLIST", lines[len(lines)-1])
+
+			var durations []string
+			for i, l := range lines {
+				if strings.HasPrefix(l, "#EXTINF:") {
+					durations = append(durations, strings.TrimSuffix(strings.TrimPrefix(l, "#EXTINF:"), ","))
+					assert.Equal(t, strconv.Itoa(len(durations)-1)+".ts", lines[i+1])
+				}
+			}
+			assert.Equal(t, tt.want, durations)
+		})
+	}
+}
+
+func TestHLSSegmentArgs(t *testing.T) {
+	probeResult := VideoFile{
+		Path:     "video.mkv",
+		Width:    1920,
+		Height:   1080,
+		Duration: 25,
+	}
+
+	options := HLSSegmentOptions{
+		OutputPath: "out.ts",
+		Index:      2,
+		Resolution: models.StreamingResolutionEnumStandard,
+	}
+
+	args := options.getArgs(probeResult)
+	assert.Equal(t, []string{"20.000000"}, argValue(args, "-ss"))
+	assert.Equal(t, []string{"5.000000"}, argValue(args, "-t"))
+	assert.Equal(t, []string{"20.000000"}, argValue(args, "-output_ts_offset"))
+	assert.Equal(t, []string{"scale=-2:480"}, argValue(args, "-vf"))
+	assert.Equal(t, []string{"1200000"}, argValue(args, "-maxrate"))
+	assert.Equal(t, []string{"aac"}, argValue(args, "-c:a"))
+	assert.Equal(t, "out.ts", args[len(args)-1])
+
+	options.VideoOnly = true
+	args = options.getArgs(probeResult)
+	assert.Contains(t, args, "-an")
+	assert.Empty(t, argValue(args, "-c:a"))
+}
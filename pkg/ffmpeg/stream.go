@@ -11,15 +11,21 @@ import (
 	"github.com/stashapp/stash/pkg/desktop"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
 )
 
 const CopyStreamCodec = "copy"
 
 type Stream struct {
-	Stdout   io.ReadCloser
-	Process  *os.Process
+	Stdout  io.ReadCloser
+	Process *os.Process
+	// Tee receives a copy of the served stream, if not nil.
+	Tee io.Writer
+
 	options  TranscodeStreamOptions
 	mimeType string
+	done     chan error
+	serveErr error
 }
 
 func (s *Stream) Serve(w http.ResponseWriter, r *http.Request) {
@@ -37,12 +43,40 @@ func (s *Stream) Serve(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	_, err := io.Copy(w, s.Stdout)
+	var dst io.Writer = w
+	if s.Tee != nil {
+		dst = io.MultiWriter(w, s.Tee)
+	}
+
+	_, err := io.Copy(dst, s.Stdout)
 	if err != nil {
+		s.serveErr = err
 		logger.Errorf("[stream] error serving transcoded video file: %s", err.Error())
 	}
 }
 
+// Wait waits for the transcode to finish. Returns an error if the transcode
+// failed, or if the stream was not served completely.
+func (s *Stream) Wait() error {
+	if err := <-s.done; err != nil {
+		return err
+	}
+
+	return s.serveErr
+}
+
+// Format returns the output format of transcodes using the codec.
+func (c Codec) Format() string {
+	return c.format
+}
+
+// Key returns a string identifying the codec, output format and encoding
+// parameters of transcodes using the codec.
+func (c Codec) Key() string {
+	params := utils.MD5FromString(strings.Join(c.extraArgs, " "))
+	return c.Codec + "-" + c.format + "-" + params[:8]
+}
+
 type Codec struct {
 	Codec     string
 	format    string
@@ -227,10 +261,13 @@ func (e *Encoder) stream(probeResult VideoFile, options TranscodeStreamOptions)
 	}
 
 	registerRunningEncoder(probeResult.Path, cmd.Process)
+	done := make(chan error, 1)
 	go func() {
-		if err := waitAndDeregister(probeResult.Path, cmd); err != nil {
+		err := waitAndDeregister(probeResult.Path, cmd)
+		if err != nil {
 			logger.Warnf("Error while deregistering ffmpeg stream: %v", err)
 		}
+		done <- err
 	}()
 
 	// stderr must be consumed or the process deadlocks
@@ -247,6 +284,7 @@ func (e *Encoder) stream(probeResult VideoFile, options TranscodeStreamOptions)
 		Process:  cmd.Process,
 		options:  options,
 		mimeType: options.Codec.MimeType,
+		done:     done,
 	}
 	return ret, nil
 }
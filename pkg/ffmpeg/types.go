@@ -26,13 +26,26 @@ type FFProbeJSON struct {
 			Comment          string          `json:"comment"`
 		} `json:"tags"`
 	} `json:"format"`
-	Streams []FFProbeStream `json:"streams"`
-	Error   struct {
+	Streams  []FFProbeStream  `json:"streams"`
+	Chapters []FFProbeChapter `json:"chapters"`
+	Error    struct {
 		Code   int    `json:"code"`
 		String string `json:"string"`
 	} `json:"error"`
 }
 
+type FFProbeChapter struct {
+	ID        int    `json:"id"`
+	TimeBase  string `json:"time_base"`
+	Start     int64  `json:"start"`
+	StartTime string `json:"start_time"`
+	End       int64  `json:"end"`
+	EndTime   string `json:"end_time"`
+	Tags      struct {
+		Title string `json:"title"`
+	} `json:"tags"`
+}
+
 type FFProbeStream struct {
 	AvgFrameRate       string `json:"avg_frame_rate"`
 	BitRate            string `json:"bit_rate"`
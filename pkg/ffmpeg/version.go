@@ -0,0 +1,38 @@
+package ffmpeg
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/pkg/desktop"
+)
+
+// versionTimeout is the maximum duration of a version probe.
+const versionTimeout = 10 * time.Second
+
+// GetVersion runs the ffmpeg or ffprobe executable at path with -version,
+// returning the version it reports. Returns an error if the executable
+// cannot be run or does not report a version.
+func GetVersion(path string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), versionTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, "-version")
+	desktop.HideExecShell(cmd)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("error running %s -version: %w", path, err)
+	}
+
+	// the first line is of the form "ffmpeg version <version> Copyright ..."
+	line := strings.SplitN(string(out), "\n", 2)[0]
+	fields := strings.Fields(line)
+	if len(fields) < 3 || fields[1] != "version" {
+		return "", fmt.Errorf("unexpected %s -version output: %q", path, line)
+	}
+
+	return fields[2], nil
+}
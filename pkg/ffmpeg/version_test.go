@@ -0,0 +1,45 @@
+package ffmpeg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		want    string
+		wantErr bool
+	}{
+		{
+			"version",
+			`echo "ffprobe version 4.4.1-static https://johnvansickle.com/ffmpeg/ Copyright (c) 2007-2021"; echo "built with gcc 8"`,
+			"4.4.1-static",
+			false,
+		},
+		{
+			"unexpected output",
+			`echo "usage: ffprobe"`,
+			"",
+			true,
+		},
+		{
+			"error",
+			`exit 1`,
+			"",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GetVersion(writeStubProbe(t, tt.script))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetVersion() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
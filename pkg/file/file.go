@@ -3,7 +3,6 @@ package file
 import (
 	"io"
 	"io/fs"
-	"os"
 )
 
 type fsFile struct {
@@ -12,7 +11,7 @@ type fsFile struct {
 }
 
 func (f *fsFile) Open() (io.ReadCloser, error) {
-	return os.Open(f.path)
+	return FileSystemFor(f.path).Open(f.path)
 }
 
 func (f *fsFile) Path() string {
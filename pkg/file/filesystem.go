@@ -0,0 +1,245 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// FileSystem provides access to the files of a library. Paths are absolute,
+// and include the scheme for remote file systems.
+type FileSystem interface {
+	Open(name string) (io.ReadSeekCloser, error)
+	Stat(name string) (fs.FileInfo, error)
+	// ReadDir returns the entries of the directory, sorted by name.
+	ReadDir(name string) ([]fs.FileInfo, error)
+}
+
+// LocalFileSystem is the FileSystem of the local host.
+type LocalFileSystem struct{}
+
+func (LocalFileSystem) Open(name string) (io.ReadSeekCloser, error) {
+	return os.Open(name)
+}
+
+func (LocalFileSystem) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (LocalFileSystem) ReadDir(name string) ([]fs.FileInfo, error) {
+	entries, err := os.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []fs.FileInfo
+	for _, e := range entries {
+		// follow symbolic links
+		info, err := os.Stat(filepath.Join(name, e.Name()))
+		if err != nil {
+			continue
+		}
+		ret = append(ret, info)
+	}
+
+	return ret, nil
+}
+
+const schemeSeparator = "://"
+
+var (
+	fileSystemsMutex sync.RWMutex
+	fileSystems      = make(map[string]FileSystem)
+)
+
+// RegisterFileSystem sets the FileSystem used for paths with the provided
+// scheme, replacing any existing FileSystem for the scheme.
+func RegisterFileSystem(scheme string, fsys FileSystem) {
+	fileSystemsMutex.Lock()
+	defer fileSystemsMutex.Unlock()
+
+	fileSystems[scheme] = fsys
+}
+
+func pathScheme(path string) string {
+	i := strings.Index(path, schemeSeparator)
+	if i <= 0 {
+		return ""
+	}
+
+	return path[:i]
+}
+
+// ErrRemoteUnsupported is returned by operations that can only be performed
+// on files of the local file system.
+var ErrRemoteUnsupported = errors.New("not supported for files on remote file systems")
+
+// IsRemotePath returns true if the path has a URL scheme, such as
+// webdav://host/path.
+func IsRemotePath(path string) bool {
+	return pathScheme(path) != ""
+}
+
+// FileSystemFor returns the FileSystem containing path. Returns
+// LocalFileSystem for paths without a scheme or with an unregistered
+// scheme.
+func FileSystemFor(path string) FileSystem {
+	scheme := pathScheme(path)
+	if scheme == "" {
+		return LocalFileSystem{}
+	}
+
+	fileSystemsMutex.RLock()
+	defer fileSystemsMutex.RUnlock()
+
+	if ret, found := fileSystems[scheme]; found {
+		return ret
+	}
+
+	return LocalFileSystem{}
+}
+
+// IsLocalPath returns true if the file at path is on the local file system.
+func IsLocalPath(path string) bool {
+	_, ret := FileSystemFor(path).(LocalFileSystem)
+	return ret
+}
+
+// JoinPath joins the name of a directory entry to the directory path.
+func JoinPath(dir string, name string) string {
+	if IsRemotePath(dir) {
+		return strings.TrimSuffix(dir, "/") + "/" + name
+	}
+
+	return filepath.Join(dir, name)
+}
+
+// Exists returns true if the file or directory at path exists.
+func Exists(path string) (bool, error) {
+	_, err := FileSystemFor(path).Stat(path)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+
+	return false, err
+}
+
+// Walk walks the file tree rooted at root in lexical order, calling fn for
+// each file or directory, in the same manner as filepath.Walk.
+func Walk(fsys FileSystem, root string, fn filepath.WalkFunc) error {
+	info, err := fsys.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = walk(fsys, root, info, fn)
+	}
+
+	if errors.Is(err, filepath.SkipDir) {
+		return nil
+	}
+	return err
+}
+
+func walk(fsys FileSystem, path string, info fs.FileInfo, fn filepath.WalkFunc) error {
+	if !info.IsDir() {
+		return fn(path, info, nil)
+	}
+
+	entries, err := fsys.ReadDir(path)
+	if err := fn(path, info, err); err != nil || entries == nil {
+		return err
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	for _, e := range entries {
+		err := walk(fsys, JoinPath(path, e.Name()), e, fn)
+		if err != nil {
+			if !e.IsDir() || !errors.Is(err, filepath.SkipDir) {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Serve serves the file at path, which may be on a remote file system.
+func Serve(w http.ResponseWriter, r *http.Request, path string) {
+	if IsLocalPath(path) {
+		http.ServeFile(w, r, path)
+		return
+	}
+
+	fsys := FileSystemFor(path)
+
+	info, err := fsys.Stat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	f, err := fsys.Open(path)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
+
+// LocalURL returns a path that ffmpeg can read the file at path from. Local
+// paths are returned unchanged. Files on remote file systems are served from
+// a random URL on the loopback interface, so that the credentials of the
+// file system are not passed to ffmpeg. The returned function must be
+// called once the file is no longer needed.
+func LocalURL(path string) (string, func(), error) {
+	if IsLocalPath(path) {
+		return path, func() {}, nil
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, fmt.Errorf("serving %s: %w", path, err)
+	}
+
+	urlPath := "/" + utils.GenerateRandomKey(16) + filepath.Ext(path)
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != urlPath {
+				http.NotFound(w, r)
+				return
+			}
+			Serve(w, r, path)
+		}),
+	}
+
+	go func() {
+		if err := srv.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Warnf("error serving %s: %v", path, err)
+		}
+	}()
+
+	return "http://" + l.Addr().String() + urlPath, func() { srv.Close() }, nil
+}
@@ -0,0 +1,179 @@
+package file
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const memScheme = "mem"
+
+// memFileSystem is an in-memory FileSystem for paths of the form
+// mem://name.
+type memFileSystem struct {
+	fsys fstest.MapFS
+}
+
+func (f memFileSystem) name(path string) string {
+	return strings.TrimPrefix(path, memScheme+schemeSeparator)
+}
+
+func (f memFileSystem) Open(path string) (io.ReadSeekCloser, error) {
+	file, err := f.fsys.Open(f.name(path))
+	if err != nil {
+		return nil, err
+	}
+
+	return file.(io.ReadSeekCloser), nil
+}
+
+func (f memFileSystem) Stat(path string) (fs.FileInfo, error) {
+	return fs.Stat(f.fsys, f.name(path))
+}
+
+func (f memFileSystem) ReadDir(path string) ([]fs.FileInfo, error) {
+	entries, err := fs.ReadDir(f.fsys, f.name(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []fs.FileInfo
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, info)
+	}
+
+	return ret, nil
+}
+
+var memModTime = time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+
+func registerMemFileSystem(t *testing.T) {
+	t.Helper()
+
+	RegisterFileSystem(memScheme, memFileSystem{
+		fsys: fstest.MapFS{
+			"lib/b.mp4":         {Data: []byte("b"), ModTime: memModTime},
+			"lib/a/1.mp4":       {Data: []byte("a1"), ModTime: memModTime},
+			"lib/a/2.mp4":       {Data: []byte("a2"), ModTime: memModTime},
+			"lib/skipped/c.mp4": {Data: []byte("c"), ModTime: memModTime},
+			"lib/video.mp4":     {Data: []byte("0123456789abcdef"), ModTime: memModTime},
+		},
+	})
+
+	t.Cleanup(func() {
+		fileSystemsMutex.Lock()
+		delete(fileSystems, memScheme)
+		fileSystemsMutex.Unlock()
+	})
+}
+
+func TestFileSystemFor(t *testing.T) {
+	registerMemFileSystem(t)
+
+	assert.IsType(t, LocalFileSystem{}, FileSystemFor(filepath.Join("lib", "a.mp4")))
+	assert.IsType(t, memFileSystem{}, FileSystemFor("mem://lib/a.mp4"))
+	assert.IsType(t, LocalFileSystem{}, FileSystemFor("unknown://lib/a.mp4"))
+
+	assert.True(t, IsRemotePath("mem://lib"))
+	assert.False(t, IsRemotePath(filepath.Join("lib", "a.mp4")))
+
+	assert.Equal(t, "mem://lib/a.mp4", JoinPath("mem://lib/", "a.mp4"))
+	assert.Equal(t, filepath.Join("lib", "a.mp4"), JoinPath("lib", "a.mp4"))
+}
+
+func TestWalk(t *testing.T) {
+	registerMemFileSystem(t)
+
+	var visited []string
+	err := Walk(FileSystemFor("mem://lib"), "mem://lib", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() && info.Name() == "skipped" {
+			return filepath.SkipDir
+		}
+
+		visited = append(visited, path)
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		"mem://lib",
+		"mem://lib/a",
+		"mem://lib/a/1.mp4",
+		"mem://lib/a/2.mp4",
+		"mem://lib/b.mp4",
+		"mem://lib/video.mp4",
+	}, visited)
+}
+
+func TestExists(t *testing.T) {
+	registerMemFileSystem(t)
+
+	exists, err := Exists("mem://lib/b.mp4")
+	assert.True(t, exists)
+	assert.Nil(t, err)
+
+	exists, err = Exists("mem://lib/missing.mp4")
+	assert.False(t, exists)
+	assert.Nil(t, err)
+}
+
+func TestFSFileOpen(t *testing.T) {
+	registerMemFileSystem(t)
+
+	const path = "mem://lib/video.mp4"
+	info, err := FileSystemFor(path).Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := FSFile(path, info).Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	data, _ := io.ReadAll(rc)
+	assert.Equal(t, "0123456789abcdef", string(data))
+
+	// oshash requires the file size to be a multiple of 8, and seeking
+	if _, err := rc.(io.Seeker).Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	oshash, err := (&FSHasher{}).OSHash(rc.(io.ReadSeeker), info.Size())
+	assert.Nil(t, err)
+	assert.NotEmpty(t, oshash)
+}
+
+func TestServeRemote(t *testing.T) {
+	registerMemFileSystem(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Range", "bytes=2-5")
+	w := httptest.NewRecorder()
+
+	Serve(w, req, "mem://lib/video.mp4")
+
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "2345", w.Body.String())
+
+	w = httptest.NewRecorder()
+	Serve(w, httptest.NewRequest(http.MethodGet, "/", nil), "mem://lib/missing.mp4")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
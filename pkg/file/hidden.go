@@ -0,0 +1,36 @@
+package file
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// systemFileNames are the lower case names of files and directories that
+// are created by operating systems and NAS devices, rather than by users.
+var systemFileNames = map[string]bool{
+	"$recycle.bin":              true,
+	"system volume information": true,
+	"thumbs.db":                 true,
+	"desktop.ini":               true,
+	"lost+found":                true,
+	"@eadir":                    true,
+	"#recycle":                  true,
+	"#snapshot":                 true,
+}
+
+// IsHidden returns true if the file or directory at path is hidden, or is an
+// operating system file. Names starting with a dot are hidden on all
+// platforms. On Windows, files with the hidden or system attribute are also
+// hidden.
+func IsHidden(path string) bool {
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, ".") && name != "." && name != ".." {
+		return true
+	}
+
+	if systemFileNames[strings.ToLower(name)] {
+		return true
+	}
+
+	return hasHiddenAttribute(path)
+}
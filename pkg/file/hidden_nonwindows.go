@@ -0,0 +1,10 @@
+//go:build !windows
+// +build !windows
+
+package file
+
+// hasHiddenAttribute returns false, since hidden files are only named with a
+// leading dot outside of Windows.
+func hasHiddenAttribute(path string) bool {
+	return false
+}
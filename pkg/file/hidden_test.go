@@ -0,0 +1,31 @@
+package file
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIsHidden(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{filepath.Join("library", "scene.mp4"), false},
+		{filepath.Join("library", "dir.name", "scene.mp4"), false},
+		{filepath.Join("library", ".scene.mp4"), true},
+		{filepath.Join("library", ".hidden"), true},
+		{filepath.Join("library", "._scene.mp4"), true},
+		{filepath.Join("library", ".DS_Store"), true},
+		{filepath.Join("library", "Thumbs.db"), true},
+		{filepath.Join("library", "$RECYCLE.BIN"), true},
+		{filepath.Join("library", "System Volume Information"), true},
+		{filepath.Join("library", "@eaDir"), true},
+		{".", false},
+	}
+
+	for _, tt := range tests {
+		if got := IsHidden(tt.path); got != tt.want {
+			t.Errorf("IsHidden(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
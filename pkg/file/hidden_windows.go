@@ -0,0 +1,24 @@
+//go:build windows
+// +build windows
+
+package file
+
+import (
+	"syscall"
+)
+
+// hasHiddenAttribute returns true if the file has the hidden or system
+// attribute.
+func hasHiddenAttribute(path string) bool {
+	p, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return false
+	}
+
+	attrs, err := syscall.GetFileAttributes(p)
+	if err != nil {
+		return false
+	}
+
+	return attrs&(syscall.FILE_ATTRIBUTE_HIDDEN|syscall.FILE_ATTRIBUTE_SYSTEM) != 0
+}
@@ -0,0 +1,218 @@
+package file
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+// ErrDestinationExists is returned when moving a file to a path that
+// already exists.
+var ErrDestinationExists = errors.New("destination already exists")
+
+type move struct {
+	src string
+	dst string
+	// copied is true if the file was copied because it could not be
+	// renamed, such as when moving between volumes.
+	copied bool
+}
+
+// Mover is used to safely move files within the filesystem. During a
+// transaction, files are moved using the Move method. Files are renamed
+// where possible, otherwise they are copied, and the source file is kept
+// until the transaction is committed. If the transaction is rolled back,
+// then the files are restored to their original paths with the Rollback
+// method. If the transaction is committed, the source files of copied
+// files are deleted using the Commit method.
+type Mover struct {
+	RenamerRemover RenamerRemover
+	moves          []move
+}
+
+func NewMover() *Mover {
+	return &Mover{
+		RenamerRemover: renamerRemoverImpl{
+			RenameFn:    os.Rename,
+			RemoveFn:    os.Remove,
+			RemoveAllFn: os.RemoveAll,
+			StatFn:      os.Stat,
+		},
+	}
+}
+
+// Exists returns true if a file exists at the path.
+func (m *Mover) Exists(path string) (bool, error) {
+	_, err := m.RenamerRemover.Stat(path)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+
+	return false, err
+}
+
+// SameFile returns true if the paths refer to the same existing file, such
+// as paths that differ only by case on a case-insensitive file system.
+func (m *Mover) SameFile(a, b string) bool {
+	aInfo, err := m.RenamerRemover.Stat(a)
+	if err != nil {
+		return false
+	}
+	bInfo, err := m.RenamerRemover.Stat(b)
+	if err != nil {
+		return false
+	}
+
+	return os.SameFile(aInfo, bInfo)
+}
+
+// Move moves the file at src to dst, creating the parent directories of
+// dst. An error is returned if dst already exists. Rollback should be
+// called to restore moved files if this function returns an error.
+func (m *Mover) Move(src, dst string) error {
+	exists, err := m.Exists(dst)
+	if err != nil {
+		return fmt.Errorf("check destination %q exists: %w", dst, err)
+	}
+	if exists {
+		if src != dst && m.SameFile(src, dst) {
+			return m.renameCase(src, dst)
+		}
+		return fmt.Errorf("moving %q to %q: %w", src, dst, ErrDestinationExists)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("creating directory for %q: %w", dst, err)
+	}
+
+	err = m.RenamerRemover.Rename(src, dst)
+	if err == nil {
+		m.moves = append(m.moves, move{src: src, dst: dst})
+		return nil
+	}
+
+	// rename fails when moving between volumes
+	logger.Debugf("Unable to rename %q to %q, copying instead: %v", src, dst, err)
+	if err := copyFile(src, dst); err != nil {
+		return fmt.Errorf("copying %q to %q: %w", src, dst, err)
+	}
+
+	m.moves = append(m.moves, move{src: src, dst: dst, copied: true})
+	return nil
+}
+
+// renameCase renames src to dst, which differs only by case, using a
+// temporary name. Renaming directly may do nothing on case-insensitive file
+// systems.
+func (m *Mover) renameCase(src, dst string) error {
+	tmp := dst + ".tmp"
+	for i := 1; ; i++ {
+		exists, err := m.Exists(tmp)
+		if err != nil {
+			return fmt.Errorf("check temporary file %q exists: %w", tmp, err)
+		}
+		if !exists {
+			break
+		}
+		tmp = fmt.Sprintf("%s.tmp%d", dst, i)
+	}
+
+	if err := m.RenamerRemover.Rename(src, tmp); err != nil {
+		return fmt.Errorf("renaming %q to %q: %w", src, tmp, err)
+	}
+	m.moves = append(m.moves, move{src: src, dst: tmp})
+
+	if err := m.RenamerRemover.Rename(tmp, dst); err != nil {
+		return fmt.Errorf("renaming %q to %q: %w", tmp, dst, err)
+	}
+	m.moves = append(m.moves, move{src: tmp, dst: dst})
+
+	return nil
+}
+
+// Rollback tries to restore all moved files to their original paths and
+// clears the moved list. Copied files are deleted, since their source files
+// were kept. Any errors encountered are logged.
+func (m *Mover) Rollback() {
+	// restore in reverse order, in case a file was moved more than once
+	for i := len(m.moves) - 1; i >= 0; i-- {
+		mv := m.moves[i]
+		var err error
+		if mv.copied {
+			err = m.RenamerRemover.Remove(mv.dst)
+		} else {
+			err = m.RenamerRemover.Rename(mv.dst, mv.src)
+		}
+
+		if err != nil {
+			logger.Warnf("Error restoring %q: %v", mv.src, err)
+		}
+	}
+
+	m.moves = nil
+}
+
+// Commit deletes the source files of copied files and clears the moved
+// list. Any errors encountered are logged.
+func (m *Mover) Commit() {
+	for _, mv := range m.moves {
+		if !mv.copied {
+			continue
+		}
+
+		if err := m.RenamerRemover.Remove(mv.src); err != nil {
+			logger.Warnf("Error deleting file %q: %v", mv.src, err)
+		}
+	}
+
+	m.moves = nil
+}
+
+// copyFile copies the contents, permissions and modification time of the
+// file at src to a new file at dst. dst is removed if the copy fails.
+func copyFile(src, dst string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			out.Close()
+			os.Remove(dst)
+		}
+	}()
+
+	if _, err = io.Copy(out, in); err != nil {
+		return err
+	}
+
+	if err = out.Sync(); err != nil {
+		return err
+	}
+
+	if err = out.Close(); err != nil {
+		return err
+	}
+
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
@@ -0,0 +1,155 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newCrossVolumeMover returns a Mover that cannot rename files, as when
+// moving files between volumes.
+func newCrossVolumeMover() *Mover {
+	return &Mover{
+		RenamerRemover: renamerRemoverImpl{
+			RenameFn: func(oldpath, newpath string) error {
+				return &os.LinkError{Op: "rename", Old: oldpath, New: newpath, Err: syscall.EXDEV}
+			},
+			RemoveFn:    os.Remove,
+			RemoveAllFn: os.RemoveAll,
+			StatFn:      os.Stat,
+		},
+	}
+}
+
+func writeMoveFile(t *testing.T, path string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	modTime := time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertFileData(t *testing.T, path string) {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "data", string(data))
+}
+
+func TestMoverMove(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.mp4")
+	dst := filepath.Join(dir, "sub", "b.mp4")
+	writeMoveFile(t, src)
+
+	m := NewMover()
+	assert.Nil(t, m.Move(src, dst))
+	assert.NoFileExists(t, src)
+	assertFileData(t, dst)
+
+	m.Rollback()
+	assert.NoFileExists(t, dst)
+	assertFileData(t, src)
+
+	assert.Nil(t, m.Move(src, dst))
+	m.Commit()
+	assert.NoFileExists(t, src)
+	assertFileData(t, dst)
+}
+
+func TestMoverMoveCrossVolume(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.mp4")
+	dst := filepath.Join(dir, "sub", "b.mp4")
+	writeMoveFile(t, src)
+
+	m := newCrossVolumeMover()
+	assert.Nil(t, m.Move(src, dst))
+
+	// the source is kept until the move is committed
+	assertFileData(t, src)
+	assertFileData(t, dst)
+
+	srcInfo, _ := os.Stat(src)
+	dstInfo, _ := os.Stat(dst)
+	assert.True(t, srcInfo.ModTime().Equal(dstInfo.ModTime()))
+
+	m.Rollback()
+	assertFileData(t, src)
+	assert.NoFileExists(t, dst)
+
+	assert.Nil(t, m.Move(src, dst))
+	m.Commit()
+	assert.NoFileExists(t, src)
+	assertFileData(t, dst)
+}
+
+func TestMoverMoveExisting(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.mp4")
+	dst := filepath.Join(dir, "b.mp4")
+	writeMoveFile(t, src)
+	writeMoveFile(t, dst)
+
+	for _, m := range []*Mover{NewMover(), newCrossVolumeMover()} {
+		err := m.Move(src, dst)
+		assert.True(t, errors.Is(err, ErrDestinationExists))
+		assertFileData(t, src)
+	}
+}
+
+func TestMoverMoveMissing(t *testing.T) {
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "b.mp4")
+
+	err := newCrossVolumeMover().Move(filepath.Join(dir, "a.mp4"), dst)
+	assert.NotNil(t, err)
+	assert.NoFileExists(t, dst)
+}
+
+func TestMoverMoveCaseOnly(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "a.mp4")
+	dst := filepath.Join(dir, "A.mp4")
+	writeMoveFile(t, src)
+
+	// simulate a case-insensitive file system, where both paths refer to
+	// the same file
+	var renames [][2]string
+	m := &Mover{
+		RenamerRemover: renamerRemoverImpl{
+			RenameFn: func(oldpath, newpath string) error {
+				renames = append(renames, [2]string{oldpath, newpath})
+				return nil
+			},
+			RemoveFn:    os.Remove,
+			RemoveAllFn: os.RemoveAll,
+			StatFn: func(name string) (os.FileInfo, error) {
+				if name == dst {
+					name = src
+				}
+				return os.Stat(name)
+			},
+		},
+	}
+
+	assert.Nil(t, m.Move(src, dst))
+	assert.Equal(t, [][2]string{{src, dst + ".tmp"}, {dst + ".tmp", dst}}, renames)
+
+	renames = nil
+	m.Rollback()
+	assert.Equal(t, [][2]string{{dst, dst + ".tmp"}, {dst + ".tmp", src}}, renames)
+}
@@ -58,11 +58,38 @@ func (s Scanned) ContentsChanged() bool {
 	return false
 }
 
+// ChangeRecorder records the objects added or updated by a scan.
+type ChangeRecorder interface {
+	// Added records that the object with the ID was created.
+	Added(id int)
+	// Updated records that the path or hash of the object with the ID
+	// changed.
+	Updated(id int)
+}
+
 type Scanner struct {
 	Hasher Hasher
 
 	CalculateMD5    bool
 	CalculateOSHash bool
+
+	// Changes records the changes made by the scan, if not nil.
+	Changes ChangeRecorder
+}
+
+// RecordAdded records that the object with the ID was created.
+func (o Scanner) RecordAdded(id int) {
+	if o.Changes != nil {
+		o.Changes.Added(id)
+	}
+}
+
+// RecordUpdated records that the path or hash of the object with the ID
+// changed.
+func (o Scanner) RecordUpdated(id int) {
+	if o.Changes != nil {
+		o.Changes.Updated(id)
+	}
 }
 
 func (o Scanner) ScanExisting(existing FileBased, file SourceFile) (h *Scanned, err error) {
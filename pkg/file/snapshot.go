@@ -0,0 +1,223 @@
+package file
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// SnapshotEntry is the state of a file or directory in a Snapshot.
+type SnapshotEntry struct {
+	// ModTime is the modification time in nanoseconds since the epoch.
+	ModTime int64 `json:"m"`
+	// Size is the size of a file. Zero for directories, and for files in
+	// snapshots taken before sizes were recorded.
+	Size  int64 `json:"s,omitempty"`
+	IsDir bool  `json:"d,omitempty"`
+}
+
+// Snapshot records the modification times and sizes of the files and the
+// modification times of the directories in a directory tree.
+type Snapshot struct {
+	Entries map[string]SnapshotEntry `json:"entries"`
+
+	children map[string][]string
+}
+
+// SnapshotDiff lists the files that changed between two snapshots.
+type SnapshotDiff struct {
+	Added    []string
+	Modified []string
+	Removed  []string
+}
+
+func newSnapshot() *Snapshot {
+	return &Snapshot{
+		Entries: make(map[string]SnapshotEntry),
+	}
+}
+
+// UnmarshalSnapshot decodes a snapshot encoded with Marshal.
+func UnmarshalSnapshot(data []byte) (*Snapshot, error) {
+	ret := newSnapshot()
+	if err := json.Unmarshal(data, ret); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// Marshal encodes the snapshot for storage.
+func (s *Snapshot) Marshal() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+func (s *Snapshot) add(path string, info os.FileInfo) {
+	e := SnapshotEntry{
+		ModTime: info.ModTime().UnixNano(),
+		IsDir:   info.IsDir(),
+	}
+	if !e.IsDir {
+		e.Size = info.Size()
+	}
+
+	s.Entries[path] = e
+}
+
+// childrenOf returns the paths of the entries directly within dir.
+func (s *Snapshot) childrenOf(dir string) []string {
+	if s.children == nil {
+		s.children = make(map[string][]string)
+		for p := range s.Entries {
+			parent := filepath.Dir(p)
+			if parent != p {
+				s.children[parent] = append(s.children[parent], p)
+			}
+		}
+	}
+
+	return s.children[dir]
+}
+
+// Diff returns the files that were added, modified or removed in newer
+// compared to s. Files are modified if their modification time or size
+// changed. Sizes are not compared if the size in s was not recorded.
+// Directories are not included. Each list is sorted.
+func (s *Snapshot) Diff(newer *Snapshot) SnapshotDiff {
+	var ret SnapshotDiff
+
+	for p, e := range newer.Entries {
+		if e.IsDir {
+			continue
+		}
+
+		old, found := s.Entries[p]
+		switch {
+		case !found || old.IsDir:
+			ret.Added = append(ret.Added, p)
+		case old.ModTime != e.ModTime, old.Size != 0 && old.Size != e.Size:
+			ret.Modified = append(ret.Modified, p)
+		}
+	}
+
+	for p, e := range s.Entries {
+		if e.IsDir {
+			continue
+		}
+
+		if n, found := newer.Entries[p]; !found || n.IsDir {
+			ret.Removed = append(ret.Removed, p)
+		}
+	}
+
+	sort.Strings(ret.Added)
+	sort.Strings(ret.Modified)
+	sort.Strings(ret.Removed)
+
+	return ret
+}
+
+// TakeSnapshot records the modification times and sizes of the files and
+// directories under root. Symbolic links are followed.
+//
+// If previous is not nil, then directories with the same modification time
+// as in previous are not read. The files of such directories are taken from
+// previous and their state is read again, so that files modified in place
+// are detected, and their subdirectories are visited as before. Files added
+// without changing the modification time of the directory are not detected.
+//
+// If skipDir returns true for a directory, then it is excluded.
+func TakeSnapshot(root string, previous *Snapshot, skipDir func(path string) bool) (*Snapshot, error) {
+	ret := newSnapshot()
+
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ret.visitDir(root, info, previous, skipDir); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (s *Snapshot) visitDir(dir string, info os.FileInfo, previous *Snapshot, skipDir func(path string) bool) error {
+	s.add(dir, info)
+
+	if previous != nil {
+		if old, found := previous.Entries[dir]; found && old.IsDir && old.ModTime == info.ModTime().UnixNano() {
+			return s.copyDir(dir, previous, skipDir)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+
+		// follow symbolic links
+		info, err := os.Stat(path)
+		if err != nil {
+			// ignore invalid links and files removed since reading the directory
+			continue
+		}
+
+		if info.IsDir() {
+			if skipDir != nil && skipDir(path) {
+				continue
+			}
+
+			if err := s.visitDir(path, info, previous, skipDir); err != nil {
+				return err
+			}
+			continue
+		}
+
+		s.add(path, info)
+	}
+
+	return nil
+}
+
+// copyDir visits the entries of an unchanged directory in previous, without
+// reading the directory.
+func (s *Snapshot) copyDir(dir string, previous *Snapshot, skipDir func(path string) bool) error {
+	for _, path := range previous.childrenOf(dir) {
+		e := previous.Entries[path]
+		if !e.IsDir {
+			// files may be modified in place without changing the
+			// directory
+			if info, err := os.Stat(path); err == nil {
+				s.add(path, info)
+			}
+			continue
+		}
+
+		if skipDir != nil && skipDir(path) {
+			continue
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			// removed directories change the modification time of their
+			// parent, but a directory may be replaced by a file
+			continue
+		}
+
+		if !info.IsDir() {
+			s.add(path, info)
+			continue
+		}
+
+		if err := s.visitDir(path, info, previous, skipDir); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
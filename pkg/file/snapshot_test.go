@@ -0,0 +1,207 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeSnapshotTestFile(t *testing.T, path string, modTime time.Time) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("test"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func setDirModTime(t *testing.T, path string, modTime time.Time) {
+	t.Helper()
+
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSnapshotDiff(t *testing.T) {
+	root := t.TempDir()
+	oldTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+	newTime := oldTime.Add(time.Minute)
+
+	unchanged := filepath.Join(root, "sub", "unchanged.mp4")
+	modified := filepath.Join(root, "sub", "modified.mp4")
+	removed := filepath.Join(root, "removed.mp4")
+	added := filepath.Join(root, "sub", "added.mp4")
+
+	writeSnapshotTestFile(t, unchanged, oldTime)
+	writeSnapshotTestFile(t, modified, oldTime)
+	writeSnapshotTestFile(t, removed, oldTime)
+
+	before, err := TakeSnapshot(root, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeSnapshotTestFile(t, modified, newTime)
+	writeSnapshotTestFile(t, added, newTime)
+	if err := os.Remove(removed); err != nil {
+		t.Fatal(err)
+	}
+
+	after, err := TakeSnapshot(root, before, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, SnapshotDiff{
+		Added:    []string{added},
+		Modified: []string{modified},
+		Removed:  []string{removed},
+	}, before.Diff(after))
+
+	assert.Equal(t, SnapshotDiff{}, after.Diff(after))
+}
+
+func TestTakeSnapshotUnchangedDirectory(t *testing.T) {
+	root := t.TempDir()
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	dir := filepath.Join(root, "unchanged")
+	nested := filepath.Join(dir, "nested")
+	existing := filepath.Join(dir, "existing.mp4")
+	nestedExisting := filepath.Join(nested, "existing.mp4")
+
+	writeSnapshotTestFile(t, existing, modTime)
+	writeSnapshotTestFile(t, nestedExisting, modTime)
+	setDirModTime(t, nested, modTime)
+	setDirModTime(t, dir, modTime)
+
+	before, err := TakeSnapshot(root, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a file added without changing the directory modification time is not
+	// detected, since the directory is not read
+	hidden := filepath.Join(dir, "hidden.mp4")
+	writeSnapshotTestFile(t, hidden, modTime)
+	setDirModTime(t, dir, modTime)
+
+	// subdirectories of unchanged directories are still visited
+	nestedAdded := filepath.Join(nested, "added.mp4")
+	writeSnapshotTestFile(t, nestedAdded, modTime)
+
+	after, err := TakeSnapshot(root, before, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff := before.Diff(after)
+	assert.Equal(t, []string{nestedAdded}, diff.Added)
+	assert.Contains(t, after.Entries, existing)
+	assert.NotContains(t, after.Entries, hidden)
+
+	// a snapshot without a previous snapshot reads all directories
+	full, err := TakeSnapshot(root, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, full.Entries, hidden)
+}
+
+func TestTakeSnapshotModifiedInPlace(t *testing.T) {
+	root := t.TempDir()
+	modTime := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+	dir := filepath.Join(root, "unchanged")
+	touched := filepath.Join(dir, "touched.mp4")
+	rewritten := filepath.Join(dir, "rewritten.mp4")
+	unchanged := filepath.Join(dir, "unchanged.mp4")
+
+	writeSnapshotTestFile(t, touched, modTime)
+	writeSnapshotTestFile(t, rewritten, modTime)
+	writeSnapshotTestFile(t, unchanged, modTime)
+	setDirModTime(t, dir, modTime)
+
+	before, err := TakeSnapshot(root, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// files rewritten in place do not change the directory modification
+	// time
+	writeSnapshotTestFile(t, touched, modTime.Add(time.Minute))
+	if err := os.WriteFile(rewritten, []byte("re-encoded"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(rewritten, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	setDirModTime(t, dir, modTime)
+
+	after, err := TakeSnapshot(root, before, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, SnapshotDiff{
+		Modified: []string{rewritten, touched},
+	}, before.Diff(after))
+}
+
+func TestSnapshotDiffWithoutSize(t *testing.T) {
+	// sizes are not compared with snapshots taken before they were recorded
+	before := &Snapshot{Entries: map[string]SnapshotEntry{"a.mp4": {ModTime: 1}}}
+	after := &Snapshot{Entries: map[string]SnapshotEntry{"a.mp4": {ModTime: 1, Size: 10}}}
+	assert.Equal(t, SnapshotDiff{}, before.Diff(after))
+}
+
+func TestTakeSnapshotSkipDir(t *testing.T) {
+	root := t.TempDir()
+	modTime := time.Now()
+
+	included := filepath.Join(root, "included", "a.mp4")
+	skipped := filepath.Join(root, "skipped", "b.mp4")
+	writeSnapshotTestFile(t, included, modTime)
+	writeSnapshotTestFile(t, skipped, modTime)
+
+	s, err := TakeSnapshot(root, nil, func(path string) bool {
+		return filepath.Base(path) == "skipped"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Contains(t, s.Entries, included)
+	assert.NotContains(t, s.Entries, skipped)
+}
+
+func TestSnapshotMarshal(t *testing.T) {
+	root := t.TempDir()
+	writeSnapshotTestFile(t, filepath.Join(root, "a.mp4"), time.Now())
+
+	s, err := TakeSnapshot(root, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := s.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := UnmarshalSnapshot(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, s.Entries, decoded.Entries)
+}
@@ -0,0 +1,364 @@
+package file
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schemes of paths on WebDAV servers. Paths are of the form
+// webdav://host[:port]/path, and are accessed over HTTP, or HTTPS for the
+// webdavs scheme.
+const (
+	WebDAVScheme  = "webdav"
+	WebDAVSScheme = "webdavs"
+)
+
+const propfindBody = `<?xml version="1.0" encoding="utf-8"?>
+<propfind xmlns="DAV:"><prop><resourcetype/><getcontentlength/><getlastmodified/></prop></propfind>`
+
+// WebDAVFileSystem is a read-only FileSystem of a WebDAV server.
+type WebDAVFileSystem struct {
+	client   *http.Client
+	secure   bool
+	username string
+	password string
+}
+
+// NewWebDAVFileSystem returns a FileSystem for paths on WebDAV servers.
+// HTTPS is used if secure is true. Requests are authenticated using basic
+// authentication if username is not empty. If client is nil,
+// http.DefaultClient is used.
+func NewWebDAVFileSystem(secure bool, username string, password string, client *http.Client) *WebDAVFileSystem {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &WebDAVFileSystem{
+		client:   client,
+		secure:   secure,
+		username: username,
+		password: password,
+	}
+}
+
+// httpURL returns the HTTP URL of the path, without credentials.
+func (f *WebDAVFileSystem) httpURL(name string) (*url.URL, error) {
+	i := strings.Index(name, schemeSeparator)
+	if i == -1 {
+		return nil, fmt.Errorf("%s is not a WebDAV path", name)
+	}
+
+	hostPath := name[i+len(schemeSeparator):]
+	host := hostPath
+	p := "/"
+	if j := strings.Index(hostPath, "/"); j != -1 {
+		host = hostPath[:j]
+		p = hostPath[j:]
+	}
+
+	ret := &url.URL{
+		Scheme: "http",
+		Host:   host,
+		Path:   p,
+	}
+	if f.secure {
+		ret.Scheme = "https"
+	}
+
+	return ret, nil
+}
+
+func (f *WebDAVFileSystem) do(op string, name string, req *http.Request, expected ...int) (*http.Response, error) {
+	if f.username != "" {
+		req.SetBasicAuth(f.username, f.password)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: err}
+	}
+
+	for _, s := range expected {
+		if resp.StatusCode == s {
+			return resp, nil
+		}
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrPermission}
+	}
+
+	return nil, &fs.PathError{Op: op, Path: name, Err: fmt.Errorf("unexpected status %s", resp.Status)}
+}
+
+type davMultistatus struct {
+	Responses []davResponse `xml:"DAV: response"`
+}
+
+type davResponse struct {
+	Href      string        `xml:"DAV: href"`
+	Propstats []davPropstat `xml:"DAV: propstat"`
+}
+
+type davPropstat struct {
+	Status string `xml:"DAV: status"`
+	Prop   struct {
+		ResourceType struct {
+			Collection *struct{} `xml:"DAV: collection"`
+		} `xml:"DAV: resourcetype"`
+		ContentLength string `xml:"DAV: getcontentlength"`
+		LastModified  string `xml:"DAV: getlastmodified"`
+	} `xml:"DAV: prop"`
+}
+
+type webdavFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	isDir   bool
+}
+
+func (i *webdavFileInfo) Name() string       { return i.name }
+func (i *webdavFileInfo) Size() int64        { return i.size }
+func (i *webdavFileInfo) ModTime() time.Time { return i.modTime }
+func (i *webdavFileInfo) IsDir() bool        { return i.isDir }
+func (i *webdavFileInfo) Sys() interface{}   { return nil }
+
+func (i *webdavFileInfo) Mode() fs.FileMode {
+	if i.isDir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+// hrefPath returns the unescaped path of a href, without a trailing slash.
+func hrefPath(href string) (string, error) {
+	u, err := url.Parse(href)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSuffix(u.Path, "/"), nil
+}
+
+func (r davResponse) fileInfo() (*webdavFileInfo, error) {
+	p, err := hrefPath(r.Href)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &webdavFileInfo{
+		name: path.Base(p),
+	}
+
+	for _, ps := range r.Propstats {
+		if !strings.Contains(ps.Status, " 200 ") {
+			continue
+		}
+
+		if ps.Prop.ResourceType.Collection != nil {
+			ret.isDir = true
+		}
+		if ps.Prop.ContentLength != "" {
+			ret.size, _ = strconv.ParseInt(ps.Prop.ContentLength, 10, 64)
+		}
+		if ps.Prop.LastModified != "" {
+			ret.modTime, _ = http.ParseTime(ps.Prop.LastModified)
+		}
+	}
+
+	return ret, nil
+}
+
+func (f *WebDAVFileSystem) propfind(op string, name string, depth string) ([]davResponse, string, error) {
+	u, err := f.httpURL(name)
+	if err != nil {
+		return nil, "", err
+	}
+
+	req, err := http.NewRequest("PROPFIND", u.String(), strings.NewReader(propfindBody))
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("Depth", depth)
+	req.Header.Set("Content-Type", "application/xml")
+
+	resp, err := f.do(op, name, req, http.StatusMultiStatus)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	var ms davMultistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, "", &fs.PathError{Op: op, Path: name, Err: fmt.Errorf("decoding response: %w", err)}
+	}
+
+	return ms.Responses, strings.TrimSuffix(u.Path, "/"), nil
+}
+
+func (f *WebDAVFileSystem) Stat(name string) (fs.FileInfo, error) {
+	responses, _, err := f.propfind("stat", name, "0")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(responses) == 0 {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return responses[0].fileInfo()
+}
+
+func (f *WebDAVFileSystem) ReadDir(name string) ([]fs.FileInfo, error) {
+	responses, dirPath, err := f.propfind("readdir", name, "1")
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []fs.FileInfo
+	for _, r := range responses {
+		p, err := hrefPath(r.Href)
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+		}
+
+		// the directory itself is included in the response
+		if p == dirPath {
+			continue
+		}
+
+		info, err := r.fileInfo()
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+		}
+		ret = append(ret, info)
+	}
+
+	return ret, nil
+}
+
+func (f *WebDAVFileSystem) Open(name string) (io.ReadSeekCloser, error) {
+	info, err := f.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: errors.New("is a directory")}
+	}
+
+	return &webdavFile{
+		fs:   f,
+		name: name,
+		size: info.Size(),
+	}, nil
+}
+
+// webdavFile reads a file using HTTP range requests. A new request is made
+// when reading after seeking.
+type webdavFile struct {
+	fs     *WebDAVFileSystem
+	name   string
+	size   int64
+	offset int64
+	body   io.ReadCloser
+}
+
+func (f *webdavFile) Read(p []byte) (int, error) {
+	if f.offset >= f.size {
+		return 0, io.EOF
+	}
+
+	if f.body == nil {
+		if err := f.request(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.body.Read(p)
+	f.offset += int64(n)
+	return n, err
+}
+
+func (f *webdavFile) request() error {
+	u, err := f.fs.httpURL(f.name)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	if f.offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", f.offset))
+	}
+
+	resp, err := f.fs.do("read", f.name, req, http.StatusOK, http.StatusPartialContent)
+	if err != nil {
+		return err
+	}
+
+	// discard up to the offset if the server does not support ranges
+	if resp.StatusCode == http.StatusOK && f.offset > 0 {
+		if _, err := io.CopyN(io.Discard, resp.Body, f.offset); err != nil {
+			resp.Body.Close()
+			return &fs.PathError{Op: "read", Path: f.name, Err: err}
+		}
+	}
+
+	f.body = resp.Body
+	return nil
+}
+
+func (f *webdavFile) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = f.offset + offset
+	case io.SeekEnd:
+		abs = f.size + offset
+	default:
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: errors.New("invalid whence")}
+	}
+
+	if abs < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: errors.New("negative position")}
+	}
+
+	if abs != f.offset {
+		f.closeBody()
+		f.offset = abs
+	}
+
+	return abs, nil
+}
+
+func (f *webdavFile) closeBody() {
+	if f.body != nil {
+		f.body.Close()
+		f.body = nil
+	}
+}
+
+func (f *webdavFile) Close() error {
+	f.closeBody()
+	return nil
+}
@@ -0,0 +1,202 @@
+package file
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/net/webdav"
+)
+
+const (
+	testDAVUsername = "user"
+	testDAVPassword = "password"
+)
+
+func writeDAVFile(t *testing.T, fsys webdav.FileSystem, name string, content string) {
+	t.Helper()
+
+	f, err := fsys.OpenFile(context.Background(), name, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write([]byte(content)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newTestWebDAVServer returns a WebDAV server backed by an in-memory file
+// system.
+func newTestWebDAVServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	ctx := context.Background()
+	fsys := webdav.NewMemFS()
+	for _, dir := range []string{"/lib", "/lib/sub dir"} {
+		if err := fsys.Mkdir(ctx, dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeDAVFile(t, fsys, "/lib/video #1.mp4", "0123456789")
+	writeDAVFile(t, fsys, "/lib/sub dir/b.mp4", "b")
+
+	handler := &webdav.Handler{
+		FileSystem: fsys,
+		LockSystem: webdav.NewMemLS(),
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != testDAVUsername || p != testDAVPassword {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func davRoot(server *httptest.Server) string {
+	return WebDAVScheme + schemeSeparator + strings.TrimPrefix(server.URL, "http://") + "/lib"
+}
+
+func TestWebDAVStatReadDir(t *testing.T) {
+	server := newTestWebDAVServer(t)
+	fsys := NewWebDAVFileSystem(false, testDAVUsername, testDAVPassword, server.Client())
+	root := davRoot(server)
+
+	info, err := fsys.Stat(root)
+	if assert.Nil(t, err) {
+		assert.True(t, info.IsDir())
+		assert.Equal(t, "lib", info.Name())
+	}
+
+	info, err = fsys.Stat(root + "/video #1.mp4")
+	if assert.Nil(t, err) {
+		assert.False(t, info.IsDir())
+		assert.Equal(t, "video #1.mp4", info.Name())
+		assert.Equal(t, int64(10), info.Size())
+		assert.False(t, info.ModTime().IsZero())
+	}
+
+	_, err = fsys.Stat(root + "/missing.mp4")
+	assert.True(t, errors.Is(err, fs.ErrNotExist))
+
+	entries, err := fsys.ReadDir(root)
+	if assert.Nil(t, err) {
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		assert.ElementsMatch(t, []string{"sub dir", "video #1.mp4"}, names)
+	}
+
+	var visited []string
+	err = Walk(fsys, root, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			visited = append(visited, path)
+		}
+		return err
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{root + "/sub dir/b.mp4", root + "/video #1.mp4"}, visited)
+}
+
+func TestWebDAVOpen(t *testing.T) {
+	server := newTestWebDAVServer(t)
+	fsys := NewWebDAVFileSystem(false, testDAVUsername, testDAVPassword, server.Client())
+	path := davRoot(server) + "/video #1.mp4"
+
+	f, err := fsys.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(f, buf); assert.Nil(t, err) {
+		assert.Equal(t, "0123", string(buf))
+	}
+
+	// seeking should read from the new offset
+	pos, err := f.Seek(-3, io.SeekEnd)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), pos)
+
+	rest, err := io.ReadAll(f)
+	assert.Nil(t, err)
+	assert.Equal(t, "789", string(rest))
+
+	if _, err := f.Seek(2, io.SeekStart); assert.Nil(t, err) {
+		if _, err := io.ReadFull(f, buf); assert.Nil(t, err) {
+			assert.Equal(t, "2345", string(buf))
+		}
+	}
+
+	_, err = fsys.Open(davRoot(server))
+	assert.NotNil(t, err)
+}
+
+func TestWebDAVAuthentication(t *testing.T) {
+	server := newTestWebDAVServer(t)
+	fsys := NewWebDAVFileSystem(false, testDAVUsername, "invalid", server.Client())
+
+	_, err := fsys.Stat(davRoot(server))
+	assert.True(t, errors.Is(err, fs.ErrPermission))
+}
+
+func TestLocalURL(t *testing.T) {
+	server := newTestWebDAVServer(t)
+	RegisterFileSystem(WebDAVScheme, NewWebDAVFileSystem(false, testDAVUsername, testDAVPassword, server.Client()))
+	t.Cleanup(func() {
+		fileSystemsMutex.Lock()
+		defer fileSystemsMutex.Unlock()
+		delete(fileSystems, WebDAVScheme)
+	})
+
+	localPath := "/lib/a.mp4"
+	u, closeURL, err := LocalURL(localPath)
+	if assert.Nil(t, err) {
+		assert.Equal(t, localPath, u)
+		closeURL()
+	}
+
+	u, closeURL, err = LocalURL(davRoot(server) + "/video #1.mp4")
+	if err != nil {
+		t.Fatalf("LocalURL error: %v", err)
+	}
+
+	assert.True(t, strings.HasPrefix(u, "http://127.0.0.1:"))
+	assert.True(t, strings.HasSuffix(u, ".mp4"))
+	assert.NotContains(t, u, testDAVPassword)
+
+	resp, err := http.Get(u)
+	if assert.Nil(t, err) {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "0123456789", string(body))
+	}
+
+	// only the file path is served
+	resp, err = http.Get(strings.TrimSuffix(u, ".mp4") + ".mkv")
+	if assert.Nil(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+
+	closeURL()
+	_, err = http.Get(u)
+	assert.NotNil(t, err)
+}
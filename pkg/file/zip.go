@@ -2,9 +2,12 @@ package file
 
 import (
 	"archive/zip"
+	"fmt"
 	"io"
 	"io/fs"
+	"path"
 	"strings"
+	"sync"
 )
 
 const zipSeparator = "\x00"
@@ -62,3 +65,97 @@ func ZipFilePath(path string) (zipFilename, filename string) {
 	}
 	return
 }
+
+// ZipReader reads the entries of a zip file on demand, without extracting
+// the zip file. Zip files on remote file systems are read using seeks, so
+// that only the directory and the entries read are transferred.
+type ZipReader struct {
+	*zip.Reader
+	f io.Closer
+}
+
+// OpenZip opens the zip file at zipPath, which may be on any registered file
+// system. The returned reader must be closed.
+func OpenZip(zipPath string) (*ZipReader, error) {
+	f, err := FileSystemFor(zipPath).Open(zipPath)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	ra, ok := f.(io.ReaderAt)
+	if !ok {
+		ra = &seekReaderAt{r: f}
+	}
+
+	r, err := zip.NewReader(ra, size)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("error reading zip file %s: %w", zipPath, err)
+	}
+
+	return &ZipReader{
+		Reader: r,
+		f:      f,
+	}, nil
+}
+
+func (z *ZipReader) Close() error {
+	return z.f.Close()
+}
+
+// Files returns the file entries of the zip file, including files in nested
+// directories. Directory entries and macOS resource forks are excluded.
+func (z *ZipReader) Files() []*zip.File {
+	var ret []*zip.File
+	for _, f := range z.File {
+		if f.FileInfo().IsDir() || strings.Contains(f.Name, "__MACOSX") {
+			continue
+		}
+		ret = append(ret, f)
+	}
+
+	return ret
+}
+
+// Find returns the file entry with the name, or nil if there is no such
+// entry. Names are compared after normalizing path separators, so that
+// entries of zip files created on Windows are found.
+func (z *ZipReader) Find(name string) *zip.File {
+	name = normalizeZipName(name)
+	for _, f := range z.File {
+		if normalizeZipName(f.Name) == name {
+			return f
+		}
+	}
+
+	return nil
+}
+
+func normalizeZipName(name string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+// seekReaderAt implements io.ReaderAt for files that do not implement it,
+// by seeking before each read.
+type seekReaderAt struct {
+	mutex sync.Mutex
+	r     io.ReadSeeker
+}
+
+func (s *seekReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err := s.r.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+
+	return io.ReadFull(s.r, p)
+}
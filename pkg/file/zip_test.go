@@ -0,0 +1,151 @@
+package file
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var zipFixtureFiles = []struct {
+	name string
+	data string
+}{
+	{"cover.jpg", "cover"},
+	{"sub/", ""},
+	{"sub/a.jpg", "a"},
+	{"sub/nested/b.png", "b"},
+	{"__MACOSX/sub/._a.jpg", "resource fork"},
+	{"win\\c.jpg", "c"},
+}
+
+func makeZipFixture(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, f := range zipFixtureFiles {
+		fw, err := w.Create(f.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write([]byte(f.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func readZipEntry(t *testing.T, f *zip.File) string {
+	t.Helper()
+
+	rc, err := f.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+func testZipReader(t *testing.T, zipPath string) {
+	t.Helper()
+
+	r, err := OpenZip(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	var names []string
+	for _, f := range r.Files() {
+		names = append(names, f.Name)
+	}
+	assert.Equal(t, []string{"cover.jpg", "sub/a.jpg", "sub/nested/b.png", "win\\c.jpg"}, names)
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"cover.jpg", "cover"},
+		{"sub/nested/b.png", "b"},
+		{"./sub/a.jpg", "a"},
+		{"sub\\nested\\b.png", "b"},
+		{"win/c.jpg", "c"},
+	}
+	for _, tt := range tests {
+		f := r.Find(tt.name)
+		if !assert.NotNil(t, f, tt.name) {
+			continue
+		}
+		assert.Equal(t, tt.want, readZipEntry(t, f), tt.name)
+	}
+
+	assert.Nil(t, r.Find("missing.jpg"))
+}
+
+func TestOpenZip(t *testing.T) {
+	zipPath := filepath.Join(t.TempDir(), "gallery.zip")
+	if err := os.WriteFile(zipPath, makeZipFixture(t), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testZipReader(t, zipPath)
+
+	_, err := OpenZip(filepath.Join(t.TempDir(), "missing.zip"))
+	assert.NotNil(t, err)
+}
+
+// seekOnlyFile hides the ReadAt method of a file.
+type seekOnlyFile struct {
+	io.ReadSeekCloser
+}
+
+type seekOnlyFileSystem struct {
+	memFileSystem
+}
+
+func (f seekOnlyFileSystem) Open(path string) (io.ReadSeekCloser, error) {
+	file, err := f.memFileSystem.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return seekOnlyFile{file}, nil
+}
+
+func TestOpenZipRemote(t *testing.T) {
+	RegisterFileSystem(memScheme, seekOnlyFileSystem{
+		memFileSystem: memFileSystem{
+			fsys: fstest.MapFS{
+				"lib/gallery.zip": {Data: makeZipFixture(t), ModTime: memModTime},
+				"lib/invalid.zip": {Data: []byte("not a zip file"), ModTime: memModTime},
+			},
+		},
+	})
+	t.Cleanup(func() {
+		fileSystemsMutex.Lock()
+		delete(fileSystems, memScheme)
+		fileSystemsMutex.Unlock()
+	})
+
+	testZipReader(t, "mem://lib/gallery.zip")
+
+	_, err := OpenZip("mem://lib/invalid.zip")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,98 @@
+package gallery
+
+import (
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+
+	"github.com/stashapp/stash/pkg/image"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// SelectCover returns the cover image from the provided gallery images,
+// using the provided strategy. Images named as covers always take
+// precedence. The seed is used by the random strategy, so that the same
+// image is selected for the same seed and images. Returns nil if images is
+// empty.
+func SelectCover(images []*models.Image, strategy models.GalleryCoverStrategy, seed string) *models.Image {
+	if len(images) == 0 {
+		return nil
+	}
+
+	for _, img := range images {
+		if image.IsCover(img) {
+			return img
+		}
+	}
+
+	switch strategy {
+	case models.GalleryCoverStrategyMiddle:
+		return images[len(images)/2]
+	case models.GalleryCoverStrategyRandom:
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(seed))
+		r := rand.New(rand.NewSource(int64(h.Sum64())))
+		return images[r.Intn(len(images))]
+	case models.GalleryCoverStrategyLargest:
+		return largestImage(images)
+	default:
+		return images[0]
+	}
+}
+
+// largestImage returns the image with the largest area. The first image is
+// returned if there are multiple with the same area.
+func largestImage(images []*models.Image) *models.Image {
+	ret := images[0]
+	var largest int64 = -1
+	for _, img := range images {
+		area := img.Width.Int64 * img.Height.Int64
+		if area > largest {
+			ret = img
+			largest = area
+		}
+	}
+
+	return ret
+}
+
+// SelectCoverID returns the id of the cover for the gallery, selected from
+// its images using the provided strategy. The returned id is not valid if
+// the gallery has no images.
+func SelectCoverID(iqb models.ImageReader, g *models.Gallery, strategy models.GalleryCoverStrategy) (sql.NullInt64, error) {
+	images, err := iqb.FindByGalleryID(g.ID)
+	if err != nil {
+		return sql.NullInt64{}, fmt.Errorf("finding gallery images: %w", err)
+	}
+
+	coverID := sql.NullInt64{}
+	if cover := SelectCover(images, strategy, g.Checksum); cover != nil {
+		coverID = sql.NullInt64{
+			Int64: int64(cover.ID),
+			Valid: true,
+		}
+	}
+
+	return coverID, nil
+}
+
+// ResolveCover selects the cover for the gallery from its images using the
+// provided strategy, and stores it against the gallery. The cover is
+// cleared if the gallery has no images. The gallery is not updated if the
+// cover has not changed.
+func ResolveCover(qb models.GalleryWriter, iqb models.ImageReader, g *models.Gallery, strategy models.GalleryCoverStrategy) (*models.Gallery, error) {
+	coverID, err := SelectCoverID(iqb, g, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	if coverID == g.CoverImageID {
+		return g, nil
+	}
+
+	return qb.UpdatePartial(models.GalleryPartial{
+		ID:           g.ID,
+		CoverImageID: &coverID,
+	})
+}
@@ -0,0 +1,131 @@
+package gallery
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	coverGalleryID   = 1
+	emptyGalleryID   = 2
+	errorGalleryID   = 3
+	coverGallerySeed = "checksum"
+)
+
+func makeCoverImage(id int, path string, width, height int64) *models.Image {
+	return &models.Image{
+		ID:     id,
+		Path:   path,
+		Width:  sql.NullInt64{Int64: width, Valid: width != 0},
+		Height: sql.NullInt64{Int64: height, Valid: height != 0},
+	}
+}
+
+var coverImages = []*models.Image{
+	makeCoverImage(1, "intro.jpg", 640, 480),
+	makeCoverImage(2, "b.jpg", 1920, 1080),
+	makeCoverImage(3, "c.jpg", 0, 0),
+	makeCoverImage(4, "d.jpg", 1080, 1920),
+	makeCoverImage(5, "e.jpg", 800, 600),
+}
+
+func TestSelectCover(t *testing.T) {
+	withCover := append([]*models.Image{}, coverImages...)
+	withCover = append(withCover, makeCoverImage(6, "cover.jpg", 10, 10))
+
+	tests := []struct {
+		name     string
+		images   []*models.Image
+		strategy models.GalleryCoverStrategy
+		wantID   int
+	}{
+		{"first", coverImages, models.GalleryCoverStrategyFirst, 1},
+		{"middle", coverImages, models.GalleryCoverStrategyMiddle, 3},
+		{"middle even", coverImages[:4], models.GalleryCoverStrategyMiddle, 3},
+		// first of images with equal largest area is selected
+		{"largest", coverImages, models.GalleryCoverStrategyLargest, 2},
+		{"invalid", coverImages, "", 1},
+		{"cover file first", withCover, models.GalleryCoverStrategyFirst, 6},
+		{"cover file largest", withCover, models.GalleryCoverStrategyLargest, 6},
+		{"single", coverImages[2:3], models.GalleryCoverStrategyLargest, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SelectCover(tt.images, tt.strategy, coverGallerySeed)
+			if assert.NotNil(t, got) {
+				assert.Equal(t, tt.wantID, got.ID)
+			}
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		assert.Nil(t, SelectCover(nil, models.GalleryCoverStrategyFirst, coverGallerySeed))
+	})
+}
+
+func TestSelectCoverRandom(t *testing.T) {
+	assert := assert.New(t)
+
+	got := SelectCover(coverImages, models.GalleryCoverStrategyRandom, coverGallerySeed)
+	if !assert.NotNil(got) {
+		return
+	}
+	assert.Contains(coverImages, got)
+
+	// same seed should select the same image
+	for i := 0; i < 10; i++ {
+		assert.Equal(got, SelectCover(coverImages, models.GalleryCoverStrategyRandom, coverGallerySeed))
+	}
+
+	// different seeds should not always select the same image
+	selected := make(map[int]bool)
+	for _, seed := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		selected[SelectCover(coverImages, models.GalleryCoverStrategyRandom, seed).ID] = true
+	}
+	assert.Greater(len(selected), 1)
+}
+
+func TestResolveCover(t *testing.T) {
+	mockGalleryReader := &mocks.GalleryReaderWriter{}
+	mockImageReader := &mocks.ImageReaderWriter{}
+
+	errFind := errors.New("find error")
+
+	mockImageReader.On("FindByGalleryID", coverGalleryID).Return(coverImages, nil).Once()
+	mockImageReader.On("FindByGalleryID", emptyGalleryID).Return(nil, nil).Once()
+	mockImageReader.On("FindByGalleryID", errorGalleryID).Return(nil, errFind).Once()
+
+	mockGalleryReader.On("UpdatePartial", models.GalleryPartial{
+		ID:           coverGalleryID,
+		CoverImageID: &sql.NullInt64{Int64: 3, Valid: true},
+	}).Return(&models.Gallery{ID: coverGalleryID}, nil).Once()
+	mockGalleryReader.On("UpdatePartial", models.GalleryPartial{
+		ID:           emptyGalleryID,
+		CoverImageID: &sql.NullInt64{},
+	}).Return(&models.Gallery{ID: emptyGalleryID}, nil).Once()
+
+	_, err := ResolveCover(mockGalleryReader, mockImageReader, &models.Gallery{ID: coverGalleryID}, models.GalleryCoverStrategyMiddle)
+	assert.Nil(t, err)
+
+	_, err = ResolveCover(mockGalleryReader, mockImageReader, &models.Gallery{ID: emptyGalleryID, CoverImageID: sql.NullInt64{Int64: 1, Valid: true}}, models.GalleryCoverStrategyFirst)
+	assert.Nil(t, err)
+
+	// unchanged covers are not updated
+	unchanged := &models.Gallery{ID: coverGalleryID, CoverImageID: sql.NullInt64{Int64: 3, Valid: true}}
+	mockImageReader.On("FindByGalleryID", coverGalleryID).Return(coverImages, nil).Once()
+	got, err := ResolveCover(mockGalleryReader, mockImageReader, unchanged, models.GalleryCoverStrategyMiddle)
+	assert.Nil(t, err)
+	assert.Equal(t, unchanged, got)
+
+	_, err = ResolveCover(mockGalleryReader, mockImageReader, &models.Gallery{ID: errorGalleryID}, models.GalleryCoverStrategyFirst)
+	assert.ErrorIs(t, err, errFind)
+
+	mockGalleryReader.AssertExpectations(t)
+	mockImageReader.AssertExpectations(t)
+}
@@ -1,7 +1,6 @@
 package gallery
 
 import (
-	"archive/zip"
 	"context"
 	"database/sql"
 	"fmt"
@@ -93,6 +92,10 @@ func (scanner *Scanner) ScanExisting(existing file.FileBased, file file.SourceFi
 			return nil, false, err
 		}
 
+		if scanned.ContentsChanged() {
+			scanner.RecordUpdated(retGallery.ID)
+		}
+
 		scanner.PluginCache.ExecutePostHooks(scanner.Ctx, retGallery.ID, plugin.GalleryUpdatePost, nil, nil)
 	}
 
@@ -182,8 +185,10 @@ func (scanner *Scanner) ScanNew(file file.SourceFile) (retGallery *models.Galler
 	}
 
 	if isNewGallery {
+		scanner.RecordAdded(g.ID)
 		scanner.PluginCache.ExecutePostHooks(scanner.Ctx, g.ID, plugin.GalleryCreatePost, nil, nil)
 	} else if isUpdatedGallery {
+		scanner.RecordUpdated(g.ID)
 		scanner.PluginCache.ExecutePostHooks(scanner.Ctx, g.ID, plugin.GalleryUpdatePost, nil, nil)
 	}
 
@@ -198,27 +203,17 @@ func (scanner *Scanner) isImage(pathname string) bool {
 }
 
 func (scanner *Scanner) hasImages(path string) bool {
-	readCloser, err := zip.OpenReader(path)
+	r, err := file.OpenZip(path)
 	if err != nil {
 		logger.Warnf("Error while walking gallery zip: %v", err)
 		return false
 	}
-	defer readCloser.Close()
-
-	for _, file := range readCloser.File {
-		if file.FileInfo().IsDir() {
-			continue
-		}
+	defer r.Close()
 
-		if strings.Contains(file.Name, "__MACOSX") {
-			continue
+	for _, f := range r.Files() {
+		if scanner.isImage(f.Name) {
+			return true
 		}
-
-		if !scanner.isImage(file.Name) {
-			continue
-		}
-
-		return true
 	}
 
 	return false
@@ -27,29 +27,40 @@ type ScraperSource struct {
 }
 
 type SceneIdentifier struct {
-	DefaultOptions              *models.IdentifyMetadataOptionsInput
-	Sources                     []ScraperSource
+	DefaultOptions *models.IdentifyMetadataOptionsInput
+	Sources        []ScraperSource
+	// MatchStrategy selects the result used if multiple sources find a
+	// match. Defaults to the result of the first source.
+	MatchStrategy models.IdentifyMatchStrategy
+	// TitleThreshold is the minimum title similarity of results selected
+	// using the FUZZY_TITLE strategy.
+	TitleThreshold float64
+
 	ScreenshotSetter            scene.ScreenshotSetter
 	SceneUpdatePostHookExecutor SceneUpdatePostHookExecutor
 }
 
-func (t *SceneIdentifier) Identify(ctx context.Context, txnManager models.TransactionManager, scene *models.Scene) error {
+// Identify scrapes the scene using the sources, and updates the scene with
+// the result selected by the match strategy. It returns true if the scene
+// was updated.
+func (t *SceneIdentifier) Identify(ctx context.Context, txnManager models.TransactionManager, scene *models.Scene) (bool, error) {
 	result, err := t.scrapeScene(ctx, scene)
 	if err != nil {
-		return err
+		return false, err
 	}
 
 	if result == nil {
 		logger.Debugf("Unable to identify %s", scene.Path)
-		return nil
+		return false, nil
 	}
 
 	// results were found, modify the scene
-	if err := t.modifyScene(ctx, txnManager, scene, result); err != nil {
-		return fmt.Errorf("error modifying scene: %v", err)
+	updated, err := t.modifyScene(ctx, txnManager, scene, result)
+	if err != nil {
+		return false, fmt.Errorf("error modifying scene: %v", err)
 	}
 
-	return nil
+	return updated, nil
 }
 
 type scrapeResult struct {
@@ -58,6 +69,15 @@ type scrapeResult struct {
 }
 
 func (t *SceneIdentifier) scrapeScene(ctx context.Context, scene *models.Scene) (*scrapeResult, error) {
+	if t.MatchStrategy.IsValid() && t.MatchStrategy != models.IdentifyMatchStrategyPriority {
+		candidates, err := t.scrapeAll(ctx, scene)
+		if err != nil {
+			return nil, err
+		}
+
+		return t.selectMatch(scene, candidates), nil
+	}
+
 	// iterate through the input sources
 	for _, source := range t.Sources {
 		// scrape using the source
@@ -165,7 +185,7 @@ func (t *SceneIdentifier) getSceneUpdater(ctx context.Context, s *models.Scene,
 	return ret, nil
 }
 
-func (t *SceneIdentifier) modifyScene(ctx context.Context, txnManager models.TransactionManager, s *models.Scene, result *scrapeResult) error {
+func (t *SceneIdentifier) modifyScene(ctx context.Context, txnManager models.TransactionManager, s *models.Scene, result *scrapeResult) (bool, error) {
 	var updater *scene.UpdateSet
 	if err := txnManager.WithTxn(ctx, func(repo models.Repository) error {
 		var err error
@@ -194,17 +214,19 @@ func (t *SceneIdentifier) modifyScene(ctx context.Context, txnManager models.Tra
 
 		return nil
 	}); err != nil {
-		return err
+		return false, err
 	}
 
-	// fire post-update hooks
-	if !updater.IsEmpty() {
-		updateInput := updater.UpdateInput()
-		fields := utils.NotNilFields(updateInput, "json")
-		t.SceneUpdatePostHookExecutor.ExecuteSceneUpdatePostHooks(ctx, updateInput, fields)
+	if updater.IsEmpty() {
+		return false, nil
 	}
 
-	return nil
+	// fire post-update hooks
+	updateInput := updater.UpdateInput()
+	fields := utils.NotNilFields(updateInput, "json")
+	t.SceneUpdatePostHookExecutor.ExecuteSceneUpdatePostHooks(ctx, updateInput, fields)
+
+	return true, nil
 }
 
 func getFieldOptions(options []models.IdentifyMetadataOptionsInput) map[string]*models.IdentifyFieldOptionsInput {
@@ -78,38 +78,45 @@ func TestSceneIdentifier_Identify(t *testing.T) {
 	})).Return(nil, errors.New("update error"))
 
 	tests := []struct {
-		name    string
-		sceneID int
-		wantErr bool
+		name        string
+		sceneID     int
+		wantUpdated bool
+		wantErr     bool
 	}{
 		{
 			"error scraping",
 			errID1,
+			false,
 			true,
 		},
 		{
 			"error scraping from second",
 			errID2,
+			false,
 			true,
 		},
 		{
 			"found in first scraper",
 			found1ID,
+			true,
 			false,
 		},
 		{
 			"found in second scraper",
 			found2ID,
+			true,
 			false,
 		},
 		{
 			"not found",
 			missingID,
 			false,
+			false,
 		},
 		{
 			"error modifying",
 			errUpdateID,
+			false,
 			true,
 		},
 	}
@@ -125,9 +132,13 @@ func TestSceneIdentifier_Identify(t *testing.T) {
 			scene := &models.Scene{
 				ID: tt.sceneID,
 			}
-			if err := identifier.Identify(context.TODO(), repo, scene); (err != nil) != tt.wantErr {
+			updated, err := identifier.Identify(context.TODO(), repo, scene)
+			if (err != nil) != tt.wantErr {
 				t.Errorf("SceneIdentifier.Identify() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if updated != tt.wantUpdated {
+				t.Errorf("SceneIdentifier.Identify() updated = %v, want %v", updated, tt.wantUpdated)
+			}
 		})
 	}
 }
@@ -158,7 +169,7 @@ func TestSceneIdentifier_modifyScene(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if err := tr.modifyScene(context.TODO(), repo, tt.args.scene, tt.args.result); (err != nil) != tt.wantErr {
+			if _, err := tr.modifyScene(context.TODO(), repo, tt.args.scene, tt.args.result); (err != nil) != tt.wantErr {
 				t.Errorf("SceneIdentifier.modifyScene() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
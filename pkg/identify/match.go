@@ -0,0 +1,184 @@
+package identify
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// DefaultTitleThreshold is the default minimum title similarity of matches
+// selected using the FUZZY_TITLE strategy.
+const DefaultTitleThreshold = 0.8
+
+// ValidateTitleThreshold returns an error if the title threshold is set and
+// is not greater than 0 and at most 1. A threshold of 0 or less would
+// select any candidate.
+func ValidateTitleThreshold(threshold *float64) error {
+	if threshold != nil && (*threshold <= 0 || *threshold > 1) {
+		return fmt.Errorf("titleThreshold must be greater than 0 and at most 1, got %v", *threshold)
+	}
+
+	return nil
+}
+
+// scrapeAll scrapes the scene using all sources, returning the results in
+// source order.
+func (t *SceneIdentifier) scrapeAll(ctx context.Context, scene *models.Scene) ([]*scrapeResult, error) {
+	var ret []*scrapeResult
+	for _, source := range t.Sources {
+		scraped, err := source.Scraper.ScrapeScene(ctx, scene.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error scraping from %v: %v", source.Scraper, err)
+		}
+
+		if scraped != nil {
+			ret = append(ret, &scrapeResult{
+				result: scraped,
+				source: source,
+			})
+		}
+	}
+
+	return ret, nil
+}
+
+// selectMatch returns the candidate selected by the match strategy, or nil
+// if no candidate is selected. Candidates are in source order, and ties are
+// broken by selecting the earliest candidate.
+func (t *SceneIdentifier) selectMatch(scene *models.Scene, candidates []*scrapeResult) *scrapeResult {
+	var score func(*models.ScrapedScene) float64
+	minScore := 0.0
+
+	switch t.MatchStrategy {
+	case models.IdentifyMatchStrategyFieldCoverage:
+		score = func(s *models.ScrapedScene) float64 {
+			return float64(fieldCoverage(s))
+		}
+	case models.IdentifyMatchStrategyFuzzyTitle:
+		title := sceneTitle(scene)
+		score = func(s *models.ScrapedScene) float64 {
+			if s.Title == nil {
+				return -1
+			}
+			return titleSimilarity(title, *s.Title)
+		}
+		minScore = t.TitleThreshold
+	default:
+		if len(candidates) > 0 {
+			return candidates[0]
+		}
+		return nil
+	}
+
+	var ret *scrapeResult
+	var best float64
+	for _, c := range candidates {
+		s := score(c.result)
+		if s < minScore {
+			continue
+		}
+
+		if ret == nil || s > best {
+			ret = c
+			best = s
+		}
+	}
+
+	return ret
+}
+
+// fieldCoverage returns the number of fields set in the scraped scene.
+func fieldCoverage(s *models.ScrapedScene) int {
+	ret := 0
+	for _, set := range []bool{
+		s.Title != nil && *s.Title != "",
+		s.Details != nil && *s.Details != "",
+		s.URL != nil && *s.URL != "",
+		s.Date != nil && *s.Date != "",
+		s.Image != nil && *s.Image != "",
+		s.Studio != nil,
+		len(s.Tags) > 0,
+		len(s.Performers) > 0,
+		len(s.Movies) > 0,
+	} {
+		if set {
+			ret++
+		}
+	}
+
+	return ret
+}
+
+// sceneTitle returns the title of the scene, or the filename without the
+// extension if the scene has no title.
+func sceneTitle(scene *models.Scene) string {
+	if scene.Title.String != "" {
+		return scene.Title.String
+	}
+
+	name := filepath.Base(scene.Path)
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// normalizeTitle lowercases the title, and replaces punctuation and runs of
+// whitespace with single spaces.
+func normalizeTitle(s string) []rune {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return []rune(strings.Join(fields, " "))
+}
+
+// titleSimilarity returns the similarity of the titles between 0 and 1,
+// based on the edit distance of the normalized titles.
+func titleSimilarity(a, b string) float64 {
+	ra := normalizeTitle(a)
+	rb := normalizeTitle(b)
+
+	maxLen := len(ra)
+	if len(rb) > maxLen {
+		maxLen = len(rb)
+	}
+	if maxLen == 0 {
+		return 1
+	}
+
+	return 1 - float64(levenshtein(ra, rb))/float64(maxLen)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+func minInt(values ...int) int {
+	ret := values[0]
+	for _, v := range values[1:] {
+		if v < ret {
+			ret = v
+		}
+	}
+	return ret
+}
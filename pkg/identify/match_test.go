@@ -0,0 +1,176 @@
+package identify
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSceneIdentifier_selectMatch(t *testing.T) {
+	const sceneID = 1
+	str := func(s string) *string { return &s }
+
+	// candidates in source order
+	candidates := []*models.ScrapedScene{
+		{
+			Title: str("A Different Scene"),
+		},
+		{
+			Title:   str("The Scene Title (2021)"),
+			Details: str("details"),
+			Date:    str("2021-01-01"),
+		},
+		{
+			Title:   str("the scene title"),
+			Details: str("details"),
+		},
+		{
+			Title:   str("Another Scene"),
+			Details: str("details"),
+			Date:    str("2021-01-01"),
+			Tags:    []*models.ScrapedTag{{Name: "tag"}},
+		},
+		{
+			Title:   str("Yet Another Scene"),
+			Details: str("details"),
+			URL:     str("url"),
+			Studio:  &models.ScrapedStudio{Name: "studio"},
+		},
+	}
+
+	var sources []ScraperSource
+	for i, c := range candidates {
+		sources = append(sources, ScraperSource{
+			Name: string(rune('a' + i)),
+			Scraper: mockSceneScraper{
+				results: map[int]*models.ScrapedScene{sceneID: c},
+			},
+		})
+	}
+
+	scene := &models.Scene{
+		ID:    sceneID,
+		Title: models.NullString("The Scene Title"),
+	}
+
+	tests := []struct {
+		name      string
+		strategy  models.IdentifyMatchStrategy
+		threshold float64
+		sources   []ScraperSource
+		scene     *models.Scene
+		want      *models.ScrapedScene
+	}{
+		{
+			"default is priority",
+			"",
+			0,
+			sources,
+			scene,
+			candidates[0],
+		},
+		{
+			"priority",
+			models.IdentifyMatchStrategyPriority,
+			0,
+			sources[1:],
+			scene,
+			candidates[1],
+		},
+		{
+			"field coverage tie uses first",
+			models.IdentifyMatchStrategyFieldCoverage,
+			0,
+			sources,
+			scene,
+			candidates[3],
+		},
+		{
+			"field coverage",
+			models.IdentifyMatchStrategyFieldCoverage,
+			0,
+			sources[:3],
+			scene,
+			candidates[1],
+		},
+		{
+			"fuzzy title",
+			models.IdentifyMatchStrategyFuzzyTitle,
+			DefaultTitleThreshold,
+			sources,
+			scene,
+			candidates[2],
+		},
+		{
+			"fuzzy title below threshold",
+			models.IdentifyMatchStrategyFuzzyTitle,
+			DefaultTitleThreshold,
+			sources[3:],
+			scene,
+			nil,
+		},
+		{
+			"fuzzy title uses filename",
+			models.IdentifyMatchStrategyFuzzyTitle,
+			DefaultTitleThreshold,
+			sources,
+			&models.Scene{ID: sceneID, Path: "/videos/Another.Scene.mp4"},
+			candidates[3],
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identifier := SceneIdentifier{
+				Sources:        tt.sources,
+				MatchStrategy:  tt.strategy,
+				TitleThreshold: tt.threshold,
+			}
+
+			got, err := identifier.scrapeScene(context.TODO(), tt.scene)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			if tt.want == nil {
+				assert.Nil(t, got)
+			} else if assert.NotNil(t, got) {
+				assert.Same(t, tt.want, got.result)
+			}
+		})
+	}
+}
+
+func Test_titleSimilarity(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want float64
+	}{
+		{"The Title", "the.title", 1},
+		{"", "", 1},
+		{"abcd", "abce", 0.75},
+		{"abcd", "", 0},
+	}
+
+	for _, tt := range tests {
+		assert.InDelta(t, tt.want, titleSimilarity(tt.a, tt.b), 0.0001, "%q, %q", tt.a, tt.b)
+	}
+}
+
+func TestValidateTitleThreshold(t *testing.T) {
+	valid := []float64{0.01, DefaultTitleThreshold, 1}
+	invalid := []float64{-0.5, 0, 1.01}
+
+	assert.Nil(t, ValidateTitleThreshold(nil))
+	for _, v := range valid {
+		v := v
+		assert.Nil(t, ValidateTitleThreshold(&v), "%v", v)
+	}
+	for _, v := range invalid {
+		v := v
+		assert.NotNil(t, ValidateTitleThreshold(&v), "%v", v)
+	}
+}
@@ -0,0 +1,73 @@
+package image
+
+import (
+	"database/sql"
+	"io"
+	"strings"
+
+	"github.com/rwcarlsen/goexif/exif"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// SetExifDetails populates the EXIF fields of the provided image from the
+// image file. The fields are cleared for images without EXIF data. Any
+// EXIF fields that cannot be parsed are ignored.
+func SetExifDetails(i *models.Image) error {
+	f, err := openSourceImage(i.Path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	setExifDetails(i, f)
+	return nil
+}
+
+func setExifDetails(i *models.Image, r io.Reader) {
+	// clear any existing values in case the file has changed
+	i.DateTaken = models.NullSQLiteTimestamp{}
+	i.CameraMake = sql.NullString{}
+	i.CameraModel = sql.NullString{}
+	i.GPSLatitude = sql.NullFloat64{}
+	i.GPSLongitude = sql.NullFloat64{}
+
+	x, err := exif.Decode(r)
+	if err != nil {
+		// no EXIF data, or the format does not support it
+		return
+	}
+
+	if t, err := x.DateTime(); err == nil {
+		i.DateTaken = models.NullSQLiteTimestamp{
+			Timestamp: t,
+			Valid:     true,
+		}
+	}
+
+	i.CameraMake = exifString(x, exif.Make)
+	i.CameraModel = exifString(x, exif.Model)
+
+	if lat, long, err := x.LatLong(); err == nil {
+		i.GPSLatitude = sql.NullFloat64{Float64: lat, Valid: true}
+		i.GPSLongitude = sql.NullFloat64{Float64: long, Valid: true}
+	}
+}
+
+func exifString(x *exif.Exif, name exif.FieldName) sql.NullString {
+	tag, err := x.Get(name)
+	if err != nil {
+		return sql.NullString{}
+	}
+
+	v, err := tag.StringVal()
+	if err != nil {
+		return sql.NullString{}
+	}
+
+	// values are often padded with nulls or spaces
+	v = strings.TrimSpace(strings.Trim(v, "\x00"))
+	return sql.NullString{
+		String: v,
+		Valid:  v != "",
+	}
+}
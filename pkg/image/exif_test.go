@@ -0,0 +1,98 @@
+package image
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	// sample JPEG with EXIF data, from the goexif package
+	exifSamplePath = filepath.Join("testdata", "exif.jpg")
+	// JPEG with only the orientation in its EXIF data
+	orientationSamplePath = filepath.Join("testdata", "exif_orientation.jpg")
+	// JPEG without EXIF data
+	noExifSamplePath = filepath.Join("testdata", "no_exif.jpg")
+)
+
+func TestSetExifDetails(t *testing.T) {
+	dateTaken := time.Date(2003, 11, 23, 18, 7, 37, 0, time.Local)
+
+	tests := []struct {
+		name      string
+		path      string
+		dateTaken models.NullSQLiteTimestamp
+		make      sql.NullString
+		model     sql.NullString
+		latitude  sql.NullFloat64
+		longitude sql.NullFloat64
+	}{
+		{
+			"exif",
+			exifSamplePath,
+			models.NullSQLiteTimestamp{Timestamp: dateTaken, Valid: true},
+			sql.NullString{String: "NIKON CORPORATION", Valid: true},
+			sql.NullString{String: "NIKON D2H", Valid: true},
+			sql.NullFloat64{Float64: 39.91555555555556, Valid: true},
+			sql.NullFloat64{Float64: 116.39083333333333, Valid: true},
+		},
+		{
+			"orientation only",
+			orientationSamplePath,
+			models.NullSQLiteTimestamp{},
+			sql.NullString{},
+			sql.NullString{},
+			sql.NullFloat64{},
+			sql.NullFloat64{},
+		},
+		{
+			"no exif",
+			noExifSamplePath,
+			models.NullSQLiteTimestamp{},
+			sql.NullString{},
+			sql.NullString{},
+			sql.NullFloat64{},
+			sql.NullFloat64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			// existing values should be cleared
+			i := &models.Image{
+				Path:        tt.path,
+				CameraMake:  sql.NullString{String: "old", Valid: true},
+				GPSLatitude: sql.NullFloat64{Float64: 1, Valid: true},
+			}
+
+			if err := SetExifDetails(i); err != nil {
+				t.Fatalf("SetExifDetails error: %v", err)
+			}
+
+			assert.Equal(tt.dateTaken.Valid, i.DateTaken.Valid)
+			if tt.dateTaken.Valid {
+				assert.True(tt.dateTaken.Timestamp.Equal(i.DateTaken.Timestamp))
+			}
+			assert.Equal(tt.make, i.CameraMake)
+			assert.Equal(tt.model, i.CameraModel)
+			assert.Equal(tt.latitude.Valid, i.GPSLatitude.Valid)
+			assert.InDelta(tt.latitude.Float64, i.GPSLatitude.Float64, 1e-6)
+			assert.Equal(tt.longitude.Valid, i.GPSLongitude.Valid)
+			assert.InDelta(tt.longitude.Float64, i.GPSLongitude.Float64, 1e-6)
+		})
+	}
+}
+
+func TestSetExifDetailsMissingFile(t *testing.T) {
+	i := &models.Image{
+		Path: filepath.Join("testdata", "missing.jpg"),
+	}
+
+	assert.NotNil(t, SetExifDetails(i))
+}
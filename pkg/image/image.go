@@ -6,9 +6,11 @@ import (
 	"fmt"
 	"image"
 	"io"
+	"mime"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -68,7 +70,7 @@ func FileExists(path string) bool {
 
 type imageReadCloser struct {
 	src io.ReadCloser
-	zrc *zip.ReadCloser
+	zrc io.Closer
 }
 
 func (i *imageReadCloser) Read(p []byte) (n int, err error) {
@@ -92,34 +94,25 @@ func openSourceImage(path string) (io.ReadCloser, error) {
 	// may need to read from a zip file
 	zipFilename, filename := file.ZipFilePath(path)
 	if zipFilename != "" {
-		r, err := zip.OpenReader(zipFilename)
+		f, r, err := openZipEntry(zipFilename, filename)
 		if err != nil {
 			return nil, err
 		}
 
 		// defer closing of zip to the calling function, unless an error
 		// is returned, in which case it should be closed immediately
-
-		// find the file matching the filename
-		for _, f := range r.File {
-			if f.Name == filename {
-				src, err := f.Open()
-				if err != nil {
-					r.Close()
-					return nil, err
-				}
-				return &imageReadCloser{
-					src: src,
-					zrc: r,
-				}, nil
-			}
+		src, err := f.Open()
+		if err != nil {
+			r.Close()
+			return nil, err
 		}
-
-		r.Close()
-		return nil, fmt.Errorf("file with name '%s' not found in zip file '%s'", filename, zipFilename)
+		return &imageReadCloser{
+			src: src,
+			zrc: r,
+		}, nil
 	}
 
-	return os.Open(filename)
+	return file.FileSystemFor(filename).Open(filename)
 }
 
 // GetFileDetails returns a pointer to an Image object with the
@@ -161,6 +154,10 @@ func SetFileDetails(i *models.Image) error {
 		Valid: true,
 	}
 
+	if err := SetExifDetails(i); err != nil {
+		logger.Warnf("error reading EXIF data from %s: %v", i.Path, err)
+	}
+
 	return nil
 }
 
@@ -182,48 +179,71 @@ func stat(path string) (os.FileInfo, error) {
 	// may need to read from a zip file
 	zipFilename, filename := file.ZipFilePath(path)
 	if zipFilename != "" {
-		r, err := zip.OpenReader(zipFilename)
+		f, r, err := openZipEntry(zipFilename, filename)
 		if err != nil {
 			return nil, err
 		}
 		defer r.Close()
 
-		// find the file matching the filename
-		for _, f := range r.File {
-			if f.Name == filename {
-				return f.FileInfo(), nil
-			}
-		}
+		return f.FileInfo(), nil
+	}
+
+	return file.FileSystemFor(filename).Stat(filename)
+}
+
+// openZipEntry opens the zip file and finds the entry with the filename.
+// The returned zip reader must be closed if no error is returned.
+func openZipEntry(zipFilename string, filename string) (*zip.File, *file.ZipReader, error) {
+	r, err := file.OpenZip(zipFilename)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		return nil, fmt.Errorf("file with name '%s' not found in zip file '%s'", filename, zipFilename)
+	f := r.Find(filename)
+	if f == nil {
+		r.Close()
+		return nil, nil, fmt.Errorf("file with name '%s' not found in zip file '%s'", filename, zipFilename)
 	}
 
-	return os.Stat(filename)
+	return f, r, nil
 }
 
 func Serve(w http.ResponseWriter, r *http.Request, path string) {
 	zipFilename, _ := file.ZipFilePath(path)
 	w.Header().Add("Cache-Control", "max-age=604800000") // 1 Week
 	if zipFilename == "" {
-		http.ServeFile(w, r, path)
+		file.Serve(w, r, path)
 	} else {
-		rc, err := openSourceImage(path)
-		if err != nil {
-			// assume not found
-			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
-			return
-		}
-		defer rc.Close()
+		serveZipEntry(w, path)
+	}
+}
 
-		data, err := io.ReadAll(rc)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-			return
-		}
+// serveZipEntry streams the image in a zip file to the response, without
+// reading the whole image into memory.
+func serveZipEntry(w http.ResponseWriter, path string) {
+	zipFilename, filename := file.ZipFilePath(path)
+	f, r, err := openZipEntry(zipFilename, filename)
+	if err != nil {
+		// assume not found
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	}
+	defer r.Close()
 
-		if k, err := w.Write(data); err != nil {
-			logger.Warnf("failure while serving image (wrote %v bytes out of %v): %v", k, len(data), err)
-		}
+	rc, err := f.Open()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer rc.Close()
+
+	if contentType := mime.TypeByExtension(filepath.Ext(filename)); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.Header().Set("Content-Length", strconv.FormatUint(f.UncompressedSize64, 10))
+
+	if k, err := io.Copy(w, rc); err != nil {
+		logger.Warnf("failure while serving image (wrote %v bytes out of %v): %v", k, f.UncompressedSize64, err)
 	}
 }
 
@@ -100,6 +100,10 @@ func (scanner *Scanner) ScanExisting(existing file.FileBased, file file.SourceFi
 			}
 		}
 
+		if scanned.ContentsChanged() {
+			scanner.RecordUpdated(retImage.ID)
+		}
+
 		scanner.PluginCache.ExecutePostHooks(scanner.Ctx, retImage.ID, plugin.ImageUpdatePost, nil, nil)
 	}
 
@@ -159,6 +163,7 @@ func (scanner *Scanner) ScanNew(f file.SourceFile) (retImage *models.Image, err
 				return nil, err
 			}
 
+			scanner.RecordUpdated(existingImage.ID)
 			scanner.PluginCache.ExecutePostHooks(scanner.Ctx, existingImage.ID, plugin.ImageUpdatePost, nil, nil)
 		}
 	} else {
@@ -185,6 +190,7 @@ func (scanner *Scanner) ScanNew(f file.SourceFile) (retImage *models.Image, err
 			return nil, err
 		}
 
+		scanner.RecordAdded(retImage.ID)
 		scanner.PluginCache.ExecutePostHooks(scanner.Ctx, retImage.ID, plugin.ImageCreatePost, nil, nil)
 	}
 
@@ -3,6 +3,8 @@ package job
 import (
 	"context"
 	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
 )
 
 // JobExec represents the implementation of a Job to be executed.
@@ -40,6 +42,8 @@ const (
 	StatusFinished Status = "FINISHED"
 	// StatusCancelled means that the job was cancelled and is now stopped.
 	StatusCancelled Status = "CANCELLED"
+	// StatusFailed means that the job stopped due to a panic.
+	StatusFailed Status = "FAILED"
 )
 
 // Job represents the status of a queued or running job.
@@ -54,10 +58,20 @@ type Job struct {
 	StartTime *time.Time
 	EndTime   *time.Time
 	AddTime   time.Time
+	// Logs contains the most recent log items logged against the job context.
+	Logs []logger.LogItem
+	// Result is the result of the job, set by the JobExec. Its type depends
+	// on the job.
+	Result interface{}
+	// Error is the reason that the job failed.
+	Error string
 
 	outerCtx   context.Context
 	exec       JobExec
 	cancelFunc context.CancelFunc
+	// concurrent is true if the job runs concurrently with other jobs,
+	// rather than being dispatched from the queue.
+	concurrent bool
 }
 
 func (j *Job) cancel() {
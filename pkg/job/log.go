@@ -0,0 +1,28 @@
+package job
+
+import (
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+// maxJobLogSize is the maximum number of log items retained for a job. Older
+// items are discarded once the limit is reached.
+const maxJobLogSize = 100
+
+// jobLogSink captures log items logged against a job's context.
+type jobLogSink struct {
+	m   *Manager
+	job *Job
+}
+
+func (s *jobLogSink) Add(item logger.LogItem) {
+	s.m.mutex.Lock()
+	defer s.m.mutex.Unlock()
+
+	// copies of the job share the backing array, so only ever append past
+	// the end and trim by reslicing
+	logs := append(s.job.Logs, item)
+	if len(logs) > maxJobLogSize {
+		logs = logs[len(logs)-maxJobLogSize:]
+	}
+	s.job.Logs = logs
+}
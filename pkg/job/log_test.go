@@ -0,0 +1,75 @@
+package job
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func waitForJob(m *Manager, id int) *Job {
+	for {
+		j := m.GetJob(id)
+		if j.Status == StatusFinished || j.Status == StatusCancelled || j.Status == StatusFailed {
+			return j
+		}
+		<-time.After(sleepTime)
+	}
+}
+
+func TestJobLogs(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+
+	exec1 := MakeJobExec(func(ctx context.Context, p *Progress) {
+		logger.Ctx(ctx).Infof("first %d", 1)
+		logger.Ctx(ctx).Error("failed")
+		// not attributed to the job
+		logger.Info("global")
+	})
+	exec2 := MakeJobExec(func(ctx context.Context, p *Progress) {
+		logger.Ctx(ctx).Warnf("second")
+	})
+
+	id1 := m.Add(context.Background(), "job 1", exec1)
+	id2 := m.Add(context.Background(), "job 2", exec2)
+
+	j1 := waitForJob(m, id1)
+	j2 := waitForJob(m, id2)
+
+	assert := assert.New(t)
+	if assert.Len(j1.Logs, 2) {
+		assert.Equal("info", j1.Logs[0].Type)
+		assert.Equal("first 1", j1.Logs[0].Message)
+		assert.Equal("error", j1.Logs[1].Type)
+		assert.Equal("failed", j1.Logs[1].Message)
+	}
+
+	if assert.Len(j2.Logs, 1) {
+		assert.Equal("warn", j2.Logs[0].Type)
+		assert.Equal("second", j2.Logs[0].Message)
+	}
+}
+
+func TestJobLogsBounded(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+
+	const count = maxJobLogSize + 10
+	exec := MakeJobExec(func(ctx context.Context, p *Progress) {
+		for i := 0; i < count; i++ {
+			logger.Ctx(ctx).Infof("%d", i)
+		}
+	})
+
+	j := waitForJob(m, m.Start(context.Background(), "job", exec))
+
+	assert := assert.New(t)
+	if assert.Len(j.Logs, maxJobLogSize) {
+		assert.Equal(fmt.Sprint(count-maxJobLogSize), j.Logs[0].Message)
+		assert.Equal(fmt.Sprint(count-1), j.Logs[maxJobLogSize-1].Message)
+	}
+}
@@ -3,12 +3,14 @@ package job
 import (
 	"context"
 	"fmt"
+	"runtime/debug"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/stashapp/stash/pkg/desktop"
+	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/utils"
 )
 
@@ -25,6 +27,8 @@ type Manager struct {
 	stop     chan struct{}
 
 	lastID int
+	// pauses is the number of Pause calls without a matching Resume
+	pauses int
 
 	subscriptions       []*ManagerSubscription
 	updateThrottleLimit time.Duration
@@ -94,15 +98,70 @@ func (m *Manager) Start(ctx context.Context, description string, e JobExec) int
 		AddTime:     t,
 		exec:        e,
 		outerCtx:    ctx,
+		concurrent:  true,
 	}
 
 	m.queue = append(m.queue, &j)
 
-	m.dispatch(&j)
+	if m.paused() {
+		// started once the manager is resumed
+		m.notifyNewJob(&j)
+	} else {
+		m.dispatch(&j)
+	}
 
 	return j.ID
 }
 
+// Pause stops the manager from starting jobs. Running jobs are not affected.
+// Jobs that are added or started while paused remain ready until Resume is
+// called. Pauses are counted, so the manager remains paused until Resume is
+// called once for each call to Pause.
+func (m *Manager) Pause() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.pauses++
+}
+
+// Resume reverses a call to Pause. The jobs that were held while the manager
+// was paused are started once every pause has been resumed.
+func (m *Manager) Resume() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.pauses == 0 {
+		return
+	}
+
+	m.pauses--
+	if m.paused() {
+		return
+	}
+
+	for _, j := range m.queue {
+		if j.concurrent && j.Status == StatusReady {
+			m.dispatch(j)
+		}
+	}
+
+	// wake the dispatcher to process the queue
+	m.notEmpty.Broadcast()
+}
+
+// IsPaused returns true if the manager is paused.
+func (m *Manager) IsPaused() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.paused()
+}
+
+func (m *Manager) paused() bool {
+	// assumes lock held
+	return m.pauses > 0
+}
+
 func (m *Manager) notifyNewJob(j *Job) {
 	// assumes lock held
 	for _, s := range m.subscriptions {
@@ -121,8 +180,12 @@ func (m *Manager) nextID() int {
 
 func (m *Manager) getReadyJob() *Job {
 	// assumes lock held
+	if m.paused() {
+		return nil
+	}
+
 	for _, j := range m.queue {
-		if j.Status == StatusReady {
+		if !j.concurrent && j.Status == StatusReady {
 			return j
 		}
 	}
@@ -165,8 +228,9 @@ func (m *Manager) dispatcher() {
 	}
 }
 
-func (m *Manager) newProgress(j *Job) *Progress {
+func (m *Manager) newProgress(ctx context.Context, j *Job) *Progress {
 	return &Progress{
+		ctx: ctx,
 		updater: &updater{
 			m:   m,
 			job: j,
@@ -183,13 +247,14 @@ func (m *Manager) dispatch(j *Job) (done chan struct{}) {
 
 	ctx, cancelFunc := context.WithCancel(utils.ValueOnlyContext(j.outerCtx))
 	j.cancelFunc = cancelFunc
+	ctx = logger.WithSink(ctx, &jobLogSink{m: m, job: j})
 
 	done = make(chan struct{})
 	go func() {
-		progress := m.newProgress(j)
-		j.exec.Execute(ctx, progress)
+		progress := m.newProgress(ctx, j)
+		err := executeJob(ctx, j.exec, progress)
 
-		m.onJobFinish(j)
+		m.onJobFinish(j, err)
 
 		close(done)
 	}()
@@ -199,13 +264,40 @@ func (m *Manager) dispatch(j *Job) (done chan struct{}) {
 	return
 }
 
-func (m *Manager) onJobFinish(job *Job) {
+// executeJob executes e, recovering from a panic so that the panic does not
+// stop the manager or the process. The panic is logged against the job with
+// its stack trace, and returned as an error. A panic recovered from one of
+// the job's tasks is also returned as an error.
+func executeJob(ctx context.Context, e JobExec, progress *Progress) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+			logger.Ctx(ctx).Errorf("job failed with %v\n%s", err, debug.Stack())
+		}
+	}()
+
+	e.Execute(ctx, progress)
+
+	// goroutines of a cancelled job may be blocked on work that is no longer
+	// consumed
+	if !IsCancelled(ctx) {
+		progress.running.Wait()
+	}
+
+	return progress.failure()
+}
+
+func (m *Manager) onJobFinish(job *Job, err error) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	if job.Status == StatusStopping {
+	switch {
+	case err != nil:
+		job.Status = StatusFailed
+		job.Error = err.Error()
+	case job.Status == StatusStopping:
 		job.Status = StatusCancelled
-	} else {
+	default:
 		job.Status = StatusFinished
 	}
 	t := time.Now()
@@ -215,6 +307,10 @@ func (m *Manager) onJobFinish(job *Job) {
 	hours := fmt.Sprintf("%+02s", strconv.FormatFloat(timeElapsed.Hours(), 'f', 0, 64))
 	minutes := fmt.Sprintf("%+02s", strconv.FormatFloat(timeElapsed.Minutes(), 'f', 0, 64))
 	seconds := fmt.Sprintf("%+02s", strconv.FormatFloat(timeElapsed.Seconds(), 'f', 0, 64))
+	if job.Status == StatusFailed {
+		desktop.SendNotification("Task Failed", "Task \""+cleanDesc+"\" failed: "+job.Error)
+		return
+	}
 	desktop.SendNotification("Task Finished", "Task \""+cleanDesc+"\" is finished in "+hours+":"+minutes+":"+seconds+".")
 }
 
@@ -355,9 +451,9 @@ func (m *Manager) Subscribe(ctx context.Context) *ManagerSubscription {
 }
 
 func (m *Manager) notifyJobUpdate(j *Job) {
-	// don't update if job is finished or cancelled - these are handled
-	// by removeJob
-	if j.Status == StatusCancelled || j.Status == StatusFinished {
+	// don't update if job is finished, cancelled or failed - these are
+	// handled by removeJob
+	if j.Status == StatusCancelled || j.Status == StatusFinished || j.Status == StatusFailed {
 		return
 	}
 
@@ -385,6 +481,14 @@ func (u *updater) notifyUpdate() {
 	u.updateTimer = nil
 }
 
+func (u *updater) setResult(result interface{}) {
+	u.m.mutex.Lock()
+	defer u.m.mutex.Unlock()
+
+	u.job.Result = result
+	u.notifyUpdate()
+}
+
 func (u *updater) updateProgress(progress float64, details []string) {
 	u.m.mutex.Lock()
 	defer u.m.mutex.Unlock()
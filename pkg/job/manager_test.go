@@ -341,3 +341,234 @@ func TestSubscribe(t *testing.T) {
 
 	cancel()
 }
+
+func TestPause(t *testing.T) {
+	m := NewManager()
+	assert := assert.New(t)
+
+	// a running job is not affected by pausing
+	exec1 := newTestExec(make(chan struct{}))
+	jobID1 := m.Add(context.Background(), "running", exec1)
+	<-exec1.started
+
+	m.Pause()
+	assert.True(m.IsPaused())
+
+	exec2 := newTestExec(nil)
+	jobID2 := m.Add(context.Background(), "queued", exec2)
+	exec3 := newTestExec(nil)
+	jobID3 := m.Start(context.Background(), "started", exec3)
+
+	// allow the running job to finish
+	close(exec1.finish)
+	time.Sleep(sleepTime)
+
+	assert.Equal(StatusFinished, m.GetJob(jobID1).Status)
+
+	// expect the other jobs not to have started
+	for _, exec := range []*testExec{exec2, exec3} {
+		select {
+		case <-exec.started:
+			t.Error("job was started while paused")
+		default:
+		}
+	}
+	assert.Equal(StatusReady, m.GetJob(jobID2).Status)
+	assert.Equal(StatusReady, m.GetJob(jobID3).Status)
+
+	m.Resume()
+	assert.False(m.IsPaused())
+
+	for _, exec := range []*testExec{exec2, exec3} {
+		select {
+		case <-exec.started:
+		case <-time.After(time.Second):
+			t.Error("job was not started after resume")
+		}
+	}
+}
+
+func TestPauseCounted(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+
+	assert := assert.New(t)
+
+	m.Pause()
+	m.Pause()
+
+	exec := newTestExec(nil)
+	m.Add(context.Background(), "queued", exec)
+
+	// the manager remains paused until every pause is resumed
+	m.Resume()
+	assert.True(m.IsPaused())
+
+	select {
+	case <-exec.started:
+		t.Error("job was started while paused")
+	case <-time.After(sleepTime):
+	}
+
+	m.Resume()
+	assert.False(m.IsPaused())
+
+	select {
+	case <-exec.started:
+	case <-time.After(time.Second):
+		t.Error("job was not started after resume")
+	}
+
+	// resuming an unpaused manager has no effect
+	m.Resume()
+	m.Pause()
+	assert.True(m.IsPaused())
+	m.Resume()
+}
+
+func TestPanickingJob(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+
+	panicking := MakeJobExec(func(ctx context.Context, p *Progress) {
+		var s []int
+		_ = s[1]
+	})
+
+	ran := false
+	next := MakeJobExec(func(ctx context.Context, p *Progress) {
+		ran = true
+	})
+
+	panicID := m.Add(context.Background(), "panicking job", panicking)
+	nextID := m.Add(context.Background(), "next job", next)
+
+	assert := assert.New(t)
+
+	j := waitForJob(m, panicID)
+	assert.Equal(StatusFailed, j.Status)
+	assert.Contains(j.Error, "index out of range")
+	assert.NotNil(j.EndTime)
+
+	// the panic and its stack trace are logged against the job
+	if assert.Len(j.Logs, 1) {
+		assert.Equal("error", j.Logs[0].Type)
+		assert.Contains(j.Logs[0].Message, "index out of range")
+		assert.Contains(j.Logs[0].Message, "TestPanickingJob")
+	}
+
+	// the next job still runs
+	j = waitForJob(m, nextID)
+	assert.Equal(StatusFinished, j.Status)
+	assert.Empty(j.Error)
+	assert.True(ran)
+}
+
+func TestPanickingConcurrentJob(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+
+	id := m.Start(context.Background(), "panicking job", MakeJobExec(func(ctx context.Context, p *Progress) {
+		panic("concurrent panic")
+	}))
+
+	j := waitForJob(m, id)
+	assert.Equal(t, StatusFailed, j.Status)
+	assert.Equal(t, "panic: concurrent panic", j.Error)
+}
+
+func TestPanickingTask(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+
+	panicking := MakeJobExec(func(ctx context.Context, p *Progress) {
+		done := make(chan struct{})
+		p.Go(func() {
+			defer close(done)
+			p.ExecuteTask("panicking task", func() {
+				panic("task panic")
+			})
+		})
+		<-done
+	})
+
+	ran := false
+	next := MakeJobExec(func(ctx context.Context, p *Progress) {
+		ran = true
+	})
+
+	panicID := m.Add(context.Background(), "panicking job", panicking)
+	nextID := m.Add(context.Background(), "next job", next)
+
+	assert := assert.New(t)
+
+	j := waitForJob(m, panicID)
+	assert.Equal(StatusFailed, j.Status)
+	assert.Equal("panic: task panic", j.Error)
+
+	// the panic and its stack trace are logged against the job
+	if assert.Len(j.Logs, 1) {
+		assert.Equal("error", j.Logs[0].Type)
+		assert.Contains(j.Logs[0].Message, "task panic")
+		assert.Contains(j.Logs[0].Message, "TestPanickingTask")
+	}
+
+	// the next job still runs
+	j = waitForJob(m, nextID)
+	assert.Equal(StatusFinished, j.Status)
+	assert.True(ran)
+}
+
+func TestPanickingGoroutine(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+
+	id := m.Add(context.Background(), "panicking job", MakeJobExec(func(ctx context.Context, p *Progress) {
+		done := make(chan struct{})
+		p.Go(func() {
+			defer close(done)
+			panic("goroutine panic")
+		})
+		<-done
+	}))
+
+	j := waitForJob(m, id)
+	assert.Equal(t, StatusFailed, j.Status)
+	assert.Equal(t, "panic: goroutine panic", j.Error)
+}
+
+func TestPanickingProducer(t *testing.T) {
+	m := NewManager()
+	defer m.Stop()
+
+	// a goroutine producing work for the job panics part way through
+	panicking := MakeJobExec(func(ctx context.Context, p *Progress) {
+		queue := make(chan int)
+		p.Go(func() {
+			defer close(queue)
+			queue <- 1
+			panic("producer panic")
+		})
+
+		for range queue {
+			p.Go(func() {})
+		}
+	})
+
+	ran := false
+	next := MakeJobExec(func(ctx context.Context, p *Progress) {
+		ran = true
+	})
+
+	panicID := m.Add(context.Background(), "panicking job", panicking)
+	nextID := m.Add(context.Background(), "next job", next)
+
+	j := waitForJob(m, panicID)
+	assert.Equal(t, StatusFailed, j.Status)
+	assert.Equal(t, "panic: producer panic", j.Error)
+
+	// the next job still runs
+	j = waitForJob(m, nextID)
+	assert.Equal(t, StatusFinished, j.Status)
+	assert.True(t, ran)
+}
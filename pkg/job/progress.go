@@ -1,6 +1,13 @@
 package job
 
-import "sync"
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"github.com/stashapp/stash/pkg/logger"
+)
 
 // ProgressIndefinite is the special percent value to indicate that the
 // percent progress is not known.
@@ -13,7 +20,13 @@ type Progress struct {
 	total        int
 	percent      float64
 	currentTasks []*task
+	// err is the error of the first task that panicked
+	err error
+
+	// running are the goroutines started by Go
+	running sync.WaitGroup
 
+	ctx     context.Context
 	mutex   sync.Mutex
 	updater *updater
 }
@@ -150,5 +163,50 @@ func (p *Progress) ExecuteTask(description string, fn func()) {
 
 	p.addTask(t)
 	defer p.removeTask(t)
+	defer p.recoverTask()
 	fn()
 }
+
+// Go executes fn in a new goroutine. A panic in fn is recovered in the same
+// way as in ExecuteTask. Unless the job is cancelled, the job does not finish
+// until fn has returned.
+func (p *Progress) Go(fn func()) {
+	p.running.Add(1)
+	go func() {
+		defer p.running.Done()
+		defer p.recoverTask()
+		fn()
+	}()
+}
+
+// recoverTask recovers from a panic in a task, so that the panic does not
+// stop the process. The panic is logged against the job with its stack
+// trace, and the job fails once it has finished.
+func (p *Progress) recoverTask() {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	err := fmt.Errorf("panic: %v", r)
+	logger.Ctx(p.ctx).Errorf("task failed with %v\n%s", err, debug.Stack())
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.err == nil {
+		p.err = err
+	}
+}
+
+func (p *Progress) failure() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.err
+}
+
+// SetResult sets the result of the job.
+func (p *Progress) SetResult(result interface{}) {
+	p.updater.setResult(result)
+}
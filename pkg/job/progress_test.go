@@ -96,6 +96,16 @@ func TestProgressSetPercent(t *testing.T) {
 	assert.Equal(float64(1), j.Progress)
 }
 
+func TestProgressSetResult(t *testing.T) {
+	m := NewManager()
+	j := &Job{}
+
+	p := createProgress(m, j)
+
+	p.SetResult("result")
+	assert.Equal(t, "result", j.Result)
+}
+
 func TestProgressIncrement(t *testing.T) {
 	m := NewManager()
 	j := &Job{}
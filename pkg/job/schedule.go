@@ -0,0 +1,203 @@
+package job
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxScheduleSearch is the maximum time after which the next trigger of a
+// schedule is searched for. Schedules such as February 30 never trigger.
+const maxScheduleSearch = 5 * 366 * 24 * time.Hour
+
+type scheduleField struct {
+	name     string
+	min, max int
+}
+
+var scheduleFields = []scheduleField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// Schedule is a cron schedule, with fields for the minute, hour, day of
+// month, month and day of week. Triggers are computed in the wall clock time
+// of the location provided to Next.
+//
+// Each field is either *, or a comma-separated list of values or ranges,
+// optionally followed by a step. For example, "30 2 * * 1-5" triggers at
+// 02:30 on weekdays, and "*/15 * * * *" triggers every 15 minutes.
+type Schedule struct {
+	expr string
+
+	minute     []bool
+	hour       []bool
+	dayOfMonth []bool
+	month      []bool
+	dayOfWeek  []bool
+
+	// cron triggers when either day field matches if both are restricted
+	dayOfMonthStar bool
+	dayOfWeekStar  bool
+}
+
+// ParseSchedule parses a cron schedule expression.
+func ParseSchedule(expr string) (*Schedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(scheduleFields) {
+		return nil, fmt.Errorf("invalid schedule %q: expected %d fields", expr, len(scheduleFields))
+	}
+
+	values := make([][]bool, len(fields))
+	for i, f := range fields {
+		v, err := parseScheduleField(f, scheduleFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", expr, err)
+		}
+		values[i] = v
+	}
+
+	return &Schedule{
+		expr:           expr,
+		minute:         values[0],
+		hour:           values[1],
+		dayOfMonth:     values[2],
+		month:          values[3],
+		dayOfWeek:      values[4],
+		dayOfMonthStar: fields[2] == "*",
+		dayOfWeekStar:  fields[4] == "*",
+	}, nil
+}
+
+func parseScheduleField(s string, field scheduleField) ([]bool, error) {
+	ret := make([]bool, field.max+1)
+
+	for _, part := range strings.Split(s, ",") {
+		rng, stepStr, hasStep := cutString(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepStr)
+			if err != nil || step <= 0 {
+				return nil, fmt.Errorf("invalid step %q in %s", stepStr, field.name)
+			}
+		}
+
+		start, end := field.min, field.max
+		if rng != "*" {
+			startStr, endStr, isRange := cutString(rng, "-")
+
+			var err error
+			start, err = strconv.Atoi(startStr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q in %s", startStr, field.name)
+			}
+
+			end = start
+			if isRange {
+				end, err = strconv.Atoi(endStr)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value %q in %s", endStr, field.name)
+				}
+			} else if hasStep {
+				// 5/10 is equivalent to 5-max/10
+				end = field.max
+			}
+		}
+
+		if start < field.min || end > field.max || start > end {
+			return nil, fmt.Errorf("%s value %q out of range %d-%d", field.name, rng, field.min, field.max)
+		}
+
+		for v := start; v <= end; v += step {
+			ret[v] = true
+		}
+	}
+
+	return ret, nil
+}
+
+func cutString(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dayOfMonth[t.Day()]
+	dow := s.dayOfWeek[int(t.Weekday())]
+
+	if !s.dayOfMonthStar && !s.dayOfWeekStar {
+		return dom || dow
+	}
+
+	return dom && dow
+}
+
+// Next returns the first trigger of the schedule after the provided time,
+// using the wall clock time of loc. Triggers at wall clock times skipped by
+// a daylight saving transition do not occur, and triggers at wall clock
+// times that are repeated occur only once. A zero time is returned if the
+// schedule never triggers.
+func (s *Schedule) Next(after time.Time, loc *time.Location) time.Time {
+	// offsets are whole minutes, so truncating the absolute time truncates
+	// the wall clock time
+	t := after.In(loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.Add(maxScheduleSearch)
+
+	for t.Before(limit) {
+		if !s.month[int(t.Month())] {
+			t = nextMidnight(t, time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc))
+			continue
+		}
+
+		if !s.dayMatches(t) {
+			t = nextMidnight(t, time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc))
+			continue
+		}
+
+		// step through the hours and minutes in absolute time, so that
+		// skipped and repeated wall clock times are handled
+		if !s.hour[t.Hour()] {
+			t = t.Add(time.Duration(60-t.Minute()) * time.Minute)
+			continue
+		}
+
+		if !s.minute[t.Minute()] {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		// the wall clock time has already occurred if the clocks were
+		// turned back during the last hour
+		if earlier := t.Add(-time.Hour); earlier.Hour() == t.Hour() && earlier.Minute() == t.Minute() {
+			t = t.Add(time.Minute)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// nextMidnight returns midnight if it is after t. Otherwise midnight does
+// not exist due to a daylight saving transition, and time.Date returned an
+// earlier time, so the start of the next hour after t is returned.
+func nextMidnight(t time.Time, midnight time.Time) time.Time {
+	if midnight.After(t) {
+		return midnight
+	}
+
+	return t.Add(time.Duration(60-t.Minute()) * time.Minute)
+}
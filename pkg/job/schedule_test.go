@@ -0,0 +1,187 @@
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func loadLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("time zone %s not available: %v", name, err)
+	}
+	return loc
+}
+
+func TestParseSchedule(t *testing.T) {
+	valid := []string{
+		"* * * * *",
+		"30 2 * * 1-5",
+		"*/15 * * * *",
+		"0 0,12 1 */2 *",
+		"5/10 * * * 0",
+	}
+	for _, expr := range valid {
+		if _, err := ParseSchedule(expr); err != nil {
+			t.Errorf("ParseSchedule(%q) error = %v", expr, err)
+		}
+	}
+
+	invalid := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 7",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+	}
+	for _, expr := range invalid {
+		if _, err := ParseSchedule(expr); err == nil {
+			t.Errorf("ParseSchedule(%q) expected error", expr)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	newYork := loadLocation(t, "America/New_York")
+	tokyo := loadLocation(t, "Asia/Tokyo")
+
+	date := func(loc *time.Location, year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name  string
+		expr  string
+		loc   *time.Location
+		after time.Time
+		want  time.Time
+	}{
+		{
+			"every minute",
+			"* * * * *",
+			time.UTC,
+			time.Date(2021, 1, 1, 10, 0, 30, 0, time.UTC),
+			date(time.UTC, 2021, 1, 1, 10, 1),
+		},
+		{
+			"step",
+			"*/15 * * * *",
+			time.UTC,
+			date(time.UTC, 2021, 1, 1, 10, 15),
+			date(time.UTC, 2021, 1, 1, 10, 30),
+		},
+		{
+			"next day",
+			"30 2 * * *",
+			time.UTC,
+			date(time.UTC, 2021, 1, 1, 3, 0),
+			date(time.UTC, 2021, 1, 2, 2, 30),
+		},
+		{
+			"weekday",
+			"0 9 * * 1-5",
+			time.UTC,
+			// Friday
+			date(time.UTC, 2021, 1, 1, 10, 0),
+			// Monday
+			date(time.UTC, 2021, 1, 4, 9, 0),
+		},
+		{
+			"day of month or week",
+			"0 0 15 * 0",
+			time.UTC,
+			// Friday
+			date(time.UTC, 2021, 1, 1, 10, 0),
+			// Sunday
+			date(time.UTC, 2021, 1, 3, 0, 0),
+		},
+		{
+			"leap day",
+			"0 0 29 2 *",
+			time.UTC,
+			date(time.UTC, 2021, 1, 1, 0, 0),
+			date(time.UTC, 2024, 2, 29, 0, 0),
+		},
+		{
+			"configured zone",
+			"0 9 * * *",
+			tokyo,
+			date(time.UTC, 2021, 1, 1, 0, 0),
+			// 09:00 in Tokyo is 00:00 UTC
+			date(time.UTC, 2021, 1, 2, 0, 0),
+		},
+		{
+			"before spring forward",
+			"0 9 * * *",
+			newYork,
+			date(newYork, 2021, 3, 13, 10, 0),
+			// 09:00 EDT is 13:00 UTC
+			date(time.UTC, 2021, 3, 14, 13, 0),
+		},
+		{
+			"after fall back",
+			"0 9 * * *",
+			newYork,
+			date(newYork, 2021, 11, 6, 10, 0),
+			// 09:00 EST is 14:00 UTC
+			date(time.UTC, 2021, 11, 7, 14, 0),
+		},
+		{
+			"skipped by spring forward",
+			"30 2 * * *",
+			newYork,
+			date(newYork, 2021, 3, 13, 3, 0),
+			// 02:30 does not occur on 14 March
+			date(newYork, 2021, 3, 15, 2, 30),
+		},
+		{
+			"repeated by fall back",
+			"30 1 * * *",
+			newYork,
+			// 01:30 EDT
+			date(time.UTC, 2021, 11, 7, 5, 30),
+			// 01:30 EST is not a second trigger
+			date(newYork, 2021, 11, 8, 1, 30),
+		},
+		{
+			"hourly across fall back",
+			"0 * * * *",
+			newYork,
+			// 01:00 EDT
+			date(time.UTC, 2021, 11, 7, 5, 0),
+			// 02:00 EST
+			date(time.UTC, 2021, 11, 7, 7, 0),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := ParseSchedule(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseSchedule(%q) error = %v", tt.expr, err)
+			}
+
+			got := s.Next(tt.after, tt.loc)
+			assert.True(t, tt.want.Equal(got), "Next() = %v, want %v", got, tt.want)
+			assert.Equal(t, tt.loc, got.Location())
+		})
+	}
+}
+
+func TestScheduleNextNever(t *testing.T) {
+	s, err := ParseSchedule("0 0 30 2 *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, s.Next(time.Now(), time.UTC).IsZero())
+}
@@ -0,0 +1,177 @@
+package job
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+// Scheduler calls functions at the triggers of their schedules. Triggers
+// are computed in the wall clock time of the location of the scheduler.
+type Scheduler struct {
+	mutex    sync.Mutex
+	location *time.Location
+	entries  map[string]*scheduleEntry
+	stopped  bool
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+type scheduleEntry struct {
+	schedule *Schedule
+	run      func()
+	next     time.Time
+	timer    *time.Timer
+}
+
+// NewScheduler returns a scheduler computing triggers in loc.
+func NewScheduler(loc *time.Location) *Scheduler {
+	return &Scheduler{
+		location: loc,
+		entries:  make(map[string]*scheduleEntry),
+		now:      time.Now,
+	}
+}
+
+// Location returns the location in which triggers are computed.
+func (s *Scheduler) Location() *time.Location {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.location
+}
+
+// SetLocation sets the location in which triggers are computed, and
+// recomputes the next trigger of each schedule.
+func (s *Scheduler) SetLocation(loc *time.Location) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.location.String() == loc.String() {
+		return
+	}
+
+	s.location = loc
+	for name, e := range s.entries {
+		e.stop()
+		s.schedule(name, e, s.now())
+	}
+}
+
+func (e *scheduleEntry) stop() {
+	if e.timer != nil {
+		e.timer.Stop()
+	}
+}
+
+// Set calls run at the triggers of schedule, replacing the schedule with
+// the same name. run is called in a new goroutine.
+func (s *Scheduler) Set(name string, schedule *Schedule, run func()) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.stopped {
+		return
+	}
+
+	s.remove(name)
+
+	e := &scheduleEntry{
+		schedule: schedule,
+		run:      run,
+	}
+	s.entries[name] = e
+	s.schedule(name, e, s.now())
+}
+
+// Remove removes the schedule with the provided name.
+func (s *Scheduler) Remove(name string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.remove(name)
+}
+
+func (s *Scheduler) remove(name string) {
+	if e := s.entries[name]; e != nil {
+		e.stop()
+		delete(s.entries, name)
+	}
+}
+
+// Names returns the sorted names of the schedules.
+func (s *Scheduler) Names() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ret := make([]string, 0, len(s.entries))
+	for name := range s.entries {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
+// Next returns the next trigger of the schedule with the provided name, or
+// a zero time if the schedule does not exist or never triggers.
+func (s *Scheduler) Next(name string) time.Time {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if e := s.entries[name]; e != nil {
+		return e.next
+	}
+
+	return time.Time{}
+}
+
+// Stop removes all schedules. Schedules cannot be added once the scheduler
+// is stopped.
+func (s *Scheduler) Stop() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for name := range s.entries {
+		s.remove(name)
+	}
+	s.stopped = true
+}
+
+// schedule starts the timer of the next trigger after the provided time.
+// The mutex must be held.
+func (s *Scheduler) schedule(name string, e *scheduleEntry, after time.Time) {
+	e.next = e.schedule.Next(after, s.location)
+	if e.next.IsZero() {
+		logger.Warnf("schedule %s (%s) never triggers", name, e.schedule)
+		e.timer = nil
+		return
+	}
+
+	next := e.next
+	e.timer = time.AfterFunc(next.Sub(s.now()), func() {
+		s.trigger(name, e, next)
+	})
+}
+
+func (s *Scheduler) trigger(name string, e *scheduleEntry, triggered time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// the schedule was replaced or rescheduled
+	if s.entries[name] != e || !e.next.Equal(triggered) {
+		return
+	}
+
+	go e.run()
+
+	// schedule after the trigger time, so that a timer firing early does
+	// not trigger the schedule twice
+	after := s.now()
+	if after.Before(triggered) {
+		after = triggered
+	}
+	s.schedule(name, e, after)
+}
@@ -0,0 +1,78 @@
+package job
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchedulerSetLocation(t *testing.T) {
+	newYork := loadLocation(t, "America/New_York")
+
+	s, err := ParseSchedule("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scheduler := NewScheduler(time.UTC)
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	scheduler.now = func() time.Time { return now }
+	defer scheduler.Stop()
+
+	scheduler.Set("test", s, func() {})
+	assert.True(t, scheduler.Next("test").Equal(time.Date(2021, 1, 1, 9, 0, 0, 0, time.UTC)))
+
+	// 09:00 EST is 14:00 UTC
+	scheduler.SetLocation(newYork)
+	assert.True(t, scheduler.Next("test").Equal(time.Date(2021, 1, 1, 14, 0, 0, 0, time.UTC)))
+
+	scheduler.Remove("test")
+	assert.True(t, scheduler.Next("test").IsZero())
+}
+
+func TestSchedulerTrigger(t *testing.T) {
+	s, err := ParseSchedule("* * * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// pretend that the next minute is about to start, so that the timer
+	// fires immediately
+	start := time.Now()
+	offset := start.Truncate(time.Minute).Add(time.Minute - 10*time.Millisecond).Sub(start)
+	scheduler := NewScheduler(time.UTC)
+	scheduler.now = func() time.Time { return time.Now().Add(offset) }
+	defer scheduler.Stop()
+
+	triggered := make(chan struct{}, 1)
+	scheduler.Set("test", s, func() {
+		select {
+		case triggered <- struct{}{}:
+		default:
+		}
+	})
+
+	select {
+	case <-triggered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("schedule was not triggered")
+	}
+}
+
+func TestSchedulerNames(t *testing.T) {
+	s, err := ParseSchedule("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scheduler := NewScheduler(time.UTC)
+	defer scheduler.Stop()
+
+	scheduler.Set("b", s, func() {})
+	scheduler.Set("a", s, func() {})
+	assert.Equal(t, []string{"a", "b"}, scheduler.Names())
+
+	scheduler.Remove("a")
+	assert.Equal(t, []string{"b"}, scheduler.Names())
+}
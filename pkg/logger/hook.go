@@ -23,3 +23,17 @@ func (hook *fileLogHook) Fire(entry *logrus.Entry) error {
 func (hook *fileLogHook) Levels() []logrus.Level {
 	return logrus.AllLevels
 }
+
+// locationHook converts the time of entries to the configured location, so
+// that timestamps are formatted in the configured time zone. It must be
+// added before hooks that format entries.
+type locationHook struct{}
+
+func (hook *locationHook) Fire(entry *logrus.Entry) error {
+	entry.Time = entry.Time.In(getLocation())
+	return nil
+}
+
+func (hook *locationHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -24,6 +25,20 @@ var logSubs []chan []LogItem
 var waiting = false
 var lastBroadcast = time.Now()
 var logBuffer []LogItem
+var location atomic.Value
+
+// SetLocation sets the location in which log timestamps are formatted.
+func SetLocation(loc *time.Location) {
+	location.Store(loc)
+}
+
+func getLocation() *time.Location {
+	if loc, ok := location.Load().(*time.Location); ok {
+		return loc
+	}
+
+	return time.Local
+}
 
 // Init initialises the logger based on a logging configuration
 func Init(logFile string, logOut bool, logLevel string) {
@@ -34,6 +49,7 @@ func Init(logFile string, logOut bool, logLevel string) {
 	customFormatter.FullTimestamp = true
 	logger.SetOutput(os.Stderr)
 	logger.SetFormatter(customFormatter)
+	logger.AddHook(&locationHook{})
 
 	// #1837 - trigger the console to use color-mode since it won't be
 	// otherwise triggered until the first log entry
@@ -97,7 +113,7 @@ func logLevelFromString(level string) logrus.Level {
 
 func addLogItem(l *LogItem) {
 	mutex.Lock()
-	l.Time = time.Now()
+	l.Time = time.Now().In(getLocation())
 	LogCache = append([]LogItem{*l}, LogCache...)
 	if len(LogCache) > 30 {
 		LogCache = LogCache[:len(LogCache)-1]
@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Sink receives log items that are logged against a context. It is used to
+// attribute log output to the operation that owns the context, such as a job.
+type Sink interface {
+	Add(item LogItem)
+}
+
+type sinkKey struct{}
+
+// WithSink returns a copy of ctx which forwards log items logged via Ctx to
+// the provided sink, in addition to the global log.
+func WithSink(ctx context.Context, sink Sink) context.Context {
+	return context.WithValue(ctx, sinkKey{}, sink)
+}
+
+// SinkFromContext returns the sink of ctx, or nil if it has none.
+func SinkFromContext(ctx context.Context) Sink {
+	if ctx == nil {
+		return nil
+	}
+
+	s, _ := ctx.Value(sinkKey{}).(Sink)
+	return s
+}
+
+// ContextLogger logs to the global log and to the sink of the context it
+// was created from, if any.
+type ContextLogger struct {
+	sink Sink
+}
+
+// Ctx returns a ContextLogger for the provided context. Log items are only
+// sent to the context sink if their level is enabled.
+func Ctx(ctx context.Context) *ContextLogger {
+	return &ContextLogger{
+		sink: SinkFromContext(ctx),
+	}
+}
+
+func (l *ContextLogger) add(level logrus.Level, t string, message string) {
+	if l.sink == nil || !logger.IsLevelEnabled(level) {
+		return
+	}
+
+	l.sink.Add(LogItem{
+		Time:    time.Now().In(getLocation()),
+		Type:    t,
+		Message: message,
+	})
+}
+
+func (l *ContextLogger) Progressf(format string, args ...interface{}) {
+	Progressf(format, args...)
+	l.add(logrus.InfoLevel, "progress", fmt.Sprintf(format, args...))
+}
+
+func (l *ContextLogger) Trace(args ...interface{}) {
+	Trace(args...)
+	l.add(logrus.TraceLevel, "trace", fmt.Sprint(args...))
+}
+
+func (l *ContextLogger) Tracef(format string, args ...interface{}) {
+	Tracef(format, args...)
+	l.add(logrus.TraceLevel, "trace", fmt.Sprintf(format, args...))
+}
+
+func (l *ContextLogger) Debug(args ...interface{}) {
+	Debug(args...)
+	l.add(logrus.DebugLevel, "debug", fmt.Sprint(args...))
+}
+
+func (l *ContextLogger) Debugf(format string, args ...interface{}) {
+	Debugf(format, args...)
+	l.add(logrus.DebugLevel, "debug", fmt.Sprintf(format, args...))
+}
+
+func (l *ContextLogger) Info(args ...interface{}) {
+	Info(args...)
+	l.add(logrus.InfoLevel, "info", fmt.Sprint(args...))
+}
+
+func (l *ContextLogger) Infof(format string, args ...interface{}) {
+	Infof(format, args...)
+	l.add(logrus.InfoLevel, "info", fmt.Sprintf(format, args...))
+}
+
+func (l *ContextLogger) Warn(args ...interface{}) {
+	Warn(args...)
+	l.add(logrus.WarnLevel, "warn", fmt.Sprint(args...))
+}
+
+func (l *ContextLogger) Warnf(format string, args ...interface{}) {
+	Warnf(format, args...)
+	l.add(logrus.WarnLevel, "warn", fmt.Sprintf(format, args...))
+}
+
+func (l *ContextLogger) Error(args ...interface{}) {
+	Error(args...)
+	l.add(logrus.ErrorLevel, "error", fmt.Sprint(args...))
+}
+
+func (l *ContextLogger) Errorf(format string, args ...interface{}) {
+	Errorf(format, args...)
+	l.add(logrus.ErrorLevel, "error", fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,61 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// autoMigrator migrates the database on startup, if it needs migration and
+// automatic migration is enabled.
+type autoMigrator struct {
+	enabled bool
+
+	needsMigration func() bool
+	// version and appVersion return the schema versions of the database and
+	// of the application
+	version    func() uint
+	appVersion func() uint
+	backupPath func() string
+	migrate    func(ctx context.Context, input models.MigrateInput) error
+}
+
+func (s *singleton) autoMigrator() autoMigrator {
+	return autoMigrator{
+		enabled:        s.Config.GetAutoMigrate(),
+		needsMigration: database.NeedsMigration,
+		version:        database.Version,
+		appVersion:     database.AppSchemaVersion,
+		backupPath:     database.DatabaseBackupPath,
+		migrate:        s.Migrate,
+	}
+}
+
+// run migrates the database if needed, keeping a backup of the database
+// before migration. Returns true if the database was migrated. The database
+// is restored from the backup if the migration fails.
+func (m autoMigrator) run(ctx context.Context) (bool, error) {
+	// a new database is created with the latest schema
+	if m.version() == 0 || !m.needsMigration() {
+		return false, nil
+	}
+
+	if !m.enabled {
+		logger.Infof("Database schema version %d must be migrated to version %d. Enable auto_migrate to migrate on startup.", m.version(), m.appVersion())
+		return false, nil
+	}
+
+	from := m.version()
+	backupPath := m.backupPath()
+	logger.Infof("Automatically migrating database from schema version %d to %d", from, m.appVersion())
+
+	if err := m.migrate(ctx, models.MigrateInput{BackupPath: backupPath}); err != nil {
+		return false, fmt.Errorf("automatic migration from schema version %d failed: %w", from, err)
+	}
+
+	logger.Infof("Migrated database from schema version %d to %d. The previous database was backed up to %s", from, m.appVersion(), backupPath)
+	return true, nil
+}
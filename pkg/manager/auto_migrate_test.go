@@ -0,0 +1,89 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type testMigration struct {
+	version   uint
+	migrateTo uint
+	err       error
+	inputs    []models.MigrateInput
+}
+
+func (m *testMigration) migrator(enabled bool) autoMigrator {
+	return autoMigrator{
+		enabled: enabled,
+		needsMigration: func() bool {
+			return m.version != m.migrateTo
+		},
+		version: func() uint {
+			return m.version
+		},
+		appVersion: func() uint {
+			return m.migrateTo
+		},
+		backupPath: func() string {
+			return "stash-go.sqlite.backup"
+		},
+		migrate: func(ctx context.Context, input models.MigrateInput) error {
+			m.inputs = append(m.inputs, input)
+			if m.err != nil {
+				return m.err
+			}
+			m.version = m.migrateTo
+			return nil
+		},
+	}
+}
+
+func TestAutoMigrateSchemaBehind(t *testing.T) {
+	m := &testMigration{version: 44, migrateTo: 45}
+
+	migrated, err := m.migrator(true).run(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, migrated)
+	assert.Equal(t, uint(45), m.version)
+
+	// the backup is kept
+	assert.Equal(t, []models.MigrateInput{{BackupPath: "stash-go.sqlite.backup"}}, m.inputs)
+}
+
+func TestAutoMigrateNoOp(t *testing.T) {
+	tests := []struct {
+		name    string
+		enabled bool
+		version uint
+	}{
+		{"current", true, 45},
+		{"new database", true, 0},
+		{"disabled", false, 44},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &testMigration{version: tt.version, migrateTo: 45}
+
+			migrated, err := m.migrator(tt.enabled).run(context.Background())
+			assert.Nil(t, err)
+			assert.False(t, migrated)
+			assert.Empty(t, m.inputs)
+			assert.Equal(t, tt.version, m.version)
+		})
+	}
+}
+
+func TestAutoMigrateFailure(t *testing.T) {
+	migrateErr := errors.New("migration failed")
+	m := &testMigration{version: 44, migrateTo: 45, err: migrateErr}
+
+	migrated, err := m.migrator(true).run(context.Background())
+	assert.False(t, migrated)
+	assert.ErrorIs(t, err, migrateErr)
+	assert.Len(t, m.inputs, 1)
+}
@@ -0,0 +1,123 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// autoTagCheckpointInterval is the number of processed files between saved
+// checkpoints.
+const autoTagCheckpointInterval = 100
+
+// autoTagFileTypes are the types of files auto-tagged, in the order they are
+// tagged.
+var autoTagFileTypes = [...]string{"scenes", "images", "galleries"}
+
+const (
+	autoTagScenes = iota
+	autoTagImages
+	autoTagGalleries
+)
+
+// autoTagCheckpointKey returns the key used to store the checkpoint of an
+// auto-tag of the provided paths. Checkpoints are only resumed by an
+// auto-tag of the same paths with the same types of objects.
+func autoTagCheckpointKey(paths []string, performers, studios, tags bool) string {
+	var objects []string
+	if performers {
+		objects = append(objects, "performers")
+	}
+	if studios {
+		objects = append(objects, "studios")
+	}
+	if tags {
+		objects = append(objects, "tags")
+	}
+
+	return "autotag:" + strings.Join(objects, ",") + "\n" + strings.Join(paths, "\n")
+}
+
+// autoTagCheckpoint is a position in an auto-tag of files. Files are tagged
+// by type, then in order of id.
+type autoTagCheckpoint struct {
+	fileType int
+	lastID   int
+}
+
+func (c autoTagCheckpoint) String() string {
+	return autoTagFileTypes[c.fileType] + ":" + strconv.Itoa(c.lastID)
+}
+
+func parseAutoTagCheckpoint(s string) (*autoTagCheckpoint, error) {
+	i := strings.LastIndex(s, ":")
+	if i == -1 {
+		return nil, fmt.Errorf("invalid auto-tag checkpoint %q", s)
+	}
+
+	id, err := strconv.Atoi(s[i+1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid auto-tag checkpoint %q: %w", s, err)
+	}
+
+	for fileType, name := range autoTagFileTypes {
+		if name == s[:i] {
+			return &autoTagCheckpoint{fileType: fileType, lastID: id}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("invalid auto-tag checkpoint %q", s)
+}
+
+// includes returns true if the file with the provided type and id was
+// processed before the checkpoint. Returns false if c is nil.
+func (c *autoTagCheckpoint) includes(fileType int, id int) bool {
+	if c == nil {
+		return false
+	}
+
+	return fileType < c.fileType || (fileType == c.fileType && id <= c.lastID)
+}
+
+func (t *autoTagFilesTask) getCheckpoint() *autoTagCheckpoint {
+	var ret *autoTagCheckpoint
+	if err := t.txnManager.WithReadTxn(t.ctx, func(r models.ReaderRepository) error {
+		checkpoint, err := r.ScanCheckpoint().FindByPaths(t.checkpointKey)
+		if err != nil || checkpoint == nil {
+			return err
+		}
+
+		ret, err = parseAutoTagCheckpoint(checkpoint.LastPath)
+		return err
+	}); err != nil {
+		logger.Warnf("error reading auto-tag checkpoint: %v", err)
+	}
+
+	return ret
+}
+
+func (t *autoTagFilesTask) saveCheckpoint(c autoTagCheckpoint) {
+	if err := t.txnManager.WithTxn(context.TODO(), func(r models.Repository) error {
+		_, err := r.ScanCheckpoint().Save(models.ScanCheckpoint{
+			Paths:     t.checkpointKey,
+			LastPath:  c.String(),
+			UpdatedAt: models.SQLiteTimestamp{Timestamp: time.Now()},
+		})
+		return err
+	}); err != nil {
+		logger.Warnf("error saving auto-tag checkpoint: %v", err)
+	}
+}
+
+func (t *autoTagFilesTask) clearCheckpoint() {
+	if err := t.txnManager.WithTxn(context.TODO(), func(r models.Repository) error {
+		return r.ScanCheckpoint().DestroyByPaths(t.checkpointKey)
+	}); err != nil {
+		logger.Warnf("error clearing auto-tag checkpoint: %v", err)
+	}
+}
@@ -1,18 +1,18 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"strings"
+	"time"
 
 	"sync"
 	// "github.com/sasha-s/go-deadlock" // if you have deadlock issues
 
-	"golang.org/x/crypto/bcrypt"
-
 	"github.com/spf13/viper"
 
 	"github.com/stashapp/stash/pkg/logger"
@@ -36,20 +36,118 @@ const (
 
 	DefaultMaxSessionAge = 60 * 60 * 1 // 1 hours
 
+	// LoginDelay is the minimum duration in milliseconds of a failed
+	// authentication. Slows down password guessing, and hides whether the
+	// username was valid.
+	LoginDelay        = "login_delay"
+	loginDelayDefault = 1000
+
+	// LoginDelaySuccess applies the login delay to successful logins as
+	// well, so that the duration of a login does not reveal the result.
+	LoginDelaySuccess = "login_delay_success"
+
+	// SessionClockSkew is the number of seconds that sessions are accepted
+	// past their maximum age, to tolerate clock adjustments.
+	SessionClockSkew        = "session_clock_skew"
+	sessionClockSkewDefault = 60
+
+	// PasswordHashAlgorithm is the algorithm used to hash passwords. One of
+	// "argon2id" or "bcrypt". Passwords hashed with another algorithm, or
+	// with a lower cost, are hashed again when the user logs in.
+	PasswordHashAlgorithm        = "password_hash.algorithm"
+	passwordHashAlgorithmDefault = "argon2id"
+
+	// PasswordHashBcryptCost is the cost of bcrypt password hashes.
+	PasswordHashBcryptCost        = "password_hash.bcrypt_cost"
+	passwordHashBcryptCostDefault = 12
+
+	// PasswordHashArgon2Memory is the memory in KiB used by argon2id
+	// password hashes.
+	PasswordHashArgon2Memory        = "password_hash.argon2_memory"
+	passwordHashArgon2MemoryDefault = 64 * 1024
+
+	// PasswordHashArgon2Iterations is the number of passes of argon2id
+	// password hashes.
+	PasswordHashArgon2Iterations        = "password_hash.argon2_iterations"
+	passwordHashArgon2IterationsDefault = 1
+
+	// TrustedHeaderAuth is the name of a request header that contains the
+	// user authenticated by a reverse proxy. The header is only honoured
+	// for requests from TrustedProxies. Disabled if empty.
+	TrustedHeaderAuth = "trusted_header_auth"
+	// TrustedProxies are the IP addresses or CIDR ranges of the reverse
+	// proxies that are trusted to set the TrustedHeaderAuth header.
+	TrustedProxies = "trusted_proxies"
+
+	// TOTPEnabled requires a TOTP code or recovery code in addition to the
+	// password when logging in.
+	TOTPEnabled = "totp.enabled"
+	// TOTPSecret is the base32 encoded TOTP secret.
+	TOTPSecret = "totp.secret"
+	// TOTPRecoveryCodes are the hashes of the unused recovery codes.
+	TOTPRecoveryCodes = "totp.recovery_codes"
+	// TOTPLastTimeStep is the time-step of the last accepted TOTP code.
+	// Codes at or before it are rejected, so that codes cannot be replayed.
+	TOTPLastTimeStep = "totp.last_time_step"
+
 	Database = "database"
 
+	// VerifyMigration is the config key used to verify the database after a
+	// migration. The database is restored from the pre-migration backup if
+	// verification fails.
+	VerifyMigration = "verify_migration"
+
+	// SkipSetup is the config key used to treat a system without a config
+	// file as an existing system, so that the setup wizard is never shown
+	// when the configuration is provided by the environment. Stash fails to
+	// start if the mandatory settings are missing.
+	SkipSetup = "skip_setup"
+
+	// AutoMigrate is the config key used to migrate the database on startup
+	// when its schema is out of date, instead of waiting for the migration
+	// to be started from the UI.
+	AutoMigrate = "auto_migrate"
+
+	// TransactionRetries is the number of times a retryable transaction is
+	// retried when the database is locked.
+	TransactionRetries        = "transaction_retries"
+	transactionRetriesDefault = 3
+
+	// TransactionRetryBackoff is the delay in milliseconds before retrying
+	// a transaction. The delay is doubled for each subsequent retry.
+	TransactionRetryBackoff        = "transaction_retry_backoff"
+	transactionRetryBackoffDefault = 100
+
 	Exclude      = "exclude"
 	ImageExclude = "image_exclude"
 
+	// JunkFilePatterns are the filename glob patterns of junk files removed
+	// by the junk file cleanup job.
+	JunkFilePatterns = "junk_file_patterns"
+
 	VideoExtensions            = "video_extensions"
 	ImageExtensions            = "image_extensions"
 	GalleryExtensions          = "gallery_extensions"
 	CreateGalleriesFromFolders = "create_galleries_from_folders"
 
+	// ScanHiddenFiles includes hidden files and directories, and operating
+	// system files, in scans. They are excluded if false.
+	ScanHiddenFiles = "scan_hidden_files"
+
+	// WatchLibrary is the config key used to determine if the library paths
+	// should be watched for changes.
+	WatchLibrary = "watch_library"
+
 	// CalculateMD5 is the config key used to determine if MD5 should be calculated
 	// for video files.
 	CalculateMD5 = "calculate_md5"
 
+	// FullHashMaxSize is the size in megabytes above which MD5 checksums
+	// are not calculated for video files, which are identified by oshash
+	// instead. Not limited if zero. Ignored if the video file naming
+	// algorithm is MD5, since generated files are named by the checksum.
+	FullHashMaxSize = "full_hash_max_size"
+
 	// VideoFileNamingAlgorithm is the config key used to determine what hash
 	// should be used when generating and using generated files for scenes.
 	VideoFileNamingAlgorithm = "video_file_naming_algorithm"
@@ -57,9 +155,70 @@ const (
 	MaxTranscodeSize          = "max_transcode_size"
 	MaxStreamingTranscodeSize = "max_streaming_transcode_size"
 
+	// TranscodeCacheSize is the maximum size in megabytes of the cache of
+	// completed streaming transcodes. The cache is disabled if zero.
+	TranscodeCacheSize        = "transcode_cache_size"
+	transcodeCacheSizeDefault = 10240
+
+	// TranscodeCleanupSchedule is the cron schedule at which partial
+	// transcodes left behind in the tmp directory are deleted. Periodic
+	// cleanup is disabled if empty.
+	TranscodeCleanupSchedule        = "transcode_cleanup_schedule"
+	transcodeCleanupScheduleDefault = "0 * * * *"
+
 	ParallelTasks        = "parallel_tasks"
 	parallelTasksDefault = 1
 
+	// AutoTagMatchWorkers is the number of files matched concurrently by the
+	// auto-tag task. The number is detected from the number of CPUs if zero.
+	AutoTagMatchWorkers        = "autotag_match_workers"
+	autoTagMatchWorkersDefault = 0
+
+	// AutoTagWriteBatchSize is the maximum number of auto-tagged files
+	// written in a single transaction.
+	AutoTagWriteBatchSize        = "autotag_write_batch_size"
+	autoTagWriteBatchSizeDefault = 100
+
+	// AutoTagApplyTagAncestors is whether auto-tag also applies the parent
+	// tags of the tags it applies.
+	AutoTagApplyTagAncestors = "autotag_apply_tag_ancestors"
+
+	// AutoTagFuzzyDistance is the maximum edit distance between the words of
+	// a name and the words of a path for auto-tag to match them when they do
+	// not match exactly. Fuzzy matching is disabled if zero.
+	AutoTagFuzzyDistance = "autotag_fuzzy_distance"
+
+	// ResourceLimitCPU, ResourceLimitIO and ResourceLimitMemory are the
+	// limits of the CPU slots, IO slots and megabytes of memory shared by
+	// scanning, transcoding and thumbnail generation. Unlimited if zero.
+	ResourceLimitCPU    = "resource_limits.cpu"
+	ResourceLimitIO     = "resource_limits.io"
+	ResourceLimitMemory = "resource_limits.memory"
+
+	FFProbeTimeout        = "ffprobe_timeout"
+	ffprobeTimeoutDefault = 60
+
+	// InstanceID names the temporary directory of the instance in the
+	// generated directory. Instances sharing a generated directory must use
+	// different IDs. A random ID is used for each run if not set.
+	InstanceID = "instance_id"
+
+	// StashPathCheckInterval is the number of seconds between checks that
+	// the stash paths are reachable. Periodic checks are disabled if zero.
+	StashPathCheckInterval        = "stash_path_check_interval"
+	stashPathCheckIntervalDefault = 60
+
+	// EncodeBenchmarkInterval is the number of seconds between benchmarks
+	// of the encoding speed. Periodic benchmarks are disabled if zero.
+	EncodeBenchmarkInterval        = "encode_benchmark_interval"
+	encodeBenchmarkIntervalDefault = 3600
+
+	// EncodeLatencyThreshold is the average time in milliseconds to encode
+	// the benchmark sample above which encoding is degraded. Disabled if
+	// zero.
+	EncodeLatencyThreshold        = "encode_latency_threshold"
+	encodeLatencyThresholdDefault = 2000
+
 	PreviewPreset = "preview_preset"
 
 	PreviewAudio        = "preview_audio"
@@ -68,6 +227,58 @@ const (
 	PreviewSegmentDuration        = "preview_segment_duration"
 	previewSegmentDurationDefault = 0.75
 
+	PreviewWidth        = "preview_width"
+	previewWidthDefault = 640
+
+	// SpriteCount is the number of thumbnails in scene sprite images.
+	SpriteCount        = "sprite_count"
+	spriteCountDefault = 81
+
+	// SpriteColumns is the number of columns in the scene sprite image grid.
+	SpriteColumns        = "sprite_columns"
+	spriteColumnsDefault = 9
+
+	// SpriteWidth is the width of each thumbnail in scene sprite images.
+	SpriteWidth        = "sprite_width"
+	spriteWidthDefault = 160
+
+	// ContactSheetColumns is the number of columns in the grid of frames of
+	// scene contact sheets.
+	ContactSheetColumns        = "contact_sheet_columns"
+	contactSheetColumnsDefault = 4
+
+	// ContactSheetRows is the number of rows in the grid of frames of scene
+	// contact sheets.
+	ContactSheetRows        = "contact_sheet_rows"
+	contactSheetRowsDefault = 4
+
+	// ContactSheetWidth is the width of each frame in scene contact sheets.
+	ContactSheetWidth        = "contact_sheet_width"
+	contactSheetWidthDefault = 320
+
+	// ContactSheetHeader is whether the scene title and duration are drawn
+	// above the frames of scene contact sheets.
+	ContactSheetHeader        = "contact_sheet_header"
+	contactSheetHeaderDefault = true
+
+	// ScreenshotPositionPercent is the position of the default scene
+	// screenshot as a percentage of the scene duration.
+	ScreenshotPositionPercent        = "screenshot_position_percent"
+	screenshotPositionPercentDefault = 20.0
+
+	// ScreenshotPositionSeconds is the position of the default scene
+	// screenshot in seconds. Used instead of the percentage if set.
+	ScreenshotPositionSeconds = "screenshot_position_seconds"
+
+	// ScreenshotFormat is the image format of generated scene screenshots:
+	// jpeg, webp or avif. JPEG is used if ffmpeg cannot encode the format.
+	ScreenshotFormat        = "screenshot_format"
+	screenshotFormatDefault = "jpeg"
+
+	// ScreenshotQuality is the quality of generated scene screenshots, from
+	// 1 to 100. The format's default quality is used if zero.
+	ScreenshotQuality = "screenshot_quality"
+
 	PreviewSegments        = "preview_segments"
 	previewSegmentsDefault = 12
 
@@ -80,6 +291,62 @@ const (
 	WriteImageThumbnails        = "write_image_thumbnails"
 	writeImageThumbnailsDefault = true
 
+	// ImageThumbnailMaxSize is the maximum width and height in pixels of
+	// image thumbnails.
+	ImageThumbnailMaxSize        = "image_thumbnail_max_size"
+	imageThumbnailMaxSizeDefault = 640
+
+	// ImageThumbnailCacheSize is the maximum size in megabytes of the cache
+	// of image thumbnails generated on demand. The cache is disabled if zero.
+	ImageThumbnailCacheSize        = "image_thumbnail_cache_size"
+	imageThumbnailCacheSizeDefault = 1024
+
+	// GeneratedCacheMaxAge is the number of seconds that clients may cache
+	// generated files such as screenshots, previews and sprites without
+	// revalidating them. Clients revalidate on each request if zero.
+	GeneratedCacheMaxAge = "generated_cache_max_age"
+
+	// GenerateMaxRetries is the number of times a failed generate item is
+	// retried by a generate retry job.
+	GenerateMaxRetries        = "generate_max_retries"
+	generateMaxRetriesDefault = 3
+
+	// GenerateRetryBackoff is the number of seconds before a failed generate
+	// item is retried. It doubles with each failed attempt.
+	GenerateRetryBackoff        = "generate_retry_backoff"
+	generateRetryBackoffDefault = 600
+
+	// ShutdownGracePeriod is the number of seconds that active streams are
+	// given to finish on shutdown. New streams are refused in this period.
+	ShutdownGracePeriod        = "shutdown_grace_period"
+	shutdownGracePeriodDefault = 30
+
+	GalleryCoverStrategy = "gallery_cover_strategy"
+
+	// MissingFilePolicy is how a clean handles scenes whose file no longer
+	// exists.
+	MissingFilePolicy = "missing_file_policy"
+
+	// WriteSceneSidecars is whether metadata sidecar files are written next
+	// to scene files when the scene metadata changes.
+	WriteSceneSidecars = "write_scene_sidecars"
+
+	// ReadSceneSidecars is whether scene metadata sidecar files are read
+	// during scan.
+	ReadSceneSidecars = "read_scene_sidecars"
+
+	// SceneSidecarFormat is the format of scene metadata sidecar files.
+	SceneSidecarFormat = "scene_sidecar_format"
+
+	// SceneSidecarPrecedence is which metadata is kept when a scene sidecar
+	// file differs from the database.
+	SceneSidecarPrecedence = "scene_sidecar_precedence"
+
+	// OrganizeTemplate is the template of the paths that scene files are
+	// moved to when organized, relative to their library path.
+	OrganizeTemplate        = "organize_template"
+	organizeTemplateDefault = "{studio}/{date} {title}.{ext}"
+
 	Host        = "host"
 	hostDefault = "0.0.0.0"
 
@@ -100,10 +367,21 @@ const (
 	ScraperCertCheck          = "scraper_cert_check"
 	ScraperCDPPath            = "scraper_cdp_path"
 	ScraperExcludeTagPatterns = "scraper_exclude_tag_patterns"
+	ScraperFieldMappings      = "scraper_field_mappings"
+	ScraperTransforms         = "scraper_transforms"
 
 	// stash-box options
 	StashBoxes = "stash_boxes"
 
+	// remote stash sync options
+	RemoteStashEndpoint    = "remote_stash_endpoint"
+	RemoteStashAPIKey      = "remote_stash_api_key"
+	RemoteStashMergePolicy = "remote_stash_merge_policy"
+
+	// credentials of WebDAV servers containing library paths
+	WebDAVUsername = "webdav_username"
+	WebDAVPassword = "webdav_password"
+
 	// plugin options
 	PluginsPath = "plugins_path"
 
@@ -160,6 +438,18 @@ const (
 	DLNADefaultIPWhitelist = "dlna.default_whitelist"
 	DLNAInterfaces         = "dlna.interfaces"
 
+	// DLNATranscodeMode sets when scenes are transcoded for DLNA clients.
+	DLNATranscodeMode = "dlna.transcode_mode"
+
+	// DLNAMaxTranscodes is the maximum number of concurrent transcodes for
+	// DLNA clients. Not limited if zero. Transcodes beyond the limit wait
+	// for a running transcode to finish, unless DLNARejectExcessTranscodes
+	// is set.
+	DLNAMaxTranscodes = "dlna.max_transcodes"
+	// DLNARejectExcessTranscodes rejects transcodes beyond
+	// DLNAMaxTranscodes, rather than queueing them.
+	DLNARejectExcessTranscodes = "dlna.reject_excess_transcodes"
+
 	// Logging options
 	LogFile          = "logFile"
 	LogOut           = "logOut"
@@ -169,6 +459,15 @@ const (
 	LogAccess        = "logAccess"
 	defaultLogAccess = true
 
+	// AuditLog is the path of the audit log of security-relevant events.
+	// Defaults to audit.log in the configuration directory.
+	AuditLog = "audit_log"
+
+	// Timezone is the IANA name of the time zone used to compute schedule
+	// triggers, and to format timestamps in logs and exports. The system
+	// time zone is used if empty.
+	Timezone = "timezone"
+
 	// Default settings
 	DefaultScanSettings     = "defaults.scan_task"
 	DefaultIdentifySettings = "defaults.identify_task"
@@ -179,6 +478,15 @@ const (
 	DeleteGeneratedDefault        = "defaults.delete_generated"
 	deleteGeneratedDefaultDefault = true
 
+	// SoftDeleteScenes is the config key used to determine if deleted scenes
+	// are moved to the trash instead of being removed from the database.
+	SoftDeleteScenes = "soft_delete_scenes"
+
+	// SoftDeleteRetentionDays is the number of days that soft-deleted scenes
+	// are kept in the trash before being purged.
+	SoftDeleteRetentionDays        = "soft_delete_retention_days"
+	softDeleteRetentionDaysDefault = 30
+
 	// Desktop Integration Options
 	NoBrowser                           = "noBrowser"
 	NoBrowserDefault                    = false
@@ -189,6 +497,19 @@ const (
 
 	// File upload options
 	MaxUploadSize = "max_upload_size"
+
+	// MaxRequestBodySize is the maximum size in megabytes of request
+	// bodies, other than graphql uploads. Not limited if zero.
+	MaxRequestBodySize        = "max_request_body_size"
+	maxRequestBodySizeDefault = 10
+
+	// MaxPageSize is the maximum number of results returned by a page of an
+	// API query. Requests for larger pages are capped.
+	MaxPageSize = "max_page_size"
+
+	// QueryStats is whether the number and duration of database queries
+	// are recorded.
+	QueryStats = "query_stats"
 )
 
 // slice default values
@@ -197,6 +518,7 @@ var (
 	defaultImageExtensions   = []string{"png", "jpg", "jpeg", "gif", "webp"}
 	defaultGalleryExtensions = []string{"zip", "cbz"}
 	defaultMenuItems         = []string{"scenes", "images", "movies", "markers", "galleries", "performers", "studios", "tags"}
+	defaultJunkFilePatterns  = []string{".DS_Store", "._*", "Thumbs.db", "desktop.ini"}
 )
 
 type MissingConfigError struct {
@@ -231,6 +553,7 @@ type Instance struct {
 
 	cpuProfilePath string
 	isNewSystem    bool
+	readOnly       bool
 	// configUpdates  chan int
 	certFile string
 	keyFile  string
@@ -244,6 +567,18 @@ func (i *Instance) IsNewSystem() bool {
 	return i.isNewSystem
 }
 
+// GetSkipSetup returns true if a system without a config file should be
+// treated as an existing system.
+func (i *Instance) GetSkipSetup() bool {
+	return i.getBool(SkipSetup)
+}
+
+// GetAutoMigrate returns true if the database should be migrated on startup
+// when its schema is out of date.
+func (i *Instance) GetAutoMigrate() bool {
+	return i.getBool(AutoMigrate)
+}
+
 func (i *Instance) SetConfigFile(fn string) {
 	i.Lock()
 	defer i.Unlock()
@@ -305,19 +640,29 @@ func (i *Instance) Set(key string, value interface{}) {
 	i.main.Set(key, value)
 }
 
-func (i *Instance) SetPassword(value string) {
-	// if blank, don't bother hashing; we want it to be blank
-	if value == "" {
-		i.Set(Password, "")
-	} else {
-		i.Set(Password, hashPassword(value))
-	}
+// Write writes the configuration file. An error wrapping ErrConfigReadOnly
+// is returned if the file cannot be written due to its permissions.
+func (i *Instance) Write() error {
+	i.Lock()
+	defer i.Unlock()
+	return i.writeConfig()
 }
 
-func (i *Instance) Write() error {
+// SetAndWrite sets the value of key and writes the configuration file. If the
+// file cannot be written, the previous value is restored.
+func (i *Instance) SetAndWrite(key string, value interface{}) error {
 	i.Lock()
 	defer i.Unlock()
-	return i.main.WriteConfig()
+
+	previous := i.main.Get(key)
+	i.main.Set(key, value)
+
+	if err := i.writeConfig(); err != nil {
+		i.main.Set(key, previous)
+		return err
+	}
+
+	return nil
 }
 
 // FileEnvSet returns true if the configuration file environment parameter
@@ -430,6 +775,20 @@ func (i *Instance) GetStashPaths() []*models.StashConfig {
 		v = i.overrides
 	}
 
+	// entries read from the configuration file may be paths or objects
+	if items, ok := v.Get(Stash).([]interface{}); ok {
+		for _, item := range items {
+			s, err := decodeStashConfig(item)
+			if err != nil {
+				logger.Warnf("ignoring invalid stash entry %v: %v", item, err)
+				continue
+			}
+			ret = append(ret, s)
+		}
+
+		return ret
+	}
+
 	if err := v.UnmarshalKey(Stash, &ret); err != nil || len(ret) == 0 {
 		// fallback to legacy format
 		ss := v.GetStringSlice(Stash)
@@ -445,6 +804,33 @@ func (i *Instance) GetStashPaths() []*models.StashConfig {
 	return ret
 }
 
+// decodeStashConfig decodes an entry of the stash list, which is either a
+// path in the legacy format or a StashConfig.
+func decodeStashConfig(item interface{}) (*models.StashConfig, error) {
+	if path, ok := item.(string); ok {
+		return &models.StashConfig{
+			Path: path,
+		}, nil
+	}
+
+	// decode using viper, so that keys are matched in the same way as
+	// other configuration values
+	const key = "stash"
+	v := viper.New()
+	v.Set(key, item)
+
+	var ret models.StashConfig
+	if err := v.UnmarshalKey(key, &ret); err != nil {
+		return nil, err
+	}
+
+	if ret.Path == "" {
+		return nil, errors.New("path is not set")
+	}
+
+	return &ret, nil
+}
+
 func (i *Instance) GetCachePath() string {
 	return i.getString(Cache)
 }
@@ -461,6 +847,41 @@ func (i *Instance) GetDatabasePath() string {
 	return i.getString(Database)
 }
 
+// GetVerifyMigration returns true if the database should be verified after
+// a migration.
+func (i *Instance) GetVerifyMigration() bool {
+	return i.getBool(VerifyMigration)
+}
+
+// GetTransactionRetries returns the number of times a retryable
+// transaction is retried when the database is locked.
+func (i *Instance) GetTransactionRetries() int {
+	i.RLock()
+	defer i.RUnlock()
+
+	ret := transactionRetriesDefault
+	v := i.viper(TransactionRetries)
+	if v.IsSet(TransactionRetries) {
+		ret = v.GetInt(TransactionRetries)
+	}
+
+	if ret < 0 {
+		ret = 0
+	}
+
+	return ret
+}
+
+// GetTransactionRetryBackoff returns the delay before the first retry of a
+// transaction.
+func (i *Instance) GetTransactionRetryBackoff() time.Duration {
+	ms := i.getInt(TransactionRetryBackoff)
+	if ms <= 0 {
+		ms = transactionRetryBackoffDefault
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
 func (i *Instance) GetJWTSignKey() []byte {
 	return []byte(i.getString(JWTSignKey))
 }
@@ -484,6 +905,15 @@ func (i *Instance) GetImageExcludes() []string {
 	return i.getStringSlice(ImageExclude)
 }
 
+// GetJunkFilePatterns returns the filename glob patterns of junk files.
+func (i *Instance) GetJunkFilePatterns() []string {
+	ret := i.getStringSlice(JunkFilePatterns)
+	if ret == nil {
+		ret = defaultJunkFilePatterns
+	}
+	return ret
+}
+
 func (i *Instance) GetVideoExtensions() []string {
 	ret := i.getStringSlice(VideoExtensions)
 	if ret == nil {
@@ -512,6 +942,12 @@ func (i *Instance) GetCreateGalleriesFromFolders() bool {
 	return i.getBool(CreateGalleriesFromFolders)
 }
 
+// IsScanHiddenFiles returns true if hidden files and operating system files
+// are scanned.
+func (i *Instance) IsScanHiddenFiles() bool {
+	return i.getBool(ScanHiddenFiles)
+}
+
 func (i *Instance) GetLanguage() string {
 	ret := i.getString(Language)
 
@@ -529,6 +965,22 @@ func (i *Instance) IsCalculateMD5() bool {
 	return i.getBool(CalculateMD5)
 }
 
+// GetFullHashMaxSize returns the size in bytes above which MD5 checksums are
+// not calculated for video files. Returns 0 if not limited.
+func (i *Instance) GetFullHashMaxSize() int64 {
+	ret := int64(i.getInt(FullHashMaxSize))
+	if ret < 0 {
+		ret = 0
+	}
+	return ret << 20
+}
+
+// IsWatchLibrary returns true if the library paths should be watched, and
+// changed directories scanned automatically.
+func (i *Instance) IsWatchLibrary() bool {
+	return i.getBool(WatchLibrary)
+}
+
 // GetVideoFileNamingAlgorithm returns what hash algorithm should be used for
 // naming generated scene video files.
 func (i *Instance) GetVideoFileNamingAlgorithm() models.HashAlgorithm {
@@ -546,6 +998,12 @@ func (i *Instance) GetScrapersPath() string {
 	return i.getString(ScrapersPath)
 }
 
+// GetScraperCookiesPath returns the directory where cookies set by scraped
+// sites are stored, which is in the same directory as the config file.
+func (i *Instance) GetScraperCookiesPath() string {
+	return filepath.Join(i.GetConfigPath(), "scraper_cookies")
+}
+
 func (i *Instance) GetScraperUserAgent() string {
 	return i.getString(ScraperUserAgent)
 }
@@ -575,6 +1033,28 @@ func (i *Instance) GetScraperExcludeTagPatterns() []string {
 	return i.getStringSlice(ScraperExcludeTagPatterns)
 }
 
+// GetScraperFieldMappings returns the mappings applied to the fields of
+// scraped content.
+func (i *Instance) GetScraperFieldMappings() []*models.ScraperFieldMapping {
+	var ret []*models.ScraperFieldMapping
+	if err := i.unmarshalKey(ScraperFieldMappings, &ret); err != nil {
+		logger.Warnf("error in unmarshalkey: %v", err)
+	}
+
+	return ret
+}
+
+// GetScraperTransforms returns the transforms applied, in order, to the
+// fields of scraped content.
+func (i *Instance) GetScraperTransforms() []*models.ScraperTransform {
+	var ret []*models.ScraperTransform
+	if err := i.unmarshalKey(ScraperTransforms, &ret); err != nil {
+		logger.Warnf("error in unmarshalkey: %v", err)
+	}
+
+	return ret
+}
+
 func (i *Instance) GetStashBoxes() models.StashBoxes {
 	var boxes models.StashBoxes
 	if err := i.unmarshalKey(StashBoxes, &boxes); err != nil {
@@ -623,12 +1103,130 @@ func (i *Instance) GetPreviewSegmentDuration() float64 {
 	return i.getFloat64(PreviewSegmentDuration)
 }
 
+// GetPreviewWidth returns the width of generated scene preview files, in
+// pixels.
+func (i *Instance) GetPreviewWidth() int {
+	return i.getInt(PreviewWidth)
+}
+
+// GetSpriteCount returns the number of thumbnails in generated scene sprite
+// images.
+func (i *Instance) GetSpriteCount() int {
+	return i.getInt(SpriteCount)
+}
+
+// GetSpriteColumns returns the number of columns in the grid of generated
+// scene sprite images.
+func (i *Instance) GetSpriteColumns() int {
+	return i.getInt(SpriteColumns)
+}
+
+// GetSpriteWidth returns the width of each thumbnail in generated scene
+// sprite images, in pixels.
+func (i *Instance) GetSpriteWidth() int {
+	return i.getInt(SpriteWidth)
+}
+
+// GetContactSheetColumns returns the number of columns in the grid of frames
+// of generated scene contact sheets.
+func (i *Instance) GetContactSheetColumns() int {
+	return i.getInt(ContactSheetColumns)
+}
+
+// GetContactSheetRows returns the number of rows in the grid of frames of
+// generated scene contact sheets.
+func (i *Instance) GetContactSheetRows() int {
+	return i.getInt(ContactSheetRows)
+}
+
+// GetContactSheetWidth returns the width of each frame in generated scene
+// contact sheets, in pixels.
+func (i *Instance) GetContactSheetWidth() int {
+	return i.getInt(ContactSheetWidth)
+}
+
+// GetContactSheetHeader returns true if the scene title and duration are
+// drawn above the frames of generated scene contact sheets.
+func (i *Instance) GetContactSheetHeader() bool {
+	return i.getBool(ContactSheetHeader)
+}
+
+// GetScreenshotPositionPercent returns the position of the default scene
+// screenshot as a percentage of the scene duration.
+func (i *Instance) GetScreenshotPositionPercent() float64 {
+	return i.getFloat64(ScreenshotPositionPercent)
+}
+
+// GetScreenshotPositionSeconds returns the position of the default scene
+// screenshot in seconds. Returns 0 if the percentage should be used.
+func (i *Instance) GetScreenshotPositionSeconds() float64 {
+	return i.getFloat64(ScreenshotPositionSeconds)
+}
+
+// GetScreenshotFormat returns the image format of generated scene
+// screenshots.
+func (i *Instance) GetScreenshotFormat() string {
+	return i.getString(ScreenshotFormat)
+}
+
+// GetScreenshotQuality returns the quality of generated scene screenshots,
+// from 1 to 100. Returns 0 if the format's default quality should be used.
+func (i *Instance) GetScreenshotQuality() int {
+	return i.getInt(ScreenshotQuality)
+}
+
 // GetParallelTasks returns the number of parallel tasks that should be started
 // by scan or generate task.
 func (i *Instance) GetParallelTasks() int {
 	return i.getInt(ParallelTasks)
 }
 
+// GetFFProbeTimeout returns the maximum duration of an ffprobe invocation.
+// Returns 0 if probes should not time out.
+func (i *Instance) GetFFProbeTimeout() time.Duration {
+	seconds := i.getInt(FFProbeTimeout)
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// GetInstanceID returns the configured ID of the instance, or an empty
+// string if not set.
+func (i *Instance) GetInstanceID() string {
+	return strings.TrimSpace(i.getString(InstanceID))
+}
+
+// GetStashPathCheckInterval returns the interval between checks that the
+// stash paths are reachable. Returns 0 if periodic checks are disabled.
+func (i *Instance) GetStashPathCheckInterval() time.Duration {
+	seconds := i.getInt(StashPathCheckInterval)
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// GetEncodeBenchmarkInterval returns the interval between benchmarks of the
+// encoding speed. Returns 0 if periodic benchmarks are disabled.
+func (i *Instance) GetEncodeBenchmarkInterval() time.Duration {
+	seconds := i.getInt(EncodeBenchmarkInterval)
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// GetEncodeLatencyThreshold returns the average benchmark time above which
+// encoding is degraded. Returns 0 if disabled.
+func (i *Instance) GetEncodeLatencyThreshold() time.Duration {
+	ms := i.getInt(EncodeLatencyThreshold)
+	if ms <= 0 {
+		return 0
+	}
+	return time.Duration(ms) * time.Millisecond
+}
+
 func (i *Instance) GetParallelTasksWithAutoDetection() int {
 	parallelTasks := i.getInt(ParallelTasks)
 	if parallelTasks <= 0 {
@@ -637,6 +1235,66 @@ func (i *Instance) GetParallelTasksWithAutoDetection() int {
 	return parallelTasks
 }
 
+// GetAutoTagMatchWorkers returns the number of files matched concurrently by
+// the auto-tag task. Returns 0 if the number should be detected.
+func (i *Instance) GetAutoTagMatchWorkers() int {
+	return i.getInt(AutoTagMatchWorkers)
+}
+
+// GetAutoTagMatchWorkersWithAutoDetection returns the number of files matched
+// concurrently by the auto-tag task, using the number of CPUs if not set.
+func (i *Instance) GetAutoTagMatchWorkersWithAutoDetection() int {
+	ret := i.getInt(AutoTagMatchWorkers)
+	if ret <= 0 {
+		ret = runtime.NumCPU()
+	}
+	return ret
+}
+
+// GetAutoTagWriteBatchSize returns the maximum number of auto-tagged files
+// written in a single transaction.
+func (i *Instance) GetAutoTagWriteBatchSize() int {
+	ret := i.getInt(AutoTagWriteBatchSize)
+	if ret <= 0 {
+		ret = autoTagWriteBatchSizeDefault
+	}
+	return ret
+}
+
+// GetAutoTagApplyTagAncestors returns true if auto-tag should also apply the
+// ancestors of the tags it applies.
+func (i *Instance) GetAutoTagApplyTagAncestors() bool {
+	return i.getBool(AutoTagApplyTagAncestors)
+}
+
+// GetAutoTagFuzzyDistance returns the maximum edit distance of fuzzy
+// auto-tag matches. Returns zero if fuzzy matching is disabled.
+func (i *Instance) GetAutoTagFuzzyDistance() int {
+	ret := i.getInt(AutoTagFuzzyDistance)
+	if ret < 0 {
+		ret = 0
+	}
+	return ret
+}
+
+// GetResourceLimitCPU returns the number of CPU slots shared by heavy work.
+// Returns zero if unlimited.
+func (i *Instance) GetResourceLimitCPU() int {
+	return i.getInt(ResourceLimitCPU)
+}
+
+// GetResourceLimitIO returns the number of IO slots shared by heavy work.
+// Returns zero if unlimited.
+func (i *Instance) GetResourceLimitIO() int {
+	return i.getInt(ResourceLimitIO)
+}
+
+// GetResourceLimitMemory returns the megabytes of memory shared by heavy
+// work. Returns zero if unlimited.
+func (i *Instance) GetResourceLimitMemory() int {
+	return i.getInt(ResourceLimitMemory)
+}
+
 func (i *Instance) GetPreviewAudio() bool {
 	return i.getBool(PreviewAudio)
 }
@@ -700,12 +1358,196 @@ func (i *Instance) GetMaxStreamingTranscodeSize() models.StreamingResolutionEnum
 	return models.StreamingResolutionEnum(ret)
 }
 
+// GetTranscodeCacheSize returns the maximum size in megabytes of the cache
+// of completed streaming transcodes. Returns 0 if the cache is disabled.
+func (i *Instance) GetTranscodeCacheSize() int {
+	ret := i.getInt(TranscodeCacheSize)
+	if ret < 0 {
+		return 0
+	}
+	return ret
+}
+
+// GetTranscodeCleanupSchedule returns the cron schedule at which partial
+// transcodes are deleted. Returns an empty string if periodic cleanup is
+// disabled.
+func (i *Instance) GetTranscodeCleanupSchedule() string {
+	return i.getString(TranscodeCleanupSchedule)
+}
+
+// GetImageThumbnailMaxSize returns the maximum width and height in pixels of
+// image thumbnails.
+func (i *Instance) GetImageThumbnailMaxSize() int {
+	ret := i.getInt(ImageThumbnailMaxSize)
+	if ret <= 0 {
+		return imageThumbnailMaxSizeDefault
+	}
+	return ret
+}
+
+// GetImageThumbnailCacheSize returns the maximum size in megabytes of the
+// cache of image thumbnails generated on demand. Returns 0 if the cache is
+// disabled.
+func (i *Instance) GetImageThumbnailCacheSize() int {
+	ret := i.getInt(ImageThumbnailCacheSize)
+	if ret < 0 {
+		return 0
+	}
+	return ret
+}
+
+// GetGeneratedCacheMaxAge returns the number of seconds that clients may
+// cache generated files without revalidating them.
+func (i *Instance) GetGeneratedCacheMaxAge() int {
+	ret := i.getInt(GeneratedCacheMaxAge)
+	if ret < 0 {
+		return 0
+	}
+	return ret
+}
+
+// GetGenerateMaxRetries returns the number of times a failed generate item
+// is retried.
+func (i *Instance) GetGenerateMaxRetries() int {
+	ret := i.getInt(GenerateMaxRetries)
+	if ret < 0 {
+		return 0
+	}
+	return ret
+}
+
+// GetGenerateRetryBackoff returns the number of seconds before a failed
+// generate item is first retried.
+func (i *Instance) GetGenerateRetryBackoff() int {
+	ret := i.getInt(GenerateRetryBackoff)
+	if ret < 0 {
+		return 0
+	}
+	return ret
+}
+
+// GetShutdownGracePeriod returns the number of seconds that active streams
+// are given to finish on shutdown.
+func (i *Instance) GetShutdownGracePeriod() int {
+	ret := i.getInt(ShutdownGracePeriod)
+	if ret < 0 {
+		return 0
+	}
+	return ret
+}
+
 // IsWriteImageThumbnails returns true if image thumbnails should be written
 // to disk after generating on the fly.
 func (i *Instance) IsWriteImageThumbnails() bool {
 	return i.getBool(WriteImageThumbnails)
 }
 
+// GetRemoteStashEndpoint returns the base URL of the remote stash instance
+// that scene metadata is synced from.
+func (i *Instance) GetRemoteStashEndpoint() string {
+	return i.getString(RemoteStashEndpoint)
+}
+
+// GetRemoteStashAPIKey returns the API key used to authenticate with the
+// remote stash instance.
+func (i *Instance) GetRemoteStashAPIKey() string {
+	return i.getString(RemoteStashAPIKey)
+}
+
+// GetWebDAVUsername returns the username used to authenticate with WebDAV
+// servers containing library paths.
+func (i *Instance) GetWebDAVUsername() string {
+	return i.getString(WebDAVUsername)
+}
+
+// GetWebDAVPassword returns the password used to authenticate with WebDAV
+// servers containing library paths.
+func (i *Instance) GetWebDAVPassword() string {
+	return i.getString(WebDAVPassword)
+}
+
+// GetRemoteStashMergePolicy returns the strategy used to merge remote
+// scene metadata with existing local values. Defaults to Merge.
+func (i *Instance) GetRemoteStashMergePolicy() models.IdentifyFieldStrategy {
+	ret := models.IdentifyFieldStrategy(i.getString(RemoteStashMergePolicy))
+
+	if !ret.IsValid() {
+		return models.IdentifyFieldStrategyMerge
+	}
+
+	return ret
+}
+
+// GetMissingFilePolicy returns how a clean handles scenes whose file no
+// longer exists. Defaults to Delete.
+func (i *Instance) GetMissingFilePolicy() models.MissingFilePolicy {
+	ret := models.MissingFilePolicy(i.getString(MissingFilePolicy))
+
+	if !ret.IsValid() {
+		return models.MissingFilePolicyDelete
+	}
+
+	return ret
+}
+
+// GetWriteSceneSidecars returns true if metadata sidecar files are written
+// next to scene files when the scene metadata changes.
+func (i *Instance) GetWriteSceneSidecars() bool {
+	return i.getBool(WriteSceneSidecars)
+}
+
+// GetReadSceneSidecars returns true if scene metadata sidecar files are read
+// during scan.
+func (i *Instance) GetReadSceneSidecars() bool {
+	return i.getBool(ReadSceneSidecars)
+}
+
+// GetSceneSidecarFormat returns the format of scene metadata sidecar files.
+// Defaults to JSON.
+func (i *Instance) GetSceneSidecarFormat() models.SidecarFormat {
+	ret := models.SidecarFormat(i.getString(SceneSidecarFormat))
+
+	if !ret.IsValid() {
+		return models.SidecarFormatJSON
+	}
+
+	return ret
+}
+
+// GetSceneSidecarPrecedence returns which metadata is kept when a scene
+// sidecar file differs from the database. Defaults to Database.
+func (i *Instance) GetSceneSidecarPrecedence() models.SidecarPrecedence {
+	ret := models.SidecarPrecedence(i.getString(SceneSidecarPrecedence))
+
+	if !ret.IsValid() {
+		return models.SidecarPrecedenceDatabase
+	}
+
+	return ret
+}
+
+// GetGalleryCoverStrategy returns the strategy used to select the cover
+// image of galleries. Defaults to First.
+func (i *Instance) GetGalleryCoverStrategy() models.GalleryCoverStrategy {
+	ret := models.GalleryCoverStrategy(i.getString(GalleryCoverStrategy))
+
+	if !ret.IsValid() {
+		return models.GalleryCoverStrategyFirst
+	}
+
+	return ret
+}
+
+// GetOrganizeTemplate returns the template of the paths that scene files are
+// moved to when organized.
+func (i *Instance) GetOrganizeTemplate() string {
+	ret := i.getString(OrganizeTemplate)
+	if ret == "" {
+		return organizeTemplateDefault
+	}
+	return ret
+}
+
 func (i *Instance) GetAPIKey() string {
 	return i.getString(ApiKey)
 }
@@ -718,38 +1560,80 @@ func (i *Instance) GetPasswordHash() string {
 	return i.getString(Password)
 }
 
-func (i *Instance) GetCredentials() (string, string) {
-	if i.HasCredentials() {
-		return i.getString(Username), i.getString(Password)
+// GetPasswordHashAlgorithm returns the algorithm used to hash passwords.
+func (i *Instance) GetPasswordHashAlgorithm() string {
+	ret := i.getString(PasswordHashAlgorithm)
+	if ret == "" {
+		return passwordHashAlgorithmDefault
 	}
+	return ret
+}
 
-	return "", ""
+// GetPasswordHashBcryptCost returns the cost of bcrypt password hashes.
+func (i *Instance) GetPasswordHashBcryptCost() int {
+	ret := i.getInt(PasswordHashBcryptCost)
+	if ret <= 0 {
+		return passwordHashBcryptCostDefault
+	}
+	return ret
 }
 
-func (i *Instance) HasCredentials() bool {
-	username := i.getString(Username)
-	pwHash := i.getString(Password)
+// GetPasswordHashArgon2Memory returns the memory in KiB used by argon2id
+// password hashes.
+func (i *Instance) GetPasswordHashArgon2Memory() int {
+	ret := i.getInt(PasswordHashArgon2Memory)
+	if ret <= 0 {
+		return passwordHashArgon2MemoryDefault
+	}
+	return ret
+}
 
-	return username != "" && pwHash != ""
+// GetPasswordHashArgon2Iterations returns the number of passes of argon2id
+// password hashes.
+func (i *Instance) GetPasswordHashArgon2Iterations() int {
+	ret := i.getInt(PasswordHashArgon2Iterations)
+	if ret <= 0 {
+		return passwordHashArgon2IterationsDefault
+	}
+	return ret
+}
+
+// IsTOTPEnabled returns true if a TOTP code is required to log in. Always
+// returns false if no credentials or TOTP secret are set.
+func (i *Instance) IsTOTPEnabled() bool {
+	return i.getBool(TOTPEnabled) && i.HasCredentials() && i.GetTOTPSecret() != ""
+}
+
+func (i *Instance) GetTOTPSecret() string {
+	return i.getString(TOTPSecret)
+}
+
+// GetTOTPRecoveryCodes returns the hashes of the unused recovery codes.
+func (i *Instance) GetTOTPRecoveryCodes() []string {
+	return i.getStringSlice(TOTPRecoveryCodes)
 }
 
-func hashPassword(password string) string {
-	hash, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+// GetTOTPLastTimeStep returns the time-step of the last accepted TOTP code.
+func (i *Instance) GetTOTPLastTimeStep() int64 {
+	i.RLock()
+	defer i.RUnlock()
 
-	return string(hash)
+	return i.viper(TOTPLastTimeStep).GetInt64(TOTPLastTimeStep)
 }
 
-func (i *Instance) ValidateCredentials(username string, password string) bool {
-	if !i.HasCredentials() {
-		// don't need to authenticate if no credentials saved
-		return true
+func (i *Instance) GetCredentials() (string, string) {
+	if i.HasCredentials() {
+		return i.getString(Username), i.getString(Password)
 	}
 
-	authUser, authPWHash := i.GetCredentials()
+	return "", ""
+}
 
-	err := bcrypt.CompareHashAndPassword([]byte(authPWHash), []byte(password))
+func (i *Instance) HasCredentials() bool {
+	username := i.getString(Username)
+	pwHash := i.getString(Password)
 
-	return username == authUser && err == nil
+	return username != "" && pwHash != ""
 }
 
 var stashBoxRe = regexp.MustCompile("^http.*graphql$")
@@ -794,6 +1678,62 @@ func (i *Instance) GetMaxSessionAge() int {
 	return ret
 }
 
+// GetLoginDelay returns the minimum duration of a failed authentication.
+func (i *Instance) GetLoginDelay() time.Duration {
+	i.RLock()
+	defer i.RUnlock()
+
+	ret := loginDelayDefault
+	v := i.viper(LoginDelay)
+	if v.IsSet(LoginDelay) {
+		ret = v.GetInt(LoginDelay)
+	}
+
+	if ret < 0 {
+		ret = 0
+	}
+
+	return time.Duration(ret) * time.Millisecond
+}
+
+// GetSessionClockSkew returns the time that sessions are accepted past their
+// maximum age.
+func (i *Instance) GetSessionClockSkew() time.Duration {
+	i.RLock()
+	defer i.RUnlock()
+
+	ret := sessionClockSkewDefault
+	v := i.viper(SessionClockSkew)
+	if v.IsSet(SessionClockSkew) {
+		ret = v.GetInt(SessionClockSkew)
+	}
+
+	if ret < 0 {
+		ret = 0
+	}
+
+	return time.Duration(ret) * time.Second
+}
+
+// GetLoginDelaySuccess returns true if the login delay also applies to
+// successful logins.
+func (i *Instance) GetLoginDelaySuccess() bool {
+	return i.getBool(LoginDelaySuccess)
+}
+
+// GetTrustedHeaderAuth returns the name of the header that contains the user
+// authenticated by a trusted proxy. Returns an empty string if trusted
+// header authentication is disabled.
+func (i *Instance) GetTrustedHeaderAuth() string {
+	return strings.TrimSpace(i.getString(TrustedHeaderAuth))
+}
+
+// GetTrustedProxies returns the IP addresses or CIDR ranges of the proxies
+// that are trusted to set the trusted authentication header.
+func (i *Instance) GetTrustedProxies() []string {
+	return i.getStringSlice(TrustedProxies)
+}
+
 // GetCustomServedFolders gets the map of custom paths to their applicable
 // filesystem locations
 func (i *Instance) GetCustomServedFolders() URLMap {
@@ -966,6 +1906,27 @@ func (i *Instance) GetDeleteGeneratedDefault() bool {
 	return ret
 }
 
+// IsSoftDeleteScenes returns true if deleted scenes should be moved to the
+// trash instead of being destroyed.
+func (i *Instance) IsSoftDeleteScenes() bool {
+	return i.getBool(SoftDeleteScenes)
+}
+
+// GetSoftDeleteRetentionDays returns the number of days that soft-deleted
+// scenes are kept before being purged. Defaults to 30.
+func (i *Instance) GetSoftDeleteRetentionDays() int {
+	i.RLock()
+	defer i.RUnlock()
+	ret := softDeleteRetentionDaysDefault
+
+	v := i.viper(SoftDeleteRetentionDays)
+	if v.IsSet(SoftDeleteRetentionDays) {
+		ret = v.GetInt(SoftDeleteRetentionDays)
+	}
+
+	return ret
+}
+
 // GetDefaultIdentifySettings returns the default Identify task settings.
 // Returns nil if the settings could not be unmarshalled, or if it
 // has not been set.
@@ -1072,6 +2033,33 @@ func (i *Instance) GetDLNADefaultIPWhitelist() []string {
 	return i.getStringSlice(DLNADefaultIPWhitelist)
 }
 
+// GetDLNATranscodeMode returns when scenes are transcoded for DLNA clients.
+// Defaults to transcoding scenes that clients cannot play directly.
+func (i *Instance) GetDLNATranscodeMode() models.DLNATranscodeMode {
+	ret := models.DLNATranscodeMode(i.getString(DLNATranscodeMode))
+	if !ret.IsValid() {
+		return models.DLNATranscodeModeAuto
+	}
+
+	return ret
+}
+
+// GetDLNAMaxTranscodes returns the maximum number of concurrent transcodes
+// for DLNA clients. Returns 0 if not limited.
+func (i *Instance) GetDLNAMaxTranscodes() int {
+	ret := i.getInt(DLNAMaxTranscodes)
+	if ret < 0 {
+		ret = 0
+	}
+	return ret
+}
+
+// GetDLNARejectExcessTranscodes returns true if DLNA transcodes beyond the
+// maximum are rejected rather than queued.
+func (i *Instance) GetDLNARejectExcessTranscodes() bool {
+	return i.getBool(DLNARejectExcessTranscodes)
+}
+
 // GetDLNAInterfaces returns a list of interface names to expose DLNA on. If
 // empty, runs on all interfaces.
 func (i *Instance) GetDLNAInterfaces() []string {
@@ -1127,6 +2115,58 @@ func (i *Instance) GetLogAccess() bool {
 	return ret
 }
 
+// GetAuditLogPath returns the path of the audit log. Returns an empty
+// string if there is no configuration file, and no path is configured.
+func (i *Instance) GetAuditLogPath() string {
+	if ret := i.getString(AuditLog); ret != "" {
+		return ret
+	}
+
+	if i.GetConfigFile() == "" {
+		return ""
+	}
+
+	return filepath.Join(i.GetConfigPath(), "audit.log")
+}
+
+// GetTimezone returns the name of the configured time zone, or an empty
+// string if the system time zone is used.
+func (i *Instance) GetTimezone() string {
+	return i.getString(Timezone)
+}
+
+// GetLocation returns the location of the configured time zone. Returns the
+// system time zone if no time zone is configured, or if the configured time
+// zone is invalid.
+func (i *Instance) GetLocation() *time.Location {
+	name := i.GetTimezone()
+	if name == "" {
+		return time.Local
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		logger.Warnf("invalid timezone %q, using system timezone: %v", name, err)
+		return time.Local
+	}
+
+	return loc
+}
+
+// ValidateTimezone returns an error if name is not empty and is not a
+// valid IANA time zone name.
+func ValidateTimezone(name string) error {
+	if name == "" {
+		return nil
+	}
+
+	if _, err := time.LoadLocation(name); err != nil {
+		return fmt.Errorf("invalid timezone %q: %w", name, err)
+	}
+
+	return nil
+}
+
 // Max allowed graphql upload size in megabytes
 func (i *Instance) GetMaxUploadSize() int64 {
 	i.RLock()
@@ -1140,6 +2180,48 @@ func (i *Instance) GetMaxUploadSize() int64 {
 	return ret << 20
 }
 
+// GetMaxRequestBodySize returns the maximum size in bytes of request bodies,
+// other than graphql uploads. Returns 0 if not limited.
+func (i *Instance) GetMaxRequestBodySize() int64 {
+	i.RLock()
+	defer i.RUnlock()
+	ret := int64(maxRequestBodySizeDefault)
+
+	v := i.viper(MaxRequestBodySize)
+	if v.IsSet(MaxRequestBodySize) {
+		ret = v.GetInt64(MaxRequestBodySize)
+	}
+
+	if ret < 0 {
+		ret = 0
+	}
+	return ret << 20
+}
+
+// GetMaxPageSize returns the maximum number of results returned by a page of
+// an API query.
+func (i *Instance) GetMaxPageSize() int {
+	i.RLock()
+	defer i.RUnlock()
+	ret := models.DefaultMaxPerPage
+
+	v := i.viper(MaxPageSize)
+	if v.IsSet(MaxPageSize) {
+		ret = v.GetInt(MaxPageSize)
+	}
+
+	if ret < 1 {
+		return models.DefaultMaxPerPage
+	}
+	return ret
+}
+
+// GetQueryStats returns true if the number and duration of database queries
+// are recorded.
+func (i *Instance) GetQueryStats() bool {
+	return i.getBool(QueryStats)
+}
+
 // ActivatePublicAccessTripwire sets the security_tripwire_accessed_from_public_internet
 // config field to the provided IP address to indicate that stash has been accessed
 // from this public IP without authentication.
@@ -1173,6 +2255,16 @@ func (i *Instance) Validate() error {
 	return nil
 }
 
+// ValidateFullHashMaxSize returns an error if the MD5 size limit is set while
+// video files are named by MD5.
+func (i *Instance) ValidateFullHashMaxSize() error {
+	if i.getInt(FullHashMaxSize) > 0 && i.GetVideoFileNamingAlgorithm() == models.HashAlgorithmMd5 {
+		return fmt.Errorf("%s cannot be set while %s is %s", FullHashMaxSize, VideoFileNamingAlgorithm, models.HashAlgorithmMd5)
+	}
+
+	return nil
+}
+
 func (i *Instance) SetChecksumDefaultValues(defaultAlgorithm models.HashAlgorithm, usingMD5 bool) {
 	i.Lock()
 	defer i.Unlock()
@@ -1195,7 +2287,25 @@ func (i *Instance) setDefaultValues(write bool) error {
 	i.main.SetDefault(Port, portDefault)
 
 	i.main.SetDefault(ParallelTasks, parallelTasksDefault)
+	i.main.SetDefault(AutoTagMatchWorkers, autoTagMatchWorkersDefault)
+	i.main.SetDefault(AutoTagWriteBatchSize, autoTagWriteBatchSizeDefault)
+	i.main.SetDefault(FFProbeTimeout, ffprobeTimeoutDefault)
+	i.main.SetDefault(StashPathCheckInterval, stashPathCheckIntervalDefault)
+	i.main.SetDefault(EncodeBenchmarkInterval, encodeBenchmarkIntervalDefault)
+	i.main.SetDefault(EncodeLatencyThreshold, encodeLatencyThresholdDefault)
+	i.main.SetDefault(TranscodeCacheSize, transcodeCacheSizeDefault)
+	i.main.SetDefault(TranscodeCleanupSchedule, transcodeCleanupScheduleDefault)
 	i.main.SetDefault(PreviewSegmentDuration, previewSegmentDurationDefault)
+	i.main.SetDefault(PreviewWidth, previewWidthDefault)
+	i.main.SetDefault(SpriteCount, spriteCountDefault)
+	i.main.SetDefault(SpriteColumns, spriteColumnsDefault)
+	i.main.SetDefault(SpriteWidth, spriteWidthDefault)
+	i.main.SetDefault(ContactSheetColumns, contactSheetColumnsDefault)
+	i.main.SetDefault(ContactSheetRows, contactSheetRowsDefault)
+	i.main.SetDefault(ContactSheetWidth, contactSheetWidthDefault)
+	i.main.SetDefault(ContactSheetHeader, contactSheetHeaderDefault)
+	i.main.SetDefault(ScreenshotPositionPercent, screenshotPositionPercentDefault)
+	i.main.SetDefault(ScreenshotFormat, screenshotFormatDefault)
 	i.main.SetDefault(PreviewSegments, previewSegmentsDefault)
 	i.main.SetDefault(PreviewExcludeStart, previewExcludeStartDefault)
 	i.main.SetDefault(PreviewExcludeEnd, previewExcludeEndDefault)
@@ -1203,6 +2313,13 @@ func (i *Instance) setDefaultValues(write bool) error {
 	i.main.SetDefault(SoundOnPreview, false)
 
 	i.main.SetDefault(WriteImageThumbnails, writeImageThumbnailsDefault)
+	i.main.SetDefault(ImageThumbnailMaxSize, imageThumbnailMaxSizeDefault)
+	i.main.SetDefault(ImageThumbnailCacheSize, imageThumbnailCacheSizeDefault)
+	i.main.SetDefault(ShutdownGracePeriod, shutdownGracePeriodDefault)
+	i.main.SetDefault(GenerateMaxRetries, generateMaxRetriesDefault)
+	i.main.SetDefault(GenerateRetryBackoff, generateRetryBackoffDefault)
+
+	i.main.SetDefault(OrganizeTemplate, organizeTemplateDefault)
 
 	i.main.SetDefault(Database, defaultDatabaseFilePath)
 
@@ -1220,7 +2337,7 @@ func (i *Instance) setDefaultValues(write bool) error {
 	i.main.SetDefault(ScrapersPath, defaultScrapersPath)
 	i.main.SetDefault(PluginsPath, defaultPluginsPath)
 	if write {
-		return i.main.WriteConfig()
+		return i.writeConfigIfWritable()
 	}
 
 	return nil
@@ -1248,7 +2365,7 @@ func (i *Instance) setExistingSystemDefaults() error {
 		}
 
 		if configDirtied {
-			return i.main.WriteConfig()
+			return i.writeConfigIfWritable()
 		}
 	}
 
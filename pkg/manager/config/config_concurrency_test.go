@@ -21,7 +21,6 @@ func TestConcurrentConfigAccess(t *testing.T) {
 				}
 
 				i.HasCredentials()
-				i.ValidateCredentials("", "")
 				i.GetCPUProfilePath()
 				i.GetConfigFile()
 				i.GetConfigPath()
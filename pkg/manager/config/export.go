@@ -0,0 +1,361 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
+)
+
+// ErrInvalidImport indicates that imported configuration could not be
+// applied.
+var ErrInvalidImport = errors.New("invalid configuration")
+
+// secretKeys are the keys of values that are removed from exported
+// configuration unless secrets are included.
+var secretKeys = []string{
+	Password,
+	ApiKey,
+	JWTSignKey,
+	SessionStoreKey,
+	TOTPSecret,
+	TOTPRecoveryCodes,
+	RemoteStashAPIKey,
+	WebDAVPassword,
+	HandyKey,
+}
+
+// localKeys are the keys of paths that belong to the instance, and are
+// neither exported nor imported, so that importing the configuration of
+// another instance does not change the database or generated files used.
+var localKeys = []string{
+	Database,
+	Generated,
+	Cache,
+	Metadata,
+}
+
+// Export returns the values set in the configuration file. Default values,
+// values set by flags or environment variables, and the paths of the
+// instance such as the database path are not included. Secrets such as the
+// password hash and API keys are removed unless includeSecrets is true.
+func (i *Instance) Export(includeSecrets bool) ([]byte, error) {
+	i.RLock()
+	settings, err := i.fileSettings()
+	i.RUnlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	deleteSettings(settings, localKeys)
+	if !includeSecrets {
+		redactSecrets(settings)
+	}
+
+	return yaml.Marshal(settings)
+}
+
+// Import applies configuration returned by Export. If overwrite is true,
+// imported values replace existing values, otherwise only values that are
+// not set are imported. Values missing from data, such as redacted
+// secrets, are not changed. The paths of the instance such as the database
+// path are not imported. The configuration file is replaced atomically,
+// and is not changed if the imported configuration is invalid. Default
+// values and overrides of the current configuration are kept.
+func (i *Instance) Import(data []byte, overwrite bool) error {
+	imported, err := readSettings(data)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	deleteSettings(imported, localKeys)
+	if len(imported) == 0 {
+		return fmt.Errorf("%w: no configuration values", ErrInvalidImport)
+	}
+
+	i.Lock()
+	defer i.Unlock()
+
+	settings, err := i.fileSettings()
+	if err != nil {
+		return err
+	}
+
+	mergeStashBoxAPIKeys(imported, settings)
+	mergeSettings(settings, imported, overwrite)
+
+	out, err := yaml.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	candidate := viper.New()
+	candidate.SetConfigType("yaml")
+	if err := candidate.ReadConfig(bytes.NewReader(out)); err != nil {
+		return err
+	}
+	keys := candidate.AllKeys()
+
+	// validate using the current values, including defaults, for values
+	// not set in the configuration file
+	for _, key := range i.main.AllKeys() {
+		candidate.SetDefault(key, i.main.Get(key))
+	}
+
+	if err := validateImport(candidate, i.overrides); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	configFile := i.main.ConfigFileUsed()
+	if configFile != "" {
+		if err := writeFileAtomic(configFile, out); err != nil {
+			if isReadOnlyError(err) {
+				i.readOnly = true
+				return fmt.Errorf("%w: %s: %v", ErrConfigReadOnly, configFile, err)
+			}
+			return err
+		}
+	}
+
+	for _, key := range keys {
+		i.main.Set(key, candidate.Get(key))
+	}
+
+	return nil
+}
+
+// fileSettings returns the current values of the keys set in the
+// configuration file, without defaults. Returns an empty map if the file
+// does not exist. Assumes read lock held.
+func (i *Instance) fileSettings() (map[string]interface{}, error) {
+	configFile := i.main.ConfigFileUsed()
+	if configFile == "" {
+		return make(map[string]interface{}), nil
+	}
+
+	data, err := os.ReadFile(configFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return make(map[string]interface{}), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fileConfig := viper.New()
+	fileConfig.SetConfigType("yaml")
+	if err := fileConfig.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	current := viper.New()
+	for _, key := range fileConfig.AllKeys() {
+		current.Set(key, i.main.Get(key))
+	}
+
+	return normalizeSettings(current.AllSettings())
+}
+
+func validateImport(v *viper.Viper, overrides *viper.Viper) error {
+	c := &Instance{
+		main:      v,
+		overrides: overrides,
+	}
+
+	if err := c.Validate(); err != nil {
+		return err
+	}
+
+	if tz := v.GetString(Timezone); tz != "" {
+		if err := ValidateTimezone(tz); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readSettings parses configuration file data into nested maps.
+func readSettings(data []byte) (map[string]interface{}, error) {
+	v := viper.New()
+	v.SetConfigType("yaml")
+	if err := v.ReadConfig(bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+
+	return v.AllSettings(), nil
+}
+
+// normalizeSettings returns a copy of settings as they would be read from
+// the configuration file. Values set in memory may be structs, which are
+// converted to maps.
+func normalizeSettings(settings map[string]interface{}) (map[string]interface{}, error) {
+	data, err := yaml.Marshal(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	return readSettings(data)
+}
+
+// mergeSettings copies the values in src to dest. Existing values are only
+// replaced if overwrite is true.
+func mergeSettings(dest map[string]interface{}, src map[string]interface{}, overwrite bool) {
+	for k, v := range src {
+		srcMap, srcIsMap := v.(map[string]interface{})
+		destMap, destIsMap := dest[k].(map[string]interface{})
+		if srcIsMap && destIsMap {
+			mergeSettings(destMap, srcMap, overwrite)
+			continue
+		}
+
+		if _, exists := dest[k]; exists && !overwrite {
+			continue
+		}
+		dest[k] = v
+	}
+}
+
+func redactSecrets(settings map[string]interface{}) {
+	deleteSettings(settings, secretKeys)
+
+	for _, box := range stashBoxSettings(settings) {
+		for k := range box {
+			if isStashBoxAPIKey(k) {
+				delete(box, k)
+			}
+		}
+	}
+}
+
+// deleteSettings deletes the values of dot-separated keys.
+func deleteSettings(settings map[string]interface{}, keys []string) {
+	for _, key := range keys {
+		deleteSetting(settings, key)
+	}
+}
+
+// deleteSetting deletes the value of a dot-separated key.
+func deleteSetting(settings map[string]interface{}, key string) {
+	parts := strings.Split(key, ".")
+
+	m := settings
+	for _, p := range parts[:len(parts)-1] {
+		next, ok := m[p].(map[string]interface{})
+		if !ok {
+			return
+		}
+		m = next
+	}
+
+	delete(m, parts[len(parts)-1])
+}
+
+// mergeStashBoxAPIKeys sets the API keys of imported stash-box entries
+// that have been redacted, using the key of the existing entry with the
+// same endpoint.
+func mergeStashBoxAPIKeys(imported map[string]interface{}, existing map[string]interface{}) {
+	existingKeys := make(map[string]interface{})
+	for _, box := range stashBoxSettings(existing) {
+		for k, v := range box {
+			if isStashBoxAPIKey(k) {
+				existingKeys[fmt.Sprint(box["endpoint"])] = v
+			}
+		}
+	}
+
+	for _, box := range stashBoxSettings(imported) {
+		hasKey := false
+		for k := range box {
+			if isStashBoxAPIKey(k) {
+				hasKey = true
+			}
+		}
+
+		if key, found := existingKeys[fmt.Sprint(box["endpoint"])]; !hasKey && found {
+			box["apikey"] = key
+		}
+	}
+}
+
+// stashBoxSettings returns the stash-box entries of settings, converting
+// the entries to string keyed maps in place.
+func stashBoxSettings(settings map[string]interface{}) []map[string]interface{} {
+	boxes, _ := settings[StashBoxes].([]interface{})
+
+	var ret []map[string]interface{}
+	for idx, b := range boxes {
+		var box map[string]interface{}
+		switch v := b.(type) {
+		case map[string]interface{}:
+			box = v
+		case map[interface{}]interface{}:
+			box = make(map[string]interface{})
+			for k, vv := range v {
+				box[fmt.Sprint(k)] = vv
+			}
+			boxes[idx] = box
+		default:
+			continue
+		}
+
+		ret = append(ret, box)
+	}
+
+	return ret
+}
+
+func isStashBoxAPIKey(k string) bool {
+	return strings.EqualFold(strings.ReplaceAll(k, "_", ""), "apikey")
+}
+
+// writeFileAtomic replaces the file at path with data, so that the file is
+// never partially written. The permissions of an existing file are kept.
+func writeFileAtomic(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Chmod(tmp, mode); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
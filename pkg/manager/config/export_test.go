@@ -0,0 +1,261 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func newExportTestInstance(t *testing.T) *Instance {
+	t.Helper()
+
+	dir := t.TempDir()
+	i := newTestInstance(filepath.Join(dir, "config.yml"))
+	i.Set(Database, filepath.Join(dir, "stash-go.sqlite"))
+	i.Set(Generated, filepath.Join(dir, "generated"))
+	i.Set(Host, "127.0.0.1")
+	i.Set(Port, 9998)
+	i.Set(Username, "user")
+	i.Set(Password, "password hash")
+	i.Set(ApiKey, "api key")
+	i.Set(JWTSignKey, "jwt key")
+	i.Set(SessionStoreKey, "session key")
+	i.Set(TOTPSecret, "totp secret")
+	i.Set(TOTPRecoveryCodes, []string{"recovery code"})
+	i.Set(HandyKey, "handy key")
+	i.Set(StashBoxes, []*models.StashBoxInput{
+		{Endpoint: "https://stashdb.org/graphql", APIKey: "stash-box key", Name: "stashdb"},
+	})
+
+	if err := i.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	return i
+}
+
+func TestExportImportWithSecrets(t *testing.T) {
+	src := newExportTestInstance(t)
+
+	data, err := src.Export(true)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	dest := newTestInstance(filepath.Join(dir, "config.yml"))
+	dest.Set(Database, filepath.Join(dir, "stash-go.sqlite"))
+	dest.Set(Generated, filepath.Join(dir, "generated"))
+	if err := dest.Import(data, true); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	for _, key := range []string{Host, Username, Password, ApiKey, JWTSignKey, SessionStoreKey, TOTPSecret, HandyKey} {
+		if got, want := dest.getString(key), src.getString(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if got := dest.GetPort(); got != 9998 {
+		t.Errorf("port = %d, want 9998", got)
+	}
+	if got := dest.GetTOTPRecoveryCodes(); len(got) != 1 || got[0] != "recovery code" {
+		t.Errorf("recovery codes = %v", got)
+	}
+
+	boxes := dest.GetStashBoxes()
+	if len(boxes) != 1 || boxes[0].APIKey != "stash-box key" || boxes[0].Name != "stashdb" {
+		t.Errorf("stash boxes = %+v", boxes)
+	}
+
+	// the imported configuration is written
+	written := newTestInstance(dest.GetConfigFile())
+	if err := written.main.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if got := written.GetAPIKey(); got != "api key" {
+		t.Errorf("written api key = %q, want %q", got, "api key")
+	}
+}
+
+func TestExportImportWithoutSecrets(t *testing.T) {
+	src := newExportTestInstance(t)
+
+	data, err := src.Export(false)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	for _, secret := range []string{"password hash", "api key", "jwt key", "session key", "totp secret", "recovery code", "handy key", "stash-box key"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("export contains secret %q", secret)
+		}
+	}
+
+	// import into an instance with its own secrets
+	dest := newExportTestInstance(t)
+	dest.Set(Host, "0.0.0.0")
+	dest.Set(Password, "dest password hash")
+	dest.Set(ApiKey, "dest api key")
+	dest.Set(StashBoxes, []*models.StashBoxInput{
+		{Endpoint: "https://stashdb.org/graphql", APIKey: "dest stash-box key", Name: "old name"},
+	})
+
+	if err := dest.Import(data, true); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	if got := dest.GetHost(); got != "127.0.0.1" {
+		t.Errorf("host = %q, want imported value", got)
+	}
+	if got := dest.GetUsername(); got != "user" {
+		t.Errorf("username = %q, want user", got)
+	}
+
+	// redacted secrets are not changed
+	if got := dest.GetPasswordHash(); got != "dest password hash" {
+		t.Errorf("password = %q, want existing value", got)
+	}
+	if got := dest.GetAPIKey(); got != "dest api key" {
+		t.Errorf("api key = %q, want existing value", got)
+	}
+
+	boxes := dest.GetStashBoxes()
+	if len(boxes) != 1 || boxes[0].Name != "stashdb" || boxes[0].APIKey != "dest stash-box key" {
+		t.Errorf("stash boxes = %+v, want imported name with existing key", boxes)
+	}
+}
+
+func TestExportImportDefaults(t *testing.T) {
+	src := newExportTestInstance(t)
+	if err := src.setDefaultValues(false); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := src.Export(true)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	// defaults are not exported
+	for _, key := range []string{ParallelTasks, PreviewSegments, ScrapersPath} {
+		if strings.Contains(string(data), key+":") {
+			t.Errorf("export contains default value of %s", key)
+		}
+	}
+
+	dest := newExportTestInstance(t)
+	if err := dest.setDefaultValues(false); err != nil {
+		t.Fatal(err)
+	}
+	dest.overrides.Set(Port, 9000)
+	scrapersPath := dest.GetScrapersPath()
+
+	if err := dest.Import(data, true); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	// defaults and overrides are kept after import
+	if got := dest.GetParallelTasks(); got != parallelTasksDefault {
+		t.Errorf("parallel tasks = %d, want default %d", got, parallelTasksDefault)
+	}
+	if got := dest.GetScrapersPath(); got != scrapersPath {
+		t.Errorf("scrapers path = %q, want default %q", got, scrapersPath)
+	}
+	if got := dest.GetPort(); got != 9000 {
+		t.Errorf("port = %d, want override 9000", got)
+	}
+	if got := dest.GetUsername(); got != "user" {
+		t.Errorf("username = %q, want imported value", got)
+	}
+}
+
+func TestImportWithoutOverwrite(t *testing.T) {
+	i := newExportTestInstance(t)
+
+	if err := i.Import([]byte("host: 0.0.0.0\nlogFile: stash.log\n"), false); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	if got := i.GetHost(); got != "127.0.0.1" {
+		t.Errorf("host = %q, want existing value", got)
+	}
+	if got := i.GetLogFile(); got != "stash.log" {
+		t.Errorf("log file = %q, want imported value", got)
+	}
+}
+
+func TestImportInstancePaths(t *testing.T) {
+	src := newExportTestInstance(t)
+	src.Set(Cache, "/other/cache")
+	src.Set(Metadata, "/other/metadata")
+
+	data, err := src.Export(true)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	// the paths of the instance are not exported
+	for _, key := range []string{Database, Generated, Cache, Metadata} {
+		if strings.Contains(string(data), key+":") {
+			t.Errorf("export contains %s", key)
+		}
+	}
+
+	dest := newExportTestInstance(t)
+	database := dest.GetDatabasePath()
+	generated := dest.GetGeneratedPath()
+
+	// nor are they imported, even if present
+	data = append(data, []byte("database: /other/stash-go.sqlite\ngenerated: /other/generated\n")...)
+	if err := dest.Import(data, true); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	if got := dest.GetDatabasePath(); got != database {
+		t.Errorf("database = %q, want existing value %q", got, database)
+	}
+	if got := dest.GetGeneratedPath(); got != generated {
+		t.Errorf("generated = %q, want existing value %q", got, generated)
+	}
+	if got := dest.GetCachePath(); got != "" {
+		t.Errorf("cache = %q, want unset", got)
+	}
+}
+
+func TestImportInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"not yaml", "host: [\n"},
+		{"empty", ""},
+		{"invalid timezone", "timezone: Not/AZone\n"},
+		{"only instance paths", "database: /other/stash-go.sqlite\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := newExportTestInstance(t)
+			before, err := os.ReadFile(i.GetConfigFile())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := i.Import([]byte(tt.data), true); !errors.Is(err, ErrInvalidImport) {
+				t.Errorf("Import() error = %v, want %v", err, ErrInvalidImport)
+			}
+
+			after, _ := os.ReadFile(i.GetConfigFile())
+			if string(after) != string(before) {
+				t.Error("configuration file changed after invalid import")
+			}
+			if got := i.GetHost(); got != "127.0.0.1" {
+				t.Errorf("host = %q after invalid import", got)
+			}
+		})
+	}
+}
@@ -52,13 +52,14 @@ func Initialize() (*Instance, error) {
 		}
 
 		if instance.isNewSystem {
-			if instance.Validate() == nil {
-				// system has been initialised by the environment
-				instance.isNewSystem = false
+			if err = instance.checkNewSystem(); err != nil {
+				return
 			}
 		}
 
 		if !instance.isNewSystem {
+			instance.refreshReadOnly()
+
 			err = instance.setExistingSystemDefaults()
 			if err == nil {
 				err = instance.SetInitialConfig()
@@ -68,6 +69,25 @@ func Initialize() (*Instance, error) {
 	return instance, err
 }
 
+// checkNewSystem determines whether a system without a config file is a
+// new system. The system is an existing system if the mandatory settings
+// are provided by the environment. If SkipSetup is set, the system is never
+// a new system, and an error is returned if mandatory settings are missing.
+func (i *Instance) checkNewSystem() error {
+	err := i.Validate()
+	if err == nil {
+		// system has been initialised by the environment
+		i.isNewSystem = false
+		return nil
+	}
+
+	if i.GetSkipSetup() {
+		return fmt.Errorf("%s is set but the configuration is incomplete: %w", SkipSetup, err)
+	}
+
+	return nil
+}
+
 func initConfig(instance *Instance, flags flagStruct) error {
 	v := instance.main
 
@@ -141,6 +161,9 @@ func initEnvs(viper *viper.Viper) {
 	bindEnv(viper, "metadata")      // STASH_METADATA
 	bindEnv(viper, "cache")         // STASH_CACHE
 	bindEnv(viper, "stash")         // STASH_STASH
+	bindEnv(viper, "database")      // STASH_DATABASE
+	bindEnv(viper, SkipSetup)       // STASH_SKIP_SETUP
+	bindEnv(viper, AutoMigrate)     // STASH_AUTO_MIGRATE
 }
 
 func bindEnv(viper *viper.Viper, key string) {
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func newEnvTestInstance() *Instance {
+	i := &Instance{
+		main:        viper.New(),
+		overrides:   viper.New(),
+		isNewSystem: true,
+	}
+	initEnvs(i.overrides)
+	return i
+}
+
+func TestCheckNewSystem(t *testing.T) {
+	tests := []struct {
+		name        string
+		env         map[string]string
+		wantNew     bool
+		wantMissing bool
+	}{
+		{
+			"new system",
+			map[string]string{},
+			true,
+			false,
+		},
+		{
+			"complete environment",
+			map[string]string{
+				"STASH_DATABASE":  "/data/stash.sqlite",
+				"STASH_GENERATED": "/data/generated",
+			},
+			false,
+			false,
+		},
+		{
+			"skip setup with complete environment",
+			map[string]string{
+				"STASH_SKIP_SETUP": "true",
+				"STASH_DATABASE":   "/data/stash.sqlite",
+				"STASH_GENERATED":  "/data/generated",
+			},
+			false,
+			false,
+		},
+		{
+			"skip setup with incomplete environment",
+			map[string]string{
+				"STASH_SKIP_SETUP": "true",
+				"STASH_DATABASE":   "/data/stash.sqlite",
+			},
+			true,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			i := newEnvTestInstance()
+			err := i.checkNewSystem()
+
+			var missing MissingConfigError
+			assert.Equal(t, tt.wantMissing, errors.As(err, &missing))
+			if !tt.wantMissing {
+				assert.Nil(t, err)
+			} else {
+				assert.Equal(t, []string{Generated}, missing.missingFields)
+			}
+			assert.Equal(t, tt.wantNew, i.IsNewSystem())
+		})
+	}
+}
+
+func TestAutoMigrateEnv(t *testing.T) {
+	i := newEnvTestInstance()
+	assert.False(t, i.GetAutoMigrate())
+
+	t.Setenv("STASH_AUTO_MIGRATE", "true")
+	i = newEnvTestInstance()
+	assert.True(t, i.GetAutoMigrate())
+}
+
+func TestValidateFullHashMaxSize(t *testing.T) {
+	i := newEnvTestInstance()
+	i.Set(Database, "/data/stash.sqlite")
+	i.Set(Generated, "/data/generated")
+	i.Set(FullHashMaxSize, 1024)
+
+	i.Set(VideoFileNamingAlgorithm, "OSHASH")
+	assert.Nil(t, i.Validate())
+	assert.Nil(t, i.ValidateFullHashMaxSize())
+
+	// the combination does not make the configuration incomplete
+	i.Set(VideoFileNamingAlgorithm, "MD5")
+	assert.Nil(t, i.Validate())
+	assert.NotNil(t, i.ValidateFullHashMaxSize())
+
+	i.Set(FullHashMaxSize, 0)
+	assert.Nil(t, i.Validate())
+	assert.Nil(t, i.ValidateFullHashMaxSize())
+}
@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestGetStashPathsMixedFormat(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yml")
+	data := `stash:
+  - /media/legacy
+  - path: /media/local
+    excludeimage: true
+    hashalgorithm: OSHASH
+    exclude:
+      - "*.part"
+      - "tmp/*"
+    scanschedule: "0 * * * *"
+  - path: /media/archive
+    scanschedule: "0 3 * * 0"
+    scansettings:
+      scangeneratepreviews: true
+      scangeneratesprites: true
+  - excludevideo: true
+`
+	if err := os.WriteFile(configFile, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	i := newTestInstance(configFile)
+	if err := i.main.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := i.GetStashPaths()
+	if len(got) != 3 {
+		t.Fatalf("GetStashPaths() returned %d entries, want 3 valid entries", len(got))
+	}
+
+	legacy := got[0]
+	if legacy.Path != "/media/legacy" || legacy.ExcludeImage || legacy.ScanSchedule != nil || legacy.Exclude != nil {
+		t.Errorf("legacy entry = %+v", legacy)
+	}
+
+	local := got[1]
+	if local.Path != "/media/local" || !local.ExcludeImage || local.ExcludeVideo {
+		t.Errorf("local entry = %+v", local)
+	}
+	if local.HashAlgorithm == nil || *local.HashAlgorithm != models.HashAlgorithmOshash {
+		t.Errorf("local hash algorithm = %v, want %s", local.HashAlgorithm, models.HashAlgorithmOshash)
+	}
+	if len(local.Exclude) != 2 || local.Exclude[0] != "*.part" || local.Exclude[1] != "tmp/*" {
+		t.Errorf("local exclude = %v", local.Exclude)
+	}
+	if local.ScanSchedule == nil || *local.ScanSchedule != "0 * * * *" {
+		t.Errorf("local scan schedule = %v", local.ScanSchedule)
+	}
+
+	archive := got[2]
+	if archive.ScanSettings == nil || !archive.ScanSettings.ScanGeneratePreviews || !archive.ScanSettings.ScanGenerateSprites || archive.ScanSettings.ScanGeneratePhashes {
+		t.Errorf("archive scan settings = %+v", archive.ScanSettings)
+	}
+}
+
+func TestGetStashPathsLegacyFormat(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(configFile, []byte("stash:\n  - /media/a\n  - /media/b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	i := newTestInstance(configFile)
+	if err := i.main.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+
+	got := i.GetStashPaths()
+	if len(got) != 2 || got[0].Path != "/media/a" || got[1].Path != "/media/b" {
+		t.Errorf("GetStashPaths() = %+v", got)
+	}
+}
+
+func TestGetStashPathsRoundTrip(t *testing.T) {
+	configFile := filepath.Join(t.TempDir(), "config.yml")
+	i := newTestInstance(configFile)
+
+	schedule := "30 2 * * *"
+	i.Set(Stash, []*models.StashConfigInput{
+		{Path: "/media/local", Exclude: []string{"*.part"}},
+		{Path: "/media/archive", ScanSchedule: &schedule, ScanSettings: &models.ScanMetadataInput{
+			ScanGeneratePhashes: &[]bool{true}[0],
+		}},
+	})
+
+	check := func(got []*models.StashConfig) {
+		t.Helper()
+		if len(got) != 2 {
+			t.Fatalf("GetStashPaths() returned %d entries, want 2", len(got))
+		}
+		if len(got[0].Exclude) != 1 || got[0].Exclude[0] != "*.part" {
+			t.Errorf("exclude = %v", got[0].Exclude)
+		}
+		if got[1].ScanSchedule == nil || *got[1].ScanSchedule != schedule {
+			t.Errorf("scan schedule = %v", got[1].ScanSchedule)
+		}
+		if got[1].ScanSettings == nil || !got[1].ScanSettings.ScanGeneratePhashes {
+			t.Errorf("scan settings = %+v", got[1].ScanSettings)
+		}
+	}
+
+	check(i.GetStashPaths())
+
+	if err := i.Write(); err != nil {
+		t.Fatal(err)
+	}
+
+	written := newTestInstance(configFile)
+	if err := written.main.ReadInConfig(); err != nil {
+		t.Fatal(err)
+	}
+	check(written.GetStashPaths())
+}
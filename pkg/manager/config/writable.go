@@ -0,0 +1,143 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+// ErrConfigReadOnly is returned when the configuration file cannot be
+// written due to its permissions, or because it is on a read-only file
+// system.
+var ErrConfigReadOnly = errors.New("configuration file is read-only")
+
+func isReadOnlyError(err error) bool {
+	return errors.Is(err, os.ErrPermission) || errors.Is(err, syscall.EROFS)
+}
+
+// CheckWritable returns an error wrapping ErrConfigReadOnly if the
+// configuration file at path cannot be written. If the file does not exist,
+// then the closest existing parent directory must allow files to be
+// created.
+func CheckWritable(path string) error {
+	err := checkWritable(path)
+	if isReadOnlyError(err) {
+		return fmt.Errorf("%w: %s: %v", ErrConfigReadOnly, path, err)
+	}
+
+	return err
+}
+
+func checkWritable(path string) error {
+	info, err := os.Stat(path)
+	if err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("%s is a directory", path)
+		}
+
+		// opening the file for writing does not modify it
+		f, err := os.OpenFile(path, os.O_WRONLY, 0)
+		if err != nil {
+			return err
+		}
+		return f.Close()
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	// find the directory in which the file, or its missing parent
+	// directories, would be created
+	dir := filepath.Dir(path)
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			break
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	f, err := os.CreateTemp(dir, ".stash-write-test-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
+
+// IsReadOnly returns true if the configuration file could not be written
+// when the configuration was loaded or last written. Changes to the
+// configuration are not persisted while the configuration is read-only.
+func (i *Instance) IsReadOnly() bool {
+	i.RLock()
+	defer i.RUnlock()
+	return i.readOnly
+}
+
+// refreshReadOnly checks whether the configuration file can be written.
+func (i *Instance) refreshReadOnly() {
+	configFile := i.GetConfigFile()
+	if configFile == "" {
+		return
+	}
+
+	err := CheckWritable(configFile)
+
+	i.Lock()
+	defer i.Unlock()
+	i.readOnly = errors.Is(err, ErrConfigReadOnly)
+	if i.readOnly {
+		logger.Warnf("Configuration file is read-only. Configuration changes will not be saved: %v", err)
+	}
+}
+
+// writeConfig writes the configuration file. If the file cannot be written
+// due to its permissions, then the configuration is marked as read-only and
+// an error wrapping ErrConfigReadOnly is returned. The write is not
+// attempted again until the file is found to be writable. The lock must be
+// held.
+func (i *Instance) writeConfig() error {
+	configFile := i.main.ConfigFileUsed()
+
+	if i.readOnly {
+		// the permissions may have been fixed since the last attempt
+		if err := CheckWritable(configFile); err != nil {
+			return err
+		}
+		i.readOnly = false
+	}
+
+	err := i.main.WriteConfig()
+	if isReadOnlyError(err) {
+		i.readOnly = true
+		return fmt.Errorf("%w: %s: %v", ErrConfigReadOnly, configFile, err)
+	}
+
+	return err
+}
+
+// writeConfigIfWritable writes the configuration file, logging a warning
+// instead of returning an error if the file is read-only. This is used for
+// writes that are not requested by the user, so that a read-only
+// deployment is able to run. The lock must be held.
+func (i *Instance) writeConfigIfWritable() error {
+	err := i.writeConfig()
+	if errors.Is(err, ErrConfigReadOnly) {
+		logger.Warnf("Not writing configuration: %v", err)
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+// makeReadOnly removes write permissions from path, skipping the test if
+// the permissions are not enforced, such as when running as root.
+func makeReadOnly(t *testing.T, path string, mode os.FileMode) {
+	t.Helper()
+
+	if runtime.GOOS == "windows" {
+		t.Skip("directory permissions are not enforced on windows")
+	}
+
+	if err := os.Chmod(path, mode); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = os.Chmod(path, 0755)
+	})
+
+	checkPath := path
+	if mode.IsDir() || mode&0111 != 0 {
+		checkPath = filepath.Join(path, "test")
+	}
+	if err := checkWritable(checkPath); err == nil {
+		t.Skip("file permissions are not enforced for the current user")
+	}
+}
+
+func writeConfigFile(t *testing.T, path string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte("host: 0.0.0.0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestInstance(configFile string) *Instance {
+	i := &Instance{
+		main:      viper.New(),
+		overrides: viper.New(),
+	}
+	i.main.SetConfigFile(configFile)
+	return i
+}
+
+func TestCheckWritable(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "config.yml")
+	writeConfigFile(t, existing)
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"existing file", existing},
+		{"missing file", filepath.Join(dir, "new.yml")},
+		{"missing directory", filepath.Join(dir, "a", "b", "config.yml")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckWritable(tt.path); err != nil {
+				t.Errorf("CheckWritable() error = %v", err)
+			}
+		})
+	}
+
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("CheckWritable() left %d entries in directory, want 1", len(entries))
+	}
+}
+
+func TestCheckWritableReadOnlyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	writeConfigFile(t, path)
+	makeReadOnly(t, path, 0444)
+
+	if err := CheckWritable(path); !errors.Is(err, ErrConfigReadOnly) {
+		t.Errorf("CheckWritable() error = %v, want %v", err, ErrConfigReadOnly)
+	}
+}
+
+func TestCheckWritableReadOnlyDirectory(t *testing.T) {
+	dir := t.TempDir()
+	makeReadOnly(t, dir, 0555)
+
+	for _, path := range []string{
+		filepath.Join(dir, "config.yml"),
+		filepath.Join(dir, "missing", "config.yml"),
+	} {
+		if err := CheckWritable(path); !errors.Is(err, ErrConfigReadOnly) {
+			t.Errorf("CheckWritable(%s) error = %v, want %v", path, err, ErrConfigReadOnly)
+		}
+	}
+}
+
+func TestWriteReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	writeConfigFile(t, path)
+
+	i := newTestInstance(path)
+	i.refreshReadOnly()
+	if i.IsReadOnly() {
+		t.Fatal("IsReadOnly() = true for writable file")
+	}
+
+	makeReadOnly(t, path, 0444)
+
+	i.Set(Port, 1234)
+	if err := i.Write(); !errors.Is(err, ErrConfigReadOnly) {
+		t.Errorf("Write() error = %v, want %v", err, ErrConfigReadOnly)
+	}
+	if !i.IsReadOnly() {
+		t.Error("IsReadOnly() = false after failed write")
+	}
+
+	// the value is retained in memory
+	if got := i.GetPort(); got != 1234 {
+		t.Errorf("GetPort() = %d, want 1234", got)
+	}
+
+	// writes not requested by the user do not fail
+	if err := i.SetInitialConfig(); err != nil {
+		t.Errorf("SetInitialConfig() error = %v", err)
+	}
+
+	// writing succeeds once the permissions are fixed
+	if err := os.Chmod(path, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Write(); err != nil {
+		t.Errorf("Write() error = %v", err)
+	}
+	if i.IsReadOnly() {
+		t.Error("IsReadOnly() = true after successful write")
+	}
+}
+
+func TestRefreshReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	writeConfigFile(t, path)
+	makeReadOnly(t, path, 0444)
+
+	i := newTestInstance(path)
+	i.refreshReadOnly()
+	if !i.IsReadOnly() {
+		t.Error("IsReadOnly() = false for read-only file")
+	}
+}
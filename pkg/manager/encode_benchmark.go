@@ -0,0 +1,176 @@
+package manager
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// encodeLatencyWindow is the number of benchmark samples averaged.
+const encodeLatencyWindow = 10
+
+// EncodeBenchmark periodically times the encoding of a sample video, so that
+// slowdowns of the encoder, such as from thermal throttling, are detected.
+// The encoder is degraded when the average of the recent samples exceeds the
+// threshold. A nil EncodeBenchmark has no samples.
+type EncodeBenchmark struct {
+	mutex      sync.RWMutex
+	samples    []time.Duration
+	measuredAt time.Time
+	threshold  time.Duration
+	degraded   bool
+
+	encode func() error
+
+	stopMutex sync.Mutex
+	stop      chan struct{}
+}
+
+// NewEncodeBenchmark returns a benchmark timing encode. Benchmarks are
+// skipped while encode returns errEncoderMissing.
+func NewEncodeBenchmark(encode func() error) *EncodeBenchmark {
+	return &EncodeBenchmark{
+		encode: encode,
+	}
+}
+
+// Run encodes the sample video and records the time taken.
+func (b *EncodeBenchmark) Run() {
+	if b == nil {
+		return
+	}
+
+	start := time.Now()
+	err := b.encode()
+	if errors.Is(err, errEncoderMissing) {
+		return
+	}
+	if err != nil {
+		logger.Warnf("Encode benchmark failed: %v", err)
+		return
+	}
+
+	b.record(time.Since(start), time.Now())
+}
+
+func (b *EncodeBenchmark) record(d time.Duration, at time.Time) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.samples = append(b.samples, d)
+	if len(b.samples) > encodeLatencyWindow {
+		b.samples = b.samples[len(b.samples)-encodeLatencyWindow:]
+	}
+	b.measuredAt = at
+
+	b.updateDegraded()
+}
+
+// updateDegraded sets the degraded flag from the recorded samples, logging
+// when it changes. The mutex must be held.
+func (b *EncodeBenchmark) updateDegraded() {
+	average := b.average()
+	degraded := b.threshold > 0 && len(b.samples) > 0 && average > b.threshold
+
+	switch {
+	case degraded && !b.degraded:
+		logger.Warnf("Encoding is slower than expected: sample took %v on average, threshold is %v", average, b.threshold)
+	case !degraded && b.degraded:
+		logger.Infof("Encoding speed has recovered: sample took %v on average", average)
+	}
+
+	b.degraded = degraded
+}
+
+func (b *EncodeBenchmark) average() time.Duration {
+	if len(b.samples) == 0 {
+		return 0
+	}
+
+	var total time.Duration
+	for _, d := range b.samples {
+		total += d
+	}
+	return total / time.Duration(len(b.samples))
+}
+
+// SetThreshold sets the average sample time above which the encoder is
+// degraded. The encoder is never degraded if threshold is zero.
+func (b *EncodeBenchmark) SetThreshold(threshold time.Duration) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.threshold = threshold
+	b.updateDegraded()
+}
+
+// Latency returns the recorded encode latency, or nil if no benchmark has
+// been recorded.
+func (b *EncodeBenchmark) Latency() *models.EncodeLatency {
+	if b == nil {
+		return nil
+	}
+
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if len(b.samples) == 0 {
+		return nil
+	}
+
+	return &models.EncodeLatency{
+		Latest:     durationMilliseconds(b.samples[len(b.samples)-1]),
+		Average:    durationMilliseconds(b.average()),
+		Samples:    len(b.samples),
+		Threshold:  durationMilliseconds(b.threshold),
+		Degraded:   b.degraded,
+		MeasuredAt: b.measuredAt,
+	}
+}
+
+func durationMilliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// SetInterval sets the interval at which the benchmark is run, restarting
+// the periodic benchmarks. Periodic benchmarks are stopped if interval is
+// zero.
+func (b *EncodeBenchmark) SetInterval(interval time.Duration) {
+	b.stopMutex.Lock()
+	defer b.stopMutex.Unlock()
+
+	if b.stop != nil {
+		close(b.stop)
+		b.stop = nil
+	}
+
+	if interval <= 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	b.stop = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		b.Run()
+		for {
+			select {
+			case <-ticker.C:
+				b.Run()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic benchmarks.
+func (b *EncodeBenchmark) Stop() {
+	b.SetInterval(0)
+}
@@ -0,0 +1,84 @@
+package manager
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeBenchmarkRun(t *testing.T) {
+	const encodeTime = 10 * time.Millisecond
+
+	runs := 0
+	b := NewEncodeBenchmark(func() error {
+		runs++
+		time.Sleep(encodeTime)
+		return nil
+	})
+
+	assert.Nil(t, b.Latency())
+
+	b.Run()
+	latency := b.Latency()
+	if assert.NotNil(t, latency) {
+		assert.Equal(t, 1, latency.Samples)
+		assert.GreaterOrEqual(t, latency.Latest, durationMilliseconds(encodeTime))
+		assert.False(t, latency.MeasuredAt.IsZero())
+	}
+
+	b.Run()
+	assert.Equal(t, 2, runs)
+	assert.Equal(t, 2, b.Latency().Samples)
+}
+
+func TestEncodeBenchmarkSkipped(t *testing.T) {
+	missing := NewEncodeBenchmark(func() error {
+		return errEncoderMissing
+	})
+	missing.Run()
+	assert.Nil(t, missing.Latency())
+
+	failing := NewEncodeBenchmark(func() error {
+		return errors.New("encode failed")
+	})
+	failing.Run()
+	assert.Nil(t, failing.Latency())
+}
+
+func TestEncodeBenchmarkDegraded(t *testing.T) {
+	b := NewEncodeBenchmark(nil)
+	b.SetThreshold(100 * time.Millisecond)
+	now := time.Now()
+
+	b.record(50*time.Millisecond, now)
+	assert.False(t, b.Latency().Degraded)
+
+	// the average of 50ms and 200ms exceeds the threshold
+	b.record(200*time.Millisecond, now)
+	latency := b.Latency()
+	assert.True(t, latency.Degraded)
+	assert.Equal(t, float64(200), latency.Latest)
+	assert.Equal(t, float64(125), latency.Average)
+
+	// raising the threshold clears the flag
+	b.SetThreshold(time.Second)
+	assert.False(t, b.Latency().Degraded)
+
+	// fast samples push the slow ones out of the window
+	b.SetThreshold(100 * time.Millisecond)
+	assert.True(t, b.Latency().Degraded)
+	for i := 0; i < encodeLatencyWindow; i++ {
+		b.record(10*time.Millisecond, now)
+	}
+	latency = b.Latency()
+	assert.False(t, latency.Degraded)
+	assert.Equal(t, encodeLatencyWindow, latency.Samples)
+	assert.Equal(t, float64(10), latency.Average)
+
+	// a zero threshold never degrades
+	b.SetThreshold(0)
+	b.record(time.Hour, now)
+	assert.False(t, b.Latency().Degraded)
+}
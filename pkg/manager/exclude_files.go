@@ -1,10 +1,14 @@
 package manager
 
 import (
+	"fmt"
+	"path"
+	"path/filepath"
 	"regexp"
 	"strings"
 
 	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
 )
 
 func excludeFiles(files []string, patterns []string) ([]string, int) {
@@ -84,3 +88,49 @@ func matchFileSimple(file string, regExps []*regexp.Regexp) bool {
 	}
 	return false
 }
+
+// matchStashExclude returns true if the file or directory, or a directory
+// containing it, matches an exclude glob pattern of the library. Patterns
+// containing a separator are matched against the path relative to the
+// library, other patterns are matched against the file or directory name.
+func matchStashExclude(stash *models.StashConfig, p string) bool {
+	if stash == nil || len(stash.Exclude) == 0 {
+		return false
+	}
+
+	rel, err := filepath.Rel(stash.Path, p)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for _, pattern := range stash.Exclude {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		matchName := !strings.Contains(pattern, "/")
+
+		for i := range parts {
+			name := parts[i]
+			if !matchName {
+				name = strings.Join(parts[:i+1], "/")
+			}
+
+			if matched, _ := path.Match(pattern, name); matched {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// ValidateStashExclude returns an error if an exclude glob pattern is
+// invalid.
+func ValidateStashExclude(patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(filepath.ToSlash(pattern), ""); err != nil {
+			return fmt.Errorf("invalid exclude pattern %q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
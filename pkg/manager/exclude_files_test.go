@@ -2,9 +2,11 @@ package manager
 
 import (
 	"fmt"
+	"path/filepath"
 	"testing"
 
 	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
 )
 
 var excludeTestFilenames = []string{
@@ -90,3 +92,36 @@ func runMatch(filenames []string, patterns []string, expCount int) error {
 
 	return nil
 }
+
+func TestMatchStashExclude(t *testing.T) {
+	root := filepath.Join("stash", "videos")
+	stash := &models.StashConfig{
+		Path:    root,
+		Exclude: []string{"*.part", ".trash", "private/*"},
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{filepath.Join(root, "file.mp4"), false},
+		{filepath.Join(root, "file.part"), true},
+		{filepath.Join(root, "sub", "file.part"), true},
+		{filepath.Join(root, ".trash"), true},
+		{filepath.Join(root, "sub", ".trash", "file.mp4"), true},
+		{filepath.Join(root, "private", "file.mp4"), true},
+		{filepath.Join(root, "sub", "private", "file.mp4"), false},
+		{filepath.Join("other", "file.part"), false},
+		{root, false},
+	}
+
+	for _, tt := range tests {
+		if got := matchStashExclude(stash, tt.path); got != tt.want {
+			t.Errorf("matchStashExclude(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	if err := ValidateStashExclude([]string{"[a-"}); err == nil {
+		t.Error("ValidateStashExclude() expected error for invalid pattern")
+	}
+}
@@ -0,0 +1,137 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/paths"
+)
+
+// ffmpegSearchPaths returns the directories searched for the ffmpeg and
+// ffprobe binaries, in order of preference.
+func ffmpegSearchPaths(configDirectory string) []string {
+	return []string{
+		configDirectory,
+		paths.GetStashHomeDirectory(),
+	}
+}
+
+// FFMPEG returns the ffmpeg encoder. The encoder is replaced when the
+// binaries are refreshed, so it should not be stored for longer than a
+// single operation.
+func (s *singleton) FFMPEG() ffmpeg.Encoder {
+	s.ffmpegMutex.RLock()
+	defer s.ffmpegMutex.RUnlock()
+
+	return s.encoder
+}
+
+// FFProbe returns the ffprobe runner. The runner is replaced when the
+// binaries are refreshed, so it should not be stored for longer than a
+// single operation.
+func (s *singleton) FFProbe() ffmpeg.FFProbe {
+	s.ffmpegMutex.RLock()
+	defer s.ffmpegMutex.RUnlock()
+
+	return s.ffprobe
+}
+
+// FFMPEGCapabilities returns the capabilities of the ffmpeg encoder.
+// Returns nil if they are not known.
+func (s *singleton) FFMPEGCapabilities() *ffmpeg.Capabilities {
+	s.ffmpegMutex.RLock()
+	defer s.ffmpegMutex.RUnlock()
+
+	return s.ffmpegCapabilities
+}
+
+func (s *singleton) setFFMPEG(encoder ffmpeg.Encoder, ffprobe ffmpeg.FFProbe, capabilities *ffmpeg.Capabilities) {
+	s.ffmpegMutex.Lock()
+	defer s.ffmpegMutex.Unlock()
+
+	s.encoder = encoder
+	s.ffprobe = ffprobe
+	s.ffmpegCapabilities = capabilities
+}
+
+func (s *singleton) setFFProbeTimeout(timeout time.Duration) {
+	s.ffmpegMutex.Lock()
+	defer s.ffmpegMutex.Unlock()
+
+	s.ffprobe.Timeout = timeout
+}
+
+// RefreshFFMPEG locates the ffmpeg and ffprobe binaries again, so that
+// binaries added or replaced while stash is running are used without a
+// restart. The binaries in use are kept if the new ones cannot be found or
+// run. Running transcodes are not affected, and continue to use the
+// binaries they were started with.
+func (s *singleton) RefreshFFMPEG() error {
+	if s.Config.GetConfigFile() == "" {
+		return errors.New("cannot locate FFMPEG without a config file")
+	}
+
+	return s.refreshFFMPEG(ffmpegSearchPaths(s.Config.GetConfigPath()))
+}
+
+func (s *singleton) refreshFFMPEG(searchPaths []string) error {
+	ffmpegPath, ffprobePath := ffmpeg.GetPaths(searchPaths)
+	if ffmpegPath == "" || ffprobePath == "" {
+		return errors.New("could not find ffmpeg and ffprobe")
+	}
+
+	// probe the new binaries before replacing the current ones, so that a
+	// broken binary does not replace a working one
+	capabilities, err := ffmpeg.ProbeCapabilities(ffmpegPath)
+	if err != nil {
+		return fmt.Errorf("error probing ffmpeg: %w", err)
+	}
+	ffprobeVersion, err := ffmpeg.GetVersion(ffprobePath)
+	if err != nil {
+		return fmt.Errorf("error probing ffprobe: %w", err)
+	}
+
+	s.ffmpegMutex.Lock()
+	s.encoder = ffmpeg.Encoder(ffmpegPath)
+	s.ffprobe.Path = ffprobePath
+	s.ffmpegCapabilities = capabilities
+	s.ffmpegMutex.Unlock()
+
+	logger.Infof("Using ffmpeg %s at %s and ffprobe %s at %s", capabilities.Version, ffmpegPath, ffprobeVersion, ffprobePath)
+	return nil
+}
+
+// screenshotFormat returns the configured format of scene screenshots. JPEG
+// is returned with a warning if the format is not valid, or if ffmpeg cannot
+// encode it.
+func (s *singleton) screenshotFormat() ffmpeg.ImageFormat {
+	format, err := resolveImageFormat(s.Config.GetScreenshotFormat(), s.FFMPEGCapabilities())
+	if err != nil {
+		logger.Warnf("%v. Using %s screenshots instead.", err, format)
+	}
+
+	return format
+}
+
+// resolveImageFormat returns the image format named by format if it can be
+// encoded by an ffmpeg with the capabilities. Otherwise, JPEG is returned
+// with an error describing why the format cannot be used.
+func resolveImageFormat(format string, capabilities *ffmpeg.Capabilities) (ffmpeg.ImageFormat, error) {
+	ret := ffmpeg.ImageFormat(strings.ToLower(format))
+	switch {
+	case ret == ffmpeg.ImageFormatJpeg:
+		return ret, nil
+	case !ret.IsValid():
+		return ffmpeg.ImageFormatJpeg, fmt.Errorf("invalid image format %q", format)
+	case capabilities == nil:
+		return ffmpeg.ImageFormatJpeg, fmt.Errorf("ffmpeg capabilities are unknown, cannot encode %s images", ret)
+	case !capabilities.SupportsImageFormat(ret):
+		return ffmpeg.ImageFormatJpeg, fmt.Errorf("ffmpeg %s cannot encode %s images", capabilities.Version, ret)
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,127 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeStubFFMPEG writes ffmpeg and ffprobe shell scripts reporting version
+// to dir.
+func writeStubFFMPEG(t *testing.T, dir string, version string) {
+	t.Helper()
+
+	for _, name := range []string{"ffmpeg", "ffprobe"} {
+		script := "#!/bin/sh\necho \"" + name + " version " + version + " Copyright (c)\"\n"
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(script), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRefreshFFMPEG(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("stub binaries require a POSIX shell")
+	}
+
+	// prevent binaries in the PATH from being used
+	t.Setenv("PATH", "")
+
+	configDir := t.TempDir()
+	homeDir := t.TempDir()
+	searchPaths := []string{configDir, homeDir}
+
+	writeStubFFMPEG(t, homeDir, "4.4")
+
+	s := &singleton{}
+	s.setFFMPEG("", ffmpeg.FFProbe{Timeout: time.Minute}, nil)
+	if err := s.refreshFFMPEG(searchPaths); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ffmpeg.Encoder(filepath.Join(homeDir, "ffmpeg")), s.FFMPEG())
+	assert.Equal(t, filepath.Join(homeDir, "ffprobe"), s.FFProbe().Path)
+
+	// an encoder held by a running transcode is unaffected by the swap
+	running := s.FFMPEG()
+
+	// newer binaries in the config directory are preferred
+	writeStubFFMPEG(t, configDir, "5.0")
+	if err := s.refreshFFMPEG(searchPaths); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, ffmpeg.Encoder(filepath.Join(configDir, "ffmpeg")), s.FFMPEG())
+	assert.Equal(t, filepath.Join(configDir, "ffprobe"), s.FFProbe().Path)
+	assert.Equal(t, time.Minute, s.FFProbe().Timeout)
+	assert.Equal(t, ffmpeg.Encoder(filepath.Join(homeDir, "ffmpeg")), running)
+}
+
+func TestRefreshFFMPEGKeepsWorkingBinaries(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("stub binaries require a POSIX shell")
+	}
+
+	t.Setenv("PATH", "")
+
+	configDir := t.TempDir()
+	homeDir := t.TempDir()
+	searchPaths := []string{configDir, homeDir}
+
+	writeStubFFMPEG(t, homeDir, "4.4")
+
+	s := &singleton{}
+	if err := s.refreshFFMPEG(searchPaths); err != nil {
+		t.Fatal(err)
+	}
+
+	// a broken ffmpeg replaced in the config directory is not used
+	writeStubFFMPEG(t, configDir, "5.0")
+	if err := os.WriteFile(filepath.Join(configDir, "ffmpeg"), []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NotNil(t, s.refreshFFMPEG(searchPaths))
+	assert.Equal(t, ffmpeg.Encoder(filepath.Join(homeDir, "ffmpeg")), s.FFMPEG())
+	assert.Equal(t, filepath.Join(homeDir, "ffprobe"), s.FFProbe().Path)
+
+	// missing binaries are not used
+	assert.NotNil(t, s.refreshFFMPEG([]string{t.TempDir()}))
+	assert.Equal(t, ffmpeg.Encoder(filepath.Join(homeDir, "ffmpeg")), s.FFMPEG())
+}
+
+func TestResolveImageFormat(t *testing.T) {
+	webpOnly := &ffmpeg.Capabilities{
+		Version:  "4.4",
+		Encoders: map[string]bool{"mjpeg": true, "libwebp": true},
+		Muxers:   map[string]bool{"image2": true},
+	}
+
+	tests := []struct {
+		name         string
+		format       string
+		capabilities *ffmpeg.Capabilities
+		want         ffmpeg.ImageFormat
+		wantErr      bool
+	}{
+		{"jpeg", "jpeg", webpOnly, ffmpeg.ImageFormatJpeg, false},
+		{"jpeg without capabilities", "jpeg", nil, ffmpeg.ImageFormatJpeg, false},
+		{"webp", "webp", webpOnly, ffmpeg.ImageFormatWebp, false},
+		{"case insensitive", "WebP", webpOnly, ffmpeg.ImageFormatWebp, false},
+		{"unsupported", "avif", webpOnly, ffmpeg.ImageFormatJpeg, true},
+		{"unknown capabilities", "webp", nil, ffmpeg.ImageFormatJpeg, true},
+		{"invalid", "png", webpOnly, ffmpeg.ImageFormatJpeg, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveImageFormat(tt.format, tt.capabilities)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("resolveImageFormat() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
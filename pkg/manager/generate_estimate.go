@@ -0,0 +1,335 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// generateAverage is a running total of values, for averaging.
+type generateAverage struct {
+	total float64
+	count int64
+}
+
+func (a *generateAverage) add(v float64, count int64) {
+	a.total += v
+	a.count += count
+}
+
+func (a generateAverage) average() (float64, bool) {
+	if a.count == 0 {
+		return 0, false
+	}
+	return a.total / float64(a.count), true
+}
+
+// GenerateStats records the average time taken to generate each type of
+// artifact, which is used to estimate the time of a generate.
+type GenerateStats struct {
+	mutex     sync.Mutex
+	durations map[models.GenerateArtifact]*generateAverage
+}
+
+func NewGenerateStats() *GenerateStats {
+	return &GenerateStats{
+		durations: make(map[models.GenerateArtifact]*generateAverage),
+	}
+}
+
+// record adds the time taken to generate count artifacts.
+func (s *GenerateStats) record(artifact models.GenerateArtifact, d time.Duration, count int64) {
+	if count <= 0 {
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	a := s.durations[artifact]
+	if a == nil {
+		a = &generateAverage{}
+		s.durations[artifact] = a
+	}
+	a.add(d.Seconds(), count)
+}
+
+// averageDuration returns the average time in seconds taken to generate an
+// artifact. Returns false if none have been recorded.
+func (s *GenerateStats) averageDuration(artifact models.GenerateArtifact) (float64, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	a := s.durations[artifact]
+	if a == nil {
+		return 0, false
+	}
+	return a.average()
+}
+
+// recordJob records the times taken by the tasks of a finished generate job
+// against the number of artifacts it generated.
+func (s *GenerateStats) recordJob(times *generateTaskTimes, totals totalsGenerate) {
+	times.mutex.Lock()
+	defer times.mutex.Unlock()
+
+	for artifact, d := range times.durations {
+		s.record(artifact, d, totals.count(artifact))
+	}
+}
+
+// generateTaskTimes totals the time taken by the tasks of a generate job for
+// each type of artifact.
+type generateTaskTimes struct {
+	mutex     sync.Mutex
+	durations map[models.GenerateArtifact]time.Duration
+}
+
+func newGenerateTaskTimes() *generateTaskTimes {
+	return &generateTaskTimes{
+		durations: make(map[models.GenerateArtifact]time.Duration),
+	}
+}
+
+func (t *generateTaskTimes) add(task Task, d time.Duration) {
+	artifact, ok := generateTaskArtifact(task)
+	if !ok {
+		return
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.durations[artifact] += d
+}
+
+// generateTaskArtifact returns the type of artifact generated by the task.
+// The time of preview tasks, which may also generate image previews, is
+// attributed to the video previews.
+func generateTaskArtifact(task Task) (models.GenerateArtifact, bool) {
+	switch task.(type) {
+	case *GenerateSpriteTask:
+		return models.GenerateArtifactSprites, true
+	case *GenerateContactSheetTask:
+		return models.GenerateArtifactContactSheets, true
+	case *GeneratePreviewTask:
+		return models.GenerateArtifactPreviews, true
+	case *GenerateMarkersTask:
+		return models.GenerateArtifactMarkers, true
+	case *GenerateTranscodeTask:
+		return models.GenerateArtifactTranscodes, true
+	case *GeneratePhashTask:
+		return models.GenerateArtifactPhashes, true
+	case *GenerateInteractiveHeatmapSpeedTask:
+		return models.GenerateArtifactInteractiveHeatmapsSpeeds, true
+	case *GenerateChapterMarkersTask:
+		return models.GenerateArtifactChapterMarkers, true
+	}
+	return "", false
+}
+
+func (t totalsGenerate) count(artifact models.GenerateArtifact) int64 {
+	switch artifact {
+	case models.GenerateArtifactSprites:
+		return t.sprites
+	case models.GenerateArtifactContactSheets:
+		return t.contactSheets
+	case models.GenerateArtifactPreviews:
+		return t.previews
+	case models.GenerateArtifactImagePreviews:
+		return t.imagePreviews
+	case models.GenerateArtifactMarkers:
+		return t.markers
+	case models.GenerateArtifactTranscodes:
+		return t.transcodes
+	case models.GenerateArtifactPhashes:
+		return t.phashes
+	case models.GenerateArtifactInteractiveHeatmapsSpeeds:
+		return t.interactiveHeatmapSpeeds
+	case models.GenerateArtifactChapterMarkers:
+		return t.chapterMarkers
+	}
+	return 0
+}
+
+// artifacts returns the types of artifact selected by the input.
+func (j *GenerateJob) artifacts() []models.GenerateArtifact {
+	selected := map[models.GenerateArtifact]bool{
+		models.GenerateArtifactSprites:                   utils.IsTrue(j.input.Sprites),
+		models.GenerateArtifactContactSheets:             utils.IsTrue(j.input.ContactSheets),
+		models.GenerateArtifactPreviews:                  utils.IsTrue(j.input.Previews),
+		models.GenerateArtifactImagePreviews:             utils.IsTrue(j.input.Previews) && utils.IsTrue(j.input.ImagePreviews),
+		models.GenerateArtifactMarkers:                   utils.IsTrue(j.input.Markers) || len(j.input.MarkerIDs) > 0,
+		models.GenerateArtifactTranscodes:                utils.IsTrue(j.input.Transcodes),
+		models.GenerateArtifactPhashes:                   utils.IsTrue(j.input.Phashes),
+		models.GenerateArtifactInteractiveHeatmapsSpeeds: utils.IsTrue(j.input.InteractiveHeatmapsSpeeds),
+		models.GenerateArtifactChapterMarkers:            utils.IsTrue(j.input.ChapterMarkers),
+	}
+
+	var ret []models.GenerateArtifact
+	for _, a := range models.AllGenerateArtifact {
+		if selected[a] {
+			ret = append(ret, a)
+		}
+	}
+	return ret
+}
+
+// generateSizeSampler averages the size of the existing artifacts of the
+// scenes walked by a generate.
+type generateSizeSampler struct {
+	artifacts      []models.GenerateArtifact
+	fileNamingAlgo models.HashAlgorithm
+	sizes          map[models.GenerateArtifact]*generateAverage
+}
+
+func newGenerateSizeSampler(artifacts []models.GenerateArtifact, fileNamingAlgo models.HashAlgorithm) *generateSizeSampler {
+	return &generateSizeSampler{
+		artifacts:      artifacts,
+		fileNamingAlgo: fileNamingAlgo,
+		sizes:          make(map[models.GenerateArtifact]*generateAverage),
+	}
+}
+
+// artifactFiles returns the files of the artifact of the scene. Returns nil
+// if the artifact is stored in the database, and false if its files are not
+// known without querying the database.
+func artifactFiles(artifact models.GenerateArtifact, sceneHash string) ([]string, bool) {
+	sp := instance.Paths.Scene
+	switch artifact {
+	case models.GenerateArtifactSprites:
+		return []string{sp.GetSpriteImageFilePath(sceneHash), sp.GetSpriteVttFilePath(sceneHash)}, true
+	case models.GenerateArtifactContactSheets:
+		return []string{sp.GetContactSheetPath(sceneHash)}, true
+	case models.GenerateArtifactPreviews:
+		return []string{sp.GetStreamPreviewPath(sceneHash)}, true
+	case models.GenerateArtifactImagePreviews:
+		return []string{sp.GetStreamPreviewImagePath(sceneHash)}, true
+	case models.GenerateArtifactTranscodes:
+		return []string{sp.GetTranscodePath(sceneHash)}, true
+	case models.GenerateArtifactInteractiveHeatmapsSpeeds:
+		return []string{sp.GetInteractiveHeatmapPath(sceneHash)}, true
+	case models.GenerateArtifactPhashes, models.GenerateArtifactChapterMarkers:
+		return nil, true
+	}
+	return nil, false
+}
+
+// sample adds the size of each existing artifact of the scene.
+func (s *generateSizeSampler) sample(scene *models.Scene) {
+	sceneHash := scene.GetHash(s.fileNamingAlgo)
+	if sceneHash == "" {
+		return
+	}
+
+	for _, artifact := range s.artifacts {
+		files, ok := artifactFiles(artifact, sceneHash)
+		if !ok {
+			continue
+		}
+
+		size, exists := filesSize(files)
+		if !exists {
+			continue
+		}
+
+		a := s.sizes[artifact]
+		if a == nil {
+			a = &generateAverage{}
+			s.sizes[artifact] = a
+		}
+		a.add(float64(size), 1)
+	}
+}
+
+// averageSize returns the average size in bytes of the artifact. Returns
+// false if there were no existing artifacts to average.
+func (s *generateSizeSampler) averageSize(artifact models.GenerateArtifact) (float64, bool) {
+	a := s.sizes[artifact]
+	if a == nil {
+		return 0, false
+	}
+	return a.average()
+}
+
+// filesSize returns the total size of the files. Returns false if any of
+// the files does not exist.
+func filesSize(files []string) (int64, bool) {
+	var ret int64
+	for _, f := range files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return 0, false
+		}
+		ret += info.Size()
+	}
+	return ret, true
+}
+
+// Estimate walks the scenes and markers of the generate without generating
+// anything. It returns the number of each type of artifact that would be
+// generated, with estimates of their size from the existing artifacts of the
+// walked scenes, and of their time from the stats of earlier generates.
+func (j *GenerateJob) Estimate(ctx context.Context, parallelTasks int) (*models.GenerateEstimate, error) {
+	j.init()
+
+	artifacts := j.artifacts()
+	j.sampler = newGenerateSizeSampler(artifacts, j.fileNamingAlgo)
+
+	// the tasks are discarded
+	queue := make(chan Task)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range queue {
+		}
+	}()
+
+	totals, err := j.queueTargets(ctx, queue)
+	close(queue)
+	<-done
+
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &models.GenerateEstimate{
+		Artifacts: []*models.GenerateArtifactEstimate{},
+		Tasks:     totals.tasks,
+	}
+
+	var duration float64
+	for _, artifact := range artifacts {
+		count := totals.count(artifact)
+		e := &models.GenerateArtifactEstimate{
+			Artifact: artifact,
+			Count:    int(count),
+		}
+
+		if size, ok := j.sampler.averageSize(artifact); ok {
+			v := size * float64(count)
+			e.Size = &v
+			ret.Size += v
+		}
+
+		if j.stats != nil {
+			if d, ok := j.stats.averageDuration(artifact); ok {
+				v := d * float64(count)
+				e.Duration = &v
+				duration += v
+			}
+		}
+
+		ret.Artifacts = append(ret.Artifacts, e)
+	}
+
+	if parallelTasks < 1 {
+		parallelTasks = 1
+	}
+	ret.Duration = duration / float64(parallelTasks)
+
+	return ret, nil
+}
@@ -0,0 +1,195 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/manager/paths"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// setTestInstancePaths sets the paths of the manager instance, which are
+// used by the generate tasks, for the duration of the test.
+func setTestInstancePaths(t *testing.T, p *paths.Paths) {
+	old := instance
+	instance = &singleton{Paths: p}
+	t.Cleanup(func() {
+		instance = old
+	})
+}
+
+func estimateTestScene(id int, hash string) *models.Scene {
+	return &models.Scene{
+		ID:       id,
+		Checksum: sql.NullString{String: hash, Valid: true},
+		OSHash:   sql.NullString{String: hash, Valid: true},
+	}
+}
+
+func artifactEstimates(e *models.GenerateEstimate) map[models.GenerateArtifact]*models.GenerateArtifactEstimate {
+	ret := make(map[models.GenerateArtifact]*models.GenerateArtifactEstimate)
+	for _, a := range e.Artifacts {
+		ret[a.Artifact] = a
+	}
+	return ret
+}
+
+func TestGenerateEstimate(t *testing.T) {
+	p := paths.NewPaths(t.TempDir())
+	setTestInstancePaths(t, p)
+
+	scenes := []*models.Scene{
+		estimateTestScene(1, "hash1"),
+		estimateTestScene(2, "hash2"),
+		estimateTestScene(3, "hash3"),
+	}
+	scenes[2].Phash = sql.NullInt64{Int64: 1, Valid: true}
+
+	// scene 1 has sprites and a contact sheet, scene 2 has a contact sheet
+	writeGeneratedFile(t, p.Scene.GetSpriteImageFilePath("hash1"), 100)
+	writeGeneratedFile(t, p.Scene.GetSpriteVttFilePath("hash1"), 20)
+	writeGeneratedFile(t, p.Scene.GetContactSheetPath("hash1"), 50)
+	writeGeneratedFile(t, p.Scene.GetContactSheetPath("hash2"), 70)
+
+	// a sprite of scene 2 without its vtt file is missing
+	writeGeneratedFile(t, p.Scene.GetSpriteImageFilePath("hash2"), 1000)
+
+	yes := true
+	input := models.GenerateMetadataInput{
+		Sprites:       &yes,
+		ContactSheets: &yes,
+		Phashes:       &yes,
+		SceneIDs:      []string{"1", "2", "3"},
+	}
+
+	stats := NewGenerateStats()
+	stats.record(models.GenerateArtifactSprites, 4*time.Second, 2)
+
+	tests := []struct {
+		name      string
+		overwrite bool
+		counts    map[models.GenerateArtifact]int
+		tasks     int
+		size      float64
+		duration  float64
+	}{
+		{
+			"missing",
+			false,
+			map[models.GenerateArtifact]int{
+				models.GenerateArtifactSprites:       2,
+				models.GenerateArtifactContactSheets: 1,
+				models.GenerateArtifactPhashes:       2,
+			},
+			5,
+			// 2 sprites of 120 bytes and a contact sheet of 60 bytes
+			300,
+			// 2 sprites of 2 seconds, in 2 parallel tasks
+			2,
+		},
+		{
+			"overwrite",
+			true,
+			map[models.GenerateArtifact]int{
+				models.GenerateArtifactSprites:       3,
+				models.GenerateArtifactContactSheets: 3,
+				models.GenerateArtifactPhashes:       3,
+			},
+			9,
+			540,
+			3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewTransactionManager()
+			repo.SceneMock().On("FindMany", []int{1, 2, 3}).Return(scenes, nil)
+
+			input := input
+			input.Overwrite = &tt.overwrite
+
+			j := &GenerateJob{
+				txnManager: repo,
+				input:      input,
+				stats:      stats,
+			}
+
+			got, err := j.Estimate(context.Background(), 2)
+			if !assert.Nil(t, err) {
+				return
+			}
+
+			estimates := artifactEstimates(got)
+			assert.Len(t, estimates, len(tt.counts))
+			for artifact, count := range tt.counts {
+				if assert.Contains(t, estimates, artifact) {
+					assert.Equal(t, count, estimates[artifact].Count, artifact)
+				}
+			}
+
+			assert.Equal(t, tt.tasks, got.Tasks)
+			assert.Equal(t, tt.size, got.Size)
+			assert.Equal(t, tt.duration, got.Duration)
+
+			// no time has been recorded for contact sheets
+			assert.Nil(t, estimates[models.GenerateArtifactContactSheets].Duration)
+		})
+	}
+}
+
+func TestGenerateEstimateNoExisting(t *testing.T) {
+	p := paths.NewPaths(t.TempDir())
+	setTestInstancePaths(t, p)
+
+	repo := mocks.NewTransactionManager()
+	repo.SceneMock().On("FindMany", []int{1}).Return([]*models.Scene{estimateTestScene(1, "hash1")}, nil)
+
+	yes := true
+	j := &GenerateJob{
+		txnManager: repo,
+		input: models.GenerateMetadataInput{
+			Sprites:  &yes,
+			SceneIDs: []string{"1"},
+		},
+	}
+
+	got, err := j.Estimate(context.Background(), 1)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	if assert.Len(t, got.Artifacts, 1) {
+		e := got.Artifacts[0]
+		assert.Equal(t, 1, e.Count)
+		// there are no existing sprites to average, nor recorded times
+		assert.Nil(t, e.Size)
+		assert.Nil(t, e.Duration)
+	}
+	assert.Equal(t, float64(0), got.Size)
+}
+
+func TestGenerateStatsRecordJob(t *testing.T) {
+	times := newGenerateTaskTimes()
+	times.add(&GenerateSpriteTask{}, 3*time.Second)
+	times.add(&GenerateSpriteTask{}, 3*time.Second)
+	times.add(&GenerateMarkersTask{}, 10*time.Second)
+
+	stats := NewGenerateStats()
+	stats.recordJob(times, totalsGenerate{sprites: 2, markers: 5})
+
+	d, ok := stats.averageDuration(models.GenerateArtifactSprites)
+	assert.True(t, ok)
+	assert.Equal(t, float64(3), d)
+
+	d, ok = stats.averageDuration(models.GenerateArtifactMarkers)
+	assert.True(t, ok)
+	assert.Equal(t, float64(2), d)
+
+	_, ok = stats.averageDuration(models.GenerateArtifactPreviews)
+	assert.False(t, ok)
+}
@@ -0,0 +1,280 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// maxGenerateBackoffDoublings caps the exponential backoff of failed items.
+const maxGenerateBackoffDoublings = 10
+
+// generateItem is the scene or scene marker of a generate task, with the
+// artifact generated for it.
+type generateItem struct {
+	artifact models.GenerateArtifact
+	sceneID  sql.NullInt64
+	markerID sql.NullInt64
+}
+
+func sceneGenerateItem(artifact models.GenerateArtifact, s models.Scene) generateItem {
+	return generateItem{
+		artifact: artifact,
+		sceneID:  sql.NullInt64{Int64: int64(s.ID), Valid: true},
+	}
+}
+
+// generateTaskItem returns the item of the generate task. Previews that
+// include image previews are recorded as image previews, so that both are
+// generated when retried.
+func generateTaskItem(task Task) (generateItem, bool) {
+	switch t := task.(type) {
+	case *GenerateSpriteTask:
+		return sceneGenerateItem(models.GenerateArtifactSprites, t.Scene), true
+	case *GenerateContactSheetTask:
+		return sceneGenerateItem(models.GenerateArtifactContactSheets, t.Scene), true
+	case *GeneratePreviewTask:
+		if t.ImagePreview {
+			return sceneGenerateItem(models.GenerateArtifactImagePreviews, t.Scene), true
+		}
+		return sceneGenerateItem(models.GenerateArtifactPreviews, t.Scene), true
+	case *GenerateMarkersTask:
+		if t.Marker != nil {
+			return generateItem{
+				artifact: models.GenerateArtifactMarkers,
+				markerID: sql.NullInt64{Int64: int64(t.Marker.ID), Valid: true},
+			}, true
+		}
+		if t.Scene != nil {
+			return sceneGenerateItem(models.GenerateArtifactMarkers, *t.Scene), true
+		}
+	case *GenerateTranscodeTask:
+		return sceneGenerateItem(models.GenerateArtifactTranscodes, t.Scene), true
+	case *GeneratePhashTask:
+		return sceneGenerateItem(models.GenerateArtifactPhashes, t.Scene), true
+	case *GenerateInteractiveHeatmapSpeedTask:
+		return sceneGenerateItem(models.GenerateArtifactInteractiveHeatmapsSpeeds, t.Scene), true
+	case *GenerateChapterMarkersTask:
+		return sceneGenerateItem(models.GenerateArtifactChapterMarkers, t.Scene), true
+	}
+	return generateItem{}, false
+}
+
+// taskErrorSink records the first error logged by a task against its
+// context, forwarding log items to the sink of the job.
+type taskErrorSink struct {
+	next logger.Sink
+
+	mutex sync.Mutex
+	err   string
+}
+
+func withTaskErrorSink(ctx context.Context) (context.Context, *taskErrorSink) {
+	sink := &taskErrorSink{
+		next: logger.SinkFromContext(ctx),
+	}
+	return logger.WithSink(ctx, sink), sink
+}
+
+func (s *taskErrorSink) Add(item logger.LogItem) {
+	if item.Type == "error" {
+		s.mutex.Lock()
+		if s.err == "" {
+			s.err = item.Message
+		}
+		s.mutex.Unlock()
+	}
+
+	if s.next != nil {
+		s.next.Add(item)
+	}
+}
+
+// Error returns the first error logged by the task, or an empty string if
+// it did not log an error.
+func (s *taskErrorSink) Error() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.err
+}
+
+// generateRetryBackoff returns the time before an item that has failed
+// attempts times is retried. The backoff doubles with each attempt.
+func generateRetryBackoff(backoff time.Duration, attempts int) time.Duration {
+	doublings := attempts - 1
+	if doublings < 0 {
+		doublings = 0
+	}
+	if doublings > maxGenerateBackoffDoublings {
+		doublings = maxGenerateBackoffDoublings
+	}
+	return backoff << uint(doublings)
+}
+
+// generateRetryDue returns true if the failed item should be retried at now.
+func generateRetryDue(f *models.GenerateFailure, maxRetries int, now time.Time) bool {
+	return f.Attempts <= maxRetries && !f.RetryAt.Timestamp.After(now)
+}
+
+// generateFailureRecorder records the generate tasks that fail in the
+// persistent list of failed items.
+type generateFailureRecorder struct {
+	txnManager models.TransactionManager
+	// backoff is the time before an item that has failed once is retried.
+	backoff time.Duration
+	// clearSucceeded removes items from the list when their task succeeds.
+	// It is set when retrying failed items.
+	clearSucceeded bool
+}
+
+// record records the outcome of the task. errMessage is the error logged by
+// the task, or empty if it succeeded.
+func (r *generateFailureRecorder) record(ctx context.Context, task Task, errMessage string) {
+	if errMessage == "" && !r.clearSucceeded {
+		return
+	}
+
+	item, ok := generateTaskItem(task)
+	if !ok {
+		return
+	}
+
+	if err := r.txnManager.WithTxn(ctx, func(repo models.Repository) error {
+		qb := repo.GenerateFailure()
+		existing, err := qb.FindByItem(item.artifact, item.sceneID, item.markerID)
+		if err != nil {
+			return err
+		}
+
+		if errMessage == "" {
+			if existing == nil {
+				return nil
+			}
+			return qb.Destroy(existing.ID)
+		}
+
+		now := time.Now()
+		if existing == nil {
+			_, err = qb.Create(models.GenerateFailure{
+				Artifact:      item.artifact,
+				SceneID:       item.sceneID,
+				SceneMarkerID: item.markerID,
+				Error:         errMessage,
+				Attempts:      1,
+				RetryAt:       models.SQLiteTimestamp{Timestamp: now.Add(generateRetryBackoff(r.backoff, 1))},
+				CreatedAt:     models.SQLiteTimestamp{Timestamp: now},
+				UpdatedAt:     models.SQLiteTimestamp{Timestamp: now},
+			})
+			return err
+		}
+
+		existing.Error = errMessage
+		existing.Attempts++
+		existing.RetryAt = models.SQLiteTimestamp{Timestamp: now.Add(generateRetryBackoff(r.backoff, existing.Attempts))}
+		existing.UpdatedAt = models.SQLiteTimestamp{Timestamp: now}
+		_, err = qb.Update(*existing)
+		return err
+	}); err != nil {
+		logger.Warnf("error recording generate failure: %v", err)
+	}
+}
+
+// generateRetryInput returns the input that generates the artifact.
+func generateRetryInput(artifact models.GenerateArtifact) models.GenerateMetadataInput {
+	yes := true
+	var ret models.GenerateMetadataInput
+
+	switch artifact {
+	case models.GenerateArtifactSprites:
+		ret.Sprites = &yes
+	case models.GenerateArtifactContactSheets:
+		ret.ContactSheets = &yes
+	case models.GenerateArtifactPreviews:
+		ret.Previews = &yes
+	case models.GenerateArtifactImagePreviews:
+		ret.Previews = &yes
+		ret.ImagePreviews = &yes
+	case models.GenerateArtifactMarkers:
+		ret.Markers = &yes
+	case models.GenerateArtifactTranscodes:
+		ret.Transcodes = &yes
+	case models.GenerateArtifactPhashes:
+		ret.Phashes = &yes
+	case models.GenerateArtifactInteractiveHeatmapsSpeeds:
+		ret.InteractiveHeatmapsSpeeds = &yes
+	case models.GenerateArtifactChapterMarkers:
+		ret.ChapterMarkers = &yes
+	}
+
+	return ret
+}
+
+// queueRetries queues the tasks of the failed items that are due to be
+// retried and have not exceeded the maximum number of retries. Items that no
+// longer need generating are removed from the list.
+func (j *GenerateJob) queueRetries(ctx context.Context, queue chan<- Task) (totalsGenerate, error) {
+	var totals totalsGenerate
+	var resolved []int
+	now := time.Now()
+
+	if err := j.txnManager.WithReadTxn(ctx, func(r models.ReaderRepository) error {
+		failures, err := r.GenerateFailure().All()
+		if err != nil {
+			return err
+		}
+
+		for _, f := range failures {
+			if !generateRetryDue(f, j.maxRetries, now) {
+				continue
+			}
+
+			queued := totals.tasks
+			if f.SceneMarkerID.Valid {
+				marker, err := r.SceneMarker().Find(int(f.SceneMarkerID.Int64))
+				if err != nil {
+					return err
+				}
+				if marker != nil {
+					j.queueMarkerJob(marker, queue, &totals)
+				}
+			} else {
+				scene, err := r.Scene().Find(int(f.SceneID.Int64))
+				if err != nil {
+					return err
+				}
+				if scene != nil {
+					retry := *j
+					retry.input = generateRetryInput(f.Artifact)
+					retry.queueSceneJobs(scene, queue, &totals)
+				}
+			}
+
+			if totals.tasks == queued {
+				resolved = append(resolved, f.ID)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return totals, err
+	}
+
+	if len(resolved) == 0 {
+		return totals, nil
+	}
+
+	err := j.txnManager.WithTxn(ctx, func(r models.Repository) error {
+		for _, id := range resolved {
+			if err := r.GenerateFailure().Destroy(id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return totals, err
+}
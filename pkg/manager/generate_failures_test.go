@@ -0,0 +1,178 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/manager/paths"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func nullID(id int) sql.NullInt64 {
+	return sql.NullInt64{Int64: int64(id), Valid: true}
+}
+
+func TestGenerateRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, time.Minute},
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{4, 8 * time.Minute},
+		{100, 1024 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, generateRetryBackoff(time.Minute, tt.attempts), tt.attempts)
+	}
+}
+
+func TestGenerateFailuresAccumulate(t *testing.T) {
+	const backoff = time.Minute
+
+	p := paths.NewPaths(t.TempDir())
+	setTestInstancePaths(t, p)
+
+	// sprites cannot be generated without ffprobe
+	failing := estimateTestScene(1, "hash1")
+	// contact sheets that exist are not generated
+	succeeding := estimateTestScene(2, "hash2")
+	writeGeneratedFile(t, p.Scene.GetContactSheetPath("hash2"), 10)
+
+	repo := mocks.NewTransactionManager()
+	qb := repo.GenerateFailureMock()
+
+	start := time.Now()
+	existing := &models.GenerateFailure{
+		ID:       10,
+		Artifact: models.GenerateArtifactSprites,
+		SceneID:  nullID(failing.ID),
+		Attempts: 1,
+	}
+
+	qb.On("FindByItem", models.GenerateArtifactSprites, nullID(failing.ID), sql.NullInt64{}).Return(nil, nil).Once()
+	qb.On("Create", mock.MatchedBy(func(f models.GenerateFailure) bool {
+		return f.Artifact == models.GenerateArtifactSprites && f.SceneID == nullID(failing.ID) &&
+			f.Attempts == 1 && f.Error != "" && !f.RetryAt.Timestamp.Before(start.Add(backoff))
+	})).Return(existing, nil).Once()
+
+	// the second failure of the same item is counted against it
+	qb.On("FindByItem", models.GenerateArtifactSprites, nullID(failing.ID), sql.NullInt64{}).Return(existing, nil).Once()
+	qb.On("Update", mock.MatchedBy(func(f models.GenerateFailure) bool {
+		return f.ID == existing.ID && f.Attempts == 2 && !f.RetryAt.Timestamp.Before(start.Add(2*backoff))
+	})).Return(existing, nil).Once()
+
+	j := &GenerateJob{
+		txnManager: repo,
+		failures: &generateFailureRecorder{
+			txnManager: repo,
+			backoff:    backoff,
+		},
+	}
+
+	ctx := context.Background()
+	times := newGenerateTaskTimes()
+	for i := 0; i < 2; i++ {
+		j.runTask(ctx, &GenerateSpriteTask{Scene: *failing, fileNamingAlgorithm: models.HashAlgorithmOshash}, times)
+		j.runTask(ctx, &GenerateContactSheetTask{Scene: *succeeding, fileNamingAlgorithm: models.HashAlgorithmOshash}, times)
+	}
+
+	qb.AssertExpectations(t)
+	// succeeded items are not looked up outside of retries
+	qb.AssertNotCalled(t, "FindByItem", models.GenerateArtifactContactSheets, mock.Anything, mock.Anything)
+}
+
+func TestGenerateFailuresClearSucceeded(t *testing.T) {
+	p := paths.NewPaths(t.TempDir())
+	setTestInstancePaths(t, p)
+
+	s := estimateTestScene(1, "hash1")
+	writeGeneratedFile(t, p.Scene.GetContactSheetPath("hash1"), 10)
+
+	repo := mocks.NewTransactionManager()
+	qb := repo.GenerateFailureMock()
+	qb.On("FindByItem", models.GenerateArtifactContactSheets, nullID(s.ID), sql.NullInt64{}).Return(&models.GenerateFailure{ID: 10}, nil).Once()
+	qb.On("Destroy", 10).Return(nil).Once()
+
+	j := &GenerateJob{
+		txnManager: repo,
+		failures: &generateFailureRecorder{
+			txnManager:     repo,
+			clearSucceeded: true,
+		},
+	}
+
+	j.runTask(context.Background(), &GenerateContactSheetTask{Scene: *s, fileNamingAlgorithm: models.HashAlgorithmOshash}, newGenerateTaskTimes())
+
+	qb.AssertExpectations(t)
+}
+
+func TestGenerateRetryQueuesFailedItems(t *testing.T) {
+	const maxRetries = 3
+
+	p := paths.NewPaths(t.TempDir())
+	setTestInstancePaths(t, p)
+
+	past := models.SQLiteTimestamp{Timestamp: time.Now().Add(-time.Minute)}
+	future := models.SQLiteTimestamp{Timestamp: time.Now().Add(time.Hour)}
+
+	// the contact sheet of scene 5 has been generated since it failed
+	writeGeneratedFile(t, p.Scene.GetContactSheetPath("hash5"), 10)
+
+	failures := []*models.GenerateFailure{
+		{ID: 1, Artifact: models.GenerateArtifactSprites, SceneID: nullID(1), Attempts: 1, RetryAt: past},
+		{ID: 2, Artifact: models.GenerateArtifactContactSheets, SceneID: nullID(2), Attempts: maxRetries, RetryAt: past},
+		// exceeded the maximum retries
+		{ID: 3, Artifact: models.GenerateArtifactSprites, SceneID: nullID(3), Attempts: maxRetries + 1, RetryAt: past},
+		// not yet due
+		{ID: 4, Artifact: models.GenerateArtifactSprites, SceneID: nullID(4), Attempts: 1, RetryAt: future},
+		{ID: 5, Artifact: models.GenerateArtifactContactSheets, SceneID: nullID(5), Attempts: 1, RetryAt: past},
+		{ID: 6, Artifact: models.GenerateArtifactMarkers, SceneMarkerID: nullID(7), Attempts: 1, RetryAt: past},
+	}
+
+	repo := mocks.NewTransactionManager()
+	repo.GenerateFailureMock().On("All").Return(failures, nil).Once()
+	repo.GenerateFailureMock().On("Destroy", 5).Return(nil).Once()
+	for _, id := range []int{1, 2, 5} {
+		repo.SceneMock().On("Find", id).Return(estimateTestScene(id, fmt.Sprintf("hash%d", id)), nil).Once()
+	}
+	repo.SceneMarkerMock().On("Find", 7).Return(&models.SceneMarker{ID: 7}, nil).Once()
+
+	j := &GenerateJob{
+		txnManager:     repo,
+		retry:          true,
+		maxRetries:     maxRetries,
+		fileNamingAlgo: models.HashAlgorithmOshash,
+	}
+
+	queue := make(chan Task, len(failures))
+	totals, err := j.queueTargets(context.Background(), queue)
+	close(queue)
+	assert.Nil(t, err)
+
+	var got []generateItem
+	for task := range queue {
+		item, ok := generateTaskItem(task)
+		assert.True(t, ok)
+		got = append(got, item)
+	}
+
+	assert.Equal(t, []generateItem{
+		{artifact: models.GenerateArtifactSprites, sceneID: nullID(1)},
+		{artifact: models.GenerateArtifactContactSheets, sceneID: nullID(2)},
+		{artifact: models.GenerateArtifactMarkers, markerID: nullID(7)},
+	}, got)
+	assert.Equal(t, 3, totals.tasks)
+
+	repo.GenerateFailureMock().AssertExpectations(t)
+	repo.SceneMock().AssertExpectations(t)
+	repo.SceneMarkerMock().AssertExpectations(t)
+}
@@ -0,0 +1,139 @@
+package manager
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/paths"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// generatedUsageTTL is the time that the disk usage of the generated
+// directory is cached for before it is walked again.
+const generatedUsageTTL = time.Minute
+
+// GeneratedUsage is the disk usage of a category of generated files.
+type GeneratedUsage struct {
+	Category models.GeneratedCategory
+	Path     string
+	// Size is the total size of the files in bytes.
+	Size  int64
+	Files int
+}
+
+// generatedCategories returns the directories of each category of generated
+// files. Some directories are nested in others, such as the thumbnail cache.
+func generatedCategories(p *paths.Paths) []GeneratedUsage {
+	gp := p.Generated
+	return []GeneratedUsage{
+		{Category: models.GeneratedCategoryScreenshots, Path: gp.Screenshots},
+		{Category: models.GeneratedCategoryThumbnails, Path: gp.Thumbnails},
+		{Category: models.GeneratedCategoryThumbnailCache, Path: gp.ThumbnailCache},
+		{Category: models.GeneratedCategoryVtt, Path: gp.Vtt},
+		{Category: models.GeneratedCategoryMarkers, Path: gp.Markers},
+		{Category: models.GeneratedCategoryTranscodes, Path: gp.Transcodes},
+		{Category: models.GeneratedCategoryTranscodeCache, Path: gp.TranscodeCache},
+		{Category: models.GeneratedCategoryDownloads, Path: gp.Downloads},
+		{Category: models.GeneratedCategoryTmp, Path: gp.Tmp},
+		{Category: models.GeneratedCategoryInteractiveHeatmaps, Path: gp.InteractiveHeatmap},
+	}
+}
+
+// getGeneratedUsage walks the directory of each category of generated files,
+// returning the size and number of files in each. Files in a directory nested
+// in another category's directory are only counted in the nested category.
+// Categories with no directory have no usage.
+func getGeneratedUsage(p *paths.Paths) []GeneratedUsage {
+	ret := generatedCategories(p)
+
+	dirs := make(map[string]bool)
+	for _, u := range ret {
+		dirs[filepath.Clean(u.Path)] = true
+	}
+
+	for i := range ret {
+		u := &ret[i]
+		root := filepath.Clean(u.Path)
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == root && errors.Is(err, os.ErrNotExist) {
+					return nil
+				}
+				logger.Warnf("error reading generated file %s: %v", path, err)
+				return nil
+			}
+
+			if d.IsDir() {
+				if path != root && dirs[path] {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
+			if !d.Type().IsRegular() {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				logger.Warnf("error reading generated file %s: %v", path, err)
+				return nil
+			}
+
+			u.Size += info.Size()
+			u.Files++
+			return nil
+		})
+		if err != nil {
+			logger.Warnf("error walking generated directory %s: %v", root, err)
+		}
+	}
+
+	return ret
+}
+
+// GeneratedUsageCache caches the disk usage of the generated directory, so
+// that it is not walked on every request.
+type GeneratedUsageCache struct {
+	ttl time.Duration
+	// now returns the current time. Replaced in tests.
+	now func() time.Time
+
+	mutex   sync.Mutex
+	paths   *paths.Paths
+	updated time.Time
+	usage   []GeneratedUsage
+}
+
+// NewGeneratedUsageCache returns a cache that walks the generated directory
+// at most once every ttl.
+func NewGeneratedUsageCache(ttl time.Duration) *GeneratedUsageCache {
+	return &GeneratedUsageCache{
+		ttl: ttl,
+		now: time.Now,
+	}
+}
+
+// Get returns the disk usage of each category of generated files in p. The
+// directories are walked again if the cached usage is older than the cache's
+// ttl, or if it is of different paths.
+func (c *GeneratedUsageCache) Get(p *paths.Paths) []GeneratedUsage {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := c.now()
+	if c.paths != p || c.usage == nil || now.Sub(c.updated) >= c.ttl {
+		c.usage = getGeneratedUsage(p)
+		c.paths = p
+		c.updated = now
+	}
+
+	ret := make([]GeneratedUsage, len(c.usage))
+	copy(ret, c.usage)
+	return ret
+}
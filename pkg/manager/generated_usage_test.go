@@ -0,0 +1,117 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/manager/paths"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeGeneratedFile(t *testing.T, path string, size int) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// newGeneratedUsageFixture returns the paths of a generated directory
+// containing files of known sizes.
+func newGeneratedUsageFixture(t *testing.T) *paths.Paths {
+	p := paths.NewPaths(t.TempDir())
+	gp := p.Generated
+
+	writeGeneratedFile(t, filepath.Join(gp.Screenshots, "a.jpg"), 100)
+	writeGeneratedFile(t, filepath.Join(gp.Screenshots, "b.jpg"), 200)
+	writeGeneratedFile(t, filepath.Join(gp.Thumbnails, "ab", "cd", "abcd_320.jpg"), 50)
+	writeGeneratedFile(t, filepath.Join(gp.ThumbnailCache, "1_abcd_640.jpg"), 70)
+	writeGeneratedFile(t, filepath.Join(gp.Markers, "abcd", "10.mp4"), 1000)
+	writeGeneratedFile(t, filepath.Join(gp.Markers, "abcd", "10.webp"), 300)
+	writeGeneratedFile(t, filepath.Join(gp.Markers, "abcd", "10.jpg"), 30)
+	writeGeneratedFile(t, filepath.Join(gp.Transcodes, "abcd.mp4"), 5000)
+	writeGeneratedFile(t, filepath.Join(gp.TranscodeCache, "abcd_h264_720.mp4"), 700)
+	if err := os.MkdirAll(gp.Tmp, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	return p
+}
+
+func usageByCategory(usage []GeneratedUsage) map[models.GeneratedCategory]GeneratedUsage {
+	ret := make(map[models.GeneratedCategory]GeneratedUsage)
+	for _, u := range usage {
+		ret[u.Category] = u
+	}
+
+	return ret
+}
+
+func TestGetGeneratedUsage(t *testing.T) {
+	p := newGeneratedUsageFixture(t)
+
+	usage := getGeneratedUsage(p)
+	assert.Len(t, usage, len(models.AllGeneratedCategory))
+
+	got := usageByCategory(usage)
+	tests := []struct {
+		category models.GeneratedCategory
+		size     int64
+		files    int
+	}{
+		{models.GeneratedCategoryScreenshots, 300, 2},
+		{models.GeneratedCategoryThumbnails, 50, 1},
+		{models.GeneratedCategoryThumbnailCache, 70, 1},
+		{models.GeneratedCategoryMarkers, 1330, 3},
+		{models.GeneratedCategoryTranscodes, 5000, 1},
+		{models.GeneratedCategoryTranscodeCache, 700, 1},
+		// empty directory
+		{models.GeneratedCategoryTmp, 0, 0},
+		// missing directory
+		{models.GeneratedCategoryVtt, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.category), func(t *testing.T) {
+			u := got[tt.category]
+			assert.Equal(t, tt.size, u.Size)
+			assert.Equal(t, tt.files, u.Files)
+		})
+	}
+}
+
+func TestGeneratedUsageCache(t *testing.T) {
+	p := newGeneratedUsageFixture(t)
+
+	now := time.Now()
+	c := NewGeneratedUsageCache(time.Minute)
+	c.now = func() time.Time {
+		return now
+	}
+
+	screenshots := func(usage []GeneratedUsage) GeneratedUsage {
+		return usageByCategory(usage)[models.GeneratedCategoryScreenshots]
+	}
+
+	assert.Equal(t, 2, screenshots(c.Get(p)).Files)
+
+	writeGeneratedFile(t, filepath.Join(p.Generated.Screenshots, "c.jpg"), 400)
+
+	// the usage is cached until the ttl has elapsed
+	now = now.Add(30 * time.Second)
+	assert.Equal(t, 2, screenshots(c.Get(p)).Files)
+
+	now = now.Add(30 * time.Second)
+	u := screenshots(c.Get(p))
+	assert.Equal(t, 3, u.Files)
+	assert.Equal(t, int64(700), u.Size)
+
+	// changed paths are walked immediately
+	other := paths.NewPaths(t.TempDir())
+	assert.Equal(t, 0, screenshots(c.Get(other)).Files)
+}
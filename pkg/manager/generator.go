@@ -72,7 +72,7 @@ func (g *GeneratorInfo) calculateFrameRate(videoStream *ffmpeg.FFProbeStream) er
 			args = append(args, "/dev/null")
 		}
 
-		command := exec.Command(string(instance.FFMPEG), args...)
+		command := exec.Command(string(instance.FFMPEG()), args...)
 		desktop.HideExecShell(command)
 		var stdErrBuffer bytes.Buffer
 		command.Stderr = &stdErrBuffer // Frames go to stderr rather than stdout
@@ -24,9 +24,19 @@ type PreviewGenerator struct {
 
 	PreviewPreset string
 
+	// Width is the width of the generated preview, in pixels.
+	Width int
+
 	Overwrite bool
 }
 
+const (
+	previewWidthDefault = 640
+
+	// a very short duration can create files without a video stream
+	minPreviewSegmentDuration = 0.75
+)
+
 func NewPreviewGenerator(videoFile ffmpeg.VideoFile, videoChecksum string, videoFilename string, imageFilename string, outputDirectory string, generateVideo bool, generateImage bool, previewPreset string) (*PreviewGenerator, error) {
 	exists, err := utils.FileExists(videoFile.Path)
 	if !exists {
@@ -47,6 +57,7 @@ func NewPreviewGenerator(videoFile ffmpeg.VideoFile, videoChecksum string, video
 		GenerateVideo:   generateVideo,
 		GenerateImage:   generateImage,
 		PreviewPreset:   previewPreset,
+		Width:           previewWidthDefault,
 	}, nil
 }
 
@@ -57,7 +68,7 @@ func (g *PreviewGenerator) Generate() error {
 		return err
 	}
 
-	encoder := instance.FFMPEG
+	encoder := instance.FFMPEG()
 	if g.GenerateVideo {
 		if err := g.generateVideo(&encoder, false); err != nil {
 			logger.Warnf("[generator] failed generating scene preview, trying fallback")
@@ -74,7 +85,7 @@ func (g *PreviewGenerator) Generate() error {
 	return nil
 }
 
-func (g *PreviewGenerator) generateConcatFile() error {
+func (g *PreviewGenerator) generateConcatFile(chunkCount int) error {
 	f, err := os.Create(g.getConcatFilePath())
 	if err != nil {
 		return err
@@ -82,7 +93,7 @@ func (g *PreviewGenerator) generateConcatFile() error {
 	defer f.Close()
 
 	w := bufio.NewWriter(f)
-	for i := 0; i < g.Info.ChunkCount; i++ {
+	for i := 0; i < chunkCount; i++ {
 		num := fmt.Sprintf("%.3d", i)
 		filename := "preview_" + g.VideoChecksum + "_" + num + ".mp4"
 		_, _ = w.WriteString(fmt.Sprintf("file '%s'\n", filename))
@@ -96,7 +107,9 @@ func (g *PreviewGenerator) generateVideo(encoder *ffmpeg.Encoder, fallback bool)
 	if !g.Overwrite && outputExists {
 		return nil
 	}
-	err := g.generateConcatFile()
+	chunks := g.chunkOptions()
+
+	err := g.generateConcatFile(len(chunks))
 	if err != nil {
 		return err
 	}
@@ -105,29 +118,12 @@ func (g *PreviewGenerator) generateVideo(encoder *ffmpeg.Encoder, fallback bool)
 	tmpFiles = append(tmpFiles, g.getConcatFilePath()) // add concat filename to tmpFiles
 	defer func() { removeFiles(tmpFiles) }()           // remove tmpFiles when done
 
-	stepSize, offset := g.Info.getStepSizeAndOffset()
-
-	durationSegment := g.Info.ChunkDuration
-	if durationSegment < 0.75 { // a very short duration can create files without a video stream
-		durationSegment = 0.75 // use 0.75 in that case
-		logger.Warnf("[generator] Segment duration (%f) too short.Using 0.75 instead.", g.Info.ChunkDuration)
-	}
-
-	includeAudio := g.Info.Audio
-
-	for i := 0; i < g.Info.ChunkCount; i++ {
-		time := offset + (float64(i) * stepSize)
+	for i, options := range chunks {
 		num := fmt.Sprintf("%.3d", i)
 		filename := "preview_" + g.VideoChecksum + "_" + num + ".mp4"
 		chunkOutputPath := instance.Paths.Generated.GetTmpPath(filename)
 		tmpFiles = append(tmpFiles, chunkOutputPath) // add chunk filename to tmpFiles
-		options := ffmpeg.ScenePreviewChunkOptions{
-			StartTime:  time,
-			Duration:   durationSegment,
-			Width:      640,
-			OutputPath: chunkOutputPath,
-			Audio:      includeAudio,
-		}
+		options.OutputPath = chunkOutputPath
 		if err := encoder.ScenePreviewVideoChunk(g.Info.VideoFile, options, g.PreviewPreset, fallback); err != nil {
 			return err
 		}
@@ -141,6 +137,43 @@ func (g *PreviewGenerator) generateVideo(encoder *ffmpeg.Encoder, fallback bool)
 	return nil
 }
 
+// chunkOptions returns the options used to generate each of the preview
+// segments, without the output path. If the configured segments would run
+// past the end of the video, then the number of segments is reduced so that
+// they fit within the video duration.
+func (g *PreviewGenerator) chunkOptions() []ffmpeg.ScenePreviewChunkOptions {
+	stepSize, offset := g.Info.getStepSizeAndOffset()
+	available := stepSize * float64(g.Info.ChunkCount)
+
+	durationSegment := g.Info.ChunkDuration
+	if durationSegment < minPreviewSegmentDuration {
+		durationSegment = minPreviewSegmentDuration
+		logger.Warnf("[generator] Segment duration (%f) too short. Using %v instead.", g.Info.ChunkDuration, minPreviewSegmentDuration)
+	}
+
+	chunkCount := g.Info.ChunkCount
+	if float64(chunkCount)*durationSegment > available {
+		chunkCount = int(available / durationSegment)
+		if chunkCount < 1 {
+			chunkCount = 1
+		}
+		logger.Warnf("[generator] %d segments of %v seconds exceed the video duration (%v seconds). Using %d segments instead.", g.Info.ChunkCount, durationSegment, available, chunkCount)
+		stepSize = available / float64(chunkCount)
+	}
+
+	var ret []ffmpeg.ScenePreviewChunkOptions
+	for i := 0; i < chunkCount; i++ {
+		ret = append(ret, ffmpeg.ScenePreviewChunkOptions{
+			StartTime: offset + (float64(i) * stepSize),
+			Duration:  durationSegment,
+			Width:     g.Width,
+			Audio:     g.Info.Audio,
+		})
+	}
+
+	return ret
+}
+
 func (g *PreviewGenerator) generateImage(encoder *ffmpeg.Encoder) error {
 	outputPath := filepath.Join(g.OutputDirectory, g.ImageFilename)
 	outputExists, _ := utils.FileExists(outputPath)
@@ -150,7 +183,7 @@ func (g *PreviewGenerator) generateImage(encoder *ffmpeg.Encoder) error {
 
 	videoPreviewPath := filepath.Join(g.OutputDirectory, g.VideoFilename)
 	tmpOutputPath := instance.Paths.Generated.GetTmpPath(g.ImageFilename)
-	if err := encoder.ScenePreviewVideoToImage(g.Info.VideoFile, 640, videoPreviewPath, tmpOutputPath); err != nil {
+	if err := encoder.ScenePreviewVideoToImage(g.Info.VideoFile, g.Width, videoPreviewPath, tmpOutputPath); err != nil {
 		return err
 	}
 	if err := utils.SafeMove(tmpOutputPath, outputPath); err != nil {
@@ -0,0 +1,94 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreviewGeneratorChunkOptions(t *testing.T) {
+	tests := []struct {
+		name          string
+		duration      float64
+		chunkCount    int
+		chunkDuration float64
+		excludeStart  string
+		excludeEnd    string
+		width         int
+		audio         bool
+		want          []ffmpeg.ScenePreviewChunkOptions
+	}{
+		{
+			"evenly spaced",
+			40, 4, 2, "0", "0", 640, true,
+			[]ffmpeg.ScenePreviewChunkOptions{
+				{StartTime: 0, Duration: 2, Width: 640, Audio: true},
+				{StartTime: 10, Duration: 2, Width: 640, Audio: true},
+				{StartTime: 20, Duration: 2, Width: 640, Audio: true},
+				{StartTime: 30, Duration: 2, Width: 640, Audio: true},
+			},
+		},
+		{
+			"excluded start and end",
+			100, 2, 1, "10", "10%", 320, false,
+			[]ffmpeg.ScenePreviewChunkOptions{
+				{StartTime: 10, Duration: 1, Width: 320},
+				{StartTime: 50, Duration: 1, Width: 320},
+			},
+		},
+		{
+			"segment duration too short",
+			20, 2, 0.1, "0", "0", 640, false,
+			[]ffmpeg.ScenePreviewChunkOptions{
+				{StartTime: 0, Duration: minPreviewSegmentDuration, Width: 640},
+				{StartTime: 10, Duration: minPreviewSegmentDuration, Width: 640},
+			},
+		},
+		{
+			"segments exceed duration",
+			10, 12, 3, "0", "0", 640, false,
+			[]ffmpeg.ScenePreviewChunkOptions{
+				{StartTime: 0, Duration: 3, Width: 640},
+				{StartTime: 10.0 / 3, Duration: 3, Width: 640},
+				{StartTime: 20.0 / 3, Duration: 3, Width: 640},
+			},
+		},
+		{
+			"single segment exceeds duration",
+			2, 4, 5, "0", "0", 640, false,
+			[]ffmpeg.ScenePreviewChunkOptions{
+				{StartTime: 0, Duration: 5, Width: 640},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &PreviewGenerator{
+				Info: &GeneratorInfo{
+					ChunkCount:    tt.chunkCount,
+					ChunkDuration: tt.chunkDuration,
+					ExcludeStart:  tt.excludeStart,
+					ExcludeEnd:    tt.excludeEnd,
+					Audio:         tt.audio,
+					VideoFile: ffmpeg.VideoFile{
+						Duration: tt.duration,
+					},
+				},
+				Width: tt.width,
+			}
+
+			got := g.chunkOptions()
+			if !assert.Len(t, got, len(tt.want)) {
+				return
+			}
+
+			for i := range got {
+				assert.InDelta(t, tt.want[i].StartTime, got[i].StartTime, 0.001, "StartTime[%d]", i)
+				got[i].StartTime = tt.want[i].StartTime
+				assert.Equal(t, tt.want[i], got[i])
+			}
+		})
+	}
+}
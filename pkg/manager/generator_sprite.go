@@ -25,18 +25,29 @@ type SpriteGenerator struct {
 	VTTOutputPath   string
 	Rows            int
 	Columns         int
+	ThumbnailWidth  int
 	SlowSeek        bool // use alternate seek function, very slow!
 
 	Overwrite bool
 }
 
-func NewSpriteGenerator(videoFile ffmpeg.VideoFile, videoChecksum string, imageOutputPath string, vttOutputPath string, rows int, cols int) (*SpriteGenerator, error) {
+const spriteThumbnailWidthDefault = 160
+
+// NewSpriteGenerator returns a generator for a sprite image containing
+// chunkCount thumbnails, arranged in a grid with cols columns.
+func NewSpriteGenerator(videoFile ffmpeg.VideoFile, videoChecksum string, imageOutputPath string, vttOutputPath string, chunkCount int, cols int) (*SpriteGenerator, error) {
 	exists, err := utils.FileExists(videoFile.Path)
 	if !exists {
 		return nil, err
 	}
+	if chunkCount < 1 || cols < 1 {
+		return nil, fmt.Errorf("invalid sprite dimensions: %d thumbnails in %d columns", chunkCount, cols)
+	}
+	if cols > chunkCount {
+		cols = chunkCount
+	}
+	rows := int(math.Ceil(float64(chunkCount) / float64(cols)))
 	slowSeek := false
-	chunkCount := rows * cols
 
 	// For files with small duration / low frame count  try to seek using frame number intead of seconds
 	if videoFile.Duration < 5 || (0 < videoFile.FrameCount && videoFile.FrameCount <= int64(chunkCount)) { // some files can have FrameCount == 0, only use SlowSeek  if duration < 5
@@ -47,7 +58,7 @@ func NewSpriteGenerator(videoFile ffmpeg.VideoFile, videoChecksum string, imageO
 		logger.Warnf("[generator] video %s too short (%.3fs, %d frames), using frame seeking", videoFile.Path, videoFile.Duration, videoFile.FrameCount)
 		slowSeek = true
 		// do an actual frame count of the file ( number of frames = read frames)
-		ffprobe := GetInstance().FFProbe
+		ffprobe := GetInstance().FFProbe()
 		fc, err := ffprobe.GetReadFrameCount(&videoFile)
 		if err == nil {
 			if fc != videoFile.FrameCount {
@@ -74,11 +85,12 @@ func NewSpriteGenerator(videoFile ffmpeg.VideoFile, videoChecksum string, imageO
 		Rows:            rows,
 		SlowSeek:        slowSeek,
 		Columns:         cols,
+		ThumbnailWidth:  spriteThumbnailWidthDefault,
 	}, nil
 }
 
 func (g *SpriteGenerator) Generate() error {
-	encoder := instance.FFMPEG
+	encoder := instance.FFMPEG()
 
 	if err := g.generateSpriteImage(&encoder); err != nil {
 		return err
@@ -99,14 +111,14 @@ func (g *SpriteGenerator) generateSpriteImage(encoder *ffmpeg.Encoder) error {
 	if !g.SlowSeek {
 		logger.Infof("[generator] generating sprite image for %s", g.Info.VideoFile.Path)
 		// generate `ChunkCount` thumbnails
-		stepSize := g.Info.VideoFile.Duration / float64(g.Info.ChunkCount)
+		stepSize := g.stepSize()
 
 		for i := 0; i < g.Info.ChunkCount; i++ {
 			time := float64(i) * stepSize
 
 			options := ffmpeg.SpriteScreenshotOptions{
 				Time:  time,
-				Width: 160,
+				Width: g.ThumbnailWidth,
 			}
 
 			img, err := encoder.SpriteScreenshot(g.Info.VideoFile, options)
@@ -129,7 +141,7 @@ func (g *SpriteGenerator) generateSpriteImage(encoder *ffmpeg.Encoder) error {
 			}
 			options := ffmpeg.SpriteScreenshotOptions{
 				Frame: int(frame),
-				Width: 160,
+				Width: g.ThumbnailWidth,
 			}
 			img, err := encoder.SpriteScreenshotSlow(g.Info.VideoFile, options)
 			if err != nil {
@@ -150,8 +162,7 @@ func (g *SpriteGenerator) generateSpriteImage(encoder *ffmpeg.Encoder) error {
 	canvasHeight := height * g.Rows
 	montage := imaging.New(canvasWidth, canvasHeight, color.NRGBA{})
 	for index := 0; index < len(images); index++ {
-		x := width * (index % g.Columns)
-		y := height * int(math.Floor(float64(index)/float64(g.Rows)))
+		x, y := spriteThumbnailPosition(index, g.Columns, width, height)
 		img := images[index]
 		montage = imaging.Paste(montage, img, image.Pt(x, y))
 	}
@@ -178,20 +189,36 @@ func (g *SpriteGenerator) generateSpriteVTT(encoder *ffmpeg.Encoder) error {
 	width := image.Width / g.Columns
 	height := image.Height / g.Rows
 
-	var stepSize float64
+	vtt := spriteVTT(spriteImageName, g.Info.ChunkCount, g.Columns, width, height, g.stepSize())
+
+	return os.WriteFile(g.VTTOutputPath, []byte(vtt), 0644)
+}
+
+// stepSize returns the time in seconds between each sprite thumbnail.
+func (g *SpriteGenerator) stepSize() float64 {
 	if !g.SlowSeek {
-		stepSize = float64(g.Info.NthFrame) / g.Info.FrameRate
-	} else {
-		// for files with a low framecount (<ChunkCount) g.Info.NthFrame can be zero
-		// so recalculate from scratch
-		stepSize = float64(g.Info.VideoFile.FrameCount-1) / float64(g.Info.ChunkCount)
-		stepSize /= g.Info.FrameRate
+		return g.Info.VideoFile.Duration / float64(g.Info.ChunkCount)
 	}
 
+	// for files with a low framecount (<ChunkCount) g.Info.NthFrame can be zero
+	// so calculate from the frame count
+	stepFrame := float64(g.Info.VideoFile.FrameCount-1) / float64(g.Info.ChunkCount)
+	return stepFrame / g.Info.FrameRate
+}
+
+// spriteThumbnailPosition returns the position of the thumbnail with the
+// provided index in the sprite image. Thumbnails are laid out left to right,
+// then top to bottom.
+func spriteThumbnailPosition(index int, columns int, width int, height int) (x int, y int) {
+	return width * (index % columns), height * (index / columns)
+}
+
+// spriteVTT returns the contents of a WebVTT file with a cue for each of the
+// count thumbnails in the sprite image, each lasting stepSize seconds.
+func spriteVTT(spriteImageName string, count int, columns int, width int, height int, stepSize float64) string {
 	vttLines := []string{"WEBVTT", ""}
-	for index := 0; index < g.Info.ChunkCount; index++ {
-		x := width * (index % g.Columns)
-		y := height * int(math.Floor(float64(index)/float64(g.Rows)))
+	for index := 0; index < count; index++ {
+		x, y := spriteThumbnailPosition(index, columns, width, height)
 		startTime := utils.GetVTTTime(float64(index) * stepSize)
 		endTime := utils.GetVTTTime(float64(index+1) * stepSize)
 
@@ -199,9 +226,8 @@ func (g *SpriteGenerator) generateSpriteVTT(encoder *ffmpeg.Encoder) error {
 		vttLines = append(vttLines, fmt.Sprintf("%s#xywh=%d,%d,%d,%d", spriteImageName, x, y, width, height))
 		vttLines = append(vttLines, "")
 	}
-	vtt := strings.Join(vttLines, "\n")
 
-	return os.WriteFile(g.VTTOutputPath, []byte(vtt), 0644)
+	return strings.Join(vttLines, "\n")
 }
 
 func (g *SpriteGenerator) imageExists() bool {
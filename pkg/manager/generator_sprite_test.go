@@ -0,0 +1,91 @@
+package manager
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpriteVTT(t *testing.T) {
+	const (
+		spriteImageName = "sprite.jpg"
+		width           = 160
+		height          = 90
+	)
+
+	tests := []struct {
+		name     string
+		count    int
+		columns  int
+		duration float64
+		// expected coordinates of the last cue
+		lastX int
+		lastY int
+	}{
+		{"default grid", 81, 9, 810, 8 * width, 8 * height},
+		{"wide grid", 20, 10, 100, 9 * width, 1 * height},
+		{"partial last row", 10, 4, 50, 1 * width, 2 * height},
+		{"single column", 3, 1, 30, 0, 2 * height},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			g := &SpriteGenerator{
+				Info: &GeneratorInfo{
+					ChunkCount: tt.count,
+					VideoFile:  ffmpeg.VideoFile{Duration: tt.duration},
+				},
+				Columns: tt.columns,
+			}
+			stepSize := g.stepSize()
+
+			vtt := spriteVTT(spriteImageName, tt.count, tt.columns, width, height, stepSize)
+			lines := strings.Split(vtt, "\n")
+
+			if !assert.Equal("WEBVTT", lines[0]) {
+				return
+			}
+
+			var cues []string
+			for _, l := range lines {
+				if strings.Contains(l, " --> ") {
+					cues = append(cues, l)
+				}
+			}
+			assert.Len(cues, tt.count)
+
+			// cues must cover the scene duration
+			assert.Equal("00:00:00.000 --> 00:00:"+fmt.Sprintf("%02d", int(stepSize))+".000", cues[0])
+			wantEnd := " --> " + fmt.Sprintf("00:%02d:%02d.000", int(tt.duration)/60, int(tt.duration)%60)
+			assert.True(strings.HasSuffix(cues[len(cues)-1], wantEnd), "last cue %q should end at %s", cues[len(cues)-1], wantEnd)
+
+			assert.Contains(lines, fmt.Sprintf("%s#xywh=%d,%d,%d,%d", spriteImageName, 0, 0, width, height))
+			assert.Contains(lines, fmt.Sprintf("%s#xywh=%d,%d,%d,%d", spriteImageName, tt.lastX, tt.lastY, width, height))
+		})
+	}
+}
+
+func TestSpriteThumbnailPosition(t *testing.T) {
+	tests := []struct {
+		index   int
+		columns int
+		x       int
+		y       int
+	}{
+		{0, 9, 0, 0},
+		{8, 9, 80, 0},
+		{9, 9, 0, 50},
+		{12, 5, 20, 100},
+	}
+
+	for _, tt := range tests {
+		x, y := spriteThumbnailPosition(tt.index, tt.columns, 10, 50)
+		assert.Equal(t, tt.x, x, "x for index %d", tt.index)
+		assert.Equal(t, tt.y, y, "y for index %d", tt.index)
+	}
+}
@@ -0,0 +1,285 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// hlsSessionTimeout is the time after the last request of a HLS session
+// after which the session ends and its segments are removed.
+const hlsSessionTimeout = 2 * time.Minute
+
+// hlsRenditions are the resolutions of the renditions offered by HLS
+// sessions, from highest to lowest.
+var hlsRenditions = []models.StreamingResolutionEnum{
+	models.StreamingResolutionEnumFullHd,
+	models.StreamingResolutionEnumStandardHd,
+	models.StreamingResolutionEnumStandard,
+	models.StreamingResolutionEnumLow,
+}
+
+var ErrHLSSegmentNotFound = errors.New("segment not found")
+
+// ErrStreamsDraining is returned when a stream is started while the active
+// streams are being drained for shutdown.
+var ErrStreamsDraining = errors.New("server is shutting down")
+
+// hlsSegmentGenerator transcodes a segment of a rendition to outputPath.
+type hlsSegmentGenerator func(resolution models.StreamingResolutionEnum, index int, outputPath string) error
+
+// HLSStore manages the segments of HLS streams. Segments are transcoded on
+// demand into a temporary directory for each session, which is removed when
+// the session ends. Sessions are registered with the stream and transcode
+// registries while they are active, and end when their transcode is
+// cancelled.
+type HLSStore struct {
+	timeout    time.Duration
+	streams    *StreamRegistry
+	transcodes *TranscodeRegistry
+	sessions   map[string]*HLSSession
+	mutex      sync.Mutex
+}
+
+func NewHLSStore(streams *StreamRegistry, transcodes *TranscodeRegistry) *HLSStore {
+	return &HLSStore{
+		timeout:    hlsSessionTimeout,
+		streams:    streams,
+		transcodes: transcodes,
+		sessions:   make(map[string]*HLSSession),
+	}
+}
+
+// HLSSession is the HLS stream of a scene for a client.
+type HLSSession struct {
+	ID          string
+	SceneID     int
+	ProbeResult ffmpeg.VideoFile
+	Resolutions []models.StreamingResolutionEnum
+
+	dir         string
+	generate    hlsSegmentGenerator
+	transcodeID int
+	timer       *time.Timer
+	segments    map[string]*hlsSegment
+	mutex       sync.Mutex
+}
+
+type hlsSegment struct {
+	once sync.Once
+	path string
+	err  error
+}
+
+// start creates a session for client with a segment directory in dir. The
+// session ends once it has not been accessed for the timeout of the store.
+// Returns ErrStreamsDraining if the stream registry is draining.
+func (s *HLSStore) start(dir string, client string, sceneID int, probeResult ffmpeg.VideoFile, resolutions []models.StreamingResolutionEnum, generate hlsSegmentGenerator) (*HLSSession, error) {
+	const keyLength = 8
+
+	if !s.streams.Begin() {
+		return nil, ErrStreamsDraining
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id := utils.GenerateRandomKey(keyLength)
+	for _, found := s.sessions[id]; found; _, found = s.sessions[id] {
+		id = utils.GenerateRandomKey(keyLength)
+	}
+
+	session := &HLSSession{
+		ID:          id,
+		SceneID:     sceneID,
+		ProbeResult: probeResult,
+		Resolutions: resolutions,
+		dir:         filepath.Join(dir, "hls_"+id),
+		generate:    generate,
+		segments:    make(map[string]*hlsSegment),
+	}
+
+	if err := utils.EnsureDir(session.dir); err != nil {
+		s.streams.End()
+		return nil, fmt.Errorf("creating segment directory: %w", err)
+	}
+
+	session.timer = time.AfterFunc(s.timeout, func() {
+		s.end(id)
+	})
+	session.transcodeID = s.transcodes.Register(RunningTranscode{
+		SceneID:    sceneID,
+		Codec:      ffmpeg.CodecHLS.Codec,
+		MimeType:   ffmpeg.MimeHLS,
+		Resolution: resolutions[0],
+		Client:     client,
+	}, func() {
+		// end the session outside of the store lock
+		go s.end(id)
+	})
+	s.sessions[id] = session
+
+	return session, nil
+}
+
+// Get returns the session with the provided id, or nil if the session has
+// ended. The end of the session is postponed.
+func (s *HLSStore) Get(id string) *HLSSession {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session := s.sessions[id]
+	if session != nil {
+		session.timer.Reset(s.timeout)
+	}
+
+	return session
+}
+
+func (s *HLSStore) end(id string) {
+	s.mutex.Lock()
+	session := s.sessions[id]
+	delete(s.sessions, id)
+	s.mutex.Unlock()
+
+	if session == nil {
+		return
+	}
+
+	session.timer.Stop()
+	s.transcodes.Deregister(session.transcodeID)
+	s.streams.End()
+
+	logger.Debugf("[stream] removing segments of ended HLS session %s", id)
+	if err := os.RemoveAll(session.dir); err != nil {
+		logger.Warnf("error removing HLS segments in %s: %v", session.dir, err)
+	}
+}
+
+// Stop ends all sessions.
+func (s *HLSStore) Stop() {
+	s.mutex.Lock()
+	var ids []string
+	for id := range s.sessions {
+		ids = append(ids, id)
+	}
+	s.mutex.Unlock()
+
+	for _, id := range ids {
+		s.end(id)
+	}
+}
+
+func (s *HLSSession) hasResolution(resolution models.StreamingResolutionEnum) bool {
+	for _, r := range s.Resolutions {
+		if r == resolution {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Segment returns the path of a segment of a rendition, transcoding the
+// segment if it has not been requested before. Concurrent requests of a
+// segment wait for the same transcode.
+func (s *HLSSession) Segment(resolution models.StreamingResolutionEnum, index int) (string, error) {
+	if !s.hasResolution(resolution) || index < 0 || index >= ffmpeg.HLSSegmentCount(s.ProbeResult) {
+		return "", ErrHLSSegmentNotFound
+	}
+
+	name := fmt.Sprintf("%s_%d.ts", resolution, index)
+
+	s.mutex.Lock()
+	segment := s.segments[name]
+	if segment == nil {
+		segment = &hlsSegment{}
+		s.segments[name] = segment
+	}
+	s.mutex.Unlock()
+
+	segment.once.Do(func() {
+		path := filepath.Join(s.dir, name)
+
+		// transcode to a temporary file, so that a partial segment is
+		// never served
+		tmpPath := path + ".tmp"
+		if err := s.generate(resolution, index, tmpPath); err != nil {
+			segment.err = fmt.Errorf("transcoding segment %d of %s: %w", index, resolution, err)
+			return
+		}
+
+		if err := os.Rename(tmpPath, path); err != nil {
+			segment.err = err
+			return
+		}
+
+		segment.path = path
+	})
+
+	if segment.err != nil {
+		// allow the segment to be requested again
+		s.mutex.Lock()
+		delete(s.segments, name)
+		s.mutex.Unlock()
+	}
+
+	return segment.path, segment.err
+}
+
+// sceneHLSResolutions returns the resolutions of the renditions of the
+// scene. Renditions larger than the scene or the maximum transcode size are
+// not offered. The scene is offered in its original resolution if it is
+// smaller than all renditions.
+func sceneHLSResolutions(scene *models.Scene, maxStreamingTranscodeSize models.StreamingResolutionEnum) []models.StreamingResolutionEnum {
+	var ret []models.StreamingResolutionEnum
+	for _, res := range hlsRenditions {
+		if includeSceneStreamPath(scene, res, maxStreamingTranscodeSize) {
+			ret = append(ret, res)
+		}
+	}
+
+	if len(ret) == 0 {
+		ret = append(ret, models.StreamingResolutionEnumOriginal)
+	}
+
+	return ret
+}
+
+// StartSceneHLSSession starts a HLS session of the scene for client, with
+// segments in the temporary directory of the generated files.
+func StartSceneHLSSession(scene *models.Scene, client string) (*HLSSession, error) {
+	ffprobe := instance.FFProbe()
+	videoFile, err := ffprobe.NewVideoFile(scene.Path, false)
+	if err != nil {
+		return nil, fmt.Errorf("error reading video file: %w", err)
+	}
+
+	if err := instance.Paths.Generated.EnsureTmpDir(); err != nil {
+		return nil, err
+	}
+
+	// ffmpeg fails if it tries to transcode a non supported audio codec
+	videoOnly := !scene.AudioCodec.Valid || ffmpeg.AudioCodec(scene.AudioCodec.String) == ffmpeg.MissingUnsupported
+
+	resolutions := sceneHLSResolutions(scene, config.GetInstance().GetMaxStreamingTranscodeSize())
+	encoder := instance.FFMPEG()
+
+	return instance.HLSStore.start(instance.Paths.Generated.Tmp, client, scene.ID, *videoFile, resolutions, func(resolution models.StreamingResolutionEnum, index int, outputPath string) error {
+		return encoder.TranscodeHLSSegment(*videoFile, ffmpeg.HLSSegmentOptions{
+			OutputPath: outputPath,
+			Index:      index,
+			Resolution: resolution,
+			VideoOnly:  videoOnly,
+		})
+	})
+}
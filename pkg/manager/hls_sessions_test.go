@@ -0,0 +1,237 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeSegment(resolution models.StreamingResolutionEnum, index int, outputPath string) error {
+	return os.WriteFile(outputPath, []byte(resolution.String()), 0644)
+}
+
+func TestHLSSessionSegment(t *testing.T) {
+	store := NewHLSStore(NewStreamRegistry(), NewTranscodeRegistry())
+	defer store.Stop()
+
+	var generated int32
+	session, err := store.start(t.TempDir(), "client", 1, ffmpeg.VideoFile{Duration: 25}, []models.StreamingResolutionEnum{
+		models.StreamingResolutionEnumLow,
+	}, func(resolution models.StreamingResolutionEnum, index int, outputPath string) error {
+		atomic.AddInt32(&generated, 1)
+		return writeSegment(resolution, index, outputPath)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// concurrent requests of a segment should transcode it once
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path, err := session.Segment(models.StreamingResolutionEnumLow, 2)
+			assert.NoError(t, err)
+			data, _ := os.ReadFile(path)
+			assert.Equal(t, "LOW", string(data))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, int32(1), generated)
+
+	_, err = session.Segment(models.StreamingResolutionEnumFullHd, 0)
+	assert.ErrorIs(t, err, ErrHLSSegmentNotFound)
+	_, err = session.Segment(models.StreamingResolutionEnumLow, 3)
+	assert.ErrorIs(t, err, ErrHLSSegmentNotFound)
+	_, err = session.Segment(models.StreamingResolutionEnumLow, -1)
+	assert.ErrorIs(t, err, ErrHLSSegmentNotFound)
+}
+
+func TestHLSSessionSegmentError(t *testing.T) {
+	store := NewHLSStore(NewStreamRegistry(), NewTranscodeRegistry())
+	defer store.Stop()
+
+	fail := true
+	session, err := store.start(t.TempDir(), "client", 1, ffmpeg.VideoFile{Duration: 25}, []models.StreamingResolutionEnum{
+		models.StreamingResolutionEnumLow,
+	}, func(resolution models.StreamingResolutionEnum, index int, outputPath string) error {
+		if fail {
+			return errors.New("transcode failed")
+		}
+		return writeSegment(resolution, index, outputPath)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = session.Segment(models.StreamingResolutionEnumLow, 0)
+	assert.Error(t, err)
+
+	// failed segments should be transcoded again
+	fail = false
+	path, err := session.Segment(models.StreamingResolutionEnumLow, 0)
+	assert.NoError(t, err)
+	assert.FileExists(t, path)
+}
+
+func TestHLSSessionCleanup(t *testing.T) {
+	store := NewHLSStore(NewStreamRegistry(), NewTranscodeRegistry())
+	store.timeout = 100 * time.Millisecond
+
+	session, err := store.start(t.TempDir(), "client", 1, ffmpeg.VideoFile{Duration: 25}, []models.StreamingResolutionEnum{
+		models.StreamingResolutionEnumLow,
+	}, writeSegment)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path, err := session.Segment(models.StreamingResolutionEnumLow, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// accessing the session postpones its end
+	for i := 0; i < 3; i++ {
+		time.Sleep(50 * time.Millisecond)
+		assert.Same(t, session, store.Get(session.ID))
+	}
+	assert.FileExists(t, path)
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(session.dir)
+		return os.IsNotExist(err)
+	}, time.Second, 10*time.Millisecond)
+	assert.Nil(t, store.Get(session.ID))
+}
+
+func TestHLSStoreStop(t *testing.T) {
+	store := NewHLSStore(NewStreamRegistry(), NewTranscodeRegistry())
+
+	var sessions []*HLSSession
+	for i := 0; i < 2; i++ {
+		session, err := store.start(t.TempDir(), "client", i, ffmpeg.VideoFile{Duration: 25}, []models.StreamingResolutionEnum{
+			models.StreamingResolutionEnumLow,
+		}, writeSegment)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sessions = append(sessions, session)
+	}
+
+	store.Stop()
+
+	for _, s := range sessions {
+		assert.NoDirExists(t, s.dir)
+		assert.Nil(t, store.Get(s.ID))
+	}
+}
+
+func TestHLSStoreStreams(t *testing.T) {
+	streams := NewStreamRegistry()
+	store := NewHLSStore(streams, NewTranscodeRegistry())
+	defer store.Stop()
+
+	session, err := store.start(t.TempDir(), "client", 1, ffmpeg.VideoFile{Duration: 25}, []models.StreamingResolutionEnum{
+		models.StreamingResolutionEnumLow,
+	}, writeSegment)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// active sessions are registered as streams
+	assert.Equal(t, 1, streams.Active())
+
+	// draining waits for the session to end
+	drained := make(chan int)
+	go func() {
+		drained <- streams.Drain(context.Background())
+	}()
+
+	// new sessions are refused while draining
+	assert.Eventually(t, func() bool {
+		_, err := store.start(t.TempDir(), "client", 2, ffmpeg.VideoFile{Duration: 25}, []models.StreamingResolutionEnum{
+			models.StreamingResolutionEnumLow,
+		}, writeSegment)
+		return errors.Is(err, ErrStreamsDraining)
+	}, time.Second, 10*time.Millisecond)
+
+	store.end(session.ID)
+	assert.Equal(t, 0, <-drained)
+}
+
+func TestHLSStoreTranscodes(t *testing.T) {
+	transcodes := NewTranscodeRegistry()
+	store := NewHLSStore(NewStreamRegistry(), transcodes)
+	defer store.Stop()
+
+	session, err := store.start(t.TempDir(), "client", 1, ffmpeg.VideoFile{Duration: 25}, []models.StreamingResolutionEnum{
+		models.StreamingResolutionEnumStandard,
+		models.StreamingResolutionEnumLow,
+	}, writeSegment)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// active sessions are listed as transcodes
+	list := transcodes.List()
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, 1, list[0].SceneID)
+		assert.Equal(t, ffmpeg.MimeHLS, list[0].MimeType)
+		assert.Equal(t, models.StreamingResolutionEnumStandard, list[0].Resolution)
+		assert.Equal(t, "client", list[0].Client)
+	}
+
+	// cancelling the transcode ends the session
+	assert.True(t, transcodes.Cancel(list[0].ID))
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(session.dir)
+		return os.IsNotExist(err)
+	}, time.Second, 10*time.Millisecond)
+	assert.Nil(t, store.Get(session.ID))
+	assert.Empty(t, transcodes.List())
+}
+
+func TestSceneHLSResolutions(t *testing.T) {
+	makeScene := func(width, height int64) *models.Scene {
+		ret := &models.Scene{}
+		ret.Width.Int64, ret.Width.Valid = width, true
+		ret.Height.Int64, ret.Height.Valid = height, true
+		return ret
+	}
+
+	tests := []struct {
+		name    string
+		scene   *models.Scene
+		maxSize models.StreamingResolutionEnum
+		want    []models.StreamingResolutionEnum
+	}{
+		{"1080p", makeScene(1920, 1080), models.StreamingResolutionEnumOriginal, []models.StreamingResolutionEnum{
+			models.StreamingResolutionEnumFullHd,
+			models.StreamingResolutionEnumStandardHd,
+			models.StreamingResolutionEnumStandard,
+			models.StreamingResolutionEnumLow,
+		}},
+		{"limited", makeScene(1920, 1080), models.StreamingResolutionEnumStandard, []models.StreamingResolutionEnum{
+			models.StreamingResolutionEnumStandard,
+			models.StreamingResolutionEnumLow,
+		}},
+		{"tiny", makeScene(160, 120), models.StreamingResolutionEnumOriginal, []models.StreamingResolutionEnum{
+			models.StreamingResolutionEnumOriginal,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sceneHLSResolutions(tt.scene, tt.maxSize))
+		})
+	}
+}
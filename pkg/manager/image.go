@@ -2,27 +2,19 @@ package manager
 
 import (
 	"archive/zip"
-	"strings"
 
+	"github.com/stashapp/stash/pkg/file"
 	"github.com/stashapp/stash/pkg/logger"
 )
 
 func walkGalleryZip(path string, walkFunc func(file *zip.File) error) error {
-	readCloser, err := zip.OpenReader(path)
+	r, err := file.OpenZip(path)
 	if err != nil {
 		return err
 	}
-	defer readCloser.Close()
-
-	for _, file := range readCloser.File {
-		if file.FileInfo().IsDir() {
-			continue
-		}
-
-		if strings.Contains(file.Name, "__MACOSX") {
-			continue
-		}
+	defer r.Close()
 
+	for _, file := range r.Files() {
 		if !isImage(file.Name) {
 			continue
 		}
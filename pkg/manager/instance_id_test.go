@@ -0,0 +1,41 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstanceID(t *testing.T) {
+	c := config.GetInstance()
+	old := c.GetInstanceID()
+	t.Cleanup(func() {
+		c.Set(config.InstanceID, old)
+	})
+
+	first := &singleton{Config: c, runID: newRunID()}
+	second := &singleton{Config: c, runID: newRunID()}
+
+	tests := []struct {
+		name       string
+		instanceID string
+		want       string
+	}{
+		{"run id", "", first.runID},
+		{"configured", "stash1", "stash1"},
+		{"path", "../stash1", first.runID},
+		{"parent", "..", first.runID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.Set(config.InstanceID, tt.instanceID)
+			assert.Equal(t, tt.want, first.instanceID())
+		})
+	}
+
+	// the temporary files of each run are unique without a configured id
+	c.Set(config.InstanceID, "")
+	assert.NotEqual(t, first.instanceID(), second.instanceID())
+}
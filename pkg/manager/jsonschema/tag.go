@@ -15,6 +15,10 @@ type Tag struct {
 	Parents   []string        `json:"parents,omitempty"`
 	CreatedAt models.JSONTime `json:"created_at,omitempty"`
 	UpdatedAt models.JSONTime `json:"updated_at,omitempty"`
+	// GenerateAliases is whether alias variants of the name are generated.
+	// The generated aliases are not exported, since they are regenerated
+	// on import.
+	GenerateAliases bool `json:"generate_aliases,omitempty"`
 }
 
 func LoadTagFile(filePath string) (*Tag, error) {
@@ -0,0 +1,368 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// libraryWatcherDebounce is the time to wait after the last file system
+// event before changed directories are scanned. Copying a file produces
+// many events, which should result in a single scan.
+const libraryWatcherDebounce = 5 * time.Second
+
+var (
+	errWatchLimit     = errors.New("watch limit reached")
+	errWatcherStopped = errors.New("watcher stopped")
+)
+
+// libraryWatcher watches library directories for changes. Directories with
+// created or modified files are scanned, and directories with removed files
+// are cleaned.
+type libraryWatcher struct {
+	watcher  *fsnotify.Watcher
+	debounce time.Duration
+
+	// skipDir returns true if the directory should not be watched.
+	skipDir func(path string) bool
+	// includeFile returns true if changes to the file should be handled.
+	includeFile func(path string) bool
+
+	scan  func(paths []string)
+	clean func(paths []string)
+
+	mutex        sync.Mutex
+	watched      map[string]bool
+	pendingScan  map[string]bool
+	pendingClean map[string]bool
+	timer        *time.Timer
+	limitReached bool
+
+	done chan struct{}
+}
+
+func newLibraryWatcher(debounce time.Duration, scan func(paths []string), clean func(paths []string)) (*libraryWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	return &libraryWatcher{
+		watcher:      watcher,
+		debounce:     debounce,
+		scan:         scan,
+		clean:        clean,
+		watched:      make(map[string]bool),
+		pendingScan:  make(map[string]bool),
+		pendingClean: make(map[string]bool),
+		done:         make(chan struct{}),
+	}, nil
+}
+
+// watch adds watches to dir and its subdirectories. fsnotify does not watch
+// recursively, so each directory must be watched individually.
+func (w *libraryWatcher) watch(dir string) error {
+	err := utils.SymWalk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			logger.Warnf("error watching %s: %v", path, err)
+			return nil
+		}
+
+		if !info.IsDir() {
+			return nil
+		}
+
+		if w.skipDir != nil && w.skipDir(path) {
+			return filepath.SkipDir
+		}
+
+		return w.addWatch(path)
+	})
+
+	if errors.Is(err, errWatchLimit) || errors.Is(err, errWatcherStopped) {
+		return nil
+	}
+
+	return err
+}
+
+func (w *libraryWatcher) addWatch(path string) error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	select {
+	case <-w.done:
+		return errWatcherStopped
+	default:
+	}
+
+	if w.limitReached {
+		return errWatchLimit
+	}
+
+	if err := w.watcher.Add(path); err != nil {
+		// inotify returns ENOSPC when the maximum number of watches is reached
+		if errors.Is(err, syscall.ENOSPC) || errors.Is(err, syscall.EMFILE) {
+			w.limitReached = true
+			logger.Warnf("Could not watch %s: limit of watched directories reached. Changes to unwatched directories require a manual scan. On Linux, increase fs.inotify.max_user_watches to watch more directories.", path)
+			return errWatchLimit
+		}
+
+		logger.Warnf("error watching %s: %v", path, err)
+		return nil
+	}
+
+	w.watched[path] = true
+	return nil
+}
+
+// unwatch removes the watches of dir and its subdirectories.
+func (w *libraryWatcher) unwatch(dir string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	for path := range w.watched {
+		if utils.IsPathInDir(dir, path) {
+			// watches of removed directories are removed automatically
+			_ = w.watcher.Remove(path)
+			delete(w.watched, path)
+		}
+	}
+}
+
+func (w *libraryWatcher) isWatched(path string) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.watched[path]
+}
+
+// start handles file system events until stop is called.
+func (w *libraryWatcher) start() {
+	go func() {
+		for {
+			select {
+			case <-w.done:
+				return
+			case e, ok := <-w.watcher.Events:
+				if !ok {
+					return
+				}
+				w.handleEvent(e)
+			case err, ok := <-w.watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Warnf("error watching library: %v", err)
+			}
+		}
+	}()
+}
+
+func (w *libraryWatcher) stop() {
+	w.mutex.Lock()
+	close(w.done)
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.mutex.Unlock()
+
+	if err := w.watcher.Close(); err != nil {
+		logger.Warnf("error closing library watcher: %v", err)
+	}
+}
+
+func (w *libraryWatcher) handleEvent(e fsnotify.Event) {
+	path := e.Name
+
+	switch {
+	case e.Op&(fsnotify.Remove|fsnotify.Rename) != 0:
+		if w.isWatched(path) {
+			w.unwatch(path)
+			w.addPending(w.pendingClean, path)
+			return
+		}
+
+		if w.includeFile == nil || w.includeFile(path) {
+			w.addPending(w.pendingClean, filepath.Dir(path))
+		}
+	case e.Op&fsnotify.Create != 0:
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			if w.skipDir != nil && w.skipDir(path) {
+				return
+			}
+
+			// files may have been added before the watch was added, so
+			// scan the whole directory
+			if err := w.watch(path); err != nil {
+				logger.Warnf("error watching %s: %v", path, err)
+			}
+			w.addPending(w.pendingScan, path)
+			return
+		}
+
+		if w.includeFile == nil || w.includeFile(path) {
+			w.addPending(w.pendingScan, filepath.Dir(path))
+		}
+	case e.Op&fsnotify.Write != 0:
+		if w.includeFile == nil || w.includeFile(path) {
+			w.addPending(w.pendingScan, filepath.Dir(path))
+		}
+	}
+}
+
+// addPending adds the directory to pending, and restarts the debounce
+// timer.
+func (w *libraryWatcher) addPending(pending map[string]bool, dir string) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	pending[dir] = true
+
+	if w.timer == nil {
+		w.timer = time.AfterFunc(w.debounce, w.flush)
+	} else {
+		w.timer.Reset(w.debounce)
+	}
+}
+
+func (w *libraryWatcher) flush() {
+	w.mutex.Lock()
+	select {
+	case <-w.done:
+		w.mutex.Unlock()
+		return
+	default:
+	}
+
+	scanDirs := reduceDirs(w.pendingScan)
+	cleanDirs := reduceDirs(w.pendingClean)
+	w.pendingScan = make(map[string]bool)
+	w.pendingClean = make(map[string]bool)
+	w.mutex.Unlock()
+
+	// scan before cleaning, so that moved files are detected by the scan
+	// before the clean removes them
+	if len(scanDirs) > 0 {
+		w.scan(scanDirs)
+	}
+	if len(cleanDirs) > 0 {
+		w.clean(cleanDirs)
+	}
+}
+
+// reduceDirs returns the sorted directories, omitting those within another
+// of the directories.
+func reduceDirs(dirs map[string]bool) []string {
+	var ret []string
+	for dir := range dirs {
+		within := false
+		for other := range dirs {
+			if other != dir && utils.IsPathInDir(other, dir) {
+				within = true
+				break
+			}
+		}
+
+		if !within {
+			ret = append(ret, dir)
+		}
+	}
+
+	sort.Strings(ret)
+	return ret
+}
+
+// RefreshLibraryWatcher stops watching the library paths, then starts
+// watching the configured library paths if enabled. Call this when the
+// library configuration changes.
+func (s *singleton) RefreshLibraryWatcher() {
+	s.libraryWatcherMutex.Lock()
+	defer s.libraryWatcherMutex.Unlock()
+
+	if s.libraryWatcher != nil {
+		s.libraryWatcher.stop()
+		s.libraryWatcher = nil
+	}
+
+	if !s.Config.IsWatchLibrary() {
+		return
+	}
+
+	stashPaths := s.Config.GetStashPaths()
+	if len(stashPaths) == 0 {
+		return
+	}
+
+	ctx := context.Background()
+	w, err := newLibraryWatcher(libraryWatcherDebounce, func(paths []string) {
+		s.events.Publish(TopicLibrary, LibraryEvent{Paths: paths})
+
+		logger.Infof("Scanning changed library directories: %s", strings.Join(paths, ", "))
+		if _, err := s.Scan(ctx, models.ScanMetadataInput{Paths: paths}); err != nil {
+			logger.Warnf("error scanning changed library directories: %v", err)
+		}
+	}, func(paths []string) {
+		s.events.Publish(TopicLibrary, LibraryEvent{Paths: paths, Removed: true})
+
+		s.Clean(ctx, models.CleanMetadataInput{Paths: paths})
+	})
+	if err != nil {
+		logger.Errorf("error creating library watcher: %v", err)
+		return
+	}
+
+	filters := make(map[*models.StashConfig]*scanFilter)
+	for _, sp := range stashPaths {
+		filters[sp] = newScanFilter(sp)
+	}
+	filterFor := func(path string) *scanFilter {
+		for _, sp := range stashPaths {
+			if utils.IsPathInDir(sp.Path, path) {
+				return filters[sp]
+			}
+		}
+		return nil
+	}
+
+	w.skipDir = func(path string) bool {
+		f := filterFor(path)
+		return f == nil || f.skipDir(path)
+	}
+	w.includeFile = func(path string) bool {
+		f := filterFor(path)
+		return f != nil && f.includeFile(path)
+	}
+
+	w.start()
+
+	// adding watches to a large library takes some time
+	go func() {
+		for _, sp := range stashPaths {
+			// remote file systems cannot be watched
+			if file.IsRemotePath(sp.Path) {
+				continue
+			}
+
+			if err := w.watch(sp.Path); err != nil {
+				logger.Warnf("error watching %s: %v", sp.Path, err)
+			}
+		}
+		logger.Infof("Watching library paths for changes")
+	}()
+
+	s.libraryWatcher = w
+}
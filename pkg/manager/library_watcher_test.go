@@ -0,0 +1,175 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+)
+
+const testWatcherDebounce = 50 * time.Millisecond
+
+type watcherJobs struct {
+	scan  chan []string
+	clean chan []string
+}
+
+func newTestLibraryWatcher(t *testing.T) (*libraryWatcher, *watcherJobs) {
+	t.Helper()
+
+	jobs := &watcherJobs{
+		scan:  make(chan []string, 10),
+		clean: make(chan []string, 10),
+	}
+
+	w, err := newLibraryWatcher(testWatcherDebounce, func(paths []string) {
+		jobs.scan <- paths
+	}, func(paths []string) {
+		jobs.clean <- paths
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(w.stop)
+
+	return w, jobs
+}
+
+func expectJob(t *testing.T, c chan []string, want []string) {
+	t.Helper()
+
+	select {
+	case got := <-c:
+		assert.Equal(t, want, got)
+	case <-time.After(5 * time.Second):
+		t.Errorf("expected job for %v", want)
+	}
+}
+
+func expectNoJob(t *testing.T, c chan []string) {
+	t.Helper()
+
+	select {
+	case got := <-c:
+		t.Errorf("unexpected job for %v", got)
+	case <-time.After(2 * testWatcherDebounce):
+	}
+}
+
+func TestLibraryWatcherFileEvents(t *testing.T) {
+	w, jobs := newTestLibraryWatcher(t)
+
+	root := t.TempDir()
+	created := filepath.Join(root, "a", "created.mp4")
+	removed := filepath.Join(root, "b", "removed.mp4")
+
+	w.includeFile = func(path string) bool {
+		return filepath.Ext(path) == ".mp4"
+	}
+
+	// multiple events should be debounced into a single job per directory
+	w.handleEvent(fsnotify.Event{Name: created, Op: fsnotify.Create})
+	w.handleEvent(fsnotify.Event{Name: created, Op: fsnotify.Write})
+	w.handleEvent(fsnotify.Event{Name: created, Op: fsnotify.Write})
+	w.handleEvent(fsnotify.Event{Name: removed, Op: fsnotify.Remove})
+	w.handleEvent(fsnotify.Event{Name: filepath.Join(root, "c", "ignored.txt"), Op: fsnotify.Create})
+	w.handleEvent(fsnotify.Event{Name: created, Op: fsnotify.Chmod})
+
+	expectJob(t, jobs.scan, []string{filepath.Dir(created)})
+	expectJob(t, jobs.clean, []string{filepath.Dir(removed)})
+	expectNoJob(t, jobs.scan)
+	expectNoJob(t, jobs.clean)
+}
+
+func TestLibraryWatcherDirectoryEvents(t *testing.T) {
+	w, jobs := newTestLibraryWatcher(t)
+
+	root := t.TempDir()
+	skipped := filepath.Join(root, "skipped")
+	w.skipDir = func(path string) bool {
+		return path == skipped
+	}
+
+	if err := w.watch(root); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, w.isWatched(root))
+
+	// created directories are watched recursively, and scanned
+	dir := filepath.Join(root, "dir")
+	nested := filepath.Join(dir, "nested")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(skipped, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	w.handleEvent(fsnotify.Event{Name: dir, Op: fsnotify.Create})
+	w.handleEvent(fsnotify.Event{Name: filepath.Join(nested, "file.mp4"), Op: fsnotify.Create})
+	w.handleEvent(fsnotify.Event{Name: skipped, Op: fsnotify.Create})
+
+	assert.True(t, w.isWatched(dir))
+	assert.True(t, w.isWatched(nested))
+	assert.False(t, w.isWatched(skipped))
+
+	// nested directory is scanned as part of its parent
+	expectJob(t, jobs.scan, []string{dir})
+
+	// removed directories are unwatched, and cleaned
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatal(err)
+	}
+	w.handleEvent(fsnotify.Event{Name: dir, Op: fsnotify.Remove})
+
+	assert.False(t, w.isWatched(dir))
+	assert.False(t, w.isWatched(nested))
+
+	expectJob(t, jobs.clean, []string{dir})
+	expectNoJob(t, jobs.scan)
+}
+
+func TestLibraryWatcherWatchesFileSystem(t *testing.T) {
+	w, jobs := newTestLibraryWatcher(t)
+
+	root := t.TempDir()
+	sub := filepath.Join(root, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.watch(root); err != nil {
+		t.Fatal(err)
+	}
+	w.start()
+
+	path := filepath.Join(sub, "new.mp4")
+	if err := os.WriteFile(path, []byte("test"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	expectJob(t, jobs.scan, []string{sub})
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+
+	expectJob(t, jobs.clean, []string{sub})
+}
+
+func TestReduceDirs(t *testing.T) {
+	p := filepath.FromSlash
+
+	got := reduceDirs(map[string]bool{
+		p("/lib/a"):     true,
+		p("/lib/a/b"):   true,
+		p("/lib/ab"):    true,
+		p("/lib/c/d/e"): true,
+	})
+
+	assert.Equal(t, []string{p("/lib/a"), p("/lib/ab"), p("/lib/c/d/e")}, got)
+}
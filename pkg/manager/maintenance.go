@@ -0,0 +1,35 @@
+package manager
+
+import (
+	"sync/atomic"
+
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+// SetMaintenance turns maintenance mode on or off. While in maintenance
+// mode, the HTTP layer refuses media requests and the job manager does not
+// start new jobs, so that risky operations such as migrations and restores
+// can be run safely. Maintenance mode is not persisted.
+func (s *singleton) SetMaintenance(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+
+	if atomic.SwapInt32(&s.maintenance, v) == v {
+		return
+	}
+
+	if on {
+		logger.Info("Entering maintenance mode")
+		s.JobManager.Pause()
+	} else {
+		logger.Info("Leaving maintenance mode")
+		s.JobManager.Resume()
+	}
+}
+
+// IsMaintenance returns true if maintenance mode is on.
+func (s *singleton) IsMaintenance() bool {
+	return atomic.LoadInt32(&s.maintenance) == 1
+}
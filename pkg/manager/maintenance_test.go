@@ -0,0 +1,49 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetMaintenance(t *testing.T) {
+	s := &singleton{
+		Config:     config.GetInstance(),
+		JobManager: job.NewManager(),
+	}
+	defer s.JobManager.Stop()
+
+	assert := assert.New(t)
+	assert.False(s.GetSystemStatus().Maintenance)
+
+	s.SetMaintenance(true)
+	assert.True(s.IsMaintenance())
+	assert.True(s.GetSystemStatus().Maintenance)
+	assert.True(s.JobManager.IsPaused())
+
+	started := make(chan struct{})
+	s.JobManager.Add(context.Background(), "job", job.MakeJobExec(func(ctx context.Context, progress *job.Progress) {
+		close(started)
+	}))
+
+	select {
+	case <-started:
+		t.Error("job was started in maintenance mode")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	s.SetMaintenance(false)
+	assert.False(s.IsMaintenance())
+	assert.False(s.GetSystemStatus().Maintenance)
+	assert.False(s.JobManager.IsPaused())
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Error("job was not started after leaving maintenance mode")
+	}
+}
@@ -10,9 +10,11 @@ import (
 	"sync"
 	"time"
 
+	"github.com/stashapp/stash/pkg/audit"
 	"github.com/stashapp/stash/pkg/database"
 	"github.com/stashapp/stash/pkg/dlna"
 	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/file"
 	"github.com/stashapp/stash/pkg/job"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager/config"
@@ -30,28 +32,85 @@ type singleton struct {
 
 	Paths *paths.Paths
 
-	FFMPEG  ffmpeg.Encoder
-	FFProbe ffmpeg.FFProbe
+	// ffmpegMutex guards encoder and ffprobe, which are replaced when the
+	// binaries are refreshed
+	ffmpegMutex sync.RWMutex
+	encoder     ffmpeg.Encoder
+	ffprobe     ffmpeg.FFProbe
+	// ffmpegCapabilities are the capabilities of encoder, or nil if they
+	// could not be probed
+	ffmpegCapabilities *ffmpeg.Capabilities
 
 	SessionStore *session.Store
+	Audit        *audit.Log
 
 	JobManager *job.Manager
+	Scheduler  *job.Scheduler
 
 	PluginCache  *plugin.Cache
 	ScraperCache *scraper.Cache
 
 	DownloadStore *DownloadStore
+	HLSStore      *HLSStore
+	Streams       *StreamRegistry
+	GenerateStats *GenerateStats
+
+	TranscodeCache    *TranscodeCache
+	Transcodes        *TranscodeRegistry
+	PartialTranscodes *PartialTranscodes
+	ThumbnailCache    *ThumbnailCache
+	GeneratedUsage    *GeneratedUsageCache
+	Resources         *ResourceManager
+	StashPathMonitor  *StashPathMonitor
+	EncodeBenchmark   *EncodeBenchmark
+	QueryStats        *sqlite.QueryStats
 
 	DLNAService *dlna.Service
 
 	TxnManager models.TransactionManager
 
-	scanSubs *subscriptionManager
+	events *eventBus
+
+	libraryWatcher      *libraryWatcher
+	libraryWatcherMutex sync.Mutex
+
+	// maintenance is 1 if maintenance mode is on. Accessed atomically.
+	maintenance int32
+
+	// runID identifies the temporary files of this run if the instance ID
+	// is not configured.
+	runID string
 }
 
 var instance *singleton
 var once sync.Once
 
+// staleTmpAge is the time after which the temporary directories of other
+// runs are removed on startup.
+const staleTmpAge = 24 * time.Hour
+
+// newRunID returns a random ID for the temporary files of a run.
+func newRunID() string {
+	return "run-" + utils.GenerateRandomKey(4)
+}
+
+// instanceID returns the ID naming the temporary directory of the instance:
+// the configured instance ID, or the ID of the run if not set or if the
+// configured ID is not a valid directory name.
+func (s *singleton) instanceID() string {
+	id := s.Config.GetInstanceID()
+	if id == "" {
+		return s.runID
+	}
+
+	if id != filepath.Base(id) || id == "." || id == ".." {
+		logger.Warnf("Invalid instance ID %q, using %s", id, s.runID)
+		return s.runID
+	}
+
+	return id
+}
+
 func GetInstance() *singleton {
 	Initialize()
 	return instance
@@ -69,17 +128,44 @@ func Initialize() *singleton {
 		initLog()
 		initProfiling(cfg.GetCPUProfilePath())
 
-		instance = &singleton{
-			Config:        cfg,
-			JobManager:    job.NewManager(),
-			DownloadStore: NewDownloadStore(),
-			PluginCache:   plugin.NewCache(cfg),
+		streams := NewStreamRegistry()
+		transcodes := NewTranscodeRegistry()
 
-			TxnManager: sqlite.NewTransactionManager(),
+		instance = &singleton{
+			Config:            cfg,
+			runID:             newRunID(),
+			JobManager:        job.NewManager(),
+			Scheduler:         job.NewScheduler(cfg.GetLocation()),
+			DownloadStore:     NewDownloadStore(),
+			HLSStore:          NewHLSStore(streams, transcodes),
+			Streams:           streams,
+			GenerateStats:     NewGenerateStats(),
+			Transcodes:        transcodes,
+			PartialTranscodes: NewPartialTranscodes(),
+			GeneratedUsage:    NewGeneratedUsageCache(generatedUsageTTL),
+			Resources:         NewResourceManager(Resources{}),
+			PluginCache:       plugin.NewCache(cfg),
+			Audit:             audit.NewLog(cfg.GetAuditLogPath()),
+			QueryStats:        sqlite.NewQueryStats(),
+
+			events: newEventBus(),
+		}
 
-			scanSubs: &subscriptionManager{},
+		instance.TxnManager = &sqlite.TransactionManager{
+			RetryConfig: cfg,
+			QueryStats:  instance.QueryStats,
 		}
 
+		instance.StashPathMonitor = NewStashPathMonitor(instance.stashPaths, func(e StashPathEvent) {
+			instance.events.Publish(TopicStashPath, e)
+		})
+
+		instance.EncodeBenchmark = NewEncodeBenchmark(func() error {
+			return checkFFMPEGEncode(instance.FFMPEG())
+		})
+
+		go forwardJobEvents(instance.JobManager.Subscribe(ctx), instance.events)
+
 		sceneServer := SceneServer{
 			TXNManager: instance.TxnManager,
 		}
@@ -107,7 +193,7 @@ func Initialize() *singleton {
 
 			// create temporary session store - this will be re-initialised
 			// after config is complete
-			instance.SessionStore = session.NewStore(cfg)
+			instance.SessionStore = session.NewStore(cfg, instance.Audit)
 
 			logger.Warnf("config file %snot found. Assuming new system...", cfgFile)
 		}
@@ -158,11 +244,8 @@ func initFFMPEG() error {
 	if instance.Config.GetConfigFile() != "" {
 		// use same directory as config path
 		configDirectory := instance.Config.GetConfigPath()
-		paths := []string{
-			configDirectory,
-			paths.GetStashHomeDirectory(),
-		}
-		ffmpegPath, ffprobePath := ffmpeg.GetPaths(paths)
+		searchPaths := ffmpegSearchPaths(configDirectory)
+		ffmpegPath, ffprobePath := ffmpeg.GetPaths(searchPaths)
 
 		if ffmpegPath == "" || ffprobePath == "" {
 			logger.Infof("couldn't find FFMPEG, attempting to download it")
@@ -178,12 +261,19 @@ func initFFMPEG() error {
 				return err
 			} else {
 				// After download get new paths for ffmpeg and ffprobe
-				ffmpegPath, ffprobePath = ffmpeg.GetPaths(paths)
+				ffmpegPath, ffprobePath = ffmpeg.GetPaths(searchPaths)
 			}
 		}
 
-		instance.FFMPEG = ffmpeg.Encoder(ffmpegPath)
-		instance.FFProbe = ffmpeg.FFProbe(ffprobePath)
+		capabilities, err := ffmpeg.ProbeCapabilities(ffmpegPath)
+		if err != nil {
+			logger.Warnf("could not probe FFMPEG capabilities: %v", err)
+		}
+
+		instance.setFFMPEG(ffmpeg.Encoder(ffmpegPath), ffmpeg.FFProbe{
+			Path:    ffprobePath,
+			Timeout: instance.Config.GetFFProbeTimeout(),
+		}, capabilities)
 	}
 
 	return nil
@@ -192,6 +282,7 @@ func initFFMPEG() error {
 func initLog() {
 	config := config.GetInstance()
 	logger.Init(config.GetLogFile(), config.GetLogOut(), config.GetLogLevel())
+	logger.SetLocation(config.GetLocation())
 }
 
 // PostInit initialises the paths, caches and txnManager after the initial
@@ -202,9 +293,9 @@ func (s *singleton) PostInit(ctx context.Context) error {
 		logger.Warnf("could not set initial configuration: %v", err)
 	}
 
-	s.Paths = paths.NewPaths(s.Config.GetGeneratedPath())
+	s.Paths = paths.NewInstancePaths(s.Config.GetGeneratedPath(), s.instanceID())
 	s.RefreshConfig()
-	s.SessionStore = session.NewStore(s.Config)
+	s.SessionStore = session.NewStore(s.Config, s.Audit)
 	s.PluginCache.RegisterSessionStore(s.SessionStore)
 
 	if err := s.PluginCache.LoadPlugins(); err != nil {
@@ -222,9 +313,12 @@ func (s *singleton) PostInit(ctx context.Context) error {
 			if err := utils.EmptyDir(instance.Paths.Generated.Downloads); err != nil {
 				logger.Warnf("could not empty Downloads directory: %v", err)
 			}
-			if err := utils.EmptyDir(instance.Paths.Generated.Tmp); err != nil {
+			if err := utils.EmptyDir(instance.Paths.Generated.Tmp); err != nil && !os.IsNotExist(err) {
 				logger.Warnf("could not empty Tmp directory: %v", err)
 			}
+			if err := instance.Paths.Generated.RemoveStaleTmpDirs(staleTmpAge); err != nil {
+				logger.Warnf("could not remove stale temporary directories: %v", err)
+			}
 		}, deleteTimeout, func(done chan struct{}) {
 			logger.Info("Please wait. Deleting temporary files...") // print
 			<-done                                                  // and wait for deletion
@@ -236,7 +330,14 @@ func (s *singleton) PostInit(ctx context.Context) error {
 		return err
 	}
 
-	if database.Ready() == nil {
+	// the migration is left to the UI if it fails
+	migrated, err := s.autoMigrator().run(ctx)
+	if err != nil {
+		logger.Error(err.Error())
+	}
+
+	// PostMigrate has been run by the migration
+	if database.Ready() == nil && !migrated {
 		s.PostMigrate(ctx)
 	}
 
@@ -255,7 +356,21 @@ func (s *singleton) initScraperCache() *scraper.Cache {
 }
 
 func (s *singleton) RefreshConfig() {
-	s.Paths = paths.NewPaths(s.Config.GetGeneratedPath())
+	s.Paths = paths.NewInstancePaths(s.Config.GetGeneratedPath(), s.instanceID())
+	s.setFFProbeTimeout(s.Config.GetFFProbeTimeout())
+	s.refreshFileSystems()
+	s.refreshTranscodeCache()
+	s.refreshThumbnailCache()
+	s.refreshResourceLimits()
+	s.refreshLocation()
+	models.SetMaxPerPage(s.Config.GetMaxPageSize())
+	s.QueryStats.SetEnabled(s.Config.GetQueryStats())
+	s.refreshScanSchedules()
+	s.refreshTranscodeCleanupSchedule()
+	s.StashPathMonitor.SetInterval(s.Config.GetStashPathCheckInterval())
+	s.EncodeBenchmark.SetThreshold(s.Config.GetEncodeLatencyThreshold())
+	s.EncodeBenchmark.SetInterval(s.Config.GetEncodeBenchmarkInterval())
+	s.Audit.SetPath(s.Config.GetAuditLogPath())
 	config := s.Config
 	if config.Validate() == nil {
 		if err := utils.EnsureDir(s.Paths.Generated.Screenshots); err != nil {
@@ -277,6 +392,64 @@ func (s *singleton) RefreshConfig() {
 			logger.Warnf("could not create directory for Interactive Heatmaps: %v", err)
 		}
 	}
+
+	s.events.Publish(TopicConfig, nil)
+}
+
+// refreshFileSystems registers the remote file systems using the current
+// configuration.
+func (s *singleton) refreshFileSystems() {
+	username := s.Config.GetWebDAVUsername()
+	password := s.Config.GetWebDAVPassword()
+	file.RegisterFileSystem(file.WebDAVScheme, file.NewWebDAVFileSystem(false, username, password, nil))
+	file.RegisterFileSystem(file.WebDAVSScheme, file.NewWebDAVFileSystem(true, username, password, nil))
+}
+
+// refreshTranscodeCache sets the directory and size of the transcode cache
+// using the current configuration.
+func (s *singleton) refreshTranscodeCache() {
+	dir := s.Paths.Generated.TranscodeCache
+	maxSize := int64(s.Config.GetTranscodeCacheSize()) * 1024 * 1024
+
+	if s.TranscodeCache != nil && s.TranscodeCache.dir == dir {
+		s.TranscodeCache.SetMaxSize(maxSize)
+		return
+	}
+
+	s.TranscodeCache = NewTranscodeCache(dir, maxSize)
+}
+
+// refreshResourceLimits sets the limits of the resources shared by heavy
+// work using the current configuration.
+func (s *singleton) refreshResourceLimits() {
+	s.Resources.SetLimits(Resources{
+		CPU:    s.Config.GetResourceLimitCPU(),
+		IO:     s.Config.GetResourceLimitIO(),
+		Memory: s.Config.GetResourceLimitMemory(),
+	})
+}
+
+// refreshThumbnailCache sets the directory and size of the image thumbnail
+// cache using the current configuration.
+func (s *singleton) refreshThumbnailCache() {
+	dir := s.Paths.Generated.ThumbnailCache
+	maxSize := int64(s.Config.GetImageThumbnailCacheSize()) * 1024 * 1024
+
+	if s.ThumbnailCache != nil && s.ThumbnailCache.dir == dir {
+		s.ThumbnailCache.SetMaxSize(maxSize)
+		return
+	}
+
+	s.ThumbnailCache = NewThumbnailCache(dir, maxSize)
+}
+
+// stashPaths returns the paths of the configured stashes.
+func (s *singleton) stashPaths() []string {
+	var ret []string
+	for _, sp := range s.Config.GetStashPaths() {
+		ret = append(ret, sp.Path)
+	}
+	return ret
 }
 
 // RefreshScraperCache refreshes the scraper cache. Call this when scraper
@@ -300,22 +473,33 @@ func setSetupDefaults(input *models.SetupInput) {
 	}
 }
 
-func (s *singleton) Setup(ctx context.Context, input models.SetupInput) error {
+// Setup creates the configuration and database of a new system. The
+// results of the self-test are returned if it is run.
+func (s *singleton) Setup(ctx context.Context, input models.SetupInput) ([]*models.SelfTestResult, error) {
 	setSetupDefaults(&input)
 	c := s.Config
 
+	// ensure that the config file can be written before creating anything
+	configFile := input.ConfigLocation
+	if config.FileEnvSet() {
+		configFile = c.GetConfigFile()
+	}
+	if err := config.CheckWritable(configFile); err != nil {
+		return nil, fmt.Errorf("error writing configuration file: %w", err)
+	}
+
 	// create the config directory if it does not exist
 	// don't do anything if config is already set in the environment
 	if !config.FileEnvSet() {
 		configDir := filepath.Dir(input.ConfigLocation)
 		if exists, _ := utils.DirExists(configDir); !exists {
 			if err := os.Mkdir(configDir, 0755); err != nil {
-				return fmt.Errorf("error creating config directory: %v", err)
+				return nil, fmt.Errorf("error creating config directory: %v", err)
 			}
 		}
 
 		if err := utils.Touch(input.ConfigLocation); err != nil {
-			return fmt.Errorf("error creating config file: %v", err)
+			return nil, fmt.Errorf("error creating config file: %v", err)
 		}
 
 		s.Config.SetConfigFile(input.ConfigLocation)
@@ -325,7 +509,7 @@ func (s *singleton) Setup(ctx context.Context, input models.SetupInput) error {
 	if !c.HasOverride(config.Generated) {
 		if exists, _ := utils.DirExists(input.GeneratedLocation); !exists {
 			if err := os.Mkdir(input.GeneratedLocation, 0755); err != nil {
-				return fmt.Errorf("error creating generated directory: %v", err)
+				return nil, fmt.Errorf("error creating generated directory: %v", err)
 			}
 		}
 
@@ -339,25 +523,44 @@ func (s *singleton) Setup(ctx context.Context, input models.SetupInput) error {
 
 	s.Config.Set(config.Stash, input.Stashes)
 	if err := s.Config.Write(); err != nil {
-		return fmt.Errorf("error writing configuration file: %v", err)
+		return nil, fmt.Errorf("error writing configuration file: %w", err)
 	}
 
 	// initialise the database
 	if err := s.PostInit(ctx); err != nil {
-		return fmt.Errorf("error initializing the database: %v", err)
+		return nil, fmt.Errorf("error initializing the database: %v", err)
 	}
 
 	s.Config.FinalizeSetup()
 
 	if err := initFFMPEG(); err != nil {
-		return fmt.Errorf("error initializing FFMPEG subsystem: %v", err)
+		return nil, fmt.Errorf("error initializing FFMPEG subsystem: %v", err)
 	}
 
-	return nil
+	if input.SelfTest == nil || *input.SelfTest {
+		return s.SelfTest(ctx), nil
+	}
+
+	return nil, nil
+}
+
+// AuditEvent records an event in the audit log, attributed to the current
+// user of the context.
+func (s *singleton) AuditEvent(ctx context.Context, eventType audit.EventType, message string, data map[string]string) {
+	s.Audit.Record(session.NewAuditEntry(ctx, eventType, message, data))
+}
+
+// refreshLocation applies the configured time zone to schedules, and to
+// log and export timestamps.
+func (s *singleton) refreshLocation() {
+	loc := s.Config.GetLocation()
+	logger.SetLocation(loc)
+	models.SetJSONTimeLocation(loc)
+	s.Scheduler.SetLocation(loc)
 }
 
 func (s *singleton) validateFFMPEG() error {
-	if s.FFMPEG == "" || s.FFProbe == "" {
+	if s.FFMPEG() == "" || s.FFProbe().Path == "" {
 		return errors.New("missing ffmpeg and/or ffprobe")
 	}
 
@@ -378,22 +581,19 @@ func (s *singleton) Migrate(ctx context.Context, input models.MigrateInput) erro
 	}
 
 	if err := database.RunMigrations(); err != nil {
-		errStr := fmt.Sprintf("error performing migration: %s", err)
-
-		// roll back to the backed up version
-		restoreErr := database.RestoreFromBackup(backupPath)
-		if restoreErr != nil {
-			errStr = fmt.Sprintf("ERROR: unable to restore database from backup after migration failure: %s\n%s", restoreErr.Error(), errStr)
-		} else {
-			errStr = "An error occurred migrating the database to the latest schema version. The backup database file was automatically renamed to restore the database.\n" + errStr
-		}
-
-		return errors.New(errStr)
+		return restoreMigrationBackup(backupPath, fmt.Sprintf("error performing migration: %s", err))
 	}
 
 	// perform post-migration operations
 	s.PostMigrate(ctx)
 
+	if s.Config.GetVerifyMigration() {
+		logger.Info("Verifying migrated database")
+		if err := database.VerifyMigration(backupPath); err != nil {
+			return restoreMigrationBackup(backupPath, fmt.Sprintf("error verifying migration: %s", err))
+		}
+	}
+
 	// if no backup path was provided, then delete the created backup
 	if input.BackupPath == "" {
 		if err := os.Remove(backupPath); err != nil {
@@ -404,6 +604,19 @@ func (s *singleton) Migrate(ctx context.Context, input models.MigrateInput) erro
 	return nil
 }
 
+// restoreMigrationBackup rolls back to the backed up version of the database
+// after a failed migration, returning an error describing the failure.
+func restoreMigrationBackup(backupPath string, errStr string) error {
+	restoreErr := database.RestoreFromBackup(backupPath)
+	if restoreErr != nil {
+		errStr = fmt.Sprintf("ERROR: unable to restore database from backup after migration failure: %s\n%s", restoreErr.Error(), errStr)
+	} else {
+		errStr = "An error occurred migrating the database to the latest schema version. The backup database file was automatically renamed to restore the database.\n" + errStr
+	}
+
+	return errors.New(errStr)
+}
+
 func (s *singleton) GetSystemStatus() *models.SystemStatus {
 	status := models.SystemStatusEnumOk
 	dbSchema := int(database.Version())
@@ -423,13 +636,25 @@ func (s *singleton) GetSystemStatus() *models.SystemStatus {
 		AppSchema:      appSchema,
 		Status:         status,
 		ConfigPath:     &configFile,
+		ConfigReadOnly: s.Config.IsReadOnly(),
+		Maintenance:    s.IsMaintenance(),
+		OfflinePaths:   s.StashPathMonitor.OfflinePaths(),
+		Version:        s.Version().VersionModel(),
+		EncodeLatency:  s.EncodeBenchmark.Latency(),
 	}
 }
 
 // Shutdown gracefully stops the manager
 func (s *singleton) Shutdown(code int) {
 	// TODO: Each part of the manager needs to gracefully stop at some point
-	// for now, we just close the database.
+	// for now, we just drain the active streams, remove the HLS segments and
+	// close the database.
+	s.drainStreams()
+	s.HLSStore.Stop()
+	s.Scheduler.Stop()
+	s.StashPathMonitor.Stop()
+	s.EncodeBenchmark.Stop()
+
 	err := database.Close()
 	if err != nil {
 		logger.Errorf("Error closing database: %s", err)
@@ -439,3 +664,24 @@ func (s *singleton) Shutdown(code int) {
 	}
 	os.Exit(code)
 }
+
+// drainStreams stops new streams from being accepted, and waits for the
+// active streams to finish, up to the shutdown grace period.
+func (s *singleton) drainStreams() {
+	if s.Streams == nil {
+		return
+	}
+
+	active := s.Streams.Active()
+	if active > 0 {
+		logger.Infof("Waiting for %d active streams to finish", active)
+	}
+
+	grace := time.Duration(s.Config.GetShutdownGracePeriod()) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), grace)
+	defer cancel()
+
+	if remaining := s.Streams.Drain(ctx); remaining > 0 {
+		logger.Warnf("Shutting down with %d active streams", remaining)
+	}
+}
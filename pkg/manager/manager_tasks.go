@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strconv"
 	"sync"
+	"time"
 
 	"github.com/stashapp/stash/pkg/job"
 	"github.com/stashapp/stash/pkg/logger"
@@ -51,10 +52,36 @@ func getScanPaths(inputPaths []string) []*models.StashConfig {
 	return ret
 }
 
+// Subscribe subscribes to the events of the topic. The returned channel is
+// closed when ctx is done.
+func (s *singleton) Subscribe(ctx context.Context, topic Topic) <-chan Event {
+	return s.events.Subscribe(ctx, topic)
+}
+
+// Publish publishes an event with the payload to the subscribers of the
+// topic.
+func (s *singleton) Publish(topic Topic, payload interface{}) {
+	s.events.Publish(topic, payload)
+}
+
 // ScanSubscribe subscribes to a notification that is triggered when a
 // scan or clean is complete.
 func (s *singleton) ScanSubscribe(ctx context.Context) <-chan bool {
-	return s.scanSubs.subscribe(ctx)
+	events := s.Subscribe(ctx, TopicScan)
+	ret := make(chan bool, subscriptionBufferSize)
+
+	go func() {
+		defer close(ret)
+		for range events {
+			select {
+			case ret <- true:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ret
 }
 
 func (s *singleton) Scan(ctx context.Context, input models.ScanMetadataInput) (int, error) {
@@ -63,14 +90,27 @@ func (s *singleton) Scan(ctx context.Context, input models.ScanMetadataInput) (i
 	}
 
 	scanJob := ScanJob{
-		txnManager:    s.TxnManager,
-		input:         input,
-		subscriptions: s.scanSubs,
+		txnManager:  s.TxnManager,
+		input:       input,
+		events:      s.events,
+		pathMonitor: s.StashPathMonitor,
 	}
 
 	return s.JobManager.Add(ctx, "Scanning...", &scanJob), nil
 }
 
+// ScanPath starts a scan of a single file or directory, which must be
+// within a library. The scan settings of the library are used, or the
+// default scan settings if it has none.
+func (s *singleton) ScanPath(ctx context.Context, path string) (int, error) {
+	stash, err := targetedScanPath(path, s.Config.GetStashPaths())
+	if err != nil {
+		return 0, err
+	}
+
+	return s.Scan(ctx, scanInput(stash, s.Config.GetDefaultScanSettings()))
+}
+
 func (s *singleton) Import(ctx context.Context) (int, error) {
 	config := config.GetInstance()
 	metadataPath := config.GetMetadataPath()
@@ -138,6 +178,11 @@ func setGeneratePreviewOptionsInput(optionsInput *models.GeneratePreviewOptionsI
 		optionsInput.PreviewSegmentDuration = &val
 	}
 
+	if optionsInput.PreviewWidth == nil {
+		val := config.GetPreviewWidth()
+		optionsInput.PreviewWidth = &val
+	}
+
 	if optionsInput.PreviewExcludeStart == nil {
 		val := config.GetPreviewExcludeStart()
 		optionsInput.PreviewExcludeStart = &val
@@ -165,11 +210,56 @@ func (s *singleton) Generate(ctx context.Context, input models.GenerateMetadataI
 	j := &GenerateJob{
 		txnManager: s.TxnManager,
 		input:      input,
+		events:     s.events,
+		stats:      s.GenerateStats,
+		failures:   s.generateFailureRecorder(false),
 	}
 
 	return s.JobManager.Add(ctx, "Generating...", j), nil
 }
 
+// RetryGenerate starts a job that retries the failed generate items that
+// are due to be retried.
+func (s *singleton) RetryGenerate(ctx context.Context) (int, error) {
+	if err := s.validateFFMPEG(); err != nil {
+		return 0, err
+	}
+	if err := instance.Paths.Generated.EnsureTmpDir(); err != nil {
+		logger.Warnf("could not generate temporary directory: %v", err)
+	}
+
+	j := &GenerateJob{
+		txnManager: s.TxnManager,
+		events:     s.events,
+		stats:      s.GenerateStats,
+		failures:   s.generateFailureRecorder(true),
+		retry:      true,
+		maxRetries: s.Config.GetGenerateMaxRetries(),
+	}
+
+	return s.JobManager.Add(ctx, "Retrying failed generate items...", j), nil
+}
+
+func (s *singleton) generateFailureRecorder(clearSucceeded bool) *generateFailureRecorder {
+	return &generateFailureRecorder{
+		txnManager:     s.TxnManager,
+		backoff:        time.Duration(s.Config.GetGenerateRetryBackoff()) * time.Second,
+		clearSucceeded: clearSucceeded,
+	}
+}
+
+// EstimateGenerate estimates the artifacts that a generate would create,
+// without generating them.
+func (s *singleton) EstimateGenerate(ctx context.Context, input models.GenerateMetadataInput) (*models.GenerateEstimate, error) {
+	j := &GenerateJob{
+		txnManager: s.TxnManager,
+		input:      input,
+		stats:      s.GenerateStats,
+	}
+
+	return j.Estimate(ctx, s.Config.GetParallelTasksWithAutoDetection())
+}
+
 func (s *singleton) GenerateDefaultScreenshot(ctx context.Context, sceneId string) int {
 	return s.generateScreenshot(ctx, sceneId, nil)
 }
@@ -206,6 +296,8 @@ func (s *singleton) generateScreenshot(ctx context.Context, sceneId string, at *
 			Scene:               *scene,
 			ScreenshotAt:        at,
 			fileNamingAlgorithm: config.GetInstance().GetVideoFileNamingAlgorithm(),
+			format:              s.screenshotFormat(),
+			quality:             config.GetInstance().GetScreenshotQuality(),
 		}
 
 		task.Start(ctx)
@@ -227,14 +319,69 @@ func (s *singleton) AutoTag(ctx context.Context, input models.AutoTagMetadataInp
 
 func (s *singleton) Clean(ctx context.Context, input models.CleanMetadataInput) int {
 	j := cleanJob{
-		txnManager: s.TxnManager,
-		input:      input,
-		scanSubs:   s.scanSubs,
+		txnManager:    s.TxnManager,
+		input:         input,
+		events:        s.events,
+		pathMonitor:   s.StashPathMonitor,
+		missingPolicy: s.Config.GetMissingFilePolicy(),
 	}
 
 	return s.JobManager.Add(ctx, "Cleaning...", &j)
 }
 
+func (s *singleton) CleanJunkFiles(ctx context.Context, input models.CleanJunkFilesInput) int {
+	var stashPaths []string
+	for _, p := range config.GetInstance().GetStashPaths() {
+		stashPaths = append(stashPaths, p.Path)
+	}
+
+	j := cleanJunkJob{
+		input:      input,
+		stashPaths: stashPaths,
+		patterns:   config.GetInstance().GetJunkFilePatterns(),
+	}
+
+	return s.JobManager.Add(ctx, "Cleaning junk files...", &j)
+}
+
+func (s *singleton) CleanGenerated(ctx context.Context, input models.CleanGeneratedInput) int {
+	j := cleanGeneratedJob{
+		txnManager: s.TxnManager,
+		paths:      s.Paths,
+		dryRun:     input.DryRun,
+	}
+
+	return s.JobManager.Add(ctx, "Cleaning generated files...", &j)
+}
+
+// ResolveGalleryCovers starts a job that resolves the covers of all
+// galleries using the configured strategy.
+func (s *singleton) ResolveGalleryCovers(ctx context.Context) int {
+	j := job.MakeJobExec(func(ctx context.Context, progress *job.Progress) {
+		var galleryIDs []int
+		if err := s.TxnManager.WithReadTxn(ctx, func(r models.ReaderRepository) error {
+			galleries, err := r.Gallery().All()
+			if err != nil {
+				return err
+			}
+
+			for _, g := range galleries {
+				galleryIDs = append(galleryIDs, g.ID)
+			}
+			return nil
+		}); err != nil {
+			logger.Errorf("Error finding galleries: %v", err)
+			return
+		}
+
+		if err := resolveGalleryCovers(ctx, s.TxnManager, s.Config.GetGalleryCoverStrategy(), galleryIDs); err != nil {
+			logger.Errorf("Error resolving gallery covers: %v", err)
+		}
+	})
+
+	return s.JobManager.Add(ctx, "Resolving gallery covers...", j)
+}
+
 func (s *singleton) MigrateHash(ctx context.Context) int {
 	j := job.MakeJobExec(func(ctx context.Context, progress *job.Progress) {
 		fileNamingAlgo := config.GetInstance().GetVideoFileNamingAlgorithm()
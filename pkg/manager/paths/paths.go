@@ -14,8 +14,16 @@ type Paths struct {
 }
 
 func NewPaths(generatedPath string) *Paths {
+	return NewInstancePaths(generatedPath, "")
+}
+
+// NewInstancePaths returns the paths of an instance sharing the generated
+// directory with other instances. The temporary files of the instance are
+// written to a directory named after instanceID, so that they do not collide
+// with the temporary files of other instances.
+func NewInstancePaths(generatedPath string, instanceID string) *Paths {
 	p := Paths{}
-	p.Generated = newGeneratedPaths(generatedPath)
+	p.Generated = newGeneratedPaths(generatedPath, instanceID)
 
 	p.Scene = newScenePaths(p)
 	p.SceneMarkers = newSceneMarkerPaths(p)
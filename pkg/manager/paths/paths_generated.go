@@ -1,9 +1,13 @@
 package paths
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/utils"
@@ -15,24 +19,37 @@ const thumbDirLength int = 2 // thumbDirDepth * thumbDirLength must be smaller t
 type generatedPaths struct {
 	Screenshots        string
 	Thumbnails         string
+	ThumbnailCache     string
 	Vtt                string
 	Markers            string
 	Transcodes         string
+	TranscodeCache     string
 	Downloads          string
-	Tmp                string
 	InteractiveHeatmap string
+
+	// TmpRoot is the directory containing the temporary directories of the
+	// instances sharing the generated directory.
+	TmpRoot string
+	// Tmp is the temporary directory of this instance.
+	Tmp string
 }
 
-func newGeneratedPaths(path string) *generatedPaths {
+func newGeneratedPaths(path string, instanceID string) *generatedPaths {
 	gp := generatedPaths{}
 	gp.Screenshots = filepath.Join(path, "screenshots")
 	gp.Thumbnails = filepath.Join(path, "thumbnails")
+	gp.ThumbnailCache = filepath.Join(gp.Thumbnails, "cache")
 	gp.Vtt = filepath.Join(path, "vtt")
 	gp.Markers = filepath.Join(path, "markers")
 	gp.Transcodes = filepath.Join(path, "transcodes")
+	gp.TranscodeCache = filepath.Join(gp.Transcodes, "cache")
 	gp.Downloads = filepath.Join(path, "download_stage")
-	gp.Tmp = filepath.Join(path, "tmp")
 	gp.InteractiveHeatmap = filepath.Join(path, "interactive_heatmaps")
+	gp.TmpRoot = filepath.Join(path, "tmp")
+	gp.Tmp = gp.TmpRoot
+	if instanceID != "" {
+		gp.Tmp = filepath.Join(gp.TmpRoot, instanceID)
+	}
 	return &gp
 }
 
@@ -52,6 +69,65 @@ func (gp *generatedPaths) RemoveTmpDir() error {
 	return utils.RemoveDir(gp.Tmp)
 }
 
+// RemoveStaleTmpDirs removes the entries of the temporary root directory,
+// other than the temporary directory of this instance, that have not been
+// modified within maxAge. These are left by earlier runs, or by instances
+// that are no longer running. A directory is modified if anything within
+// it is, since writing to nested directories does not change the
+// modification time of the directory.
+func (gp *generatedPaths) RemoveStaleTmpDirs(maxAge time.Duration) error {
+	if gp.Tmp == gp.TmpRoot {
+		return nil
+	}
+
+	entries, err := os.ReadDir(gp.TmpRoot)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	for _, e := range entries {
+		p := filepath.Join(gp.TmpRoot, e.Name())
+		if p == gp.Tmp {
+			continue
+		}
+
+		if modifiedSince(p, cutoff) {
+			continue
+		}
+
+		if err := os.RemoveAll(p); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// modifiedSince returns true if the file at path, or any file within it if
+// it is a directory, has been modified after t. Returns true if the
+// modification times cannot be read, so that the file is kept.
+func modifiedSince(path string, t time.Time) bool {
+	errModified := errors.New("modified")
+
+	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.ModTime().After(t) {
+			return errModified
+		}
+
+		return nil
+	})
+
+	return err != nil
+}
+
 func (gp *generatedPaths) TempDir(pattern string) (string, error) {
 	if err := gp.EnsureTmpDir(); err != nil {
 		logger.Warnf("Could not ensure existence of a temporary directory: %v", err)
@@ -72,3 +148,23 @@ func (gp *generatedPaths) GetThumbnailPath(checksum string, width int) string {
 	fname := fmt.Sprintf("%s_%d.jpg", checksum, width)
 	return filepath.Join(gp.Thumbnails, utils.GetIntraDir(checksum, thumbDirDepth, thumbDirLength), fname)
 }
+
+// ThumbnailChecksumFromFilename returns the image checksum in the name of an
+// image thumbnail, and false if the name is not the name of a thumbnail.
+func ThumbnailChecksumFromFilename(name string) (string, bool) {
+	if !strings.HasSuffix(name, ".jpg") {
+		return "", false
+	}
+
+	i := strings.LastIndex(name, "_")
+	if i == -1 {
+		return "", false
+	}
+
+	if _, err := strconv.Atoi(strings.TrimSuffix(name[i+1:], ".jpg")); err != nil {
+		return "", false
+	}
+
+	checksum := name[:i]
+	return checksum, isHash(checksum)
+}
@@ -0,0 +1,72 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstanceTmpPath(t *testing.T) {
+	p := NewInstancePaths("generated", "instance1")
+
+	assert.Equal(t, filepath.Join("generated", "tmp"), p.Generated.TmpRoot)
+	assert.Equal(t, filepath.Join("generated", "tmp", "instance1", "files.txt"), p.Generated.GetTmpPath("files.txt"))
+
+	other := NewInstancePaths("generated", "instance2")
+	assert.NotEqual(t, p.Generated.GetTmpPath("files.txt"), other.Generated.GetTmpPath("files.txt"))
+
+	// without an instance id the temporary directory is shared
+	shared := NewPaths("generated")
+	assert.Equal(t, filepath.Join("generated", "tmp", "files.txt"), shared.Generated.GetTmpPath("files.txt"))
+}
+
+func TestRemoveStaleTmpDirs(t *testing.T) {
+	generated := t.TempDir()
+	p := NewInstancePaths(generated, "current")
+	old := time.Now().Add(-48 * time.Hour)
+
+	mkdir := func(name string, modTime time.Time) string {
+		dir := filepath.Join(p.Generated.TmpRoot, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(dir, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+
+	current := mkdir("current", old)
+	stale := mkdir("stale", old)
+	active := mkdir("active", time.Now())
+
+	// files written in nested directories do not change the modification
+	// time of the top level directory
+	nested := mkdir("nested", old)
+	nestedDir := filepath.Join(nested, "transcode")
+	if err := os.MkdirAll(nestedDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(nestedDir, "segment.ts"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(nestedDir, old, old); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(nested, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Nil(t, p.Generated.RemoveStaleTmpDirs(24*time.Hour))
+
+	assert.DirExists(t, current)
+	assert.DirExists(t, active)
+	assert.DirExists(t, nested)
+	assert.NoDirExists(t, stale)
+
+	// a missing temporary directory is not an error
+	assert.Nil(t, NewInstancePaths(t.TempDir(), "current").Generated.RemoveStaleTmpDirs(time.Hour))
+}
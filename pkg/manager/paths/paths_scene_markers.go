@@ -26,3 +26,9 @@ func (sp *sceneMarkerPaths) GetStreamPreviewImagePath(checksum string, seconds i
 func (sp *sceneMarkerPaths) GetStreamScreenshotPath(checksum string, seconds int) string {
 	return filepath.Join(sp.generated.Markers, checksum, strconv.Itoa(seconds)+".jpg")
 }
+
+// SceneHashFromMarkerDir returns the scene hash that names a directory of
+// generated marker files, and false if the name is not a scene hash.
+func SceneHashFromMarkerDir(name string) (string, bool) {
+	return name, isHash(name)
+}
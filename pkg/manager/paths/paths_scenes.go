@@ -2,10 +2,54 @@ package paths
 
 import (
 	"path/filepath"
+	"strings"
 
 	"github.com/stashapp/stash/pkg/utils"
 )
 
+// sceneFileSuffixes are the suffixes following the scene hash in the names
+// of generated scene files. Longer suffixes are first, so that they are
+// matched before their own suffixes.
+var sceneFileSuffixes = []string{
+	".thumb.jpg",
+	".thumb.png",
+	".thumb.gif",
+	".thumb.webp",
+	".thumb.avif",
+	".screenshot.webp",
+	"_sprite.jpg",
+	"_contactsheet.jpg",
+	"_thumbs.vtt",
+	".jpg",
+	".mp4",
+	".webp",
+	".png",
+	".gif",
+	".avif",
+}
+
+// screenshotExtensions are the file extensions of the formats of scene
+// screenshots and thumbnails, in order of preference if there are
+// screenshots in several formats.
+var screenshotExtensions = []string{
+	".jpg",
+	".webp",
+	".avif",
+	".png",
+	".gif",
+}
+
+// screenshotSuffix returns the suffix following the scene hash in the name
+// of a screenshot with the file extension. WebP screenshots have their own
+// suffix, since the webp suffix is used by preview images.
+func screenshotSuffix(ext string) string {
+	if ext == ".webp" {
+		return ".screenshot" + ext
+	}
+
+	return ext
+}
+
 type scenePaths struct {
 	generated generatedPaths
 }
@@ -16,12 +60,50 @@ func newScenePaths(p Paths) *scenePaths {
 	return &sp
 }
 
+// GetScreenshotPath returns the path of the existing screenshot of the
+// scene, or the path of a JPEG screenshot if there is none.
 func (sp *scenePaths) GetScreenshotPath(checksum string) string {
-	return filepath.Join(sp.generated.Screenshots, checksum+".jpg")
+	return sp.existingPath(checksum, sp.GetScreenshotPathForExtension)
 }
 
+// GetThumbnailScreenshotPath returns the path of the existing screenshot
+// thumbnail of the scene, or the path of a JPEG thumbnail if there is none.
 func (sp *scenePaths) GetThumbnailScreenshotPath(checksum string) string {
-	return filepath.Join(sp.generated.Screenshots, checksum+".thumb.jpg")
+	return sp.existingPath(checksum, sp.GetThumbnailScreenshotPathForExtension)
+}
+
+// GetScreenshotPathForExtension returns the path of a screenshot of the
+// scene with the file extension, such as .jpg.
+func (sp *scenePaths) GetScreenshotPathForExtension(checksum string, ext string) string {
+	return filepath.Join(sp.generated.Screenshots, checksum+screenshotSuffix(ext))
+}
+
+// GetThumbnailScreenshotPathForExtension returns the path of a screenshot
+// thumbnail of the scene with the file extension, such as .jpg.
+func (sp *scenePaths) GetThumbnailScreenshotPathForExtension(checksum string, ext string) string {
+	return filepath.Join(sp.generated.Screenshots, checksum+".thumb"+ext)
+}
+
+// GetScreenshotPaths returns the paths of the screenshots and thumbnails of
+// the scene in all formats, whether or not they exist.
+func (sp *scenePaths) GetScreenshotPaths(checksum string) []string {
+	var ret []string
+	for _, ext := range screenshotExtensions {
+		ret = append(ret, sp.GetScreenshotPathForExtension(checksum, ext), sp.GetThumbnailScreenshotPathForExtension(checksum, ext))
+	}
+
+	return ret
+}
+
+func (sp *scenePaths) existingPath(checksum string, pathForExtension func(checksum string, ext string) string) string {
+	for _, ext := range screenshotExtensions {
+		path := pathForExtension(checksum, ext)
+		if exists, _ := utils.FileExists(path); exists {
+			return path
+		}
+	}
+
+	return pathForExtension(checksum, screenshotExtensions[0])
 }
 
 func (sp *scenePaths) GetTranscodePath(checksum string) string {
@@ -53,6 +135,40 @@ func (sp *scenePaths) GetSpriteVttFilePath(checksum string) string {
 	return filepath.Join(sp.generated.Vtt, checksum+"_thumbs.vtt")
 }
 
+func (sp *scenePaths) GetContactSheetPath(checksum string) string {
+	return filepath.Join(sp.generated.Vtt, checksum+"_contactsheet.jpg")
+}
+
 func (sp *scenePaths) GetInteractiveHeatmapPath(checksum string) string {
 	return filepath.Join(sp.generated.InteractiveHeatmap, checksum+".png")
 }
+
+// SceneHashFromFilename returns the scene hash in the name of a generated
+// scene file, and false if the name is not the name of a generated scene
+// file.
+func SceneHashFromFilename(name string) (string, bool) {
+	for _, suffix := range sceneFileSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			hash := strings.TrimSuffix(name, suffix)
+			return hash, isHash(hash)
+		}
+	}
+
+	return "", false
+}
+
+// isHash returns true if s is a hexadecimal hash, such as a checksum or
+// oshash.
+func isHash(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,61 @@
+package paths
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetContactSheetPath(t *testing.T) {
+	const checksum = "aaaa0000aaaa0000"
+
+	p := NewPaths("generated")
+	got := p.Scene.GetContactSheetPath(checksum)
+
+	assert.Equal(t, filepath.Join("generated", "vtt", checksum+"_contactsheet.jpg"), got)
+
+	hash, ok := SceneHashFromFilename(filepath.Base(got))
+	assert.True(t, ok)
+	assert.Equal(t, checksum, hash)
+}
+
+func TestGetScreenshotPath(t *testing.T) {
+	const checksum = "aaaa0000aaaa0000"
+
+	dir := t.TempDir()
+	p := NewPaths(dir)
+	screenshots := filepath.Join(dir, "screenshots")
+
+	// JPEG if there is no screenshot
+	assert.Equal(t, filepath.Join(screenshots, checksum+".jpg"), p.Scene.GetScreenshotPath(checksum))
+	assert.Equal(t, filepath.Join(screenshots, checksum+".thumb.jpg"), p.Scene.GetThumbnailScreenshotPath(checksum))
+
+	// webp screenshots do not use the name of preview images
+	webpPath := p.Scene.GetScreenshotPathForExtension(checksum, ".webp")
+	assert.Equal(t, filepath.Join(screenshots, checksum+".screenshot.webp"), webpPath)
+	assert.NotEqual(t, p.Scene.GetStreamPreviewImagePath(checksum), webpPath)
+
+	thumbPath := p.Scene.GetThumbnailScreenshotPathForExtension(checksum, ".avif")
+	assert.Equal(t, filepath.Join(screenshots, checksum+".thumb.avif"), thumbPath)
+
+	if err := os.MkdirAll(screenshots, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{webpPath, thumbPath} {
+		if err := os.WriteFile(path, []byte("image"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// existing screenshots are found in any format
+	assert.Equal(t, webpPath, p.Scene.GetScreenshotPath(checksum))
+	assert.Equal(t, thumbPath, p.Scene.GetThumbnailScreenshotPath(checksum))
+
+	for _, path := range p.Scene.GetScreenshotPaths(checksum) {
+		hash, ok := SceneHashFromFilename(filepath.Base(path))
+		assert.True(t, ok, path)
+		assert.Equal(t, checksum, hash, path)
+	}
+}
@@ -0,0 +1,48 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/manager/config"
+)
+
+// ErrJobRunning is returned by operations that cannot be performed while a
+// job is running.
+var ErrJobRunning = errors.New("a job is running")
+
+// RelocateDatabase moves the database file to newPath and updates the
+// configuration to use it. The database is reopened at its original path if
+// relocation fails. Jobs are not started during relocation, and an error
+// wrapping ErrJobRunning is returned if a job is running.
+func (s *singleton) RelocateDatabase(newPath string) error {
+	newPath, err := filepath.Abs(newPath)
+	if err != nil {
+		return err
+	}
+
+	return s.relocateDatabase(func() error {
+		return database.Relocate(newPath, func(path string) error {
+			return s.Config.SetAndWrite(config.Database, path)
+		})
+	})
+}
+
+// relocateDatabase calls relocate with the job manager paused, unless a job
+// is running. The pause is held until relocate returns, even if maintenance
+// mode is left in the meantime.
+func (s *singleton) relocateDatabase(relocate func() error) error {
+	s.JobManager.Pause()
+	defer s.JobManager.Resume()
+
+	for _, j := range s.JobManager.GetQueue() {
+		if j.Status == job.StatusRunning || j.Status == job.StatusStopping {
+			return fmt.Errorf("cannot relocate database: %w", ErrJobRunning)
+		}
+	}
+
+	return relocate()
+}
@@ -0,0 +1,70 @@
+package manager
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelocateDatabaseJobRunning(t *testing.T) {
+	s := &singleton{
+		JobManager: job.NewManager(),
+	}
+	defer s.JobManager.Stop()
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	s.JobManager.Add(context.Background(), "job", job.MakeJobExec(func(ctx context.Context, progress *job.Progress) {
+		close(started)
+		<-finish
+	}))
+	<-started
+
+	err := s.RelocateDatabase(filepath.Join(t.TempDir(), "stash.sqlite"))
+	assert.ErrorIs(t, err, ErrJobRunning)
+
+	// jobs are resumed once relocation is refused
+	assert.False(t, s.JobManager.IsPaused())
+	close(finish)
+}
+
+func TestRelocateDatabaseLeaveMaintenance(t *testing.T) {
+	s := &singleton{
+		JobManager: job.NewManager(),
+	}
+	defer s.JobManager.Stop()
+
+	s.SetMaintenance(true)
+
+	started := make(chan struct{})
+	err := s.relocateDatabase(func() error {
+		// leaving maintenance mode does not start jobs during relocation
+		s.SetMaintenance(false)
+		assert.True(t, s.JobManager.IsPaused())
+
+		s.JobManager.Add(context.Background(), "job", job.MakeJobExec(func(ctx context.Context, progress *job.Progress) {
+			close(started)
+		}))
+
+		select {
+		case <-started:
+			t.Error("job was started during relocation")
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		return nil
+	})
+	assert.Nil(t, err)
+
+	// jobs are started once relocation is finished
+	assert.False(t, s.JobManager.IsPaused())
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Error("job was not started after relocation")
+	}
+}
@@ -0,0 +1,169 @@
+package manager
+
+import (
+	"context"
+	"sync"
+)
+
+// Resources are amounts of the system resources shared by heavy work. CPU
+// and IO are numbers of slots, Memory is in megabytes.
+type Resources struct {
+	CPU    int
+	IO     int
+	Memory int
+}
+
+// The resources used by each subsystem for a single unit of work.
+var (
+	// ScanResources are the resources used to scan and hash a file.
+	ScanResources = Resources{IO: 1}
+	// TranscodeResources are the resources used by an ffmpeg transcode.
+	TranscodeResources = Resources{CPU: 1, IO: 1, Memory: 256}
+	// ThumbnailResources are the resources used to generate an image
+	// thumbnail.
+	ThumbnailResources = Resources{CPU: 1, Memory: 64}
+)
+
+// fits returns true if r can be added to used without exceeding limits.
+// Limits of zero are unlimited.
+func (r Resources) fits(used Resources, limits Resources) bool {
+	fits := func(want, used, limit int) bool {
+		return limit <= 0 || want == 0 || used+want <= limit
+	}
+
+	return fits(r.CPU, used.CPU, limits.CPU) &&
+		fits(r.IO, used.IO, limits.IO) &&
+		fits(r.Memory, used.Memory, limits.Memory)
+}
+
+// clamp returns r with each amount reduced to at most its limit, so that
+// work requiring more than the limit can run once nothing else is.
+func (r Resources) clamp(limits Resources) Resources {
+	clamp := func(want, limit int) int {
+		if limit > 0 && want > limit {
+			return limit
+		}
+		return want
+	}
+
+	return Resources{
+		CPU:    clamp(r.CPU, limits.CPU),
+		IO:     clamp(r.IO, limits.IO),
+		Memory: clamp(r.Memory, limits.Memory),
+	}
+}
+
+func (r Resources) add(o Resources) Resources {
+	return Resources{
+		CPU:    r.CPU + o.CPU,
+		IO:     r.IO + o.IO,
+		Memory: r.Memory + o.Memory,
+	}
+}
+
+func (r Resources) sub(o Resources) Resources {
+	return Resources{
+		CPU:    r.CPU - o.CPU,
+		IO:     r.IO - o.IO,
+		Memory: r.Memory - o.Memory,
+	}
+}
+
+// ResourceManager limits the total resources used by heavy work across
+// subsystems. Work acquires its resources before it starts and releases
+// them when it finishes, waiting until enough resources are free.
+type ResourceManager struct {
+	mutex  sync.Mutex
+	limits Resources
+	used   Resources
+	// changed is closed when resources are released or the limits are
+	// changed, waking waiting acquirers
+	changed chan struct{}
+}
+
+// NewResourceManager returns a ResourceManager with the provided limits.
+// Limits of zero are unlimited.
+func NewResourceManager(limits Resources) *ResourceManager {
+	return &ResourceManager{
+		limits:  limits,
+		changed: make(chan struct{}),
+	}
+}
+
+// SetLimits sets the limits of the resources. Work already running is not
+// affected.
+func (m *ResourceManager) SetLimits(limits Resources) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.limits = limits
+	m.notify()
+}
+
+// Limits returns the limits of the resources.
+func (m *ResourceManager) Limits() Resources {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.limits
+}
+
+// Used returns the resources currently acquired.
+func (m *ResourceManager) Used() Resources {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.used
+}
+
+// notify wakes waiting acquirers. Must be called with the mutex held.
+func (m *ResourceManager) notify() {
+	close(m.changed)
+	m.changed = make(chan struct{})
+}
+
+// Acquire waits until r is available, then acquires it. The returned
+// function releases the resources, and must be called once the work is
+// finished. Amounts greater than the limit are reduced to the limit. Returns
+// the context's error if it is cancelled before the resources are acquired.
+// A nil ResourceManager has no limits.
+func (m *ResourceManager) Acquire(ctx context.Context, r Resources) (release func(), err error) {
+	if m == nil {
+		return func() {}, nil
+	}
+
+	m.mutex.Lock()
+	for {
+		acquired := r.clamp(m.limits)
+		if acquired.fits(m.used, m.limits) {
+			m.used = m.used.add(acquired)
+			m.mutex.Unlock()
+
+			var once sync.Once
+			return func() {
+				once.Do(func() {
+					m.release(acquired)
+				})
+			}, nil
+		}
+
+		changed := m.changed
+		m.mutex.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		m.mutex.Lock()
+	}
+}
+
+func (m *ResourceManager) release(r Resources) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.used = m.used.sub(r)
+	m.notify()
+}
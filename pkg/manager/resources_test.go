@@ -0,0 +1,162 @@
+package manager
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// resourceTracker records the maximum resources used concurrently.
+type resourceTracker struct {
+	mutex sync.Mutex
+	used  Resources
+	max   Resources
+}
+
+func (t *resourceTracker) start(r Resources) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.used = t.used.add(r)
+	if t.used.CPU > t.max.CPU {
+		t.max.CPU = t.used.CPU
+	}
+	if t.used.IO > t.max.IO {
+		t.max.IO = t.used.IO
+	}
+	if t.used.Memory > t.max.Memory {
+		t.max.Memory = t.used.Memory
+	}
+}
+
+func (t *resourceTracker) finish(r Resources) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.used = t.used.sub(r)
+}
+
+func TestResourceManagerLimitsAcrossSubsystems(t *testing.T) {
+	limits := Resources{CPU: 3, IO: 2, Memory: 512}
+	m := NewResourceManager(limits)
+
+	subsystems := []Resources{
+		ScanResources,
+		TranscodeResources,
+		ThumbnailResources,
+	}
+
+	tracker := &resourceTracker{}
+	var wg sync.WaitGroup
+	for i := 0; i < 60; i++ {
+		r := subsystems[i%len(subsystems)]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			release, err := m.Acquire(context.Background(), r)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+
+			tracker.start(r)
+			time.Sleep(time.Millisecond)
+			tracker.finish(r)
+			release()
+		}()
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, tracker.max.CPU, limits.CPU)
+	assert.LessOrEqual(t, tracker.max.IO, limits.IO)
+	assert.LessOrEqual(t, tracker.max.Memory, limits.Memory)
+	// the limits are reached with this many concurrent acquirers
+	assert.Equal(t, limits.IO, tracker.max.IO)
+
+	assert.Equal(t, Resources{}, m.Used())
+}
+
+func TestResourceManagerUnlimited(t *testing.T) {
+	m := NewResourceManager(Resources{})
+
+	for i := 0; i < 10; i++ {
+		if _, err := m.Acquire(context.Background(), TranscodeResources); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	assert.Equal(t, Resources{CPU: 10, IO: 10, Memory: 2560}, m.Used())
+}
+
+func TestResourceManagerClampsToLimit(t *testing.T) {
+	m := NewResourceManager(Resources{Memory: 100})
+
+	// requests for more than the limit run once nothing else is running
+	release, err := m.Acquire(context.Background(), Resources{Memory: 200})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, Resources{Memory: 100}, m.Used())
+
+	release()
+	// releasing more than once has no effect
+	release()
+	assert.Equal(t, Resources{}, m.Used())
+}
+
+func TestResourceManagerCancel(t *testing.T) {
+	m := NewResourceManager(Resources{CPU: 1})
+
+	release, err := m.Acquire(context.Background(), ThumbnailResources)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err = m.Acquire(ctx, ThumbnailResources)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, ThumbnailResources, m.Used())
+}
+
+func TestResourceManagerSetLimits(t *testing.T) {
+	m := NewResourceManager(Resources{CPU: 1})
+
+	release, err := m.Acquire(context.Background(), Resources{CPU: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+
+	acquired := make(chan struct{})
+	go func() {
+		release, err := m.Acquire(context.Background(), Resources{CPU: 1})
+		if err == nil {
+			release()
+		}
+		close(acquired)
+	}()
+
+	// raising the limit wakes the waiting acquirer
+	m.SetLimits(Resources{CPU: 2})
+
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("acquire did not return after the limit was raised")
+	}
+}
+
+func TestNilResourceManager(t *testing.T) {
+	var m *ResourceManager
+
+	release, err := m.Acquire(context.Background(), TranscodeResources)
+	assert.Nil(t, err)
+	release()
+}
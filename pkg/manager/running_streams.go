@@ -1,13 +1,17 @@
 package manager
 
 import (
+	"context"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/file"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene"
 	"github.com/stashapp/stash/pkg/utils"
 )
 
@@ -86,26 +90,199 @@ type SceneServer struct {
 	TXNManager models.TransactionManager
 }
 
+// beginStream registers a stream with the stream registry. Returns false,
+// after responding with an error, if the server is shutting down.
+func beginStream(w http.ResponseWriter) bool {
+	if !GetInstance().Streams.Begin() {
+		http.Error(w, "server is shutting down", http.StatusServiceUnavailable)
+		return false
+	}
+	return true
+}
+
 func (s *SceneServer) StreamSceneDirect(scene *models.Scene, w http.ResponseWriter, r *http.Request) {
+	if !beginStream(w) {
+		return
+	}
+	defer GetInstance().Streams.End()
+
 	fileNamingAlgo := config.GetInstance().GetVideoFileNamingAlgorithm()
 
 	filepath := GetInstance().Paths.Scene.GetStreamPath(scene.Path, scene.GetHash(fileNamingAlgo))
 	RegisterStream(filepath, &w)
-	http.ServeFile(w, r, filepath)
+	serveStream(w, r, filepath, sceneStreamMimeType(scene, filepath != scene.Path))
 	WaitAndDeregisterStream(filepath, &w, r)
 }
 
-func (s *SceneServer) ServeScreenshot(scene *models.Scene, w http.ResponseWriter, r *http.Request) {
-	filepath := GetInstance().Paths.Scene.GetScreenshotPath(scene.GetHash(config.GetInstance().GetVideoFileNamingAlgorithm()))
+// HasTranscode returns true if a transcode of the scene has been generated.
+func (s *SceneServer) HasTranscode(scene *models.Scene) bool {
+	fileNamingAlgo := config.GetInstance().GetVideoFileNamingAlgorithm()
+	return GetInstance().Paths.Scene.GetStreamPath(scene.Path, scene.GetHash(fileNamingAlgo)) != scene.Path
+}
+
+// sceneStreamMimeType returns the content type of the direct stream of the
+// scene, based on the probed container. Transcoded files are always mp4.
+func sceneStreamMimeType(scene *models.Scene, transcoded bool) string {
+	if transcoded {
+		return ffmpeg.MimeMp4
+	}
+
+	container, err := GetSceneFileContainer(scene)
+	if err != nil {
+		logger.Warnf("[stream] error getting container of %s: %v", scene.Path, err)
+		return ""
+	}
+
+	return ffmpeg.ContainerMimeType(container)
+}
+
+// serveStream serves the file at path with the provided content type.
+// Range requests are served from the file, so that clients can seek
+// without the file being transcoded. If mimeType is empty, the content
+// type is detected from the file.
+func serveStream(w http.ResponseWriter, r *http.Request, path string, mimeType string) {
+	if mimeType != "" {
+		w.Header().Set("Content-Type", mimeType)
+	}
+
+	file.Serve(w, r, path)
+}
+
+// StreamSceneTranscode transcodes the scene with the video codec while it is
+// streamed. The start query parameter sets the start time of the stream, and
+// the resolution parameter overrides the maximum streaming transcode size.
+func (s *SceneServer) StreamSceneTranscode(scene *models.Scene, w http.ResponseWriter, r *http.Request, videoCodec ffmpeg.Codec) {
+	if !beginStream(w) {
+		return
+	}
+	defer GetInstance().Streams.End()
+
+	logger.Debugf("Streaming as %s", videoCodec.MimeType)
+
+	// start stream based on query param, if provided
+	if err := r.ParseForm(); err != nil {
+		logger.Warnf("[stream] error parsing query form: %v", err)
+	}
+
+	startTime := r.Form.Get("start")
+	requestedSize := r.Form.Get("resolution")
+
+	maxTranscodeSize := config.GetInstance().GetMaxStreamingTranscodeSize()
+	if requestedSize != "" {
+		maxTranscodeSize = models.StreamingResolutionEnum(requestedSize)
+	}
+
+	// only transcodes from the start of the scene are cached
+	cache := GetInstance().TranscodeCache
+	cacheable := startTime == "" && cache != nil && cache.Enabled()
+	cacheKey := NewTranscodeCacheKey(scene, videoCodec, maxTranscodeSize)
+	if cacheable {
+		if f := cache.Get(cacheKey); f != nil {
+			defer f.Close()
+
+			if info, err := f.Stat(); err != nil {
+				logger.Warnf("[stream] error reading cached transcode %s: %v", f.Name(), err)
+			} else {
+				logger.Debugf("[stream] serving cached transcode %s", f.Name())
+				w.Header().Set("Content-Type", videoCodec.MimeType)
+				http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+				return
+			}
+		}
+	}
+
+	// needs to be transcoded
+	ffprobe := GetInstance().FFProbe()
+	videoFile, err := ffprobe.NewVideoFile(scene.Path, false)
+	if err != nil {
+		logger.Errorf("[stream] error reading video file: %v", err)
+		return
+	}
+
+	var stream *ffmpeg.Stream
+
+	audioCodec := ffmpeg.MissingUnsupported
+	if scene.AudioCodec.Valid {
+		audioCodec = ffmpeg.AudioCodec(scene.AudioCodec.String)
+	}
+
+	options := ffmpeg.GetTranscodeStreamOptions(*videoFile, videoCodec, audioCodec)
+	options.StartTime = startTime
+	options.MaxTranscodeSize = maxTranscodeSize
+
+	// wait for resources to be free, until the client disconnects
+	resourceWriter := &idleResourceWriter{
+		ResponseWriter: w,
+		ctx:            r.Context(),
+		resources:      GetInstance().Resources,
+		want:           TranscodeResources,
+		idleTimeout:    streamIdleTimeout,
+	}
+	defer resourceWriter.releaseHeld()
+	if err := resourceWriter.acquire(); err != nil {
+		logger.Debugf("[stream] transcode cancelled while waiting for resources: %v", err)
+		return
+	}
+
+	encoder := GetInstance().FFMPEG()
+	stream, err = encoder.GetTranscodeStream(options)
+
+	if err != nil {
+		logger.Errorf("[stream] error transcoding video file: %v", err)
+		w.WriteHeader(http.StatusBadRequest)
+		if _, err := w.Write([]byte(err.Error())); err != nil {
+			logger.Warnf("[stream] error writing response: %v", err)
+		}
+		return
+	}
+
+	var cacheWriter *TranscodeCacheWriter
+	if cacheable {
+		cacheWriter, err = cache.Create(cacheKey)
+		if err != nil {
+			logger.Warnf("[stream] error caching transcode: %v", err)
+		} else {
+			stream.Tee = cacheWriter
+		}
+	}
+
+	transcodes := GetInstance().Transcodes
+	transcodeID := transcodes.Register(RunningTranscode{
+		SceneID:    scene.ID,
+		Codec:      videoCodec.Codec,
+		MimeType:   videoCodec.MimeType,
+		Resolution: maxTranscodeSize,
+		Client:     r.RemoteAddr,
+	}, func() {
+		if err := stream.Process.Kill(); err != nil {
+			logger.Warnf("[stream] error killing cancelled transcode: %v", err)
+		}
+	})
+
+	stream.Serve(resourceWriter, r)
+	transcodes.Deregister(transcodeID)
+
+	if cacheWriter != nil {
+		if err := cacheWriter.Close(stream.Wait() == nil); err != nil {
+			logger.Warnf("[stream] error caching transcode: %v", err)
+		}
+	}
+}
+
+func (s *SceneServer) ServeScreenshot(scn *models.Scene, w http.ResponseWriter, r *http.Request) {
+	filepath := GetInstance().Paths.Scene.GetScreenshotPath(scn.GetHash(config.GetInstance().GetVideoFileNamingAlgorithm()))
 
 	// fall back to the scene image blob if the file isn't present
 	screenshotExists, _ := utils.FileExists(filepath)
 	if screenshotExists {
-		http.ServeFile(w, r, filepath)
+		if mimeType := scene.ScreenshotMimeType(filepath); mimeType != "" {
+			w.Header().Set("Content-Type", mimeType)
+		}
+		utils.ServeFileCached(w, r, filepath, config.GetInstance().GetGeneratedCacheMaxAge())
 	} else {
 		var cover []byte
 		err := s.TXNManager.WithReadTxn(r.Context(), func(repo models.ReaderRepository) error {
-			cover, _ = repo.Scene().GetCover(scene.ID)
+			cover, _ = repo.Scene().GetCover(scn.ID)
 			return nil
 		})
 		if err != nil {
@@ -117,3 +294,105 @@ func (s *SceneServer) ServeScreenshot(scene *models.Scene, w http.ResponseWriter
 		}
 	}
 }
+
+// streamIdleTimeout is the duration a write to a streaming client may block,
+// such as when the player is paused, before the transcode's IO is released.
+const streamIdleTimeout = 10 * time.Second
+
+// idleResourceWriter holds resources while writing to a client. The IO is
+// released when a write blocks for longer than idleTimeout, and acquired
+// again before the next write, so that idle clients do not hold IO needed by
+// other work. The CPU and memory are held until releaseHeld is called, since
+// the transcode process is still running while the write is blocked.
+type idleResourceWriter struct {
+	http.ResponseWriter
+
+	ctx         context.Context
+	resources   *ResourceManager
+	want        Resources
+	idleTimeout time.Duration
+
+	mutex sync.Mutex
+	// releaseProcess releases the resources of the running process
+	releaseProcess func()
+	releaseIO      func()
+}
+
+// acquire acquires the resources that are not held, waiting until they are
+// available or the context is cancelled. If the IO cannot be acquired, the
+// process resources acquired by the same call are released.
+func (w *idleResourceWriter) acquire() error {
+	w.mutex.Lock()
+	processHeld := w.releaseProcess != nil
+	ioHeld := w.releaseIO != nil
+	w.mutex.Unlock()
+
+	if !processHeld {
+		process := w.want
+		process.IO = 0
+		release, err := w.resources.Acquire(w.ctx, process)
+		if err != nil {
+			return err
+		}
+
+		w.mutex.Lock()
+		w.releaseProcess = release
+		w.mutex.Unlock()
+	}
+
+	if !ioHeld {
+		release, err := w.resources.Acquire(w.ctx, Resources{IO: w.want.IO})
+		if err != nil {
+			if !processHeld {
+				w.mutex.Lock()
+				if w.releaseProcess != nil {
+					w.releaseProcess()
+					w.releaseProcess = nil
+				}
+				w.mutex.Unlock()
+			}
+			return err
+		}
+
+		w.mutex.Lock()
+		w.releaseIO = release
+		w.mutex.Unlock()
+	}
+
+	return nil
+}
+
+// releaseIdle releases the IO if it is held.
+func (w *idleResourceWriter) releaseIdle() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.releaseIO != nil {
+		w.releaseIO()
+		w.releaseIO = nil
+	}
+}
+
+// releaseHeld releases the resources that are held.
+func (w *idleResourceWriter) releaseHeld() {
+	w.releaseIdle()
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.releaseProcess != nil {
+		w.releaseProcess()
+		w.releaseProcess = nil
+	}
+}
+
+func (w *idleResourceWriter) Write(p []byte) (int, error) {
+	if err := w.acquire(); err != nil {
+		return 0, err
+	}
+
+	timer := time.AfterFunc(w.idleTimeout, w.releaseIdle)
+	defer timer.Stop()
+
+	return w.ResponseWriter.Write(p)
+}
@@ -0,0 +1,180 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSceneStreamMimeType(t *testing.T) {
+	tests := []struct {
+		name       string
+		format     string
+		transcoded bool
+		want       string
+	}{
+		{"mp4", "mp4", false, ffmpeg.MimeMp4},
+		{"m4v", "m4v", false, ffmpeg.MimeMp4},
+		{"webm", "webm", false, ffmpeg.MimeWebm},
+		{"matroska", "matroska", false, ffmpeg.MimeMkv},
+		{"mpegts", "mpegts", false, ffmpeg.MimeMpegts},
+		{"unknown", "unknown", false, ""},
+		{"transcoded", "matroska", true, ffmpeg.MimeMp4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scene := &models.Scene{
+				Path:   "test",
+				Format: sql.NullString{String: tt.format, Valid: true},
+			}
+			assert.Equal(t, tt.want, sceneStreamMimeType(scene, tt.transcoded))
+		})
+	}
+}
+
+func TestServeStream(t *testing.T) {
+	const content = "0123456789"
+
+	path := filepath.Join(t.TempDir(), "test.mkv")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name             string
+		rangeHeader      string
+		wantStatus       int
+		wantBody         string
+		wantContentRange string
+	}{
+		{"full", "", http.StatusOK, content, ""},
+		{"range", "bytes=2-5", http.StatusPartialContent, "2345", "bytes 2-5/10"},
+		{"open ended", "bytes=7-", http.StatusPartialContent, "789", "bytes 7-9/10"},
+		{"suffix", "bytes=-2", http.StatusPartialContent, "89", "bytes 8-9/10"},
+		{"unsatisfiable", "bytes=20-", http.StatusRequestedRangeNotSatisfiable, "", "bytes */10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/scene/1/stream", nil)
+			if tt.rangeHeader != "" {
+				r.Header.Set("Range", tt.rangeHeader)
+			}
+			w := httptest.NewRecorder()
+
+			serveStream(w, r, path, ffmpeg.MimeMkv)
+
+			resp := w.Result()
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, tt.wantContentRange, resp.Header.Get("Content-Range"))
+
+			if tt.wantBody != "" {
+				assert.Equal(t, "bytes", resp.Header.Get("Accept-Ranges"))
+				body, _ := io.ReadAll(resp.Body)
+				assert.Equal(t, tt.wantBody, string(body))
+				assert.Equal(t, ffmpeg.MimeMkv, resp.Header.Get("Content-Type"))
+			}
+		})
+	}
+}
+
+// blockingResponseWriter blocks writes until unblock is closed.
+type blockingResponseWriter struct {
+	*httptest.ResponseRecorder
+	unblock chan struct{}
+}
+
+func (w *blockingResponseWriter) Write(p []byte) (int, error) {
+	<-w.unblock
+	return w.ResponseRecorder.Write(p)
+}
+
+func TestIdleResourceWriterReleasesIOWhenBlocked(t *testing.T) {
+	m := NewResourceManager(TranscodeResources)
+	dst := &blockingResponseWriter{
+		ResponseRecorder: httptest.NewRecorder(),
+		unblock:          make(chan struct{}),
+	}
+	w := &idleResourceWriter{
+		ResponseWriter: dst,
+		ctx:            context.Background(),
+		resources:      m,
+		want:           TranscodeResources,
+		idleTimeout:    10 * time.Millisecond,
+	}
+
+	if err := w.acquire(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, TranscodeResources, m.Used())
+
+	written := make(chan struct{})
+	go func() {
+		_, _ = w.Write([]byte("data"))
+		close(written)
+	}()
+
+	// the blocked write releases the IO for other work, but the running
+	// transcode keeps its CPU and memory
+	running := Resources{CPU: TranscodeResources.CPU, Memory: TranscodeResources.Memory}
+	assert.Eventually(t, func() bool { return m.Used() == running }, time.Second, time.Millisecond)
+
+	close(dst.unblock)
+	<-written
+
+	// the next write acquires the resources again
+	_, err := w.Write([]byte("more"))
+	assert.Nil(t, err)
+	assert.Equal(t, TranscodeResources, m.Used())
+	assert.Equal(t, "datamore", dst.Body.String())
+
+	w.releaseHeld()
+	assert.Equal(t, Resources{}, m.Used())
+}
+
+func TestIdleResourceWriterReleasesProcessWhenCancelled(t *testing.T) {
+	m := NewResourceManager(TranscodeResources)
+
+	// hold the IO elsewhere so that the writer waits for it
+	releaseIO, err := m.Acquire(context.Background(), Resources{IO: TranscodeResources.IO})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer releaseIO()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &idleResourceWriter{
+		ResponseWriter: httptest.NewRecorder(),
+		ctx:            ctx,
+		resources:      m,
+		want:           TranscodeResources,
+		idleTimeout:    time.Second,
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- w.acquire()
+	}()
+
+	// the writer holds the process resources while waiting for the IO
+	assert.Eventually(t, func() bool { return m.Used().CPU == TranscodeResources.CPU }, time.Second, time.Millisecond)
+
+	cancel()
+	assert.NotNil(t, <-done)
+
+	// the process resources are returned when the client disconnects
+	assert.Equal(t, Resources{IO: TranscodeResources.IO}, m.Used())
+}
@@ -0,0 +1,316 @@
+package manager
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// savedFilterCriterionNames maps the criterion types of saved filters to the
+// filter fields they set, where the two differ.
+var savedFilterCriterionNames = map[string]string{
+	"hasMarkers":         "has_markers",
+	"sceneIsMissing":     "is_missing",
+	"imageIsMissing":     "is_missing",
+	"galleryIsMissing":   "is_missing",
+	"performerIsMissing": "is_missing",
+	"sceneTags":          "scene_tags",
+	"performerTags":      "performer_tags",
+}
+
+// savedFilterTarget is the query of a saved filter, which selects the
+// objects a job operates on.
+type savedFilterTarget struct {
+	id   int
+	mode models.FilterMode
+	// q is the search term of the filter
+	q string
+
+	sceneFilter   *models.SceneFilterType
+	markerFilter  *models.SceneMarkerFilterType
+	imageFilter   *models.ImageFilterType
+	galleryFilter *models.GalleryFilterType
+}
+
+// savedFilterJSON is the encoding of a saved filter by the UI. Criteria are
+// either objects or JSON-encoded strings.
+type savedFilterJSON struct {
+	Q string            `json:"q"`
+	C []json.RawMessage `json:"c"`
+}
+
+type savedFilterCriterion struct {
+	Type     string          `json:"type"`
+	Value    json.RawMessage `json:"value"`
+	Modifier string          `json:"modifier"`
+}
+
+// loadSavedFilterTarget loads the saved filter with the provided id, and
+// decodes its query. Returns an error if the saved filter does not exist,
+// or if it cannot be decoded. Criteria that are not understood are errors
+// rather than ignored, so that jobs do not operate on more objects than
+// the filter selects.
+func loadSavedFilterTarget(r models.ReaderRepository, id int) (*savedFilterTarget, error) {
+	savedFilter, err := r.SavedFilter().Find(id)
+	if err != nil {
+		return nil, fmt.Errorf("finding saved filter %d: %w", id, err)
+	}
+	if savedFilter == nil {
+		return nil, fmt.Errorf("saved filter %d not found", id)
+	}
+
+	ret, err := decodeSavedFilter(savedFilter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid saved filter %q: %w", savedFilter.Name, err)
+	}
+
+	return ret, nil
+}
+
+func decodeSavedFilter(savedFilter *models.SavedFilter) (*savedFilterTarget, error) {
+	ret := &savedFilterTarget{
+		id:   savedFilter.ID,
+		mode: savedFilter.Mode,
+	}
+
+	var newFilter func() interface{}
+	switch savedFilter.Mode {
+	case models.FilterModeScenes:
+		newFilter = func() interface{} { return &models.SceneFilterType{} }
+	case models.FilterModeSceneMarkers:
+		newFilter = func() interface{} { return &models.SceneMarkerFilterType{} }
+	case models.FilterModeImages:
+		newFilter = func() interface{} { return &models.ImageFilterType{} }
+	case models.FilterModeGalleries:
+		newFilter = func() interface{} { return &models.GalleryFilterType{} }
+	default:
+		return nil, fmt.Errorf("unsupported filter mode %s", savedFilter.Mode)
+	}
+
+	var encoded savedFilterJSON
+	if savedFilter.Filter != "" {
+		if err := json.Unmarshal([]byte(savedFilter.Filter), &encoded); err != nil {
+			return nil, err
+		}
+	}
+	ret.q = strings.TrimSpace(encoded.Q)
+
+	fields := make(map[string]interface{})
+	for _, c := range encoded.C {
+		criterion, err := decodeSavedFilterCriterion(c)
+		if err != nil {
+			return nil, err
+		}
+
+		name, value, err := criterionField(criterion, newFilter)
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = value
+	}
+
+	filter := newFilter()
+	if err := decodeStrict(fields, filter); err != nil {
+		return nil, err
+	}
+
+	switch f := filter.(type) {
+	case *models.SceneFilterType:
+		ret.sceneFilter = f
+	case *models.SceneMarkerFilterType:
+		ret.markerFilter = f
+	case *models.ImageFilterType:
+		ret.imageFilter = f
+	case *models.GalleryFilterType:
+		ret.galleryFilter = f
+	}
+
+	return ret, nil
+}
+
+func decodeSavedFilterCriterion(data json.RawMessage) (*savedFilterCriterion, error) {
+	// the UI encodes each criterion as a JSON string
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		data = json.RawMessage(s)
+	}
+
+	var ret savedFilterCriterion
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return nil, fmt.Errorf("invalid criterion %s: %w", string(data), err)
+	}
+	if ret.Type == "" {
+		return nil, fmt.Errorf("criterion %s has no type", string(data))
+	}
+
+	return &ret, nil
+}
+
+// criterionField returns the filter field set by the criterion, and its
+// value. The value is the first of the possible encodings of the criterion
+// value that the filter accepts.
+func criterionField(c *savedFilterCriterion, newFilter func() interface{}) (string, interface{}, error) {
+	name := c.Type
+	if n, ok := savedFilterCriterionNames[name]; ok {
+		name = n
+	}
+
+	var value interface{}
+	if len(c.Value) > 0 {
+		if err := json.Unmarshal(c.Value, &value); err != nil {
+			return "", nil, fmt.Errorf("invalid value of criterion %s: %w", c.Type, err)
+		}
+	}
+
+	for _, v := range criterionValues(value, c.Modifier) {
+		if err := decodeStrict(map[string]interface{}{name: v}, newFilter()); err == nil {
+			return name, v, nil
+		}
+	}
+
+	return "", nil, fmt.Errorf("unsupported criterion %s", c.Type)
+}
+
+// criterionValues returns the possible encodings of a criterion value as a
+// filter field, in order of preference.
+func criterionValues(value interface{}, modifier string) []interface{} {
+	withModifier := func(m map[string]interface{}) map[string]interface{} {
+		m["modifier"] = modifier
+		return m
+	}
+
+	switch v := value.(type) {
+	case string:
+		// the UI escapes these characters of string values
+		v = strings.ReplaceAll(v, "%26", "&")
+		v = strings.ReplaceAll(v, "%2B", "+")
+
+		ret := []interface{}{
+			withModifier(map[string]interface{}{"value": v}),
+			v,
+		}
+		if v == "true" || v == "false" {
+			ret = append(ret, v == "true")
+		}
+		return ret
+	case []interface{}:
+		return []interface{}{
+			withModifier(map[string]interface{}{"value": labeledIDs(v)}),
+		}
+	case map[string]interface{}:
+		if items, ok := v["items"].([]interface{}); ok {
+			return []interface{}{
+				withModifier(map[string]interface{}{
+					"value": labeledIDs(items),
+					"depth": v["depth"],
+				}),
+			}
+		}
+
+		m := make(map[string]interface{}, len(v)+1)
+		for k, vv := range v {
+			m[k] = vv
+		}
+		return []interface{}{withModifier(m)}
+	case nil:
+		return []interface{}{withModifier(map[string]interface{}{})}
+	default:
+		return []interface{}{
+			withModifier(map[string]interface{}{"value": v}),
+			v,
+		}
+	}
+}
+
+// labeledIDs returns the ids of the labeled ids of a criterion value.
+// Values that are not labeled ids are returned as is.
+func labeledIDs(values []interface{}) []interface{} {
+	ret := make([]interface{}, len(values))
+	for i, v := range values {
+		ret[i] = v
+		if m, ok := v.(map[string]interface{}); ok {
+			if id, ok := m["id"]; ok {
+				ret[i] = id
+			}
+		}
+	}
+	return ret
+}
+
+// decodeStrict decodes fields into filter, returning an error if any field
+// is unknown or of the wrong type.
+func decodeStrict(fields map[string]interface{}, filter interface{}) error {
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	return d.Decode(filter)
+}
+
+func (t *savedFilterTarget) findFilter() *models.FindFilterType {
+	perPage := models.PerPageAll
+	ret := &models.FindFilterType{
+		PerPage: &perPage,
+	}
+	if t.q != "" {
+		ret.Q = &t.q
+	}
+	return ret
+}
+
+// ids returns the ids of the objects selected by the saved filter, at the
+// time of calling.
+func (t *savedFilterTarget) ids(r models.ReaderRepository) ([]int, error) {
+	switch t.mode {
+	case models.FilterModeScenes:
+		result, err := r.Scene().Query(models.SceneQueryOptions{
+			QueryOptions: models.QueryOptions{
+				FindFilter: t.findFilter(),
+			},
+			SceneFilter: t.sceneFilter,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result.IDs, nil
+	case models.FilterModeSceneMarkers:
+		markers, _, err := r.SceneMarker().Query(t.markerFilter, t.findFilter())
+		if err != nil {
+			return nil, err
+		}
+		var ret []int
+		for _, m := range markers {
+			ret = append(ret, m.ID)
+		}
+		return ret, nil
+	case models.FilterModeImages:
+		result, err := r.Image().Query(models.ImageQueryOptions{
+			QueryOptions: models.QueryOptions{
+				FindFilter: t.findFilter(),
+			},
+			ImageFilter: t.imageFilter,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return result.IDs, nil
+	case models.FilterModeGalleries:
+		galleries, _, err := r.Gallery().Query(t.galleryFilter, t.findFilter())
+		if err != nil {
+			return nil, err
+		}
+		var ret []int
+		for _, g := range galleries {
+			ret = append(ret, g.ID)
+		}
+		return ret, nil
+	}
+
+	return nil, fmt.Errorf("unsupported filter mode %s", t.mode)
+}
@@ -0,0 +1,136 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const sceneSavedFilter = `{
+	"q": " search ",
+	"sortby": "date",
+	"c": [
+		"{\"type\":\"rating\",\"value\":{\"value\":4},\"modifier\":\"GREATER_THAN\"}",
+		"{\"type\":\"organized\",\"value\":\"false\",\"modifier\":\"EQUALS\"}",
+		"{\"type\":\"sceneIsMissing\",\"value\":\"cover\",\"modifier\":\"EQUALS\"}",
+		"{\"type\":\"path\",\"value\":\"a%26b\",\"modifier\":\"INCLUDES\"}",
+		"{\"type\":\"tags\",\"value\":{\"items\":[{\"id\":\"1\",\"label\":\"a\"},{\"id\":\"2\",\"label\":\"b\"}],\"depth\":-1},\"modifier\":\"INCLUDES_ALL\"}",
+		{"type": "performers", "value": [{"id": "3", "label": "c"}], "modifier": "INCLUDES"}
+	]
+}`
+
+func TestDecodeSavedFilter(t *testing.T) {
+	target, err := decodeSavedFilter(&models.SavedFilter{
+		ID:     1,
+		Mode:   models.FilterModeScenes,
+		Filter: sceneSavedFilter,
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, "search", target.q)
+
+	f := target.sceneFilter
+	if assert.NotNil(t, f.Rating) {
+		assert.Equal(t, 4, f.Rating.Value)
+		assert.Equal(t, models.CriterionModifierGreaterThan, f.Rating.Modifier)
+	}
+	if assert.NotNil(t, f.Organized) {
+		assert.False(t, *f.Organized)
+	}
+	if assert.NotNil(t, f.IsMissing) {
+		assert.Equal(t, "cover", *f.IsMissing)
+	}
+	if assert.NotNil(t, f.Path) {
+		assert.Equal(t, "a&b", f.Path.Value)
+	}
+	if assert.NotNil(t, f.Tags) {
+		assert.Equal(t, []string{"1", "2"}, f.Tags.Value)
+		assert.Equal(t, models.CriterionModifierIncludesAll, f.Tags.Modifier)
+		if assert.NotNil(t, f.Tags.Depth) {
+			assert.Equal(t, -1, *f.Tags.Depth)
+		}
+	}
+	if assert.NotNil(t, f.Performers) {
+		assert.Equal(t, []string{"3"}, f.Performers.Value)
+	}
+}
+
+func TestDecodeSavedFilterInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		mode   models.FilterMode
+		filter string
+	}{
+		{"unsupported mode", models.FilterModePerformers, `{}`},
+		{"malformed", models.FilterModeScenes, `{"c": [`},
+		{"unknown criterion", models.FilterModeScenes, `{"c": [{"type": "unknown", "value": "x", "modifier": "EQUALS"}]}`},
+		{"wrong value type", models.FilterModeScenes, `{"c": [{"type": "organized", "value": [1], "modifier": "EQUALS"}]}`},
+		{"criterion without type", models.FilterModeScenes, `{"c": [{"value": "x"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeSavedFilter(&models.SavedFilter{
+				Mode:   tt.mode,
+				Filter: tt.filter,
+			})
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestSavedFilterTargetIDs(t *testing.T) {
+	const savedFilterID = 1
+
+	m := mocks.NewTransactionManager()
+	m.SavedFilterMock().On("Find", savedFilterID).Return(&models.SavedFilter{
+		ID:     savedFilterID,
+		Mode:   models.FilterModeScenes,
+		Filter: sceneSavedFilter,
+	}, nil)
+
+	sceneQB := m.SceneMock()
+	sceneQB.On("Query", mock.MatchedBy(func(options models.SceneQueryOptions) bool {
+		ff := options.FindFilter
+		return ff.Q != nil && *ff.Q == "search" && ff.PerPage != nil && *ff.PerPage == models.PerPageAll &&
+			options.SceneFilter != nil && options.SceneFilter.Rating != nil
+	})).Return(func(options models.SceneQueryOptions) *models.SceneQueryResult {
+		ret := models.NewSceneQueryResult(sceneQB)
+		ret.IDs = []int{3, 5, 8}
+		return ret
+	}, nil)
+
+	var ids []int
+	err := m.WithReadTxn(context.Background(), func(r models.ReaderRepository) error {
+		target, err := loadSavedFilterTarget(r, savedFilterID)
+		if err != nil {
+			return err
+		}
+
+		ids, err = target.ids(r)
+		return err
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{3, 5, 8}, ids)
+}
+
+func TestSavedFilterTargetMissing(t *testing.T) {
+	const savedFilterID = 2
+
+	m := mocks.NewTransactionManager()
+	m.SavedFilterMock().On("Find", savedFilterID).Return(nil, nil)
+
+	err := m.WithReadTxn(context.Background(), func(r models.ReaderRepository) error {
+		_, err := loadSavedFilterTarget(r, savedFilterID)
+		return err
+	})
+
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,125 @@
+package manager
+
+import (
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// scanChangeSet records the IDs of the objects of one type added, updated or
+// removed by a scan or clean. An object is recorded in one bucket only: an
+// object added and then updated is added, and a removed object is neither
+// added nor updated.
+type scanChangeSet struct {
+	mutex   sync.Mutex
+	added   map[int]struct{}
+	updated map[int]struct{}
+	removed map[int]struct{}
+}
+
+func newScanChangeSet() *scanChangeSet {
+	return &scanChangeSet{
+		added:   make(map[int]struct{}),
+		updated: make(map[int]struct{}),
+		removed: make(map[int]struct{}),
+	}
+}
+
+// Added records that the object with the ID was created.
+func (s *scanChangeSet) Added(id int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.updated, id)
+	s.added[id] = struct{}{}
+}
+
+// Updated records that the path or hash of the object with the ID changed.
+func (s *scanChangeSet) Updated(id int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, added := s.added[id]; added {
+		return
+	}
+	s.updated[id] = struct{}{}
+}
+
+// Removed records that the object with the ID was deleted, trashed or
+// marked missing.
+func (s *scanChangeSet) Removed(id int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.added, id)
+	delete(s.updated, id)
+	s.removed[id] = struct{}{}
+}
+
+// changedIDs returns the sorted IDs of the added and updated objects.
+func (s *scanChangeSet) changedIDs() []int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ret := make([]int, 0, len(s.added)+len(s.updated))
+	for id := range s.added {
+		ret = append(ret, id)
+	}
+	for id := range s.updated {
+		ret = append(ret, id)
+	}
+	sort.Ints(ret)
+
+	return ret
+}
+
+func (s *scanChangeSet) model() *models.ScanChangeSet {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return &models.ScanChangeSet{
+		Added:   sortedIDs(s.added),
+		Updated: sortedIDs(s.updated),
+		Removed: sortedIDs(s.removed),
+	}
+}
+
+func sortedIDs(ids map[int]struct{}) []string {
+	sorted := make([]int, 0, len(ids))
+	for id := range ids {
+		sorted = append(sorted, id)
+	}
+	sort.Ints(sorted)
+
+	ret := make([]string, len(sorted))
+	for i, id := range sorted {
+		ret[i] = strconv.Itoa(id)
+	}
+	return ret
+}
+
+// scanChanges records the scenes, images and galleries changed by a scan or
+// clean.
+type scanChanges struct {
+	scenes    *scanChangeSet
+	images    *scanChangeSet
+	galleries *scanChangeSet
+}
+
+func newScanChanges() *scanChanges {
+	return &scanChanges{
+		scenes:    newScanChangeSet(),
+		images:    newScanChangeSet(),
+		galleries: newScanChangeSet(),
+	}
+}
+
+func (c *scanChanges) model() *models.ScanChanges {
+	return &models.ScanChanges{
+		Scenes:    c.scenes.model(),
+		Images:    c.images.model(),
+		Galleries: c.galleries.model(),
+	}
+}
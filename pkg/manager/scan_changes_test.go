@@ -0,0 +1,47 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanChangeSet(t *testing.T) {
+	s := newScanChangeSet()
+
+	s.Added(3)
+	s.Added(1)
+	s.Updated(2)
+	s.Removed(4)
+
+	changes := s.model()
+	assert.Equal(t, []string{"1", "3"}, changes.Added)
+	assert.Equal(t, []string{"2"}, changes.Updated)
+	assert.Equal(t, []string{"4"}, changes.Removed)
+
+	// an added object that is then updated is still added
+	s.Updated(1)
+	// a removed object is neither added nor updated
+	s.Removed(2)
+	s.Removed(3)
+
+	changes = s.model()
+	assert.Equal(t, []string{"1"}, changes.Added)
+	assert.Empty(t, changes.Updated)
+	assert.Equal(t, []string{"2", "3", "4"}, changes.Removed)
+}
+
+func TestScanChanges(t *testing.T) {
+	c := newScanChanges()
+
+	c.scenes.Added(1)
+	c.images.Updated(2)
+	c.galleries.Removed(3)
+
+	changes := c.model()
+	assert.Equal(t, []string{"1"}, changes.Scenes.Added)
+	assert.Empty(t, changes.Images.Added)
+	assert.Equal(t, []string{"2"}, changes.Images.Updated)
+	assert.Equal(t, []string{"3"}, changes.Galleries.Removed)
+	assert.Empty(t, changes.Scenes.Removed)
+}
@@ -0,0 +1,153 @@
+package manager
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// scanCheckpointInterval is the number of completed files between saved
+// checkpoints.
+const scanCheckpointInterval = 100
+
+// scanCheckpointKey returns the key used to store the checkpoint of a scan
+// of the provided library paths.
+func scanCheckpointKey(paths []*models.StashConfig) string {
+	var p []string
+	for _, sp := range paths {
+		p = append(p, sp.Path)
+	}
+
+	return strings.Join(p, "\n")
+}
+
+// walkOrderLess returns true if a is visited before b when walking the
+// file system. Directories are walked in lexical order, so paths are
+// compared per path component.
+func walkOrderLess(a, b string) bool {
+	aParts := strings.Split(filepath.Clean(a), string(filepath.Separator))
+	bParts := strings.Split(filepath.Clean(b), string(filepath.Separator))
+
+	for i := 0; i < len(aParts) && i < len(bParts); i++ {
+		if aParts[i] != bParts[i] {
+			return aParts[i] < bParts[i]
+		}
+	}
+
+	return len(aParts) < len(bParts)
+}
+
+// scanResumePoint determines where a scan of paths should resume from the
+// checkpointed path. Returns the index of the library path containing the
+// checkpoint, or -1 if no library path contains it.
+func scanResumePoint(paths []*models.StashConfig, lastPath string) int {
+	if lastPath == "" {
+		return -1
+	}
+
+	for i, sp := range paths {
+		if utils.IsPathInDir(sp.Path, lastPath) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// scanCheckpointTracker tracks the files of a scan in walk order. Files may
+// complete out of order since they are scanned in parallel, so the
+// checkpoint is the last file for which it and all files before it have
+// completed.
+type scanCheckpointTracker struct {
+	mutex sync.Mutex
+
+	paths     []string
+	completed map[int]bool
+	next      int
+	lastPath  string
+
+	interval  int
+	sinceSave int
+	save      func(lastPath string)
+}
+
+func newScanCheckpointTracker(interval int, save func(lastPath string)) *scanCheckpointTracker {
+	return &scanCheckpointTracker{
+		completed: make(map[int]bool),
+		interval:  interval,
+		save:      save,
+	}
+}
+
+// add adds a file to the tracker, returning its sequence number. Must be
+// called in walk order.
+func (t *scanCheckpointTracker) add(path string) int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.paths = append(t.paths, path)
+	return len(t.paths) - 1
+}
+
+// done marks the file with the provided sequence number as completed.
+func (t *scanCheckpointTracker) done(seq int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.completed[seq] = true
+	for t.completed[t.next] {
+		delete(t.completed, t.next)
+		t.lastPath = t.paths[t.next]
+		// release the path since it is no longer needed
+		t.paths[t.next] = ""
+		t.next++
+		t.sinceSave++
+	}
+
+	if t.sinceSave >= t.interval && t.save != nil {
+		t.save(t.lastPath)
+		t.sinceSave = 0
+	}
+}
+
+func (j *ScanJob) getScanCheckpoint(ctx context.Context, key string) string {
+	var ret string
+	if err := j.txnManager.WithReadTxn(ctx, func(r models.ReaderRepository) error {
+		checkpoint, err := r.ScanCheckpoint().FindByPaths(key)
+		if checkpoint != nil {
+			ret = checkpoint.LastPath
+		}
+		return err
+	}); err != nil {
+		logger.Warnf("error reading scan checkpoint: %v", err)
+	}
+
+	return ret
+}
+
+func (j *ScanJob) saveScanCheckpoint(key string, lastPath string) {
+	if err := j.txnManager.WithTxn(context.TODO(), func(r models.Repository) error {
+		_, err := r.ScanCheckpoint().Save(models.ScanCheckpoint{
+			Paths:     key,
+			LastPath:  lastPath,
+			UpdatedAt: models.SQLiteTimestamp{Timestamp: time.Now()},
+		})
+		return err
+	}); err != nil {
+		logger.Warnf("error saving scan checkpoint: %v", err)
+	}
+}
+
+func (j *ScanJob) clearScanCheckpoint(key string) {
+	if err := j.txnManager.WithTxn(context.TODO(), func(r models.Repository) error {
+		return r.ScanCheckpoint().DestroyByPaths(key)
+	}); err != nil {
+		logger.Warnf("error clearing scan checkpoint: %v", err)
+	}
+}
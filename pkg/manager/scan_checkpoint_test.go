@@ -0,0 +1,78 @@
+package manager
+
+import (
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalkOrderLess(t *testing.T) {
+	p := filepath.FromSlash
+
+	// the order that filepath.Walk visits these files
+	walkOrder := []string{
+		p("/lib/a/z.mp4"),
+		p("/lib/a-b.mp4"),
+		p("/lib/b/a.mp4"),
+		p("/lib/b.mp4"),
+		p("/lib/c.mp4"),
+	}
+
+	sorted := []string{
+		walkOrder[4],
+		walkOrder[1],
+		walkOrder[3],
+		walkOrder[0],
+		walkOrder[2],
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return walkOrderLess(sorted[i], sorted[j])
+	})
+
+	assert.Equal(t, walkOrder, sorted)
+	assert.False(t, walkOrderLess(walkOrder[0], walkOrder[0]))
+}
+
+func TestScanResumePoint(t *testing.T) {
+	p := filepath.FromSlash
+	paths := []*models.StashConfig{
+		{Path: p("/lib1")},
+		{Path: p("/lib2")},
+	}
+
+	assert.Equal(t, -1, scanResumePoint(paths, ""))
+	assert.Equal(t, 0, scanResumePoint(paths, p("/lib1/a.mp4")))
+	assert.Equal(t, 1, scanResumePoint(paths, p("/lib2/sub/a.mp4")))
+	assert.Equal(t, -1, scanResumePoint(paths, p("/other/a.mp4")))
+}
+
+func TestScanCheckpointTracker(t *testing.T) {
+	var saved []string
+	tracker := newScanCheckpointTracker(2, func(lastPath string) {
+		saved = append(saved, lastPath)
+	})
+
+	files := []string{"a", "b", "c", "d", "e"}
+	seqs := make([]int, len(files))
+	for i, f := range files {
+		seqs[i] = tracker.add(f)
+	}
+
+	// out of order completion should not advance the checkpoint
+	tracker.done(seqs[1])
+	tracker.done(seqs[2])
+	assert.Empty(t, saved)
+
+	// completing the first file advances past all completed files
+	tracker.done(seqs[0])
+	assert.Equal(t, []string{"c"}, saved)
+
+	tracker.done(seqs[4])
+	assert.Equal(t, []string{"c"}, saved)
+
+	tracker.done(seqs[3])
+	assert.Equal(t, []string{"c", "e"}, saved)
+}
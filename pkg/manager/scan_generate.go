@@ -0,0 +1,117 @@
+package manager
+
+import (
+	"sync"
+
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// scanGenerateScheduler orders the generate work of scanned scenes against
+// the scanning of files, according to the generate mode of the scan.
+type scanGenerateScheduler struct {
+	mode models.ScanGenerateMode
+	// ratio is the number of files scanned for each generate when
+	// interleaving.
+	ratio int
+
+	mutex   sync.Mutex
+	scanned int
+	pending []func()
+}
+
+func newScanGenerateScheduler(mode *models.ScanGenerateMode, ratio *int) *scanGenerateScheduler {
+	ret := &scanGenerateScheduler{
+		mode:  models.ScanGenerateModeConcurrent,
+		ratio: 1,
+	}
+	if mode != nil && mode.IsValid() {
+		ret.mode = *mode
+	}
+	if ratio != nil && *ratio > 0 {
+		ret.ratio = *ratio
+	}
+	return ret
+}
+
+// concurrent returns true if scenes are generated as soon as they are
+// scanned.
+func (s *scanGenerateScheduler) concurrent() bool {
+	return s.mode == models.ScanGenerateModeConcurrent
+}
+
+// fileScanned records that a file has been scanned, with its generate work,
+// which is nil if the file needs no generating. Returns the generate work to
+// run now.
+func (s *scanGenerateScheduler) fileScanned(work func()) []func() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.mode == models.ScanGenerateModeConcurrent {
+		if work == nil {
+			return nil
+		}
+		return []func(){work}
+	}
+
+	if work != nil {
+		s.pending = append(s.pending, work)
+	}
+
+	if s.mode != models.ScanGenerateModeInterleave {
+		return nil
+	}
+
+	s.scanned++
+	if s.scanned%s.ratio != 0 || len(s.pending) == 0 {
+		return nil
+	}
+
+	next := s.pending[0]
+	s.pending = s.pending[1:]
+	return []func(){next}
+}
+
+// scanTotal sets the total of a scan's progress to the number of files to
+// scan and the generate work deferred until after files are scanned, so that
+// the scan is not complete until the deferred work has run.
+type scanTotal struct {
+	progress *job.Progress
+
+	mutex     sync.Mutex
+	counted   bool
+	files     int
+	generates int
+}
+
+// setFiles sets the number of files to scan, once they have been counted.
+func (t *scanTotal) setFiles(files int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.counted = true
+	t.files = files
+	t.progress.SetTotal(t.files + t.generates)
+}
+
+// addGenerate adds deferred generate work to the total.
+func (t *scanTotal) addGenerate() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.generates++
+	if t.counted {
+		t.progress.SetTotal(t.files + t.generates)
+	}
+}
+
+// remaining returns the generate work that has not been run, once scanning
+// has finished.
+func (s *scanGenerateScheduler) remaining() []func() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ret := s.pending
+	s.pending = nil
+	return ret
+}
@@ -0,0 +1,135 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// runScanGenerate simulates scanning the files in order, where the files
+// marked in generates have generate work. Returns the order in which the
+// files were scanned and generated.
+func runScanGenerate(s *scanGenerateScheduler, generates []bool) []string {
+	var ret []string
+	run := func(work []func()) {
+		for _, w := range work {
+			w()
+		}
+	}
+
+	for i, generate := range generates {
+		ret = append(ret, fmt.Sprintf("scan %d", i))
+
+		var work func()
+		if generate {
+			i := i
+			work = func() {
+				ret = append(ret, fmt.Sprintf("generate %d", i))
+			}
+		}
+		run(s.fileScanned(work))
+	}
+
+	run(s.remaining())
+	return ret
+}
+
+func TestScanGenerateScheduler(t *testing.T) {
+	concurrent := models.ScanGenerateModeConcurrent
+	scanFirst := models.ScanGenerateModeScanFirst
+	interleave := models.ScanGenerateModeInterleave
+	invalid := models.ScanGenerateMode("invalid")
+	two := 2
+	zero := 0
+
+	tests := []struct {
+		name      string
+		mode      *models.ScanGenerateMode
+		ratio     *int
+		generates []bool
+		want      []string
+	}{
+		{
+			"default",
+			nil, nil,
+			[]bool{true, false, true},
+			[]string{"scan 0", "generate 0", "scan 1", "scan 2", "generate 2"},
+		},
+		{
+			"concurrent",
+			&concurrent, nil,
+			[]bool{true, true},
+			[]string{"scan 0", "generate 0", "scan 1", "generate 1"},
+		},
+		{
+			"invalid mode is concurrent",
+			&invalid, nil,
+			[]bool{true, true},
+			[]string{"scan 0", "generate 0", "scan 1", "generate 1"},
+		},
+		{
+			"scan first",
+			&scanFirst, nil,
+			[]bool{true, false, true},
+			[]string{"scan 0", "scan 1", "scan 2", "generate 0", "generate 2"},
+		},
+		{
+			"interleave",
+			&interleave, &two,
+			[]bool{true, true, true, true, true},
+			[]string{"scan 0", "scan 1", "generate 0", "scan 2", "scan 3", "generate 1", "scan 4", "generate 2", "generate 3", "generate 4"},
+		},
+		{
+			"interleave without generates",
+			&interleave, &two,
+			[]bool{false, false, true, false},
+			[]string{"scan 0", "scan 1", "scan 2", "scan 3", "generate 2"},
+		},
+		{
+			"interleave defaults to one",
+			&interleave, &zero,
+			[]bool{true, true},
+			[]string{"scan 0", "generate 0", "scan 1", "generate 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newScanGenerateScheduler(tt.mode, tt.ratio)
+			assert.Equal(t, tt.want, runScanGenerate(s, tt.generates))
+		})
+	}
+}
+
+func TestScanTotal(t *testing.T) {
+	m := job.NewManager()
+	defer m.Stop()
+
+	scanned := make(chan struct{})
+	generated := make(chan struct{})
+	exec := job.MakeJobExec(func(ctx context.Context, p *job.Progress) {
+		totals := &scanTotal{progress: p}
+
+		// generate work deferred before the files are counted
+		totals.addGenerate()
+		totals.setFiles(2)
+		totals.addGenerate()
+
+		// both files are scanned
+		p.Increment()
+		p.Increment()
+		scanned <- struct{}{}
+		<-generated
+	})
+
+	id := m.Add(context.Background(), "scan", exec)
+
+	<-scanned
+	// the scan is incomplete until the deferred work has run
+	assert.Equal(t, 0.5, m.GetJob(id).Progress)
+	generated <- struct{}{}
+}
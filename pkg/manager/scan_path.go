@@ -0,0 +1,47 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// targetedScanPath returns the library of the file or directory to scan,
+// with its path changed to the path to scan. Returns an error if the path
+// does not exist or is not within one of the libraries in stashes.
+func targetedScanPath(path string, stashes []*models.StashConfig) (*models.StashConfig, error) {
+	if path == "" {
+		return nil, errors.New("path is required")
+	}
+
+	if !file.IsRemotePath(path) {
+		if !filepath.IsAbs(path) {
+			return nil, fmt.Errorf("%s is not an absolute path", path)
+		}
+		path = filepath.Clean(path)
+	}
+
+	var stash *models.StashConfig
+	for _, s := range stashes {
+		if utils.IsPathInDir(s.Path, path) {
+			stash = s
+			break
+		}
+	}
+	if stash == nil {
+		return nil, fmt.Errorf("%s is not in the configured stash paths", path)
+	}
+
+	if _, err := file.FileSystemFor(path).Stat(path); err != nil {
+		return nil, fmt.Errorf("cannot scan %s: %w", path, err)
+	}
+
+	// make a copy, changing the path
+	ret := *stash
+	ret.Path = path
+	return &ret, nil
+}
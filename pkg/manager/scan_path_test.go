@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// makeScanPathLibrary creates a library with video files in two
+// directories, returning its path.
+func makeScanPathLibrary(t *testing.T) string {
+	lib := t.TempDir()
+	for _, p := range []string{"a/1.mp4", "a/sub/2.mp4", "b/3.mp4", "4.mp4"} {
+		p = filepath.Join(lib, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("video"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return lib
+}
+
+func walkScanPath(t *testing.T, stash *models.StashConfig) []string {
+	var ret []string
+	if err := walkFilesToScan(stash, func(path string, info os.FileInfo, err error) error {
+		ret = append(ret, path)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return ret
+}
+
+func TestTargetedScanPath(t *testing.T) {
+	lib := makeScanPathLibrary(t)
+	stashes := []*models.StashConfig{
+		{Path: lib, ExcludeImage: true},
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{
+			"directory",
+			filepath.Join(lib, "a"),
+			[]string{filepath.Join(lib, "a", "1.mp4"), filepath.Join(lib, "a", "sub", "2.mp4")},
+		},
+		{
+			"file",
+			filepath.Join(lib, "b", "3.mp4"),
+			[]string{filepath.Join(lib, "b", "3.mp4")},
+		},
+		{
+			"unclean path",
+			filepath.Join(lib, "a", "sub") + string(filepath.Separator) + ".." + string(filepath.Separator) + "sub",
+			[]string{filepath.Join(lib, "a", "sub", "2.mp4")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stash, err := targetedScanPath(tt.path, stashes)
+			if !assert.Nil(t, err) {
+				return
+			}
+
+			// the settings of the library are kept
+			assert.True(t, stash.ExcludeImage)
+			assert.Equal(t, tt.want, walkScanPath(t, stash))
+		})
+	}
+
+	// the library is not changed
+	assert.Equal(t, lib, stashes[0].Path)
+}
+
+func TestTargetedScanPathRejected(t *testing.T) {
+	lib := makeScanPathLibrary(t)
+	other := t.TempDir()
+	stashes := []*models.StashConfig{
+		{Path: filepath.Join(lib, "a")},
+	}
+
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"empty", ""},
+		{"relative", filepath.Join("a", "1.mp4")},
+		{"outside library", other},
+		{"sibling of library", filepath.Join(lib, "b")},
+		{"parent of library", lib},
+		{"escapes library", filepath.Join(lib, "a") + string(filepath.Separator) + ".." + string(filepath.Separator) + "b"},
+		{"missing", filepath.Join(lib, "a", "missing.mp4")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := targetedScanPath(tt.path, stashes)
+			assert.NotNil(t, err)
+		})
+	}
+}
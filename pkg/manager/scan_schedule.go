@@ -0,0 +1,78 @@
+package manager
+
+import (
+	"context"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// scanSchedulePrefix prefixes the scheduler names of library scan
+// schedules, which are followed by the library path.
+const scanSchedulePrefix = "scan:"
+
+// refreshScanSchedules schedules scans of the libraries with a scan
+// schedule, and removes the schedules of libraries without one.
+func (s *singleton) refreshScanSchedules() {
+	scheduled := make(map[string]bool)
+
+	for _, stash := range s.Config.GetStashPaths() {
+		if stash.ScanSchedule == nil || *stash.ScanSchedule == "" {
+			continue
+		}
+
+		schedule, err := job.ParseSchedule(*stash.ScanSchedule)
+		if err != nil {
+			logger.Warnf("invalid scan schedule for %s: %v", stash.Path, err)
+			continue
+		}
+
+		name := scanSchedulePrefix + stash.Path
+		stash := stash
+		s.Scheduler.Set(name, schedule, func() {
+			s.runScheduledScan(stash)
+		})
+		scheduled[name] = true
+	}
+
+	for _, name := range s.Scheduler.Names() {
+		if strings.HasPrefix(name, scanSchedulePrefix) && !scheduled[name] {
+			s.Scheduler.Remove(name)
+		}
+	}
+}
+
+func (s *singleton) runScheduledScan(stash *models.StashConfig) {
+	logger.Infof("Starting scheduled scan of %s", stash.Path)
+	if _, err := s.Scan(context.Background(), scanInput(stash, s.Config.GetDefaultScanSettings())); err != nil {
+		logger.Errorf("error starting scheduled scan of %s: %v", stash.Path, err)
+	}
+}
+
+// scanInput returns the input of a scan of the library. The scan settings
+// of the library are used if set, otherwise defaults is used.
+func scanInput(stash *models.StashConfig, defaults *models.ScanMetadataOptions) models.ScanMetadataInput {
+	input := models.ScanMetadataInput{
+		Paths: []string{stash.Path},
+	}
+
+	options := stash.ScanSettings
+	if options == nil {
+		options = defaults
+	}
+	if options == nil {
+		return input
+	}
+
+	input.UseFileMetadata = &options.UseFileMetadata
+	input.StripFileExtension = &options.StripFileExtension
+	input.ScanGeneratePreviews = &options.ScanGeneratePreviews
+	input.ScanGenerateImagePreviews = &options.ScanGenerateImagePreviews
+	input.ScanGenerateSprites = &options.ScanGenerateSprites
+	input.ScanGeneratePhashes = &options.ScanGeneratePhashes
+	input.ScanGenerateThumbnails = &options.ScanGenerateThumbnails
+
+	return input
+}
@@ -0,0 +1,38 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestScanInput(t *testing.T) {
+	defaults := &models.ScanMetadataOptions{
+		ScanGenerateSprites: true,
+	}
+
+	stash := &models.StashConfig{Path: "/stash"}
+	input := scanInput(stash, defaults)
+	if len(input.Paths) != 1 || input.Paths[0] != "/stash" {
+		t.Errorf("paths = %v, want [/stash]", input.Paths)
+	}
+	if input.ScanGenerateSprites == nil || !*input.ScanGenerateSprites {
+		t.Error("default settings not used")
+	}
+
+	stash.ScanSettings = &models.ScanMetadataOptions{
+		ScanGeneratePhashes: true,
+	}
+	input = scanInput(stash, defaults)
+	if input.ScanGenerateSprites == nil || *input.ScanGenerateSprites {
+		t.Error("default settings used instead of library settings")
+	}
+	if input.ScanGeneratePhashes == nil || !*input.ScanGeneratePhashes {
+		t.Error("library settings not used")
+	}
+
+	input = scanInput(&models.StashConfig{Path: "/stash"}, nil)
+	if input.ScanGenerateSprites != nil {
+		t.Error("settings set without library or default settings")
+	}
+}
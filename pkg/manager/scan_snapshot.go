@@ -0,0 +1,165 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// incrementalScanMaxAge is the age after which a snapshot is considered
+// stale. Incremental scans of library paths with stale snapshots scan all
+// files, so that files added without changing the modification time of
+// their directory are eventually scanned.
+const incrementalScanMaxAge = 7 * 24 * time.Hour
+
+// incrementalScan is the state of an incremental scan of a library path.
+type incrementalScan struct {
+	// snapshot is the snapshot to store once the scan completes.
+	snapshot *file.Snapshot
+	// full is true if there is no usable snapshot from a previous scan.
+	full bool
+	// changed are the files added or modified since the previous snapshot,
+	// in walk order.
+	changed []string
+}
+
+// walk calls f for each changed file.
+func (s *incrementalScan) walk(f filepath.WalkFunc) error {
+	for _, path := range s.changed {
+		info, err := os.Stat(path)
+		if err != nil {
+			logger.Warnf("error scanning %s: %s", path, err.Error())
+			continue
+		}
+
+		if err := f(path, info, nil); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prepareIncrementalScans takes snapshots of the provided library paths
+// and determines the files changed since the previous incremental scan.
+// Library paths which cannot be snapshotted are omitted from the returned
+// map, and should be fully scanned.
+func (j *ScanJob) prepareIncrementalScans(ctx context.Context, paths []*models.StashConfig) map[string]*incrementalScan {
+	ret := make(map[string]*incrementalScan)
+
+	for _, sp := range paths {
+		// zip files are handled by walkFilesToScan, and remote file systems
+		// are not snapshotted
+		if file.IsZipPath(sp.Path) || file.IsRemotePath(sp.Path) {
+			continue
+		}
+
+		previous := j.getScanSnapshot(ctx, sp.Path)
+		filter := newScanFilter(sp)
+
+		snapshot, err := file.TakeSnapshot(sp.Path, previous, filter.skipDir)
+		if err != nil {
+			logger.Warnf("error taking snapshot of %s: %v", sp.Path, err)
+			continue
+		}
+
+		s := &incrementalScan{
+			snapshot: snapshot,
+			full:     previous == nil,
+		}
+
+		if s.full {
+			logger.Infof("No recent snapshot of %s. Scanning all files.", sp.Path)
+		} else {
+			diff := previous.Diff(snapshot)
+			s.changed = changedFilesToScan(diff, filter)
+
+			logger.Infof("%s: %d added, %d modified and %d removed files since last scan", sp.Path, len(diff.Added), len(diff.Modified), len(diff.Removed))
+		}
+
+		ret[sp.Path] = s
+	}
+
+	return ret
+}
+
+// changedFilesToScan returns the added and modified files that should be
+// scanned, in walk order.
+func changedFilesToScan(diff file.SnapshotDiff, filter *scanFilter) []string {
+	var ret []string
+	for _, l := range [][]string{diff.Added, diff.Modified} {
+		for _, path := range l {
+			if filter.includeFile(path) {
+				ret = append(ret, path)
+			}
+		}
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return walkOrderLess(ret[i], ret[j])
+	})
+
+	return ret
+}
+
+// getScanSnapshot returns the stored snapshot of the library path, or nil
+// if there is no snapshot or it is stale.
+func (j *ScanJob) getScanSnapshot(ctx context.Context, path string) *file.Snapshot {
+	var stored *models.ScanSnapshot
+	if err := j.txnManager.WithReadTxn(ctx, func(r models.ReaderRepository) error {
+		var err error
+		stored, err = r.ScanSnapshot().FindByPath(path)
+		return err
+	}); err != nil {
+		logger.Warnf("error reading snapshot of %s: %v", path, err)
+		return nil
+	}
+
+	if stored == nil || time.Since(stored.CreatedAt.Timestamp) > incrementalScanMaxAge {
+		return nil
+	}
+
+	ret, err := file.UnmarshalSnapshot(stored.Data)
+	if err != nil {
+		logger.Warnf("error decoding snapshot of %s: %v", path, err)
+		return nil
+	}
+
+	return ret
+}
+
+// saveScanSnapshot stores the snapshot of the library path. The creation
+// time of the previous snapshot is kept unless all files were scanned, so
+// that incremental scans do not keep the snapshot from becoming stale.
+func (j *ScanJob) saveScanSnapshot(path string, s *incrementalScan, created time.Time) {
+	snapshot := s.snapshot
+	data, err := snapshot.Marshal()
+	if err != nil {
+		logger.Warnf("error encoding snapshot of %s: %v", path, err)
+		return
+	}
+
+	if err := j.txnManager.WithTxn(context.TODO(), func(r models.Repository) error {
+		qb := r.ScanSnapshot()
+		if s.full {
+			if err := qb.DestroyByPath(path); err != nil {
+				return err
+			}
+		}
+
+		_, err := qb.Save(models.ScanSnapshot{
+			Path:      path,
+			Data:      data,
+			CreatedAt: models.SQLiteTimestamp{Timestamp: created},
+		})
+		return err
+	}); err != nil {
+		logger.Warnf("error saving snapshot of %s: %v", path, err)
+	}
+}
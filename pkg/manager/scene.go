@@ -1,21 +1,62 @@
 package manager
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene"
 	"github.com/stashapp/stash/pkg/utils"
 )
 
+// SceneSidecarOptions returns the configured options for scene metadata
+// sidecar files.
+func SceneSidecarOptions() scene.SidecarOptions {
+	c := config.GetInstance()
+	return scene.SidecarOptions{
+		Read:       c.GetReadSceneSidecars(),
+		Write:      c.GetWriteSceneSidecars(),
+		Format:     c.GetSceneSidecarFormat(),
+		Precedence: c.GetSceneSidecarPrecedence(),
+	}
+}
+
+// WriteSceneSidecars writes the metadata sidecar files of the scenes with
+// the provided ids, if writing sidecars is enabled.
+func WriteSceneSidecars(ctx context.Context, txnManager models.TransactionManager, ids []int) {
+	options := SceneSidecarOptions()
+	if !options.Write || len(ids) == 0 {
+		return
+	}
+
+	if err := txnManager.WithReadTxn(ctx, func(r models.ReaderRepository) error {
+		scenes, err := r.Scene().FindMany(ids)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range scenes {
+			if _, err := scene.WriteSidecar(r, s, options.Format); err != nil {
+				logger.Warnf("error writing sidecar for %s: %v", s.Path, err)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		logger.Warnf("error writing scene sidecars: %v", err)
+	}
+}
+
 func GetSceneFileContainer(scene *models.Scene) (ffmpeg.Container, error) {
 	var container ffmpeg.Container
 	if scene.Format.Valid {
 		container = ffmpeg.Container(scene.Format.String)
 	} else { // container isn't in the DB
 		// shouldn't happen, fallback to ffprobe
-		ffprobe := GetInstance().FFProbe
+		ffprobe := GetInstance().FFProbe()
 		tmpVideoFile, err := ffprobe.NewVideoFile(scene.Path, false)
 		if err != nil {
 			return ffmpeg.Container(""), fmt.Errorf("error reading video file: %v", err)
@@ -80,11 +121,18 @@ func GetSceneStreamPaths(scene *models.Scene, directStreamURL string, maxStreami
 	// don't care if we can't get the container
 	container, _ := GetSceneFileContainer(scene)
 
-	if HasTranscode(scene, config.GetInstance().GetVideoFileNamingAlgorithm()) || ffmpeg.IsValidAudioForContainer(audioCodec, container) {
+	// the direct stream has the mime type of the served file, so that
+	// clients that can't play the container fall back to a transcode
+	hasTranscode := HasTranscode(scene, config.GetInstance().GetVideoFileNamingAlgorithm())
+	if hasTranscode || ffmpeg.IsValidAudioForContainer(audioCodec, container) {
 		label := "Direct stream"
+		mimeDirect := ffmpeg.ContainerMimeType(container)
+		if hasTranscode || mimeDirect == "" {
+			mimeDirect = mimeMp4
+		}
 		ret = append(ret, &models.SceneStreamEndpoint{
 			URL:      directStreamURL,
-			MimeType: &mimeMp4,
+			MimeType: &mimeDirect,
 			Label:    &label,
 		})
 	}
@@ -107,6 +155,13 @@ func GetSceneStreamPaths(scene *models.Scene, directStreamURL string, maxStreami
 	}
 	ret = append(ret, &hls)
 
+	labelAdaptiveHLS := "HLS (adaptive)"
+	ret = append(ret, &models.SceneStreamEndpoint{
+		URL:      directStreamURL + "/hls/master.m3u8",
+		MimeType: &mimeHLS,
+		Label:    &labelAdaptiveHLS,
+	})
+
 	// WEBM quality transcoding options
 	// Note: These have the wrong mime type intentionally to allow jwplayer to selection between mp4/webm
 	webmLabelFourK := "WEBM 4K (2160p)"         // "FOUR_K"
@@ -3,12 +3,25 @@ package manager
 import (
 	"github.com/stashapp/stash/pkg/ffmpeg"
 	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/scene"
 )
 
-func makeScreenshot(probeResult ffmpeg.VideoFile, outputPath string, quality int, width int, time float64) {
-	encoder := instance.FFMPEG
+// screenshotPosition returns the configured position of default scene
+// screenshots.
+func screenshotPosition() scene.ScreenshotPosition {
+	c := config.GetInstance()
+	return scene.ScreenshotPosition{
+		Percent: c.GetScreenshotPositionPercent(),
+		Seconds: c.GetScreenshotPositionSeconds(),
+	}
+}
+
+func makeScreenshot(probeResult ffmpeg.VideoFile, outputPath string, format ffmpeg.ImageFormat, quality int, width int, time float64) {
+	encoder := instance.FFMPEG()
 	options := ffmpeg.ScreenshotOptions{
 		OutputPath: outputPath,
+		Format:     format,
 		Quality:    quality,
 		Time:       time,
 		Width:      width,
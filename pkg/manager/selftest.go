@@ -0,0 +1,116 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+var errEncoderMissing = errors.New("ffmpeg not found")
+
+type selfTestCheck struct {
+	name string
+	run  func() error
+}
+
+// SelfTest checks that ffmpeg can encode a sample video, and that the
+// database and generated directory are writable. The result of each check
+// is logged and returned.
+func (s *singleton) SelfTest(ctx context.Context) []*models.SelfTestResult {
+	generatedPath := s.Config.GetGeneratedPath()
+
+	return runSelfTest(ctx, []selfTestCheck{
+		{"ffmpeg", func() error {
+			return checkFFMPEGEncode(s.FFMPEG())
+		}},
+		{"database", database.CheckWritable},
+		{"generated", func() error {
+			return checkDirWritable(generatedPath)
+		}},
+	})
+}
+
+func runSelfTest(ctx context.Context, checks []selfTestCheck) []*models.SelfTestResult {
+	var ret []*models.SelfTestResult
+	for _, c := range checks {
+		err := ctx.Err()
+		if err == nil {
+			err = c.run()
+		}
+
+		result := &models.SelfTestResult{
+			Name:   c.name,
+			Passed: err == nil,
+		}
+
+		if err != nil {
+			msg := err.Error()
+			result.Error = &msg
+			logger.Errorf("Self-test %s failed: %s", c.name, msg)
+		} else {
+			logger.Infof("Self-test %s passed", c.name)
+		}
+
+		ret = append(ret, result)
+	}
+
+	return ret
+}
+
+// checkFFMPEGEncode returns an error if the encoder cannot encode a sample
+// video.
+func checkFFMPEGEncode(encoder ffmpeg.Encoder) error {
+	if encoder == "" {
+		return errEncoderMissing
+	}
+
+	dir, err := os.MkdirTemp("", "stash-selftest")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	outputPath := filepath.Join(dir, "sample.mp4")
+	if err := encoder.EncodeSample(outputPath); err != nil {
+		return fmt.Errorf("error encoding sample: %w", err)
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		return fmt.Errorf("error reading encoded sample: %w", err)
+	}
+	if info.Size() == 0 {
+		return errors.New("encoded sample is empty")
+	}
+
+	return nil
+}
+
+// checkDirWritable returns an error if a file cannot be written to dir.
+func checkDirWritable(dir string) error {
+	if dir == "" {
+		return errors.New("directory not set")
+	}
+
+	f, err := os.CreateTemp(dir, ".selftest-*")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.WriteString("selftest")
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if removeErr := os.Remove(f.Name()); err == nil {
+		err = removeErr
+	}
+
+	return err
+}
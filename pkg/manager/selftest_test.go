@@ -0,0 +1,94 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeStubEncoder writes an ffmpeg shell script running script to dir.
+// The output path is the last argument of the script.
+func writeStubEncoder(t *testing.T, dir string, script string) ffmpeg.Encoder {
+	t.Helper()
+
+	path := filepath.Join(dir, "ffmpeg")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\nfor out; do :; done\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return ffmpeg.Encoder(path)
+}
+
+func TestCheckFFMPEGEncode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("stub binaries require a POSIX shell")
+	}
+
+	tests := []struct {
+		name    string
+		script  string
+		wantErr bool
+	}{
+		{"encodes", `echo sample > "$out"`, false},
+		{"fails", "exit 1", true},
+		{"no output", "exit 0", true},
+		{"empty output", `touch "$out"`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			encoder := writeStubEncoder(t, t.TempDir(), tt.script)
+			err := checkFFMPEGEncode(encoder)
+			assert.Equal(t, tt.wantErr, err != nil, "%v", err)
+		})
+	}
+
+	assert.NotNil(t, checkFFMPEGEncode(""))
+}
+
+func TestCheckDirWritable(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, checkDirWritable(dir))
+
+	// the test file is removed
+	entries, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+
+	assert.NotNil(t, checkDirWritable(filepath.Join(dir, "missing")))
+	assert.NotNil(t, checkDirWritable(""))
+}
+
+func TestRunSelfTest(t *testing.T) {
+	failure := "check failed"
+	checks := []selfTestCheck{
+		{"pass", func() error { return nil }},
+		{"fail", func() error { return errors.New(failure) }},
+	}
+
+	got := runSelfTest(context.Background(), checks)
+	assert.Equal(t, []*models.SelfTestResult{
+		{Name: "pass", Passed: true},
+		{Name: "fail", Passed: false, Error: &failure},
+	}, got)
+
+	// checks are not run once the context is cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	ran := false
+	got = runSelfTest(ctx, []selfTestCheck{
+		{"cancelled", func() error {
+			ran = true
+			return nil
+		}},
+	})
+	assert.False(t, ran)
+	assert.False(t, got[0].Passed)
+}
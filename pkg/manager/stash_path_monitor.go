@@ -0,0 +1,233 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// checkStashPath returns an error if the stash path cannot be read. Local
+// paths are opened and read, so that unreachable network mounts are
+// detected without listing the whole directory.
+func checkStashPath(path string) error {
+	if file.IsRemotePath(path) {
+		_, err := file.FileSystemFor(path).Stat(path)
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Readdirnames(1); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	return nil
+}
+
+// stashPathCheckTimeout is the maximum duration of a stash path check.
+// File system calls on unreachable network mounts can block indefinitely, so
+// paths that do not respond in time are treated as unreachable.
+const stashPathCheckTimeout = 10 * time.Second
+
+var errStashPathCheckTimeout = errors.New("timed out checking path")
+
+// StashPathMonitor periodically checks that the stash paths are reachable.
+// Files in offline stash paths are not treated as missing by scans and
+// cleans. A nil StashPathMonitor treats all paths as online.
+type StashPathMonitor struct {
+	// checkMutex serializes checks, so that state changes are published
+	// once
+	checkMutex sync.Mutex
+	mutex      sync.RWMutex
+	offline    map[string]bool
+
+	paths   func() []string
+	check   func(path string) error
+	timeout time.Duration
+	// pending is the paths with a check that has timed out and not yet
+	// returned. New checks of these paths are not started.
+	pending map[string]bool
+	publish func(e StashPathEvent)
+
+	stopMutex sync.Mutex
+	stop      chan struct{}
+}
+
+// NewStashPathMonitor returns a monitor checking the stash paths returned by
+// paths. publish is called when a stash path goes offline or comes back
+// online.
+func NewStashPathMonitor(paths func() []string, publish func(e StashPathEvent)) *StashPathMonitor {
+	return &StashPathMonitor{
+		offline: make(map[string]bool),
+		paths:   paths,
+		check:   checkStashPath,
+		timeout: stashPathCheckTimeout,
+		pending: make(map[string]bool),
+		publish: publish,
+	}
+}
+
+// checkWithTimeout checks the path in a goroutine, returning an error if the
+// check does not return within the timeout. A check that times out is left
+// running, and the path is reported as unreachable until it returns.
+func (m *StashPathMonitor) checkWithTimeout(path string) error {
+	m.mutex.Lock()
+	if m.pending[path] {
+		m.mutex.Unlock()
+		return fmt.Errorf("%w: previous check has not returned", errStashPathCheckTimeout)
+	}
+	m.pending[path] = true
+	m.mutex.Unlock()
+
+	done := make(chan error, 1)
+	go func() {
+		err := m.check(path)
+
+		m.mutex.Lock()
+		delete(m.pending, path)
+		m.mutex.Unlock()
+
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(m.timeout):
+		return fmt.Errorf("%w after %v", errStashPathCheckTimeout, m.timeout)
+	}
+}
+
+// Check checks each stash path, publishing an event for each path whose
+// state has changed.
+func (m *StashPathMonitor) Check() {
+	if m == nil {
+		return
+	}
+
+	m.checkMutex.Lock()
+	defer m.checkMutex.Unlock()
+
+	paths := m.paths()
+
+	var changed []StashPathEvent
+	offline := make(map[string]bool)
+	for _, p := range paths {
+		err := m.checkWithTimeout(p)
+		if err != nil {
+			offline[p] = true
+		}
+
+		m.mutex.RLock()
+		wasOffline := m.offline[p]
+		m.mutex.RUnlock()
+
+		switch {
+		case err != nil && !wasOffline:
+			logger.Warnf("Stash path %s is offline. Files in it will not be scanned or cleaned: %v", p, err)
+			changed = append(changed, StashPathEvent{Path: p, Online: false})
+		case err == nil && wasOffline:
+			logger.Infof("Stash path %s is back online", p)
+			changed = append(changed, StashPathEvent{Path: p, Online: true})
+		}
+	}
+
+	// paths removed from the configuration are forgotten
+	m.mutex.Lock()
+	m.offline = offline
+	m.mutex.Unlock()
+
+	if m.publish != nil {
+		for _, e := range changed {
+			m.publish(e)
+		}
+	}
+}
+
+// IsOffline returns true if path is in an offline stash path.
+func (m *StashPathMonitor) IsOffline(path string) bool {
+	if m == nil {
+		return false
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	for p := range m.offline {
+		if utils.IsPathInDir(p, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OfflinePaths returns the sorted stash paths that are offline.
+func (m *StashPathMonitor) OfflinePaths() []string {
+	if m == nil {
+		return nil
+	}
+
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	var ret []string
+	for p := range m.offline {
+		ret = append(ret, p)
+	}
+	sort.Strings(ret)
+
+	return ret
+}
+
+// SetInterval sets the interval at which the stash paths are checked,
+// restarting the periodic checks. Periodic checks are stopped if interval
+// is zero.
+func (m *StashPathMonitor) SetInterval(interval time.Duration) {
+	m.stopMutex.Lock()
+	defer m.stopMutex.Unlock()
+
+	if m.stop != nil {
+		close(m.stop)
+		m.stop = nil
+	}
+
+	if interval <= 0 {
+		return
+	}
+
+	stop := make(chan struct{})
+	m.stop = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		m.Check()
+		for {
+			select {
+			case <-ticker.C:
+				m.Check()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the periodic checks.
+func (m *StashPathMonitor) Stop() {
+	m.SetInterval(0)
+}
@@ -0,0 +1,130 @@
+package manager
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestStashPathMonitor(paths ...string) (*StashPathMonitor, *[]StashPathEvent) {
+	var events []StashPathEvent
+	m := NewStashPathMonitor(func() []string {
+		return paths
+	}, func(e StashPathEvent) {
+		events = append(events, e)
+	})
+
+	return m, &events
+}
+
+func TestStashPathMonitorCheck(t *testing.T) {
+	dir := t.TempDir()
+	online := filepath.Join(dir, "online")
+	offline := filepath.Join(dir, "offline")
+	if err := os.Mkdir(online, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	m, events := newTestStashPathMonitor(online, offline)
+	m.Check()
+
+	assert := assert.New(t)
+	assert.Equal([]StashPathEvent{{Path: offline, Online: false}}, *events)
+	assert.Equal([]string{offline}, m.OfflinePaths())
+	assert.True(m.IsOffline(offline))
+	assert.True(m.IsOffline(filepath.Join(offline, "sub", "file.mp4")))
+	assert.False(m.IsOffline(filepath.Join(online, "file.mp4")))
+
+	// unchanged state is not published again
+	m.Check()
+	assert.Len(*events, 1)
+
+	if err := os.Mkdir(offline, 0755); err != nil {
+		t.Fatal(err)
+	}
+	m.Check()
+
+	assert.Equal(StashPathEvent{Path: offline, Online: true}, (*events)[1])
+	assert.Empty(m.OfflinePaths())
+	assert.False(m.IsOffline(filepath.Join(offline, "file.mp4")))
+}
+
+func TestStashPathMonitorCheckTimeout(t *testing.T) {
+	const path = "/mnt/hung"
+
+	unblock := make(chan struct{})
+	var calls int32
+	m, events := newTestStashPathMonitor(path)
+	m.timeout = 10 * time.Millisecond
+	m.check = func(string) error {
+		atomic.AddInt32(&calls, 1)
+		<-unblock
+		return nil
+	}
+
+	// a hung check is treated as unreachable
+	m.Check()
+	assert.Equal(t, []StashPathEvent{{Path: path, Online: false}}, *events)
+	assert.True(t, m.IsOffline(path))
+
+	// another check is not started while the previous check is hung
+	err := m.checkWithTimeout(path)
+	assert.True(t, errors.Is(err, errStashPathCheckTimeout))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	close(unblock)
+	assert.Eventually(t, func() bool {
+		return m.checkWithTimeout(path) == nil
+	}, time.Second, time.Millisecond)
+}
+
+func TestCleanSkipsOfflineStashPaths(t *testing.T) {
+	dir := t.TempDir()
+	online := filepath.Join(dir, "online")
+	offline := filepath.Join(dir, "offline")
+	if err := os.Mkdir(online, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	m, _ := newTestStashPathMonitor(online, offline)
+	m.Check()
+
+	j := &cleanJob{
+		pathMonitor: m,
+	}
+
+	assert := assert.New(t)
+
+	// missing files in an offline stash path are not cleaned
+	assert.False(j.shouldClean(filepath.Join(offline, "missing.mp4")))
+	assert.True(j.shouldClean(filepath.Join(online, "missing.mp4")))
+}
+
+func TestScanSkipsOfflineStashPaths(t *testing.T) {
+	dir := t.TempDir()
+	online := filepath.Join(dir, "online")
+	offline := filepath.Join(dir, "offline")
+	if err := os.Mkdir(online, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	m, _ := newTestStashPathMonitor(online, offline)
+	j := &ScanJob{
+		pathMonitor: m,
+	}
+
+	paths := j.onlinePaths([]*models.StashConfig{
+		{Path: online},
+		{Path: offline},
+	})
+
+	if assert.Len(t, paths, 1) {
+		assert.Equal(t, online, paths[0].Path)
+	}
+}
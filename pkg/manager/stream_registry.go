@@ -0,0 +1,77 @@
+package manager
+
+import (
+	"context"
+	"sync"
+)
+
+// StreamRegistry tracks the active scene streams, including HLS sessions,
+// so that shutdown can wait for them to finish.
+type StreamRegistry struct {
+	mutex    sync.Mutex
+	active   int
+	draining bool
+	// idle is closed when the last active stream ends while draining
+	idle chan struct{}
+}
+
+func NewStreamRegistry() *StreamRegistry {
+	return &StreamRegistry{
+		idle: make(chan struct{}),
+	}
+}
+
+// Begin registers a new stream. Returns false if the registry is draining,
+// in which case the stream should be refused. End must be called once the
+// stream finishes if Begin returns true.
+func (r *StreamRegistry) Begin() bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.draining {
+		return false
+	}
+
+	r.active++
+	return true
+}
+
+// End deregisters a stream registered with Begin.
+func (r *StreamRegistry) End() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.active--
+	if r.active == 0 && r.draining {
+		close(r.idle)
+	}
+}
+
+// Active returns the number of active streams.
+func (r *StreamRegistry) Active() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.active
+}
+
+// Drain stops new streams from being accepted, then waits for the active
+// streams to finish or for the context to be done. Returns the number of
+// streams that were still active.
+func (r *StreamRegistry) Drain(ctx context.Context) int {
+	r.mutex.Lock()
+	if !r.draining {
+		r.draining = true
+		if r.active == 0 {
+			close(r.idle)
+		}
+	}
+	r.mutex.Unlock()
+
+	select {
+	case <-r.idle:
+	case <-ctx.Done():
+	}
+
+	return r.Active()
+}
@@ -0,0 +1,72 @@
+package manager
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStream begins a stream that ends once done is closed.
+func fakeStream(t *testing.T, r *StreamRegistry, done <-chan struct{}) {
+	t.Helper()
+
+	if !r.Begin() {
+		t.Fatal("stream refused")
+	}
+
+	go func() {
+		<-done
+		r.End()
+	}()
+}
+
+func TestStreamRegistryDrainIdle(t *testing.T) {
+	r := NewStreamRegistry()
+
+	assert.Equal(t, 0, r.Drain(context.Background()))
+
+	// new streams are refused once draining
+	assert.False(t, r.Begin())
+	assert.Equal(t, 0, r.Active())
+}
+
+func TestStreamRegistryDrainFinished(t *testing.T) {
+	r := NewStreamRegistry()
+
+	done := make(chan struct{})
+	fakeStream(t, r, done)
+	fakeStream(t, r, done)
+	assert.Equal(t, 2, r.Active())
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(done)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	assert.Equal(t, 0, r.Drain(ctx))
+	assert.Nil(t, ctx.Err(), "drain waited until the deadline")
+}
+
+func TestStreamRegistryDrainDeadline(t *testing.T) {
+	r := NewStreamRegistry()
+
+	finished := make(chan struct{})
+	close(finished)
+	stuck := make(chan struct{})
+	defer close(stuck)
+
+	fakeStream(t, r, finished)
+	fakeStream(t, r, stuck)
+	fakeStream(t, r, stuck)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.Equal(t, 2, r.Drain(ctx))
+	assert.False(t, r.Begin())
+}
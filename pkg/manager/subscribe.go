@@ -3,42 +3,188 @@ package manager
 import (
 	"context"
 	"sync"
+
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// Topic is the category of an Event. Subscribers receive the events of the
+// topic they subscribed to.
+type Topic string
+
+const (
+	// TopicJob events are published when jobs are added, updated or
+	// removed. The payload is a JobEvent.
+	TopicJob Topic = "job"
+	// TopicScan events are published when a scan or clean is complete. The
+	// payload is a ScanEvent.
+	TopicScan Topic = "scan"
+	// TopicGenerate events are published when a generate job is complete.
+	// The payload is a GenerateEvent.
+	TopicGenerate Topic = "generate"
+	// TopicConfig events are published when the configuration changes. The
+	// payload is nil.
+	TopicConfig Topic = "config"
+	// TopicLibrary events are published when changes to library
+	// directories are detected. The payload is a LibraryEvent.
+	TopicLibrary Topic = "library"
+	// TopicStashPath events are published when a stash path goes offline
+	// or comes back online. The payload is a StashPathEvent.
+	TopicStashPath Topic = "stash_path"
 )
 
-type subscriptionManager struct {
-	subscriptions []chan bool
+// Event is an event published to the subscribers of a topic.
+type Event struct {
+	Topic   Topic
+	Payload interface{}
+}
+
+// JobEventType is the type of a JobEvent.
+type JobEventType string
+
+const (
+	// JobAdded is published when a job is queued.
+	JobAdded JobEventType = "ADDED"
+	// JobProgress is published when the status, progress or details of a
+	// job change.
+	JobProgress JobEventType = "PROGRESS"
+	// JobCompleted is published when a job finishes, fails or is cancelled.
+	JobCompleted JobEventType = "COMPLETED"
+)
+
+type JobEvent struct {
+	Type JobEventType
+	// Job is a copy of the job.
+	Job job.Job
+}
+
+type ScanEvent struct {
+	// Clean is true if the event is for a clean rather than a scan.
+	Clean bool
+	// Changes are the objects changed by the scan or clean.
+	Changes *models.ScanChanges
+}
+
+type GenerateEvent struct {
+	Cancelled bool
+}
+
+type LibraryEvent struct {
+	// Paths are the changed directories.
+	Paths []string
+	// Removed is true if files were removed from the directories, rather
+	// than added or modified.
+	Removed bool
+}
+
+type StashPathEvent struct {
+	// Path is the stash path.
+	Path string
+	// Online is true if the path is reachable.
+	Online bool
+}
+
+// subscriptionBufferSize is the number of events buffered for each
+// subscriber. Events are dropped for subscribers that do not keep up.
+const subscriptionBufferSize = 100
+
+// eventBus broadcasts events to the subscribers of their topic.
+type eventBus struct {
+	subscriptions map[Topic]map[chan Event]struct{}
 	mutex         sync.Mutex
 }
 
-func (m *subscriptionManager) subscribe(ctx context.Context) <-chan bool {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+func newEventBus() *eventBus {
+	return &eventBus{
+		subscriptions: make(map[Topic]map[chan Event]struct{}),
+	}
+}
+
+// Subscribe returns a channel receiving the events published to topic. The
+// channel is closed and the subscription removed when ctx is done.
+func (b *eventBus) Subscribe(ctx context.Context, topic Topic) <-chan Event {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	c := make(chan Event, subscriptionBufferSize)
 
-	c := make(chan bool, 10)
-	m.subscriptions = append(m.subscriptions, c)
+	subs := b.subscriptions[topic]
+	if subs == nil {
+		subs = make(map[chan Event]struct{})
+		b.subscriptions[topic] = subs
+	}
+	subs[c] = struct{}{}
 
 	go func() {
 		<-ctx.Done()
-		m.mutex.Lock()
-		defer m.mutex.Unlock()
-		close(c)
-
-		for i, s := range m.subscriptions {
-			if s == c {
-				m.subscriptions = append(m.subscriptions[:i], m.subscriptions[i+1:]...)
-				break
-			}
-		}
+		b.unsubscribe(topic, c)
 	}()
 
 	return c
 }
 
-func (m *subscriptionManager) notify() {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+func (b *eventBus) unsubscribe(topic Topic, c chan Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	subs := b.subscriptions[topic]
+	delete(subs, c)
+	if len(subs) == 0 {
+		delete(b.subscriptions, topic)
+	}
+
+	// the mutex is held while publishing, so the channel is not sent to
+	// after it is closed
+	close(c)
+}
+
+// Publish sends an event with the payload to the subscribers of topic.
+// Publish does not block if a subscriber is not receiving.
+func (b *eventBus) Publish(topic Topic, payload interface{}) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	e := Event{
+		Topic:   topic,
+		Payload: payload,
+	}
+
+	for c := range b.subscriptions[topic] {
+		// don't block if channel is full
+		select {
+		case c <- e:
+		default:
+		}
+	}
+}
+
+// forwardJobEvents publishes the job changes received by the subscription as
+// events, until the subscription is closed.
+func forwardJobEvents(subscription *job.ManagerSubscription, events *eventBus) {
+	publish := func(t JobEventType, j job.Job) {
+		events.Publish(TopicJob, JobEvent{
+			Type: t,
+			Job:  j,
+		})
+	}
 
-	for _, s := range m.subscriptions {
-		s <- true
+	for {
+		select {
+		case j, ok := <-subscription.NewJob:
+			if !ok {
+				return
+			}
+			publish(JobAdded, j)
+		case j, ok := <-subscription.UpdatedJob:
+			if !ok {
+				return
+			}
+			publish(JobProgress, j)
+		case j, ok := <-subscription.RemovedJob:
+			if !ok {
+				return
+			}
+			publish(JobCompleted, j)
+		}
 	}
 }
@@ -0,0 +1,246 @@
+package manager
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stretchr/testify/assert"
+)
+
+const eventTimeout = 5 * time.Second
+
+func receiveEvent(t *testing.T, c <-chan Event) Event {
+	t.Helper()
+
+	select {
+	case e, ok := <-c:
+		if !ok {
+			t.Fatal("channel closed")
+		}
+		return e
+	case <-time.After(eventTimeout):
+		t.Fatal("timed out waiting for event")
+	}
+
+	return Event{}
+}
+
+func TestEventBusMultipleSubscribers(t *testing.T) {
+	b := newEventBus()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c1 := b.Subscribe(ctx, TopicScan)
+	c2 := b.Subscribe(ctx, TopicScan)
+
+	b.Publish(TopicScan, ScanEvent{})
+	b.Publish(TopicScan, ScanEvent{Clean: true})
+
+	for _, c := range []<-chan Event{c1, c2} {
+		e := receiveEvent(t, c)
+		assert.Equal(t, TopicScan, e.Topic)
+		assert.Equal(t, ScanEvent{}, e.Payload)
+		assert.Equal(t, ScanEvent{Clean: true}, receiveEvent(t, c).Payload)
+	}
+}
+
+func TestEventBusTopicIsolation(t *testing.T) {
+	b := newEventBus()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	scan := b.Subscribe(ctx, TopicScan)
+	config := b.Subscribe(ctx, TopicConfig)
+	library := b.Subscribe(ctx, TopicLibrary)
+
+	b.Publish(TopicConfig, nil)
+	b.Publish(TopicLibrary, LibraryEvent{Paths: []string{"/a"}})
+	// no subscribers
+	b.Publish(TopicGenerate, GenerateEvent{})
+
+	assert.Equal(t, TopicConfig, receiveEvent(t, config).Topic)
+	assert.Equal(t, LibraryEvent{Paths: []string{"/a"}}, receiveEvent(t, library).Payload)
+
+	assert.Len(t, scan, 0)
+	assert.Len(t, config, 0)
+	assert.Len(t, library, 0)
+}
+
+func TestEventBusUnsubscribe(t *testing.T) {
+	b := newEventBus()
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+
+	c1 := b.Subscribe(ctx1, TopicScan)
+	c2 := b.Subscribe(ctx2, TopicScan)
+
+	cancel1()
+
+	select {
+	case _, ok := <-c1:
+		assert.False(t, ok)
+	case <-time.After(eventTimeout):
+		t.Fatal("channel was not closed")
+	}
+
+	b.mutex.Lock()
+	assert.Len(t, b.subscriptions[TopicScan], 1)
+	b.mutex.Unlock()
+
+	// publishing should not panic on the closed subscription, and should
+	// still reach the remaining subscriber
+	b.Publish(TopicScan, ScanEvent{})
+	assert.Equal(t, TopicScan, receiveEvent(t, c2).Topic)
+
+	cancel2()
+	assert.Eventually(t, func() bool {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		return len(b.subscriptions) == 0
+	}, eventTimeout, 10*time.Millisecond)
+}
+
+func TestEventBusSlowSubscriber(t *testing.T) {
+	b := newEventBus()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := b.Subscribe(ctx, TopicScan)
+
+	// publish should not block if the subscriber does not receive
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < subscriptionBufferSize*2; i++ {
+			b.Publish(TopicScan, ScanEvent{})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(eventTimeout):
+		t.Fatal("publish blocked")
+	}
+
+	assert.Len(t, c, subscriptionBufferSize)
+}
+
+func TestEventBusConcurrent(t *testing.T) {
+	const (
+		subscribers = 20
+		publishers  = 10
+		events      = 5
+	)
+
+	b := newEventBus()
+	goroutines := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// subscribe and unsubscribe while publishing
+	var wg sync.WaitGroup
+	for i := 0; i < subscribers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			subCtx, subCancel := context.WithCancel(ctx)
+			c := b.Subscribe(subCtx, TopicScan)
+			if i%2 == 0 {
+				subCancel()
+			} else {
+				defer subCancel()
+			}
+
+			// drain until closed or all events are received
+			received := 0
+			for range c {
+				received++
+				if received == publishers*events {
+					return
+				}
+			}
+		}(i)
+	}
+
+	for i := 0; i < publishers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < events; j++ {
+				b.Publish(TopicScan, ScanEvent{})
+			}
+		}()
+	}
+
+	// subscribers that are still waiting stop when the context is done
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		cancel()
+	}()
+
+	wg.Wait()
+	cancel()
+
+	// all subscriptions should be removed
+	assert.Eventually(t, func() bool {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+		return len(b.subscriptions) == 0
+	}, eventTimeout, 10*time.Millisecond)
+
+	// with no leaked goroutines. Eventually runs the condition in a
+	// goroutine, so the count is polled here.
+	deadline := time.Now().Add(eventTimeout)
+	for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), goroutines)
+}
+
+func TestForwardJobEvents(t *testing.T) {
+	jm := job.NewManager()
+	defer jm.Stop()
+
+	events := newEventBus()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := events.Subscribe(ctx, TopicJob)
+	go forwardJobEvents(jm.Subscribe(ctx), events)
+
+	proceed := make(chan struct{})
+	id := jm.Add(ctx, "test", job.MakeJobExec(func(ctx context.Context, progress *job.Progress) {
+		<-proceed
+		progress.SetPercent(0.5)
+	}))
+
+	close(proceed)
+
+	// events of the job manager are received from separate channels, so
+	// the order of added and progress events is not guaranteed
+	seen := make(map[JobEventType]JobEvent)
+	for {
+		e := receiveEvent(t, c).Payload.(JobEvent)
+		assert.Equal(t, id, e.Job.ID)
+		seen[e.Type] = e
+
+		_, added := seen[JobAdded]
+		_, completed := seen[JobCompleted]
+		if added && completed {
+			break
+		}
+	}
+
+	assert.Equal(t, job.StatusFinished, seen[JobCompleted].Job.Status)
+}
@@ -4,16 +4,18 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
-	"sync"
 
 	"github.com/stashapp/stash/pkg/autotag"
 	"github.com/stashapp/stash/pkg/image"
 	"github.com/stashapp/stash/pkg/job"
 	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/scene"
+	"github.com/stashapp/stash/pkg/utils"
 )
 
 type autoTagJob struct {
@@ -23,6 +25,11 @@ type autoTagJob struct {
 
 func (j *autoTagJob) Execute(ctx context.Context, progress *job.Progress) {
 	input := j.input
+	if input.SavedFilterID != nil && !j.isFileBasedAutoTag(input) {
+		logger.Error("a saved filter can only be used when auto-tagging files")
+		return
+	}
+
 	if j.isFileBasedAutoTag(input) {
 		// doing file-based auto-tag
 		j.autoTagFiles(ctx, progress, input.Paths, len(input.Performers) > 0, len(input.Studios) > 0, len(input.Tags) > 0)
@@ -42,14 +49,36 @@ func (j *autoTagJob) isFileBasedAutoTag(input models.AutoTagMetadataInput) bool
 }
 
 func (j *autoTagJob) autoTagFiles(ctx context.Context, progress *job.Progress, paths []string, performers, studios, tags bool) {
+	cfg := config.GetInstance()
 	t := autoTagFilesTask{
-		paths:      paths,
-		performers: performers,
-		studios:    studios,
-		tags:       tags,
-		ctx:        ctx,
-		progress:   progress,
-		txnManager: j.txnManager,
+		paths:          paths,
+		performers:     performers,
+		studios:        studios,
+		tags:           tags,
+		tagAncestors:   cfg.GetAutoTagApplyTagAncestors(),
+		fuzzyDistance:  cfg.GetAutoTagFuzzyDistance(),
+		matchWorkers:   cfg.GetAutoTagMatchWorkersWithAutoDetection(),
+		writeBatchSize: cfg.GetAutoTagWriteBatchSize(),
+		checkpointKey:  autoTagCheckpointKey(paths, performers, studios, tags),
+		ctx:            ctx,
+		progress:       progress,
+		txnManager:     j.txnManager,
+	}
+
+	if j.input.SavedFilterID != nil {
+		savedFilterID, err := strconv.Atoi(*j.input.SavedFilterID)
+		if err != nil {
+			logger.Errorf("invalid saved filter id %s: %v", *j.input.SavedFilterID, err)
+			return
+		}
+
+		t.paths = nil
+		t.savedFilterID = &savedFilterID
+		t.checkpointKey += "\nsaved filter:" + strconv.Itoa(savedFilterID)
+	}
+
+	if !utils.IsTrue(j.input.ForceRestart) {
+		t.resumeFrom = t.getCheckpoint()
 	}
 
 	t.process()
@@ -253,6 +282,8 @@ func (j *autoTagJob) autoTagTags(ctx context.Context, progress *job.Progress, pa
 		return
 	}
 
+	applyAncestors := config.GetInstance().GetAutoTagApplyTagAncestors()
+
 	for _, tagId := range tagIds {
 		var tags []*models.Tag
 		if err := j.txnManager.WithReadTxn(context.TODO(), func(r models.ReaderRepository) error {
@@ -283,18 +314,26 @@ func (j *autoTagJob) autoTagTags(ctx context.Context, progress *job.Progress, pa
 				}
 
 				if err := j.txnManager.WithTxn(context.TODO(), func(r models.Repository) error {
-					aliases, err := r.Tag().GetAliases(tag.ID)
+					aliases, err := autotag.TagAliases(tag, r.Tag())
 					if err != nil {
 						return err
 					}
 
-					if err := autotag.TagScenes(tag, paths, aliases, r.Scene()); err != nil {
+					var ancestors []*models.Tag
+					if applyAncestors {
+						ancestors, err = autotag.TagAncestors(tag.ID, r.Tag())
+						if err != nil {
+							return err
+						}
+					}
+
+					if err := autotag.TagScenes(tag, paths, aliases, ancestors, r.Scene()); err != nil {
 						return err
 					}
-					if err := autotag.TagImages(tag, paths, aliases, r.Image()); err != nil {
+					if err := autotag.TagImages(tag, paths, aliases, ancestors, r.Image()); err != nil {
 						return err
 					}
-					if err := autotag.TagGalleries(tag, paths, aliases, r.Gallery()); err != nil {
+					if err := autotag.TagGalleries(tag, paths, aliases, ancestors, r.Gallery()); err != nil {
 						return err
 					}
 
@@ -314,14 +353,60 @@ func (j *autoTagJob) autoTagTags(ctx context.Context, progress *job.Progress, pa
 	}
 }
 
+// autoTagProgress is the progress of an auto-tag of files.
+type autoTagProgress interface {
+	SetTotal(total int)
+	AddProcessed(v int)
+	Increment()
+	ExecuteTask(description string, fn func())
+}
+
+// autoTagCount is the number of files of a type processed and tagged by an
+// auto-tag.
+type autoTagCount struct {
+	processed int
+	tagged    int
+}
+
 type autoTagFilesTask struct {
-	paths      []string
+	paths []string
+	// savedFilterID is the id of the saved filter selecting the files to
+	// auto-tag instead of paths, or nil to select files by path
+	savedFilterID *int
+	// targetType and targetIDs are the type and the sorted ids of the files
+	// selected by the saved filter, resolved when the task is run
+	targetType int
+	targetIDs  []int
+
 	performers bool
 	studios    bool
 	tags       bool
+	// tagAncestors is whether the ancestors of matched tags are also applied
+	tagAncestors bool
+	// fuzzyDistance is the maximum edit distance of fuzzy matches
+	fuzzyDistance int
+
+	// matchWorkers is the number of files matched concurrently
+	matchWorkers int
+	// writeBatchSize is the maximum number of files written in a single
+	// transaction
+	writeBatchSize int
+
+	// checkpointKey is the key of the checkpoint of the task
+	checkpointKey string
+	// resumeFrom is the checkpoint of an interrupted auto-tag. Files up to
+	// and including the checkpoint are skipped.
+	resumeFrom *autoTagCheckpoint
+	// position is the last file for which it and all files before it have
+	// been processed
+	position  *autoTagCheckpoint
+	sinceSave int
+
+	counts  [len(autoTagFileTypes)]autoTagCount
+	skipped int
 
 	ctx        context.Context
-	progress   *job.Progress
+	progress   autoTagProgress
 	txnManager models.TransactionManager
 }
 
@@ -401,7 +486,42 @@ func (t *autoTagFilesTask) makeGalleryFilter() *models.GalleryFilterType {
 	return ret
 }
 
-func (t *autoTagFilesTask) getCount(r models.ReaderRepository) (int, error) {
+// resolveSavedFilter resolves the saved filter of the task into the files
+// to auto-tag.
+func (t *autoTagFilesTask) resolveSavedFilter(r models.ReaderRepository) error {
+	target, err := loadSavedFilterTarget(r, *t.savedFilterID)
+	if err != nil {
+		return err
+	}
+
+	switch target.mode {
+	case models.FilterModeScenes:
+		t.targetType = autoTagScenes
+	case models.FilterModeImages:
+		t.targetType = autoTagImages
+	case models.FilterModeGalleries:
+		t.targetType = autoTagGalleries
+	default:
+		return fmt.Errorf("cannot auto-tag saved filter of %s", target.mode)
+	}
+
+	t.targetIDs, err = target.ids(r)
+	if err != nil {
+		return fmt.Errorf("querying saved filter %d: %w", *t.savedFilterID, err)
+	}
+	sort.Ints(t.targetIDs)
+
+	return nil
+}
+
+// getCounts returns the number of files of each type to auto-tag.
+func (t *autoTagFilesTask) getCounts(r models.ReaderRepository) ([]int, error) {
+	if t.savedFilterID != nil {
+		ret := make([]int, len(autoTagFileTypes))
+		ret[t.targetType] = len(t.targetIDs)
+		return ret, nil
+	}
+
 	pp := 0
 	findFilter := &models.FindFilterType{
 		PerPage: &pp,
@@ -415,7 +535,7 @@ func (t *autoTagFilesTask) getCount(r models.ReaderRepository) (int, error) {
 		SceneFilter: t.makeSceneFilter(),
 	})
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	sceneCount := sceneResults.Count
@@ -428,105 +548,213 @@ func (t *autoTagFilesTask) getCount(r models.ReaderRepository) (int, error) {
 		ImageFilter: t.makeImageFilter(),
 	})
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
 	imageCount := imageResults.Count
 
 	_, galleryCount, err := r.Gallery().Query(t.makeGalleryFilter(), findFilter)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	return sceneCount + imageCount + galleryCount, nil
+	return []int{sceneCount, imageCount, galleryCount}, nil
 }
 
-func (t *autoTagFilesTask) processScenes(r models.ReaderRepository) error {
-	if job.IsCancelled(t.ctx) {
+// run auto-tags the targets, which are files of the provided type with the
+// provided ids.
+func (t *autoTagFilesTask) run(fileType int, ids []int, targets []autotag.Target) {
+	p := autotag.Pipeline{
+		TxnManager: t.txnManager,
+		Matcher: autotag.Matcher{
+			Performers:    t.performers,
+			Studios:       t.studios,
+			Tags:          t.tags,
+			TagAncestors:  t.tagAncestors,
+			FuzzyDistance: t.fuzzyDistance,
+		},
+		Workers:   t.matchWorkers,
+		BatchSize: t.writeBatchSize,
+	}
+
+	// the pipeline processes the targets in order
+	i := 0
+	p.Run(t.ctx, targets, func(tagged bool) {
+		t.done(fileType, ids[i], tagged)
+		i++
+	})
+}
+
+// done records that the file has been processed, saving a checkpoint every
+// autoTagCheckpointInterval files.
+func (t *autoTagFilesTask) done(fileType int, id int, tagged bool) {
+	t.counts[fileType].processed++
+	if tagged {
+		t.counts[fileType].tagged++
+	}
+
+	t.position = &autoTagCheckpoint{fileType: fileType, lastID: id}
+	t.progress.Increment()
+
+	t.sinceSave++
+	if t.sinceSave >= autoTagCheckpointInterval {
+		t.saveCheckpoint(*t.position)
+		t.sinceSave = 0
+	}
+}
+
+// skip returns true if the file was processed by the interrupted auto-tag
+// being resumed.
+func (t *autoTagFilesTask) skip(fileType int, id int) bool {
+	if !t.resumeFrom.includes(fileType, id) {
+		return false
+	}
+
+	t.skipped++
+	t.progress.Increment()
+	return true
+}
+
+// processTargets auto-tags the files selected by the saved filter, if they
+// are of the provided type. find returns the targets of the files with the
+// provided ids that are not organized, with their ids.
+func (t *autoTagFilesTask) processTargets(fileType int, find func(ids []int) ([]int, []autotag.Target, error)) error {
+	const batchSize = 1000
+
+	if fileType != t.targetType {
 		return nil
 	}
 
-	batchSize := 1000
+	for start := 0; start < len(t.targetIDs); start += batchSize {
+		if job.IsCancelled(t.ctx) {
+			return nil
+		}
 
-	findFilter := models.BatchFindFilter(batchSize)
-	sceneFilter := t.makeSceneFilter()
+		end := start + batchSize
+		if end > len(t.targetIDs) {
+			end = len(t.targetIDs)
+		}
 
-	more := true
-	for more {
-		scenes, err := scene.Query(r.Scene(), sceneFilter, findFilter)
+		var batch []int
+		for _, id := range t.targetIDs[start:end] {
+			if !t.skip(fileType, id) {
+				batch = append(batch, id)
+			}
+		}
+		if len(batch) == 0 {
+			continue
+		}
+
+		ids, targets, err := find(batch)
 		if err != nil {
 			return err
 		}
 
-		for _, ss := range scenes {
-			if job.IsCancelled(t.ctx) {
-				return nil
-			}
+		// organized files are not auto-tagged
+		t.progress.AddProcessed(len(batch) - len(ids))
+
+		t.run(fileType, ids, targets)
+	}
 
-			tt := autoTagSceneTask{
-				txnManager: t.txnManager,
-				scene:      ss,
-				performers: t.performers,
-				studios:    t.studios,
-				tags:       t.tags,
+	return nil
+}
+
+func (t *autoTagFilesTask) processScenes(r models.ReaderRepository) error {
+	const batchSize = 1000
+
+	if t.savedFilterID != nil {
+		return t.processTargets(autoTagScenes, func(ids []int) ([]int, []autotag.Target, error) {
+			scenes, err := r.Scene().FindMany(ids)
+			if err != nil {
+				return nil, nil, err
 			}
 
-			var wg sync.WaitGroup
-			wg.Add(1)
-			go tt.Start(&wg)
-			wg.Wait()
+			var retIDs []int
+			var ret []autotag.Target
+			for _, s := range scenes {
+				if !s.Organized {
+					retIDs = append(retIDs, s.ID)
+					ret = append(ret, autotag.SceneTarget(s))
+				}
+			}
+			return retIDs, ret, nil
+		})
+	}
 
-			t.progress.Increment()
+	var ids []int
+	var targets []autotag.Target
+	if err := scene.ForEach(t.ctx, r.Scene(), t.makeSceneFilter(), models.BatchFindFilter(batchSize), func(ss *models.Scene) error {
+		if t.skip(autoTagScenes, ss.ID) {
+			return nil
 		}
 
-		if len(scenes) != batchSize {
-			more = false
-		} else {
-			*findFilter.Page++
+		ids = append(ids, ss.ID)
+		targets = append(targets, autotag.SceneTarget(ss))
+		if len(targets) == batchSize {
+			t.run(autoTagScenes, ids, targets)
+			ids = nil
+			targets = nil
 		}
+
+		return nil
+	}); err != nil {
+		return err
 	}
 
+	t.run(autoTagScenes, ids, targets)
+
 	return nil
 }
 
 func (t *autoTagFilesTask) processImages(r models.ReaderRepository) error {
-	if job.IsCancelled(t.ctx) {
-		return nil
-	}
-
 	batchSize := 1000
 
+	if t.savedFilterID != nil {
+		return t.processTargets(autoTagImages, func(ids []int) ([]int, []autotag.Target, error) {
+			images, err := r.Image().FindMany(ids)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			var retIDs []int
+			var ret []autotag.Target
+			for _, i := range images {
+				if !i.Organized {
+					retIDs = append(retIDs, i.ID)
+					ret = append(ret, autotag.ImageTarget(i))
+				}
+			}
+			return retIDs, ret, nil
+		})
+	}
+
+	sort := "id"
 	findFilter := models.BatchFindFilter(batchSize)
+	findFilter.Sort = &sort
 	imageFilter := t.makeImageFilter()
 
 	more := true
 	for more {
+		if job.IsCancelled(t.ctx) {
+			return nil
+		}
+
 		images, err := image.Query(r.Image(), imageFilter, findFilter)
 		if err != nil {
 			return err
 		}
 
+		var ids []int
+		var targets []autotag.Target
 		for _, ss := range images {
-			if job.IsCancelled(t.ctx) {
-				return nil
-			}
-
-			tt := autoTagImageTask{
-				txnManager: t.txnManager,
-				image:      ss,
-				performers: t.performers,
-				studios:    t.studios,
-				tags:       t.tags,
+			if !t.skip(autoTagImages, ss.ID) {
+				ids = append(ids, ss.ID)
+				targets = append(targets, autotag.ImageTarget(ss))
 			}
-
-			var wg sync.WaitGroup
-			wg.Add(1)
-			go tt.Start(&wg)
-			wg.Wait()
-
-			t.progress.Increment()
 		}
 
+		t.run(autoTagImages, ids, targets)
+
 		if len(images) != batchSize {
 			more = false
 		} else {
@@ -538,43 +766,54 @@ func (t *autoTagFilesTask) processImages(r models.ReaderRepository) error {
 }
 
 func (t *autoTagFilesTask) processGalleries(r models.ReaderRepository) error {
-	if job.IsCancelled(t.ctx) {
-		return nil
-	}
-
 	batchSize := 1000
 
+	if t.savedFilterID != nil {
+		return t.processTargets(autoTagGalleries, func(ids []int) ([]int, []autotag.Target, error) {
+			galleries, err := r.Gallery().FindMany(ids)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			var retIDs []int
+			var ret []autotag.Target
+			for _, g := range galleries {
+				if !g.Organized {
+					retIDs = append(retIDs, g.ID)
+					ret = append(ret, autotag.GalleryTarget(g))
+				}
+			}
+			return retIDs, ret, nil
+		})
+	}
+
+	sort := "id"
 	findFilter := models.BatchFindFilter(batchSize)
+	findFilter.Sort = &sort
 	galleryFilter := t.makeGalleryFilter()
 
 	more := true
 	for more {
+		if job.IsCancelled(t.ctx) {
+			return nil
+		}
+
 		galleries, _, err := r.Gallery().Query(galleryFilter, findFilter)
 		if err != nil {
 			return err
 		}
 
+		var ids []int
+		var targets []autotag.Target
 		for _, ss := range galleries {
-			if job.IsCancelled(t.ctx) {
-				return nil
+			if !t.skip(autoTagGalleries, ss.ID) {
+				ids = append(ids, ss.ID)
+				targets = append(targets, autotag.GalleryTarget(ss))
 			}
-
-			tt := autoTagGalleryTask{
-				txnManager: t.txnManager,
-				gallery:    ss,
-				performers: t.performers,
-				studios:    t.studios,
-				tags:       t.tags,
-			}
-
-			var wg sync.WaitGroup
-			wg.Add(1)
-			go tt.Start(&wg)
-			wg.Wait()
-
-			t.progress.Increment()
 		}
 
+		t.run(autoTagGalleries, ids, targets)
+
 		if len(galleries) != batchSize {
 			more = false
 		} else {
@@ -586,138 +825,82 @@ func (t *autoTagFilesTask) processGalleries(r models.ReaderRepository) error {
 }
 
 func (t *autoTagFilesTask) process() {
-	if err := t.txnManager.WithReadTxn(context.TODO(), func(r models.ReaderRepository) error {
-		total, err := t.getCount(r)
-		if err != nil {
-			return err
-		}
-
-		t.progress.SetTotal(total)
-
-		logger.Infof("Starting autotag of %d files", total)
+	processFns := []func(r models.ReaderRepository) error{
+		t.processScenes,
+		t.processImages,
+		t.processGalleries,
+	}
 
-		if err := t.processScenes(r); err != nil {
-			return err
-		}
+	t.position = t.resumeFrom
+	if t.resumeFrom != nil {
+		logger.Infof("Resuming autotag after %s", t.resumeFrom)
+	}
 
-		if err := t.processImages(r); err != nil {
-			return err
+	err := t.txnManager.WithReadTxn(context.TODO(), func(r models.ReaderRepository) error {
+		if t.savedFilterID != nil {
+			if err := t.resolveSavedFilter(r); err != nil {
+				return err
+			}
 		}
 
-		if err := t.processGalleries(r); err != nil {
+		counts, err := t.getCounts(r)
+		if err != nil {
 			return err
 		}
 
-		if job.IsCancelled(t.ctx) {
-			logger.Info("Stopping due to user request")
+		total := 0
+		for _, c := range counts {
+			total += c
 		}
+		t.progress.SetTotal(total)
 
-		return nil
-	}); err != nil {
-		logger.Error(err.Error())
-	}
-
-	logger.Info("Finished autotag")
-}
-
-type autoTagSceneTask struct {
-	txnManager models.TransactionManager
-	scene      *models.Scene
-
-	performers bool
-	studios    bool
-	tags       bool
-}
+		logger.Infof("Starting autotag of %d files", total)
 
-func (t *autoTagSceneTask) Start(wg *sync.WaitGroup) {
-	defer wg.Done()
-	if err := t.txnManager.WithTxn(context.TODO(), func(r models.Repository) error {
-		if t.performers {
-			if err := autotag.ScenePerformers(t.scene, r.Scene(), r.Performer()); err != nil {
-				return fmt.Errorf("error tagging scene performers for %s: %v", t.scene.Path, err)
-			}
-		}
-		if t.studios {
-			if err := autotag.SceneStudios(t.scene, r.Scene(), r.Studio()); err != nil {
-				return fmt.Errorf("error tagging scene studio for %s: %v", t.scene.Path, err)
-			}
-		}
-		if t.tags {
-			if err := autotag.SceneTags(t.scene, r.Scene(), r.Tag()); err != nil {
-				return fmt.Errorf("error tagging scene tags for %s: %v", t.scene.Path, err)
+		for fileType, fn := range processFns {
+			if job.IsCancelled(t.ctx) {
+				return nil
 			}
-		}
 
-		return nil
-	}); err != nil {
-		logger.Error(err.Error())
-	}
-}
-
-type autoTagImageTask struct {
-	txnManager models.TransactionManager
-	image      *models.Image
-
-	performers bool
-	studios    bool
-	tags       bool
-}
-
-func (t *autoTagImageTask) Start(wg *sync.WaitGroup) {
-	defer wg.Done()
-	if err := t.txnManager.WithTxn(context.TODO(), func(r models.Repository) error {
-		if t.performers {
-			if err := autotag.ImagePerformers(t.image, r.Image(), r.Performer()); err != nil {
-				return fmt.Errorf("error tagging image performers for %s: %v", t.image.Path, err)
-			}
-		}
-		if t.studios {
-			if err := autotag.ImageStudios(t.image, r.Image(), r.Studio()); err != nil {
-				return fmt.Errorf("error tagging image studio for %s: %v", t.image.Path, err)
+			// skip types completed by the interrupted auto-tag
+			if t.resumeFrom != nil && fileType < t.resumeFrom.fileType {
+				t.skipped += counts[fileType]
+				t.progress.AddProcessed(counts[fileType])
+				continue
 			}
-		}
-		if t.tags {
-			if err := autotag.ImageTags(t.image, r.Image(), r.Tag()); err != nil {
-				return fmt.Errorf("error tagging image tags for %s: %v", t.image.Path, err)
+
+			t.progress.ExecuteTask("Auto-tagging "+autoTagFileTypes[fileType], func() {
+				err = fn(r)
+			})
+			if err != nil {
+				return err
 			}
 		}
 
 		return nil
-	}); err != nil {
+	})
+	if err != nil {
 		logger.Error(err.Error())
 	}
-}
 
-type autoTagGalleryTask struct {
-	txnManager models.TransactionManager
-	gallery    *models.Gallery
+	cancelled := job.IsCancelled(t.ctx)
+	if cancelled {
+		logger.Info("Stopping due to user request")
+	}
 
-	performers bool
-	studios    bool
-	tags       bool
-}
+	// keep the position of an interrupted auto-tag, so that it can be resumed
+	if err == nil && !cancelled {
+		t.clearCheckpoint()
+	} else if t.position != nil {
+		t.saveCheckpoint(*t.position)
+	}
 
-func (t *autoTagGalleryTask) Start(wg *sync.WaitGroup) {
-	defer wg.Done()
-	if err := t.txnManager.WithTxn(context.TODO(), func(r models.Repository) error {
-		if t.performers {
-			if err := autotag.GalleryPerformers(t.gallery, r.Gallery(), r.Performer()); err != nil {
-				return fmt.Errorf("error tagging gallery performers for %s: %v", t.gallery.Path.String, err)
-			}
-		}
-		if t.studios {
-			if err := autotag.GalleryStudios(t.gallery, r.Gallery(), r.Studio()); err != nil {
-				return fmt.Errorf("error tagging gallery studio for %s: %v", t.gallery.Path.String, err)
-			}
-		}
-		if t.tags {
-			if err := autotag.GalleryTags(t.gallery, r.Gallery(), r.Tag()); err != nil {
-				return fmt.Errorf("error tagging gallery tags for %s: %v", t.gallery.Path.String, err)
-			}
-		}
+	if t.skipped > 0 {
+		logger.Infof("Skipped %d files processed by the interrupted autotag", t.skipped)
+	}
 
-		return nil
-	}); err != nil {
-		logger.Error(err.Error())
+	var summary []string
+	for fileType, c := range t.counts {
+		summary = append(summary, fmt.Sprintf("%d of %d %s", c.tagged, c.processed, autoTagFileTypes[fileType]))
 	}
+	logger.Infof("Finished autotag: tagged %s", strings.Join(summary, ", "))
 }
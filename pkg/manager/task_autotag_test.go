@@ -0,0 +1,262 @@
+package manager
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const autoTagTestTagID = 10
+
+type testAutoTagProgress struct {
+	total     int
+	processed int
+	tasks     []string
+
+	// onIncrement is called after each increment
+	onIncrement func(processed int)
+}
+
+func (p *testAutoTagProgress) SetTotal(total int) {
+	p.total = total
+}
+
+func (p *testAutoTagProgress) AddProcessed(v int) {
+	p.processed += v
+}
+
+func (p *testAutoTagProgress) Increment() {
+	p.processed++
+	if p.onIncrement != nil {
+		p.onIncrement(p.processed)
+	}
+}
+
+func (p *testAutoTagProgress) ExecuteTask(description string, fn func()) {
+	p.tasks = append(p.tasks, description)
+	fn()
+}
+
+// newAutoTagTestTxnManager returns a transaction manager with three scenes,
+// two images and a gallery, all of which match the tag.
+func newAutoTagTestTxnManager() *mocks.TransactionManager {
+	m := mocks.NewTransactionManager()
+
+	m.TagMock().On("QueryForAutoTag", mock.Anything).Return([]*models.Tag{
+		{ID: autoTagTestTagID, Name: "tag"},
+	}, nil)
+	m.TagMock().On("GetAliases", mock.Anything).Return([]string{}, nil)
+
+	scenes := []*models.Scene{
+		{ID: 1, Path: "tag.1.mp4"},
+		{ID: 2, Path: "tag.2.mp4"},
+		{ID: 3, Path: "tag.3.mp4"},
+	}
+	sceneQB := m.SceneMock()
+	sceneQB.On("Query", mock.Anything).Return(func(options models.SceneQueryOptions) *models.SceneQueryResult {
+		ret := models.NewSceneQueryResult(sceneQB)
+		ret.Count = len(scenes)
+		if !options.Count {
+			ret.IDs = []int{1, 2, 3}
+		}
+		return ret
+	}, nil)
+	sceneQB.On("FindMany", []int{1, 2, 3}).Return(scenes, nil)
+	sceneQB.On("GetTagIDs", mock.Anything).Return(nil, nil)
+	sceneQB.On("UpdateTags", mock.Anything, mock.Anything).Return(nil)
+
+	images := []*models.Image{
+		{ID: 1, Path: "tag.1.jpg"},
+		{ID: 2, Path: "tag.2.jpg"},
+	}
+	imageQB := m.ImageMock()
+	imageQB.On("Query", mock.Anything).Return(func(options models.ImageQueryOptions) *models.ImageQueryResult {
+		ret := models.NewImageQueryResult(imageQB)
+		ret.Count = len(images)
+		if !options.Count {
+			ret.IDs = []int{1, 2}
+		}
+		return ret
+	}, nil)
+	imageQB.On("FindMany", []int{1, 2}).Return(images, nil)
+	imageQB.On("GetTagIDs", mock.Anything).Return(nil, nil)
+	imageQB.On("UpdateTags", mock.Anything, mock.Anything).Return(nil)
+
+	galleries := []*models.Gallery{
+		{ID: 1, Path: models.NullString("tag.1.zip")},
+	}
+	galleryQB := m.GalleryMock()
+	galleryQB.On("Query", mock.Anything, mock.Anything).Return(galleries, len(galleries), nil)
+	galleryQB.On("GetTagIDs", mock.Anything).Return(nil, nil)
+	galleryQB.On("UpdateTags", mock.Anything, mock.Anything).Return(nil)
+
+	return m
+}
+
+func newAutoTagTestTask(ctx context.Context, m *mocks.TransactionManager, progress autoTagProgress) *autoTagFilesTask {
+	return &autoTagFilesTask{
+		tags:           true,
+		matchWorkers:   1,
+		writeBatchSize: 1,
+		checkpointKey:  autoTagCheckpointKey(nil, false, false, true),
+		ctx:            ctx,
+		progress:       progress,
+		txnManager:     m,
+	}
+}
+
+func taggedIDs(qb *mock.Mock) []int {
+	var ret []int
+	for _, c := range qb.Calls {
+		if c.Method == "UpdateTags" {
+			ret = append(ret, c.Arguments.Int(0))
+		}
+	}
+
+	return ret
+}
+
+func TestAutoTagFilesTaskProgress(t *testing.T) {
+	m := newAutoTagTestTxnManager()
+	m.ScanCheckpointMock().On("DestroyByPaths", "autotag:tags\n").Return(nil).Once()
+
+	progress := &testAutoTagProgress{}
+	task := newAutoTagTestTask(context.Background(), m, progress)
+	task.process()
+
+	assert.Equal(t, 6, progress.total)
+	assert.Equal(t, 6, progress.processed)
+	assert.Equal(t, []string{"Auto-tagging scenes", "Auto-tagging images", "Auto-tagging galleries"}, progress.tasks)
+	assert.Equal(t, [3]autoTagCount{{3, 3}, {2, 2}, {1, 1}}, task.counts)
+
+	// the checkpoint is cleared when all files are processed
+	m.ScanCheckpointMock().AssertExpectations(t)
+	m.ScanCheckpointMock().AssertNotCalled(t, "Save", mock.Anything)
+}
+
+func TestAutoTagFilesTaskCancel(t *testing.T) {
+	m := newAutoTagTestTxnManager()
+	m.ScanCheckpointMock().On("Save", mock.Anything).Return(nil, nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	progress := &testAutoTagProgress{
+		onIncrement: func(processed int) {
+			if processed == 2 {
+				cancel()
+			}
+		},
+	}
+	task := newAutoTagTestTask(ctx, m, progress)
+	task.process()
+
+	assert.Equal(t, 2, progress.processed)
+	assert.Equal(t, []int{1, 2}, taggedIDs(&m.SceneMock().Mock))
+	assert.Empty(t, taggedIDs(&m.ImageMock().Mock))
+	assert.Empty(t, taggedIDs(&m.GalleryMock().Mock))
+
+	// the position is saved so that the auto-tag can be resumed
+	m.ScanCheckpointMock().AssertCalled(t, "Save", mock.MatchedBy(func(c models.ScanCheckpoint) bool {
+		return c.Paths == "autotag:tags\n" && c.LastPath == "scenes:2"
+	}))
+	m.ScanCheckpointMock().AssertNotCalled(t, "DestroyByPaths", mock.Anything)
+}
+
+func TestAutoTagFilesTaskResume(t *testing.T) {
+	m := newAutoTagTestTxnManager()
+	m.ScanCheckpointMock().On("FindByPaths", "autotag:tags\n").Return(&models.ScanCheckpoint{
+		LastPath: "scenes:2",
+	}, nil).Once()
+	m.ScanCheckpointMock().On("DestroyByPaths", "autotag:tags\n").Return(nil).Once()
+
+	progress := &testAutoTagProgress{}
+	task := newAutoTagTestTask(context.Background(), m, progress)
+	task.resumeFrom = task.getCheckpoint()
+	task.process()
+
+	// skipped files are included in the progress
+	assert.Equal(t, 6, progress.processed)
+	assert.Equal(t, 2, task.skipped)
+	assert.Equal(t, []int{3}, taggedIDs(&m.SceneMock().Mock))
+	assert.Equal(t, []int{1, 2}, taggedIDs(&m.ImageMock().Mock))
+	assert.Equal(t, []int{1}, taggedIDs(&m.GalleryMock().Mock))
+
+	m.ScanCheckpointMock().AssertExpectations(t)
+}
+
+func TestAutoTagFilesTaskSavedFilter(t *testing.T) {
+	const savedFilterID = 1
+
+	m := newAutoTagTestTxnManager()
+	m.SavedFilterMock().On("Find", savedFilterID).Return(&models.SavedFilter{
+		ID:     savedFilterID,
+		Mode:   models.FilterModeImages,
+		Filter: `{"c": [{"type": "path", "value": "tag", "modifier": "INCLUDES"}]}`,
+	}, nil)
+	m.ScanCheckpointMock().On("DestroyByPaths", mock.Anything).Return(nil).Once()
+
+	progress := &testAutoTagProgress{}
+	task := newAutoTagTestTask(context.Background(), m, progress)
+	id := savedFilterID
+	task.savedFilterID = &id
+	task.process()
+
+	// only the images selected by the saved filter are tagged
+	assert.Equal(t, 2, progress.total)
+	assert.Equal(t, 2, progress.processed)
+	assert.Empty(t, taggedIDs(&m.SceneMock().Mock))
+	assert.Equal(t, []int{1, 2}, taggedIDs(&m.ImageMock().Mock))
+	assert.Empty(t, taggedIDs(&m.GalleryMock().Mock))
+}
+
+func TestAutoTagFilesTaskDeletedSavedFilter(t *testing.T) {
+	const savedFilterID = 2
+
+	m := newAutoTagTestTxnManager()
+	m.SavedFilterMock().On("Find", savedFilterID).Return(nil, nil)
+
+	progress := &testAutoTagProgress{}
+	task := newAutoTagTestTask(context.Background(), m, progress)
+	id := savedFilterID
+	task.savedFilterID = &id
+	task.process()
+
+	// nothing is tagged, rather than every file
+	assert.Equal(t, 0, progress.processed)
+	assert.Empty(t, taggedIDs(&m.SceneMock().Mock))
+	assert.Empty(t, taggedIDs(&m.ImageMock().Mock))
+	assert.Empty(t, taggedIDs(&m.GalleryMock().Mock))
+	m.ScanCheckpointMock().AssertNotCalled(t, "DestroyByPaths", mock.Anything)
+}
+
+func TestParseAutoTagCheckpoint(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    *autoTagCheckpoint
+		wantErr bool
+	}{
+		{"scenes:12", &autoTagCheckpoint{autoTagScenes, 12}, false},
+		{"galleries:3", &autoTagCheckpoint{autoTagGalleries, 3}, false},
+		{"movies:3", nil, true},
+		{"images:x", nil, true},
+		{"images", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := parseAutoTagCheckpoint(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseAutoTagCheckpoint() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+			if got != nil {
+				assert.Equal(t, tt.s, got.String())
+			}
+		})
+	}
+}
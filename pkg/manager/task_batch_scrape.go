@@ -0,0 +1,223 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/pkg/identify"
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// batchScrapeDefaultDelay is the default time waited between scrapes, to
+// avoid exceeding the rate limits of scraped sites.
+const batchScrapeDefaultDelay = time.Second
+
+// BatchScrapeJob scrapes a set of scenes using a scraper, and updates the
+// scenes with the scraped metadata.
+type BatchScrapeJob struct {
+	txnManager models.TransactionManager
+	identifier *identify.SceneIdentifier
+	input      models.BatchScrapeInput
+	delay      time.Duration
+}
+
+// CreateBatchScrapeJob returns a job that scrapes the scenes in the input.
+// Scenes are updated using the merge policy in the input, or MERGE if not
+// set, which only sets empty fields.
+func CreateBatchScrapeJob(input models.BatchScrapeInput) (*BatchScrapeJob, error) {
+	s := instance.ScraperCache.GetScraper(input.ScraperID)
+	if s == nil {
+		return nil, fmt.Errorf("%w: scraper with id %q", models.ErrNotFound, input.ScraperID)
+	}
+	if s.Scene == nil {
+		return nil, fmt.Errorf("%w: scraper %q cannot scrape scenes", ErrInput, input.ScraperID)
+	}
+
+	if _, err := utils.StringSliceToIntSlice(input.SceneIDs); err != nil {
+		return nil, fmt.Errorf("%w: invalid scene IDs: %v", ErrInput, err)
+	}
+
+	mergePolicy := models.IdentifyFieldStrategyMerge
+	if input.MergePolicy != nil && input.MergePolicy.IsValid() {
+		mergePolicy = *input.MergePolicy
+	}
+
+	delay := batchScrapeDefaultDelay
+	if input.Delay != nil {
+		if *input.Delay < 0 {
+			return nil, fmt.Errorf("%w: delay must not be negative", ErrInput)
+		}
+		delay = time.Duration(*input.Delay) * time.Second
+	}
+
+	return &BatchScrapeJob{
+		txnManager: instance.TxnManager,
+		identifier: &identify.SceneIdentifier{
+			DefaultOptions: mergePolicyOptions(mergePolicy),
+			Sources: []identify.ScraperSource{
+				{
+					Name: s.Name,
+					Scraper: scraperSource{
+						cache:     instance.ScraperCache,
+						scraperID: input.ScraperID,
+					},
+				},
+			},
+			ScreenshotSetter: &scene.PathsScreenshotSetter{
+				Paths:               instance.Paths,
+				FileNamingAlgorithm: instance.Config.GetVideoFileNamingAlgorithm(),
+			},
+			SceneUpdatePostHookExecutor: instance.PluginCache,
+		},
+		input: input,
+		delay: delay,
+	}, nil
+}
+
+func (j *BatchScrapeJob) Execute(ctx context.Context, progress *job.Progress) {
+	scenes, err := j.findScenes(ctx)
+	if err != nil {
+		logger.Errorf("Error finding scenes to scrape: %v", err)
+		return
+	}
+
+	progress.SetTotal(len(scenes))
+	summary := j.scrapeScenes(ctx, scenes, progress.Increment)
+
+	if job.IsCancelled(ctx) {
+		logger.Infof("Batch scrape stopped: %v", summary)
+		return
+	}
+
+	logger.Infof("Batch scrape finished: %v", summary)
+	if err := summary.err(); err != nil {
+		logger.Errorf("Batch scrape errors: %v", err)
+	}
+}
+
+// findScenes returns the scenes with the IDs in the input if set, otherwise
+// the scenes matching the scene filter. Scenes are found before scraping so
+// that updates do not change the scenes matching the filter.
+func (j *BatchScrapeJob) findScenes(ctx context.Context) ([]*models.Scene, error) {
+	var ret []*models.Scene
+	if err := j.txnManager.WithReadTxn(ctx, func(r models.ReaderRepository) error {
+		qb := r.Scene()
+
+		if len(j.input.SceneIDs) > 0 {
+			ids, err := utils.StringSliceToIntSlice(j.input.SceneIDs)
+			if err != nil {
+				return fmt.Errorf("invalid scene IDs: %w", err)
+			}
+
+			for _, id := range ids {
+				s, err := qb.Find(id)
+				if err != nil {
+					return fmt.Errorf("error finding scene with id %d: %w", id, err)
+				}
+				if s == nil {
+					return fmt.Errorf("%w: scene with id %d", models.ErrNotFound, id)
+				}
+				ret = append(ret, s)
+			}
+
+			return nil
+		}
+
+		sort := "path"
+		findFilter := &models.FindFilterType{
+			Sort: &sort,
+		}
+
+		return scene.ForEach(ctx, qb, j.input.SceneFilter, findFilter, func(s *models.Scene) error {
+			ret = append(ret, s)
+			return nil
+		})
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// scrapeScenes scrapes and updates the scenes in order, waiting for the
+// delay between scrapes. done is called after each scene.
+func (j *BatchScrapeJob) scrapeScenes(ctx context.Context, scenes []*models.Scene, done func()) batchScrapeSummary {
+	var summary batchScrapeSummary
+
+	for i, s := range scenes {
+		if i > 0 && !waitDelay(ctx, j.delay) {
+			break
+		}
+		if job.IsCancelled(ctx) {
+			break
+		}
+
+		updated, err := j.identifier.Identify(ctx, j.txnManager, s)
+		if err != nil {
+			logger.Errorf("Error scraping %s: %v", s.Path, err)
+		}
+		summary.add(s.Path, updated, err)
+		done()
+	}
+
+	return summary
+}
+
+// waitDelay waits for the duration, returning false if the context is done
+// first.
+func waitDelay(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// batchScrapeSummary counts the results of a batch scrape. Scenes are
+// skipped if no result was found or the result did not change the scene.
+type batchScrapeSummary struct {
+	updated int
+	skipped int
+	failed  int
+	errors  []string
+}
+
+func (s *batchScrapeSummary) add(path string, updated bool, err error) {
+	switch {
+	case err != nil:
+		s.failed++
+		s.errors = append(s.errors, fmt.Sprintf("%s: %v", path, err))
+	case updated:
+		s.updated++
+	default:
+		s.skipped++
+	}
+}
+
+func (s batchScrapeSummary) String() string {
+	return fmt.Sprintf("%d updated, %d skipped, %d failed", s.updated, s.skipped, s.failed)
+}
+
+// err returns an error listing the errors of the failed scenes, or nil if
+// no scenes failed.
+func (s batchScrapeSummary) err() error {
+	if len(s.errors) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("%d scenes failed: %s", len(s.errors), strings.Join(s.errors, "; "))
+}
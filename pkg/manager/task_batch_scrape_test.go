@@ -0,0 +1,153 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/identify"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockSceneScraper map[int]*models.ScrapedScene
+
+var errMockScrape = errors.New("scrape error")
+
+func (s mockSceneScraper) ScrapeScene(ctx context.Context, sceneID int) (*models.ScrapedScene, error) {
+	ret, found := s[sceneID]
+	if found && ret == nil {
+		return nil, errMockScrape
+	}
+	return ret, nil
+}
+
+type mockHookExecutor struct{}
+
+func (mockHookExecutor) ExecuteSceneUpdatePostHooks(ctx context.Context, input models.SceneUpdateInput, inputFields []string) {
+}
+
+func newTestBatchScrapeJob(repo models.TransactionManager, scraper identify.SceneScraper, mergePolicy models.IdentifyFieldStrategy) *BatchScrapeJob {
+	return &BatchScrapeJob{
+		txnManager: repo,
+		identifier: &identify.SceneIdentifier{
+			DefaultOptions: mergePolicyOptions(mergePolicy),
+			Sources: []identify.ScraperSource{
+				{Scraper: scraper},
+			},
+			SceneUpdatePostHookExecutor: mockHookExecutor{},
+		},
+	}
+}
+
+func TestBatchScrapeMergePolicy(t *testing.T) {
+	const sceneID = 1
+	existingTitle := "existing title"
+	scrapedTitle := "scraped title"
+	scrapedDetails := "scraped details"
+
+	tests := []struct {
+		name        string
+		mergePolicy models.IdentifyFieldStrategy
+		wantTitle   bool
+		wantUpdated bool
+	}{
+		{"merge fills empty fields", models.IdentifyFieldStrategyMerge, false, true},
+		{"overwrite replaces existing fields", models.IdentifyFieldStrategyOverwrite, true, true},
+		{"ignore skips", models.IdentifyFieldStrategyIgnore, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := mocks.NewTransactionManager()
+			var partial *models.ScenePartial
+			repo.Scene().(*mocks.SceneReaderWriter).On("Update", mock.AnythingOfType("models.ScenePartial")).Run(func(args mock.Arguments) {
+				p := args.Get(0).(models.ScenePartial)
+				partial = &p
+			}).Return(nil, nil)
+
+			j := newTestBatchScrapeJob(repo, mockSceneScraper{
+				sceneID: {Title: &scrapedTitle, Details: &scrapedDetails},
+			}, tt.mergePolicy)
+
+			scenes := []*models.Scene{
+				{ID: sceneID, Title: models.NullString(existingTitle)},
+			}
+			summary := j.scrapeScenes(context.Background(), scenes, func() {})
+
+			if !tt.wantUpdated {
+				assert.Nil(t, partial)
+				assert.Equal(t, batchScrapeSummary{skipped: 1}, summary)
+				return
+			}
+
+			assert.Equal(t, batchScrapeSummary{updated: 1}, summary)
+			if assert.NotNil(t, partial) {
+				assert.Equal(t, scrapedDetails, partial.Details.String)
+				if tt.wantTitle {
+					assert.Equal(t, scrapedTitle, partial.Title.String)
+				} else {
+					assert.Nil(t, partial.Title)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchScrapeSummary(t *testing.T) {
+	const (
+		updatedID = iota + 1
+		missingID
+		errorID1
+		errorID2
+	)
+	scrapedTitle := "scraped title"
+
+	repo := mocks.NewTransactionManager()
+	repo.Scene().(*mocks.SceneReaderWriter).On("Update", mock.AnythingOfType("models.ScenePartial")).Return(nil, nil)
+
+	j := newTestBatchScrapeJob(repo, mockSceneScraper{
+		updatedID: {Title: &scrapedTitle},
+		errorID1:  nil,
+		errorID2:  nil,
+	}, models.IdentifyFieldStrategyMerge)
+
+	var done int
+	summary := j.scrapeScenes(context.Background(), []*models.Scene{
+		{ID: updatedID, Path: "updated"},
+		{ID: errorID1, Path: "error1"},
+		{ID: missingID, Path: "missing"},
+		{ID: errorID2, Path: "error2"},
+	}, func() { done++ })
+
+	assert.Equal(t, 4, done)
+	assert.Equal(t, 1, summary.updated)
+	assert.Equal(t, 1, summary.skipped)
+	assert.Equal(t, 2, summary.failed)
+	assert.Equal(t, "1 updated, 1 skipped, 2 failed", summary.String())
+
+	err := summary.err()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "error1: ")
+		assert.Contains(t, err.Error(), "error2: ")
+		assert.NotContains(t, err.Error(), "missing")
+	}
+
+	assert.NoError(t, batchScrapeSummary{updated: 1, skipped: 1}.err())
+}
+
+func TestBatchScrapeCancelled(t *testing.T) {
+	repo := mocks.NewTransactionManager()
+	j := newTestBatchScrapeJob(repo, mockSceneScraper{}, models.IdentifyFieldStrategyMerge)
+	j.delay = time.Hour
+
+	// cancel while waiting to scrape the second scene
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	summary := j.scrapeScenes(ctx, []*models.Scene{{ID: 1}, {ID: 2}}, cancel)
+	assert.Equal(t, batchScrapeSummary{skipped: 1}, summary)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"time"
 
 	"github.com/stashapp/stash/pkg/file"
 	"github.com/stashapp/stash/pkg/gallery"
@@ -18,9 +19,14 @@ import (
 )
 
 type cleanJob struct {
-	txnManager models.TransactionManager
-	input      models.CleanMetadataInput
-	scanSubs   *subscriptionManager
+	txnManager  models.TransactionManager
+	input       models.CleanMetadataInput
+	events      *eventBus
+	pathMonitor *StashPathMonitor
+	// missingPolicy is how scenes whose file no longer exists are handled.
+	missingPolicy models.MissingFilePolicy
+	// changes records the objects removed by the clean.
+	changes *scanChanges
 }
 
 func (j *cleanJob) Execute(ctx context.Context, progress *job.Progress) {
@@ -29,6 +35,11 @@ func (j *cleanJob) Execute(ctx context.Context, progress *job.Progress) {
 		logger.Infof("Running in Dry Mode")
 	}
 
+	// files in offline stash paths are not cleaned, so refresh their state
+	j.pathMonitor.Check()
+
+	j.changes = newScanChanges()
+
 	if err := j.txnManager.WithReadTxn(context.TODO(), func(r models.ReaderRepository) error {
 		total, err := j.getCount(r)
 		if err != nil {
@@ -57,12 +68,18 @@ func (j *cleanJob) Execute(ctx context.Context, progress *job.Progress) {
 		return
 	}
 
+	changes := j.changes.model()
+	progress.SetResult(changes)
+
 	if job.IsCancelled(ctx) {
 		logger.Info("Stopping due to user request")
 		return
 	}
 
-	j.scanSubs.notify()
+	j.events.Publish(TopicScan, ScanEvent{
+		Clean:   true,
+		Changes: changes,
+	})
 	logger.Info("Finished Cleaning")
 }
 
@@ -100,6 +117,8 @@ func (j *cleanJob) processScenes(ctx context.Context, progress *job.Progress, qb
 	findFilter.Sort = &sort
 
 	var toDelete []int
+	var toTrash []int
+	var toMark []int
 
 	more := true
 	for more {
@@ -114,9 +133,14 @@ func (j *cleanJob) processScenes(ctx context.Context, progress *job.Progress, qb
 
 		for _, scene := range scenes {
 			progress.ExecuteTask(fmt.Sprintf("Assessing scene %s for clean", scene.Path), func() {
-				if j.shouldCleanScene(scene) {
+				switch j.sceneCleanAction(scene) {
+				case cleanDelete:
 					toDelete = append(toDelete, scene.ID)
-				} else {
+				case cleanTrash:
+					toTrash = append(toTrash, scene.ID)
+				case cleanMarkMissing:
+					toMark = append(toMark, scene.ID)
+				default:
 					// increment progress, no further processing
 					progress.Increment()
 				}
@@ -130,14 +154,15 @@ func (j *cleanJob) processScenes(ctx context.Context, progress *job.Progress, qb
 		}
 	}
 
-	if j.input.DryRun && len(toDelete) > 0 {
-		// add progress for scenes that would've been deleted
-		progress.AddProcessed(len(toDelete))
+	if j.input.DryRun {
+		// add progress for scenes that would've been cleaned
+		progress.AddProcessed(len(toDelete) + len(toTrash) + len(toMark))
+		return nil
 	}
 
 	fileNamingAlgorithm := instance.Config.GetVideoFileNamingAlgorithm()
 
-	if !j.input.DryRun && len(toDelete) > 0 {
+	if len(toDelete) > 0 {
 		progress.ExecuteTask(fmt.Sprintf("Cleaning %d scenes", len(toDelete)), func() {
 			for _, sceneID := range toDelete {
 				if job.IsCancelled(ctx) {
@@ -151,6 +176,34 @@ func (j *cleanJob) processScenes(ctx context.Context, progress *job.Progress, qb
 		})
 	}
 
+	if len(toTrash) > 0 {
+		progress.ExecuteTask(fmt.Sprintf("Moving %d scenes with missing files to trash", len(toTrash)), func() {
+			for _, sceneID := range toTrash {
+				if job.IsCancelled(ctx) {
+					return
+				}
+
+				j.trashScene(sceneID)
+
+				progress.Increment()
+			}
+		})
+	}
+
+	if len(toMark) > 0 {
+		progress.ExecuteTask(fmt.Sprintf("Marking %d scenes with missing files", len(toMark)), func() {
+			for _, sceneID := range toMark {
+				if job.IsCancelled(ctx) {
+					return
+				}
+
+				j.markSceneMissing(sceneID)
+
+				progress.Increment()
+			}
+		})
+	}
+
 	return nil
 }
 
@@ -280,6 +333,11 @@ func (j *cleanJob) processImages(ctx context.Context, progress *job.Progress, qb
 }
 
 func (j *cleanJob) shouldClean(path string) bool {
+	if j.pathMonitor.IsOffline(path) {
+		logger.Debugf("Stash path is offline. Not cleaning: \"%s\"", path)
+		return false
+	}
+
 	// use image.FileExists for zip file checking
 	fileExists := image.FileExists(path)
 
@@ -293,6 +351,46 @@ func (j *cleanJob) shouldClean(path string) bool {
 	return false
 }
 
+type cleanAction int
+
+const (
+	cleanKeep cleanAction = iota
+	cleanDelete
+	cleanTrash
+	cleanMarkMissing
+)
+
+// isMissing returns true if the file at path no longer exists. Files in
+// offline stash paths are not missing.
+func (j *cleanJob) isMissing(path string) bool {
+	// use image.FileExists for zip file checking
+	return !j.pathMonitor.IsOffline(path) && !image.FileExists(path)
+}
+
+// sceneCleanAction returns the action to take for the scene. Scenes whose
+// file no longer exists are handled according to the missing file policy.
+func (j *cleanJob) sceneCleanAction(s *models.Scene) cleanAction {
+	if j.isMissing(s.Path) {
+		switch j.missingPolicy {
+		case models.MissingFilePolicyTrash:
+			logger.Infof("File not found. Marking to move to trash: \"%s\"", s.Path)
+			return cleanTrash
+		case models.MissingFilePolicyMark:
+			if s.IsMissing() {
+				return cleanKeep
+			}
+			logger.Infof("File not found. Marking as missing: \"%s\"", s.Path)
+			return cleanMarkMissing
+		}
+	}
+
+	if j.shouldCleanScene(s) {
+		return cleanDelete
+	}
+
+	return cleanKeep
+}
+
 func (j *cleanJob) shouldCleanScene(s *models.Scene) bool {
 	if j.shouldClean(s.Path) {
 		return true
@@ -315,6 +413,11 @@ func (j *cleanJob) shouldCleanScene(s *models.Scene) bool {
 		return true
 	}
 
+	if matchStashExclude(stash, s.Path) {
+		logger.Infof("File matched library exclude pattern. Marking to clean: \"%s\"", s.Path)
+		return true
+	}
+
 	return false
 }
 
@@ -364,6 +467,11 @@ func (j *cleanJob) shouldCleanGallery(g *models.Gallery, qb models.ImageReader)
 		return true
 	}
 
+	if matchStashExclude(stash, path) {
+		logger.Infof("File matched library exclude pattern. Marking to clean: \"%s\"", path)
+		return true
+	}
+
 	return false
 }
 
@@ -389,6 +497,11 @@ func (j *cleanJob) shouldCleanImage(s *models.Image) bool {
 		return true
 	}
 
+	if matchStashExclude(stash, s.Path) {
+		logger.Infof("File matched library exclude pattern. Marking to clean: \"%s\"", s.Path)
+		return true
+	}
+
 	return false
 }
 
@@ -420,6 +533,7 @@ func (j *cleanJob) deleteScene(ctx context.Context, fileNamingAlgorithm models.H
 
 	// perform the post-commit actions
 	fileDeleter.Commit()
+	j.changes.scenes.Removed(sceneID)
 
 	GetInstance().PluginCache.ExecutePostHooks(ctx, sceneID, plugin.SceneDestroyPost, plugin.SceneDestroyInput{
 		Checksum: s.Checksum.String,
@@ -428,6 +542,33 @@ func (j *cleanJob) deleteScene(ctx context.Context, fileNamingAlgorithm models.H
 	}, nil)
 }
 
+func (j *cleanJob) trashScene(sceneID int) {
+	if err := j.txnManager.WithTxn(context.TODO(), func(repo models.Repository) error {
+		// the file is missing, so only the generated files are deleted
+		return repo.Scene().SoftDestroy(sceneID, false, true)
+	}); err != nil {
+		logger.Errorf("Error moving scene to trash: %s", err.Error())
+		return
+	}
+
+	j.changes.scenes.Removed(sceneID)
+}
+
+func (j *cleanJob) markSceneMissing(sceneID int) {
+	if err := j.txnManager.WithTxn(context.TODO(), func(repo models.Repository) error {
+		_, err := repo.Scene().Update(models.ScenePartial{
+			ID:        sceneID,
+			MissingAt: &models.NullSQLiteTimestamp{Timestamp: time.Now(), Valid: true},
+		})
+		return err
+	}); err != nil {
+		logger.Errorf("Error marking scene as missing: %s", err.Error())
+		return
+	}
+
+	j.changes.scenes.Removed(sceneID)
+}
+
 func (j *cleanJob) deleteGallery(ctx context.Context, galleryID int) {
 	var g *models.Gallery
 
@@ -446,6 +587,8 @@ func (j *cleanJob) deleteGallery(ctx context.Context, galleryID int) {
 		return
 	}
 
+	j.changes.galleries.Removed(galleryID)
+
 	GetInstance().PluginCache.ExecutePostHooks(ctx, galleryID, plugin.GalleryDestroyPost, plugin.GalleryDestroyInput{
 		Checksum: g.Checksum,
 		Path:     g.Path.String,
@@ -482,6 +625,8 @@ func (j *cleanJob) deleteImage(ctx context.Context, imageID int) {
 
 	// perform the post-commit actions
 	fileDeleter.Commit()
+	j.changes.images.Removed(imageID)
+
 	GetInstance().PluginCache.ExecutePostHooks(ctx, imageID, plugin.ImageDestroyPost, plugin.ImageDestroyInput{
 		Checksum: i.Checksum,
 		Path:     i.Path,
@@ -0,0 +1,226 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/paths"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// generatedFile is a generated file or directory of a scene or image.
+type generatedFile struct {
+	path string
+	// hash is the scene hash or the image checksum
+	hash  string
+	image bool
+}
+
+// cleanGeneratedJob removes generated files of scenes and images that no
+// longer exist. Soft-deleted scenes keep their generated files.
+type cleanGeneratedJob struct {
+	txnManager models.TransactionManager
+	paths      *paths.Paths
+	dryRun     bool
+}
+
+func (j *cleanGeneratedJob) Execute(ctx context.Context, progress *job.Progress) {
+	logger.Infof("Starting cleaning of generated files")
+	if j.dryRun {
+		logger.Infof("Running in Dry Mode")
+	}
+
+	progress.Indefinite()
+
+	files, err := j.findGeneratedFiles()
+	if err != nil {
+		logger.Errorf("Error finding generated files: %v", err)
+		return
+	}
+
+	orphans, err := j.findOrphans(ctx, files)
+	if err != nil {
+		logger.Errorf("Error finding orphaned generated files: %v", err)
+		return
+	}
+
+	if job.IsCancelled(ctx) {
+		logger.Info("Stopping due to user request")
+		return
+	}
+
+	removed := j.remove(orphans)
+
+	if j.dryRun {
+		logger.Infof("Finished cleaning generated files: %d files would be removed", removed)
+	} else {
+		logger.Infof("Finished cleaning generated files: %d files removed", removed)
+	}
+}
+
+// findGeneratedFiles returns the generated files of scenes and images.
+// Files with names that are not generated names are not returned, so that
+// they are never removed. Caches are not included, since they remove their
+// own files.
+func (j *cleanGeneratedJob) findGeneratedFiles() ([]generatedFile, error) {
+	var ret []generatedFile
+
+	g := j.paths.Generated
+	for _, dir := range []string{g.Screenshots, g.Vtt, g.Transcodes, g.InteractiveHeatmap} {
+		entries, err := readGeneratedDir(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+
+			if hash, ok := paths.SceneHashFromFilename(e.Name()); ok {
+				ret = append(ret, generatedFile{path: filepath.Join(dir, e.Name()), hash: hash})
+			}
+		}
+	}
+
+	entries, err := readGeneratedDir(g.Markers)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+
+		if hash, ok := paths.SceneHashFromMarkerDir(e.Name()); ok {
+			ret = append(ret, generatedFile{path: filepath.Join(g.Markers, e.Name()), hash: hash})
+		}
+	}
+
+	if err := filepath.Walk(g.Thumbnails, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if info.IsDir() {
+			if path == g.ThumbnailCache {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if checksum, ok := paths.ThumbnailChecksumFromFilename(info.Name()); ok {
+			ret = append(ret, generatedFile{path: path, hash: checksum, image: true})
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("error walking %s: %w", g.Thumbnails, err)
+	}
+
+	return ret, nil
+}
+
+// readGeneratedDir returns the entries of the directory, or nil if the
+// directory does not exist.
+func readGeneratedDir(dir string) ([]os.DirEntry, error) {
+	ret, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", dir, err)
+	}
+
+	return ret, nil
+}
+
+// findOrphans returns the files of scenes and images that do not exist.
+// Scene files are named by the checksum or oshash, depending on the naming
+// algorithm when they were generated, so files are kept if either hash of
+// a scene matches.
+func (j *cleanGeneratedJob) findOrphans(ctx context.Context, files []generatedFile) ([]generatedFile, error) {
+	var ret []generatedFile
+	if err := j.txnManager.WithReadTxn(ctx, func(r models.ReaderRepository) error {
+		sceneExists := make(map[string]bool)
+		imageExists := make(map[string]bool)
+
+		for _, f := range files {
+			if job.IsCancelled(ctx) {
+				return nil
+			}
+
+			existing := sceneExists
+			lookup := func() (bool, error) {
+				return sceneHashExists(r.Scene(), f.hash)
+			}
+			if f.image {
+				existing = imageExists
+				lookup = func() (bool, error) {
+					img, err := r.Image().FindByChecksum(f.hash)
+					return img != nil, err
+				}
+			}
+
+			exists, found := existing[f.hash]
+			if !found {
+				var err error
+				exists, err = lookup()
+				if err != nil {
+					return fmt.Errorf("error finding owner of %s: %w", f.path, err)
+				}
+				existing[f.hash] = exists
+			}
+
+			if !exists {
+				ret = append(ret, f)
+			}
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func sceneHashExists(qb models.SceneReader, hash string) (bool, error) {
+	s, err := qb.FindByChecksum(hash)
+	if err != nil || s != nil {
+		return s != nil, err
+	}
+
+	s, err = qb.FindByOSHash(hash)
+	return s != nil, err
+}
+
+// remove removes the files, returning the number of files removed. Files
+// are only logged if the job is a dry run.
+func (j *cleanGeneratedJob) remove(files []generatedFile) int {
+	ret := 0
+	for _, f := range files {
+		if j.dryRun {
+			logger.Infof("Would remove orphaned generated file %s", f.path)
+			ret++
+			continue
+		}
+
+		if err := os.RemoveAll(f.path); err != nil {
+			logger.Warnf("Error removing orphaned generated file %s: %v", f.path, err)
+			continue
+		}
+
+		logger.Debugf("Removed orphaned generated file %s", f.path)
+		ret++
+	}
+
+	return ret
+}
@@ -0,0 +1,126 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/manager/paths"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanGeneratedJob(t *testing.T) {
+	const (
+		sceneChecksum   = "aaaa0000aaaa0000aaaa0000aaaa0000"
+		sceneOSHash     = "bbbb0000bbbb0000"
+		orphanHash      = "cccc0000cccc0000"
+		imageChecksum   = "dddd0000dddd0000dddd0000dddd0000"
+		orphanImageHash = "eeee0000eeee0000eeee0000eeee0000"
+	)
+
+	p := paths.NewPaths(t.TempDir())
+
+	write := func(path string) string {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// referenced files
+	kept := []string{
+		write(p.Scene.GetScreenshotPath(sceneChecksum)),
+		write(p.Scene.GetSpriteVttFilePath(sceneChecksum)),
+		write(p.Scene.GetContactSheetPath(sceneChecksum)),
+		write(p.Scene.GetThumbnailScreenshotPath(sceneOSHash)),
+		write(p.SceneMarkers.GetStreamPath(sceneOSHash, 10)),
+		write(p.Generated.GetThumbnailPath(imageChecksum, 640)),
+		// files without generated names are never removed
+		write(filepath.Join(p.Generated.Screenshots, "notes.txt")),
+		// caches remove their own files
+		write(filepath.Join(p.Generated.ThumbnailCache, "1_"+orphanImageHash+"_640.jpg")),
+	}
+
+	// orphaned files
+	orphans := []string{
+		write(p.Scene.GetStreamPreviewPath(orphanHash)),
+		write(p.Scene.GetSpriteImageFilePath(orphanHash)),
+		write(p.Scene.GetContactSheetPath(orphanHash)),
+		write(p.Scene.GetTranscodePath(orphanHash)),
+		write(p.Scene.GetInteractiveHeatmapPath(orphanHash)),
+		write(p.Generated.GetThumbnailPath(orphanImageHash, 640)),
+	}
+	orphanMarkers := filepath.Join(p.Generated.Markers, orphanHash)
+	write(p.SceneMarkers.GetStreamPath(orphanHash, 20))
+	orphans = append(orphans, orphanMarkers)
+
+	mockTxn := mocks.NewTransactionManager()
+	sceneQB := mockTxn.SceneMock()
+	sceneQB.On("FindByChecksum", sceneChecksum).Return(&models.Scene{ID: 1}, nil)
+	sceneQB.On("FindByChecksum", sceneOSHash).Return(nil, nil)
+	sceneQB.On("FindByOSHash", sceneOSHash).Return(&models.Scene{ID: 1}, nil)
+	sceneQB.On("FindByChecksum", orphanHash).Return(nil, nil)
+	sceneQB.On("FindByOSHash", orphanHash).Return(nil, nil)
+
+	imageQB := mockTxn.ImageMock()
+	imageQB.On("FindByChecksum", imageChecksum).Return(&models.Image{ID: 1}, nil)
+	imageQB.On("FindByChecksum", orphanImageHash).Return(nil, nil)
+
+	j := &cleanGeneratedJob{
+		txnManager: mockTxn,
+		paths:      p,
+		dryRun:     true,
+	}
+
+	files, err := j.findGeneratedFiles()
+	assert.Nil(t, err)
+
+	found, err := j.findOrphans(context.Background(), files)
+	assert.Nil(t, err)
+
+	var got []string
+	for _, f := range found {
+		got = append(got, f.path)
+	}
+	sort.Strings(got)
+	sort.Strings(orphans)
+	assert.Equal(t, orphans, got)
+
+	// hashes are only looked up once
+	sceneQB.AssertNumberOfCalls(t, "FindByOSHash", 2)
+
+	// dry run does not remove files
+	assert.Equal(t, len(orphans), j.remove(found))
+	for _, f := range orphans {
+		_, err := os.Stat(f)
+		assert.Nil(t, err, f)
+	}
+
+	j.dryRun = false
+	assert.Equal(t, len(orphans), j.remove(found))
+	for _, f := range orphans {
+		_, err := os.Stat(f)
+		assert.True(t, os.IsNotExist(err), f)
+	}
+	for _, f := range kept {
+		assert.FileExists(t, f)
+	}
+}
+
+func TestCleanGeneratedJobMissingDirs(t *testing.T) {
+	j := &cleanGeneratedJob{
+		paths: paths.NewPaths(filepath.Join(t.TempDir(), "missing")),
+	}
+
+	files, err := j.findGeneratedFiles()
+	assert.Nil(t, err)
+	assert.Empty(t, files)
+}
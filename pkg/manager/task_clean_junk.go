@@ -0,0 +1,157 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// cleanJunkJob removes junk files, such as files created by file managers,
+// from the stash paths.
+type cleanJunkJob struct {
+	input      models.CleanJunkFilesInput
+	stashPaths []string
+	patterns   []string
+}
+
+func (j *cleanJunkJob) Execute(ctx context.Context, progress *job.Progress) {
+	logger.Infof("Starting cleaning of junk files")
+	if j.input.DryRun {
+		logger.Infof("Running in Dry Mode")
+	}
+
+	progress.Indefinite()
+
+	removed, err := j.clean(ctx, j.roots())
+	if err != nil {
+		logger.Errorf("Error cleaning junk files: %v", err)
+	}
+
+	if job.IsCancelled(ctx) {
+		logger.Info("Stopping due to user request")
+		return
+	}
+
+	if j.input.DryRun {
+		logger.Infof("Finished cleaning junk files: %d files would be removed", len(removed))
+	} else {
+		logger.Infof("Finished cleaning junk files: %d files removed", len(removed))
+	}
+}
+
+// roots returns the directories to clean. Paths in the input that are not in
+// a stash path are ignored, so that files outside the library are never
+// removed.
+func (j *cleanJunkJob) roots() []string {
+	paths := j.input.Paths
+	if len(paths) == 0 {
+		paths = j.stashPaths
+	}
+
+	var ret []string
+	for _, p := range paths {
+		if !j.inStashPath(p) {
+			logger.Warnf("Not cleaning junk files from %s: not in a library path", p)
+			continue
+		}
+
+		if file.IsRemotePath(p) {
+			logger.Warnf("Not cleaning junk files from remote path %s", p)
+			continue
+		}
+
+		ret = append(ret, p)
+	}
+
+	return ret
+}
+
+func (j *cleanJunkJob) inStashPath(path string) bool {
+	for _, p := range j.stashPaths {
+		if utils.IsPathInDir(p, path) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isJunk returns true if the file is a junk file. Only regular files are
+// junk, so that directories and symbolic links are never removed.
+func (j *cleanJunkJob) isJunk(info os.FileInfo) bool {
+	if !info.Mode().IsRegular() {
+		return false
+	}
+
+	if utils.IsTrue(j.input.IncludeEmptyFiles) && info.Size() == 0 {
+		return true
+	}
+
+	return matchJunkPattern(j.patterns, info.Name())
+}
+
+// matchJunkPattern returns true if the filename matches one of the glob
+// patterns. Matching is not case sensitive. Invalid patterns are ignored.
+func matchJunkPattern(patterns []string, name string) bool {
+	name = strings.ToLower(name)
+	for _, p := range patterns {
+		if matched, _ := filepath.Match(strings.ToLower(p), name); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clean removes the junk files in the root directories, returning the paths
+// of the removed files. Files are only logged if the job is a dry run.
+func (j *cleanJunkJob) clean(ctx context.Context, roots []string) ([]string, error) {
+	var ret []string
+	for _, root := range roots {
+		if err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+			if job.IsCancelled(ctx) {
+				return context.Canceled
+			}
+
+			if err != nil {
+				logger.Warnf("Error walking %s: %v", path, err)
+				return nil
+			}
+
+			if !j.isJunk(info) {
+				return nil
+			}
+
+			if j.input.DryRun {
+				logger.Infof("Would remove junk file %s", path)
+				ret = append(ret, path)
+				return nil
+			}
+
+			if err := os.Remove(path); err != nil {
+				logger.Warnf("Error removing junk file %s: %v", path, err)
+				return nil
+			}
+
+			logger.Infof("Removed junk file %s", path)
+			ret = append(ret, path)
+			return nil
+		}); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return ret, nil
+			}
+			return ret, fmt.Errorf("error walking %s: %w", root, err)
+		}
+	}
+
+	return ret, nil
+}
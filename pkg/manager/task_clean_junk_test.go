@@ -0,0 +1,125 @@
+package manager
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchJunkPattern(t *testing.T) {
+	patterns := []string{".DS_Store", "._*", "Thumbs.db", "[invalid"}
+
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{".DS_Store", true},
+		{"thumbs.db", true},
+		{"THUMBS.DB", true},
+		{"._video.mp4", true},
+		{"video.mp4", false},
+		{"Thumbs.db.mp4", false},
+		{"my.DS_Store", false},
+		{"[invalid", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchJunkPattern(patterns, tt.name))
+		})
+	}
+}
+
+func writeJunkTestFile(t *testing.T, path string, data string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCleanJunkJob(t *testing.T) {
+	dir := t.TempDir()
+	lib := filepath.Join(dir, "lib")
+	outside := filepath.Join(dir, "outside")
+
+	junk := []string{
+		filepath.Join(lib, ".DS_Store"),
+		filepath.Join(lib, "sub", "Thumbs.db"),
+	}
+	empty := filepath.Join(lib, "sub", "empty.mp4")
+	keep := []string{
+		filepath.Join(lib, "video.mp4"),
+		filepath.Join(outside, ".DS_Store"),
+		filepath.Join(outside, "empty.mp4"),
+	}
+
+	for _, p := range junk {
+		writeJunkTestFile(t, p, "junk")
+	}
+	writeJunkTestFile(t, empty, "")
+	writeJunkTestFile(t, keep[0], "video")
+	writeJunkTestFile(t, keep[1], "junk")
+	writeJunkTestFile(t, keep[2], "")
+
+	// a directory matching a pattern is not removed
+	if err := os.MkdirAll(filepath.Join(lib, "._dir"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	newJob := func(dryRun bool, includeEmpty bool, paths []string) *cleanJunkJob {
+		return &cleanJunkJob{
+			input: models.CleanJunkFilesInput{
+				Paths:             paths,
+				DryRun:            dryRun,
+				IncludeEmptyFiles: &includeEmpty,
+			},
+			stashPaths: []string{lib},
+			patterns:   []string{".DS_Store", "._*", "Thumbs.db"},
+		}
+	}
+
+	// paths outside the stash paths are ignored
+	j := newJob(false, true, []string{outside})
+	removed, err := j.clean(context.Background(), j.roots())
+	assert.Nil(t, err)
+	assert.Empty(t, removed)
+
+	// dry run reports files without removing them
+	j = newJob(true, true, nil)
+	removed, err = j.clean(context.Background(), j.roots())
+	assert.Nil(t, err)
+
+	want := append([]string{empty}, junk...)
+	sort.Strings(want)
+	sort.Strings(removed)
+	assert.Equal(t, want, removed)
+
+	for _, p := range want {
+		assert.FileExists(t, p)
+	}
+
+	// empty files are only removed if requested
+	j = newJob(false, false, nil)
+	removed, err = j.clean(context.Background(), j.roots())
+	assert.Nil(t, err)
+	sort.Strings(removed)
+	assert.Equal(t, junk, removed)
+
+	for _, p := range junk {
+		assert.NoFileExists(t, p)
+	}
+	assert.FileExists(t, empty)
+
+	for _, p := range keep {
+		assert.FileExists(t, p)
+	}
+	assert.DirExists(t, filepath.Join(lib, "._dir"))
+}
@@ -0,0 +1,102 @@
+package manager
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCleanMissingScene(t *testing.T) {
+	missingPath := filepath.Join(t.TempDir(), "missing.mp4")
+
+	const sceneID = 1
+
+	tests := []struct {
+		name          string
+		policy        models.MissingFilePolicy
+		alreadyMarked bool
+		want          cleanAction
+	}{
+		{"delete", models.MissingFilePolicyDelete, false, cleanDelete},
+		{"trash", models.MissingFilePolicyTrash, false, cleanTrash},
+		{"mark", models.MissingFilePolicyMark, false, cleanMarkMissing},
+		{"mark already marked", models.MissingFilePolicyMark, true, cleanKeep},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &models.Scene{
+				ID:   sceneID,
+				Path: missingPath,
+			}
+			if tt.alreadyMarked {
+				s.MissingAt = models.NullSQLiteTimestamp{Timestamp: time.Now(), Valid: true}
+			}
+
+			j := &cleanJob{
+				missingPolicy: tt.policy,
+			}
+
+			assert.Equal(t, tt.want, j.sceneCleanAction(s))
+		})
+	}
+}
+
+func TestCleanTrashScene(t *testing.T) {
+	const sceneID = 1
+
+	repo := mocks.NewTransactionManager()
+	repo.SceneMock().On("SoftDestroy", sceneID, false, true).Return(nil).Once()
+
+	j := &cleanJob{
+		txnManager:    repo,
+		missingPolicy: models.MissingFilePolicyTrash,
+		changes:       newScanChanges(),
+	}
+	j.trashScene(sceneID)
+
+	repo.SceneMock().AssertExpectations(t)
+	assert.Equal(t, []string{"1"}, j.changes.model().Scenes.Removed)
+}
+
+func TestCleanTrashSceneError(t *testing.T) {
+	const sceneID = 1
+
+	repo := mocks.NewTransactionManager()
+	repo.SceneMock().On("SoftDestroy", sceneID, false, true).Return(errors.New("trash failed")).Once()
+
+	j := &cleanJob{
+		txnManager:    repo,
+		missingPolicy: models.MissingFilePolicyTrash,
+		changes:       newScanChanges(),
+	}
+	j.trashScene(sceneID)
+
+	// scenes that failed to be trashed are not removed
+	assert.Empty(t, j.changes.model().Scenes.Removed)
+}
+
+func TestCleanMarkSceneMissing(t *testing.T) {
+	const sceneID = 1
+
+	repo := mocks.NewTransactionManager()
+	repo.SceneMock().On("Update", mock.MatchedBy(func(p models.ScenePartial) bool {
+		return p.ID == sceneID && p.MissingAt != nil && p.MissingAt.Valid
+	})).Return(&models.Scene{ID: sceneID}, nil).Once()
+
+	j := &cleanJob{
+		txnManager:    repo,
+		missingPolicy: models.MissingFilePolicyMark,
+		changes:       newScanChanges(),
+	}
+	j.markSceneMissing(sceneID)
+
+	repo.SceneMock().AssertExpectations(t)
+	assert.Equal(t, []string{"1"}, j.changes.model().Scenes.Removed)
+}
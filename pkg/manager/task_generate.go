@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/remeh/sizedwaitgroup"
@@ -20,6 +21,18 @@ const generateQueueSize = 200000
 type GenerateJob struct {
 	txnManager models.TransactionManager
 	input      models.GenerateMetadataInput
+	events     *eventBus
+	// stats records the time taken by the tasks. May be nil.
+	stats *GenerateStats
+	// sampler samples the existing artifacts of the queued scenes when
+	// estimating. Nil otherwise.
+	sampler *generateSizeSampler
+	// failures records the tasks that fail. May be nil.
+	failures *generateFailureRecorder
+	// retry queues the failed items that are due to be retried, instead of
+	// the scenes and markers of the input.
+	retry      bool
+	maxRetries int
 
 	overwrite      bool
 	fileNamingAlgo models.HashAlgorithm
@@ -27,90 +40,58 @@ type GenerateJob struct {
 
 type totalsGenerate struct {
 	sprites                  int64
+	contactSheets            int64
 	previews                 int64
 	imagePreviews            int64
 	markers                  int64
 	transcodes               int64
 	phashes                  int64
 	interactiveHeatmapSpeeds int64
+	chapterMarkers           int64
 
 	tasks int
 }
 
 func (j *GenerateJob) Execute(ctx context.Context, progress *job.Progress) {
-	var scenes []*models.Scene
 	var err error
-	var markers []*models.SceneMarker
 
-	if j.input.Overwrite != nil {
-		j.overwrite = *j.input.Overwrite
-	}
-	j.fileNamingAlgo = config.GetInstance().GetVideoFileNamingAlgorithm()
+	j.init()
 
 	config := config.GetInstance()
 	parallelTasks := config.GetParallelTasksWithAutoDetection()
 
-	logger.Infof("Generate started with %d parallel tasks", parallelTasks)
+	logger.Ctx(ctx).Infof("Generate started with %d parallel tasks", parallelTasks)
+
+	var totals totalsGenerate
+	times := newGenerateTaskTimes()
 
 	queue := make(chan Task, generateQueueSize)
-	go func() {
+	progress.Go(func() {
 		defer close(queue)
 
-		var totals totalsGenerate
-		sceneIDs, err := utils.StringSliceToIntSlice(j.input.SceneIDs)
+		var err error
+		totals, err = j.queueTargets(ctx, queue)
 		if err != nil {
-			logger.Error(err.Error())
-		}
-		markerIDs, err := utils.StringSliceToIntSlice(j.input.MarkerIDs)
-		if err != nil {
-			logger.Error(err.Error())
-		}
-
-		if err := j.txnManager.WithReadTxn(ctx, func(r models.ReaderRepository) error {
-			qb := r.Scene()
-			if len(j.input.SceneIDs) == 0 && len(j.input.MarkerIDs) == 0 {
-				totals = j.queueTasks(ctx, queue)
-			} else {
-				if len(j.input.SceneIDs) > 0 {
-					scenes, err = qb.FindMany(sceneIDs)
-					for _, s := range scenes {
-						j.queueSceneJobs(s, queue, &totals)
-					}
-				}
-
-				if len(j.input.MarkerIDs) > 0 {
-					markers, err = r.SceneMarker().FindMany(markerIDs)
-					if err != nil {
-						return err
-					}
-					for _, m := range markers {
-						j.queueMarkerJob(m, queue, &totals)
-					}
-				}
-			}
-
-			return nil
-		}); err != nil {
-			logger.Error(err.Error())
+			logger.Ctx(ctx).Error(err.Error())
 			return
 		}
 
-		logger.Infof("Generating %d sprites %d previews %d image previews %d markers %d transcodes %d phashes %d heatmaps & speeds", totals.sprites, totals.previews, totals.imagePreviews, totals.markers, totals.transcodes, totals.phashes, totals.interactiveHeatmapSpeeds)
+		logger.Ctx(ctx).Infof("Generating %d sprites %d contact sheets %d previews %d image previews %d markers %d transcodes %d phashes %d heatmaps & speeds %d chapter markers", totals.sprites, totals.contactSheets, totals.previews, totals.imagePreviews, totals.markers, totals.transcodes, totals.phashes, totals.interactiveHeatmapSpeeds, totals.chapterMarkers)
 
 		progress.SetTotal(int(totals.tasks))
-	}()
+	})
 
 	wg := sizedwaitgroup.New(parallelTasks)
 
 	// Start measuring how long the generate has taken. (consider moving this up)
 	start := time.Now()
 	if err = instance.Paths.Generated.EnsureTmpDir(); err != nil {
-		logger.Warnf("could not create temporary directory: %v", err)
+		logger.Ctx(ctx).Warnf("could not create temporary directory: %v", err)
 	}
 
 	defer func() {
 		if err := instance.Paths.Generated.EmptyTmpDir(); err != nil {
-			logger.Warnf("failure emptying temporary directory: %v", err)
+			logger.Ctx(ctx).Warnf("failure emptying temporary directory: %v", err)
 		}
 	}()
 
@@ -124,8 +105,8 @@ func (j *GenerateJob) Execute(ctx context.Context, progress *job.Progress) {
 		// where f is changed when the goroutine runs
 		localTask := f
 		go progress.ExecuteTask(localTask.GetDescription(), func() {
-			localTask.Start(ctx)
-			wg.Done()
+			defer wg.Done()
+			j.runTask(ctx, localTask, times)
 			progress.Increment()
 		})
 	}
@@ -133,12 +114,130 @@ func (j *GenerateJob) Execute(ctx context.Context, progress *job.Progress) {
 	wg.Wait()
 
 	if job.IsCancelled(ctx) {
-		logger.Info("Stopping due to user request")
+		j.publishComplete(true)
+		logger.Ctx(ctx).Info("Stopping due to user request")
 		return
 	}
 
+	if j.stats != nil {
+		j.stats.recordJob(times, totals)
+	}
+
 	elapsed := time.Since(start)
-	logger.Info(fmt.Sprintf("Generate finished (%s)", elapsed))
+	logger.Ctx(ctx).Info(fmt.Sprintf("Generate finished (%s)", elapsed))
+	j.publishComplete(false)
+}
+
+// runTask runs the task, recording the time it takes and whether it failed.
+func (j *GenerateJob) runTask(ctx context.Context, task Task, times *generateTaskTimes) {
+	taskCtx, taskErr := withTaskErrorSink(ctx)
+	start := time.Now()
+	task.Start(taskCtx)
+	times.add(task, time.Since(start))
+
+	if j.failures != nil && !job.IsCancelled(ctx) {
+		j.failures.record(ctx, task, taskErr.Error())
+	}
+}
+
+func (j *GenerateJob) init() {
+	if j.input.Overwrite != nil {
+		j.overwrite = *j.input.Overwrite
+	}
+	j.fileNamingAlgo = config.GetInstance().GetVideoFileNamingAlgorithm()
+}
+
+// queueTargets queues the tasks for the scenes and markers of the input,
+// including those selected by its saved filter, or for all scenes if none
+// are specified. Returns the totals of the queued tasks.
+func (j *GenerateJob) queueTargets(ctx context.Context, queue chan<- Task) (totalsGenerate, error) {
+	if j.retry {
+		return j.queueRetries(ctx, queue)
+	}
+
+	var totals totalsGenerate
+
+	if len(j.input.SceneIDs) == 0 && len(j.input.MarkerIDs) == 0 && j.input.SavedFilterID == nil {
+		return j.queueTasks(ctx, queue), nil
+	}
+
+	sceneIDs, err := utils.StringSliceToIntSlice(j.input.SceneIDs)
+	if err != nil {
+		logger.Ctx(ctx).Error(err.Error())
+	}
+	markerIDs, err := utils.StringSliceToIntSlice(j.input.MarkerIDs)
+	if err != nil {
+		logger.Ctx(ctx).Error(err.Error())
+	}
+
+	err = j.txnManager.WithReadTxn(ctx, func(r models.ReaderRepository) error {
+		if j.input.SavedFilterID != nil {
+			var err error
+			sceneIDs, markerIDs, err = j.addSavedFilterTargets(r, sceneIDs, markerIDs)
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(sceneIDs) > 0 {
+			scenes, err := r.Scene().FindMany(sceneIDs)
+			if err != nil {
+				return err
+			}
+			for _, s := range scenes {
+				j.queueSceneJobs(s, queue, &totals)
+			}
+		}
+
+		if len(markerIDs) > 0 {
+			markers, err := r.SceneMarker().FindMany(markerIDs)
+			if err != nil {
+				return err
+			}
+			for _, m := range markers {
+				j.queueMarkerJob(m, queue, &totals)
+			}
+		}
+
+		return nil
+	})
+
+	return totals, err
+}
+
+// addSavedFilterTargets adds the scenes or markers selected by the saved
+// filter of the input to the provided ids, omitting duplicates.
+func (j *GenerateJob) addSavedFilterTargets(r models.ReaderRepository, sceneIDs, markerIDs []int) ([]int, []int, error) {
+	savedFilterID, err := strconv.Atoi(*j.input.SavedFilterID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid saved filter id %s: %w", *j.input.SavedFilterID, err)
+	}
+
+	target, err := loadSavedFilterTarget(r, savedFilterID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if target.mode != models.FilterModeScenes && target.mode != models.FilterModeSceneMarkers {
+		return nil, nil, fmt.Errorf("cannot generate saved filter of %s", target.mode)
+	}
+
+	ids, err := target.ids(r)
+	if err != nil {
+		return nil, nil, fmt.Errorf("querying saved filter %d: %w", savedFilterID, err)
+	}
+
+	if target.mode == models.FilterModeScenes {
+		sceneIDs = utils.IntAppendUniques(sceneIDs, ids)
+	} else {
+		markerIDs = utils.IntAppendUniques(markerIDs, ids)
+	}
+
+	return sceneIDs, markerIDs, nil
+}
+
+func (j *GenerateJob) publishComplete(cancelled bool) {
+	j.events.Publish(TopicGenerate, GenerateEvent{Cancelled: cancelled})
 }
 
 func (j *GenerateJob) queueTasks(ctx context.Context, queue chan<- Task) totalsGenerate {
@@ -177,7 +276,7 @@ func (j *GenerateJob) queueTasks(ctx context.Context, queue chan<- Task) totalsG
 		return nil
 	}); err != nil {
 		if !errors.Is(err, context.Canceled) {
-			logger.Errorf("Error encountered queuing files to scan: %s", err.Error())
+			logger.Ctx(ctx).Errorf("Error encountered queuing files to scan: %s", err.Error())
 		}
 	}
 
@@ -185,6 +284,10 @@ func (j *GenerateJob) queueTasks(ctx context.Context, queue chan<- Task) totalsG
 }
 
 func (j *GenerateJob) queueSceneJobs(scene *models.Scene, queue chan<- Task, totals *totalsGenerate) {
+	if j.sampler != nil {
+		j.sampler.sample(scene)
+	}
+
 	if utils.IsTrue(j.input.Sprites) {
 		task := &GenerateSpriteTask{
 			Scene:               *scene,
@@ -199,6 +302,20 @@ func (j *GenerateJob) queueSceneJobs(scene *models.Scene, queue chan<- Task, tot
 		}
 	}
 
+	if utils.IsTrue(j.input.ContactSheets) {
+		task := &GenerateContactSheetTask{
+			Scene:               *scene,
+			Overwrite:           j.overwrite,
+			fileNamingAlgorithm: j.fileNamingAlgo,
+		}
+
+		if j.overwrite || task.required() {
+			totals.contactSheets++
+			totals.tasks++
+			queue <- task
+		}
+	}
+
 	if utils.IsTrue(j.input.Previews) {
 		generatePreviewOptions := j.input.PreviewOptions
 		if generatePreviewOptions == nil {
@@ -232,6 +349,17 @@ func (j *GenerateJob) queueSceneJobs(scene *models.Scene, queue chan<- Task, tot
 		}
 	}
 
+	if utils.IsTrue(j.input.ChapterMarkers) {
+		task := &GenerateChapterMarkersTask{
+			Scene:      *scene,
+			TxnManager: j.txnManager,
+		}
+
+		totals.chapterMarkers++
+		totals.tasks++
+		queue <- task
+	}
+
 	if utils.IsTrue(j.input.Markers) {
 		task := &GenerateMarkersTask{
 			TxnManager:          j.txnManager,
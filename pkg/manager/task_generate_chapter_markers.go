@@ -0,0 +1,59 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene"
+)
+
+// GenerateChapterMarkersTask creates scene markers from the chapters
+// embedded in the scene file.
+type GenerateChapterMarkersTask struct {
+	Scene      models.Scene
+	TxnManager models.TransactionManager
+}
+
+func (t *GenerateChapterMarkersTask) GetDescription() string {
+	return fmt.Sprintf("Importing chapter markers for %s", t.Scene.Path)
+}
+
+func (t *GenerateChapterMarkersTask) Start(ctx context.Context) {
+	hasMarkers := false
+	if err := t.TxnManager.WithReadTxn(ctx, func(r models.ReaderRepository) error {
+		markers, err := r.SceneMarker().FindBySceneID(t.Scene.ID)
+		hasMarkers = len(markers) > 0
+		return err
+	}); err != nil {
+		logger.Ctx(ctx).Errorf("error finding scene markers: %s", err.Error())
+		return
+	}
+
+	// don't probe the file if the markers would not be created
+	if hasMarkers {
+		return
+	}
+
+	ffprobe := instance.FFProbe()
+	videoFile, err := ffprobe.NewVideoFile(t.Scene.Path, false)
+	if err != nil {
+		logger.Ctx(ctx).Errorf("error reading video file: %s", err.Error())
+		return
+	}
+
+	if len(videoFile.Chapters) == 0 {
+		return
+	}
+
+	if err := t.TxnManager.WithTxn(ctx, func(r models.Repository) error {
+		created, err := scene.CreateChapterMarkers(r.SceneMarker(), r.Tag(), t.Scene.ID, videoFile.Chapters)
+		if len(created) > 0 {
+			logger.Ctx(ctx).Infof("Created %d chapter markers for %s", len(created), t.Scene.Path)
+		}
+		return err
+	}); err != nil {
+		logger.Ctx(ctx).Errorf("error creating chapter markers: %s", err.Error())
+	}
+}
@@ -0,0 +1,75 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+type GenerateContactSheetTask struct {
+	Scene               models.Scene
+	Overwrite           bool
+	fileNamingAlgorithm models.HashAlgorithm
+}
+
+func (t *GenerateContactSheetTask) GetDescription() string {
+	return fmt.Sprintf("Generating contact sheet for %s", t.Scene.Path)
+}
+
+func (t *GenerateContactSheetTask) Start(ctx context.Context) {
+	if !t.Overwrite && !t.required() {
+		return
+	}
+
+	ffprobe := instance.FFProbe()
+	videoFile, err := ffprobe.NewVideoFile(t.Scene.Path, false)
+	if err != nil {
+		logger.Ctx(ctx).Errorf("error reading video file: %s", err.Error())
+		return
+	}
+
+	c := config.GetInstance()
+	options := ffmpeg.ContactSheetOptions{
+		OutputPath: instance.Paths.Scene.GetContactSheetPath(t.Scene.GetHash(t.fileNamingAlgorithm)),
+		Columns:    c.GetContactSheetColumns(),
+		Rows:       c.GetContactSheetRows(),
+		Width:      c.GetContactSheetWidth(),
+	}
+	if c.GetContactSheetHeader() {
+		// the header is drawn by the drawtext filter, which is not available
+		// if ffmpeg is built without libfreetype
+		if instance.FFMPEGCapabilities().SupportsFilter("drawtext") {
+			options.Header = contactSheetHeader(t.Scene, videoFile.Duration)
+		} else {
+			logger.Ctx(ctx).Warnf("ffmpeg does not support the drawtext filter. Generating contact sheet for %s without header.", t.Scene.Path)
+		}
+	}
+
+	encoder := instance.FFMPEG()
+	if err := encoder.ContactSheet(*videoFile, options); err != nil {
+		logger.Ctx(ctx).Errorf("error generating contact sheet: %s", err.Error())
+		return
+	}
+}
+
+// contactSheetHeader returns the title and duration of the scene.
+func contactSheetHeader(s models.Scene, duration float64) string {
+	secs := int(duration)
+	return fmt.Sprintf("%s - %02d:%02d:%02d", s.GetTitle(), secs/3600, secs/60%60, secs%60)
+}
+
+// required returns true if the contact sheet needs to be generated
+func (t GenerateContactSheetTask) required() bool {
+	sceneHash := t.Scene.GetHash(t.fileNamingAlgorithm)
+	if sceneHash == "" {
+		return false
+	}
+
+	exists, _ := utils.FileExists(instance.Paths.Scene.GetContactSheetPath(sceneHash))
+	return !exists
+}
@@ -35,7 +35,7 @@ func (t *GenerateInteractiveHeatmapSpeedTask) Start(ctx context.Context) {
 	err := generator.Generate()
 
 	if err != nil {
-		logger.Errorf("error generating heatmap: %s", err.Error())
+		logger.Ctx(ctx).Errorf("error generating heatmap: %s", err.Error())
 		return
 	}
 
@@ -51,7 +51,7 @@ func (t *GenerateInteractiveHeatmapSpeedTask) Start(ctx context.Context) {
 		s, err = r.Scene().FindByPath(t.Scene.Path)
 		return err
 	}); err != nil {
-		logger.Error(err.Error())
+		logger.Ctx(ctx).Error(err.Error())
 		return
 	}
 
@@ -64,7 +64,7 @@ func (t *GenerateInteractiveHeatmapSpeedTask) Start(ctx context.Context) {
 		_, err := qb.Update(scenePartial)
 		return err
 	}); err != nil {
-		logger.Error(err.Error())
+		logger.Ctx(ctx).Error(err.Error())
 	}
 
 }
@@ -35,7 +35,7 @@ func (t *GenerateMarkersTask) GetDescription() string {
 
 func (t *GenerateMarkersTask) Start(ctx context.Context) {
 	if t.Scene != nil {
-		t.generateSceneMarkers()
+		t.generateSceneMarkers(ctx)
 	}
 
 	if t.Marker != nil {
@@ -45,34 +45,34 @@ func (t *GenerateMarkersTask) Start(ctx context.Context) {
 			scene, err = r.Scene().Find(int(t.Marker.SceneID.Int64))
 			return err
 		}); err != nil {
-			logger.Errorf("error finding scene for marker: %s", err.Error())
+			logger.Ctx(ctx).Errorf("error finding scene for marker: %s", err.Error())
 			return
 		}
 
 		if scene == nil {
-			logger.Errorf("scene not found for id %d", t.Marker.SceneID.Int64)
+			logger.Ctx(ctx).Errorf("scene not found for id %d", t.Marker.SceneID.Int64)
 			return
 		}
 
-		ffprobe := instance.FFProbe
+		ffprobe := instance.FFProbe()
 		videoFile, err := ffprobe.NewVideoFile(t.Scene.Path, false)
 		if err != nil {
-			logger.Errorf("error reading video file: %s", err.Error())
+			logger.Ctx(ctx).Errorf("error reading video file: %s", err.Error())
 			return
 		}
 
-		t.generateMarker(videoFile, scene, t.Marker)
+		t.generateMarker(ctx, videoFile, scene, t.Marker)
 	}
 }
 
-func (t *GenerateMarkersTask) generateSceneMarkers() {
+func (t *GenerateMarkersTask) generateSceneMarkers(ctx context.Context) {
 	var sceneMarkers []*models.SceneMarker
 	if err := t.TxnManager.WithReadTxn(context.TODO(), func(r models.ReaderRepository) error {
 		var err error
 		sceneMarkers, err = r.SceneMarker().FindBySceneID(t.Scene.ID)
 		return err
 	}); err != nil {
-		logger.Errorf("error getting scene markers: %s", err.Error())
+		logger.Ctx(ctx).Errorf("error getting scene markers: %s", err.Error())
 		return
 	}
 
@@ -80,10 +80,10 @@ func (t *GenerateMarkersTask) generateSceneMarkers() {
 		return
 	}
 
-	ffprobe := instance.FFProbe
+	ffprobe := instance.FFProbe()
 	videoFile, err := ffprobe.NewVideoFile(t.Scene.Path, false)
 	if err != nil {
-		logger.Errorf("error reading video file: %s", err.Error())
+		logger.Ctx(ctx).Errorf("error reading video file: %s", err.Error())
 		return
 	}
 
@@ -92,18 +92,18 @@ func (t *GenerateMarkersTask) generateSceneMarkers() {
 	// Make the folder for the scenes markers
 	markersFolder := filepath.Join(instance.Paths.Generated.Markers, sceneHash)
 	if err := utils.EnsureDir(markersFolder); err != nil {
-		logger.Warnf("could not create the markers folder (%v): %v", markersFolder, err)
+		logger.Ctx(ctx).Warnf("could not create the markers folder (%v): %v", markersFolder, err)
 	}
 
 	for i, sceneMarker := range sceneMarkers {
 		index := i + 1
-		logger.Progressf("[generator] <%s> scene marker %d of %d", sceneHash, index, len(sceneMarkers))
+		logger.Ctx(ctx).Progressf("[generator] <%s> scene marker %d of %d", sceneHash, index, len(sceneMarkers))
 
-		t.generateMarker(videoFile, t.Scene, sceneMarker)
+		t.generateMarker(ctx, videoFile, t.Scene, sceneMarker)
 	}
 }
 
-func (t *GenerateMarkersTask) generateMarker(videoFile *ffmpeg.VideoFile, scene *models.Scene, sceneMarker *models.SceneMarker) {
+func (t *GenerateMarkersTask) generateMarker(ctx context.Context, videoFile *ffmpeg.VideoFile, scene *models.Scene, sceneMarker *models.SceneMarker) {
 	sceneHash := t.Scene.GetHash(t.fileNamingAlgorithm)
 	seconds := int(sceneMarker.Seconds)
 
@@ -120,7 +120,7 @@ func (t *GenerateMarkersTask) generateMarker(videoFile *ffmpeg.VideoFile, scene
 		Audio:     instance.Config.GetPreviewAudio(),
 	}
 
-	encoder := instance.FFMPEG
+	encoder := instance.FFMPEG()
 
 	if t.Overwrite || !videoExists {
 		videoFilename := baseFilename + ".mp4"
@@ -128,10 +128,10 @@ func (t *GenerateMarkersTask) generateMarker(videoFile *ffmpeg.VideoFile, scene
 
 		options.OutputPath = instance.Paths.Generated.GetTmpPath(videoFilename) // tmp output in case the process ends abruptly
 		if err := encoder.SceneMarkerVideo(*videoFile, options); err != nil {
-			logger.Errorf("[generator] failed to generate marker video: %s", err)
+			logger.Ctx(ctx).Errorf("[generator] failed to generate marker video: %s", err)
 		} else {
 			_ = utils.SafeMove(options.OutputPath, videoPath)
-			logger.Debug("created marker video: ", videoPath)
+			logger.Ctx(ctx).Debug("created marker video: ", videoPath)
 		}
 	}
 
@@ -141,10 +141,10 @@ func (t *GenerateMarkersTask) generateMarker(videoFile *ffmpeg.VideoFile, scene
 
 		options.OutputPath = instance.Paths.Generated.GetTmpPath(imageFilename) // tmp output in case the process ends abruptly
 		if err := encoder.SceneMarkerImage(*videoFile, options); err != nil {
-			logger.Errorf("[generator] failed to generate marker image: %s", err)
+			logger.Ctx(ctx).Errorf("[generator] failed to generate marker image: %s", err)
 		} else {
 			_ = utils.SafeMove(options.OutputPath, imagePath)
-			logger.Debug("created marker image: ", imagePath)
+			logger.Ctx(ctx).Debug("created marker image: ", imagePath)
 		}
 	}
 
@@ -159,10 +159,10 @@ func (t *GenerateMarkersTask) generateMarker(videoFile *ffmpeg.VideoFile, scene
 			Time:       float64(seconds),
 		}
 		if err := encoder.Screenshot(*videoFile, screenshotOptions); err != nil {
-			logger.Errorf("[generator] failed to generate marker screenshot: %s", err)
+			logger.Ctx(ctx).Errorf("[generator] failed to generate marker screenshot: %s", err)
 		} else {
 			_ = utils.SafeMove(screenshotOptions.OutputPath, screenshotPath)
-			logger.Debug("created marker screenshot: ", screenshotPath)
+			logger.Ctx(ctx).Debug("created marker screenshot: ", screenshotPath)
 		}
 	}
 }
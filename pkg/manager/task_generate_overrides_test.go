@@ -0,0 +1,96 @@
+package manager
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeneratePreviewSceneOverrides(t *testing.T) {
+	segments := 2
+	duration := 1.0
+	width := 640
+	excludeStart := "0"
+	excludeEnd := "0"
+	options := models.GeneratePreviewOptionsInput{
+		PreviewSegments:        &segments,
+		PreviewSegmentDuration: &duration,
+		PreviewWidth:           &width,
+		PreviewExcludeStart:    &excludeStart,
+		PreviewExcludeEnd:      &excludeEnd,
+	}
+
+	overridden := models.Scene{
+		ID:                     1,
+		PreviewSegments:        sql.NullInt64{Int64: 4, Valid: true},
+		PreviewSegmentDuration: sql.NullFloat64{Float64: 2, Valid: true},
+		PreviewExcludeStart:    sql.NullString{String: "10%", Valid: true},
+	}
+	global := models.Scene{ID: 2}
+
+	chunkOptions := func(s models.Scene) []ffmpeg.ScenePreviewChunkOptions {
+		task := &GeneratePreviewTask{
+			Scene:   s,
+			Options: options,
+		}
+		g := &PreviewGenerator{
+			Info: &GeneratorInfo{
+				VideoFile: ffmpeg.VideoFile{Duration: 100},
+			},
+		}
+		task.configure(g)
+		return g.chunkOptions()
+	}
+
+	assert.Equal(t, []ffmpeg.ScenePreviewChunkOptions{
+		{StartTime: 10, Duration: 2, Width: 640},
+		{StartTime: 32.5, Duration: 2, Width: 640},
+		{StartTime: 55, Duration: 2, Width: 640},
+		{StartTime: 77.5, Duration: 2, Width: 640},
+	}, chunkOptions(overridden))
+
+	// scenes without overrides use the options of the job
+	assert.Equal(t, []ffmpeg.ScenePreviewChunkOptions{
+		{StartTime: 0, Duration: 1, Width: 640},
+		{StartTime: 50, Duration: 1, Width: 640},
+	}, chunkOptions(global))
+
+	// the options of the job are not changed by the override
+	assert.Equal(t, 2, *options.PreviewSegments)
+}
+
+func TestGenerateSpriteSceneOverrides(t *testing.T) {
+	c := config.GetInstance()
+	old := c.GetSpriteCount()
+	c.Set(config.SpriteCount, 10)
+	t.Cleanup(func() {
+		c.Set(config.SpriteCount, old)
+	})
+
+	overridden := &GenerateSpriteTask{
+		Scene: models.Scene{ID: 1, SpriteCount: sql.NullInt64{Int64: 40, Valid: true}},
+	}
+	global := &GenerateSpriteTask{
+		Scene: models.Scene{ID: 2},
+	}
+
+	stepSize := func(count int) float64 {
+		g := &SpriteGenerator{
+			Info: &GeneratorInfo{
+				ChunkCount: count,
+				VideoFile:  ffmpeg.VideoFile{Duration: 400},
+			},
+		}
+		return g.stepSize()
+	}
+
+	assert.Equal(t, 40, overridden.spriteCount())
+	assert.Equal(t, 10, global.spriteCount())
+
+	// screenshots are taken at a shorter interval for the overridden scene
+	assert.Less(t, stepSize(overridden.spriteCount()), stepSize(global.spriteCount()))
+}
@@ -25,10 +25,10 @@ func (t *GeneratePhashTask) Start(ctx context.Context) {
 		return
 	}
 
-	ffprobe := instance.FFProbe
+	ffprobe := instance.FFProbe()
 	videoFile, err := ffprobe.NewVideoFile(t.Scene.Path, false)
 	if err != nil {
-		logger.Errorf("error reading video file: %s", err.Error())
+		logger.Ctx(ctx).Errorf("error reading video file: %s", err.Error())
 		return
 	}
 
@@ -36,12 +36,12 @@ func (t *GeneratePhashTask) Start(ctx context.Context) {
 	generator, err := NewPhashGenerator(*videoFile, sceneHash)
 
 	if err != nil {
-		logger.Errorf("error creating phash generator: %s", err.Error())
+		logger.Ctx(ctx).Errorf("error creating phash generator: %s", err.Error())
 		return
 	}
 	hash, err := generator.Generate()
 	if err != nil {
-		logger.Errorf("error generating phash: %s", err.Error())
+		logger.Ctx(ctx).Errorf("error generating phash: %s", err.Error())
 		return
 	}
 
@@ -55,7 +55,7 @@ func (t *GeneratePhashTask) Start(ctx context.Context) {
 		_, err := qb.Update(scenePartial)
 		return err
 	}); err != nil {
-		logger.Error(err.Error())
+		logger.Ctx(ctx).Error(err.Error())
 	}
 }
 
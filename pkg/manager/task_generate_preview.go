@@ -33,10 +33,10 @@ func (t *GeneratePreviewTask) Start(ctx context.Context) {
 		return
 	}
 
-	ffprobe := instance.FFProbe
+	ffprobe := instance.FFProbe()
 	videoFile, err := ffprobe.NewVideoFile(t.Scene.Path, false)
 	if err != nil {
-		logger.Errorf("error reading video file: %s", err.Error())
+		logger.Ctx(ctx).Errorf("error reading video file: %s", err.Error())
 		return
 	}
 
@@ -44,24 +44,57 @@ func (t *GeneratePreviewTask) Start(ctx context.Context) {
 	generator, err := NewPreviewGenerator(*videoFile, videoChecksum, videoFilename, imageFilename, instance.Paths.Generated.Screenshots, generateVideo, t.ImagePreview, t.Options.PreviewPreset.String())
 
 	if err != nil {
-		logger.Errorf("error creating preview generator: %s", err.Error())
+		logger.Ctx(ctx).Errorf("error creating preview generator: %s", err.Error())
 		return
 	}
 	generator.Overwrite = t.Overwrite
 
-	// set the preview generation configuration from the global config
-	generator.Info.ChunkCount = *t.Options.PreviewSegments
-	generator.Info.ChunkDuration = *t.Options.PreviewSegmentDuration
-	generator.Info.ExcludeStart = *t.Options.PreviewExcludeStart
-	generator.Info.ExcludeEnd = *t.Options.PreviewExcludeEnd
-	generator.Info.Audio = config.GetInstance().GetPreviewAudio()
+	t.configure(generator)
 
 	if err := generator.Generate(); err != nil {
-		logger.Errorf("error generating preview: %s", err.Error())
+		logger.Ctx(ctx).Errorf("error generating preview: %s", err.Error())
 		return
 	}
 }
 
+// configure sets the preview generation configuration from the options of
+// the task, overridden by the generate overrides of the scene.
+func (t *GeneratePreviewTask) configure(generator *PreviewGenerator) {
+	options := t.sceneOptions()
+	generator.Info.ChunkCount = *options.PreviewSegments
+	generator.Info.ChunkDuration = *options.PreviewSegmentDuration
+	generator.Width = *options.PreviewWidth
+	generator.Info.ExcludeStart = *options.PreviewExcludeStart
+	generator.Info.ExcludeEnd = *options.PreviewExcludeEnd
+	generator.Info.Audio = config.GetInstance().GetPreviewAudio()
+}
+
+// sceneOptions returns the options of the task with the generate overrides
+// of the scene applied.
+func (t *GeneratePreviewTask) sceneOptions() models.GeneratePreviewOptionsInput {
+	ret := t.Options
+	s := t.Scene
+
+	if s.PreviewSegments.Valid {
+		segments := int(s.PreviewSegments.Int64)
+		ret.PreviewSegments = &segments
+	}
+	if s.PreviewSegmentDuration.Valid {
+		duration := s.PreviewSegmentDuration.Float64
+		ret.PreviewSegmentDuration = &duration
+	}
+	if s.PreviewExcludeStart.Valid {
+		excludeStart := s.PreviewExcludeStart.String
+		ret.PreviewExcludeStart = &excludeStart
+	}
+	if s.PreviewExcludeEnd.Valid {
+		excludeEnd := s.PreviewExcludeEnd.String
+		ret.PreviewExcludeEnd = &excludeEnd
+	}
+
+	return ret
+}
+
 func (t GeneratePreviewTask) required() bool {
 	sceneHash := t.Scene.GetHash(t.fileNamingAlgorithm)
 	videoExists := t.doesVideoPreviewExist(sceneHash)
@@ -7,6 +7,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/stashapp/stash/pkg/ffmpeg"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/scene"
@@ -17,45 +18,57 @@ type GenerateScreenshotTask struct {
 	ScreenshotAt        *float64
 	fileNamingAlgorithm models.HashAlgorithm
 	txnManager          models.TransactionManager
+
+	// format is the image format of the screenshot. JPEG if not set.
+	format ffmpeg.ImageFormat
+	// quality is the quality of the screenshot from 1 to 100, or zero for
+	// the default quality of the format
+	quality int
 }
 
 func (t *GenerateScreenshotTask) Start(ctx context.Context) {
 	scenePath := t.Scene.Path
-	ffprobe := instance.FFProbe
+	ffprobe := instance.FFProbe()
 	probeResult, err := ffprobe.NewVideoFile(scenePath, false)
 
 	if err != nil {
-		logger.Error(err.Error())
+		logger.Ctx(ctx).Error(err.Error())
 		return
 	}
 
 	var at float64
 	if t.ScreenshotAt == nil {
-		at = float64(probeResult.Duration) * 0.2
+		at = screenshotPosition().SceneTime(&t.Scene, probeResult.Duration)
 	} else {
 		at = *t.ScreenshotAt
 	}
 
+	format := t.format
+	if format == "" {
+		format = ffmpeg.ImageFormatJpeg
+	}
+
 	checksum := t.Scene.GetHash(t.fileNamingAlgorithm)
-	normalPath := instance.Paths.Scene.GetScreenshotPath(checksum)
+	normalPath := instance.Paths.Scene.GetScreenshotPathForExtension(checksum, format.Extension())
+	thumbPath := instance.Paths.Scene.GetThumbnailScreenshotPathForExtension(checksum, format.Extension())
 
-	// we'll generate the screenshot, grab the generated data and set it
-	// in the database. We'll use SetSceneScreenshot to set the data
-	// which also generates the thumbnail
+	// we'll generate the screenshot and thumbnail, grab the generated data
+	// and set it in the database
 
-	logger.Debugf("Creating screenshot for %s", scenePath)
-	makeScreenshot(*probeResult, normalPath, 2, probeResult.Width, at)
+	logger.Ctx(ctx).Debugf("Creating screenshot for %s", scenePath)
+	makeScreenshot(*probeResult, normalPath, format, t.quality, probeResult.Width, at)
+	makeScreenshot(*probeResult, thumbPath, format, t.quality, 320, at)
 
 	f, err := os.Open(normalPath)
 	if err != nil {
-		logger.Errorf("Error reading screenshot: %s", err.Error())
+		logger.Ctx(ctx).Errorf("Error reading screenshot: %s", err.Error())
 		return
 	}
 	defer f.Close()
 
 	coverImageData, err := io.ReadAll(f)
 	if err != nil {
-		logger.Errorf("Error reading screenshot: %s", err.Error())
+		logger.Ctx(ctx).Errorf("Error reading screenshot: %s", err.Error())
 		return
 	}
 
@@ -67,8 +80,9 @@ func (t *GenerateScreenshotTask) Start(ctx context.Context) {
 			UpdatedAt: &models.SQLiteTimestamp{Timestamp: updatedTime},
 		}
 
-		if err := scene.SetScreenshot(instance.Paths, checksum, coverImageData); err != nil {
-			return fmt.Errorf("error writing screenshot: %v", err)
+		// remove the screenshots in other formats
+		if err := scene.RemoveScreenshots(instance.Paths, checksum, normalPath, thumbPath); err != nil {
+			return fmt.Errorf("error removing screenshots: %v", err)
 		}
 
 		// update the scene cover table
@@ -84,6 +98,6 @@ func (t *GenerateScreenshotTask) Start(ctx context.Context) {
 
 		return nil
 	}); err != nil {
-		logger.Error(err.Error())
+		logger.Ctx(ctx).Error(err.Error())
 	}
 }
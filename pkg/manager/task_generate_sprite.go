@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
 )
@@ -24,30 +25,41 @@ func (t *GenerateSpriteTask) Start(ctx context.Context) {
 		return
 	}
 
-	ffprobe := instance.FFProbe
+	ffprobe := instance.FFProbe()
 	videoFile, err := ffprobe.NewVideoFile(t.Scene.Path, false)
 	if err != nil {
-		logger.Errorf("error reading video file: %s", err.Error())
+		logger.Ctx(ctx).Errorf("error reading video file: %s", err.Error())
 		return
 	}
 
 	sceneHash := t.Scene.GetHash(t.fileNamingAlgorithm)
 	imagePath := instance.Paths.Scene.GetSpriteImageFilePath(sceneHash)
 	vttPath := instance.Paths.Scene.GetSpriteVttFilePath(sceneHash)
-	generator, err := NewSpriteGenerator(*videoFile, sceneHash, imagePath, vttPath, 9, 9)
+	c := config.GetInstance()
+	generator, err := NewSpriteGenerator(*videoFile, sceneHash, imagePath, vttPath, t.spriteCount(), c.GetSpriteColumns())
 
 	if err != nil {
-		logger.Errorf("error creating sprite generator: %s", err.Error())
+		logger.Ctx(ctx).Errorf("error creating sprite generator: %s", err.Error())
 		return
 	}
 	generator.Overwrite = t.Overwrite
+	generator.ThumbnailWidth = c.GetSpriteWidth()
 
 	if err := generator.Generate(); err != nil {
-		logger.Errorf("error generating sprite: %s", err.Error())
+		logger.Ctx(ctx).Errorf("error generating sprite: %s", err.Error())
 		return
 	}
 }
 
+// spriteCount returns the number of screenshots in the sprite of the scene,
+// falling back to the configured number if the scene does not override it.
+func (t *GenerateSpriteTask) spriteCount() int {
+	if t.Scene.SpriteCount.Valid {
+		return int(t.Scene.SpriteCount.Int64)
+	}
+	return config.GetInstance().GetSpriteCount()
+}
+
 // required returns true if the sprite needs to be generated
 func (t GenerateSpriteTask) required() bool {
 	sceneHash := t.Scene.GetHash(t.fileNamingAlgorithm)
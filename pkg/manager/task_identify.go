@@ -25,6 +25,9 @@ type IdentifyJob struct {
 
 	stashBoxes models.StashBoxes
 	progress   *job.Progress
+
+	// sources overrides the sources in the input if set
+	sources []identify.ScraperSource
 }
 
 func CreateIdentifyJob(input models.IdentifyMetadataInput) *IdentifyJob {
@@ -39,15 +42,19 @@ func CreateIdentifyJob(input models.IdentifyMetadataInput) *IdentifyJob {
 func (j *IdentifyJob) Execute(ctx context.Context, progress *job.Progress) {
 	j.progress = progress
 
-	// if no sources provided - just return
-	if len(j.input.Sources) == 0 {
-		return
-	}
+	sources := j.sources
+	if sources == nil {
+		// if no sources provided - just return
+		if len(j.input.Sources) == 0 {
+			return
+		}
 
-	sources, err := j.getSources()
-	if err != nil {
-		logger.Error(err)
-		return
+		var err error
+		sources, err = j.getSources()
+		if err != nil {
+			logger.Error(err)
+			return
+		}
 	}
 
 	// if scene ids provided, use those
@@ -101,10 +108,9 @@ func (j *IdentifyJob) identifyAllScenes(ctx context.Context, r models.ReaderRepo
 
 	// get the count
 	pp := 0
-	findFilter.PerPage = &pp
 	countResult, err := r.Scene().Query(models.SceneQueryOptions{
 		QueryOptions: models.QueryOptions{
-			FindFilter: findFilter,
+			FindFilter: &models.FindFilterType{PerPage: &pp},
 			Count:      true,
 		},
 		SceneFilter: sceneFilter,
@@ -115,11 +121,7 @@ func (j *IdentifyJob) identifyAllScenes(ctx context.Context, r models.ReaderRepo
 
 	j.progress.SetTotal(countResult.Count)
 
-	return scene.BatchProcess(ctx, r.Scene(), sceneFilter, findFilter, func(scene *models.Scene) error {
-		if job.IsCancelled(ctx) {
-			return nil
-		}
-
+	return scene.ForEach(ctx, r.Scene(), sceneFilter, findFilter, func(scene *models.Scene) error {
 		j.identifyScene(ctx, scene, sources)
 		return nil
 	})
@@ -135,14 +137,21 @@ func (j *IdentifyJob) identifyScene(ctx context.Context, s *models.Scene, source
 		task := identify.SceneIdentifier{
 			DefaultOptions: j.input.Options,
 			Sources:        sources,
+			TitleThreshold: identify.DefaultTitleThreshold,
 			ScreenshotSetter: &scene.PathsScreenshotSetter{
 				Paths:               instance.Paths,
 				FileNamingAlgorithm: instance.Config.GetVideoFileNamingAlgorithm(),
 			},
 			SceneUpdatePostHookExecutor: j.postHookExecutor,
 		}
+		if j.input.MatchStrategy != nil {
+			task.MatchStrategy = *j.input.MatchStrategy
+		}
+		if j.input.TitleThreshold != nil {
+			task.TitleThreshold = *j.input.TitleThreshold
+		}
 
-		taskError = task.Identify(ctx, j.txnManager, s)
+		_, taskError = task.Identify(ctx, j.txnManager, s)
 	})
 
 	if taskError != nil {
@@ -249,3 +258,33 @@ func (s scraperSource) ScrapeScene(ctx context.Context, sceneID int) (*models.Sc
 func (s scraperSource) String() string {
 	return fmt.Sprintf("scraper %s", s.scraperID)
 }
+
+// mergePolicyFields are the scene fields set by jobs that apply a single
+// merge policy to scraped metadata.
+var mergePolicyFields = []string{
+	"title",
+	"date",
+	"details",
+	"url",
+	"studio",
+	"performers",
+	"tags",
+}
+
+// mergePolicyOptions returns the identify options that apply the merge
+// policy to all of mergePolicyFields.
+func mergePolicyOptions(mergePolicy models.IdentifyFieldStrategy) *models.IdentifyMetadataOptionsInput {
+	var fieldOptions []*models.IdentifyFieldOptionsInput
+	for _, f := range mergePolicyFields {
+		fieldOptions = append(fieldOptions, &models.IdentifyFieldOptionsInput{
+			Field:    f,
+			Strategy: mergePolicy,
+		})
+	}
+
+	setCoverImage := false
+	return &models.IdentifyMetadataOptionsInput{
+		FieldOptions:  fieldOptions,
+		SetCoverImage: &setCoverImage,
+	}
+}
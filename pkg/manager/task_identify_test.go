@@ -0,0 +1,22 @@
+package manager
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePolicyOptions(t *testing.T) {
+	for _, policy := range models.AllIdentifyFieldStrategy {
+		got := mergePolicyOptions(policy)
+
+		assert.False(t, *got.SetCoverImage)
+		assert.Len(t, got.FieldOptions, len(mergePolicyFields))
+		for i, o := range got.FieldOptions {
+			assert.Equal(t, mergePolicyFields[i], o.Field)
+			assert.Equal(t, policy, o.Strategy)
+			assert.Nil(t, o.CreateMissing)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package manager
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// OrganizeJob moves scene files to paths rendered from a template, and
+// updates the scene paths.
+type OrganizeJob struct {
+	txnManager     models.TransactionManager
+	sceneIDs       []int
+	organizer      scene.Organizer
+	fileNamingAlgo models.HashAlgorithm
+}
+
+// newSceneOrganizer returns the organizer for the input, using the
+// configured template if the input does not set one, and the ids of the
+// scenes to organize.
+func newSceneOrganizer(input models.ScenesOrganizeInput) (*scene.Organizer, []int, error) {
+	sceneIDs, err := utils.StringSliceToIntSlice(input.Ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInput, err)
+	}
+
+	c := instance.Config
+
+	template := c.GetOrganizeTemplate()
+	if input.Template != nil {
+		template = *input.Template
+	}
+	if err := scene.ValidateOrganizeTemplate(template); err != nil {
+		return nil, nil, fmt.Errorf("%w: invalid template: %v", ErrInput, err)
+	}
+
+	var libraryPaths []string
+	for _, p := range c.GetStashPaths() {
+		libraryPaths = append(libraryPaths, p.Path)
+	}
+
+	collision := models.OrganizeCollisionStrategySkip
+	if input.Collision != nil && input.Collision.IsValid() {
+		collision = *input.Collision
+	}
+
+	return &scene.Organizer{
+		Template:     template,
+		LibraryPaths: libraryPaths,
+		Collision:    collision,
+		Mover:        file.NewMover(),
+	}, sceneIDs, nil
+}
+
+// CreateOrganizeJob returns a job organizing the scenes of the input.
+func CreateOrganizeJob(input models.ScenesOrganizeInput) (*OrganizeJob, error) {
+	organizer, sceneIDs, err := newSceneOrganizer(input)
+	if err != nil {
+		return nil, err
+	}
+
+	return &OrganizeJob{
+		txnManager:     instance.TxnManager,
+		sceneIDs:       sceneIDs,
+		organizer:      *organizer,
+		fileNamingAlgo: instance.Config.GetVideoFileNamingAlgorithm(),
+	}, nil
+}
+
+// OrganizePreview returns the paths that the scene files of the input would
+// be moved to, without moving them.
+func OrganizePreview(ctx context.Context, txnManager models.TransactionManager, input models.ScenesOrganizeInput) ([]*models.SceneOrganizeResult, error) {
+	organizer, sceneIDs, err := newSceneOrganizer(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*models.SceneOrganizeResult
+	if err := txnManager.WithReadTxn(ctx, func(r models.ReaderRepository) error {
+		for _, id := range sceneIDs {
+			result := &models.SceneOrganizeResult{
+				SceneID: strconv.Itoa(id),
+			}
+			ret = append(ret, result)
+
+			s, err := r.Scene().Find(id)
+			if err != nil {
+				return err
+			}
+			if s == nil {
+				errStr := fmt.Sprintf("scene with id %d not found", id)
+				result.Error = &errStr
+				continue
+			}
+			result.OldPath = s.Path
+
+			dst, err := organizer.Destination(r.Scene(), r.Studio(), s)
+			if err != nil {
+				errStr := err.Error()
+				result.Error = &errStr
+				continue
+			}
+			result.NewPath = &dst
+		}
+
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (j *OrganizeJob) Execute(ctx context.Context, progress *job.Progress) {
+	progress.SetTotal(len(j.sceneIDs))
+
+	var results []*models.SceneOrganizeResult
+	for _, id := range j.sceneIDs {
+		if job.IsCancelled(ctx) {
+			logger.Info("Stopping due to user request")
+			break
+		}
+
+		progress.ExecuteTask(fmt.Sprintf("Organizing scene %d", id), func() {
+			results = append(results, j.organizeScene(ctx, id))
+		})
+		progress.Increment()
+	}
+
+	progress.SetResult(results)
+}
+
+// organizeScene moves the files of the scene to their destination. The
+// files are moved outside of a transaction, so that the database is not
+// locked while files are copied between volumes. Only the path update is
+// made in a transaction, and the files are restored if it fails.
+func (j *OrganizeJob) organizeScene(ctx context.Context, id int) *models.SceneOrganizeResult {
+	result := &models.SceneOrganizeResult{
+		SceneID: strconv.Itoa(id),
+	}
+
+	fail := func(err error) *models.SceneOrganizeResult {
+		logger.Warnf("Error organizing scene %d: %v", id, err)
+		errStr := err.Error()
+		result.Error = &errStr
+		return result
+	}
+
+	// each scene has its own mover, so that an error does not restore the
+	// files of other scenes
+	organizer := j.organizer
+	organizer.Mover = file.NewMover()
+
+	var s *models.Scene
+	var dst string
+	if err := j.txnManager.WithReadTxn(ctx, func(r models.ReaderRepository) error {
+		var err error
+		s, err = r.Scene().Find(id)
+		if err != nil {
+			return err
+		}
+		if s == nil {
+			return fmt.Errorf("scene with id %d not found", id)
+		}
+		result.OldPath = s.Path
+
+		dst, err = organizer.Destination(r.Scene(), r.Studio(), s)
+		return err
+	}); err != nil {
+		return fail(err)
+	}
+
+	result.NewPath = &dst
+	if dst == s.Path {
+		return result
+	}
+
+	// kill any running encoders
+	KillRunningStreams(s, j.fileNamingAlgo)
+
+	if err := organizer.MoveFiles(s.Path, dst); err != nil {
+		organizer.Mover.Rollback()
+		return fail(err)
+	}
+
+	if err := j.txnManager.WithTxn(ctx, func(r models.Repository) error {
+		return organizer.UpdatePath(r.Scene(), s, dst)
+	}); err != nil {
+		organizer.Mover.Rollback()
+		return fail(err)
+	}
+
+	organizer.Mover.Commit()
+
+	result.Moved = true
+	logger.Infof("Moved %s to %s", s.Path, dst)
+
+	return result
+}
@@ -0,0 +1,60 @@
+package manager
+
+import (
+	"context"
+	"time"
+
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/paths"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene"
+)
+
+// purgeDeletedJob permanently destroys scenes that have been in the trash
+// for longer than the retention period.
+type purgeDeletedJob struct {
+	txnManager     models.TransactionManager
+	paths          *paths.Paths
+	fileNamingAlgo models.HashAlgorithm
+	retention      time.Duration
+}
+
+func (j *purgeDeletedJob) Execute(ctx context.Context, progress *job.Progress) {
+	before := time.Now().Add(-j.retention)
+
+	fileDeleter := &scene.FileDeleter{
+		Deleter:        *file.NewDeleter(),
+		FileNamingAlgo: j.fileNamingAlgo,
+		Paths:          j.paths,
+	}
+
+	var purged []*models.Scene
+	if err := j.txnManager.WithTxn(ctx, func(r models.Repository) error {
+		var err error
+		purged, err = scene.PurgeDeleted(r, fileDeleter, before)
+		return err
+	}); err != nil {
+		fileDeleter.Rollback()
+		logger.Errorf("error purging deleted scenes: %v", err)
+		return
+	}
+
+	fileDeleter.Commit()
+
+	logger.Infof("Purged %d deleted scenes", len(purged))
+}
+
+// PurgeDeletedScenes starts a job that permanently deletes scenes that
+// have been soft-deleted for longer than the configured retention period.
+func (s *singleton) PurgeDeletedScenes(ctx context.Context) int {
+	j := &purgeDeletedJob{
+		txnManager:     s.TxnManager,
+		paths:          s.Paths,
+		fileNamingAlgo: s.Config.GetVideoFileNamingAlgorithm(),
+		retention:      time.Duration(s.Config.GetSoftDeleteRetentionDays()) * 24 * time.Hour,
+	}
+
+	return s.JobManager.Add(ctx, "Purging deleted scenes...", j)
+}
@@ -0,0 +1,43 @@
+package manager
+
+import (
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/identify"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scraper/remotestash"
+)
+
+// CreateRemoteStashSyncJob returns a job that syncs scene metadata from the
+// configured remote stash instance. Scenes are updated using the merge
+// policy in the input, or the configured policy if not set.
+func CreateRemoteStashSyncJob(input models.RemoteStashSyncInput) (*IdentifyJob, error) {
+	c := instance.Config
+	endpoint := c.GetRemoteStashEndpoint()
+	if endpoint == "" {
+		return nil, fmt.Errorf("%w: remote stash endpoint is not configured", ErrInput)
+	}
+
+	mergePolicy := c.GetRemoteStashMergePolicy()
+	if input.MergePolicy != nil && input.MergePolicy.IsValid() {
+		mergePolicy = *input.MergePolicy
+	}
+
+	client := remotestash.NewClient(endpoint, c.GetRemoteStashAPIKey(), nil, instance.TxnManager)
+
+	return &IdentifyJob{
+		txnManager:       instance.TxnManager,
+		postHookExecutor: instance.PluginCache,
+		input: models.IdentifyMetadataInput{
+			Options:  mergePolicyOptions(mergePolicy),
+			SceneIDs: input.SceneIDs,
+			Paths:    input.Paths,
+		},
+		sources: []identify.ScraperSource{
+			{
+				Name:    "remote stash: " + endpoint,
+				Scraper: client,
+			},
+		},
+	}, nil
+}
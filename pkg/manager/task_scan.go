@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"time"
 
 	"github.com/remeh/sizedwaitgroup"
@@ -21,20 +22,41 @@ import (
 const scanQueueSize = 200000
 
 type ScanJob struct {
-	txnManager    models.TransactionManager
-	input         models.ScanMetadataInput
-	subscriptions *subscriptionManager
+	txnManager  models.TransactionManager
+	input       models.ScanMetadataInput
+	events      *eventBus
+	pathMonitor *StashPathMonitor
 }
 
 type scanFile struct {
 	path            string
 	info            os.FileInfo
 	caseSensitiveFs bool
+	hashAlgorithm   *models.HashAlgorithm
+	seq             int
+}
+
+// calculateMD5 returns true if MD5 checksums should be calculated for video
+// files in a library using the provided hash algorithm. Uses the global
+// setting if the library does not set an algorithm. MD5 is not calculated
+// for files larger than fullHashMaxSize, if positive, since reading the
+// whole of such files is impractical. The algorithm used is recorded in the
+// hash algorithm of the scene.
+func calculateMD5(hashAlgorithm *models.HashAlgorithm, defaultCalculateMD5 bool, size int64, fullHashMaxSize int64) bool {
+	if fullHashMaxSize > 0 && size > fullHashMaxSize {
+		return false
+	}
+
+	if hashAlgorithm == nil || !hashAlgorithm.IsValid() {
+		return defaultCalculateMD5
+	}
+
+	return *hashAlgorithm == models.HashAlgorithmMd5
 }
 
 func (j *ScanJob) Execute(ctx context.Context, progress *job.Progress) {
 	input := j.input
-	paths := getScanPaths(input.Paths)
+	paths := j.onlinePaths(getScanPaths(input.Paths))
 
 	if job.IsCancelled(ctx) {
 		logger.Info("Stopping due to user request")
@@ -47,26 +69,54 @@ func (j *ScanJob) Execute(ctx context.Context, progress *job.Progress) {
 
 	logger.Infof("Scan started with %d parallel tasks", parallelTasks)
 
+	checkpointKey := scanCheckpointKey(paths)
+	var resumeFrom string
+	if !utils.IsTrue(input.ForceFullRescan) {
+		resumeFrom = j.getScanCheckpoint(ctx, checkpointKey)
+		if resumeFrom != "" {
+			logger.Infof("Resuming scan after %s", resumeFrom)
+		}
+	}
+
+	var incremental map[string]*incrementalScan
+	if utils.IsTrue(input.Incremental) {
+		incremental = j.prepareIncrementalScans(ctx, paths)
+	}
+
+	tracker := newScanCheckpointTracker(scanCheckpointInterval, func(lastPath string) {
+		j.saveScanCheckpoint(checkpointKey, lastPath)
+	})
+
+	totals := &scanTotal{progress: progress}
+
 	fileQueue := make(chan scanFile, scanQueueSize)
-	go func() {
-		total, newFiles := j.queueFiles(ctx, paths, fileQueue, parallelTasks)
+	progress.Go(func() {
+		total, newFiles := j.queueFiles(ctx, progress, paths, fileQueue, parallelTasks, tracker, resumeFrom, incremental)
 
 		if !job.IsCancelled(ctx) {
-			progress.SetTotal(total)
+			totals.setFiles(total)
 			logger.Infof("Finished counting files. Total files to scan: %d, %d new files found", total, newFiles)
 		}
-	}()
+	})
 
 	wg := sizedwaitgroup.New(parallelTasks)
 
 	fileNamingAlgo := config.GetVideoFileNamingAlgorithm()
-	calculateMD5 := config.IsCalculateMD5()
+	defaultCalculateMD5 := config.IsCalculateMD5()
+	fullHashMaxSize := config.GetFullHashMaxSize()
+	if err := config.ValidateFullHashMaxSize(); err != nil {
+		// generated files are named by MD5, so it is always calculated
+		logger.Warnf("%v: ignoring the limit", err)
+		fullHashMaxSize = 0
+	}
 
 	var err error
 
 	var galleries []string
 
 	mutexManager := utils.NewMutexManager()
+	changes := newScanChanges()
+	scheduler := newScanGenerateScheduler(input.ScanGenerateMode, input.ScanGenerateRatio)
 
 	for f := range fileQueue {
 		if job.IsCancelled(ctx) {
@@ -88,7 +138,7 @@ func (j *ScanJob) Execute(ctx context.Context, progress *job.Progress) {
 			UseFileMetadata:      utils.IsTrue(input.UseFileMetadata),
 			StripFileExtension:   utils.IsTrue(input.StripFileExtension),
 			fileNamingAlgorithm:  fileNamingAlgo,
-			calculateMD5:         calculateMD5,
+			calculateMD5:         calculateMD5(f.hashAlgorithm, defaultCalculateMD5, f.info.Size(), fullHashMaxSize),
 			GeneratePreview:      utils.IsTrue(input.ScanGeneratePreviews),
 			GenerateImagePreview: utils.IsTrue(input.ScanGenerateImagePreviews),
 			GenerateSprite:       utils.IsTrue(input.ScanGenerateSprites),
@@ -98,17 +148,66 @@ func (j *ScanJob) Execute(ctx context.Context, progress *job.Progress) {
 			CaseSensitiveFs:      f.caseSensitiveFs,
 			ctx:                  ctx,
 			mutexManager:         mutexManager,
+			changes:              changes,
 		}
 
-		go func() {
-			task.Start(ctx)
-			wg.Done()
-			progress.Increment()
-		}()
+		seq := f.seq
+		progress.Go(func() {
+			defer wg.Done()
+
+			// files in zip galleries are scanned by the gallery's task, so
+			// the resources are acquired here rather than in Start
+			var generate []func()
+			if release, err := instance.Resources.Acquire(ctx, ScanResources); err == nil {
+				// the file is only marked done once it has been scanned and
+				// generated, so that it is scanned again when the scan is
+				// resumed
+				if scheduler.concurrent() {
+					task.Start(ctx)
+					tracker.done(seq)
+				} else {
+					work := task.generateWork(ctx, task.scan(ctx))
+					if work != nil {
+						totals.addGenerate()
+						generateScene := work
+						work = func() {
+							generateScene()
+							progress.Increment()
+							tracker.done(seq)
+						}
+					} else {
+						tracker.done(seq)
+					}
+					generate = scheduler.fileScanned(work)
+				}
+				release()
+
+				progress.Increment()
+			}
+
+			j.runGenerateWork(ctx, generate)
+		})
 	}
 
 	wg.Wait()
 
+	if remaining := scheduler.remaining(); len(remaining) > 0 && !job.IsCancelled(ctx) {
+		logger.Infof("Generating for %d scanned scenes", len(remaining))
+		for _, work := range remaining {
+			if job.IsCancelled(ctx) {
+				break
+			}
+
+			wg.Add()
+			work := work
+			progress.Go(func() {
+				defer wg.Done()
+				j.runGenerateWork(ctx, []func(){work})
+			})
+		}
+		wg.Wait()
+	}
+
 	if err := instance.Paths.Generated.EmptyTmpDir(); err != nil {
 		logger.Warnf("couldn't empty temporary directory: %v", err)
 	}
@@ -116,6 +215,9 @@ func (j *ScanJob) Execute(ctx context.Context, progress *job.Progress) {
 	elapsed := time.Since(start)
 	logger.Info(fmt.Sprintf("Scan finished (%s)", elapsed))
 
+	scanChanges := changes.model()
+	progress.SetResult(scanChanges)
+
 	if job.IsCancelled(ctx) {
 		logger.Info("Stopping due to user request")
 		return
@@ -125,6 +227,13 @@ func (j *ScanJob) Execute(ctx context.Context, progress *job.Progress) {
 		return
 	}
 
+	// scan completed, so the next scan should start from the beginning
+	j.clearScanCheckpoint(checkpointKey)
+
+	for path, s := range incremental {
+		j.saveScanSnapshot(path, s, start)
+	}
+
 	progress.ExecuteTask("Associating galleries", func() {
 		for _, path := range galleries {
 			wg.Add()
@@ -134,16 +243,36 @@ func (j *ScanJob) Execute(ctx context.Context, progress *job.Progress) {
 				UseFileMetadata: false,
 			}
 
-			go task.associateGallery(&wg)
+			progress.Go(func() {
+				task.associateGallery(&wg)
+			})
 			wg.Wait()
 		}
 		logger.Info("Finished gallery association")
 	})
 
-	j.subscriptions.notify()
+	progress.ExecuteTask("Resolving gallery covers", func() {
+		galleryIDs, err := scanGalleryIDs(ctx, j.txnManager, changes)
+		if err != nil {
+			logger.Errorf("Error finding scanned galleries: %v", err)
+			return
+		}
+
+		if err := resolveGalleryCovers(ctx, j.txnManager, config.GetGalleryCoverStrategy(), galleryIDs); err != nil {
+			logger.Errorf("Error resolving gallery covers: %v", err)
+		}
+	})
+
+	j.events.Publish(TopicScan, ScanEvent{
+		Changes: scanChanges,
+	})
 }
 
-func (j *ScanJob) queueFiles(ctx context.Context, paths []*models.StashConfig, scanQueue chan<- scanFile, parallelTasks int) (total int, newFiles int) {
+// queueFiles walks the provided paths and sends the files to scan to
+// scanQueue. Files at or before resumeFrom in walk order are skipped. Each
+// queued file is added to tracker. For library paths in incremental, only
+// the changed files are queued.
+func (j *ScanJob) queueFiles(ctx context.Context, progress *job.Progress, paths []*models.StashConfig, scanQueue chan<- scanFile, parallelTasks int, tracker *scanCheckpointTracker, resumeFrom string, incremental map[string]*incrementalScan) (total int, newFiles int) {
 	defer close(scanQueue)
 
 	var minModTime time.Time
@@ -153,13 +282,32 @@ func (j *ScanJob) queueFiles(ctx context.Context, paths []*models.StashConfig, s
 
 	wg := sizedwaitgroup.New(parallelTasks)
 
-	for _, sp := range paths {
-		csFs, er := utils.IsFsPathCaseSensitive(sp.Path)
-		if er != nil {
-			logger.Warnf("Cannot determine fs case sensitivity: %s", er.Error())
+	resumeIndex := scanResumePoint(paths, resumeFrom)
+
+	for i, sp := range paths {
+		// skip library paths that were completed before the checkpoint
+		if i < resumeIndex {
+			continue
+		}
+
+		// assume remote file systems are case sensitive
+		csFs := true
+		if !file.IsRemotePath(sp.Path) {
+			var er error
+			csFs, er = utils.IsFsPathCaseSensitive(sp.Path)
+			if er != nil {
+				logger.Warnf("Cannot determine fs case sensitivity: %s", er.Error())
+			}
+		}
+
+		walk := func(f filepath.WalkFunc) error {
+			return walkFilesToScan(sp, f)
+		}
+		if s := incremental[sp.Path]; s != nil && !s.full {
+			walk = s.walk
 		}
 
-		err := walkFilesToScan(sp, func(path string, info os.FileInfo, err error) error {
+		err := walk(func(path string, info os.FileInfo, err error) error {
 			// check stop
 			if job.IsCancelled(ctx) {
 				return context.Canceled
@@ -170,18 +318,26 @@ func (j *ScanJob) queueFiles(ctx context.Context, paths []*models.StashConfig, s
 				return nil
 			}
 
+			// skip files scanned before the checkpoint
+			if i == resumeIndex && !walkOrderLess(resumeFrom, path) {
+				return nil
+			}
+
+			seq := tracker.add(path)
 			wg.Add()
 
-			go func() {
+			progress.Go(func() {
 				defer wg.Done()
 
 				// #1756 - skip zero length files and directories
 				if info.IsDir() {
+					tracker.done(seq)
 					return
 				}
 
 				if info.Size() == 0 {
 					logger.Infof("Skipping zero-length file: %s", path)
+					tracker.done(seq)
 					return
 				}
 
@@ -194,8 +350,10 @@ func (j *ScanJob) queueFiles(ctx context.Context, paths []*models.StashConfig, s
 					path:            path,
 					info:            info,
 					caseSensitiveFs: csFs,
+					hashAlgorithm:   sp.HashAlgorithm,
+					seq:             seq,
 				}
-			}()
+			})
 
 			return nil
 		})
@@ -211,6 +369,23 @@ func (j *ScanJob) queueFiles(ctx context.Context, paths []*models.StashConfig, s
 	return
 }
 
+// onlinePaths returns the paths that are not in an offline stash path, so
+// that files in unreachable stash paths are not treated as removed.
+func (j *ScanJob) onlinePaths(paths []*models.StashConfig) []*models.StashConfig {
+	j.pathMonitor.Check()
+
+	var ret []*models.StashConfig
+	for _, sp := range paths {
+		if j.pathMonitor.IsOffline(sp.Path) {
+			logger.Warnf("Not scanning %s: stash path is offline", sp.Path)
+			continue
+		}
+		ret = append(ret, sp)
+	}
+
+	return ret
+}
+
 func (j *ScanJob) doesPathExist(path string) bool {
 	config := config.GetInstance()
 	vidExt := config.GetVideoExtensions()
@@ -264,9 +439,20 @@ type ScanTask struct {
 	CaseSensitiveFs      bool
 
 	mutexManager *utils.MutexManager
+	// changes records the objects changed by the scan, if not nil.
+	changes *scanChanges
 }
 
 func (t *ScanTask) Start(ctx context.Context) {
+	s := t.scan(ctx)
+	if work := t.generateWork(ctx, s); work != nil {
+		work()
+	}
+}
+
+// scan scans the file of the task, returning the scene if the file is a
+// video.
+func (t *ScanTask) scan(ctx context.Context) *models.Scene {
 	var s *models.Scene
 	path := t.file.Path()
 	t.progress.ExecuteTask("Scanning "+path, func() {
@@ -279,25 +465,37 @@ func (t *ScanTask) Start(ctx context.Context) {
 			t.scanImage()
 		}
 	})
+	return s
+}
 
-	if s == nil {
-		return
+// generateWork returns the work that generates for the scanned scene, or nil
+// if there is nothing to generate.
+func (t *ScanTask) generateWork(ctx context.Context, s *models.Scene) func() {
+	if s == nil || !(t.GenerateSprite || t.GeneratePhash || t.GeneratePreview) {
+		return nil
 	}
 
-	// Handle the case of a scene
+	return func() {
+		t.generate(ctx, s)
+	}
+}
+
+func (t *ScanTask) generate(ctx context.Context, s *models.Scene) {
+	path := t.file.Path()
 	iwg := sizedwaitgroup.New(2)
 
 	if t.GenerateSprite {
 		iwg.Add()
 
 		go t.progress.ExecuteTask(fmt.Sprintf("Generating sprites for %s", path), func() {
+			defer iwg.Done()
+
 			taskSprite := GenerateSpriteTask{
 				Scene:               *s,
 				Overwrite:           false,
 				fileNamingAlgorithm: t.fileNamingAlgorithm,
 			}
 			taskSprite.Start(ctx)
-			iwg.Done()
 		})
 	}
 
@@ -305,13 +503,14 @@ func (t *ScanTask) Start(ctx context.Context) {
 		iwg.Add()
 
 		go t.progress.ExecuteTask(fmt.Sprintf("Generating phash for %s", path), func() {
+			defer iwg.Done()
+
 			taskPhash := GeneratePhashTask{
 				Scene:               *s,
 				fileNamingAlgorithm: t.fileNamingAlgorithm,
 				txnManager:          t.TxnManager,
 			}
 			taskPhash.Start(ctx)
-			iwg.Done()
 		})
 	}
 
@@ -319,9 +518,12 @@ func (t *ScanTask) Start(ctx context.Context) {
 		iwg.Add()
 
 		go t.progress.ExecuteTask(fmt.Sprintf("Generating preview for %s", path), func() {
+			defer iwg.Done()
+
 			config := config.GetInstance()
 			var previewSegmentDuration = config.GetPreviewSegmentDuration()
 			var previewSegments = config.GetPreviewSegments()
+			var previewWidth = config.GetPreviewWidth()
 			var previewExcludeStart = config.GetPreviewExcludeStart()
 			var previewExcludeEnd = config.GetPreviewExcludeEnd()
 			var previewPresent = config.GetPreviewPreset()
@@ -330,6 +532,7 @@ func (t *ScanTask) Start(ctx context.Context) {
 			previewOptions := models.GeneratePreviewOptionsInput{
 				PreviewSegments:        &previewSegments,
 				PreviewSegmentDuration: &previewSegmentDuration,
+				PreviewWidth:           &previewWidth,
 				PreviewExcludeStart:    &previewExcludeStart,
 				PreviewExcludeEnd:      &previewExcludeEnd,
 				PreviewPreset:          &previewPresent,
@@ -343,61 +546,134 @@ func (t *ScanTask) Start(ctx context.Context) {
 				fileNamingAlgorithm: t.fileNamingAlgorithm,
 			}
 			taskPreview.Start(ctx)
-			iwg.Done()
 		})
 	}
 
 	iwg.Wait()
 }
 
-func walkFilesToScan(s *models.StashConfig, f filepath.WalkFunc) error {
+// runGenerateWork runs the generate work of scanned scenes, holding the scan
+// resources while it runs.
+func (j *ScanJob) runGenerateWork(ctx context.Context, work []func()) {
+	for _, w := range work {
+		if job.IsCancelled(ctx) {
+			return
+		}
+
+		release, err := instance.Resources.Acquire(ctx, ScanResources)
+		if err != nil {
+			return
+		}
+		w()
+		release()
+	}
+}
+
+// scanFilter determines which files and directories of a library path are
+// scanned.
+type scanFilter struct {
+	stash           *models.StashConfig
+	vidExt          []string
+	imgExt          []string
+	gExt            []string
+	excludeVidRegex []*regexp.Regexp
+	excludeImgRegex []*regexp.Regexp
+	generatedPath   string
+	// scanHidden is true if hidden and operating system files are scanned
+	scanHidden bool
+}
+
+func newScanFilter(s *models.StashConfig) *scanFilter {
 	config := config.GetInstance()
-	vidExt := config.GetVideoExtensions()
-	imgExt := config.GetImageExtensions()
-	gExt := config.GetGalleryExtensions()
-	excludeVidRegex := generateRegexps(config.GetExcludes())
-	excludeImgRegex := generateRegexps(config.GetImageExcludes())
+	return &scanFilter{
+		stash:           s,
+		vidExt:          config.GetVideoExtensions(),
+		imgExt:          config.GetImageExtensions(),
+		gExt:            config.GetGalleryExtensions(),
+		excludeVidRegex: generateRegexps(config.GetExcludes()),
+		excludeImgRegex: generateRegexps(config.GetImageExcludes()),
+		generatedPath:   config.GetGeneratedPath(),
+		scanHidden:      config.IsScanHiddenFiles(),
+	}
+}
+
+// skipHidden returns true if path is hidden or an operating system file,
+// and hidden files are not scanned. The library path itself is never
+// skipped, so that hidden libraries can be scanned.
+func (f *scanFilter) skipHidden(path string) bool {
+	return !f.scanHidden && path != f.stash.Path && file.IsHidden(path)
+}
+
+// skipDir returns true if the directory should not be scanned.
+func (f *scanFilter) skipDir(path string) bool {
+	// #1102 - ignore files in generated path
+	if utils.IsPathInDir(f.generatedPath, path) {
+		return true
+	}
+
+	if matchStashExclude(f.stash, path) || f.skipHidden(path) {
+		return true
+	}
 
+	// shortcut: skip the directory entirely if it matches both exclusion patterns
+	// add a trailing separator so that it correctly matches against patterns like path/.*
+	pathExcludeTest := path + string(filepath.Separator)
+	return (f.stash.ExcludeVideo || matchFileRegex(pathExcludeTest, f.excludeVidRegex)) && (f.stash.ExcludeImage || matchFileRegex(pathExcludeTest, f.excludeImgRegex))
+}
+
+// includeFile returns true if the file should be scanned.
+func (f *scanFilter) includeFile(path string) bool {
+	if matchStashExclude(f.stash, path) || f.skipHidden(path) {
+		return false
+	}
+
+	if !f.stash.ExcludeVideo && utils.MatchExtension(path, f.vidExt) && !matchFileRegex(path, f.excludeVidRegex) {
+		return true
+	}
+
+	if !f.stash.ExcludeImage {
+		if (utils.MatchExtension(path, f.imgExt) || utils.MatchExtension(path, f.gExt)) && !matchFileRegex(path, f.excludeImgRegex) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func walkFilesToScan(s *models.StashConfig, f filepath.WalkFunc) error {
 	// don't scan zip images directly
 	if file.IsZipPath(s.Path) {
 		logger.Warnf("Cannot rescan zip image %s. Rescan zip gallery instead.", s.Path)
 		return nil
 	}
 
-	generatedPath := config.GetGeneratedPath()
+	filter := newScanFilter(s)
+
+	walk := utils.SymWalk
+	if file.IsRemotePath(s.Path) {
+		walk = func(root string, fn filepath.WalkFunc) error {
+			return file.Walk(file.FileSystemFor(root), root, fn)
+		}
+	}
 
-	return utils.SymWalk(s.Path, func(path string, info os.FileInfo, err error) error {
+	return walk(s.Path, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			logger.Warnf("error scanning %s: %s", path, err.Error())
 			return nil
 		}
 
 		if info.IsDir() {
-			// #1102 - ignore files in generated path
-			if utils.IsPathInDir(generatedPath, path) {
-				return filepath.SkipDir
-			}
-
-			// shortcut: skip the directory entirely if it matches both exclusion patterns
-			// add a trailing separator so that it correctly matches against patterns like path/.*
-			pathExcludeTest := path + string(filepath.Separator)
-			if (s.ExcludeVideo || matchFileRegex(pathExcludeTest, excludeVidRegex)) && (s.ExcludeImage || matchFileRegex(pathExcludeTest, excludeImgRegex)) {
+			if filter.skipDir(path) {
 				return filepath.SkipDir
 			}
 
 			return nil
 		}
 
-		if !s.ExcludeVideo && utils.MatchExtension(path, vidExt) && !matchFileRegex(path, excludeVidRegex) {
+		if filter.includeFile(path) {
 			return f(path, info, err)
 		}
 
-		if !s.ExcludeImage {
-			if (utils.MatchExtension(path, imgExt) || utils.MatchExtension(path, gExt)) && !matchFileRegex(path, excludeImgRegex) {
-				return f(path, info, err)
-			}
-		}
-
 		return nil
 	})
 }
@@ -5,11 +5,13 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/remeh/sizedwaitgroup"
 	"github.com/stashapp/stash/pkg/file"
 	"github.com/stashapp/stash/pkg/gallery"
+	"github.com/stashapp/stash/pkg/job"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
@@ -49,6 +51,9 @@ func (t *ScanTask) scanGallery(ctx context.Context) {
 		PluginCache:        instance.PluginCache,
 		MutexManager:       t.mutexManager,
 	}
+	if t.changes != nil {
+		scanner.Changes = t.changes.galleries
+	}
 
 	var err error
 	if g != nil {
@@ -79,6 +84,8 @@ func (t *ScanTask) scanGallery(ctx context.Context) {
 
 // associates a gallery to a scene with the same basename
 func (t *ScanTask) associateGallery(wg *sizedwaitgroup.SizedWaitGroup) {
+	defer wg.Done()
+
 	path := t.file.Path()
 	if err := t.TxnManager.WithTxn(context.TODO(), func(r models.Repository) error {
 		qb := r.Gallery()
@@ -126,11 +133,123 @@ func (t *ScanTask) associateGallery(wg *sizedwaitgroup.SizedWaitGroup) {
 				}
 			}
 		}
+
 		return nil
 	}); err != nil {
 		logger.Error(err.Error())
 	}
-	wg.Done()
+}
+
+// galleryCoverBatchSize is the number of galleries of which the covers are
+// resolved in each transaction.
+const galleryCoverBatchSize = 100
+
+// scanGalleryIDs returns the ids of the galleries added or updated by a
+// scan, and of the galleries of the images added or updated by the scan.
+func scanGalleryIDs(ctx context.Context, txnManager models.TransactionManager, changes *scanChanges) ([]int, error) {
+	ids := make(map[int]struct{})
+	for _, id := range changes.galleries.changedIDs() {
+		ids[id] = struct{}{}
+	}
+
+	imageIDs := changes.images.changedIDs()
+	for start := 0; start < len(imageIDs); start += galleryCoverBatchSize {
+		end := start + galleryCoverBatchSize
+		if end > len(imageIDs) {
+			end = len(imageIDs)
+		}
+
+		if err := txnManager.WithReadTxn(ctx, func(r models.ReaderRepository) error {
+			for _, imageID := range imageIDs[start:end] {
+				galleries, err := r.Gallery().FindByImageID(imageID)
+				if err != nil {
+					return err
+				}
+				for _, g := range galleries {
+					ids[g.ID] = struct{}{}
+				}
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	ret := make([]int, 0, len(ids))
+	for id := range ids {
+		ret = append(ret, id)
+	}
+	sort.Ints(ret)
+
+	return ret, nil
+}
+
+// resolveGalleryCovers resolves the covers of the galleries with the
+// provided ids using the provided strategy. Covers are selected in read
+// transactions, and only changed covers are written, in batches, so that
+// the database is not locked for long. Galleries that no longer exist are
+// skipped.
+func resolveGalleryCovers(ctx context.Context, txnManager models.TransactionManager, strategy models.GalleryCoverStrategy, galleryIDs []int) error {
+	for start := 0; start < len(galleryIDs); start += galleryCoverBatchSize {
+		if job.IsCancelled(ctx) {
+			return nil
+		}
+
+		end := start + galleryCoverBatchSize
+		if end > len(galleryIDs) {
+			end = len(galleryIDs)
+		}
+
+		var changed []models.GalleryPartial
+		if err := txnManager.WithReadTxn(ctx, func(r models.ReaderRepository) error {
+			qb := r.Gallery()
+			iqb := r.Image()
+
+			for _, id := range galleryIDs[start:end] {
+				g, err := qb.Find(id)
+				if err != nil {
+					return err
+				}
+				if g == nil {
+					continue
+				}
+
+				coverID, err := gallery.SelectCoverID(iqb, g, strategy)
+				if err != nil {
+					return fmt.Errorf("resolving cover for gallery %d: %w", id, err)
+				}
+
+				if coverID != g.CoverImageID {
+					changed = append(changed, models.GalleryPartial{
+						ID:           id,
+						CoverImageID: &coverID,
+					})
+				}
+			}
+
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		if len(changed) == 0 {
+			continue
+		}
+
+		if err := txnManager.WithTxn(ctx, func(r models.Repository) error {
+			qb := r.Gallery()
+			for _, partial := range changed {
+				if _, err := qb.UpdatePartial(partial); err != nil {
+					return fmt.Errorf("updating cover for gallery %d: %w", partial.ID, err)
+				}
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (t *ScanTask) scanZipImages(zipGallery *models.Gallery) {
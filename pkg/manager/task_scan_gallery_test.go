@@ -0,0 +1,81 @@
+package manager
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveGalleryCovers(t *testing.T) {
+	const (
+		folderGalleryID = iota + 1
+		unchangedGalleryID
+		changedGalleryID
+		removedGalleryID
+	)
+
+	images := []*models.Image{{ID: 1}, {ID: 2}, {ID: 3}}
+
+	folderGallery := &models.Gallery{ID: folderGalleryID}
+	unchangedGallery := &models.Gallery{ID: unchangedGalleryID, CoverImageID: sql.NullInt64{Int64: 2, Valid: true}}
+	// selected using a different strategy
+	changedGallery := &models.Gallery{ID: changedGalleryID, CoverImageID: sql.NullInt64{Int64: 1, Valid: true}}
+
+	txnManager := mocks.NewTransactionManager()
+	gqb := txnManager.GalleryMock()
+	iqb := txnManager.ImageMock()
+
+	for _, g := range []*models.Gallery{folderGallery, unchangedGallery, changedGallery} {
+		gqb.On("Find", g.ID).Return(g, nil).Once()
+		iqb.On("FindByGalleryID", g.ID).Return(images, nil).Once()
+	}
+	gqb.On("Find", removedGalleryID).Return(nil, nil).Once()
+
+	cover := &sql.NullInt64{Int64: 2, Valid: true}
+	gqb.On("UpdatePartial", models.GalleryPartial{ID: folderGalleryID, CoverImageID: cover}).Return(folderGallery, nil).Once()
+	gqb.On("UpdatePartial", models.GalleryPartial{ID: changedGalleryID, CoverImageID: cover}).Return(changedGallery, nil).Once()
+
+	err := resolveGalleryCovers(context.Background(), txnManager, models.GalleryCoverStrategyMiddle, []int{
+		folderGalleryID,
+		unchangedGalleryID,
+		changedGalleryID,
+		removedGalleryID,
+	})
+	assert.Nil(t, err)
+
+	gqb.AssertExpectations(t)
+	iqb.AssertExpectations(t)
+}
+
+func TestScanGalleryIDs(t *testing.T) {
+	const (
+		addedGalleryID = iota + 1
+		updatedGalleryID
+		imageGalleryID
+		addedImageID
+	)
+
+	changes := newScanChanges()
+	changes.galleries.Added(addedGalleryID)
+	changes.galleries.Updated(updatedGalleryID)
+	changes.images.Added(addedImageID)
+
+	txnManager := mocks.NewTransactionManager()
+	gqb := txnManager.GalleryMock()
+
+	// the image is in a scanned gallery and an unchanged gallery
+	gqb.On("FindByImageID", addedImageID).Return([]*models.Gallery{
+		{ID: addedGalleryID},
+		{ID: imageGalleryID},
+	}, nil).Once()
+
+	ids, err := scanGalleryIDs(context.Background(), txnManager, changes)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{addedGalleryID, updatedGalleryID, imageGalleryID}, ids)
+
+	gqb.AssertExpectations(t)
+}
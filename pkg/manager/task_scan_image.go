@@ -38,6 +38,9 @@ func (t *ScanTask) scanImage() {
 		PluginCache:        instance.PluginCache,
 		MutexManager:       t.mutexManager,
 	}
+	if t.changes != nil {
+		scanner.Changes = t.changes.images
+	}
 
 	var err error
 	if i != nil {
@@ -77,6 +80,9 @@ func (t *ScanTask) scanImage() {
 				}
 
 				if isNewGallery {
+					if t.changes != nil {
+						t.changes.galleries.Added(galleryID)
+					}
 					GetInstance().PluginCache.ExecutePostHooks(t.ctx, galleryID, plugin.GalleryCreatePost, nil, nil)
 				}
 			}
@@ -150,8 +156,14 @@ func (t *ScanTask) generateThumbnail(i *models.Image) {
 	}
 
 	if config.Height > models.DefaultGthumbWidth || config.Width > models.DefaultGthumbWidth {
-		encoder := image.NewThumbnailEncoder(instance.FFMPEG)
+		release, err := instance.Resources.Acquire(t.ctx, ThumbnailResources)
+		if err != nil {
+			return
+		}
+
+		encoder := image.NewThumbnailEncoder(instance.FFMPEG())
 		data, err := encoder.GetThumbnail(i, models.DefaultGthumbWidth)
+		release()
 
 		if err != nil {
 			logger.Errorf("error getting thumbnail for image %s: %s", i.Path, err.Error())
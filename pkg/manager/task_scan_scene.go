@@ -27,6 +27,8 @@ func (t *ScanTask) scanScene() *models.Scene {
 		return nil
 	}
 
+	encoder := instance.FFMPEG()
+	ffprobe := instance.FFProbe()
 	scanner := scene.Scanner{
 		Scanner:             scene.FileScanner(&file.FSHasher{}, t.fileNamingAlgorithm, t.calculateMD5),
 		StripFileExtension:  t.StripFileExtension,
@@ -34,14 +36,36 @@ func (t *ScanTask) scanScene() *models.Scene {
 		Ctx:                 t.ctx,
 		TxnManager:          t.TxnManager,
 		Paths:               GetInstance().Paths,
-		Screenshotter:       &instance.FFMPEG,
-		VideoFileCreator:    &instance.FFProbe,
+		Screenshotter:       &encoder,
+		ScreenshotPosition:  screenshotPosition(),
+		ScreenshotFormat:    instance.screenshotFormat(),
+		ScreenshotQuality:   instance.Config.GetScreenshotQuality(),
+		VideoFileCreator:    &ffprobe,
 		PluginCache:         instance.PluginCache,
 		MutexManager:        t.mutexManager,
 		UseFileMetadata:     t.UseFileMetadata,
+		Sidecars:            SceneSidecarOptions(),
+	}
+	if t.changes != nil {
+		scanner.Changes = t.changes.scenes
 	}
 
 	if s != nil {
+		if s.IsMissing() {
+			// the file has come back, so the scene is no longer missing
+			if err := t.TxnManager.WithTxn(context.TODO(), func(r models.Repository) error {
+				var err error
+				s, err = r.Scene().Update(models.ScenePartial{
+					ID:        s.ID,
+					MissingAt: &models.NullSQLiteTimestamp{},
+				})
+				return err
+			}); err != nil {
+				return logError(err)
+			}
+			logger.Infof("Scene file %s is no longer missing", s.Path)
+		}
+
 		if err := scanner.ScanExisting(s, t.file); err != nil {
 			return logError(err)
 		}
@@ -0,0 +1,101 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalculateMD5(t *testing.T) {
+	md5 := models.HashAlgorithmMd5
+	oshash := models.HashAlgorithmOshash
+	invalid := models.HashAlgorithm("invalid")
+
+	tests := []struct {
+		name          string
+		hashAlgorithm *models.HashAlgorithm
+		defaultMD5    bool
+		want          bool
+	}{
+		{"unset default false", nil, false, false},
+		{"unset default true", nil, true, true},
+		{"md5", &md5, false, true},
+		{"oshash", &oshash, true, false},
+		{"invalid", &invalid, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, calculateMD5(tt.hashAlgorithm, tt.defaultMD5, 0, 0))
+		})
+	}
+}
+
+func TestCalculateMD5FullHashMaxSize(t *testing.T) {
+	const maxSize = 50 << 20
+
+	md5 := models.HashAlgorithmMd5
+	oshash := models.HashAlgorithmOshash
+
+	tests := []struct {
+		name          string
+		hashAlgorithm *models.HashAlgorithm
+		defaultMD5    bool
+		size          int64
+		maxSize       int64
+		want          bool
+	}{
+		{"smaller default md5", nil, true, maxSize - 1, maxSize, true},
+		{"smaller default oshash", nil, false, maxSize - 1, maxSize, false},
+		{"equal default md5", nil, true, maxSize, maxSize, true},
+		{"smaller library md5", &md5, false, 1, maxSize, true},
+		{"smaller library oshash", &oshash, true, 1, maxSize, false},
+		{"larger default md5", nil, true, maxSize + 1, maxSize, false},
+		{"larger library md5", &md5, true, maxSize + 1, maxSize, false},
+		{"larger not limited", nil, true, maxSize + 1, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, calculateMD5(tt.hashAlgorithm, tt.defaultMD5, tt.size, tt.maxSize))
+		})
+	}
+}
+
+func TestWalkFilesToScanHidden(t *testing.T) {
+	// the library itself is hidden, and is still scanned
+	lib := filepath.Join(t.TempDir(), ".library")
+	for _, p := range []string{"1.mp4", ".2.mp4", ".hidden/3.mp4", "@eaDir/4.mp4", "dir/5.mp4"} {
+		p = filepath.Join(lib, filepath.FromSlash(p))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte("video"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stash := &models.StashConfig{Path: lib}
+	c := config.GetInstance()
+
+	// hidden files are skipped by default
+	assert.Equal(t, []string{
+		filepath.Join(lib, "1.mp4"),
+		filepath.Join(lib, "dir", "5.mp4"),
+	}, walkScanPath(t, stash))
+
+	c.Set(config.ScanHiddenFiles, true)
+	defer c.Set(config.ScanHiddenFiles, false)
+
+	assert.Equal(t, []string{
+		filepath.Join(lib, ".2.mp4"),
+		filepath.Join(lib, ".hidden", "3.mp4"),
+		filepath.Join(lib, "1.mp4"),
+		filepath.Join(lib, "@eaDir", "4.mp4"),
+		filepath.Join(lib, "dir", "5.mp4"),
+	}, walkScanPath(t, stash))
+}
@@ -5,10 +5,10 @@ import (
 	"fmt"
 
 	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/job"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/models"
-	"github.com/stashapp/stash/pkg/utils"
 )
 
 type GenerateTranscodeTask struct {
@@ -30,7 +30,7 @@ func (t *GenerateTranscodeTask) Start(ctc context.Context) {
 		return
 	}
 
-	ffprobe := instance.FFProbe
+	ffprobe := instance.FFProbe()
 	var container ffmpeg.Container
 
 	if t.Scene.Format.Valid {
@@ -39,7 +39,7 @@ func (t *GenerateTranscodeTask) Start(ctc context.Context) {
 		// shouldn't happen unless user hasn't scanned after updating to PR#384+ version
 		tmpVideoFile, err := ffprobe.NewVideoFile(t.Scene.Path, false)
 		if err != nil {
-			logger.Errorf("[transcode] error reading video file: %s", err.Error())
+			logger.Ctx(ctc).Errorf("[transcode] error reading video file: %s", err.Error())
 			return
 		}
 
@@ -58,36 +58,57 @@ func (t *GenerateTranscodeTask) Start(ctc context.Context) {
 
 	videoFile, err := ffprobe.NewVideoFile(t.Scene.Path, false)
 	if err != nil {
-		logger.Errorf("[transcode] error reading video file: %s", err.Error())
+		logger.Ctx(ctc).Errorf("[transcode] error reading video file: %s", err.Error())
 		return
 	}
 
+	release, err := instance.Resources.Acquire(ctc, TranscodeResources)
+	if err != nil {
+		return
+	}
+	defer release()
+
 	sceneHash := t.Scene.GetHash(t.fileNamingAlgorithm)
-	outputPath := instance.Paths.Generated.GetTmpPath(sceneHash + ".mp4")
+	partialPath := transcodePartialPath(instance.Paths.Generated.Tmp, sceneHash)
+	outputPath := instance.Paths.Scene.GetTranscodePath(sceneHash)
 	transcodeSize := config.GetInstance().GetMaxTranscodeSize()
 	options := ffmpeg.TranscodeOptions{
-		OutputPath:       outputPath,
+		OutputPath:       partialPath,
 		MaxTranscodeSize: transcodeSize,
 	}
-	encoder := instance.FFMPEG
-
-	if videoCodec == ffmpeg.H264 { // for non supported h264 files stream copy the video part
-		if audioCodec == ffmpeg.MissingUnsupported {
-			encoder.CopyVideo(*videoFile, options)
-		} else {
-			encoder.TranscodeAudio(*videoFile, options)
+	encoder := instance.FFMPEG()
+
+	// kill the encoder if the task is cancelled
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctc.Done():
+			ffmpeg.KillRunningEncoders(t.Scene.Path)
+		case <-done:
 		}
-	} else {
+	}()
+
+	err = instance.PartialTranscodes.Run(ctc, partialPath, outputPath, func() error {
+		if videoCodec == ffmpeg.H264 { // for non supported h264 files stream copy the video part
+			if audioCodec == ffmpeg.MissingUnsupported {
+				return encoder.CopyVideo(*videoFile, options)
+			}
+			return encoder.TranscodeAudio(*videoFile, options)
+		}
+
 		if audioCodec == ffmpeg.MissingUnsupported {
 			// ffmpeg fails if it trys to transcode an unsupported audio codec
-			encoder.TranscodeVideo(*videoFile, options)
-		} else {
-			encoder.Transcode(*videoFile, options)
+			return encoder.TranscodeVideo(*videoFile, options)
 		}
+		return encoder.Transcode(*videoFile, options)
+	})
+	if job.IsCancelled(ctc) {
+		logger.Infof("[transcode] <%s> transcode cancelled", sceneHash)
+		return
 	}
-
-	if err := utils.SafeMove(outputPath, instance.Paths.Scene.GetTranscodePath(sceneHash)); err != nil {
-		logger.Errorf("[transcode] error generating transcode: %s", err.Error())
+	if err != nil {
+		logger.Ctx(ctc).Errorf("[transcode] error generating transcode: %s", err.Error())
 		return
 	}
 
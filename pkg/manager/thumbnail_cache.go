@@ -0,0 +1,302 @@
+package manager
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// ThumbnailCacheKey identifies a thumbnail of an image. The checksum of the
+// image is included, so that thumbnails of a replaced file are not used.
+type ThumbnailCacheKey struct {
+	ImageID  int
+	Checksum string
+	// MaxSize is the maximum width and height of the thumbnail.
+	MaxSize int
+}
+
+// NewThumbnailCacheKey returns the key of the thumbnail of the image with
+// the maximum size.
+func NewThumbnailCacheKey(img *models.Image, maxSize int) ThumbnailCacheKey {
+	return ThumbnailCacheKey{
+		ImageID:  img.ID,
+		Checksum: img.Checksum,
+		MaxSize:  maxSize,
+	}
+}
+
+func (k ThumbnailCacheKey) valid() bool {
+	return k.Checksum != "" && k.MaxSize > 0
+}
+
+// filename returns the name of the cached file. The key is recovered from
+// the name when loading the cache.
+func (k ThumbnailCacheKey) filename() string {
+	return fmt.Sprintf("%d_%s_%d.jpg", k.ImageID, k.Checksum, k.MaxSize)
+}
+
+func parseThumbnailCacheFilename(name string) (ThumbnailCacheKey, error) {
+	parts := strings.Split(strings.TrimSuffix(name, ".jpg"), "_")
+	if len(parts) != 3 || !strings.HasSuffix(name, ".jpg") {
+		return ThumbnailCacheKey{}, fmt.Errorf("invalid thumbnail cache filename %s", name)
+	}
+
+	imageID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return ThumbnailCacheKey{}, fmt.Errorf("invalid thumbnail cache filename %s", name)
+	}
+
+	maxSize, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return ThumbnailCacheKey{}, fmt.Errorf("invalid thumbnail cache filename %s", name)
+	}
+
+	return ThumbnailCacheKey{
+		ImageID:  imageID,
+		Checksum: parts[1],
+		MaxSize:  maxSize,
+	}, nil
+}
+
+type thumbnailCacheEntry struct {
+	key  ThumbnailCacheKey
+	size int64
+}
+
+// ThumbnailCache stores image thumbnails generated on demand. The least
+// recently used thumbnails are removed when the cache exceeds its maximum
+// size.
+type ThumbnailCache struct {
+	dir     string
+	maxSize int64
+
+	mutex   sync.Mutex
+	size    int64
+	entries map[ThumbnailCacheKey]*list.Element
+	// byImage indexes the keys of the entries by image id
+	byImage map[int][]ThumbnailCacheKey
+	// lru is ordered from most to least recently used
+	lru *list.List
+}
+
+// NewThumbnailCache returns a cache of the thumbnails in dir, with a
+// maximum size of maxSize bytes. The cache is disabled if maxSize is zero.
+func NewThumbnailCache(dir string, maxSize int64) *ThumbnailCache {
+	ret := &ThumbnailCache{
+		dir:     dir,
+		maxSize: maxSize,
+		entries: make(map[ThumbnailCacheKey]*list.Element),
+		byImage: make(map[int][]ThumbnailCacheKey),
+		lru:     list.New(),
+	}
+
+	if err := ret.load(); err != nil {
+		logger.Warnf("error loading thumbnail cache: %v", err)
+	}
+
+	return ret
+}
+
+// load adds the files in the cache directory to the cache, ordered by
+// modification time.
+func (c *ThumbnailCache) load() error {
+	entries, err := os.ReadDir(c.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var infos []os.FileInfo
+	for _, e := range entries {
+		// remove thumbnails that were incomplete when stash stopped
+		if strings.HasSuffix(e.Name(), ".tmp") {
+			path := filepath.Join(c.dir, e.Name())
+			if err := os.Remove(path); err != nil {
+				logger.Warnf("error removing incomplete thumbnail %s: %v", path, err)
+			}
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil || info.IsDir() {
+			continue
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().After(infos[j].ModTime())
+	})
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, info := range infos {
+		key, err := parseThumbnailCacheFilename(info.Name())
+		if err != nil {
+			logger.Warnf("ignoring file in thumbnail cache: %v", err)
+			continue
+		}
+
+		c.entries[key] = c.lru.PushBack(&thumbnailCacheEntry{
+			key:  key,
+			size: info.Size(),
+		})
+		c.byImage[key.ImageID] = append(c.byImage[key.ImageID], key)
+		c.size += info.Size()
+	}
+
+	c.evict()
+	return nil
+}
+
+// Enabled returns true if thumbnails are cached.
+func (c *ThumbnailCache) Enabled() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.maxSize > 0
+}
+
+// SetMaxSize sets the maximum size of the cache in bytes, removing
+// thumbnails if the cache exceeds the size.
+func (c *ThumbnailCache) SetMaxSize(maxSize int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.maxSize = maxSize
+	c.evict()
+}
+
+// Get returns the path of the cached thumbnail, or an empty string if the
+// thumbnail is not cached. Thumbnails of previous versions of the image
+// are removed.
+func (c *ThumbnailCache) Get(key ThumbnailCacheKey) string {
+	if !key.valid() {
+		return ""
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.invalidate(key.ImageID, key.Checksum)
+
+	e, found := c.entries[key]
+	if !found {
+		return ""
+	}
+
+	path := filepath.Join(c.dir, key.filename())
+	if exists, _ := utils.FileExists(path); !exists {
+		c.remove(e)
+		return ""
+	}
+
+	c.lru.MoveToFront(e)
+	return path
+}
+
+// Put adds the thumbnail data to the cache. Thumbnails larger than the
+// cache are not added.
+func (c *ThumbnailCache) Put(key ThumbnailCacheKey, data []byte) error {
+	if !key.valid() {
+		return errors.New("image has no checksum")
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	size := int64(len(data))
+	if size > c.maxSize {
+		return nil
+	}
+
+	if err := utils.EnsureDir(c.dir); err != nil {
+		return err
+	}
+
+	path := filepath.Join(c.dir, key.filename())
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if e, found := c.entries[key]; found {
+		c.lru.Remove(e)
+		c.size -= e.Value.(*thumbnailCacheEntry).size
+	} else {
+		c.byImage[key.ImageID] = append(c.byImage[key.ImageID], key)
+	}
+
+	c.entries[key] = c.lru.PushFront(&thumbnailCacheEntry{
+		key:  key,
+		size: size,
+	})
+	c.size += size
+
+	c.evict()
+	return nil
+}
+
+// invalidate removes the thumbnails of the image with a checksum other than
+// checksum. The mutex must be held.
+func (c *ThumbnailCache) invalidate(imageID int, checksum string) {
+	// copy the keys, since removing entries modifies the index
+	keys := append([]ThumbnailCacheKey(nil), c.byImage[imageID]...)
+	for _, key := range keys {
+		if key.Checksum != checksum {
+			c.remove(c.entries[key])
+		}
+	}
+}
+
+// remove removes the entry and its file. The mutex must be held.
+func (c *ThumbnailCache) remove(e *list.Element) {
+	entry := e.Value.(*thumbnailCacheEntry)
+
+	c.lru.Remove(e)
+	delete(c.entries, entry.key)
+	c.size -= entry.size
+
+	keys := c.byImage[entry.key.ImageID]
+	for i, k := range keys {
+		if k == entry.key {
+			keys = append(keys[:i], keys[i+1:]...)
+			break
+		}
+	}
+	if len(keys) == 0 {
+		delete(c.byImage, entry.key.ImageID)
+	} else {
+		c.byImage[entry.key.ImageID] = keys
+	}
+
+	path := filepath.Join(c.dir, entry.key.filename())
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Warnf("error removing cached thumbnail %s: %v", path, err)
+	}
+}
+
+// evict removes the least recently used thumbnails until the cache does not
+// exceed its maximum size. The mutex must be held.
+func (c *ThumbnailCache) evict() {
+	for c.size > c.maxSize && c.lru.Len() > 0 {
+		c.remove(c.lru.Back())
+	}
+}
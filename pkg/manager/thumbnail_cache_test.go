@@ -0,0 +1,100 @@
+package manager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThumbnailCacheKey(t *testing.T) {
+	img := &models.Image{ID: 1, Checksum: "abc"}
+	key := NewThumbnailCacheKey(img, 640)
+
+	assert.Equal(t, ThumbnailCacheKey{ImageID: 1, Checksum: "abc", MaxSize: 640}, key)
+
+	parsed, err := parseThumbnailCacheFilename(key.filename())
+	assert.NoError(t, err)
+	assert.Equal(t, key, parsed)
+
+	// changing any parameter should change the key
+	others := []ThumbnailCacheKey{
+		NewThumbnailCacheKey(&models.Image{ID: 2, Checksum: "abc"}, 640),
+		NewThumbnailCacheKey(&models.Image{ID: 1, Checksum: "def"}, 640),
+		NewThumbnailCacheKey(img, 320),
+	}
+	for _, o := range others {
+		assert.NotEqual(t, key.filename(), o.filename())
+	}
+
+	for _, name := range []string{"1_abc.jpg", "x_abc_640.jpg", "1_abc_x.jpg", "1_abc_640.png"} {
+		_, err := parseThumbnailCacheFilename(name)
+		assert.Error(t, err, name)
+	}
+
+	assert.False(t, NewThumbnailCacheKey(&models.Image{ID: 1}, 640).valid())
+}
+
+func TestThumbnailCacheReuse(t *testing.T) {
+	dir := t.TempDir()
+	c := NewThumbnailCache(dir, 1024)
+	key := NewThumbnailCacheKey(&models.Image{ID: 1, Checksum: "abc"}, 640)
+
+	assert.Empty(t, c.Get(key))
+
+	assert.NoError(t, c.Put(key, []byte("thumbnail")))
+	path := c.Get(key)
+	assert.Equal(t, filepath.Join(dir, key.filename()), path)
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "thumbnail", string(data))
+
+	// other sizes are cached separately
+	assert.Empty(t, c.Get(NewThumbnailCacheKey(&models.Image{ID: 1, Checksum: "abc"}, 320)))
+
+	// the cache is reused after restarting
+	c = NewThumbnailCache(dir, 1024)
+	assert.Equal(t, path, c.Get(key))
+
+	// a changed image invalidates its thumbnails
+	changed := NewThumbnailCacheKey(&models.Image{ID: 1, Checksum: "def"}, 640)
+	assert.Empty(t, c.Get(changed))
+	assert.Empty(t, c.Get(key))
+	assert.NoFileExists(t, path)
+}
+
+func TestThumbnailCacheEviction(t *testing.T) {
+	dir := t.TempDir()
+	c := NewThumbnailCache(dir, 10)
+
+	keys := []ThumbnailCacheKey{
+		{ImageID: 1, Checksum: "a", MaxSize: 640},
+		{ImageID: 2, Checksum: "b", MaxSize: 640},
+		{ImageID: 3, Checksum: "c", MaxSize: 640},
+	}
+
+	assert.NoError(t, c.Put(keys[0], []byte("1234")))
+	assert.NoError(t, c.Put(keys[1], []byte("1234")))
+
+	// use the first, so that the second is least recently used
+	assert.NotEmpty(t, c.Get(keys[0]))
+
+	assert.NoError(t, c.Put(keys[2], []byte("1234")))
+	assert.NotEmpty(t, c.Get(keys[0]))
+	assert.Empty(t, c.Get(keys[1]))
+	assert.NotEmpty(t, c.Get(keys[2]))
+	assert.NoFileExists(t, filepath.Join(dir, keys[1].filename()))
+
+	// thumbnails larger than the cache are not cached
+	large := ThumbnailCacheKey{ImageID: 4, Checksum: "d", MaxSize: 640}
+	assert.NoError(t, c.Put(large, []byte("12345678901")))
+	assert.Empty(t, c.Get(large))
+
+	// reducing the size evicts thumbnails
+	c.SetMaxSize(4)
+	assert.Empty(t, c.Get(keys[0]))
+	assert.NotEmpty(t, c.Get(keys[2]))
+}
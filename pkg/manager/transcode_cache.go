@@ -0,0 +1,377 @@
+package manager
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+const transcodeCacheTmpExt = ".tmp"
+
+var errTranscodeTooLarge = errors.New("transcode exceeds the cache size")
+
+// TranscodeCacheKey identifies a streaming transcode of a scene. The hash of
+// the source file is included, so that transcodes of a replaced file are not
+// used.
+type TranscodeCacheKey struct {
+	SceneID    int
+	SourceHash string
+	// Codec identifies the codec and encoding parameters of the transcode.
+	Codec      string
+	Format     string
+	Resolution models.StreamingResolutionEnum
+}
+
+// NewTranscodeCacheKey returns the key of the transcode of the scene using
+// the codec, with the provided maximum resolution.
+func NewTranscodeCacheKey(scene *models.Scene, codec ffmpeg.Codec, resolution models.StreamingResolutionEnum) TranscodeCacheKey {
+	return TranscodeCacheKey{
+		SceneID:    scene.ID,
+		SourceHash: scene.GetHash(config.GetInstance().GetVideoFileNamingAlgorithm()),
+		Codec:      codec.Key(),
+		Format:     codec.Format(),
+		Resolution: resolution,
+	}
+}
+
+func (k TranscodeCacheKey) valid() bool {
+	return k.SourceHash != ""
+}
+
+// filename returns the name of the cached file. The scene id and source
+// hash are recovered from the name when loading the cache.
+func (k TranscodeCacheKey) filename() string {
+	return fmt.Sprintf("%d_%s_%s_%s.%s", k.SceneID, k.SourceHash, k.Codec, k.Resolution, k.Format)
+}
+
+// parseTranscodeCacheFilename returns the scene id and source hash of a
+// cached file.
+func parseTranscodeCacheFilename(name string) (int, string, error) {
+	parts := strings.SplitN(name, "_", 3)
+	if len(parts) != 3 {
+		return 0, "", fmt.Errorf("invalid transcode cache filename %s", name)
+	}
+
+	sceneID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid transcode cache filename %s", name)
+	}
+
+	return sceneID, parts[1], nil
+}
+
+type transcodeCacheEntry struct {
+	name       string
+	sceneID    int
+	sourceHash string
+	size       int64
+}
+
+// TranscodeCache stores completed streaming transcodes, so that they are
+// served without transcoding on subsequent requests. The least recently used
+// transcodes are removed when the cache exceeds its maximum size.
+type TranscodeCache struct {
+	dir     string
+	maxSize int64
+
+	mutex   sync.Mutex
+	size    int64
+	entries map[string]*list.Element
+	// lru is ordered from most to least recently used
+	lru *list.List
+}
+
+// NewTranscodeCache returns a cache of the transcodes in dir, with a maximum
+// size of maxSize bytes. The cache is disabled if maxSize is zero.
+func NewTranscodeCache(dir string, maxSize int64) *TranscodeCache {
+	ret := &TranscodeCache{
+		dir:     dir,
+		maxSize: maxSize,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+
+	if err := ret.load(); err != nil {
+		logger.Warnf("error loading transcode cache: %v", err)
+	}
+
+	return ret
+}
+
+// load adds the files in the cache directory to the cache, ordered by
+// modification time.
+func (c *TranscodeCache) load() error {
+	entries, err := os.ReadDir(c.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var infos []os.FileInfo
+	for _, e := range entries {
+		path := filepath.Join(c.dir, e.Name())
+
+		// remove transcodes that were incomplete when stash stopped
+		if strings.HasSuffix(e.Name(), transcodeCacheTmpExt) {
+			if err := os.Remove(path); err != nil {
+				logger.Warnf("error removing incomplete transcode %s: %v", path, err)
+			}
+			continue
+		}
+
+		info, err := e.Info()
+		if err != nil || info.IsDir() {
+			continue
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().After(infos[j].ModTime())
+	})
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, info := range infos {
+		sceneID, sourceHash, err := parseTranscodeCacheFilename(info.Name())
+		if err != nil {
+			logger.Warnf("ignoring file in transcode cache: %v", err)
+			continue
+		}
+
+		c.entries[info.Name()] = c.lru.PushBack(&transcodeCacheEntry{
+			name:       info.Name(),
+			sceneID:    sceneID,
+			sourceHash: sourceHash,
+			size:       info.Size(),
+		})
+		c.size += info.Size()
+	}
+
+	c.evict()
+	return nil
+}
+
+// Enabled returns true if transcodes are cached.
+func (c *TranscodeCache) Enabled() bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return c.maxSize > 0
+}
+
+// SetMaxSize sets the maximum size of the cache in bytes, removing
+// transcodes if the cache exceeds the size.
+func (c *TranscodeCache) SetMaxSize(maxSize int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.maxSize = maxSize
+	c.evict()
+}
+
+// Get opens the cached transcode, or returns nil if the transcode is not
+// cached. The file is opened while the cache is locked, so that it can be
+// read after a concurrent eviction removes it. The caller must close the
+// file. Transcodes of previous versions of the source file are removed.
+func (c *TranscodeCache) Get(key TranscodeCacheKey) *os.File {
+	if !key.valid() {
+		return nil
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.invalidate(key.SceneID, key.SourceHash)
+
+	e, found := c.entries[key.filename()]
+	if !found {
+		return nil
+	}
+
+	entry := e.Value.(*transcodeCacheEntry)
+	path := filepath.Join(c.dir, entry.name)
+	f, err := os.Open(path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			logger.Warnf("error opening cached transcode %s: %v", path, err)
+		}
+		c.remove(e)
+		return nil
+	}
+
+	c.lru.MoveToFront(e)
+	return f
+}
+
+// Invalidate removes the transcodes of the scene with a source hash other
+// than sourceHash. All transcodes of the scene are removed if sourceHash is
+// empty.
+func (c *TranscodeCache) Invalidate(sceneID int, sourceHash string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.invalidate(sceneID, sourceHash)
+}
+
+func (c *TranscodeCache) invalidate(sceneID int, sourceHash string) {
+	var next *list.Element
+	for e := c.lru.Front(); e != nil; e = next {
+		next = e.Next()
+
+		entry := e.Value.(*transcodeCacheEntry)
+		if entry.sceneID == sceneID && entry.sourceHash != sourceHash {
+			c.remove(e)
+		}
+	}
+}
+
+// remove removes the entry and its file. The mutex must be held.
+func (c *TranscodeCache) remove(e *list.Element) {
+	entry := e.Value.(*transcodeCacheEntry)
+
+	c.lru.Remove(e)
+	delete(c.entries, entry.name)
+	c.size -= entry.size
+
+	path := filepath.Join(c.dir, entry.name)
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Warnf("error removing cached transcode %s: %v", path, err)
+	}
+}
+
+// evict removes the least recently used transcodes until the cache does not
+// exceed its maximum size. The mutex must be held.
+func (c *TranscodeCache) evict() {
+	for c.size > c.maxSize && c.lru.Len() > 0 {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *TranscodeCache) add(name string, key TranscodeCacheKey, size int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if e, found := c.entries[name]; found {
+		c.lru.Remove(e)
+		c.size -= e.Value.(*transcodeCacheEntry).size
+	}
+
+	c.entries[name] = c.lru.PushFront(&transcodeCacheEntry{
+		name:       name,
+		sceneID:    key.SceneID,
+		sourceHash: key.SourceHash,
+		size:       size,
+	})
+	c.size += size
+
+	c.evict()
+}
+
+// Create returns a writer for the transcode of key. The transcode is added
+// to the cache when the writer is closed.
+func (c *TranscodeCache) Create(key TranscodeCacheKey) (*TranscodeCacheWriter, error) {
+	if !key.valid() {
+		return nil, errors.New("scene has no hash")
+	}
+
+	if err := utils.EnsureDir(c.dir); err != nil {
+		return nil, err
+	}
+
+	f, err := os.CreateTemp(c.dir, key.filename()+".*"+transcodeCacheTmpExt)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	maxSize := c.maxSize
+	c.mutex.Unlock()
+
+	return &TranscodeCacheWriter{
+		cache:   c,
+		key:     key,
+		f:       f,
+		maxSize: maxSize,
+	}, nil
+}
+
+// TranscodeCacheWriter writes a transcode to the cache. Write never returns
+// an error, so that a failure to cache the transcode does not interrupt the
+// stream.
+type TranscodeCacheWriter struct {
+	cache   *TranscodeCache
+	key     TranscodeCacheKey
+	f       *os.File
+	maxSize int64
+	size    int64
+	err     error
+}
+
+func (w *TranscodeCacheWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return len(p), nil
+	}
+
+	if w.size+int64(len(p)) > w.maxSize {
+		w.err = errTranscodeTooLarge
+		return len(p), nil
+	}
+
+	n, err := w.f.Write(p)
+	w.size += int64(n)
+	if err != nil {
+		w.err = err
+	}
+
+	return len(p), nil
+}
+
+// Close closes the file, and adds the transcode to the cache if completed is
+// true. The file is removed if the transcode is incomplete.
+func (w *TranscodeCacheWriter) Close(completed bool) error {
+	tmpPath := w.f.Name()
+
+	err := w.f.Close()
+	if err == nil {
+		err = w.err
+	}
+	if err == nil && !completed {
+		err = errors.New("transcode incomplete")
+	}
+
+	if err != nil {
+		if removeErr := os.Remove(tmpPath); removeErr != nil {
+			logger.Warnf("error removing incomplete transcode %s: %v", tmpPath, removeErr)
+		}
+
+		if errors.Is(err, errTranscodeTooLarge) || !completed {
+			// not an error worth reporting
+			return nil
+		}
+		return err
+	}
+
+	name := w.key.filename()
+	if err := os.Rename(tmpPath, filepath.Join(w.cache.dir, name)); err != nil {
+		return err
+	}
+
+	w.cache.add(name, w.key, w.size)
+	return nil
+}
@@ -0,0 +1,203 @@
+package manager
+
+import (
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func makeTranscodeCacheScene(id int, hash string) *models.Scene {
+	return &models.Scene{
+		ID:       id,
+		Checksum: sql.NullString{String: hash, Valid: true},
+		OSHash:   sql.NullString{String: hash, Valid: true},
+	}
+}
+
+func TestTranscodeCacheKey(t *testing.T) {
+	scene := makeTranscodeCacheScene(1, "abc")
+	key := NewTranscodeCacheKey(scene, ffmpeg.CodecH264, models.StreamingResolutionEnumStandardHd)
+
+	assert.Equal(t, 1, key.SceneID)
+	assert.Equal(t, "abc", key.SourceHash)
+	assert.Equal(t, "mp4", key.Format)
+	assert.Equal(t, models.StreamingResolutionEnumStandardHd, key.Resolution)
+
+	sceneID, sourceHash, err := parseTranscodeCacheFilename(key.filename())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sceneID)
+	assert.Equal(t, "abc", sourceHash)
+
+	// changing any parameter should change the key
+	others := []TranscodeCacheKey{
+		NewTranscodeCacheKey(makeTranscodeCacheScene(2, "abc"), ffmpeg.CodecH264, models.StreamingResolutionEnumStandardHd),
+		NewTranscodeCacheKey(makeTranscodeCacheScene(1, "def"), ffmpeg.CodecH264, models.StreamingResolutionEnumStandardHd),
+		NewTranscodeCacheKey(scene, ffmpeg.CodecVP9, models.StreamingResolutionEnumStandardHd),
+		NewTranscodeCacheKey(scene, ffmpeg.CodecHEVC, models.StreamingResolutionEnumStandardHd),
+		NewTranscodeCacheKey(scene, ffmpeg.CodecH264, models.StreamingResolutionEnumLow),
+	}
+	for _, other := range others {
+		assert.NotEqual(t, key.filename(), other.filename())
+	}
+
+	assert.False(t, NewTranscodeCacheKey(&models.Scene{ID: 1}, ffmpeg.CodecH264, models.StreamingResolutionEnumLow).valid())
+
+	_, _, err = parseTranscodeCacheFilename("invalid.mp4")
+	assert.Error(t, err)
+}
+
+func cacheTranscode(t *testing.T, c *TranscodeCache, key TranscodeCacheKey, data string) {
+	t.Helper()
+
+	w, err := c.Create(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// cachedTranscode returns the contents of the cached transcode, or an empty
+// string if the transcode is not cached.
+func cachedTranscode(t *testing.T, c *TranscodeCache, key TranscodeCacheKey) string {
+	t.Helper()
+
+	f := c.Get(key)
+	if f == nil {
+		return ""
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestTranscodeCacheReuse(t *testing.T) {
+	c := NewTranscodeCache(t.TempDir(), 1024)
+	key := NewTranscodeCacheKey(makeTranscodeCacheScene(1, "abc"), ffmpeg.CodecH264, models.StreamingResolutionEnumLow)
+
+	assert.Empty(t, cachedTranscode(t, c, key))
+
+	cacheTranscode(t, c, key, "transcode")
+
+	assert.Equal(t, "transcode", cachedTranscode(t, c, key))
+
+	// incomplete transcodes should not be cached
+	other := NewTranscodeCacheKey(makeTranscodeCacheScene(2, "def"), ffmpeg.CodecH264, models.StreamingResolutionEnumLow)
+	w, err := c.Create(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = w.Write([]byte("partial"))
+	assert.NoError(t, w.Close(false))
+	assert.Empty(t, cachedTranscode(t, c, other))
+
+	// only the completed transcode should remain in the directory
+	entries, _ := os.ReadDir(c.dir)
+	assert.Len(t, entries, 1)
+}
+
+func TestTranscodeCacheInvalidate(t *testing.T) {
+	c := NewTranscodeCache(t.TempDir(), 1024)
+	oldKey := NewTranscodeCacheKey(makeTranscodeCacheScene(1, "abc"), ffmpeg.CodecH264, models.StreamingResolutionEnumLow)
+	otherScene := NewTranscodeCacheKey(makeTranscodeCacheScene(2, "abc"), ffmpeg.CodecH264, models.StreamingResolutionEnumLow)
+
+	cacheTranscode(t, c, oldKey, "old")
+	cacheTranscode(t, c, otherScene, "other")
+	oldPath := filepath.Join(c.dir, oldKey.filename())
+	assert.Equal(t, "old", cachedTranscode(t, c, oldKey))
+
+	// the source file of scene 1 changed
+	newKey := NewTranscodeCacheKey(makeTranscodeCacheScene(1, "def"), ffmpeg.CodecH264, models.StreamingResolutionEnumLow)
+	assert.Empty(t, cachedTranscode(t, c, newKey))
+	assert.NoFileExists(t, oldPath)
+	assert.Empty(t, cachedTranscode(t, c, oldKey))
+	assert.NotEmpty(t, cachedTranscode(t, c, otherScene))
+	assert.Equal(t, int64(len("other")), c.size)
+}
+
+func TestTranscodeCacheEviction(t *testing.T) {
+	c := NewTranscodeCache(t.TempDir(), 10)
+
+	keys := make([]TranscodeCacheKey, 3)
+	for i := range keys {
+		keys[i] = NewTranscodeCacheKey(makeTranscodeCacheScene(i, "abc"), ffmpeg.CodecH264, models.StreamingResolutionEnumLow)
+	}
+
+	cacheTranscode(t, c, keys[0], "0000")
+	cacheTranscode(t, c, keys[1], "1111")
+
+	// use the first transcode, so that the second is least recently used
+	assert.NotEmpty(t, cachedTranscode(t, c, keys[0]))
+
+	cacheTranscode(t, c, keys[2], "2222")
+	assert.NotEmpty(t, cachedTranscode(t, c, keys[0]))
+	assert.Empty(t, cachedTranscode(t, c, keys[1]))
+	assert.NotEmpty(t, cachedTranscode(t, c, keys[2]))
+
+	// transcodes larger than the cache should not be cached
+	large := NewTranscodeCacheKey(makeTranscodeCacheScene(4, "abc"), ffmpeg.CodecH264, models.StreamingResolutionEnumLow)
+	cacheTranscode(t, c, large, "01234567890")
+	assert.Empty(t, cachedTranscode(t, c, large))
+
+	c.SetMaxSize(4)
+	assert.Empty(t, cachedTranscode(t, c, keys[0]))
+	assert.NotEmpty(t, cachedTranscode(t, c, keys[2]))
+}
+
+func TestTranscodeCacheLoad(t *testing.T) {
+	dir := t.TempDir()
+	c := NewTranscodeCache(dir, 1024)
+	key := NewTranscodeCacheKey(makeTranscodeCacheScene(1, "abc"), ffmpeg.CodecH264, models.StreamingResolutionEnumLow)
+	cacheTranscode(t, c, key, "transcode")
+
+	// incomplete transcodes are removed when loading
+	tmpPath := filepath.Join(dir, "2_abc_x.mp4.123"+transcodeCacheTmpExt)
+	if err := os.WriteFile(tmpPath, []byte("partial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded := NewTranscodeCache(dir, 1024)
+	assert.NotEmpty(t, cachedTranscode(t, loaded, key))
+	assert.Equal(t, int64(len("transcode")), loaded.size)
+	assert.NoFileExists(t, tmpPath)
+
+	// stale transcodes found when loading are invalidated
+	newKey := NewTranscodeCacheKey(makeTranscodeCacheScene(1, "def"), ffmpeg.CodecH264, models.StreamingResolutionEnumLow)
+	assert.Empty(t, cachedTranscode(t, loaded, newKey))
+	assert.Empty(t, cachedTranscode(t, loaded, key))
+}
+
+func TestTranscodeCacheEvictWhileServing(t *testing.T) {
+	c := NewTranscodeCache(t.TempDir(), 10)
+	key := NewTranscodeCacheKey(makeTranscodeCacheScene(1, "abc"), ffmpeg.CodecH264, models.StreamingResolutionEnumLow)
+	cacheTranscode(t, c, key, "0000")
+
+	f := c.Get(key)
+	if f == nil {
+		t.Fatal("transcode not cached")
+	}
+	defer f.Close()
+
+	// a concurrent transcode evicts the transcode being served
+	other := NewTranscodeCacheKey(makeTranscodeCacheScene(2, "abc"), ffmpeg.CodecH264, models.StreamingResolutionEnumLow)
+	cacheTranscode(t, c, other, "11111111")
+	assert.Empty(t, cachedTranscode(t, c, key))
+
+	data, err := io.ReadAll(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "0000", string(data))
+}
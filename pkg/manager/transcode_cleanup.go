@@ -0,0 +1,168 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/stashapp/stash/pkg/job"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// transcodePartialSuffix is the suffix of the files that transcodes are
+// written to until they complete. The suffix keeps the mp4 extension so
+// that ffmpeg infers the output format.
+const transcodePartialSuffix = ".partial.mp4"
+
+// transcodeCleanupScheduleName is the scheduler name of the periodic
+// cleanup of partial transcodes.
+const transcodeCleanupScheduleName = "transcode-cleanup"
+
+// transcodePartialPath returns the path in dir of the partial transcode of
+// the scene with the provided hash.
+func transcodePartialPath(dir string, sceneHash string) string {
+	return filepath.Join(dir, sceneHash+transcodePartialSuffix)
+}
+
+func isTranscodePartial(name string) bool {
+	return strings.HasSuffix(name, transcodePartialSuffix)
+}
+
+// PartialTranscodes tracks the partial transcodes that are being written, so
+// that the periodic cleanup does not delete them.
+type PartialTranscodes struct {
+	mutex  sync.Mutex
+	active map[string]bool
+}
+
+func NewPartialTranscodes() *PartialTranscodes {
+	return &PartialTranscodes{
+		active: make(map[string]bool),
+	}
+}
+
+func (p *PartialTranscodes) add(path string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.active[path] {
+		return false
+	}
+
+	p.active[path] = true
+	return true
+}
+
+func (p *PartialTranscodes) remove(path string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	delete(p.active, path)
+}
+
+func (p *PartialTranscodes) isActive(path string) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.active[path]
+}
+
+// Run writes a transcode to partialPath using fn, and moves it to
+// outputPath once it completes. The partial file is deleted if fn fails or
+// ctx is cancelled.
+func (p *PartialTranscodes) Run(ctx context.Context, partialPath string, outputPath string, fn func() error) error {
+	if !p.add(partialPath) {
+		return errors.New("transcode is already running")
+	}
+	defer p.remove(partialPath)
+
+	// remove any partial file left behind by an earlier transcode
+	removePartialTranscode(partialPath)
+
+	err := fn()
+	if err == nil {
+		err = ctx.Err()
+	}
+
+	if err != nil {
+		removePartialTranscode(partialPath)
+		return err
+	}
+
+	if err := utils.SafeMove(partialPath, outputPath); err != nil {
+		removePartialTranscode(partialPath)
+		return err
+	}
+
+	return nil
+}
+
+func removePartialTranscode(path string) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Warnf("could not delete partial transcode %s: %v", path, err)
+	}
+}
+
+// Sweep deletes the partial transcodes in dir that are not being written.
+// Returns the number of files deleted.
+func (p *PartialTranscodes) Sweep(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+
+	removed := 0
+	for _, e := range entries {
+		if e.IsDir() || !isTranscodePartial(e.Name()) {
+			continue
+		}
+
+		path := filepath.Join(dir, e.Name())
+		if p.isActive(path) {
+			continue
+		}
+
+		if err := os.Remove(path); err != nil {
+			logger.Warnf("could not delete partial transcode %s: %v", path, err)
+			continue
+		}
+		removed++
+	}
+
+	return removed, nil
+}
+
+// refreshTranscodeCleanupSchedule schedules the periodic cleanup of partial
+// transcodes using the current configuration.
+func (s *singleton) refreshTranscodeCleanupSchedule() {
+	expr := s.Config.GetTranscodeCleanupSchedule()
+	if expr == "" {
+		s.Scheduler.Remove(transcodeCleanupScheduleName)
+		return
+	}
+
+	schedule, err := job.ParseSchedule(expr)
+	if err != nil {
+		logger.Warnf("invalid transcode cleanup schedule: %v", err)
+		s.Scheduler.Remove(transcodeCleanupScheduleName)
+		return
+	}
+
+	s.Scheduler.Set(transcodeCleanupScheduleName, schedule, func() {
+		removed, err := s.PartialTranscodes.Sweep(s.Paths.Generated.Tmp)
+		if err != nil {
+			logger.Warnf("error cleaning up partial transcodes: %v", err)
+			return
+		}
+		if removed > 0 {
+			logger.Infof("Deleted %d partial transcodes", removed)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package manager
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writePartial(t *testing.T, path string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte("partial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPartialTranscodesRunCancelled(t *testing.T) {
+	dir := t.TempDir()
+	partialPath := transcodePartialPath(dir, "hash")
+	outputPath := filepath.Join(dir, "hash.mp4")
+
+	p := NewPartialTranscodes()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	err := p.Run(ctx, partialPath, outputPath, func() error {
+		writePartial(t, partialPath)
+		cancel()
+		return nil
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NoFileExists(t, partialPath)
+	assert.NoFileExists(t, outputPath)
+	assert.False(t, p.isActive(partialPath))
+}
+
+func TestPartialTranscodesRunFailed(t *testing.T) {
+	dir := t.TempDir()
+	partialPath := transcodePartialPath(dir, "hash")
+	outputPath := filepath.Join(dir, "hash.mp4")
+
+	p := NewPartialTranscodes()
+	errFailed := errors.New("failed")
+
+	err := p.Run(context.Background(), partialPath, outputPath, func() error {
+		writePartial(t, partialPath)
+		return errFailed
+	})
+
+	assert.ErrorIs(t, err, errFailed)
+	assert.NoFileExists(t, partialPath)
+	assert.NoFileExists(t, outputPath)
+}
+
+func TestPartialTranscodesRunCompleted(t *testing.T) {
+	dir := t.TempDir()
+	partialPath := transcodePartialPath(dir, "hash")
+	outputPath := filepath.Join(dir, "hash.mp4")
+
+	// left behind by an earlier transcode
+	writePartial(t, partialPath)
+
+	p := NewPartialTranscodes()
+	err := p.Run(context.Background(), partialPath, outputPath, func() error {
+		if _, err := os.Stat(partialPath); err == nil {
+			return errors.New("stale partial transcode was not removed")
+		}
+		writePartial(t, partialPath)
+
+		// the running transcode is not swept
+		removed, err := p.Sweep(dir)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, removed)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.NoFileExists(t, partialPath)
+	assert.FileExists(t, outputPath)
+}
+
+func TestPartialTranscodesSweep(t *testing.T) {
+	dir := t.TempDir()
+	stale := transcodePartialPath(dir, "stale")
+	other := filepath.Join(dir, "other.mp4")
+	writePartial(t, stale)
+	writePartial(t, other)
+
+	p := NewPartialTranscodes()
+	removed, err := p.Sweep(dir)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.NoFileExists(t, stale)
+	assert.FileExists(t, other)
+
+	removed, err = p.Sweep(filepath.Join(dir, "missing"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, removed)
+}
@@ -0,0 +1,99 @@
+package manager
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// RunningTranscode is a live transcode of a scene for a client.
+type RunningTranscode struct {
+	ID         int
+	SceneID    int
+	Codec      string
+	MimeType   string
+	Resolution models.StreamingResolutionEnum
+	// Client is the address of the client receiving the transcode.
+	Client    string
+	StartTime time.Time
+
+	cancel func()
+}
+
+// TranscodeRegistry tracks the live transcodes, so that they can be listed
+// and cancelled.
+type TranscodeRegistry struct {
+	mutex      sync.Mutex
+	lastID     int
+	transcodes map[int]*RunningTranscode
+}
+
+func NewTranscodeRegistry() *TranscodeRegistry {
+	return &TranscodeRegistry{
+		transcodes: make(map[int]*RunningTranscode),
+	}
+}
+
+// Register adds a transcode to the registry, returning its id. cancel is
+// called to stop the transcode when it is cancelled. Deregister must be
+// called with the returned id once the transcode finishes.
+func (r *TranscodeRegistry) Register(t RunningTranscode, cancel func()) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.lastID++
+	t.ID = r.lastID
+	t.cancel = cancel
+	if t.StartTime.IsZero() {
+		t.StartTime = time.Now()
+	}
+
+	r.transcodes[t.ID] = &t
+	return t.ID
+}
+
+// Deregister removes the transcode from the registry.
+func (r *TranscodeRegistry) Deregister(id int) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	delete(r.transcodes, id)
+}
+
+// List returns the running transcodes, ordered by id.
+func (r *TranscodeRegistry) List() []RunningTranscode {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	ret := make([]RunningTranscode, 0, len(r.transcodes))
+	for _, t := range r.transcodes {
+		ret = append(ret, *t)
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i].ID < ret[j].ID
+	})
+
+	return ret
+}
+
+// Cancel stops the transcode and removes it from the registry. Returns
+// false if the transcode is not running.
+func (r *TranscodeRegistry) Cancel(id int) bool {
+	r.mutex.Lock()
+	t, found := r.transcodes[id]
+	delete(r.transcodes, id)
+	r.mutex.Unlock()
+
+	if !found {
+		return false
+	}
+
+	if t.cancel != nil {
+		t.cancel()
+	}
+
+	return true
+}
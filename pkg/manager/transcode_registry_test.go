@@ -0,0 +1,99 @@
+package manager
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranscodeRegistryList(t *testing.T) {
+	r := NewTranscodeRegistry()
+	assert.Empty(t, r.List())
+
+	id1 := r.Register(RunningTranscode{
+		SceneID:    1,
+		Codec:      "libx264",
+		Resolution: models.StreamingResolutionEnumLow,
+		Client:     "127.0.0.1:1234",
+	}, nil)
+	id2 := r.Register(RunningTranscode{SceneID: 2}, nil)
+	assert.NotEqual(t, id1, id2)
+
+	list := r.List()
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, id1, list[0].ID)
+		assert.Equal(t, 1, list[0].SceneID)
+		assert.Equal(t, "libx264", list[0].Codec)
+		assert.Equal(t, models.StreamingResolutionEnumLow, list[0].Resolution)
+		assert.Equal(t, "127.0.0.1:1234", list[0].Client)
+		assert.False(t, list[0].StartTime.IsZero())
+		assert.Equal(t, id2, list[1].ID)
+	}
+
+	r.Deregister(id1)
+	list = r.List()
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, id2, list[0].ID)
+	}
+}
+
+func TestTranscodeRegistryCancel(t *testing.T) {
+	r := NewTranscodeRegistry()
+
+	cancelled := 0
+	id := r.Register(RunningTranscode{SceneID: 1}, func() {
+		cancelled++
+	})
+	other := r.Register(RunningTranscode{SceneID: 2}, nil)
+
+	assert.True(t, r.Cancel(id))
+	assert.Equal(t, 1, cancelled)
+
+	// cancelling again should not call cancel
+	assert.False(t, r.Cancel(id))
+	assert.Equal(t, 1, cancelled)
+
+	list := r.List()
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, other, list[0].ID)
+	}
+
+	// deregistering a cancelled transcode should be harmless
+	r.Deregister(id)
+	assert.Len(t, r.List(), 1)
+}
+
+func TestTranscodeRegistryCancelProcess(t *testing.T) {
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep not available")
+	}
+
+	cmd := exec.Command(sleep, "30")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- cmd.Wait()
+	}()
+
+	r := NewTranscodeRegistry()
+	id := r.Register(RunningTranscode{SceneID: 1}, func() {
+		_ = cmd.Process.Kill()
+	})
+
+	assert.True(t, r.Cancel(id))
+
+	select {
+	case err := <-done:
+		assert.Error(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("process was not killed")
+	}
+	assert.Empty(t, r.List())
+}
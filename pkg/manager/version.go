@@ -0,0 +1,79 @@
+package manager
+
+import (
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// Build information, set at build time using ldflags. See the Makefile.
+var (
+	version    string
+	githash    string
+	buildstamp string
+)
+
+// UnknownVersion is used for build information that was not set at build
+// time.
+const UnknownVersion = "unknown"
+
+// BuildInfo identifies the build of the running binary.
+type BuildInfo struct {
+	Version   string
+	GitHash   string
+	BuildTime string
+	Official  bool
+}
+
+func (b BuildInfo) String() string {
+	build := "Unofficial Build"
+	if b.Official {
+		build = "Official Build"
+	}
+
+	return fmt.Sprintf("%s (%s - %s) - %s", b.Version, b.GitHash, build, b.BuildTime)
+}
+
+// HasVersion returns true if the version was set at build time.
+func (b BuildInfo) HasVersion() bool {
+	return b.Version != UnknownVersion
+}
+
+// VersionModel returns the build information for the GraphQL API. The
+// version is nil if it was not set at build time.
+func (b BuildInfo) VersionModel() *models.Version {
+	ret := &models.Version{
+		Hash:      b.GitHash,
+		BuildTime: b.BuildTime,
+	}
+
+	if b.HasVersion() {
+		v := b.Version
+		ret.Version = &v
+	}
+
+	return ret
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return UnknownVersion
+	}
+	return s
+}
+
+// GetBuildInfo returns the build information of the running binary.
+func GetBuildInfo() BuildInfo {
+	return BuildInfo{
+		Version:   orUnknown(version),
+		GitHash:   orUnknown(githash),
+		BuildTime: orUnknown(buildstamp),
+		Official:  config.IsOfficialBuild(),
+	}
+}
+
+// Version returns the build information of the running binary.
+func (s *singleton) Version() BuildInfo {
+	return GetBuildInfo()
+}
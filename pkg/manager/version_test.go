@@ -0,0 +1,44 @@
+package manager
+
+import "testing"
+
+func setBuildInfo(v, hash, stamp string) func() {
+	oldVersion, oldHash, oldStamp := version, githash, buildstamp
+	version, githash, buildstamp = v, hash, stamp
+	return func() {
+		version, githash, buildstamp = oldVersion, oldHash, oldStamp
+	}
+}
+
+func TestGetBuildInfo(t *testing.T) {
+	defer setBuildInfo("v0.13.0", "abcdef1", "2022-01-02 03:04:05")()
+
+	got := GetBuildInfo()
+	if got.Version != "v0.13.0" || got.GitHash != "abcdef1" || got.BuildTime != "2022-01-02 03:04:05" {
+		t.Errorf("GetBuildInfo() = %+v", got)
+	}
+	if !got.HasVersion() {
+		t.Error("HasVersion() = false for set version")
+	}
+
+	v := got.VersionModel()
+	if v.Version == nil || *v.Version != "v0.13.0" || v.Hash != "abcdef1" || v.BuildTime != "2022-01-02 03:04:05" {
+		t.Errorf("VersionModel() = %+v", v)
+	}
+}
+
+func TestGetBuildInfoUnknown(t *testing.T) {
+	defer setBuildInfo("", "", "")()
+
+	got := GetBuildInfo()
+	if got.Version != UnknownVersion || got.GitHash != UnknownVersion || got.BuildTime != UnknownVersion {
+		t.Errorf("GetBuildInfo() = %+v, want %s values", got, UnknownVersion)
+	}
+	if got.HasVersion() {
+		t.Error("HasVersion() = true for unset version")
+	}
+
+	if v := got.VersionModel(); v.Version != nil || v.Hash != UnknownVersion {
+		t.Errorf("VersionModel() = %+v", v)
+	}
+}
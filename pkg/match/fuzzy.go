@@ -0,0 +1,149 @@
+package match
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// minFuzzyWordLength is the minimum length of a name word for it to be
+	// fuzzy matched. Shorter words must match exactly.
+	minFuzzyWordLength = 4
+	// maxFuzzyWordLength is the maximum length of a word that is fuzzy
+	// matched, bounding the cost of computing the edit distance.
+	maxFuzzyWordLength = 32
+)
+
+var pathWordRE = regexp.MustCompile(`[^\W_]+`)
+
+// PathMatcher matches names against paths. A name matches a path if the
+// path contains the full name, ignoring any separator characters. If
+// FuzzyDistance is greater than zero and a name does not match exactly, the
+// name also matches if each of its words is within FuzzyDistance edits of
+// consecutive words in the path.
+//
+// Candidate names are queried by the first two characters of each path
+// word, so a name is only fuzzy matched if its first two characters match
+// the start of a path word exactly.
+type PathMatcher struct {
+	// FuzzyDistance is the maximum Levenshtein distance between a name word
+	// and a path word. Fuzzy matching is disabled if zero.
+	FuzzyDistance int
+}
+
+// nameMatchesPath returns the index in the path for the right-most match.
+// Returns -1 if not found.
+func (m PathMatcher) nameMatchesPath(name, path string) int {
+	ret := nameMatchesPath(name, path)
+	if ret == -1 && m.FuzzyDistance > 0 {
+		ret = fuzzyNameMatchesPath(name, path, m.FuzzyDistance)
+	}
+
+	return ret
+}
+
+// fuzzyNameMatchesPath returns the index in the path of the right-most
+// sequence of path words that fuzzy match the words of name. Returns -1 if
+// not found.
+func fuzzyNameMatchesPath(name, path string, maxDistance int) int {
+	nameWords := pathWordRE.FindAllString(strings.ToLower(name), -1)
+	if len(nameWords) == 0 {
+		return -1
+	}
+
+	path = strings.ToLower(path)
+	pathWords := pathWordRE.FindAllStringIndex(path, -1)
+
+	for i := len(pathWords) - len(nameWords); i >= 0; i-- {
+		matched := true
+		for j, w := range nameWords {
+			loc := pathWords[i+j]
+			if !fuzzyWordMatches(w, path[loc[0]:loc[1]], maxDistance) {
+				matched = false
+				break
+			}
+		}
+
+		if matched {
+			return pathWords[i][0]
+		}
+	}
+
+	return -1
+}
+
+// fuzzyWordMatches returns true if the words are equal, or if they are
+// within maxDistance edits of each other and the distance is less than half
+// the length of the name word.
+func fuzzyWordMatches(nameWord, pathWord string, maxDistance int) bool {
+	if nameWord == pathWord {
+		return true
+	}
+
+	nameLen := utf8.RuneCountInString(nameWord)
+	pathLen := utf8.RuneCountInString(pathWord)
+	if nameLen < minFuzzyWordLength || nameLen > maxFuzzyWordLength || pathLen > maxFuzzyWordLength {
+		return false
+	}
+
+	if maxDistance*2 >= nameLen {
+		maxDistance = (nameLen - 1) / 2
+	}
+
+	return levenshtein(nameWord, pathWord, maxDistance) <= maxDistance
+}
+
+// levenshtein returns the edit distance between a and b. Returns a value
+// greater than maxDistance as soon as the distance is known to exceed it.
+func levenshtein(a, b string, maxDistance int) int {
+	ar := []rune(a)
+	br := []rune(b)
+
+	diff := len(ar) - len(br)
+	if diff < 0 {
+		diff = -diff
+	}
+	if diff > maxDistance {
+		return diff
+	}
+
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if cur[j] < rowMin {
+				rowMin = cur[j]
+			}
+		}
+
+		if rowMin > maxDistance {
+			return rowMin
+		}
+
+		prev, cur = cur, prev
+	}
+
+	return prev[len(br)]
+}
+
+func minInt(v int, others ...int) int {
+	for _, o := range others {
+		if o < v {
+			v = o
+		}
+	}
+	return v
+}
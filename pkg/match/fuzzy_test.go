@@ -0,0 +1,86 @@
+package match
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_levenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"jennifer", "jennifer", 0},
+		{"jennifer", "jenifer", 1},
+		{"jennifer", "jeniffer", 2},
+		{"kitten", "sitting", 3},
+		{"žena", "zena", 1},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, levenshtein(tt.a, tt.b, 10), "%s -> %s", tt.a, tt.b)
+	}
+
+	// stops once the distance exceeds the maximum
+	assert.Greater(t, levenshtein("kitten", "sitting", 1), 1)
+}
+
+func TestPathMatcher_nameMatchesPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		distance int
+		path     string
+		want     int
+	}{
+		{"jennifer", 0, "Jeniffer.mp4", -1},
+		{"jennifer", 1, "Jeniffer.mp4", -1},
+		{"jennifer", 2, "Jeniffer.mp4", 0},
+		// exact matches are preferred
+		{"jennifer", 2, "jeniffer jennifer.mp4", 8},
+		// right-most fuzzy match
+		{"jennifer", 2, "jeniffer/jenifer.mp4", 9},
+		{"first last", 1, "dir/firsst lastt.mp4", 4},
+		// all words must match, in order
+		{"first last", 1, "dir/firsst.mp4", -1},
+		{"first last", 1, "dir/lastt firsst.mp4", -1},
+		// words shorter than the minimum length are not fuzzy matched
+		{"anna", 1, "ann.mp4", 0},
+		{"ann", 1, "anne.mp4", -1},
+		// distance must be less than half the word length
+		{"anna", 3, "bob.mp4", -1},
+		// words are split on separators
+		{"jennifer", 2, "jenniferx.mp4", 0},
+		{"jennifer", 2, "jennifer_x.mp4", 0},
+	}
+
+	for _, tt := range tests {
+		m := PathMatcher{FuzzyDistance: tt.distance}
+		assert.Equal(t, tt.want, m.nameMatchesPath(tt.name, tt.path), "%s in %s with distance %d", tt.name, tt.path, tt.distance)
+	}
+}
+
+func TestPathMatcher_PathToPerformers(t *testing.T) {
+	const path = "Jeniffer.mp4"
+
+	performer := &models.Performer{
+		ID:   1,
+		Name: models.NullString("Jennifer"),
+	}
+
+	mockPerformerReader := &mocks.PerformerReaderWriter{}
+	mockPerformerReader.On("QueryForAutoTag", mock.Anything).Return([]*models.Performer{performer}, nil)
+
+	ret, err := PathToPerformers(path, mockPerformerReader)
+	assert.Nil(t, err)
+	assert.Empty(t, ret)
+
+	ret, err = PathMatcher{FuzzyDistance: 2}.PathToPerformers(path, mockPerformerReader)
+	assert.Nil(t, err)
+	assert.Equal(t, []*models.Performer{performer}, ret)
+}
@@ -84,7 +84,13 @@ func nameMatchesPath(name, path string) int {
 	return found[len(found)-1][0]
 }
 
+// PathToPerformers returns the performers whose name matches the given path.
 func PathToPerformers(path string, performerReader models.PerformerReader) ([]*models.Performer, error) {
+	return PathMatcher{}.PathToPerformers(path, performerReader)
+}
+
+// PathToPerformers returns the performers whose name matches the given path.
+func (m PathMatcher) PathToPerformers(path string, performerReader models.PerformerReader) ([]*models.Performer, error) {
 	words := getPathWords(path)
 	performers, err := performerReader.QueryForAutoTag(words)
 
@@ -95,7 +101,7 @@ func PathToPerformers(path string, performerReader models.PerformerReader) ([]*m
 	var ret []*models.Performer
 	for _, p := range performers {
 		// TODO - commenting out alias handling until both sides work correctly
-		if nameMatchesPath(p.Name.String, path) != -1 { // || nameMatchesPath(p.Aliases.String, path) {
+		if m.nameMatchesPath(p.Name.String, path) != -1 { // || nameMatchesPath(p.Aliases.String, path) {
 			ret = append(ret, p)
 		}
 	}
@@ -107,6 +113,13 @@ func PathToPerformers(path string, performerReader models.PerformerReader) ([]*m
 // Where multiple matching studios are found, the one that matches the latest
 // position in the path is returned.
 func PathToStudio(path string, reader models.StudioReader) (*models.Studio, error) {
+	return PathMatcher{}.PathToStudio(path, reader)
+}
+
+// PathToStudio returns the Studio that matches the given path.
+// Where multiple matching studios are found, the one that matches the latest
+// position in the path is returned.
+func (m PathMatcher) PathToStudio(path string, reader models.StudioReader) (*models.Studio, error) {
 	words := getPathWords(path)
 	candidates, err := reader.QueryForAutoTag(words)
 
@@ -117,7 +130,7 @@ func PathToStudio(path string, reader models.StudioReader) (*models.Studio, erro
 	var ret *models.Studio
 	index := -1
 	for _, c := range candidates {
-		matchIndex := nameMatchesPath(c.Name.String, path)
+		matchIndex := m.nameMatchesPath(c.Name.String, path)
 		if matchIndex != -1 && matchIndex > index {
 			ret = c
 			index = matchIndex
@@ -129,7 +142,7 @@ func PathToStudio(path string, reader models.StudioReader) (*models.Studio, erro
 		}
 
 		for _, alias := range aliases {
-			matchIndex = nameMatchesPath(alias, path)
+			matchIndex = m.nameMatchesPath(alias, path)
 			if matchIndex != -1 && matchIndex > index {
 				ret = c
 				index = matchIndex
@@ -140,7 +153,13 @@ func PathToStudio(path string, reader models.StudioReader) (*models.Studio, erro
 	return ret, nil
 }
 
+// PathToTags returns the tags whose name or aliases match the given path.
 func PathToTags(path string, tagReader models.TagReader) ([]*models.Tag, error) {
+	return PathMatcher{}.PathToTags(path, tagReader)
+}
+
+// PathToTags returns the tags whose name or aliases match the given path.
+func (m PathMatcher) PathToTags(path string, tagReader models.TagReader) ([]*models.Tag, error) {
 	words := getPathWords(path)
 	tags, err := tagReader.QueryForAutoTag(words)
 
@@ -151,7 +170,7 @@ func PathToTags(path string, tagReader models.TagReader) ([]*models.Tag, error)
 	var ret []*models.Tag
 	for _, t := range tags {
 		matches := false
-		if nameMatchesPath(t.Name, path) != -1 {
+		if m.nameMatchesPath(t.Name, path) != -1 {
 			matches = true
 		}
 
@@ -160,8 +179,17 @@ func PathToTags(path string, tagReader models.TagReader) ([]*models.Tag, error)
 			if err != nil {
 				return nil, err
 			}
+
+			if t.GenerateAliases {
+				generated, err := tagReader.GetGeneratedAliases(t.ID)
+				if err != nil {
+					return nil, err
+				}
+				aliases = append(aliases, generated...)
+			}
+
 			for _, alias := range aliases {
-				if nameMatchesPath(alias, path) != -1 {
+				if m.nameMatchesPath(alias, path) != -1 {
 					matches = true
 					break
 				}
@@ -1,9 +1,30 @@
 package models
 
+import "sync/atomic"
+
 // PerPageAll is the value used for perPage to indicate all results should be
 // returned.
 const PerPageAll = -1
 
+// DefaultMaxPerPage is the default maximum page size.
+const DefaultMaxPerPage = 1000
+
+var maxPerPage int64 = DefaultMaxPerPage
+
+// SetMaxPerPage sets the maximum page size of filters capped by
+// CapPageSize. Values less than one restore the default.
+func SetMaxPerPage(n int) {
+	if n < 1 {
+		n = DefaultMaxPerPage
+	}
+	atomic.StoreInt64(&maxPerPage, int64(n))
+}
+
+// GetMaxPerPage returns the maximum page size.
+func GetMaxPerPage() int {
+	return int(atomic.LoadInt64(&maxPerPage))
+}
+
 func (ff FindFilterType) GetSort(defaultSort string) string {
 	var sort string
 	if ff.Sort == nil {
@@ -37,25 +58,45 @@ func (ff FindFilterType) GetPage() int {
 	return *ff.Page
 }
 
+const defaultPerPage = 25
+
 func (ff FindFilterType) GetPageSize() int {
-	const defaultPerPage = 25
 	const minPerPage = 0
-	const maxPerPage = 1000
 
-	if ff.PerPage == nil {
-		return defaultPerPage
+	perPage := defaultPerPage
+	if ff.PerPage != nil {
+		perPage = *ff.PerPage
 	}
 
-	if *ff.PerPage > maxPerPage {
-		return maxPerPage
-	} else if *ff.PerPage < minPerPage {
+	if perPage < minPerPage {
 		// negative page sizes should return all results
 		// this is a sanity check in case GetPageSize is
 		// called with a negative page size.
 		return minPerPage
 	}
 
-	return *ff.PerPage
+	return perPage
+}
+
+// CapPageSize returns a copy of the filter with the page size reduced to the
+// maximum page size. It is applied to filters provided by API clients, so
+// that internal batch queries are not affected by the maximum. Requests for
+// all results are not capped.
+func (ff *FindFilterType) CapPageSize() *FindFilterType {
+	if ff == nil {
+		if defaultPerPage <= GetMaxPerPage() {
+			return nil
+		}
+		ff = &FindFilterType{}
+	}
+
+	ret := *ff
+	maxPerPage := GetMaxPerPage()
+	if ret.GetPageSize() > maxPerPage {
+		ret.PerPage = &maxPerPage
+	}
+
+	return &ret
 }
 
 func (ff FindFilterType) IsGetAll() bool {
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindFilterCapPageSize(t *testing.T) {
+	defer SetMaxPerPage(DefaultMaxPerPage)
+
+	perPage := func(v int) *int {
+		return &v
+	}
+
+	tests := []struct {
+		name       string
+		maxPerPage int
+		perPage    *int
+		want       int
+	}{
+		{"default", DefaultMaxPerPage, nil, 25},
+		{"explicit", DefaultMaxPerPage, perPage(40), 40},
+		{"oversized", DefaultMaxPerPage, perPage(5000), DefaultMaxPerPage},
+		{"negative", DefaultMaxPerPage, perPage(-1), 0},
+		{"configured maximum", 10, perPage(40), 10},
+		{"default capped", 10, nil, 10},
+		{"invalid maximum", 0, perPage(5000), DefaultMaxPerPage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetMaxPerPage(tt.maxPerPage)
+			ff := FindFilterType{PerPage: tt.perPage}
+			assert.Equal(t, tt.want, ff.CapPageSize().GetPageSize())
+		})
+	}
+}
+
+func TestFindFilterGetPageSizeUncapped(t *testing.T) {
+	SetMaxPerPage(10)
+	defer SetMaxPerPage(DefaultMaxPerPage)
+
+	// internal batch queries are not limited by the maximum page size
+	assert.Equal(t, 1000, BatchFindFilter(1000).GetPageSize())
+	assert.Equal(t, 25, FindFilterType{}.GetPageSize())
+}
@@ -0,0 +1,20 @@
+package models
+
+import "database/sql"
+
+type GenerateFailureReader interface {
+	FindByItem(artifact GenerateArtifact, sceneID sql.NullInt64, sceneMarkerID sql.NullInt64) (*GenerateFailure, error)
+	All() ([]*GenerateFailure, error)
+}
+
+type GenerateFailureWriter interface {
+	Create(obj GenerateFailure) (*GenerateFailure, error)
+	Update(obj GenerateFailure) (*GenerateFailure, error)
+	Destroy(id int) error
+	DestroyAll() error
+}
+
+type GenerateFailureReaderWriter interface {
+	GenerateFailureReader
+	GenerateFailureWriter
+}
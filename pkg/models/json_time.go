@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/stashapp/stash/pkg/logger"
@@ -11,6 +12,13 @@ import (
 
 var currentLocation = time.Now().Location()
 
+var marshalLocation atomic.Value
+
+// SetJSONTimeLocation sets the location in which JSON times are marshalled.
+func SetJSONTimeLocation(loc *time.Location) {
+	marshalLocation.Store(loc)
+}
+
 type JSONTime struct {
 	time.Time
 }
@@ -36,7 +44,11 @@ func (jt *JSONTime) MarshalJSON() ([]byte, error) {
 	if jt.Time.IsZero() {
 		return []byte("null"), nil
 	}
-	return []byte(fmt.Sprintf("\"%s\"", jt.Time.Format(time.RFC3339))), nil
+	t := jt.Time
+	if loc, ok := marshalLocation.Load().(*time.Location); ok {
+		t = t.In(loc)
+	}
+	return []byte(fmt.Sprintf("\"%s\"", t.Format(time.RFC3339))), nil
 }
 
 func (jt JSONTime) GetTime() time.Time {
@@ -0,0 +1,135 @@
+// Code generated by mockery v0.0.0-dev. DO NOT EDIT.
+
+package mocks
+
+import (
+	sql "database/sql"
+
+	models "github.com/stashapp/stash/pkg/models"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// GenerateFailureReaderWriter is an autogenerated mock type for the GenerateFailureReaderWriter type
+type GenerateFailureReaderWriter struct {
+	mock.Mock
+}
+
+// All provides a mock function with given fields:
+func (_m *GenerateFailureReaderWriter) All() ([]*models.GenerateFailure, error) {
+	ret := _m.Called()
+
+	var r0 []*models.GenerateFailure
+	if rf, ok := ret.Get(0).(func() []*models.GenerateFailure); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.GenerateFailure)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: obj
+func (_m *GenerateFailureReaderWriter) Create(obj models.GenerateFailure) (*models.GenerateFailure, error) {
+	ret := _m.Called(obj)
+
+	var r0 *models.GenerateFailure
+	if rf, ok := ret.Get(0).(func(models.GenerateFailure) *models.GenerateFailure); ok {
+		r0 = rf(obj)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.GenerateFailure)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(models.GenerateFailure) error); ok {
+		r1 = rf(obj)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Destroy provides a mock function with given fields: id
+func (_m *GenerateFailureReaderWriter) Destroy(id int) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DestroyAll provides a mock function with given fields:
+func (_m *GenerateFailureReaderWriter) DestroyAll() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindByItem provides a mock function with given fields: artifact, sceneID, sceneMarkerID
+func (_m *GenerateFailureReaderWriter) FindByItem(artifact models.GenerateArtifact, sceneID sql.NullInt64, sceneMarkerID sql.NullInt64) (*models.GenerateFailure, error) {
+	ret := _m.Called(artifact, sceneID, sceneMarkerID)
+
+	var r0 *models.GenerateFailure
+	if rf, ok := ret.Get(0).(func(models.GenerateArtifact, sql.NullInt64, sql.NullInt64) *models.GenerateFailure); ok {
+		r0 = rf(artifact, sceneID, sceneMarkerID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.GenerateFailure)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(models.GenerateArtifact, sql.NullInt64, sql.NullInt64) error); ok {
+		r1 = rf(artifact, sceneID, sceneMarkerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Update provides a mock function with given fields: obj
+func (_m *GenerateFailureReaderWriter) Update(obj models.GenerateFailure) (*models.GenerateFailure, error) {
+	ret := _m.Called(obj)
+
+	var r0 *models.GenerateFailure
+	if rf, ok := ret.Get(0).(func(models.GenerateFailure) *models.GenerateFailure); ok {
+		r0 = rf(obj)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.GenerateFailure)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(models.GenerateFailure) error); ok {
+		r1 = rf(obj)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -404,6 +404,43 @@ func (_m *PerformerReaderWriter) GetTagIDs(performerID int) ([]int, error) {
 	return r0, r1
 }
 
+// Merge provides a mock function with given fields: source, destination
+func (_m *PerformerReaderWriter) Merge(source []int, destination int) error {
+	ret := _m.Called(source, destination)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]int, int) error); ok {
+		r0 = rf(source, destination)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MergeCounts provides a mock function with given fields: source, destination
+func (_m *PerformerReaderWriter) MergeCounts(source []int, destination int) (*models.MergeCounts, error) {
+	ret := _m.Called(source, destination)
+
+	var r0 *models.MergeCounts
+	if rf, ok := ret.Get(0).(func([]int, int) *models.MergeCounts); ok {
+		r0 = rf(source, destination)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.MergeCounts)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]int, int) error); ok {
+		r1 = rf(source, destination)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Query provides a mock function with given fields: performerFilter, findFilter
 func (_m *PerformerReaderWriter) Query(performerFilter *models.PerformerFilterType, findFilter *models.FindFilterType) ([]*models.Performer, int, error) {
 	ret := _m.Called(performerFilter, findFilter)
@@ -457,6 +494,27 @@ func (_m *PerformerReaderWriter) QueryForAutoTag(words []string) ([]*models.Perf
 	return r0, r1
 }
 
+// SetFavorite provides a mock function with given fields: performerIDs, favorite
+func (_m *PerformerReaderWriter) SetFavorite(performerIDs []int, favorite bool) (int, error) {
+	ret := _m.Called(performerIDs, favorite)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func([]int, bool) int); ok {
+		r0 = rf(performerIDs, favorite)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]int, bool) error); ok {
+		r1 = rf(performerIDs, favorite)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: updatedPerformer
 func (_m *PerformerReaderWriter) Update(updatedPerformer models.PerformerPartial) (*models.Performer, error) {
 	ret := _m.Called(updatedPerformer)
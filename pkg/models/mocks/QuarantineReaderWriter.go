@@ -0,0 +1,110 @@
+// Code generated by mockery v0.0.0-dev. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "github.com/stashapp/stash/pkg/models"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// QuarantineReaderWriter is an autogenerated mock type for the QuarantineReaderWriter type
+type QuarantineReaderWriter struct {
+	mock.Mock
+}
+
+// All provides a mock function with given fields:
+func (_m *QuarantineReaderWriter) All() ([]*models.QuarantinedFile, error) {
+	ret := _m.Called()
+
+	var r0 []*models.QuarantinedFile
+	if rf, ok := ret.Get(0).(func() []*models.QuarantinedFile); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.QuarantinedFile)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Create provides a mock function with given fields: obj
+func (_m *QuarantineReaderWriter) Create(obj models.QuarantinedFile) (*models.QuarantinedFile, error) {
+	ret := _m.Called(obj)
+
+	var r0 *models.QuarantinedFile
+	if rf, ok := ret.Get(0).(func(models.QuarantinedFile) *models.QuarantinedFile); ok {
+		r0 = rf(obj)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.QuarantinedFile)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(models.QuarantinedFile) error); ok {
+		r1 = rf(obj)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Destroy provides a mock function with given fields: id
+func (_m *QuarantineReaderWriter) Destroy(id int) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DestroyAll provides a mock function with given fields:
+func (_m *QuarantineReaderWriter) DestroyAll() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindByPath provides a mock function with given fields: path
+func (_m *QuarantineReaderWriter) FindByPath(path string) (*models.QuarantinedFile, error) {
+	ret := _m.Called(path)
+
+	var r0 *models.QuarantinedFile
+	if rf, ok := ret.Get(0).(func(string) *models.QuarantinedFile); ok {
+		r0 = rf(path)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.QuarantinedFile)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(path)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
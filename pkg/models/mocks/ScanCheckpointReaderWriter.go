@@ -0,0 +1,73 @@
+// Code generated by mockery v0.0.0-dev. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "github.com/stashapp/stash/pkg/models"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ScanCheckpointReaderWriter is an autogenerated mock type for the ScanCheckpointReaderWriter type
+type ScanCheckpointReaderWriter struct {
+	mock.Mock
+}
+
+// DestroyByPaths provides a mock function with given fields: paths
+func (_m *ScanCheckpointReaderWriter) DestroyByPaths(paths string) error {
+	ret := _m.Called(paths)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(paths)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindByPaths provides a mock function with given fields: paths
+func (_m *ScanCheckpointReaderWriter) FindByPaths(paths string) (*models.ScanCheckpoint, error) {
+	ret := _m.Called(paths)
+
+	var r0 *models.ScanCheckpoint
+	if rf, ok := ret.Get(0).(func(string) *models.ScanCheckpoint); ok {
+		r0 = rf(paths)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ScanCheckpoint)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(paths)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: obj
+func (_m *ScanCheckpointReaderWriter) Save(obj models.ScanCheckpoint) (*models.ScanCheckpoint, error) {
+	ret := _m.Called(obj)
+
+	var r0 *models.ScanCheckpoint
+	if rf, ok := ret.Get(0).(func(models.ScanCheckpoint) *models.ScanCheckpoint); ok {
+		r0 = rf(obj)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ScanCheckpoint)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(models.ScanCheckpoint) error); ok {
+		r1 = rf(obj)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -0,0 +1,73 @@
+// Code generated by mockery v0.0.0-dev. DO NOT EDIT.
+
+package mocks
+
+import (
+	models "github.com/stashapp/stash/pkg/models"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ScanSnapshotReaderWriter is an autogenerated mock type for the ScanSnapshotReaderWriter type
+type ScanSnapshotReaderWriter struct {
+	mock.Mock
+}
+
+// DestroyByPath provides a mock function with given fields: path
+func (_m *ScanSnapshotReaderWriter) DestroyByPath(path string) error {
+	ret := _m.Called(path)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(path)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindByPath provides a mock function with given fields: path
+func (_m *ScanSnapshotReaderWriter) FindByPath(path string) (*models.ScanSnapshot, error) {
+	ret := _m.Called(path)
+
+	var r0 *models.ScanSnapshot
+	if rf, ok := ret.Get(0).(func(string) *models.ScanSnapshot); ok {
+		r0 = rf(path)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ScanSnapshot)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(path)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Save provides a mock function with given fields: obj
+func (_m *ScanSnapshotReaderWriter) Save(obj models.ScanSnapshot) (*models.ScanSnapshot, error) {
+	ret := _m.Called(obj)
+
+	var r0 *models.ScanSnapshot
+	if rf, ok := ret.Get(0).(func(models.ScanSnapshot) *models.ScanSnapshot); ok {
+		r0 = rf(obj)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.ScanSnapshot)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(models.ScanSnapshot) error); ok {
+		r1 = rf(obj)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
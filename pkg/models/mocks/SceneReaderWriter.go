@@ -5,6 +5,8 @@ package mocks
 import (
 	models "github.com/stashapp/stash/pkg/models"
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // SceneReaderWriter is an autogenerated mock type for the SceneReaderWriter type
@@ -12,6 +14,27 @@ type SceneReaderWriter struct {
 	mock.Mock
 }
 
+// AddTags provides a mock function with given fields: sceneIDs, tagIDs
+func (_m *SceneReaderWriter) AddTags(sceneIDs []int, tagIDs []int) (int, error) {
+	ret := _m.Called(sceneIDs, tagIDs)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func([]int, []int) int); ok {
+		r0 = rf(sceneIDs, tagIDs)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]int, []int) error); ok {
+		r1 = rf(sceneIDs, tagIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // All provides a mock function with given fields:
 func (_m *SceneReaderWriter) All() ([]*models.Scene, error) {
 	ret := _m.Called()
@@ -436,6 +459,29 @@ func (_m *SceneReaderWriter) FindByPerformerID(performerID int) ([]*models.Scene
 	return r0, r1
 }
 
+// FindDeletedBefore provides a mock function with given fields: t
+func (_m *SceneReaderWriter) FindDeletedBefore(t time.Time) ([]*models.Scene, error) {
+	ret := _m.Called(t)
+
+	var r0 []*models.Scene
+	if rf, ok := ret.Get(0).(func(time.Time) []*models.Scene); ok {
+		r0 = rf(t)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Scene)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(time.Time) error); ok {
+		r1 = rf(t)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindDuplicates provides a mock function with given fields: distance
 func (_m *SceneReaderWriter) FindDuplicates(distance int) ([][]*models.Scene, error) {
 	ret := _m.Called(distance)
@@ -459,6 +505,29 @@ func (_m *SceneReaderWriter) FindDuplicates(distance int) ([][]*models.Scene, er
 	return r0, r1
 }
 
+// FindByStashID provides a mock function with given fields: stashID
+func (_m *SceneReaderWriter) FindByStashID(stashID models.StashID) ([]*models.Scene, error) {
+	ret := _m.Called(stashID)
+
+	var r0 []*models.Scene
+	if rf, ok := ret.Get(0).(func(models.StashID) []*models.Scene); ok {
+		r0 = rf(stashID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Scene)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(models.StashID) error); ok {
+		r1 = rf(stashID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindMany provides a mock function with given fields: ids
 func (_m *SceneReaderWriter) FindMany(ids []int) ([]*models.Scene, error) {
 	ret := _m.Called(ids)
@@ -482,6 +551,52 @@ func (_m *SceneReaderWriter) FindMany(ids []int) ([]*models.Scene, error) {
 	return r0, r1
 }
 
+// FindSharedStashIDs provides a mock function with given fields:
+func (_m *SceneReaderWriter) FindSharedStashIDs() ([][]int, error) {
+	ret := _m.Called()
+
+	var r0 [][]int
+	if rf, ok := ret.Get(0).(func() [][]int); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([][]int)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindSimilar provides a mock function with given fields: phash, distance
+func (_m *SceneReaderWriter) FindSimilar(phash int64, distance int) ([]*models.Scene, error) {
+	ret := _m.Called(phash, distance)
+
+	var r0 []*models.Scene
+	if rf, ok := ret.Get(0).(func(int64, int) []*models.Scene); ok {
+		r0 = rf(phash, distance)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.Scene)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int64, int) error); ok {
+		r1 = rf(phash, distance)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetCover provides a mock function with given fields: sceneID
 func (_m *SceneReaderWriter) GetCover(sceneID int) ([]byte, error) {
 	ret := _m.Called(sceneID)
@@ -597,6 +712,29 @@ func (_m *SceneReaderWriter) GetStashIDs(sceneID int) ([]*models.StashID, error)
 	return r0, r1
 }
 
+// GetSubtitles provides a mock function with given fields: sceneID
+func (_m *SceneReaderWriter) GetSubtitles(sceneID int) ([]*models.SceneSubtitle, error) {
+	ret := _m.Called(sceneID)
+
+	var r0 []*models.SceneSubtitle
+	if rf, ok := ret.Get(0).(func(int) []*models.SceneSubtitle); ok {
+		r0 = rf(sceneID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*models.SceneSubtitle)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(sceneID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetTagIDs provides a mock function with given fields: sceneID
 func (_m *SceneReaderWriter) GetTagIDs(sceneID int) ([]int, error) {
 	ret := _m.Called(sceneID)
@@ -641,6 +779,27 @@ func (_m *SceneReaderWriter) IncrementOCounter(id int) (int, error) {
 	return r0, r1
 }
 
+// IncrementPlayCount provides a mock function with given fields: id, playedAt
+func (_m *SceneReaderWriter) IncrementPlayCount(id int, playedAt time.Time) (int, error) {
+	ret := _m.Called(id, playedAt)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(int, time.Time) int); ok {
+		r0 = rf(id, playedAt)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int, time.Time) error); ok {
+		r1 = rf(id, playedAt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Query provides a mock function with given fields: options
 func (_m *SceneReaderWriter) Query(options models.SceneQueryOptions) (*models.SceneQueryResult, error) {
 	ret := _m.Called(options)
@@ -664,6 +823,48 @@ func (_m *SceneReaderWriter) Query(options models.SceneQueryOptions) (*models.Sc
 	return r0, r1
 }
 
+// RemoveTags provides a mock function with given fields: sceneIDs, tagIDs
+func (_m *SceneReaderWriter) RemoveTags(sceneIDs []int, tagIDs []int) (int, error) {
+	ret := _m.Called(sceneIDs, tagIDs)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func([]int, []int) int); ok {
+		r0 = rf(sceneIDs, tagIDs)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]int, []int) error); ok {
+		r1 = rf(sceneIDs, tagIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ResetCounters provides a mock function with given fields: sceneIDs, counters
+func (_m *SceneReaderWriter) ResetCounters(sceneIDs []int, counters []models.SceneCounter) (int, error) {
+	ret := _m.Called(sceneIDs, counters)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func([]int, []models.SceneCounter) int); ok {
+		r0 = rf(sceneIDs, counters)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]int, []models.SceneCounter) error); ok {
+		r1 = rf(sceneIDs, counters)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ResetOCounter provides a mock function with given fields: id
 func (_m *SceneReaderWriter) ResetOCounter(id int) (int, error) {
 	ret := _m.Called(id)
@@ -685,6 +886,20 @@ func (_m *SceneReaderWriter) ResetOCounter(id int) (int, error) {
 	return r0, r1
 }
 
+// Restore provides a mock function with given fields: id
+func (_m *SceneReaderWriter) Restore(id int) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Size provides a mock function with given fields:
 func (_m *SceneReaderWriter) Size() (float64, error) {
 	ret := _m.Called()
@@ -706,6 +921,20 @@ func (_m *SceneReaderWriter) Size() (float64, error) {
 	return r0, r1
 }
 
+// SoftDestroy provides a mock function with given fields: id, deleteFile, deleteGenerated
+func (_m *SceneReaderWriter) SoftDestroy(id int, deleteFile bool, deleteGenerated bool) error {
+	ret := _m.Called(id, deleteFile, deleteGenerated)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, bool, bool) error); ok {
+		r0 = rf(id, deleteFile, deleteGenerated)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Update provides a mock function with given fields: updatedScene
 func (_m *SceneReaderWriter) Update(updatedScene models.ScenePartial) (*models.Scene, error) {
 	ret := _m.Called(updatedScene)
@@ -836,6 +1065,20 @@ func (_m *SceneReaderWriter) UpdateStashIDs(sceneID int, stashIDs []models.Stash
 	return r0
 }
 
+// UpdateSubtitles provides a mock function with given fields: sceneID, subtitles
+func (_m *SceneReaderWriter) UpdateSubtitles(sceneID int, subtitles []models.SceneSubtitle) error {
+	ret := _m.Called(sceneID, subtitles)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, []models.SceneSubtitle) error); ok {
+		r0 = rf(sceneID, subtitles)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateTags provides a mock function with given fields: sceneID, tagIDs
 func (_m *SceneReaderWriter) UpdateTags(sceneID int, tagIDs []int) error {
 	ret := _m.Called(sceneID, tagIDs)
@@ -872,3 +1115,26 @@ func (_m *SceneReaderWriter) Wall(q *string) ([]*models.Scene, error) {
 
 	return r0, r1
 }
+
+// Years provides a mock function with given fields:
+func (_m *SceneReaderWriter) Years() ([]int, error) {
+	ret := _m.Called()
+
+	var r0 []int
+	if rf, ok := ret.Get(0).(func() []int); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]int)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -312,6 +312,43 @@ func (_m *StudioReaderWriter) HasImage(studioID int) (bool, error) {
 	return r0, r1
 }
 
+// Merge provides a mock function with given fields: source, destination
+func (_m *StudioReaderWriter) Merge(source []int, destination int) error {
+	ret := _m.Called(source, destination)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func([]int, int) error); ok {
+		r0 = rf(source, destination)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MergeCounts provides a mock function with given fields: source, destination
+func (_m *StudioReaderWriter) MergeCounts(source []int, destination int) (*models.MergeCounts, error) {
+	ret := _m.Called(source, destination)
+
+	var r0 *models.MergeCounts
+	if rf, ok := ret.Get(0).(func([]int, int) *models.MergeCounts); ok {
+		r0 = rf(source, destination)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.MergeCounts)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]int, int) error); ok {
+		r1 = rf(source, destination)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Query provides a mock function with given fields: studioFilter, findFilter
 func (_m *StudioReaderWriter) Query(studioFilter *models.StudioFilterType, findFilter *models.FindFilterType) ([]*models.Studio, int, error) {
 	ret := _m.Called(studioFilter, findFilter)
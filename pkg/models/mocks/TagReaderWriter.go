@@ -429,6 +429,29 @@ func (_m *TagReaderWriter) GetAliases(tagID int) ([]string, error) {
 	return r0, r1
 }
 
+// GetGeneratedAliases provides a mock function with given fields: tagID
+func (_m *TagReaderWriter) GetGeneratedAliases(tagID int) ([]string, error) {
+	ret := _m.Called(tagID)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(int) []string); ok {
+		r0 = rf(tagID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(int) error); ok {
+		r1 = rf(tagID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetImage provides a mock function with given fields: tagID
 func (_m *TagReaderWriter) GetImage(tagID int) ([]byte, error) {
 	ret := _m.Called(tagID)
@@ -466,6 +489,29 @@ func (_m *TagReaderWriter) Merge(source []int, destination int) error {
 	return r0
 }
 
+// MergeCounts provides a mock function with given fields: source, destination
+func (_m *TagReaderWriter) MergeCounts(source []int, destination int) (*models.MergeCounts, error) {
+	ret := _m.Called(source, destination)
+
+	var r0 *models.MergeCounts
+	if rf, ok := ret.Get(0).(func([]int, int) *models.MergeCounts); ok {
+		r0 = rf(source, destination)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*models.MergeCounts)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]int, int) error); ok {
+		r1 = rf(source, destination)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Query provides a mock function with given fields: tagFilter, findFilter
 func (_m *TagReaderWriter) Query(tagFilter *models.TagFilterType, findFilter *models.FindFilterType) ([]*models.Tag, int, error) {
 	ret := _m.Called(tagFilter, findFilter)
@@ -593,6 +639,20 @@ func (_m *TagReaderWriter) UpdateFull(updatedTag models.Tag) (*models.Tag, error
 	return r0, r1
 }
 
+// UpdateGeneratedAliases provides a mock function with given fields: tagID, aliases
+func (_m *TagReaderWriter) UpdateGeneratedAliases(tagID int, aliases []string) error {
+	ret := _m.Called(tagID, aliases)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(int, []string) error); ok {
+		r0 = rf(tagID, aliases)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdateImage provides a mock function with given fields: tagID, image
 func (_m *TagReaderWriter) UpdateImage(tagID int, image []byte) error {
 	ret := _m.Called(tagID, image)
@@ -17,6 +17,12 @@ type TransactionManager struct {
 	studio      *StudioReaderWriter
 	tag         *TagReaderWriter
 	savedFilter *SavedFilterReaderWriter
+	quarantine  *QuarantineReaderWriter
+
+	scanCheckpoint *ScanCheckpointReaderWriter
+	scanSnapshot   *ScanSnapshotReaderWriter
+
+	generateFailure *GenerateFailureReaderWriter
 }
 
 func NewTransactionManager() *TransactionManager {
@@ -31,6 +37,12 @@ func NewTransactionManager() *TransactionManager {
 		studio:      &StudioReaderWriter{},
 		tag:         &TagReaderWriter{},
 		savedFilter: &SavedFilterReaderWriter{},
+		quarantine:  &QuarantineReaderWriter{},
+
+		scanCheckpoint: &ScanCheckpointReaderWriter{},
+		scanSnapshot:   &ScanSnapshotReaderWriter{},
+
+		generateFailure: &GenerateFailureReaderWriter{},
 	}
 }
 
@@ -78,6 +90,22 @@ func (t *TransactionManager) SavedFilterMock() *SavedFilterReaderWriter {
 	return t.savedFilter
 }
 
+func (t *TransactionManager) QuarantineMock() *QuarantineReaderWriter {
+	return t.quarantine
+}
+
+func (t *TransactionManager) ScanCheckpointMock() *ScanCheckpointReaderWriter {
+	return t.scanCheckpoint
+}
+
+func (t *TransactionManager) ScanSnapshotMock() *ScanSnapshotReaderWriter {
+	return t.scanSnapshot
+}
+
+func (t *TransactionManager) GenerateFailureMock() *GenerateFailureReaderWriter {
+	return t.generateFailure
+}
+
 func (t *TransactionManager) Gallery() models.GalleryReaderWriter {
 	return t.GalleryMock()
 }
@@ -118,6 +146,22 @@ func (t *TransactionManager) SavedFilter() models.SavedFilterReaderWriter {
 	return t.SavedFilterMock()
 }
 
+func (t *TransactionManager) Quarantine() models.QuarantineReaderWriter {
+	return t.QuarantineMock()
+}
+
+func (t *TransactionManager) ScanCheckpoint() models.ScanCheckpointReaderWriter {
+	return t.ScanCheckpointMock()
+}
+
+func (t *TransactionManager) ScanSnapshot() models.ScanSnapshotReaderWriter {
+	return t.ScanSnapshotMock()
+}
+
+func (t *TransactionManager) GenerateFailure() models.GenerateFailureReaderWriter {
+	return t.GenerateFailureMock()
+}
+
 type ReadTransaction struct {
 	*TransactionManager
 }
@@ -165,3 +209,19 @@ func (r *ReadTransaction) Tag() models.TagReader {
 func (r *ReadTransaction) SavedFilter() models.SavedFilterReader {
 	return r.SavedFilterMock()
 }
+
+func (r *ReadTransaction) Quarantine() models.QuarantineReader {
+	return r.QuarantineMock()
+}
+
+func (r *ReadTransaction) ScanCheckpoint() models.ScanCheckpointReader {
+	return r.ScanCheckpointMock()
+}
+
+func (r *ReadTransaction) ScanSnapshot() models.ScanSnapshotReader {
+	return r.ScanSnapshotMock()
+}
+
+func (r *ReadTransaction) GenerateFailure() models.GenerateFailureReader {
+	return r.GenerateFailureMock()
+}
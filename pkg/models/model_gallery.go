@@ -21,6 +21,8 @@ type Gallery struct {
 	FileModTime NullSQLiteTimestamp `db:"file_mod_time" json:"file_mod_time"`
 	CreatedAt   SQLiteTimestamp     `db:"created_at" json:"created_at"`
 	UpdatedAt   SQLiteTimestamp     `db:"updated_at" json:"updated_at"`
+
+	CoverImageID sql.NullInt64 `db:"cover_image_id,omitempty" json:"cover_image_id"`
 }
 
 // GalleryPartial represents part of a Gallery object. It is used to update
@@ -39,6 +41,8 @@ type GalleryPartial struct {
 	FileModTime *NullSQLiteTimestamp `db:"file_mod_time" json:"file_mod_time"`
 	CreatedAt   *SQLiteTimestamp     `db:"created_at" json:"created_at"`
 	UpdatedAt   *SQLiteTimestamp     `db:"updated_at" json:"updated_at"`
+
+	CoverImageID *sql.NullInt64 `db:"cover_image_id,omitempty" json:"cover_image_id"`
 }
 
 func (s *Gallery) File() File {
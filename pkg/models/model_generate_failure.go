@@ -0,0 +1,30 @@
+package models
+
+import "database/sql"
+
+// GenerateFailure is a scene or scene marker for which a generate task
+// failed. Failed items are retried by a generate retry job.
+type GenerateFailure struct {
+	ID            int              `db:"id" json:"id"`
+	Artifact      GenerateArtifact `db:"artifact" json:"artifact"`
+	SceneID       sql.NullInt64    `db:"scene_id,omitempty" json:"scene_id"`
+	SceneMarkerID sql.NullInt64    `db:"scene_marker_id,omitempty" json:"scene_marker_id"`
+	// Error is the error logged by the last failed attempt.
+	Error string `db:"error" json:"error"`
+	// Attempts is the number of times the item has failed.
+	Attempts int `db:"attempts" json:"attempts"`
+	// RetryAt is the time before which the item is not retried.
+	RetryAt   SQLiteTimestamp `db:"retry_at" json:"retry_at"`
+	CreatedAt SQLiteTimestamp `db:"created_at" json:"created_at"`
+	UpdatedAt SQLiteTimestamp `db:"updated_at" json:"updated_at"`
+}
+
+type GenerateFailures []*GenerateFailure
+
+func (m *GenerateFailures) Append(o interface{}) {
+	*m = append(*m, o.(*GenerateFailure))
+}
+
+func (m *GenerateFailures) New() interface{} {
+	return &GenerateFailure{}
+}
@@ -23,6 +23,13 @@ type Image struct {
 	FileModTime NullSQLiteTimestamp `db:"file_mod_time" json:"file_mod_time"`
 	CreatedAt   SQLiteTimestamp     `db:"created_at" json:"created_at"`
 	UpdatedAt   SQLiteTimestamp     `db:"updated_at" json:"updated_at"`
+
+	// EXIF fields
+	DateTaken    NullSQLiteTimestamp `db:"date_taken" json:"date_taken"`
+	CameraMake   sql.NullString      `db:"camera_make" json:"camera_make"`
+	CameraModel  sql.NullString      `db:"camera_model" json:"camera_model"`
+	GPSLatitude  sql.NullFloat64     `db:"gps_latitude" json:"gps_latitude"`
+	GPSLongitude sql.NullFloat64     `db:"gps_longitude" json:"gps_longitude"`
 }
 
 // ImagePartial represents part of a Image object. It is used to update
@@ -0,0 +1,20 @@
+package models
+
+// QuarantinedFile is a media file that could not be read during a scan.
+// Quarantined files are skipped by future scans until they are cleared.
+type QuarantinedFile struct {
+	ID        int             `db:"id" json:"id"`
+	Path      string          `db:"path" json:"path"`
+	Reason    string          `db:"reason" json:"reason"`
+	CreatedAt SQLiteTimestamp `db:"created_at" json:"created_at"`
+}
+
+type QuarantinedFiles []*QuarantinedFile
+
+func (m *QuarantinedFiles) Append(o interface{}) {
+	*m = append(*m, o.(*QuarantinedFile))
+}
+
+func (m *QuarantinedFiles) New() interface{} {
+	return &QuarantinedFile{}
+}
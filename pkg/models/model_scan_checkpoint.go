@@ -0,0 +1,23 @@
+package models
+
+// ScanCheckpoint records the progress of a scan or auto-tag, so that an
+// interrupted task can resume where it stopped.
+type ScanCheckpoint struct {
+	ID int `db:"id" json:"id"`
+	// Paths identifies the task and the set of library paths it processes.
+	Paths string `db:"paths" json:"paths"`
+	// LastPath is the position of the last file for which it and all
+	// preceding files have been processed.
+	LastPath  string          `db:"last_path" json:"last_path"`
+	UpdatedAt SQLiteTimestamp `db:"updated_at" json:"updated_at"`
+}
+
+type ScanCheckpoints []*ScanCheckpoint
+
+func (m *ScanCheckpoints) Append(o interface{}) {
+	*m = append(*m, o.(*ScanCheckpoint))
+}
+
+func (m *ScanCheckpoints) New() interface{} {
+	return &ScanCheckpoint{}
+}
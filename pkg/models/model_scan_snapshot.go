@@ -0,0 +1,21 @@
+package models
+
+// ScanSnapshot stores the file system snapshot of a library path taken by
+// an incremental scan.
+type ScanSnapshot struct {
+	ID   int    `db:"id" json:"id"`
+	Path string `db:"path" json:"path"`
+	// Data is the encoded file.Snapshot.
+	Data      []byte          `db:"data" json:"data"`
+	CreatedAt SQLiteTimestamp `db:"created_at" json:"created_at"`
+}
+
+type ScanSnapshots []*ScanSnapshot
+
+func (m *ScanSnapshots) Append(o interface{}) {
+	*m = append(*m, o.(*ScanSnapshot))
+}
+
+func (m *ScanSnapshots) New() interface{} {
+	return &ScanSnapshot{}
+}
@@ -36,6 +36,44 @@ type Scene struct {
 	UpdatedAt        SQLiteTimestamp     `db:"updated_at" json:"updated_at"`
 	Interactive      bool                `db:"interactive" json:"interactive"`
 	InteractiveSpeed sql.NullInt64       `db:"interactive_speed" json:"interactive_speed"`
+	DeletedAt        NullSQLiteTimestamp `db:"deleted_at" json:"deleted_at"`
+	ScreenshotAt     sql.NullFloat64     `db:"screenshot_at" json:"screenshot_at"`
+	// DeleteFile and DeleteGenerated are the options requested when the
+	// scene was soft-deleted, applied when it is purged.
+	DeleteFile      bool `db:"delete_file" json:"delete_file"`
+	DeleteGenerated bool `db:"delete_generated" json:"delete_generated"`
+	// HashAlgorithm is the algorithm used to hash the file when it was
+	// last scanned.
+	HashAlgorithm sql.NullString `db:"hash_algorithm" json:"hash_algorithm"`
+	// ResumeTime is the playback position in seconds to resume playback
+	// from.
+	ResumeTime sql.NullFloat64 `db:"resume_time" json:"resume_time"`
+	// MissingAt is the time the file of the scene was found to be missing.
+	MissingAt NullSQLiteTimestamp `db:"missing_at" json:"missing_at"`
+	// SidecarModTime is the modification time of the metadata sidecar file
+	// when it was last synchronised.
+	SidecarModTime NullSQLiteTimestamp `db:"sidecar_mod_time" json:"sidecar_mod_time"`
+	// PlayCount is the number of times the scene has been played.
+	PlayCount    int                 `db:"play_count" json:"play_count"`
+	LastPlayedAt NullSQLiteTimestamp `db:"last_played_at" json:"last_played_at"`
+
+	// The generate overrides of the scene are used instead of the
+	// configured values when set.
+	PreviewSegments        sql.NullInt64   `db:"preview_segments" json:"preview_segments"`
+	PreviewSegmentDuration sql.NullFloat64 `db:"preview_segment_duration" json:"preview_segment_duration"`
+	PreviewExcludeStart    sql.NullString  `db:"preview_exclude_start" json:"preview_exclude_start"`
+	PreviewExcludeEnd      sql.NullString  `db:"preview_exclude_end" json:"preview_exclude_end"`
+	SpriteCount            sql.NullInt64   `db:"sprite_count" json:"sprite_count"`
+}
+
+// IsDeleted returns true if the scene has been soft-deleted.
+func (s *Scene) IsDeleted() bool {
+	return s.DeletedAt.Valid
+}
+
+// IsMissing returns true if the file of the scene was found to be missing.
+func (s *Scene) IsMissing() bool {
+	return s.MissingAt.Valid
 }
 
 func (s *Scene) File() File {
@@ -120,6 +158,17 @@ type ScenePartial struct {
 	UpdatedAt        *SQLiteTimestamp     `db:"updated_at" json:"updated_at"`
 	Interactive      *bool                `db:"interactive" json:"interactive"`
 	InteractiveSpeed *sql.NullInt64       `db:"interactive_speed" json:"interactive_speed"`
+	ScreenshotAt     *sql.NullFloat64     `db:"screenshot_at" json:"screenshot_at"`
+	HashAlgorithm    *sql.NullString      `db:"hash_algorithm" json:"hash_algorithm"`
+	ResumeTime       *sql.NullFloat64     `db:"resume_time" json:"resume_time"`
+	MissingAt        *NullSQLiteTimestamp `db:"missing_at" json:"missing_at"`
+	SidecarModTime   *NullSQLiteTimestamp `db:"sidecar_mod_time" json:"sidecar_mod_time"`
+
+	PreviewSegments        *sql.NullInt64   `db:"preview_segments" json:"preview_segments"`
+	PreviewSegmentDuration *sql.NullFloat64 `db:"preview_segment_duration" json:"preview_segment_duration"`
+	PreviewExcludeStart    *sql.NullString  `db:"preview_exclude_start" json:"preview_exclude_start"`
+	PreviewExcludeEnd      *sql.NullString  `db:"preview_exclude_end" json:"preview_exclude_end"`
+	SpriteCount            *sql.NullInt64   `db:"sprite_count" json:"sprite_count"`
 }
 
 // UpdateInput constructs a SceneUpdateInput using the populated fields in the ScenePartial object.
@@ -134,14 +183,22 @@ func (s ScenePartial) UpdateInput() SceneUpdateInput {
 	}
 
 	return SceneUpdateInput{
-		ID:        strconv.Itoa(s.ID),
-		Title:     nullStringPtrToStringPtr(s.Title),
-		Details:   nullStringPtrToStringPtr(s.Details),
-		URL:       nullStringPtrToStringPtr(s.URL),
-		Date:      s.Date.StringPtr(),
-		Rating:    nullInt64PtrToIntPtr(s.Rating),
-		Organized: boolPtrCopy(s.Organized),
-		StudioID:  nullInt64PtrToStringPtr(s.StudioID),
+		ID:           strconv.Itoa(s.ID),
+		Title:        nullStringPtrToStringPtr(s.Title),
+		Details:      nullStringPtrToStringPtr(s.Details),
+		URL:          nullStringPtrToStringPtr(s.URL),
+		Date:         s.Date.StringPtr(),
+		Rating:       nullInt64PtrToIntPtr(s.Rating),
+		Organized:    boolPtrCopy(s.Organized),
+		StudioID:     nullInt64PtrToStringPtr(s.StudioID),
+		ScreenshotAt: nullFloat64PtrToFloatPtr(s.ScreenshotAt),
+		ResumeTime:   nullFloat64PtrToFloatPtr(s.ResumeTime),
+
+		PreviewSegments:        nullInt64PtrToIntPtr(s.PreviewSegments),
+		PreviewSegmentDuration: nullFloat64PtrToFloatPtr(s.PreviewSegmentDuration),
+		PreviewExcludeStart:    nullStringPtrToStringPtr(s.PreviewExcludeStart),
+		PreviewExcludeEnd:      nullStringPtrToStringPtr(s.PreviewExcludeEnd),
+		SpriteCount:            nullInt64PtrToIntPtr(s.SpriteCount),
 	}
 }
 
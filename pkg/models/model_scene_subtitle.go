@@ -0,0 +1,20 @@
+package models
+
+// SceneSubtitle is a subtitle sidecar file associated with a scene.
+type SceneSubtitle struct {
+	// LanguageCode is the language of the subtitle, taken from the file
+	// name. Empty if the language is not specified.
+	LanguageCode string `db:"language_code" json:"language_code"`
+	SubtitleType string `db:"subtitle_type" json:"subtitle_type"`
+	Path         string `db:"path" json:"path"`
+}
+
+type SceneSubtitles []*SceneSubtitle
+
+func (s *SceneSubtitles) Append(o interface{}) {
+	*s = append(*s, o.(*SceneSubtitle))
+}
+
+func (s *SceneSubtitles) New() interface{} {
+	return &SceneSubtitle{}
+}
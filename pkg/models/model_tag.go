@@ -7,13 +7,17 @@ type Tag struct {
 	Name      string          `db:"name" json:"name"` // TODO make schema not null
 	CreatedAt SQLiteTimestamp `db:"created_at" json:"created_at"`
 	UpdatedAt SQLiteTimestamp `db:"updated_at" json:"updated_at"`
+	// GenerateAliases is whether alias variants of the name are generated
+	// for auto-tagging.
+	GenerateAliases bool `db:"generate_aliases" json:"generate_aliases"`
 }
 
 type TagPartial struct {
-	ID        int              `db:"id" json:"id"`
-	Name      *string          `db:"name" json:"name"` // TODO make schema not null
-	CreatedAt *SQLiteTimestamp `db:"created_at" json:"created_at"`
-	UpdatedAt *SQLiteTimestamp `db:"updated_at" json:"updated_at"`
+	ID              int              `db:"id" json:"id"`
+	Name            *string          `db:"name" json:"name"` // TODO make schema not null
+	CreatedAt       *SQLiteTimestamp `db:"created_at" json:"created_at"`
+	UpdatedAt       *SQLiteTimestamp `db:"updated_at" json:"updated_at"`
+	GenerateAliases *bool            `db:"generate_aliases" json:"generate_aliases"`
 }
 
 type TagPath struct {
@@ -20,6 +20,7 @@ type PerformerReader interface {
 	GetImage(performerID int) ([]byte, error)
 	GetStashIDs(performerID int) ([]*StashID, error)
 	GetTagIDs(performerID int) ([]int, error)
+	MergeCounts(source []int, destination int) (*MergeCounts, error)
 }
 
 type PerformerWriter interface {
@@ -31,6 +32,8 @@ type PerformerWriter interface {
 	DestroyImage(performerID int) error
 	UpdateStashIDs(performerID int, stashIDs []StashID) error
 	UpdateTags(performerID int, tagIDs []int) error
+	SetFavorite(performerIDs []int, favorite bool) (int, error)
+	Merge(source []int, destination int) error
 }
 
 type PerformerReaderWriter interface {
@@ -0,0 +1,17 @@
+package models
+
+type QuarantineReader interface {
+	FindByPath(path string) (*QuarantinedFile, error)
+	All() ([]*QuarantinedFile, error)
+}
+
+type QuarantineWriter interface {
+	Create(obj QuarantinedFile) (*QuarantinedFile, error)
+	Destroy(id int) error
+	DestroyAll() error
+}
+
+type QuarantineReaderWriter interface {
+	QuarantineReader
+	QuarantineWriter
+}
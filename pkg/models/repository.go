@@ -11,6 +11,10 @@ type Repository interface {
 	Studio() StudioReaderWriter
 	Tag() TagReaderWriter
 	SavedFilter() SavedFilterReaderWriter
+	Quarantine() QuarantineReaderWriter
+	ScanCheckpoint() ScanCheckpointReaderWriter
+	ScanSnapshot() ScanSnapshotReaderWriter
+	GenerateFailure() GenerateFailureReaderWriter
 }
 
 type ReaderRepository interface {
@@ -24,4 +28,8 @@ type ReaderRepository interface {
 	Studio() StudioReader
 	Tag() TagReader
 	SavedFilter() SavedFilterReader
+	Quarantine() QuarantineReader
+	ScanCheckpoint() ScanCheckpointReader
+	ScanSnapshot() ScanSnapshotReader
+	GenerateFailure() GenerateFailureReader
 }
@@ -0,0 +1,15 @@
+package models
+
+type ScanCheckpointReader interface {
+	FindByPaths(paths string) (*ScanCheckpoint, error)
+}
+
+type ScanCheckpointWriter interface {
+	Save(obj ScanCheckpoint) (*ScanCheckpoint, error)
+	DestroyByPaths(paths string) error
+}
+
+type ScanCheckpointReaderWriter interface {
+	ScanCheckpointReader
+	ScanCheckpointWriter
+}
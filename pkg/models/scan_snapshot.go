@@ -0,0 +1,15 @@
+package models
+
+type ScanSnapshotReader interface {
+	FindByPath(path string) (*ScanSnapshot, error)
+}
+
+type ScanSnapshotWriter interface {
+	Save(obj ScanSnapshot) (*ScanSnapshot, error)
+	DestroyByPath(path string) error
+}
+
+type ScanSnapshotReaderWriter interface {
+	ScanSnapshotReader
+	ScanSnapshotWriter
+}
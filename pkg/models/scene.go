@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type SceneQueryOptions struct {
 	QueryOptions
 	SceneFilter *SceneFilterType
@@ -47,6 +49,12 @@ type SceneReader interface {
 	FindByPerformerID(performerID int) ([]*Scene, error)
 	FindByGalleryID(performerID int) ([]*Scene, error)
 	FindDuplicates(distance int) ([][]*Scene, error)
+	// FindByStashID returns the scenes with the stash id on its endpoint.
+	FindByStashID(stashID StashID) ([]*Scene, error)
+	// FindSharedStashIDs returns the ids of scenes that share a stash id,
+	// on any endpoint, grouped by the shared stash id.
+	FindSharedStashIDs() ([][]int, error)
+	FindSimilar(phash int64, distance int) ([]*Scene, error)
 	CountByPerformerID(performerID int) (int, error)
 	// FindByStudioID(studioID int) ([]*Scene, error)
 	FindByMovieID(movieID int) ([]*Scene, error)
@@ -54,6 +62,9 @@ type SceneReader interface {
 	Count() (int, error)
 	Size() (float64, error)
 	Duration() (float64, error)
+	// Years returns the distinct years of the dates of scenes, from oldest
+	// to newest.
+	Years() ([]int, error)
 	// SizeCount() (string, error)
 	CountByStudioID(studioID int) (int, error)
 	CountByTagID(tagID int) (int, error)
@@ -68,6 +79,8 @@ type SceneReader interface {
 	GetGalleryIDs(sceneID int) ([]int, error)
 	GetPerformerIDs(sceneID int) ([]int, error)
 	GetStashIDs(sceneID int) ([]*StashID, error)
+	GetSubtitles(sceneID int) ([]*SceneSubtitle, error)
+	FindDeletedBefore(t time.Time) ([]*Scene, error)
 }
 
 type SceneWriter interface {
@@ -77,15 +90,24 @@ type SceneWriter interface {
 	IncrementOCounter(id int) (int, error)
 	DecrementOCounter(id int) (int, error)
 	ResetOCounter(id int) (int, error)
+	// IncrementPlayCount increments the play count of the scene and sets
+	// its last played time. Returns the new play count.
+	IncrementPlayCount(id int, playedAt time.Time) (int, error)
+	ResetCounters(sceneIDs []int, counters []SceneCounter) (int, error)
 	UpdateFileModTime(id int, modTime NullSQLiteTimestamp) error
 	Destroy(id int) error
+	SoftDestroy(id int, deleteFile bool, deleteGenerated bool) error
+	Restore(id int) error
 	UpdateCover(sceneID int, cover []byte) error
 	DestroyCover(sceneID int) error
 	UpdatePerformers(sceneID int, performerIDs []int) error
 	UpdateTags(sceneID int, tagIDs []int) error
+	AddTags(sceneIDs []int, tagIDs []int) (int, error)
+	RemoveTags(sceneIDs []int, tagIDs []int) (int, error)
 	UpdateGalleries(sceneID int, galleryIDs []int) error
 	UpdateMovies(sceneID int, movies []MoviesScenes) error
 	UpdateStashIDs(sceneID int, stashIDs []StashID) error
+	UpdateSubtitles(sceneID int, subtitles []SceneSubtitle) error
 }
 
 type SceneReaderWriter interface {
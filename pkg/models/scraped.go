@@ -16,3 +16,41 @@ type ScrapedItemReaderWriter interface {
 	ScrapedItemReader
 	ScrapedItemWriter
 }
+
+// ScraperFieldMappingTag is the target of field mappings that add the value
+// of the source field as a tag.
+const ScraperFieldMappingTag = "tag"
+
+// ScraperFieldMapping moves the value of a field of scraped content to
+// another field, or to a tag if Target is ScraperFieldMappingTag. Fields are
+// identified by their GraphQL names, such as details or remote_site_id.
+type ScraperFieldMapping struct {
+	// Scraper is the ID of the scraper the mapping applies to. The mapping
+	// applies to all scrapers if empty.
+	Scraper string `json:"scraper"`
+	Source  string `json:"source"`
+	Target  string `json:"target"`
+}
+
+// Types of ScraperTransform.
+const (
+	// ScraperTransformRegexReplace replaces matches of Regex with With.
+	ScraperTransformRegexReplace = "regex_replace"
+	// ScraperTransformTrim removes leading and trailing whitespace.
+	ScraperTransformTrim = "trim"
+	// ScraperTransformTitleCase capitalises the first letter of each word
+	// and lowercases the rest.
+	ScraperTransformTitleCase = "title_case"
+)
+
+// ScraperTransform transforms the value of a string field of scraped
+// content. Transforms are applied in order after field mappings.
+type ScraperTransform struct {
+	// Scraper is the ID of the scraper the transform applies to. The
+	// transform applies to all scrapers if empty.
+	Scraper string `json:"scraper"`
+	Field   string `json:"field"`
+	Type    string `json:"type"`
+	Regex   string `json:"regex"`
+	With    string `json:"with"`
+}
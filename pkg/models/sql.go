@@ -52,3 +52,12 @@ func nullInt64PtrToStringPtr(v *sql.NullInt64) *string {
 	vv := strconv.FormatInt(v.Int64, 10)
 	return &vv
 }
+
+func nullFloat64PtrToFloatPtr(v *sql.NullFloat64) *float64 {
+	if v == nil || !v.Valid {
+		return nil
+	}
+
+	vv := v.Float64
+	return &vv
+}
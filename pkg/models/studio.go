@@ -16,6 +16,7 @@ type StudioReader interface {
 	HasImage(studioID int) (bool, error)
 	GetStashIDs(studioID int) ([]*StashID, error)
 	GetAliases(studioID int) ([]string, error)
+	MergeCounts(source []int, destination int) (*MergeCounts, error)
 }
 
 type StudioWriter interface {
@@ -27,6 +28,7 @@ type StudioWriter interface {
 	DestroyImage(studioID int) error
 	UpdateStashIDs(studioID int, stashIDs []StashID) error
 	UpdateAliases(studioID int, aliases []string) error
+	Merge(source []int, destination int) error
 }
 
 type StudioReaderWriter interface {
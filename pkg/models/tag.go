@@ -20,6 +20,9 @@ type TagReader interface {
 	Query(tagFilter *TagFilterType, findFilter *FindFilterType) ([]*Tag, int, error)
 	GetImage(tagID int) ([]byte, error)
 	GetAliases(tagID int) ([]string, error)
+	// GetGeneratedAliases returns the aliases generated from the tag name.
+	GetGeneratedAliases(tagID int) ([]string, error)
+	MergeCounts(source []int, destination int) (*MergeCounts, error)
 	FindAllAncestors(tagID int, excludeIDs []int) ([]*TagPath, error)
 	FindAllDescendants(tagID int, excludeIDs []int) ([]*TagPath, error)
 }
@@ -32,6 +35,8 @@ type TagWriter interface {
 	UpdateImage(tagID int, image []byte) error
 	DestroyImage(tagID int) error
 	UpdateAliases(tagID int, aliases []string) error
+	// UpdateGeneratedAliases replaces the aliases generated from the tag name.
+	UpdateGeneratedAliases(tagID int, aliases []string) error
 	Merge(source []int, destination int) error
 	UpdateParentTags(tagID int, parentIDs []int) error
 	UpdateChildTags(tagID int, parentIDs []int) error
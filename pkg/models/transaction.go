@@ -25,6 +25,22 @@ type TransactionManager interface {
 	WithReadTxn(ctx context.Context, fn func(r ReaderRepository) error) error
 }
 
+type retryableKey struct{}
+
+// WithRetryable returns a context that marks transactions as retryable.
+// A retryable transaction is run again if it fails because the database is
+// locked, so fn must be idempotent, and must not have side effects outside
+// of the transaction.
+func WithRetryable(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retryableKey{}, true)
+}
+
+// IsRetryable returns true if transactions using ctx may be retried.
+func IsRetryable(ctx context.Context) bool {
+	v, _ := ctx.Value(retryableKey{}).(bool)
+	return v
+}
+
 func WithTxn(txn Transaction, fn func(r Repository) error) error {
 	err := txn.Begin()
 	if err != nil {
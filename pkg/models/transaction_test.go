@@ -0,0 +1,93 @@
+package models
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testTransaction struct {
+	begun      bool
+	committed  bool
+	rolledBack bool
+}
+
+func (t *testTransaction) Begin() error {
+	t.begun = true
+	return nil
+}
+
+func (t *testTransaction) Rollback() error {
+	t.rolledBack = true
+	return nil
+}
+
+func (t *testTransaction) Commit() error {
+	t.committed = true
+	return nil
+}
+
+func (t *testTransaction) Repository() Repository {
+	return nil
+}
+
+func TestWithTxnCommit(t *testing.T) {
+	txn := &testTransaction{}
+
+	err := WithTxn(txn, func(r Repository) error {
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.True(t, txn.begun)
+	assert.True(t, txn.committed)
+	assert.False(t, txn.rolledBack)
+}
+
+func TestWithTxnErrorRollback(t *testing.T) {
+	txn := &testTransaction{}
+	fnErr := errors.New("test error")
+
+	err := WithTxn(txn, func(r Repository) error {
+		return fnErr
+	})
+
+	assert.ErrorIs(t, err, fnErr)
+	assert.False(t, txn.committed)
+	assert.True(t, txn.rolledBack)
+}
+
+func TestWithTxnPanicRollback(t *testing.T) {
+	txn := &testTransaction{}
+
+	assert.PanicsWithValue(t, "test panic", func() {
+		_ = WithTxn(txn, func(r Repository) error {
+			panic("test panic")
+		})
+	})
+
+	assert.False(t, txn.committed)
+	assert.True(t, txn.rolledBack)
+}
+
+type testReadTransaction struct {
+	testTransaction
+}
+
+func (t *testReadTransaction) Repository() ReaderRepository {
+	return nil
+}
+
+func TestWithROTxnPanicRollback(t *testing.T) {
+	txn := &testReadTransaction{}
+
+	assert.Panics(t, func() {
+		_ = WithROTxn(txn, func(r ReaderRepository) error {
+			panic("test panic")
+		})
+	})
+
+	assert.False(t, txn.committed)
+	assert.True(t, txn.rolledBack)
+}
@@ -0,0 +1,35 @@
+package performer
+
+import (
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// FavoriteBulkUpdater provides the methods needed to set whether many
+// performers are favorites at once.
+type FavoriteBulkUpdater interface {
+	Query(performerFilter *models.PerformerFilterType, findFilter *models.FindFilterType) ([]*models.Performer, int, error)
+	SetFavorite(performerIDs []int, favorite bool) (int, error)
+}
+
+// BulkSetFavorite sets whether each of the performers with the provided ids
+// is a favorite. If performerFilter is not nil, every performer matching the
+// filter is instead updated. Returns the number of performers changed.
+func BulkSetFavorite(qb FavoriteBulkUpdater, performerIDs []int, performerFilter *models.PerformerFilterType, favorite bool) (int, error) {
+	ids := performerIDs
+	if performerFilter != nil {
+		perPage := models.PerPageAll
+		performers, _, err := qb.Query(performerFilter, &models.FindFilterType{PerPage: &perPage})
+		if err != nil {
+			return 0, fmt.Errorf("error querying for performers: %w", err)
+		}
+
+		ids = nil
+		for _, p := range performers {
+			ids = append(ids, p.ID)
+		}
+	}
+
+	return qb.SetFavorite(ids, favorite)
+}
@@ -33,10 +33,12 @@ const (
 
 	PerformerCreatePost  HookTriggerEnum = "Performer.Create.Post"
 	PerformerUpdatePost  HookTriggerEnum = "Performer.Update.Post"
+	PerformerMergePost   HookTriggerEnum = "Performer.Merge.Post"
 	PerformerDestroyPost HookTriggerEnum = "Performer.Destroy.Post"
 
 	StudioCreatePost  HookTriggerEnum = "Studio.Create.Post"
 	StudioUpdatePost  HookTriggerEnum = "Studio.Update.Post"
+	StudioMergePost   HookTriggerEnum = "Studio.Merge.Post"
 	StudioDestroyPost HookTriggerEnum = "Studio.Destroy.Post"
 
 	TagCreatePost  HookTriggerEnum = "Tag.Create.Post"
@@ -68,10 +70,12 @@ var AllHookTriggerEnum = []HookTriggerEnum{
 
 	PerformerCreatePost,
 	PerformerUpdatePost,
+	PerformerMergePost,
 	PerformerDestroyPost,
 
 	StudioCreatePost,
 	StudioUpdatePost,
+	StudioMergePost,
 	StudioDestroyPost,
 
 	TagCreatePost,
@@ -105,10 +109,12 @@ func (e HookTriggerEnum) IsValid() bool {
 
 		PerformerCreatePost,
 		PerformerUpdatePost,
+		PerformerMergePost,
 		PerformerDestroyPost,
 
 		StudioCreatePost,
 		StudioUpdatePost,
+		StudioMergePost,
 		StudioDestroyPost,
 
 		TagCreatePost,
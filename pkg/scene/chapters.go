@@ -0,0 +1,74 @@
+package scene
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// ChapterTagName is the name of the primary tag of markers created from
+// container chapters. The tag is created if it does not exist.
+const ChapterTagName = "Chapter"
+
+// chapterMarkers returns scene markers for the provided chapters. Chapters
+// without a title are titled using their position.
+func chapterMarkers(sceneID int, primaryTagID int, chapters []ffmpeg.Chapter) []models.SceneMarker {
+	currentTime := time.Now()
+
+	var ret []models.SceneMarker
+	for i, c := range chapters {
+		title := c.Title
+		if title == "" {
+			title = fmt.Sprintf("Chapter %d", i+1)
+		}
+
+		ret = append(ret, models.SceneMarker{
+			Title:        title,
+			Seconds:      c.Start,
+			PrimaryTagID: primaryTagID,
+			SceneID:      sql.NullInt64{Int64: int64(sceneID), Valid: true},
+			CreatedAt:    models.SQLiteTimestamp{Timestamp: currentTime},
+			UpdatedAt:    models.SQLiteTimestamp{Timestamp: currentTime},
+		})
+	}
+
+	return ret
+}
+
+// CreateChapterMarkers creates scene markers for the provided container
+// chapters. Markers are not created if the scene already has markers.
+// Returns the created markers.
+func CreateChapterMarkers(mqb models.SceneMarkerReaderWriter, tqb models.TagReaderWriter, sceneID int, chapters []ffmpeg.Chapter) ([]*models.SceneMarker, error) {
+	if len(chapters) == 0 {
+		return nil, nil
+	}
+
+	existing, err := mqb.FindBySceneID(sceneID)
+	if err != nil {
+		return nil, fmt.Errorf("finding scene markers: %w", err)
+	}
+
+	if len(existing) > 0 {
+		return nil, nil
+	}
+
+	tags, err := importTags(tqb, []string{ChapterTagName}, models.ImportMissingRefEnumCreate)
+	if err != nil {
+		return nil, fmt.Errorf("finding chapter tag: %w", err)
+	}
+
+	var ret []*models.SceneMarker
+	for _, m := range chapterMarkers(sceneID, tags[0].ID, chapters) {
+		created, err := mqb.Create(m)
+		if err != nil {
+			return nil, fmt.Errorf("creating marker %q: %w", m.Title, err)
+		}
+
+		ret = append(ret, created)
+	}
+
+	return ret, nil
+}
@@ -0,0 +1,86 @@
+package scene
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	chapterSceneID      = 1
+	markedSceneID       = 2
+	errChapterSceneID   = 3
+	chapterTagID        = 10
+	createdChapterTagID = 11
+)
+
+var testChapters = []ffmpeg.Chapter{
+	{Title: "Intro", Start: 0, End: 90.5},
+	{Title: "", Start: 90.5, End: 200},
+}
+
+func TestChapterMarkers(t *testing.T) {
+	markers := chapterMarkers(chapterSceneID, chapterTagID, testChapters)
+
+	if !assert.Len(t, markers, 2) {
+		return
+	}
+
+	assert.Equal(t, "Intro", markers[0].Title)
+	assert.Equal(t, 0.0, markers[0].Seconds)
+	assert.Equal(t, "Chapter 2", markers[1].Title)
+	assert.Equal(t, 90.5, markers[1].Seconds)
+
+	for _, m := range markers {
+		assert.Equal(t, chapterTagID, m.PrimaryTagID)
+		assert.Equal(t, sql.NullInt64{Int64: chapterSceneID, Valid: true}, m.SceneID)
+	}
+}
+
+func TestCreateChapterMarkers(t *testing.T) {
+	mockMarkerReaderWriter := &mocks.SceneMarkerReaderWriter{}
+	mockTagReaderWriter := &mocks.TagReaderWriter{}
+
+	errFind := errors.New("find error")
+
+	mockMarkerReaderWriter.On("FindBySceneID", chapterSceneID).Return(nil, nil).Once()
+	mockMarkerReaderWriter.On("FindBySceneID", markedSceneID).Return([]*models.SceneMarker{{ID: 1}}, nil).Once()
+	mockMarkerReaderWriter.On("FindBySceneID", errChapterSceneID).Return(nil, errFind).Once()
+
+	mockTagReaderWriter.On("FindByNames", []string{ChapterTagName}, false).Return(nil, nil).Once()
+	mockTagReaderWriter.On("Create", mock.MatchedBy(func(t models.Tag) bool {
+		return t.Name == ChapterTagName
+	})).Return(&models.Tag{ID: createdChapterTagID, Name: ChapterTagName}, nil).Once()
+
+	mockMarkerReaderWriter.On("Create", mock.MatchedBy(func(m models.SceneMarker) bool {
+		return m.PrimaryTagID == createdChapterTagID && m.SceneID.Int64 == chapterSceneID
+	})).Return(func(m models.SceneMarker) *models.SceneMarker {
+		return &m
+	}, nil).Twice()
+
+	created, err := CreateChapterMarkers(mockMarkerReaderWriter, mockTagReaderWriter, chapterSceneID, testChapters)
+	assert.Nil(t, err)
+	assert.Len(t, created, 2)
+
+	// scenes with existing markers are skipped
+	created, err = CreateChapterMarkers(mockMarkerReaderWriter, mockTagReaderWriter, markedSceneID, testChapters)
+	assert.Nil(t, err)
+	assert.Len(t, created, 0)
+
+	_, err = CreateChapterMarkers(mockMarkerReaderWriter, mockTagReaderWriter, errChapterSceneID, testChapters)
+	assert.ErrorIs(t, err, errFind)
+
+	// no chapters does not query markers
+	created, err = CreateChapterMarkers(mockMarkerReaderWriter, mockTagReaderWriter, chapterSceneID, nil)
+	assert.Nil(t, err)
+	assert.Len(t, created, 0)
+
+	mockMarkerReaderWriter.AssertExpectations(t)
+	mockTagReaderWriter.AssertExpectations(t)
+}
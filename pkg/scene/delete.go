@@ -2,6 +2,7 @@ package scene
 
 import (
 	"path/filepath"
+	"time"
 
 	"github.com/stashapp/stash/pkg/file"
 	"github.com/stashapp/stash/pkg/manager/paths"
@@ -36,16 +37,11 @@ func (d *FileDeleter) MarkGeneratedFiles(scene *models.Scene) error {
 
 	var files []string
 
-	thumbPath := d.Paths.Scene.GetThumbnailScreenshotPath(sceneHash)
-	exists, _ = utils.FileExists(thumbPath)
-	if exists {
-		files = append(files, thumbPath)
-	}
-
-	normalPath := d.Paths.Scene.GetScreenshotPath(sceneHash)
-	exists, _ = utils.FileExists(normalPath)
-	if exists {
-		files = append(files, normalPath)
+	for _, screenshotPath := range d.Paths.Scene.GetScreenshotPaths(sceneHash) {
+		exists, _ = utils.FileExists(screenshotPath)
+		if exists {
+			files = append(files, screenshotPath)
+		}
 	}
 
 	streamPreviewPath := d.Paths.Scene.GetStreamPreviewPath(sceneHash)
@@ -78,6 +74,12 @@ func (d *FileDeleter) MarkGeneratedFiles(scene *models.Scene) error {
 		files = append(files, vttPath)
 	}
 
+	contactSheetPath := d.Paths.Scene.GetContactSheetPath(sceneHash)
+	exists, _ = utils.FileExists(contactSheetPath)
+	if exists {
+		files = append(files, contactSheetPath)
+	}
+
 	heatmapPath := d.Paths.Scene.GetInteractiveHeatmapPath(sceneHash)
 	exists, _ = utils.FileExists(heatmapPath)
 	if exists {
@@ -170,3 +172,21 @@ func DestroyMarker(scene *models.Scene, sceneMarker *models.SceneMarker, qb mode
 	seconds := int(sceneMarker.Seconds)
 	return fileDeleter.MarkMarkerFiles(scene, seconds)
 }
+
+// PurgeDeleted destroys the scenes that were soft-deleted before the
+// provided time, marking their files and generated files for deletion as
+// requested when each scene was deleted. Returns the purged scenes.
+func PurgeDeleted(repo models.Repository, fileDeleter *FileDeleter, before time.Time) ([]*models.Scene, error) {
+	scenes, err := repo.Scene().FindDeletedBefore(before)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range scenes {
+		if err := Destroy(s, repo, fileDeleter, s.DeleteGenerated, s.DeleteFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return scenes, nil
+}
@@ -302,3 +302,12 @@ func getPrecision(num float64) int {
 	}
 	return p
 }
+
+func GetIDs(scenes []*models.Scene) []int {
+	var results []int
+	for _, scene := range scenes {
+		results = append(results, scene.ID)
+	}
+
+	return results
+}
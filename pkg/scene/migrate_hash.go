@@ -15,13 +15,10 @@ func MigrateHash(p *paths.Paths, oldHash string, newHash string) {
 	migrateSceneFiles(oldPath, newPath)
 
 	scenePaths := p.Scene
-	oldPath = scenePaths.GetThumbnailScreenshotPath(oldHash)
-	newPath = scenePaths.GetThumbnailScreenshotPath(newHash)
-	migrateSceneFiles(oldPath, newPath)
-
-	oldPath = scenePaths.GetScreenshotPath(oldHash)
-	newPath = scenePaths.GetScreenshotPath(newHash)
-	migrateSceneFiles(oldPath, newPath)
+	newScreenshotPaths := scenePaths.GetScreenshotPaths(newHash)
+	for i, oldPath := range scenePaths.GetScreenshotPaths(oldHash) {
+		migrateSceneFiles(oldPath, newScreenshotPaths[i])
+	}
 
 	oldPath = scenePaths.GetStreamPreviewPath(oldHash)
 	newPath = scenePaths.GetStreamPreviewPath(newHash)
@@ -43,6 +40,10 @@ func MigrateHash(p *paths.Paths, oldHash string, newHash string) {
 	newPath = scenePaths.GetSpriteImageFilePath(newHash)
 	migrateSceneFiles(oldPath, newPath)
 
+	oldPath = scenePaths.GetContactSheetPath(oldHash)
+	newPath = scenePaths.GetContactSheetPath(newHash)
+	migrateSceneFiles(oldPath, newPath)
+
 	oldPath = scenePaths.GetInteractiveHeatmapPath(oldHash)
 	newPath = scenePaths.GetInteractiveHeatmapPath(newHash)
 	migrateSceneFiles(oldPath, newPath)
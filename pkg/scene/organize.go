@@ -0,0 +1,242 @@
+package scene
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/template"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+// ErrOrganizeCollision is returned when the destination of an organized
+// scene file is used by another file or scene.
+var ErrOrganizeCollision = errors.New("destination path is in use")
+
+// RenderOrganizeTemplate returns the path of the scene file rendered from
+// the template, relative to the library path. The template uses the
+// variables of template.SceneVars. Directories that render empty are
+// omitted, and spaces and dashes left at the ends of path segments by empty
+// values are trimmed.
+func RenderOrganizeTemplate(tmpl string, s *models.Scene, studio string) (string, error) {
+	t, err := template.Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	rendered, err := t.ExecuteFilename(template.SceneVars(s, studio))
+	if err != nil {
+		return "", err
+	}
+
+	var segments []string
+	for _, segment := range strings.FieldsFunc(rendered, func(r rune) bool {
+		return r == '/' || r == '\\'
+	}) {
+		segment = strings.Trim(strings.Join(strings.Fields(segment), " "), " -")
+		if segment == "." || segment == ".." {
+			return "", fmt.Errorf("invalid path segment %q", segment)
+		}
+		if segment != "" {
+			segments = append(segments, segment)
+		}
+	}
+
+	if len(segments) == 0 {
+		return "", errors.New("template renders an empty path")
+	}
+
+	filename := segments[len(segments)-1]
+	if strings.TrimSuffix(filename, filepath.Ext(filename)) == "" {
+		return "", errors.New("template renders an empty filename")
+	}
+
+	return filepath.Join(segments...), nil
+}
+
+// ValidateOrganizeTemplate returns an error if the template cannot be
+// rendered.
+func ValidateOrganizeTemplate(tmpl string) error {
+	s := &models.Scene{
+		ID:    1,
+		Path:  "scene.mp4",
+		Title: models.NullString("title"),
+		Date:  models.SQLiteDate{String: "2021-01-02", Valid: true},
+	}
+
+	_, err := RenderOrganizeTemplate(tmpl, s, "studio")
+	return err
+}
+
+// Organizer moves scene files to paths rendered from a template, and
+// updates the paths of the scenes.
+type Organizer struct {
+	// Template is the template of the destination paths, relative to the
+	// library path containing the scene file.
+	Template     string
+	LibraryPaths []string
+	Collision    models.OrganizeCollisionStrategy
+	Mover        *file.Mover
+}
+
+// libraryPath returns the deepest library path containing the path, or an
+// empty string if the path is not in a library.
+func (o *Organizer) libraryPath(path string) string {
+	ret := ""
+	for _, p := range o.LibraryPaths {
+		if utils.IsPathInDir(p, path) && len(p) > len(ret) {
+			ret = p
+		}
+	}
+
+	return ret
+}
+
+// Destination returns the path that the scene file is moved to when
+// organized, or the current path if the file is already organized.
+func (o *Organizer) Destination(qb models.SceneReader, studioReader models.StudioReader, s *models.Scene) (string, error) {
+	if file.IsRemotePath(s.Path) {
+		return "", errors.New("cannot organize files on remote file systems")
+	}
+
+	library := o.libraryPath(s.Path)
+	if library == "" {
+		return "", fmt.Errorf("%s is not in a library path", s.Path)
+	}
+
+	studio, err := GetStudioName(studioReader, s)
+	if err != nil {
+		return "", fmt.Errorf("error getting studio name: %w", err)
+	}
+
+	rel, err := RenderOrganizeTemplate(o.Template, s, studio)
+	if err != nil {
+		return "", err
+	}
+
+	dst := filepath.Join(library, rel)
+	if dst == s.Path {
+		return dst, nil
+	}
+
+	ext := filepath.Ext(dst)
+	base := strings.TrimSuffix(dst, ext)
+	for i := 1; ; i++ {
+		inUse, err := o.pathInUse(qb, s.Path, dst)
+		if err != nil {
+			return "", err
+		}
+		if !inUse {
+			return dst, nil
+		}
+
+		if o.Collision != models.OrganizeCollisionStrategySuffix {
+			return "", fmt.Errorf("%w: %s", ErrOrganizeCollision, dst)
+		}
+
+		dst = fmt.Sprintf("%s (%d)%s", base, i, ext)
+	}
+}
+
+// pathInUse returns true if a file other than the file at src exists at
+// the path, or a scene has the path. The file at src exists at the path if
+// the paths differ only by case on a case-insensitive file system.
+func (o *Organizer) pathInUse(qb models.SceneReader, src string, path string) (bool, error) {
+	exists, err := o.Mover.Exists(path)
+	if err != nil {
+		return false, err
+	}
+	if exists && !o.Mover.SameFile(src, path) {
+		return true, nil
+	}
+
+	existing, err := qb.FindByPath(path)
+	if err != nil {
+		return false, fmt.Errorf("error finding scene with path %s: %w", path, err)
+	}
+
+	return existing != nil, nil
+}
+
+// companionFiles returns the existing files that belong to the scene file
+// at src, mapped to their paths alongside the scene file at dst. These are
+// the funscript, subtitle and metadata sidecar files.
+func companionFiles(src string, dst string) (map[string]string, error) {
+	candidates := map[string]string{
+		utils.GetFunscriptPath(src): utils.GetFunscriptPath(dst),
+	}
+	for _, format := range models.AllSidecarFormat {
+		candidates[SidecarPath(src, format)] = SidecarPath(dst, format)
+	}
+
+	ret := make(map[string]string)
+	for from, to := range candidates {
+		exists, err := file.Exists(from)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			ret[from] = to
+		}
+	}
+
+	subtitles, err := FindSubtitles(src)
+	if err != nil {
+		return nil, err
+	}
+
+	srcBase := strings.TrimSuffix(filepath.Base(src), filepath.Ext(src))
+	dstBase := strings.TrimSuffix(filepath.Base(dst), filepath.Ext(dst))
+	for _, sub := range subtitles {
+		name := dstBase + strings.TrimPrefix(filepath.Base(sub.Path), srcBase)
+		ret[sub.Path] = filepath.Join(filepath.Dir(dst), name)
+	}
+
+	return ret, nil
+}
+
+// MoveFiles moves the scene file at src to dst, with its funscript,
+// subtitle and metadata sidecar files. The Mover must be committed once the
+// scene path is updated, or rolled back if the update fails.
+func (o *Organizer) MoveFiles(src string, dst string) error {
+	companions, err := companionFiles(src, dst)
+	if err != nil {
+		return fmt.Errorf("error finding files of %s: %w", src, err)
+	}
+
+	if err := o.Mover.Move(src, dst); err != nil {
+		return err
+	}
+
+	for from, to := range companions {
+		if err := o.Mover.Move(from, to); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdatePath sets the path of the scene to dst, and associates the
+// subtitle files moved with it.
+func (o *Organizer) UpdatePath(qb models.SceneReaderWriter, s *models.Scene, dst string) error {
+	if _, err := qb.Update(models.ScenePartial{
+		ID:        s.ID,
+		Path:      &dst,
+		UpdatedAt: &models.SQLiteTimestamp{Timestamp: time.Now()},
+	}); err != nil {
+		return fmt.Errorf("error updating scene path: %w", err)
+	}
+
+	moved := *s
+	moved.Path = dst
+	if _, err := UpdateSubtitles(qb, &moved); err != nil {
+		return fmt.Errorf("error updating scene subtitles: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,268 @@
+package scene
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRenderOrganizeTemplate(t *testing.T) {
+	s := &models.Scene{
+		ID:    12,
+		Path:  filepath.Join("lib", "unsorted", "file name.mp4"),
+		Title: models.NullString("A: Title?"),
+		Date:  models.SQLiteDate{String: "2021-03-04", Valid: true},
+	}
+	noMetadata := &models.Scene{
+		ID:   12,
+		Path: filepath.Join("lib", "unsorted", "file name.mp4"),
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		scene    *models.Scene
+		studio   string
+		want     string
+		wantErr  bool
+	}{
+		{
+			"default",
+			"{studio}/{date} {title}.{ext}",
+			s,
+			"Studio",
+			filepath.Join("Studio", "2021-03-04 A_ Title_.mp4"),
+			false,
+		},
+		{
+			"all placeholders",
+			"{year}/{id} - {filename}.{EXT}",
+			s,
+			"",
+			filepath.Join("2021", "12 - file name.mp4"),
+			false,
+		},
+		{
+			"empty values",
+			"{studio}/{date} - {title}.{ext}",
+			noMetadata,
+			"",
+			"file name.mp4",
+			false,
+		},
+		{
+			"windows separators",
+			`{studio}\{title}.{ext}`,
+			s,
+			"Studio/Sub",
+			filepath.Join("Studio_Sub", "A_ Title_.mp4"),
+			false,
+		},
+		{
+			"unknown placeholder",
+			"{performer}/{title}.{ext}",
+			s,
+			"",
+			"",
+			true,
+		},
+		{
+			"parent directory",
+			"../{title}.{ext}",
+			s,
+			"",
+			"",
+			true,
+		},
+		{
+			"empty filename",
+			"{title}/{studio}.{ext}",
+			s,
+			"",
+			"",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderOrganizeTemplate(tt.template, tt.scene, tt.studio)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("RenderOrganizeTemplate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func writeOrganizeFile(t *testing.T, path string, data string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOrganizerOrganize(t *testing.T) {
+	const organizeStudioID = 3
+
+	lib := t.TempDir()
+	src := filepath.Join(lib, "unsorted", "a.mp4")
+	writeOrganizeFile(t, src, "a")
+	writeOrganizeFile(t, filepath.Join(lib, "unsorted", "a.funscript"), "funscript")
+	writeOrganizeFile(t, filepath.Join(lib, "unsorted", "a.en.srt"), "subtitle")
+	writeOrganizeFile(t, filepath.Join(lib, "unsorted", "a.mp4.json"), "{}")
+	writeOrganizeFile(t, filepath.Join(lib, "unsorted", "ab.funscript"), "other")
+
+	dst := filepath.Join(lib, "Studio", "Title.mp4")
+	dstSubtitle := filepath.Join(lib, "Studio", "Title.en.srt")
+
+	s := &models.Scene{
+		ID:       1,
+		Path:     src,
+		Title:    models.NullString("Title"),
+		StudioID: models.NullInt64(organizeStudioID),
+	}
+
+	studioReader := &mocks.StudioReaderWriter{}
+	studioReader.On("Find", organizeStudioID).Return(&models.Studio{
+		Name: models.NullString("Studio"),
+	}, nil)
+
+	qb := &mocks.SceneReaderWriter{}
+	qb.On("FindByPath", dst).Return(nil, nil)
+	qb.On("Update", mock.MatchedBy(func(p models.ScenePartial) bool {
+		return p.ID == s.ID && p.Path != nil && *p.Path == dst
+	})).Return(nil, nil).Once()
+	qb.On("GetSubtitles", s.ID).Return(nil, nil).Once()
+	qb.On("UpdateSubtitles", s.ID, []models.SceneSubtitle{{
+		LanguageCode: "en",
+		SubtitleType: "srt",
+		Path:         dstSubtitle,
+	}}).Return(nil).Once()
+
+	o := &Organizer{
+		Template:     "{studio}/{title}.{ext}",
+		LibraryPaths: []string{lib},
+		Mover:        file.NewMover(),
+	}
+
+	// finding the destination does not move the file
+	got, err := o.Destination(qb, studioReader, s)
+	assert.Nil(t, err)
+	assert.Equal(t, dst, got)
+	assert.FileExists(t, src)
+
+	assert.Nil(t, o.MoveFiles(src, dst))
+	assert.Nil(t, o.UpdatePath(qb, s, dst))
+	o.Mover.Commit()
+
+	assert.NoFileExists(t, src)
+	assert.FileExists(t, dst)
+	assert.FileExists(t, filepath.Join(lib, "Studio", "Title.funscript"))
+	assert.FileExists(t, dstSubtitle)
+	assert.FileExists(t, filepath.Join(lib, "Studio", "Title.mp4.json"))
+	// files of other scenes are not moved
+	assert.FileExists(t, filepath.Join(lib, "unsorted", "ab.funscript"))
+	qb.AssertExpectations(t)
+
+	// an organized scene is already at its destination
+	s.Path = dst
+	got, err = o.Destination(qb, studioReader, s)
+	assert.Nil(t, err)
+	assert.Equal(t, dst, got)
+}
+
+func TestOrganizerCollision(t *testing.T) {
+	lib := t.TempDir()
+	src := filepath.Join(lib, "unsorted", "a.mp4")
+	writeOrganizeFile(t, src, "a")
+
+	existingFile := filepath.Join(lib, "Title.mp4")
+	writeOrganizeFile(t, existingFile, "existing")
+	existingScene := filepath.Join(lib, "Title (1).mp4")
+	free := filepath.Join(lib, "Title (2).mp4")
+
+	s := &models.Scene{
+		ID:    1,
+		Path:  src,
+		Title: models.NullString("Title"),
+	}
+
+	qb := &mocks.SceneReaderWriter{}
+	qb.On("FindByPath", existingScene).Return(&models.Scene{ID: 2}, nil)
+	qb.On("FindByPath", free).Return(nil, nil)
+
+	o := &Organizer{
+		Template:     "{title}.{ext}",
+		LibraryPaths: []string{lib},
+		Collision:    models.OrganizeCollisionStrategySkip,
+		Mover:        file.NewMover(),
+	}
+
+	_, err := o.Destination(qb, nil, s)
+	assert.True(t, errors.Is(err, ErrOrganizeCollision))
+
+	o.Collision = models.OrganizeCollisionStrategySuffix
+	got, err := o.Destination(qb, nil, s)
+	assert.Nil(t, err)
+	assert.Equal(t, free, got)
+}
+
+func TestOrganizerRollback(t *testing.T) {
+	lib := t.TempDir()
+	src := filepath.Join(lib, "unsorted", "a.mp4")
+	writeOrganizeFile(t, src, "a")
+	dst := filepath.Join(lib, "Title.mp4")
+
+	s := &models.Scene{
+		ID:    1,
+		Path:  src,
+		Title: models.NullString("Title"),
+	}
+
+	qb := &mocks.SceneReaderWriter{}
+	qb.On("FindByPath", dst).Return(nil, nil)
+
+	o := &Organizer{
+		Template:     "{title}.{ext}",
+		LibraryPaths: []string{lib},
+		Mover:        file.NewMover(),
+	}
+
+	got, err := o.Destination(qb, nil, s)
+	assert.Nil(t, err)
+	assert.Nil(t, o.MoveFiles(src, got))
+	assert.FileExists(t, dst)
+
+	// the path update failed after moving the file
+	o.Mover.Rollback()
+	assert.FileExists(t, src)
+	assert.NoFileExists(t, dst)
+}
+
+func TestOrganizerNotInLibrary(t *testing.T) {
+	s := &models.Scene{
+		ID:   1,
+		Path: filepath.Join(t.TempDir(), "a.mp4"),
+	}
+
+	o := &Organizer{
+		Template:     "{title}.{ext}",
+		LibraryPaths: []string{t.TempDir()},
+		Mover:        file.NewMover(),
+	}
+
+	_, err := o.Destination(nil, nil, s)
+	assert.NotNil(t, err)
+}
@@ -2,6 +2,7 @@ package scene
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
@@ -57,42 +58,70 @@ func Query(qb Queryer, sceneFilter *models.SceneFilterType, findFilter *models.F
 	return scenes, nil
 }
 
-func BatchProcess(ctx context.Context, reader models.SceneReader, sceneFilter *models.SceneFilterType, findFilter *models.FindFilterType, fn func(scene *models.Scene) error) error {
-	const batchSize = 1000
+// defaultBatchSize is the number of scenes queried at a time by ForEach when
+// the find filter does not set the page size.
+const defaultBatchSize = 1000
+
+// ErrStopIteration may be returned by a ForEach callback to stop iterating
+// without ForEach returning an error.
+var ErrStopIteration = errors.New("stop iteration")
+
+// ForEach calls fn for each scene matching sceneFilter, in the order of the
+// sort of findFilter, or ordered by id if it is not set. Scenes are queried
+// in pages of the page size of findFilter, so that only a single page of
+// scenes is held in memory at a time. The page of findFilter is ignored, and
+// findFilter is not modified. Iteration stops when the context is cancelled
+// or when fn returns an error. The error returned by fn is returned, unless
+// it is ErrStopIteration.
+func ForEach(ctx context.Context, qb Queryer, sceneFilter *models.SceneFilterType, findFilter *models.FindFilterType, fn func(scene *models.Scene) error) error {
+	var ff models.FindFilterType
+	if findFilter != nil {
+		ff = *findFilter
+	}
+
+	if ff.Sort == nil {
+		sort := "id"
+		ff.Sort = &sort
+	}
 
-	if findFilter == nil {
-		findFilter = &models.FindFilterType{}
+	batchSize := defaultBatchSize
+	if ff.PerPage != nil && *ff.PerPage > 0 {
+		batchSize = *ff.PerPage
 	}
 
 	page := 1
-	perPage := batchSize
-	findFilter.Page = &page
-	findFilter.PerPage = &perPage
+	ff.Page = &page
+	ff.PerPage = &batchSize
 
-	for more := true; more; {
+	for {
 		if job.IsCancelled(ctx) {
 			return nil
 		}
 
-		scenes, err := Query(reader, sceneFilter, findFilter)
+		scenes, err := Query(qb, sceneFilter, &ff)
 		if err != nil {
 			return fmt.Errorf("error querying for scenes: %w", err)
 		}
 
-		for _, scene := range scenes {
-			if err := fn(scene); err != nil {
+		for _, s := range scenes {
+			if job.IsCancelled(ctx) {
+				return nil
+			}
+
+			if err := fn(s); err != nil {
+				if errors.Is(err, ErrStopIteration) {
+					return nil
+				}
 				return err
 			}
 		}
 
-		if len(scenes) != batchSize {
-			more = false
-		} else {
-			*findFilter.Page++
+		if len(scenes) < batchSize {
+			return nil
 		}
-	}
 
-	return nil
+		page++
+	}
 }
 
 // FilterFromPaths creates a SceneFilterType that filters using the provided
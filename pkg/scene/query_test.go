@@ -0,0 +1,171 @@
+package scene
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type pagedQueryer struct {
+	scenes  []*models.Scene
+	queries int
+	sorts   []string
+}
+
+func (q *pagedQueryer) FindMany(ids []int) ([]*models.Scene, error) {
+	var ret []*models.Scene
+	for _, id := range ids {
+		ret = append(ret, q.scenes[id-1])
+	}
+	return ret, nil
+}
+
+func (q *pagedQueryer) Query(options models.SceneQueryOptions) (*models.SceneQueryResult, error) {
+	q.queries++
+
+	ff := options.FindFilter
+	q.sorts = append(q.sorts, ff.GetSort(""))
+	start := (ff.GetPage() - 1) * ff.GetPageSize()
+	end := start + ff.GetPageSize()
+	if end > len(q.scenes) {
+		end = len(q.scenes)
+	}
+
+	ret := models.NewSceneQueryResult(q)
+	for _, s := range q.scenes[start:end] {
+		ret.IDs = append(ret.IDs, s.ID)
+	}
+
+	return ret, nil
+}
+
+func makePagedQueryer(n int) *pagedQueryer {
+	ret := &pagedQueryer{}
+	for i := 1; i <= n; i++ {
+		ret.scenes = append(ret.scenes, &models.Scene{ID: i})
+	}
+	return ret
+}
+
+func TestForEach(t *testing.T) {
+	const batchSize = 3
+
+	tests := []struct {
+		name        string
+		scenes      int
+		wantQueries int
+	}{
+		{"empty", 0, 1},
+		{"partial page", 2, 1},
+		{"exact pages", 6, 3},
+		{"trailing page", 7, 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := makePagedQueryer(tt.scenes)
+
+			var got []int
+			err := ForEach(context.Background(), q, nil, models.BatchFindFilter(batchSize), func(s *models.Scene) error {
+				got = append(got, s.ID)
+				return nil
+			})
+
+			assert.Nil(t, err)
+			assert.Len(t, got, tt.scenes)
+			for i, id := range got {
+				assert.Equal(t, i+1, id)
+			}
+			assert.Equal(t, tt.wantQueries, q.queries)
+		})
+	}
+}
+
+func TestForEachEarlyTermination(t *testing.T) {
+	const batchSize = 2
+	q := makePagedQueryer(10)
+
+	var got []int
+	err := ForEach(context.Background(), q, nil, models.BatchFindFilter(batchSize), func(s *models.Scene) error {
+		got = append(got, s.ID)
+		if s.ID == 3 {
+			return ErrStopIteration
+		}
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, got)
+	assert.Equal(t, 2, q.queries)
+
+	fnErr := errors.New("test error")
+	err = ForEach(context.Background(), q, nil, models.BatchFindFilter(batchSize), func(s *models.Scene) error {
+		return fnErr
+	})
+	assert.ErrorIs(t, err, fnErr)
+}
+
+func TestForEachCancelled(t *testing.T) {
+	q := makePagedQueryer(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	err := ForEach(ctx, q, nil, models.BatchFindFilter(2), func(s *models.Scene) error {
+		called = true
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.False(t, called)
+	assert.Equal(t, 0, q.queries)
+}
+
+func TestForEachCancelledWithinPage(t *testing.T) {
+	q := makePagedQueryer(10)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var got []int
+	err := ForEach(ctx, q, nil, models.BatchFindFilter(5), func(s *models.Scene) error {
+		got = append(got, s.ID)
+		if s.ID == 2 {
+			cancel()
+		}
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2}, got)
+	assert.Equal(t, 1, q.queries)
+}
+
+func TestForEachFindFilter(t *testing.T) {
+	// scenes are sorted by id by default
+	q := makePagedQueryer(3)
+	err := ForEach(context.Background(), q, nil, nil, func(s *models.Scene) error {
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"id"}, q.sorts)
+
+	// the sort and page size of the find filter are used, and the find
+	// filter is not modified
+	sort := "path"
+	findFilter := models.BatchFindFilter(2)
+	findFilter.Sort = &sort
+
+	q = makePagedQueryer(3)
+	var got []int
+	err = ForEach(context.Background(), q, nil, findFilter, func(s *models.Scene) error {
+		got = append(got, s.ID)
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []int{1, 2, 3}, got)
+	assert.Equal(t, []string{"path", "path"}, q.sorts)
+	assert.Equal(t, 1, findFilter.GetPage())
+}
@@ -3,6 +3,7 @@ package scene
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
@@ -32,14 +33,22 @@ type Scanner struct {
 	UseFileMetadata     bool
 	FileNamingAlgorithm models.HashAlgorithm
 
-	Ctx              context.Context
-	CaseSensitiveFs  bool
-	TxnManager       models.TransactionManager
-	Paths            *paths.Paths
-	Screenshotter    screenshotter
-	VideoFileCreator videoFileCreator
-	PluginCache      *plugin.Cache
-	MutexManager     *utils.MutexManager
+	Ctx                context.Context
+	CaseSensitiveFs    bool
+	TxnManager         models.TransactionManager
+	Paths              *paths.Paths
+	Screenshotter      screenshotter
+	ScreenshotPosition ScreenshotPosition
+	VideoFileCreator   videoFileCreator
+	PluginCache        *plugin.Cache
+	MutexManager       *utils.MutexManager
+	Sidecars           SidecarOptions
+
+	// ScreenshotFormat is the format of screenshots. JPEG if not set.
+	ScreenshotFormat ffmpeg.ImageFormat
+	// ScreenshotQuality is the quality of screenshots from 1 to 100, or
+	// zero for the default quality of the format.
+	ScreenshotQuality int
 }
 
 func FileScanner(hasher file.Hasher, fileNamingAlgorithm models.HashAlgorithm, calculateMD5 bool) file.Scanner {
@@ -51,6 +60,10 @@ func FileScanner(hasher file.Hasher, fileNamingAlgorithm models.HashAlgorithm, c
 }
 
 func (scanner *Scanner) ScanExisting(existing file.FileBased, file file.SourceFile) (err error) {
+	if scanner.isQuarantined(file.Path()) {
+		return nil
+	}
+
 	scanned, err := scanner.Scanner.ScanExisting(existing, file)
 	if err != nil {
 		return err
@@ -60,6 +73,7 @@ func (scanner *Scanner) ScanExisting(existing file.FileBased, file file.SourceFi
 
 	path := scanned.New.Path
 	interactive := getInteractive(path)
+	hashAlgorithm := scanner.hashAlgorithm()
 
 	config := config.GetInstance()
 	oldHash := s.GetHash(scanner.FileNamingAlgorithm)
@@ -72,14 +86,14 @@ func (scanner *Scanner) ScanExisting(existing file.FileBased, file file.SourceFi
 
 		s.SetFile(*scanned.New)
 
-		videoFile, err = scanner.VideoFileCreator.NewVideoFile(path, scanner.StripFileExtension)
+		videoFile, err = scanner.newVideoFile(path)
 		if err != nil {
 			return err
 		}
 
 		videoFileToScene(s, videoFile)
 		changed = true
-	} else if scanned.FileUpdated() || s.Interactive != interactive {
+	} else if scanned.FileUpdated() || s.Interactive != interactive || s.HashAlgorithm.String != string(hashAlgorithm) {
 		logger.Infof("Updated scene file %s", path)
 
 		// update fields as needed
@@ -90,7 +104,7 @@ func (scanner *Scanner) ScanExisting(existing file.FileBased, file file.SourceFi
 	// check for container
 	if !s.Format.Valid {
 		if videoFile == nil {
-			videoFile, err = scanner.VideoFileCreator.NewVideoFile(path, scanner.StripFileExtension)
+			videoFile, err = scanner.newVideoFile(path)
 			if err != nil {
 				return err
 			}
@@ -131,6 +145,7 @@ func (scanner *Scanner) ScanExisting(existing file.FileBased, file file.SourceFi
 			}
 
 			s.Interactive = interactive
+			s.HashAlgorithm = models.NullString(string(hashAlgorithm))
 			s.UpdatedAt = models.SQLiteTimestamp{Timestamp: time.Now()}
 
 			_, err := qb.UpdateFull(*s)
@@ -145,17 +160,28 @@ func (scanner *Scanner) ScanExisting(existing file.FileBased, file file.SourceFi
 			MigrateHash(scanner.Paths, oldHash, newHash)
 		}
 
+		if scanned.ContentsChanged() {
+			scanner.RecordUpdated(s.ID)
+		}
+
 		scanner.PluginCache.ExecutePostHooks(scanner.Ctx, s.ID, plugin.SceneUpdatePost, nil, nil)
 	}
 
+	scanner.associateSubtitles(s)
+	scanner.syncSidecar(s, false)
+
 	// We already have this item in the database
 	// check for thumbnails, screenshots
-	scanner.makeScreenshots(path, videoFile, s.GetHash(scanner.FileNamingAlgorithm))
+	scanner.makeScreenshots(s, path, videoFile, s.GetHash(scanner.FileNamingAlgorithm))
 
 	return nil
 }
 
 func (scanner *Scanner) ScanNew(file file.SourceFile) (retScene *models.Scene, err error) {
+	if scanner.isQuarantined(file.Path()) {
+		return nil, nil
+	}
+
 	scanned, err := scanner.Scanner.ScanNew(file)
 	if err != nil {
 		return nil, err
@@ -187,6 +213,9 @@ func (scanner *Scanner) ScanNew(file file.SourceFile) (retScene *models.Scene, e
 
 		if s == nil {
 			s, _ = qb.FindByOSHash(oshash)
+			if s != nil && !sameFileByOSHash(s, scanner.hashAlgorithm(), checksum) {
+				return fmt.Errorf("oshash of %s collides with %s, which has a different checksum", path, s.Path)
+			}
 		}
 
 		return nil
@@ -203,7 +232,7 @@ func (scanner *Scanner) ScanNew(file file.SourceFile) (retScene *models.Scene, e
 	interactive := getInteractive(file.Path())
 
 	if s != nil {
-		exists, _ := utils.FileExists(s.Path)
+		exists := pathExists(s.Path)
 		if !scanner.CaseSensitiveFs {
 			// #1426 - if file exists but is a case-insensitive match for the
 			// original filename, then treat it as a move
@@ -220,22 +249,28 @@ func (scanner *Scanner) ScanNew(file file.SourceFile) (retScene *models.Scene, e
 				ID:          s.ID,
 				Path:        &path,
 				Interactive: &interactive,
+				// the moved file has been found
+				MissingAt: &models.NullSQLiteTimestamp{},
 			}
 			if err := scanner.TxnManager.WithTxn(context.TODO(), func(r models.Repository) error {
-				_, err := r.Scene().Update(scenePartial)
+				var err error
+				s, err = r.Scene().Update(scenePartial)
 				return err
 			}); err != nil {
 				return nil, err
 			}
 
-			scanner.makeScreenshots(path, nil, sceneHash)
+			scanner.RecordUpdated(s.ID)
+			scanner.associateSubtitles(s)
+			scanner.syncSidecar(s, false)
+			scanner.makeScreenshots(s, path, nil, sceneHash)
 			scanner.PluginCache.ExecutePostHooks(scanner.Ctx, s.ID, plugin.SceneUpdatePost, nil, nil)
 		}
 	} else {
 		logger.Infof("%s doesn't exist. Creating new item...", path)
 		currentTime := time.Now()
 
-		videoFile, err := scanner.VideoFileCreator.NewVideoFile(path, scanner.StripFileExtension)
+		videoFile, err := scanner.newVideoFile(path)
 		if err != nil {
 			return nil, err
 		}
@@ -253,10 +288,11 @@ func (scanner *Scanner) ScanNew(file file.SourceFile) (retScene *models.Scene, e
 				Timestamp: scanned.FileModTime,
 				Valid:     true,
 			},
-			Title:       sql.NullString{String: videoFile.Title, Valid: true},
-			CreatedAt:   models.SQLiteTimestamp{Timestamp: currentTime},
-			UpdatedAt:   models.SQLiteTimestamp{Timestamp: currentTime},
-			Interactive: interactive,
+			Title:         sql.NullString{String: videoFile.Title, Valid: true},
+			CreatedAt:     models.SQLiteTimestamp{Timestamp: currentTime},
+			UpdatedAt:     models.SQLiteTimestamp{Timestamp: currentTime},
+			Interactive:   interactive,
+			HashAlgorithm: models.NullString(string(scanner.hashAlgorithm())),
 		}
 
 		videoFileToScene(&newScene, videoFile)
@@ -266,7 +302,7 @@ func (scanner *Scanner) ScanNew(file file.SourceFile) (retScene *models.Scene, e
 			_ = newScene.Date.Scan(videoFile.CreationTime)
 		}
 
-		if err := scanner.TxnManager.WithTxn(context.TODO(), func(r models.Repository) error {
+		if err := scanner.TxnManager.WithTxn(models.WithRetryable(context.TODO()), func(r models.Repository) error {
 			var err error
 			retScene, err = r.Scene().Create(newScene)
 			return err
@@ -274,13 +310,187 @@ func (scanner *Scanner) ScanNew(file file.SourceFile) (retScene *models.Scene, e
 			return nil, err
 		}
 
-		scanner.makeScreenshots(path, videoFile, sceneHash)
+		scanner.RecordAdded(retScene.ID)
+		scanner.associateSubtitles(retScene)
+		scanner.syncSidecar(retScene, true)
+		scanner.makeScreenshots(retScene, path, videoFile, sceneHash)
 		scanner.PluginCache.ExecutePostHooks(scanner.Ctx, retScene.ID, plugin.SceneCreatePost, nil, nil)
 	}
 
 	return retScene, nil
 }
 
+// hashAlgorithm returns the algorithm used to identify scanned files.
+func (scanner *Scanner) hashAlgorithm() models.HashAlgorithm {
+	if scanner.Scanner.CalculateMD5 {
+		return models.HashAlgorithmMd5
+	}
+
+	return models.HashAlgorithmOshash
+}
+
+// sceneHashAlgorithm returns the algorithm that was used to identify the
+// scene file. Scenes scanned before the algorithm was stored are treated
+// as MD5 if they have a checksum.
+func sceneHashAlgorithm(s *models.Scene) models.HashAlgorithm {
+	if s.HashAlgorithm.Valid {
+		return models.HashAlgorithm(s.HashAlgorithm.String)
+	}
+
+	if s.Checksum.Valid {
+		return models.HashAlgorithmMd5
+	}
+
+	return models.HashAlgorithmOshash
+}
+
+// sameFileByOSHash returns true if the existing scene, which has the same
+// oshash as a scanned file, is the same file as the scanned file. Since
+// oshash is prone to collisions, the checksums are compared if both files
+// were identified using MD5.
+func sameFileByOSHash(existing *models.Scene, hashAlgorithm models.HashAlgorithm, checksum string) bool {
+	if hashAlgorithm != models.HashAlgorithmMd5 || sceneHashAlgorithm(existing) != models.HashAlgorithmMd5 {
+		return true
+	}
+
+	return existing.Checksum.String == checksum
+}
+
+// associateSubtitles associates subtitle files alongside the scene file
+// with the scene.
+func (scanner *Scanner) associateSubtitles(s *models.Scene) {
+	if err := scanner.TxnManager.WithTxn(context.TODO(), func(r models.Repository) error {
+		changed, err := UpdateSubtitles(r.Scene(), s)
+		if changed {
+			logger.Infof("Updated subtitles for %s", s.Path)
+		}
+		return err
+	}); err != nil {
+		logger.Warnf("error associating subtitles for %s: %v", s.Path, err)
+	}
+}
+
+// syncSidecar reconciles the scene with its metadata sidecar file. The
+// sidecar of an existing scene is only synchronised if it has been modified
+// since it was last synchronised, or if it is missing and sidecars are
+// written.
+func (scanner *Scanner) syncSidecar(s *models.Scene, isNew bool) {
+	if !scanner.Sidecars.Read && !scanner.Sidecars.Write {
+		return
+	}
+
+	path := SidecarPath(s.Path, scanner.Sidecars.Format)
+	if !isNew && !sidecarChanged(s, path, scanner.Sidecars.Write) {
+		return
+	}
+
+	if err := scanner.TxnManager.WithTxn(context.TODO(), func(r models.Repository) error {
+		if err := SyncSidecar(r, s, isNew, scanner.Sidecars); err != nil {
+			return err
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil
+		}
+
+		modTime := models.NullSQLiteTimestamp{
+			Timestamp: info.ModTime().Truncate(time.Second),
+			Valid:     true,
+		}
+		if _, err := r.Scene().Update(models.ScenePartial{
+			ID:             s.ID,
+			SidecarModTime: &modTime,
+		}); err != nil {
+			return err
+		}
+		s.SidecarModTime = modTime
+
+		return nil
+	}); err != nil {
+		logger.Warnf("error synchronising sidecar for %s: %v", s.Path, err)
+	}
+}
+
+// sidecarChanged returns true if the sidecar file at path has been modified
+// since it was last synchronised with the scene. A missing sidecar is
+// considered changed if sidecars are written.
+func sidecarChanged(s *models.Scene, path string, write bool) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return write
+	}
+
+	return !s.SidecarModTime.Valid || !s.SidecarModTime.Timestamp.Equal(info.ModTime().Truncate(time.Second))
+}
+
+// isQuarantined returns true if the file at path has been quarantined by a
+// previous scan.
+func (scanner *Scanner) isQuarantined(path string) bool {
+	var q *models.QuarantinedFile
+	if err := scanner.TxnManager.WithReadTxn(context.TODO(), func(r models.ReaderRepository) error {
+		var err error
+		q, err = r.Quarantine().FindByPath(path)
+		return err
+	}); err != nil {
+		logger.Warnf("error checking quarantine for %s: %v", path, err)
+		return false
+	}
+
+	if q != nil {
+		logger.Infof("Skipping quarantined file %s: %s", path, q.Reason)
+		return true
+	}
+
+	return false
+}
+
+// pathExists returns true if the file at path exists. The path may be on a
+// remote file system.
+func pathExists(path string) bool {
+	exists, _ := file.Exists(path)
+	return exists
+}
+
+// newVideoFile probes the file at path. Files that cannot be probed are
+// quarantined so that they are skipped in future scans.
+func (scanner *Scanner) newVideoFile(path string) (*ffmpeg.VideoFile, error) {
+	probePath, closeProbePath, err := file.LocalURL(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeProbePath()
+
+	videoFile, probeErr := scanner.VideoFileCreator.NewVideoFile(probePath, scanner.StripFileExtension)
+	if probeErr == nil && videoFile.Path != path {
+		// remote files are probed using a loopback URL
+		videoFile.Path = path
+		if videoFile.JSON.Format.Tags.Title == "" {
+			videoFile.SetTitleFromPath(scanner.StripFileExtension)
+		}
+	}
+	if probeErr != nil {
+		if err := scanner.TxnManager.WithTxn(models.WithRetryable(context.TODO()), func(r models.Repository) error {
+			_, err := r.Quarantine().Create(models.QuarantinedFile{
+				Path:      path,
+				Reason:    probeErr.Error(),
+				CreatedAt: models.SQLiteTimestamp{Timestamp: time.Now()},
+			})
+			return err
+		}); err != nil {
+			logger.Errorf("error quarantining %s: %v", path, err)
+		} else if errors.Is(probeErr, ffmpeg.ErrProbeTimeout) {
+			logger.Warnf("Quarantined file %s: probe timed out", path)
+		} else {
+			logger.Warnf("Quarantined unreadable file %s", path)
+		}
+
+		return nil, probeErr
+	}
+
+	return videoFile, nil
+}
+
 func videoFileToScene(s *models.Scene, videoFile *ffmpeg.VideoFile) {
 	container := ffmpeg.MatchContainer(videoFile.Container, s.Path)
 
@@ -295,12 +505,9 @@ func videoFileToScene(s *models.Scene, videoFile *ffmpeg.VideoFile) {
 	s.Size = sql.NullString{String: strconv.FormatInt(videoFile.Size, 10), Valid: true}
 }
 
-func (scanner *Scanner) makeScreenshots(path string, probeResult *ffmpeg.VideoFile, checksum string) {
-	thumbPath := scanner.Paths.Scene.GetThumbnailScreenshotPath(checksum)
-	normalPath := scanner.Paths.Scene.GetScreenshotPath(checksum)
-
-	thumbExists, _ := utils.FileExists(thumbPath)
-	normalExists, _ := utils.FileExists(normalPath)
+func (scanner *Scanner) makeScreenshots(s *models.Scene, path string, probeResult *ffmpeg.VideoFile, checksum string) {
+	thumbExists, _ := utils.FileExists(scanner.Paths.Scene.GetThumbnailScreenshotPath(checksum))
+	normalExists, _ := utils.FileExists(scanner.Paths.Scene.GetScreenshotPath(checksum))
 
 	if thumbExists && normalExists {
 		return
@@ -308,7 +515,7 @@ func (scanner *Scanner) makeScreenshots(path string, probeResult *ffmpeg.VideoFi
 
 	if probeResult == nil {
 		var err error
-		probeResult, err = scanner.VideoFileCreator.NewVideoFile(path, scanner.StripFileExtension)
+		probeResult, err = scanner.newVideoFile(path)
 
 		if err != nil {
 			logger.Error(err.Error())
@@ -317,16 +524,34 @@ func (scanner *Scanner) makeScreenshots(path string, probeResult *ffmpeg.VideoFi
 		logger.Infof("Regenerating images for %s", path)
 	}
 
-	at := float64(probeResult.Duration) * 0.2
+	at := scanner.ScreenshotPosition.SceneTime(s, probeResult.Duration)
+
+	// ffmpeg reads remote files through a loopback URL
+	inputPath, closeInputPath, err := file.LocalURL(path)
+	if err != nil {
+		logger.Errorf("error generating images for %s: %v", path, err)
+		return
+	}
+	defer closeInputPath()
+
+	input := *probeResult
+	input.Path = inputPath
+
+	format := scanner.ScreenshotFormat
+	if format == "" {
+		format = ffmpeg.ImageFormatJpeg
+	}
 
 	if !thumbExists {
 		logger.Debugf("Creating thumbnail for %s", path)
-		makeScreenshot(scanner.Screenshotter, *probeResult, thumbPath, 5, 320, at)
+		thumbPath := scanner.Paths.Scene.GetThumbnailScreenshotPathForExtension(checksum, format.Extension())
+		makeScreenshot(scanner.Screenshotter, input, thumbPath, format, scanner.ScreenshotQuality, 320, at)
 	}
 
 	if !normalExists {
 		logger.Debugf("Creating screenshot for %s", path)
-		makeScreenshot(scanner.Screenshotter, *probeResult, normalPath, 2, probeResult.Width, at)
+		normalPath := scanner.Paths.Scene.GetScreenshotPathForExtension(checksum, format.Extension())
+		makeScreenshot(scanner.Screenshotter, input, normalPath, format, scanner.ScreenshotQuality, probeResult.Width, at)
 	}
 }
 
@@ -0,0 +1,270 @@
+package scene
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/manager/paths"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stashapp/stash/pkg/plugin"
+	"github.com/stashapp/stash/pkg/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type errVideoFileCreator struct {
+	err   error
+	calls int
+}
+
+func (c *errVideoFileCreator) NewVideoFile(path string, stripFileExtension bool) (*ffmpeg.VideoFile, error) {
+	c.calls++
+	return nil, c.err
+}
+
+func TestScanNewQuarantine(t *testing.T) {
+	assert := assert.New(t)
+
+	// oshash requires the file size to be a multiple of 8
+	path := filepath.Join(t.TempDir(), "corrupt.mp4")
+	if err := os.WriteFile(path, []byte("this is not a valid video file!!"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errProbe := errors.New("invalid data found when processing input")
+	creator := &errVideoFileCreator{err: errProbe}
+
+	mockTxn := mocks.NewTransactionManager()
+	quarantined := &models.QuarantinedFile{
+		ID:     1,
+		Path:   path,
+		Reason: errProbe.Error(),
+	}
+
+	mockTxn.QuarantineMock().On("FindByPath", path).Return(nil, nil).Once()
+	mockTxn.SceneMock().On("FindByOSHash", mock.Anything).Return(nil, nil).Once()
+	mockTxn.QuarantineMock().On("Create", mock.MatchedBy(func(q models.QuarantinedFile) bool {
+		return q.Path == path && q.Reason == errProbe.Error()
+	})).Return(quarantined, nil).Once()
+
+	scanner := Scanner{
+		Scanner:          FileScanner(&file.FSHasher{}, models.HashAlgorithmOshash, false),
+		TxnManager:       mockTxn,
+		VideoFileCreator: creator,
+		MutexManager:     utils.NewMutexManager(),
+	}
+
+	// unreadable file should be quarantined
+	s, err := scanner.ScanNew(file.FSFile(path, info))
+	assert.Nil(s)
+	assert.ErrorIs(err, errProbe)
+	assert.Equal(1, creator.calls)
+
+	// quarantined file should be skipped
+	mockTxn.QuarantineMock().On("FindByPath", path).Return(quarantined, nil).Once()
+
+	s, err = scanner.ScanNew(file.FSFile(path, info))
+	assert.Nil(s)
+	assert.Nil(err)
+	assert.Equal(1, creator.calls)
+
+	mockTxn.QuarantineMock().AssertExpectations(t)
+	mockTxn.SceneMock().AssertExpectations(t)
+}
+
+func writeScanTestFile(t *testing.T, name string, content string) file.SourceFile {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return file.FSFile(path, info)
+}
+
+func TestFileScannerHashAlgorithm(t *testing.T) {
+	assert := assert.New(t)
+
+	// oshash requires the file size to be a multiple of 8
+	f := writeScanTestFile(t, "scene.mp4", "0123456789abcdef0123456789abcdef")
+
+	md5Scanner := FileScanner(&file.FSHasher{}, models.HashAlgorithmOshash, true)
+	oshashScanner := FileScanner(&file.FSHasher{}, models.HashAlgorithmOshash, false)
+
+	md5Scanned, err := md5Scanner.ScanNew(f)
+	if !assert.Nil(err) {
+		return
+	}
+	oshashScanned, err := oshashScanner.ScanNew(f)
+	if !assert.Nil(err) {
+		return
+	}
+
+	assert.NotEmpty(md5Scanned.Checksum)
+	assert.NotEmpty(md5Scanned.OSHash)
+	assert.Empty(oshashScanned.Checksum)
+	assert.Equal(md5Scanned.OSHash, oshashScanned.OSHash)
+
+	// hashes should be stable between scans
+	for _, s := range []file.Scanner{md5Scanner, oshashScanner} {
+		first, _ := s.ScanNew(f)
+		second, _ := s.ScanNew(f)
+		assert.Equal(first.Checksum, second.Checksum)
+		assert.Equal(first.OSHash, second.OSHash)
+	}
+
+	// the algorithm recorded for the scene is the one that was calculated
+	assert.Equal(models.HashAlgorithmMd5, (&Scanner{Scanner: md5Scanner}).hashAlgorithm())
+	assert.Equal(models.HashAlgorithmOshash, (&Scanner{Scanner: oshashScanner}).hashAlgorithm())
+
+	// MD5 is required when naming files by MD5
+	md5Named, _ := FileScanner(&file.FSHasher{}, models.HashAlgorithmMd5, false).ScanNew(f)
+	assert.Equal(md5Scanned.Checksum, md5Named.Checksum)
+}
+
+func TestSameFileByOSHash(t *testing.T) {
+	const (
+		checksum      = "checksum"
+		otherChecksum = "other"
+	)
+
+	md5Scene := &models.Scene{
+		Checksum:      models.NullString(checksum),
+		HashAlgorithm: models.NullString(string(models.HashAlgorithmMd5)),
+	}
+	oshashScene := &models.Scene{
+		Checksum:      models.NullString(otherChecksum),
+		HashAlgorithm: models.NullString(string(models.HashAlgorithmOshash)),
+	}
+	legacyMD5Scene := &models.Scene{
+		Checksum: models.NullString(otherChecksum),
+	}
+	legacyOSHashScene := &models.Scene{}
+
+	tests := []struct {
+		name          string
+		existing      *models.Scene
+		hashAlgorithm models.HashAlgorithm
+		checksum      string
+		want          bool
+	}{
+		{"md5 same checksum", md5Scene, models.HashAlgorithmMd5, checksum, true},
+		{"md5 different checksum", md5Scene, models.HashAlgorithmMd5, otherChecksum, false},
+		{"oshash scanned", md5Scene, models.HashAlgorithmOshash, "", true},
+		{"oshash existing", oshashScene, models.HashAlgorithmMd5, checksum, true},
+		{"legacy md5 existing", legacyMD5Scene, models.HashAlgorithmMd5, checksum, false},
+		{"legacy oshash existing", legacyOSHashScene, models.HashAlgorithmMd5, checksum, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, sameFileByOSHash(tt.existing, tt.hashAlgorithm, tt.checksum))
+		})
+	}
+}
+
+func TestScanNewOSHashCollision(t *testing.T) {
+	f := writeScanTestFile(t, "scene.mp4", "0123456789abcdef0123456789abcdef")
+
+	creator := &errVideoFileCreator{err: errors.New("should not be probed")}
+	mockTxn := mocks.NewTransactionManager()
+
+	existing := &models.Scene{
+		ID:            1,
+		Path:          "existing.mp4",
+		Checksum:      models.NullString("different"),
+		HashAlgorithm: models.NullString(string(models.HashAlgorithmMd5)),
+	}
+
+	mockTxn.QuarantineMock().On("FindByPath", f.Path()).Return(nil, nil)
+	mockTxn.SceneMock().On("FindByChecksum", mock.Anything).Return(nil, nil).Once()
+	mockTxn.SceneMock().On("FindByOSHash", mock.Anything).Return(existing, nil).Once()
+
+	scanner := Scanner{
+		Scanner:          FileScanner(&file.FSHasher{}, models.HashAlgorithmOshash, true),
+		TxnManager:       mockTxn,
+		VideoFileCreator: creator,
+		MutexManager:     utils.NewMutexManager(),
+	}
+
+	// collision should not be treated as a moved file
+	s, err := scanner.ScanNew(f)
+	assert.Nil(t, s)
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, creator.calls)
+
+	mockTxn.SceneMock().AssertExpectations(t)
+	mockTxn.SceneMock().AssertNotCalled(t, "Update", mock.Anything)
+}
+
+type testChangeRecorder struct {
+	added   []int
+	updated []int
+}
+
+func (r *testChangeRecorder) Added(id int) {
+	r.added = append(r.added, id)
+}
+
+func (r *testChangeRecorder) Updated(id int) {
+	r.updated = append(r.updated, id)
+}
+
+func TestScanNewMovedRecordsUpdated(t *testing.T) {
+	f := writeScanTestFile(t, "moved.mp4", "0123456789abcdef0123456789abcdef")
+
+	const sceneID = 1
+	existing := &models.Scene{
+		ID:   sceneID,
+		Path: filepath.Join(t.TempDir(), "original.mp4"),
+	}
+
+	mockTxn := mocks.NewTransactionManager()
+	mockTxn.QuarantineMock().On("FindByPath", f.Path()).Return(nil, nil)
+	mockTxn.SceneMock().On("FindByOSHash", mock.Anything).Return(existing, nil).Once()
+	mockTxn.SceneMock().On("Update", mock.MatchedBy(func(p models.ScenePartial) bool {
+		return p.ID == sceneID && p.Path != nil && *p.Path == f.Path()
+	})).Return(&models.Scene{ID: sceneID, Path: f.Path()}, nil).Once()
+	mockTxn.SceneMock().On("GetSubtitles", sceneID).Return(nil, nil).Maybe()
+	mockTxn.SceneMock().On("UpdateSubtitles", sceneID, mock.Anything).Return(nil).Maybe()
+	// regenerating the screenshots fails to probe the file
+	mockTxn.QuarantineMock().On("Create", mock.Anything).Return(&models.QuarantinedFile{}, nil).Maybe()
+
+	changes := &testChangeRecorder{}
+	scanner := Scanner{
+		Scanner:          FileScanner(&file.FSHasher{}, models.HashAlgorithmOshash, false),
+		TxnManager:       mockTxn,
+		Paths:            paths.NewPaths(t.TempDir()),
+		VideoFileCreator: &errVideoFileCreator{err: errors.New("not a video")},
+		PluginCache:      &plugin.Cache{},
+		Ctx:              context.Background(),
+		MutexManager:     utils.NewMutexManager(),
+	}
+	scanner.Changes = changes
+
+	_, err := scanner.ScanNew(f)
+	assert.Nil(t, err)
+
+	// moved files are updated rather than added
+	assert.Empty(t, changes.added)
+	assert.Equal(t, []int{sceneID}, changes.updated)
+
+	mockTxn.SceneMock().AssertExpectations(t)
+}
@@ -2,29 +2,35 @@ package scene
 
 import (
 	"bytes"
+	"fmt"
 	"image"
 	"image/jpeg"
 	"os"
+	"path/filepath"
 
 	"github.com/stashapp/stash/pkg/ffmpeg"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager/paths"
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
 
 	"github.com/disintegration/imaging"
 
 	// needed to decode other image formats
 	_ "image/gif"
 	_ "image/png"
+
+	_ "golang.org/x/image/webp"
 )
 
 type screenshotter interface {
 	Screenshot(probeResult ffmpeg.VideoFile, options ffmpeg.ScreenshotOptions) error
 }
 
-func makeScreenshot(encoder screenshotter, probeResult ffmpeg.VideoFile, outputPath string, quality int, width int, time float64) {
+func makeScreenshot(encoder screenshotter, probeResult ffmpeg.VideoFile, outputPath string, format ffmpeg.ImageFormat, quality int, width int, time float64) {
 	options := ffmpeg.ScreenshotOptions{
 		OutputPath: outputPath,
+		Format:     format,
 		Quality:    quality,
 		Time:       time,
 		Width:      width,
@@ -35,6 +41,45 @@ func makeScreenshot(encoder screenshotter, probeResult ffmpeg.VideoFile, outputP
 	}
 }
 
+// ScreenshotPosition is the position in a scene at which the default
+// screenshot is taken.
+type ScreenshotPosition struct {
+	// Percent is the position as a percentage of the scene duration.
+	Percent float64
+	// Seconds is the position in seconds. Used instead of Percent if greater
+	// than zero and within the scene duration.
+	Seconds float64
+}
+
+// Time returns the screenshot time in seconds for a scene with the provided
+// duration.
+func (p ScreenshotPosition) Time(duration float64) float64 {
+	if p.Seconds > 0 && p.Seconds < duration {
+		return p.Seconds
+	}
+
+	at := duration * p.Percent / 100
+	if at < 0 {
+		return 0
+	}
+	if at > duration {
+		return duration
+	}
+
+	return at
+}
+
+// SceneTime returns the screenshot time in seconds for the provided scene.
+// The screenshot time set on the scene is used if it is within the scene
+// duration, otherwise the position is used.
+func (p ScreenshotPosition) SceneTime(scene *models.Scene, duration float64) float64 {
+	if scene.ScreenshotAt.Valid && scene.ScreenshotAt.Float64 >= 0 && scene.ScreenshotAt.Float64 < duration {
+		return scene.ScreenshotAt.Float64
+	}
+
+	return p.Time(duration)
+}
+
 type ScreenshotSetter interface {
 	SetScreenshot(scene *models.Scene, imageData []byte) error
 }
@@ -70,15 +115,62 @@ func writeThumbnail(path string, thumbnail image.Image) error {
 	return jpeg.Encode(f, thumbnail, nil)
 }
 
-func SetScreenshot(paths *paths.Paths, checksum string, imageData []byte) error {
-	thumbPath := paths.Scene.GetThumbnailScreenshotPath(checksum)
-	normalPath := paths.Scene.GetScreenshotPath(checksum)
+// imageExtensions are the file extensions of screenshots by the name of
+// their decoded image format.
+var imageExtensions = map[string]string{
+	"jpeg": ".jpg",
+	"png":  ".png",
+	"gif":  ".gif",
+	"webp": ".webp",
+}
 
-	img, _, err := image.Decode(bytes.NewReader(imageData))
+// screenshotMimeTypes are the MIME types of screenshots by file extension.
+var screenshotMimeTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".png":  "image/png",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+	".avif": "image/avif",
+}
+
+// ScreenshotMimeType returns the MIME type of the screenshot file at path,
+// or an empty string if it is not known.
+func ScreenshotMimeType(path string) string {
+	return screenshotMimeTypes[filepath.Ext(path)]
+}
+
+// RemoveScreenshots removes the screenshots and thumbnails of the scene in
+// all formats, except for the paths in keep. Used so that a screenshot in a
+// new format replaces the screenshots in other formats.
+func RemoveScreenshots(paths *paths.Paths, checksum string, keep ...string) error {
+	for _, path := range paths.Scene.GetScreenshotPaths(checksum) {
+		if utils.StrInclude(keep, path) {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetScreenshot writes the image as the screenshot of the scene, named by
+// the format of the image, and writes a JPEG thumbnail of it.
+func SetScreenshot(paths *paths.Paths, checksum string, imageData []byte) error {
+	img, format, err := image.Decode(bytes.NewReader(imageData))
 	if err != nil {
 		return err
 	}
 
+	ext, ok := imageExtensions[format]
+	if !ok {
+		return fmt.Errorf("unsupported screenshot format %s", format)
+	}
+
+	thumbPath := paths.Scene.GetThumbnailScreenshotPathForExtension(checksum, ".jpg")
+	normalPath := paths.Scene.GetScreenshotPathForExtension(checksum, ext)
+
 	// resize to 320 width maintaining aspect ratio, for the thumbnail
 	const width = 320
 	origWidth := img.Bounds().Max.X
@@ -91,7 +183,9 @@ func SetScreenshot(paths *paths.Paths, checksum string, imageData []byte) error
 		return err
 	}
 
-	err = writeImage(normalPath, imageData)
+	if err := writeImage(normalPath, imageData); err != nil {
+		return err
+	}
 
-	return err
+	return RemoveScreenshots(paths, checksum, thumbPath, normalPath)
 }
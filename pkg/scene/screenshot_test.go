@@ -0,0 +1,124 @@
+package scene
+
+import (
+	"bytes"
+	"database/sql"
+	"image"
+	"image/png"
+	"os"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/ffmpeg"
+	"github.com/stashapp/stash/pkg/manager/paths"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScreenshotPosition_Time(t *testing.T) {
+	const duration = 200.0
+
+	tests := []struct {
+		name     string
+		position ScreenshotPosition
+		want     float64
+	}{
+		{"default percent", ScreenshotPosition{Percent: 20}, 40},
+		{"zero percent", ScreenshotPosition{Percent: 0}, 0},
+		{"fractional percent", ScreenshotPosition{Percent: 12.5}, 25},
+		{"percent over 100", ScreenshotPosition{Percent: 150}, duration},
+		{"negative percent", ScreenshotPosition{Percent: -10}, 0},
+		{"seconds", ScreenshotPosition{Percent: 20, Seconds: 15}, 15},
+		{"seconds past end", ScreenshotPosition{Percent: 20, Seconds: 300}, 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.position.Time(duration))
+		})
+	}
+}
+
+func TestScreenshotPosition_SceneTime(t *testing.T) {
+	const duration = 200.0
+	position := ScreenshotPosition{Percent: 20}
+
+	tests := []struct {
+		name         string
+		screenshotAt sql.NullFloat64
+		want         float64
+	}{
+		{"not set", sql.NullFloat64{}, 40},
+		{"override", sql.NullFloat64{Float64: 12, Valid: true}, 12},
+		{"override at start", sql.NullFloat64{Float64: 0, Valid: true}, 0},
+		{"override past end", sql.NullFloat64{Float64: 250, Valid: true}, 40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &models.Scene{
+				ScreenshotAt: tt.screenshotAt,
+			}
+			assert.Equal(t, tt.want, position.SceneTime(s, duration))
+		})
+	}
+}
+
+func TestSetScreenshot(t *testing.T) {
+	const checksum = "aaaa0000aaaa0000"
+
+	dir := t.TempDir()
+	p := paths.NewPaths(dir)
+	if err := os.MkdirAll(p.Generated.Screenshots, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// an existing screenshot in another format is replaced
+	oldPath := p.Scene.GetScreenshotPathForExtension(checksum, ".jpg")
+	if err := os.WriteFile(oldPath, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 640, 360))); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SetScreenshot(p, checksum, buf.Bytes()); err != nil {
+		t.Fatalf("SetScreenshot() error = %v", err)
+	}
+
+	normalPath := p.Scene.GetScreenshotPath(checksum)
+	assert.Equal(t, p.Scene.GetScreenshotPathForExtension(checksum, ".png"), normalPath)
+	assert.Equal(t, "image/png", ScreenshotMimeType(normalPath))
+	assert.Equal(t, p.Scene.GetThumbnailScreenshotPathForExtension(checksum, ".jpg"), p.Scene.GetThumbnailScreenshotPath(checksum))
+
+	_, err := os.Stat(oldPath)
+	assert.True(t, os.IsNotExist(err), "old screenshot was not removed")
+}
+
+type recordingScreenshotter struct {
+	times []float64
+}
+
+func (s *recordingScreenshotter) Screenshot(probeResult ffmpeg.VideoFile, options ffmpeg.ScreenshotOptions) error {
+	s.times = append(s.times, options.Time)
+	return nil
+}
+
+func TestMakeScreenshotsSceneTime(t *testing.T) {
+	screenshotter := &recordingScreenshotter{}
+	scanner := &Scanner{
+		Paths:              paths.NewPaths(t.TempDir()),
+		Screenshotter:      screenshotter,
+		ScreenshotPosition: ScreenshotPosition{Percent: 20},
+	}
+
+	probeResult := &ffmpeg.VideoFile{Duration: 100, Width: 1920}
+
+	// the screenshot time of the scene is used instead of the position
+	s := &models.Scene{ScreenshotAt: sql.NullFloat64{Float64: 42, Valid: true}}
+	scanner.makeScreenshots(s, "scene.mp4", probeResult, "checksum")
+	assert.Equal(t, []float64{42, 42}, screenshotter.times)
+
+	screenshotter.times = nil
+	scanner.makeScreenshots(&models.Scene{}, "scene.mp4", probeResult, "other")
+	assert.Equal(t, []float64{20, 20}, screenshotter.times)
+}
@@ -0,0 +1,352 @@
+package scene
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/stashapp/stash/pkg/gallery"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/jsonschema"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/performer"
+	"gopkg.in/yaml.v2"
+)
+
+// SidecarOptions are the options for metadata sidecar files written next to
+// scene files.
+type SidecarOptions struct {
+	// Read is whether sidecar files are read during scan.
+	Read bool
+	// Write is whether sidecar files are written when the scene metadata
+	// differs from the sidecar.
+	Write      bool
+	Format     models.SidecarFormat
+	Precedence models.SidecarPrecedence
+}
+
+// SidecarPath returns the path of the metadata sidecar file of the scene
+// file at path. The sidecar has the full name of the scene file, followed by
+// the extension of the format, so that scene files that differ only by
+// extension have different sidecars.
+func SidecarPath(path string, format models.SidecarFormat) string {
+	ext := ".json"
+	if format == models.SidecarFormatYaml {
+		ext = ".yml"
+	}
+
+	return path + ext
+}
+
+// ToSidecarJSON returns the sidecar metadata of the scene. This is the
+// metadata of the scene export, without the cover image, file properties
+// and o-counter, which are not portable between files or databases.
+func ToSidecarJSON(repo models.ReaderRepository, s *models.Scene) (*jsonschema.Scene, error) {
+	return toSidecarJSON(repo.Scene(), repo.Studio(), repo.Gallery(), repo.Performer(), repo.Movie(), repo.Tag(), s)
+}
+
+func toSidecarJSON(sceneReader models.SceneReader, studioReader models.StudioReader, galleryReader models.GalleryReader, performerReader models.PerformerReader, movieReader models.MovieReader, tagReader models.TagReader, s *models.Scene) (*jsonschema.Scene, error) {
+	ret, err := ToBasicJSON(sceneReader, s)
+	if err != nil {
+		return nil, err
+	}
+
+	ret.Cover = ""
+	ret.File = nil
+	ret.OCounter = 0
+
+	ret.Studio, err = GetStudioName(studioReader, s)
+	if err != nil {
+		return nil, fmt.Errorf("error getting scene studio name: %v", err)
+	}
+
+	galleries, err := galleryReader.FindBySceneID(s.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting scene gallery checksums: %v", err)
+	}
+	ret.Galleries = gallery.GetChecksums(galleries)
+
+	performers, err := performerReader.FindBySceneID(s.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting scene performer names: %v", err)
+	}
+	ret.Performers = performer.GetNames(performers)
+
+	ret.Tags, err = GetTagNames(tagReader, s)
+	if err != nil {
+		return nil, fmt.Errorf("error getting scene tag names: %v", err)
+	}
+
+	ret.Movies, err = GetSceneMoviesJSON(movieReader, sceneReader, s)
+	if err != nil {
+		return nil, fmt.Errorf("error getting scene movies JSON: %v", err)
+	}
+
+	return ret, nil
+}
+
+func marshalSidecar(sidecar *jsonschema.Scene, format models.SidecarFormat) ([]byte, error) {
+	data, err := json.MarshalIndent(sidecar, "", "  ")
+	if err != nil || format != models.SidecarFormatYaml {
+		return data, err
+	}
+
+	// convert through JSON so that the field names and values are the
+	// same in both formats
+	var m yaml.MapSlice
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(m)
+}
+
+func unmarshalSidecar(data []byte, format models.SidecarFormat) (*jsonschema.Scene, error) {
+	if format == models.SidecarFormatYaml {
+		var v interface{}
+		if err := yaml.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+
+		var err error
+		data, err = json.Marshal(yamlToJSON(v))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var ret jsonschema.Scene
+	if err := json.Unmarshal(data, &ret); err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+// yamlToJSON converts the maps decoded from YAML, which have interface
+// keys, to maps that can be encoded as JSON.
+func yamlToJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		ret := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			ret[fmt.Sprint(k)] = yamlToJSON(e)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(v))
+		for i, e := range v {
+			ret[i] = yamlToJSON(e)
+		}
+		return ret
+	}
+	return v
+}
+
+// LoadSidecar reads the sidecar file at path. Returns nil if the file does
+// not exist.
+func LoadSidecar(path string, format models.SidecarFormat) (*jsonschema.Scene, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := unmarshalSidecar(data, format)
+	if err != nil {
+		return nil, fmt.Errorf("error reading sidecar %s: %v", path, err)
+	}
+	return ret, nil
+}
+
+// writeSidecar writes the sidecar file at path, unless the existing file
+// has the same contents. Returns true if the file was written.
+func writeSidecar(path string, sidecar *jsonschema.Scene, format models.SidecarFormat) (bool, error) {
+	data, err := marshalSidecar(sidecar, format)
+	if err != nil {
+		return false, err
+	}
+
+	existing, err := os.ReadFile(path)
+	if err == nil && string(existing) == string(data) {
+		return false, nil
+	}
+
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// WriteSidecar writes the sidecar file of the scene, unless the existing
+// sidecar is up to date. Returns true if the file was written.
+func WriteSidecar(repo models.ReaderRepository, s *models.Scene, format models.SidecarFormat) (bool, error) {
+	sidecar, err := ToSidecarJSON(repo, s)
+	if err != nil {
+		return false, err
+	}
+
+	return writeSidecar(SidecarPath(s.Path, format), sidecar, format)
+}
+
+// sidecarMetadataEqual returns true if the sidecars have the same metadata,
+// ignoring the values that are not taken from sidecars.
+func sidecarMetadataEqual(a, b jsonschema.Scene) bool {
+	for _, s := range []*jsonschema.Scene{&a, &b} {
+		s.Checksum = ""
+		s.OSHash = ""
+		s.Phash = ""
+		s.CreatedAt = models.JSONTime{}
+		s.UpdatedAt = models.JSONTime{}
+	}
+
+	return jsonschema.CompareJSON(&a, &b)
+}
+
+// sidecarTakesPrecedence returns true if the metadata of the sidecar should
+// replace that of the scene, when they differ.
+func sidecarTakesPrecedence(precedence models.SidecarPrecedence, sidecar *jsonschema.Scene, current *jsonschema.Scene) bool {
+	switch precedence {
+	case models.SidecarPrecedenceSidecar:
+		return true
+	case models.SidecarPrecedenceNewest:
+		return sidecar.UpdatedAt.Time.After(current.UpdatedAt.Time)
+	}
+	return false
+}
+
+// ApplySidecar replaces the metadata and relationships of the scene with
+// those of the sidecar. Studios, performers, movies and tags in the sidecar
+// that do not exist are ignored, since sidecars are not rewritten when they
+// are changed outside of the scene. The file properties of the scene are not
+// changed.
+func ApplySidecar(repo models.Repository, s *models.Scene, sidecar *jsonschema.Scene) error {
+	i := &Importer{
+		ReaderWriter:        repo.Scene(),
+		StudioWriter:        repo.Studio(),
+		GalleryWriter:       repo.Gallery(),
+		PerformerWriter:     repo.Performer(),
+		MovieWriter:         repo.Movie(),
+		TagWriter:           repo.Tag(),
+		Input:               *sidecar,
+		Path:                s.Path,
+		MissingRefBehaviour: models.ImportMissingRefEnumIgnore,
+	}
+
+	// the cover is not taken from the sidecar
+	i.Input.Cover = ""
+
+	if err := i.PreImport(); err != nil {
+		return err
+	}
+
+	qb := repo.Scene()
+	if _, err := qb.Update(models.ScenePartial{
+		ID:        s.ID,
+		Title:     &i.scene.Title,
+		Details:   &i.scene.Details,
+		URL:       &i.scene.URL,
+		Date:      &i.scene.Date,
+		Rating:    &i.scene.Rating,
+		Organized: &i.scene.Organized,
+		StudioID:  &i.scene.StudioID,
+		UpdatedAt: &models.SQLiteTimestamp{Timestamp: time.Now()},
+	}); err != nil {
+		return fmt.Errorf("error updating scene: %v", err)
+	}
+
+	var galleryIDs []int
+	for _, g := range i.galleries {
+		galleryIDs = append(galleryIDs, g.ID)
+	}
+	if err := qb.UpdateGalleries(s.ID, galleryIDs); err != nil {
+		return fmt.Errorf("failed to associate galleries: %v", err)
+	}
+
+	var performerIDs []int
+	for _, p := range i.performers {
+		performerIDs = append(performerIDs, p.ID)
+	}
+	if err := qb.UpdatePerformers(s.ID, performerIDs); err != nil {
+		return fmt.Errorf("failed to associate performers: %v", err)
+	}
+
+	for index := range i.movies {
+		i.movies[index].SceneID = s.ID
+	}
+	if err := qb.UpdateMovies(s.ID, i.movies); err != nil {
+		return fmt.Errorf("failed to associate movies: %v", err)
+	}
+
+	var tagIDs []int
+	for _, t := range i.tags {
+		tagIDs = append(tagIDs, t.ID)
+	}
+	if err := qb.UpdateTags(s.ID, tagIDs); err != nil {
+		return fmt.Errorf("failed to associate tags: %v", err)
+	}
+
+	if err := qb.UpdateStashIDs(s.ID, sidecar.StashIDs); err != nil {
+		return fmt.Errorf("error setting stash id: %v", err)
+	}
+
+	return nil
+}
+
+// SyncSidecar reconciles the scene with its sidecar file. The sidecar of a
+// new scene is applied to it. Where the sidecar of an existing scene has
+// different metadata, the precedence option determines whether the
+// sidecar is applied to the scene or rewritten. A sidecar that is missing or
+// out of date is written if the write option is set.
+func SyncSidecar(repo models.Repository, s *models.Scene, isNew bool, options SidecarOptions) error {
+	if !options.Read && !options.Write {
+		return nil
+	}
+
+	path := SidecarPath(s.Path, options.Format)
+
+	var sidecar *jsonschema.Scene
+	if options.Read {
+		var err error
+		sidecar, err = LoadSidecar(path, options.Format)
+		if err != nil {
+			return err
+		}
+	}
+
+	toJSON := func(s *models.Scene) (*jsonschema.Scene, error) {
+		return toSidecarJSON(repo.Scene(), repo.Studio(), repo.Gallery(), repo.Performer(), repo.Movie(), repo.Tag(), s)
+	}
+
+	current, err := toJSON(s)
+	if err != nil {
+		return err
+	}
+
+	if sidecar != nil && !sidecarMetadataEqual(*sidecar, *current) && (isNew || sidecarTakesPrecedence(options.Precedence, sidecar, current)) {
+		logger.Infof("Updating scene %s from sidecar %s", s.Path, path)
+		if err := ApplySidecar(repo, s, sidecar); err != nil {
+			return fmt.Errorf("error applying sidecar %s: %v", path, err)
+		}
+
+		updated, err := repo.Scene().Find(s.ID)
+		if err != nil {
+			return err
+		}
+
+		current, err = toJSON(updated)
+		if err != nil {
+			return err
+		}
+	}
+
+	if options.Write {
+		if _, err := writeSidecar(path, current, options.Format); err != nil {
+			return fmt.Errorf("error writing sidecar %s: %v", path, err)
+		}
+	}
+
+	return nil
+}
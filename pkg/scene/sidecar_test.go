@@ -0,0 +1,301 @@
+package scene
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/manager/jsonschema"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSidecarPath(t *testing.T) {
+	assert.Equal(t, filepath.Join("dir", "movie.mp4.json"), SidecarPath(filepath.Join("dir", "movie.mp4"), models.SidecarFormatJSON))
+	assert.Equal(t, filepath.Join("dir", "movie.mp4.yml"), SidecarPath(filepath.Join("dir", "movie.mp4"), models.SidecarFormatYaml))
+	assert.Equal(t, filepath.Join("dir", "movie.part1.mkv.json"), SidecarPath(filepath.Join("dir", "movie.part1.mkv"), models.SidecarFormatJSON))
+}
+
+func TestSidecarFormats(t *testing.T) {
+	updatedAt := time.Date(2021, 6, 1, 12, 30, 0, 0, time.UTC)
+	sidecar := &jsonschema.Scene{
+		Title:      "Title: with colon",
+		Date:       "2021-05-01",
+		Rating:     4,
+		Organized:  true,
+		Studio:     "Studio",
+		Performers: []string{"Performer"},
+		Tags:       []string{"Tag 1", "Tag 2"},
+		Movies:     []jsonschema.SceneMovie{{MovieName: "Movie", SceneIndex: 2}},
+		StashIDs:   []models.StashID{{StashID: "abc", Endpoint: "https://stashdb.org/graphql"}},
+		UpdatedAt:  models.JSONTime{Time: updatedAt},
+	}
+
+	for _, format := range []models.SidecarFormat{models.SidecarFormatJSON, models.SidecarFormatYaml} {
+		t.Run(format.String(), func(t *testing.T) {
+			data, err := marshalSidecar(sidecar, format)
+			if !assert.Nil(t, err) {
+				return
+			}
+
+			got, err := unmarshalSidecar(data, format)
+			if !assert.Nil(t, err) {
+				return
+			}
+
+			assert.True(t, jsonschema.CompareJSON(sidecar, got), string(data))
+		})
+	}
+}
+
+// mockSidecarReaders sets up the readers used to build the sidecar of a
+// scene without relationships.
+func mockSidecarReaders(repo *mocks.TransactionManager, sceneID int) {
+	repo.SceneMock().On("GetCover", sceneID).Return(nil, nil)
+	repo.SceneMock().On("GetStashIDs", sceneID).Return(nil, nil)
+	repo.SceneMock().On("GetMovies", sceneID).Return(nil, nil)
+	repo.GalleryMock().On("FindBySceneID", sceneID).Return(nil, nil)
+	repo.PerformerMock().On("FindBySceneID", sceneID).Return(nil, nil)
+	repo.TagMock().On("FindBySceneID", sceneID).Return(nil, nil)
+}
+
+func TestWriteSidecar(t *testing.T) {
+	const sceneID = 1
+
+	dir := t.TempDir()
+	s := &models.Scene{
+		ID:    sceneID,
+		Path:  filepath.Join(dir, "movie.mp4"),
+		Title: models.NullString("Title"),
+	}
+
+	repo := mocks.NewTransactionManager()
+	mockSidecarReaders(repo, sceneID)
+
+	write := func() bool {
+		t.Helper()
+		var written bool
+		if err := repo.WithReadTxn(context.Background(), func(r models.ReaderRepository) error {
+			var err error
+			written, err = WriteSidecar(r, s, models.SidecarFormatJSON)
+			return err
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return written
+	}
+
+	// written when missing
+	assert.True(t, write())
+	// not rewritten when unchanged
+	assert.False(t, write())
+
+	// rewritten when the metadata changes
+	s.Title = models.NullString("New title")
+	assert.True(t, write())
+
+	got, err := LoadSidecar(filepath.Join(dir, "movie.mp4.json"), models.SidecarFormatJSON)
+	assert.Nil(t, err)
+	assert.Equal(t, "New title", got.Title)
+}
+
+func TestLoadSidecarMissing(t *testing.T) {
+	got, err := LoadSidecar(filepath.Join(t.TempDir(), "movie.mp4.json"), models.SidecarFormatJSON)
+	assert.Nil(t, err)
+	assert.Nil(t, got)
+}
+
+func writeTestSidecar(t *testing.T, path string, sidecar *jsonschema.Scene) {
+	t.Helper()
+	data, err := marshalSidecar(sidecar, models.SidecarFormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSyncSidecarNewScene(t *testing.T) {
+	const (
+		sceneID  = 1
+		studioID = 2
+		tagID    = 3
+	)
+
+	dir := t.TempDir()
+	s := &models.Scene{
+		ID:    sceneID,
+		Path:  filepath.Join(dir, "movie.mp4"),
+		Title: models.NullString("movie"),
+	}
+
+	writeTestSidecar(t, filepath.Join(dir, "movie.mp4.json"), &jsonschema.Scene{
+		Title:  "Sidecar title",
+		Studio: "Studio",
+		Tags:   []string{"Tag"},
+	})
+
+	repo := mocks.NewTransactionManager()
+	mockSidecarReaders(repo, sceneID)
+
+	repo.StudioMock().On("FindByName", "Studio", false).Return(&models.Studio{ID: studioID}, nil)
+	repo.TagMock().On("FindByNames", []string{"Tag"}, false).Return([]*models.Tag{{ID: tagID, Name: "Tag"}}, nil)
+
+	sceneQB := repo.SceneMock()
+	sceneQB.On("Update", mock.MatchedBy(func(p models.ScenePartial) bool {
+		return p.ID == sceneID && p.Title.String == "Sidecar title" && p.StudioID.Int64 == studioID
+	})).Return(s, nil).Once()
+	sceneQB.On("UpdateGalleries", sceneID, []int(nil)).Return(nil).Once()
+	sceneQB.On("UpdatePerformers", sceneID, []int(nil)).Return(nil).Once()
+	sceneQB.On("UpdateMovies", sceneID, []models.MoviesScenes(nil)).Return(nil).Once()
+	sceneQB.On("UpdateTags", sceneID, []int{tagID}).Return(nil).Once()
+	sceneQB.On("UpdateStashIDs", sceneID, []models.StashID(nil)).Return(nil).Once()
+	sceneQB.On("Find", sceneID).Return(s, nil)
+
+	err := repo.WithTxn(context.Background(), func(r models.Repository) error {
+		return SyncSidecar(r, s, true, SidecarOptions{
+			Read:       true,
+			Format:     models.SidecarFormatJSON,
+			Precedence: models.SidecarPrecedenceDatabase,
+		})
+	})
+
+	assert.Nil(t, err)
+	sceneQB.AssertExpectations(t)
+}
+
+func TestSyncSidecarExistingScene(t *testing.T) {
+	const sceneID = 1
+
+	sceneUpdated := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		precedence     models.SidecarPrecedence
+		sidecarUpdated time.Time
+		wantApplied    bool
+	}{
+		{"database", models.SidecarPrecedenceDatabase, sceneUpdated.Add(time.Hour), false},
+		{"sidecar", models.SidecarPrecedenceSidecar, sceneUpdated.Add(-time.Hour), true},
+		{"newest sidecar", models.SidecarPrecedenceNewest, sceneUpdated.Add(time.Hour), true},
+		{"newest database", models.SidecarPrecedenceNewest, sceneUpdated.Add(-time.Hour), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			sidecarPath := filepath.Join(dir, "movie.mp4.json")
+			s := &models.Scene{
+				ID:        sceneID,
+				Path:      filepath.Join(dir, "movie.mp4"),
+				Title:     models.NullString("Database title"),
+				UpdatedAt: models.SQLiteTimestamp{Timestamp: sceneUpdated},
+			}
+
+			writeTestSidecar(t, sidecarPath, &jsonschema.Scene{
+				Title:     "Sidecar title",
+				UpdatedAt: models.JSONTime{Time: tt.sidecarUpdated},
+			})
+
+			repo := mocks.NewTransactionManager()
+			mockSidecarReaders(repo, sceneID)
+
+			sceneQB := repo.SceneMock()
+			if tt.wantApplied {
+				applied := *s
+				applied.Title = models.NullString("Sidecar title")
+
+				sceneQB.On("Update", mock.MatchedBy(func(p models.ScenePartial) bool {
+					return p.Title.String == "Sidecar title"
+				})).Return(&applied, nil).Once()
+				sceneQB.On("UpdateGalleries", sceneID, mock.Anything).Return(nil)
+				sceneQB.On("UpdatePerformers", sceneID, mock.Anything).Return(nil)
+				sceneQB.On("UpdateMovies", sceneID, mock.Anything).Return(nil)
+				sceneQB.On("UpdateTags", sceneID, mock.Anything).Return(nil)
+				sceneQB.On("UpdateStashIDs", sceneID, mock.Anything).Return(nil)
+				sceneQB.On("Find", sceneID).Return(&applied, nil)
+			}
+
+			err := repo.WithTxn(context.Background(), func(r models.Repository) error {
+				return SyncSidecar(r, s, false, SidecarOptions{
+					Read:       true,
+					Write:      true,
+					Format:     models.SidecarFormatJSON,
+					Precedence: tt.precedence,
+				})
+			})
+			assert.Nil(t, err)
+			sceneQB.AssertExpectations(t)
+
+			// the sidecar is rewritten if the database takes precedence
+			got, err := LoadSidecar(sidecarPath, models.SidecarFormatJSON)
+			assert.Nil(t, err)
+			assert.Equal(t, "Sidecar title" == got.Title, tt.wantApplied)
+		})
+	}
+}
+
+func TestSyncSidecarUnchanged(t *testing.T) {
+	const sceneID = 1
+
+	dir := t.TempDir()
+	s := &models.Scene{
+		ID:        sceneID,
+		Path:      filepath.Join(dir, "movie.mp4"),
+		Title:     models.NullString("Title"),
+		Checksum:  sql.NullString{String: "checksum", Valid: true},
+		UpdatedAt: models.SQLiteTimestamp{Timestamp: time.Now()},
+	}
+
+	// the sidecar differs only in values that are not taken from it
+	writeTestSidecar(t, filepath.Join(dir, "movie.mp4.json"), &jsonschema.Scene{
+		Title:    "Title",
+		Checksum: "other",
+	})
+
+	repo := mocks.NewTransactionManager()
+	mockSidecarReaders(repo, sceneID)
+
+	err := repo.WithTxn(context.Background(), func(r models.Repository) error {
+		return SyncSidecar(r, s, true, SidecarOptions{
+			Read:       true,
+			Format:     models.SidecarFormatJSON,
+			Precedence: models.SidecarPrecedenceSidecar,
+		})
+	})
+
+	assert.Nil(t, err)
+	repo.SceneMock().AssertNotCalled(t, "Update", mock.Anything)
+}
+
+func TestSidecarChanged(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "movie.mp4.json")
+	s := &models.Scene{Path: filepath.Join(dir, "movie.mp4")}
+
+	// a missing sidecar only needs to be synchronised if it is written
+	assert.False(t, sidecarChanged(s, path, false))
+	assert.True(t, sidecarChanged(s, path, true))
+
+	writeTestSidecar(t, path, &jsonschema.Scene{Title: "Title"})
+	assert.True(t, sidecarChanged(s, path, false))
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SidecarModTime = models.NullSQLiteTimestamp{Timestamp: info.ModTime().Truncate(time.Second), Valid: true}
+	assert.False(t, sidecarChanged(s, path, true))
+
+	modTime := info.ModTime().Add(time.Hour)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, sidecarChanged(s, path, false))
+}
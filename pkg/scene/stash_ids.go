@@ -0,0 +1,146 @@
+package scene
+
+import (
+	"sort"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// ReconcileStashIDs merges the stash ids of scenes that share a stash id.
+// Scenes that share a stash id on any endpoint, directly or through other
+// scenes, are treated as duplicates of the same stash-box scene.
+//
+// The stash ids of each group of duplicates are merged into the scene with
+// the lowest id. Where the scenes have different stash ids for an endpoint,
+// the existing stash id of that scene is kept. The stash ids merged into
+// that scene are then removed from the other scenes of the group.
+//
+// Returns the number of groups of duplicates that were merged.
+func ReconcileStashIDs(qb models.SceneReaderWriter) (int, error) {
+	shared, err := qb.FindSharedStashIDs()
+	if err != nil {
+		return 0, err
+	}
+
+	ret := 0
+	for _, group := range groupSharedStashIDs(shared) {
+		if err := mergeStashIDs(qb, group[0], group[1:]); err != nil {
+			return ret, err
+		}
+		ret++
+	}
+
+	return ret, nil
+}
+
+// groupSharedStashIDs joins the groups of scene ids that have a scene in
+// common. The returned groups and the ids within them are sorted.
+func groupSharedStashIDs(shared [][]int) [][]int {
+	parent := make(map[int]int)
+	var find func(id int) int
+	find = func(id int) int {
+		p, found := parent[id]
+		if !found {
+			parent[id] = id
+			return id
+		}
+		if p != id {
+			p = find(p)
+			parent[id] = p
+		}
+		return p
+	}
+
+	for _, ids := range shared {
+		for _, id := range ids[1:] {
+			a, b := find(ids[0]), find(id)
+			if a < b {
+				parent[b] = a
+			} else {
+				parent[a] = b
+			}
+		}
+	}
+
+	groups := make(map[int][]int)
+	for id := range parent {
+		root := find(id)
+		groups[root] = append(groups[root], id)
+	}
+
+	var ret [][]int
+	for _, g := range groups {
+		if len(g) < 2 {
+			continue
+		}
+		sort.Ints(g)
+		ret = append(ret, g)
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		return ret[i][0] < ret[j][0]
+	})
+
+	return ret
+}
+
+func mergeStashIDs(qb models.SceneReaderWriter, destination int, sources []int) error {
+	destIDs, err := qb.GetStashIDs(destination)
+	if err != nil {
+		return err
+	}
+
+	endpoints := make(map[string]bool)
+	var merged []models.StashID
+	for _, id := range destIDs {
+		endpoints[id.Endpoint] = true
+		merged = append(merged, *id)
+	}
+
+	sourceIDs := make(map[int][]*models.StashID)
+	for _, src := range sources {
+		ids, err := qb.GetStashIDs(src)
+		if err != nil {
+			return err
+		}
+		sourceIDs[src] = ids
+
+		for _, id := range ids {
+			if !endpoints[id.Endpoint] {
+				endpoints[id.Endpoint] = true
+				merged = append(merged, *id)
+			}
+		}
+	}
+
+	if len(merged) != len(destIDs) {
+		if err := qb.UpdateStashIDs(destination, merged); err != nil {
+			return err
+		}
+	}
+
+	// the stash-box scenes are now identified by the destination scene
+	held := make(map[string]bool)
+	for _, id := range merged {
+		held[id.StashID] = true
+	}
+
+	for _, src := range sources {
+		ids := sourceIDs[src]
+
+		var remaining []models.StashID
+		for _, id := range ids {
+			if !held[id.StashID] {
+				remaining = append(remaining, *id)
+			}
+		}
+
+		if len(remaining) != len(ids) {
+			if err := qb.UpdateStashIDs(src, remaining); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package scene
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_groupSharedStashIDs(t *testing.T) {
+	tests := []struct {
+		name   string
+		shared [][]int
+		want   [][]int
+	}{
+		{"none", nil, nil},
+		{"single", [][]int{{2, 1}}, [][]int{{1, 2}}},
+		{"separate", [][]int{{3, 4}, {1, 2}}, [][]int{{1, 2}, {3, 4}}},
+		{"transitive", [][]int{{1, 2}, {3, 4}, {2, 3}}, [][]int{{1, 2, 3, 4}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, groupSharedStashIDs(tt.shared))
+		})
+	}
+}
+
+func TestReconcileStashIDs(t *testing.T) {
+	const (
+		sceneID1 = 1
+		sceneID2 = 2
+
+		endpoint1 = "endpoint1"
+		endpoint2 = "endpoint2"
+		endpoint3 = "endpoint3"
+
+		sharedID = "sharedID"
+	)
+
+	mockSceneReader := &mocks.SceneReaderWriter{}
+
+	// both scenes have the shared id, on different endpoints, and
+	// conflicting ids for endpoint 3
+	mockSceneReader.On("FindSharedStashIDs").Return([][]int{{sceneID1, sceneID2}}, nil).Once()
+	mockSceneReader.On("GetStashIDs", sceneID1).Return([]*models.StashID{
+		{StashID: sharedID, Endpoint: endpoint1},
+		{StashID: "scene1ID", Endpoint: endpoint3},
+	}, nil).Once()
+	mockSceneReader.On("GetStashIDs", sceneID2).Return([]*models.StashID{
+		{StashID: sharedID, Endpoint: endpoint2},
+		{StashID: "scene2ID", Endpoint: endpoint3},
+	}, nil).Once()
+
+	// the first scene gains the ids of new endpoints and keeps its own id
+	// for conflicting endpoints
+	mockSceneReader.On("UpdateStashIDs", sceneID1, []models.StashID{
+		{StashID: sharedID, Endpoint: endpoint1},
+		{StashID: "scene1ID", Endpoint: endpoint3},
+		{StashID: sharedID, Endpoint: endpoint2},
+	}).Return(nil).Once()
+	// the second scene no longer has the shared id
+	mockSceneReader.On("UpdateStashIDs", sceneID2, []models.StashID{
+		{StashID: "scene2ID", Endpoint: endpoint3},
+	}).Return(nil).Once()
+
+	merged, err := ReconcileStashIDs(mockSceneReader)
+
+	assert := assert.New(t)
+	assert.Nil(err)
+	assert.Equal(1, merged)
+	mockSceneReader.AssertExpectations(t)
+}
@@ -0,0 +1,119 @@
+package scene
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// SubtitleExtensions are the file extensions of subtitle sidecar files.
+var SubtitleExtensions = []string{".srt", ".vtt"}
+
+// languageCodeRE matches language codes in subtitle file names, such as
+// en, eng or pt-BR.
+var languageCodeRE = regexp.MustCompile(`^[a-zA-Z]{2,3}([-_][a-zA-Z0-9]{2,4})?$`)
+
+// matchSubtitle returns the subtitle for the file name subtitleName if it
+// is a subtitle for the video file with the base name videoName. Subtitle
+// files must have the same base name as the video file, optionally
+// followed by a language code, for example movie.srt or movie.en.srt.
+// Returns nil if the file is not a subtitle for the video.
+func matchSubtitle(videoName string, subtitleName string) *models.SceneSubtitle {
+	ext := filepath.Ext(subtitleName)
+	subtitleType := strings.ToLower(strings.TrimPrefix(ext, "."))
+
+	isSubtitle := false
+	for _, e := range SubtitleExtensions {
+		if strings.EqualFold(ext, e) {
+			isSubtitle = true
+			break
+		}
+	}
+	if !isSubtitle {
+		return nil
+	}
+
+	videoBase := strings.TrimSuffix(videoName, filepath.Ext(videoName))
+	subtitleBase := strings.TrimSuffix(subtitleName, ext)
+
+	if subtitleBase == videoBase {
+		return &models.SceneSubtitle{
+			SubtitleType: subtitleType,
+		}
+	}
+
+	lang := strings.TrimPrefix(subtitleBase, videoBase+".")
+	if lang == subtitleBase || !languageCodeRE.MatchString(lang) {
+		return nil
+	}
+
+	return &models.SceneSubtitle{
+		LanguageCode: lang,
+		SubtitleType: subtitleType,
+	}
+}
+
+// FindSubtitles returns the subtitle files in the same directory as the
+// video file at videoPath.
+func FindSubtitles(videoPath string) ([]models.SceneSubtitle, error) {
+	// subtitles of remote files are not supported
+	if file.IsRemotePath(videoPath) {
+		return nil, nil
+	}
+
+	dir := filepath.Dir(videoPath)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	videoName := filepath.Base(videoPath)
+
+	var ret []models.SceneSubtitle
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+
+		if s := matchSubtitle(videoName, e.Name()); s != nil {
+			s.Path = filepath.Join(dir, e.Name())
+			ret = append(ret, *s)
+		}
+	}
+
+	return ret, nil
+}
+
+// UpdateSubtitles associates the subtitle files found alongside the scene
+// file with the scene. Returns true if the associated subtitles changed.
+func UpdateSubtitles(qb models.SceneReaderWriter, s *models.Scene) (bool, error) {
+	subtitles, err := FindSubtitles(s.Path)
+	if err != nil {
+		return false, err
+	}
+
+	existing, err := qb.GetSubtitles(s.ID)
+	if err != nil {
+		return false, err
+	}
+
+	var current []models.SceneSubtitle
+	for _, e := range existing {
+		current = append(current, *e)
+	}
+
+	if reflect.DeepEqual(current, subtitles) {
+		return false, nil
+	}
+
+	if err := qb.UpdateSubtitles(s.ID, subtitles); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,112 @@
+package scene
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMatchSubtitle(t *testing.T) {
+	const videoName = "movie.mp4"
+
+	tests := []struct {
+		subtitleName string
+		want         *models.SceneSubtitle
+	}{
+		{"movie.srt", &models.SceneSubtitle{SubtitleType: "srt"}},
+		{"movie.vtt", &models.SceneSubtitle{SubtitleType: "vtt"}},
+		{"movie.SRT", &models.SceneSubtitle{SubtitleType: "srt"}},
+		{"movie.en.srt", &models.SceneSubtitle{LanguageCode: "en", SubtitleType: "srt"}},
+		{"movie.eng.vtt", &models.SceneSubtitle{LanguageCode: "eng", SubtitleType: "vtt"}},
+		{"movie.pt-BR.srt", &models.SceneSubtitle{LanguageCode: "pt-BR", SubtitleType: "srt"}},
+		{"movie.mp4", nil},
+		{"movie.txt", nil},
+		{"movie2.srt", nil},
+		{"other.srt", nil},
+		{"movie.en", nil},
+		// other video files sharing the prefix should not match
+		{"movie.part2.srt", nil},
+		{"movie.mp4.srt", nil},
+		{"Movie.srt", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subtitleName, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchSubtitle(videoName, tt.subtitleName))
+		})
+	}
+}
+
+func TestFindSubtitles(t *testing.T) {
+	dir := t.TempDir()
+
+	files := []string{
+		"movie.mp4",
+		"movie.srt",
+		"movie.en.srt",
+		"movie.de.srt",
+		"movie.fr.vtt",
+		"movie.nfo",
+		"other.mp4",
+		"other.en.srt",
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(dir, f), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// directories with matching names should be ignored
+	if err := os.Mkdir(filepath.Join(dir, "movie.es.srt"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := FindSubtitles(filepath.Join(dir, "movie.mp4"))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	assert.Equal(t, []models.SceneSubtitle{
+		{LanguageCode: "de", SubtitleType: "srt", Path: filepath.Join(dir, "movie.de.srt")},
+		{LanguageCode: "en", SubtitleType: "srt", Path: filepath.Join(dir, "movie.en.srt")},
+		{LanguageCode: "fr", SubtitleType: "vtt", Path: filepath.Join(dir, "movie.fr.vtt")},
+		{SubtitleType: "srt", Path: filepath.Join(dir, "movie.srt")},
+	}, got)
+}
+
+func TestUpdateSubtitles(t *testing.T) {
+	const (
+		unchangedID = 1
+		changedID   = 2
+	)
+
+	dir := t.TempDir()
+	videoPath := filepath.Join(dir, "movie.mp4")
+	subtitlePath := filepath.Join(dir, "movie.en.srt")
+	if err := os.WriteFile(subtitlePath, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	existing := &models.SceneSubtitle{LanguageCode: "en", SubtitleType: "srt", Path: subtitlePath}
+
+	mockSceneReader := &mocks.SceneReaderWriter{}
+	mockSceneReader.On("GetSubtitles", unchangedID).Return([]*models.SceneSubtitle{existing}, nil).Once()
+	mockSceneReader.On("GetSubtitles", changedID).Return(nil, nil).Once()
+	mockSceneReader.On("UpdateSubtitles", changedID, []models.SceneSubtitle{*existing}).Return(nil).Once()
+
+	changed, err := UpdateSubtitles(mockSceneReader, &models.Scene{ID: unchangedID, Path: videoPath})
+	assert.Nil(t, err)
+	assert.False(t, changed)
+
+	changed, err = UpdateSubtitles(mockSceneReader, &models.Scene{ID: changedID, Path: videoPath})
+	assert.Nil(t, err)
+	assert.True(t, changed)
+
+	mockSceneReader.AssertNotCalled(t, "UpdateSubtitles", unchangedID, mock.Anything)
+	mockSceneReader.AssertExpectations(t)
+}
@@ -219,3 +219,70 @@ func AddGallery(qb models.SceneReaderWriter, id int, galleryID int) (bool, error
 
 	return false, nil
 }
+
+// TagsBulkUpdater provides the methods needed to add or remove tags across
+// many scenes at once.
+type TagsBulkUpdater interface {
+	Queryer
+	AddTags(sceneIDs []int, tagIDs []int) (int, error)
+	RemoveTags(sceneIDs []int, tagIDs []int) (int, error)
+}
+
+// BulkAddTags adds the tags to each of the scenes with the provided ids. If
+// sceneFilter is not nil, the tags are instead added to every scene matching
+// the filter. Scenes that already have a tag are left unchanged. Returns the
+// number of scene tags added.
+func BulkAddTags(qb TagsBulkUpdater, sceneIDs []int, sceneFilter *models.SceneFilterType, tagIDs []int) (int, error) {
+	ids, err := bulkSceneIDs(qb, sceneIDs, sceneFilter)
+	if err != nil {
+		return 0, err
+	}
+
+	return qb.AddTags(ids, tagIDs)
+}
+
+// BulkRemoveTags removes the tags from each of the scenes with the provided
+// ids. If sceneFilter is not nil, the tags are instead removed from every
+// scene matching the filter. Returns the number of scene tags removed.
+func BulkRemoveTags(qb TagsBulkUpdater, sceneIDs []int, sceneFilter *models.SceneFilterType, tagIDs []int) (int, error) {
+	ids, err := bulkSceneIDs(qb, sceneIDs, sceneFilter)
+	if err != nil {
+		return 0, err
+	}
+
+	return qb.RemoveTags(ids, tagIDs)
+}
+
+// CountersBulkUpdater provides the methods needed to reset the counters of
+// many scenes at once.
+type CountersBulkUpdater interface {
+	Queryer
+	ResetCounters(sceneIDs []int, counters []models.SceneCounter) (int, error)
+}
+
+// BulkResetCounters resets the counters of each of the scenes with the
+// provided ids. If sceneFilter is not nil, the counters of every scene
+// matching the filter are instead reset. Returns the number of scenes with a
+// counter reset.
+func BulkResetCounters(qb CountersBulkUpdater, sceneIDs []int, sceneFilter *models.SceneFilterType, counters []models.SceneCounter) (int, error) {
+	ids, err := bulkSceneIDs(qb, sceneIDs, sceneFilter)
+	if err != nil {
+		return 0, err
+	}
+
+	return qb.ResetCounters(ids, counters)
+}
+
+func bulkSceneIDs(qb Queryer, sceneIDs []int, sceneFilter *models.SceneFilterType) ([]int, error) {
+	if sceneFilter == nil {
+		return sceneIDs, nil
+	}
+
+	perPage := -1
+	result, err := qb.Query(QueryOptions(sceneFilter, &models.FindFilterType{PerPage: &perPage}, false))
+	if err != nil {
+		return nil, fmt.Errorf("error querying for scenes: %w", err)
+	}
+
+	return result.IDs, nil
+}
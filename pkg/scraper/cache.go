@@ -36,6 +36,9 @@ type GlobalConfig interface {
 	GetScrapersPath() string
 	GetScraperCDPPath() string
 	GetScraperCertCheck() bool
+	GetScraperCookiesPath() string
+	GetScraperFieldMappings() []*models.ScraperFieldMapping
+	GetScraperTransforms() []*models.ScraperTransform
 }
 
 func isCDPPathHTTP(c GlobalConfig) bool {
@@ -201,7 +204,16 @@ func (c Cache) ScrapeName(ctx context.Context, id, query string, ty models.Scrap
 		return nil, fmt.Errorf("%w: cannot use scraper %s to scrape by name", ErrNotSupported, id)
 	}
 
-	return ns.viaName(ctx, c.client, query, ty)
+	content, err := ns.viaName(ctx, c.client, query, ty)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range content {
+		content[i] = c.processFields(id, content[i])
+	}
+
+	return content, nil
 }
 
 // ScrapeFragment uses the given fragment input to scrape
@@ -221,7 +233,7 @@ func (c Cache) ScrapeFragment(ctx context.Context, id string, input Input) (mode
 		return nil, fmt.Errorf("error while fragment scraping with scraper %s: %w", id, err)
 	}
 
-	return c.postScrape(ctx, content)
+	return c.postScrape(ctx, c.processFields(id, content))
 }
 
 // ScrapeURL scrapes a given url for the given content. Searches the scraper cache
@@ -243,7 +255,7 @@ func (c Cache) ScrapeURL(ctx context.Context, url string, ty models.ScrapeConten
 				return ret, nil
 			}
 
-			return c.postScrape(ctx, ret)
+			return c.postScrape(ctx, c.processFields(s.spec().ID, ret))
 		}
 	}
 
@@ -294,5 +306,5 @@ func (c Cache) ScrapeID(ctx context.Context, scraperID string, id int, ty models
 		}
 	}
 
-	return c.postScrape(ctx, ret)
+	return c.postScrape(ctx, c.processFields(scraperID, ret))
 }
@@ -0,0 +1,235 @@
+package scraper
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+
+	"github.com/stashapp/stash/pkg/logger"
+)
+
+// storedCookie is a cookie set by a scraped site, stored with the URL of
+// the response that set it.
+type storedCookie struct {
+	URL      string `json:"url"`
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+	HttpOnly bool   `json:"http_only,omitempty"`
+	// Expires is zero for session cookies, which are kept until they are
+	// replaced.
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+// key identifies the cookie by its domain, path and name. Cookies without
+// a domain are only sent to the host of their URL.
+func (c storedCookie) key() string {
+	domain := c.Domain
+	if domain == "" {
+		if u, err := url.Parse(c.URL); err == nil {
+			domain = u.Hostname()
+		}
+	}
+
+	return domain + ";" + c.Path + ";" + c.Name
+}
+
+func (c storedCookie) expired(now time.Time) bool {
+	return !c.Expires.IsZero() && !c.Expires.After(now)
+}
+
+func (c storedCookie) httpCookie() *http.Cookie {
+	return &http.Cookie{
+		Name:     c.Name,
+		Value:    c.Value,
+		Path:     c.Path,
+		Domain:   c.Domain,
+		Secure:   c.Secure,
+		HttpOnly: c.HttpOnly,
+		Expires:  c.Expires,
+	}
+}
+
+// persistentJar is a cookie jar that stores its cookies in a file, so that
+// sessions of scraped sites are kept between restarts.
+type persistentJar struct {
+	mutex   sync.Mutex
+	path    string
+	jar     *cookiejar.Jar
+	cookies map[string]storedCookie
+}
+
+// loadPersistentJar returns a jar with the cookies stored in the file at
+// path, excluding expired cookies. The jar is empty if the file does not
+// exist.
+func loadPersistentJar(path string, now time.Time) (*persistentJar, error) {
+	jar, err := cookiejar.New(&cookiejar.Options{
+		PublicSuffixList: publicsuffix.List,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ret := &persistentJar{
+		path:    path,
+		jar:     jar,
+		cookies: make(map[string]storedCookie),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ret, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var stored []storedCookie
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, err
+	}
+
+	for _, c := range stored {
+		if c.expired(now) {
+			continue
+		}
+
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			continue
+		}
+
+		ret.cookies[c.key()] = c
+		ret.jar.SetCookies(u, []*http.Cookie{c.httpCookie()})
+	}
+
+	return ret, nil
+}
+
+// Cookies returns the cookies to send in a request for the URL.
+func (j *persistentJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// SetCookies handles the cookies of a response from the URL. Cookies with a
+// MaxAge are converted to cookies with an expiry time, and cookies that have
+// expired are removed.
+func (j *persistentJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.setCookies(u, cookies, time.Now())
+}
+
+func (j *persistentJar) setCookies(u *url.URL, cookies []*http.Cookie, now time.Time) {
+	if len(cookies) == 0 {
+		return
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	j.jar.SetCookies(u, cookies)
+
+	for _, c := range cookies {
+		stored := storedCookie{
+			URL:      u.String(),
+			Name:     c.Name,
+			Value:    c.Value,
+			Path:     c.Path,
+			Domain:   c.Domain,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+			Expires:  c.Expires,
+		}
+
+		switch {
+		case c.MaxAge < 0:
+			stored.Expires = now
+		case c.MaxAge > 0:
+			stored.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		}
+
+		if stored.expired(now) {
+			delete(j.cookies, stored.key())
+		} else {
+			j.cookies[stored.key()] = stored
+		}
+	}
+}
+
+// save writes the cookies to the file of the jar, excluding expired
+// cookies. The file is only readable by the owner, since cookies may
+// contain session credentials.
+func (j *persistentJar) save(now time.Time) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	stored := []storedCookie{}
+	for k, c := range j.cookies {
+		if c.expired(now) {
+			delete(j.cookies, k)
+			continue
+		}
+		stored = append(stored, c)
+	}
+
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0700); err != nil {
+		return err
+	}
+
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, j.path)
+}
+
+// cookieJars holds the persistent cookie jars of scrapers.
+type cookieJars struct {
+	mutex sync.Mutex
+	jars  map[string]*persistentJar
+}
+
+var scraperCookieJars = &cookieJars{
+	jars: make(map[string]*persistentJar),
+}
+
+// get returns the persistent jar of the scraper, loading it from the
+// directory the first time it is used. It returns nil if dir is empty.
+func (c *cookieJars) get(dir string, scraperID string) *persistentJar {
+	if dir == "" || scraperID == "" {
+		return nil
+	}
+
+	path := filepath.Join(dir, scraperID+".json")
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if jar, found := c.jars[path]; found {
+		return jar
+	}
+
+	jar, err := loadPersistentJar(path, time.Now())
+	if err != nil {
+		logger.Warnf("error loading cookies of scraper %s: %v", scraperID, err)
+		return nil
+	}
+
+	c.jars[path] = jar
+	return jar
+}
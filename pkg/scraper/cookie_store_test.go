@@ -0,0 +1,166 @@
+package scraper
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func cookieValues(cookies []*http.Cookie) map[string]string {
+	ret := make(map[string]string)
+	for _, c := range cookies {
+		ret[c.Name] = c.Value
+	}
+	return ret
+}
+
+func TestPersistentJarSaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies", "scraper.json")
+	now := time.Now()
+
+	jar, err := loadPersistentJar(path, now)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	u, _ := url.Parse("https://www.example.com/login")
+	jar.setCookies(u, []*http.Cookie{
+		{Name: "session", Value: "abc", Path: "/"},
+		{Name: "remember", Value: "def", Path: "/", Expires: now.Add(time.Hour)},
+		{Name: "maxage", Value: "ghi", Path: "/", MaxAge: 3600},
+	}, now)
+
+	if !assert.NoError(t, jar.save(now)) {
+		return
+	}
+
+	info, err := os.Stat(path)
+	if assert.NoError(t, err) {
+		assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	loaded, err := loadPersistentJar(path, now)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	other, _ := url.Parse("https://www.example.com/scenes/1")
+	assert.Equal(t, map[string]string{
+		"session":  "abc",
+		"remember": "def",
+		"maxage":   "ghi",
+	}, cookieValues(loaded.Cookies(other)))
+
+	// host-only cookies are not sent to other hosts
+	sub, _ := url.Parse("https://sub.www.example.com/")
+	assert.Empty(t, loaded.Cookies(sub))
+}
+
+func TestPersistentJarExpiry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scraper.json")
+	now := time.Now()
+
+	jar, err := loadPersistentJar(path, now)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	u, _ := url.Parse("https://example.com/")
+	jar.setCookies(u, []*http.Cookie{
+		{Name: "short", Value: "a", Expires: now.Add(time.Minute)},
+		{Name: "long", Value: "b", Expires: now.Add(time.Hour)},
+		{Name: "maxage", Value: "c", MaxAge: 120},
+		{Name: "deleted", Value: "d"},
+	}, now)
+
+	// deleting a cookie removes it
+	jar.setCookies(u, []*http.Cookie{
+		{Name: "deleted", MaxAge: -1},
+	}, now)
+
+	assert.NoError(t, jar.save(now))
+
+	// cookies that have expired by the time the jar is loaded are pruned
+	later := now.Add(90 * time.Second)
+	loaded, err := loadPersistentJar(path, later)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, loaded.cookies, 2)
+	assert.Contains(t, loaded.cookies, "example.com;;long")
+	assert.Contains(t, loaded.cookies, "example.com;;maxage")
+
+	// expired cookies are pruned when saved
+	assert.NoError(t, loaded.save(now.Add(3*time.Minute)))
+	loaded, err = loadPersistentJar(path, now)
+	if assert.NoError(t, err) {
+		assert.Len(t, loaded.cookies, 1)
+		assert.Contains(t, loaded.cookies, "example.com;;long")
+	}
+}
+
+func TestMergeCookies(t *testing.T) {
+	got := mergeCookies([]*http.Cookie{
+		{Name: "a", Value: "config"},
+		{Name: "b", Value: "config"},
+	}, []*http.Cookie{
+		{Name: "b", Value: "site"},
+	})
+
+	assert.Equal(t, map[string]string{"a": "config", "b": "site"}, cookieValues(got))
+	assert.Len(t, got, 2)
+}
+
+type cookiesGlobalConfig struct {
+	mockGlobalConfig
+	path string
+}
+
+func (c cookiesGlobalConfig) GetScraperCookiesPath() string {
+	return c.path
+}
+
+func TestLoadURLRedirectCookies(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		http.Redirect(w, r, "/page", http.StatusFound)
+	})
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		c, err := r.Cookie("session")
+		if err != nil {
+			http.Error(w, "no session", http.StatusForbidden)
+			return
+		}
+		_, _ = io.WriteString(w, c.Value)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	gc := cookiesGlobalConfig{path: t.TempDir()}
+	c := config{ID: "redirect"}
+
+	// the cookie set by the redirect is sent to the redirected page
+	r, err := loadURL(context.Background(), server.URL+"/login", server.Client(), c, gc)
+	if !assert.NoError(t, err) {
+		return
+	}
+	body, _ := io.ReadAll(r)
+	assert.Equal(t, "abc", string(body))
+
+	// and is persisted
+	loaded, err := loadPersistentJar(filepath.Join(gc.path, "redirect.json"), time.Now())
+	if !assert.NoError(t, err) {
+		return
+	}
+	u, _ := url.Parse(server.URL + "/page")
+	assert.Equal(t, map[string]string{"session": "abc"}, cookieValues(loaded.Cookies(u)))
+}
@@ -58,6 +58,57 @@ func (c config) jar() (*cookiejar.Jar, error) {
 	return jar, nil
 }
 
+// mergeCookies returns the cookies, with cookies replaced by the override of
+// the same name.
+func mergeCookies(cookies []*http.Cookie, overrides []*http.Cookie) []*http.Cookie {
+	names := make(map[string]bool)
+	for _, c := range overrides {
+		names[c.Name] = true
+	}
+
+	var ret []*http.Cookie
+	for _, c := range cookies {
+		if !names[c.Name] {
+			ret = append(ret, c)
+		}
+	}
+
+	return append(ret, overrides...)
+}
+
+// requestJar is the cookie jar of a single scrape request. Cookies are taken
+// from the jar of the scraper configuration, replaced by cookies previously
+// set by the site. Cookies set by responses, including redirects, are stored
+// in the persistent jar if there is one.
+type requestJar struct {
+	jar        *cookiejar.Jar
+	persistent *persistentJar
+	changed    bool
+}
+
+func (j *requestJar) Cookies(u *url.URL) []*http.Cookie {
+	cookies := j.jar.Cookies(u)
+	if j.persistent != nil {
+		cookies = mergeCookies(cookies, j.persistent.Cookies(u))
+	}
+
+	return cookies
+}
+
+func (j *requestJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if len(cookies) == 0 {
+		return
+	}
+
+	if j.persistent == nil {
+		j.jar.SetCookies(u, cookies)
+		return
+	}
+
+	j.persistent.SetCookies(u, cookies)
+	j.changed = true
+}
+
 func getCookieValue(cookie *scraperCookies) string {
 	if cookie.ValueRandom > 0 {
 		return utils.RandomSequence(cookie.ValueRandom)
@@ -0,0 +1,109 @@
+package scraper
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// mapScrapedFields applies the mappings for the scraper to the content.
+// Mappings of fields that the content does not have, and of fields that are
+// not strings, are ignored. A mapped field is cleared, and the target field
+// is replaced with its value.
+func mapScrapedFields(mappings []*models.ScraperFieldMapping, scraperID string, content models.ScrapedContent) models.ScrapedContent {
+	if len(mappings) == 0 {
+		return content
+	}
+
+	return modifyScrapedContent(content, func(s reflect.Value) {
+		for _, m := range mappings {
+			if m == nil || (m.Scraper != "" && m.Scraper != scraperID) {
+				continue
+			}
+
+			mapScrapedField(s, m.Source, m.Target)
+		}
+	})
+}
+
+// modifyScrapedContent calls fn with the struct value of the content, and
+// returns the modified content. Content passed by value is copied.
+func modifyScrapedContent(content models.ScrapedContent, fn func(s reflect.Value)) models.ScrapedContent {
+	if content == nil {
+		return content
+	}
+
+	v := reflect.ValueOf(content)
+	isPtr := v.Kind() == reflect.Ptr
+	if isPtr {
+		if v.IsNil() {
+			return content
+		}
+	} else {
+		// copy the value so that it can be modified
+		ptr := reflect.New(v.Type())
+		ptr.Elem().Set(v)
+		v = ptr
+	}
+
+	s := v.Elem()
+	if s.Kind() != reflect.Struct {
+		return content
+	}
+
+	fn(s)
+
+	if isPtr {
+		return content
+	}
+	return s.Interface().(models.ScrapedContent)
+}
+
+func mapScrapedField(s reflect.Value, source string, target string) {
+	src := scrapedField(s, source)
+	if !isStringPtr(src) || src.IsNil() {
+		return
+	}
+
+	value := src.Elem().String()
+
+	if strings.EqualFold(target, models.ScraperFieldMappingTag) {
+		tags := scrapedField(s, "tags")
+		if !tags.IsValid() || tags.Type() != reflect.TypeOf([]*models.ScrapedTag{}) {
+			return
+		}
+
+		if value != "" {
+			tags.Set(reflect.Append(tags, reflect.ValueOf(&models.ScrapedTag{Name: value})))
+		}
+		src.Set(reflect.Zero(src.Type()))
+		return
+	}
+
+	dest := scrapedField(s, target)
+	if !isStringPtr(dest) || strings.EqualFold(source, target) {
+		return
+	}
+
+	dest.Set(reflect.ValueOf(&value))
+	src.Set(reflect.Zero(src.Type()))
+}
+
+// scrapedField returns the field of the struct with the json name, or the
+// zero Value if there is no such field.
+func scrapedField(s reflect.Value, name string) reflect.Value {
+	t := s.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if tag != "" && strings.EqualFold(tag, name) {
+			return s.Field(i)
+		}
+	}
+
+	return reflect.Value{}
+}
+
+func isStringPtr(v reflect.Value) bool {
+	return v.IsValid() && v.Type() == reflect.TypeOf((*string)(nil))
+}
@@ -0,0 +1,82 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestMapScrapedFields(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	tests := []struct {
+		name     string
+		mappings []*models.ScraperFieldMapping
+		content  models.ScrapedContent
+		want     models.ScrapedContent
+	}{
+		{
+			"field to field",
+			[]*models.ScraperFieldMapping{{Source: "details", Target: "title"}},
+			&models.ScrapedScene{Title: str("title"), Details: str("details")},
+			&models.ScrapedScene{Title: str("details")},
+		},
+		{
+			"field to tag",
+			[]*models.ScraperFieldMapping{{Source: "remote_site_id", Target: "tag"}},
+			models.ScrapedScene{
+				RemoteSiteID: str("series"),
+				Tags:         []*models.ScrapedTag{{Name: "tag"}},
+			},
+			models.ScrapedScene{
+				Tags: []*models.ScrapedTag{{Name: "tag"}, {Name: "series"}},
+			},
+		},
+		{
+			"other scraper",
+			[]*models.ScraperFieldMapping{{Scraper: "other", Source: "details", Target: "title"}},
+			&models.ScrapedScene{Details: str("details")},
+			&models.ScrapedScene{Details: str("details")},
+		},
+		{
+			"matching scraper",
+			[]*models.ScraperFieldMapping{{Scraper: "scraper", Source: "twitter", Target: "url"}},
+			&models.ScrapedPerformer{Twitter: str("twitter")},
+			&models.ScrapedPerformer{URL: str("twitter")},
+		},
+		{
+			"missing source",
+			[]*models.ScraperFieldMapping{{Source: "details", Target: "title"}},
+			&models.ScrapedScene{Title: str("title")},
+			&models.ScrapedScene{Title: str("title")},
+		},
+		{
+			"unknown target",
+			[]*models.ScraperFieldMapping{{Source: "details", Target: "series"}},
+			&models.ScrapedScene{Details: str("details")},
+			&models.ScrapedScene{Details: str("details")},
+		},
+		{
+			"non-string target",
+			[]*models.ScraperFieldMapping{{Source: "details", Target: "duration"}},
+			&models.ScrapedScene{Details: str("details")},
+			&models.ScrapedScene{Details: str("details")},
+		},
+		{
+			"without tags",
+			[]*models.ScraperFieldMapping{{Source: "name", Target: "tag"}},
+			&models.ScrapedStudio{Name: "name"},
+			&models.ScrapedStudio{Name: "name"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mapScrapedFields(tt.mappings, "scraper", tt.content)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mapScrapedFields() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
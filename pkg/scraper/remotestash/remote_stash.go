@@ -0,0 +1,290 @@
+// Package remotestash provides a client to pull scene metadata from a
+// remote stash instance.
+package remotestash
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/shurcooL/graphql"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/match"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+const apiKeyHeader = "ApiKey"
+
+// Client represents the client interface to a remote stash instance.
+type Client struct {
+	client     *graphql.Client
+	txnManager models.TransactionManager
+	endpoint   string
+}
+
+// apiKeyTransport sets the API key header on outgoing requests.
+type apiKeyTransport struct {
+	apiKey string
+	base   http.RoundTripper
+}
+
+func (t apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set(apiKeyHeader, t.apiKey)
+	return t.base.RoundTrip(req)
+}
+
+// NewClient returns a new client for the remote stash instance at endpoint.
+// The endpoint is the base URL of the remote server. The API key is sent
+// with each request if it is not empty. If httpClient is nil,
+// http.DefaultClient is used.
+func NewClient(endpoint string, apiKey string, httpClient *http.Client, txnManager models.TransactionManager) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	if apiKey != "" {
+		base := httpClient.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+
+		c := *httpClient
+		c.Transport = apiKeyTransport{
+			apiKey: apiKey,
+			base:   base,
+		}
+		httpClient = &c
+	}
+
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	return &Client{
+		client:     graphql.NewClient(endpoint+"/graphql", httpClient),
+		txnManager: txnManager,
+		endpoint:   endpoint,
+	}
+}
+
+func (c Client) String() string {
+	return fmt.Sprintf("remote stash %s", c.endpoint)
+}
+
+type remoteStudio struct {
+	Name string `graphql:"name"`
+}
+
+type remoteTag struct {
+	Name string `graphql:"name"`
+}
+
+type remotePerformer struct {
+	Name string `graphql:"name"`
+}
+
+type remoteScene struct {
+	ID         string            `graphql:"id"`
+	Title      *string           `graphql:"title"`
+	Details    *string           `graphql:"details"`
+	URL        *string           `graphql:"url"`
+	Date       *string           `graphql:"date"`
+	Studio     *remoteStudio     `graphql:"studio"`
+	Tags       []remoteTag       `graphql:"tags"`
+	Performers []remotePerformer `graphql:"performers"`
+}
+
+func (s remoteScene) toScrapedScene() *models.ScrapedScene {
+	ret := &models.ScrapedScene{
+		Title:   s.Title,
+		Details: s.Details,
+		URL:     s.URL,
+		Date:    s.Date,
+	}
+
+	if s.Studio != nil {
+		ret.Studio = &models.ScrapedStudio{
+			Name: s.Studio.Name,
+		}
+	}
+
+	for _, t := range s.Tags {
+		ret.Tags = append(ret.Tags, &models.ScrapedTag{
+			Name: t.Name,
+		})
+	}
+
+	for _, p := range s.Performers {
+		name := p.Name
+		ret.Performers = append(ret.Performers, &models.ScrapedPerformer{
+			Name: &name,
+		})
+	}
+
+	return ret
+}
+
+// names of the graphql input types must match the remote schema
+
+type SceneHashInput struct {
+	Checksum *string `json:"checksum,omitempty"`
+	Oshash   *string `json:"oshash,omitempty"`
+}
+
+type CriterionModifier string
+
+type StringCriterionInput struct {
+	Value    string            `json:"value"`
+	Modifier CriterionModifier `json:"modifier"`
+}
+
+type SceneFilterType struct {
+	Phash *StringCriterionInput `json:"phash,omitempty"`
+}
+
+type FindFilterType struct {
+	PerPage *int `json:"per_page,omitempty"`
+}
+
+func (c Client) findSceneByHash(ctx context.Context, s *models.Scene) (*remoteScene, error) {
+	if !s.Checksum.Valid && !s.OSHash.Valid {
+		return nil, nil
+	}
+
+	var q struct {
+		FindScene *remoteScene `graphql:"findSceneByHash(input: $c)"`
+	}
+
+	input := SceneHashInput{}
+	if s.Checksum.Valid {
+		input.Checksum = &s.Checksum.String
+	}
+	if s.OSHash.Valid {
+		input.Oshash = &s.OSHash.String
+	}
+
+	vars := map[string]interface{}{
+		"c": input,
+	}
+
+	if err := c.client.Query(ctx, &q, vars); err != nil {
+		return nil, err
+	}
+
+	return q.FindScene, nil
+}
+
+func (c Client) findSceneByPhash(ctx context.Context, s *models.Scene) (*remoteScene, error) {
+	if !s.Phash.Valid {
+		return nil, nil
+	}
+
+	var q struct {
+		FindScenes struct {
+			Count  int            `graphql:"count"`
+			Scenes []*remoteScene `graphql:"scenes"`
+		} `graphql:"findScenes(scene_filter: $f, filter: $p)"`
+	}
+
+	// only need to know if there is more than one match
+	perPage := 2
+	vars := map[string]interface{}{
+		"f": SceneFilterType{
+			Phash: &StringCriterionInput{
+				Value:    utils.PhashToString(s.Phash.Int64),
+				Modifier: CriterionModifier(models.CriterionModifierEquals),
+			},
+		},
+		"p": FindFilterType{
+			PerPage: &perPage,
+		},
+	}
+
+	if err := c.client.Query(ctx, &q, vars); err != nil {
+		return nil, err
+	}
+
+	// ignore ambiguous matches
+	if q.FindScenes.Count != 1 || len(q.FindScenes.Scenes) != 1 {
+		if q.FindScenes.Count > 1 {
+			logger.Debugf("%d remote scenes match phash of %s", q.FindScenes.Count, s.Path)
+		}
+		return nil, nil
+	}
+
+	return q.FindScenes.Scenes[0], nil
+}
+
+// FindScene returns the metadata of the remote scene matching the provided
+// scene. Scenes are matched by MD5 checksum or oshash, then by phash.
+// Studios, performers and tags are matched to existing local objects by
+// name. Returns nil if no matching remote scene was found.
+func (c Client) FindScene(ctx context.Context, s *models.Scene) (*models.ScrapedScene, error) {
+	found, err := c.findSceneByHash(ctx, s)
+	if err != nil {
+		return nil, fmt.Errorf("querying remote scene by hash: %w", err)
+	}
+
+	if found == nil {
+		found, err = c.findSceneByPhash(ctx, s)
+		if err != nil {
+			return nil, fmt.Errorf("querying remote scene by phash: %w", err)
+		}
+	}
+
+	if found == nil {
+		return nil, nil
+	}
+
+	ret := found.toScrapedScene()
+	if err := c.matchRelationships(ctx, ret); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+func (c Client) matchRelationships(ctx context.Context, s *models.ScrapedScene) error {
+	return c.txnManager.WithReadTxn(ctx, func(r models.ReaderRepository) error {
+		if s.Studio != nil {
+			if err := match.ScrapedStudio(r.Studio(), s.Studio, nil); err != nil {
+				return err
+			}
+		}
+
+		for _, p := range s.Performers {
+			if err := match.ScrapedPerformer(r.Performer(), p, nil); err != nil {
+				return err
+			}
+		}
+
+		for _, t := range s.Tags {
+			if err := match.ScrapedTag(r.Tag(), t); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// ScrapeScene returns the metadata of the remote scene matching the local
+// scene with the provided ID.
+func (c Client) ScrapeScene(ctx context.Context, sceneID int) (*models.ScrapedScene, error) {
+	var s *models.Scene
+	if err := c.txnManager.WithReadTxn(ctx, func(r models.ReaderRepository) error {
+		var err error
+		s, err = r.Scene().Find(sceneID)
+		return err
+	}); err != nil {
+		return nil, err
+	}
+
+	if s == nil {
+		return nil, fmt.Errorf("%w: scene with id %d", models.ErrNotFound, sceneID)
+	}
+
+	return c.FindScene(ctx, s)
+}
@@ -0,0 +1,206 @@
+package remotestash
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stashapp/stash/pkg/utils"
+)
+
+const (
+	testAPIKey = "apikey"
+
+	hashMatchOSHash = "hashmatch"
+	noMatchOSHash   = "nomatch"
+
+	phashMatch     int64 = 0x1234
+	phashAmbiguous int64 = 0x5678
+
+	remoteTitle         = "remote title"
+	remoteDetails       = "remote details"
+	remoteStudioName    = "remote studio"
+	remoteTagName       = "remote tag"
+	remotePerformerName = "remote performer"
+
+	studioID    = 1
+	tagID       = 2
+	performerID = 3
+)
+
+const remoteSceneJSON = `{
+	"id": "10",
+	"title": "` + remoteTitle + `",
+	"details": "` + remoteDetails + `",
+	"url": null,
+	"date": "2021-01-02",
+	"studio": {"name": "` + remoteStudioName + `"},
+	"tags": [{"name": "` + remoteTagName + `"}],
+	"performers": [{"name": "` + remotePerformerName + `"}]
+}`
+
+type graphqlRequest struct {
+	Query     string                     `json:"query"`
+	Variables map[string]json.RawMessage `json:"variables"`
+}
+
+// remoteHandler mocks the graphql API of a remote stash instance.
+func remoteHandler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(apiKeyHeader) != testAPIKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var req graphqlRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case strings.Contains(req.Query, "findSceneByHash"):
+			assert.Contains(t, req.Query, "$c:SceneHashInput!")
+
+			var input SceneHashInput
+			_ = json.Unmarshal(req.Variables["c"], &input)
+
+			scene := "null"
+			if input.Oshash != nil && *input.Oshash == hashMatchOSHash {
+				scene = remoteSceneJSON
+			}
+			_, _ = w.Write([]byte(`{"data": {"findSceneByHash": ` + scene + `}}`))
+		case strings.Contains(req.Query, "findScenes"):
+			var filter SceneFilterType
+			_ = json.Unmarshal(req.Variables["f"], &filter)
+
+			result := `{"count": 0, "scenes": []}`
+			if filter.Phash != nil {
+				switch filter.Phash.Value {
+				case utils.PhashToString(phashMatch):
+					result = `{"count": 1, "scenes": [` + remoteSceneJSON + `]}`
+				case utils.PhashToString(phashAmbiguous):
+					result = `{"count": 2, "scenes": [` + remoteSceneJSON + `, ` + remoteSceneJSON + `]}`
+				}
+			}
+			_, _ = w.Write([]byte(`{"data": {"findScenes": ` + result + `}}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}
+}
+
+func newTestTxnManager() *mocks.TransactionManager {
+	mockTxn := mocks.NewTransactionManager()
+
+	mockTxn.StudioMock().On("Query", mock.MatchedBy(func(f *models.StudioFilterType) bool {
+		return f.Name != nil && f.Name.Value == remoteStudioName
+	}), mock.Anything).Return([]*models.Studio{{ID: studioID}}, 1, nil)
+	mockTxn.TagMock().On("Query", mock.MatchedBy(func(f *models.TagFilterType) bool {
+		return f.Name != nil && f.Name.Value == remoteTagName
+	}), mock.Anything).Return([]*models.Tag{{ID: tagID}}, 1, nil)
+	mockTxn.PerformerMock().On("FindByNames", []string{remotePerformerName}, true).Return([]*models.Performer{{ID: performerID}}, nil)
+
+	return mockTxn
+}
+
+func TestClientFindScene(t *testing.T) {
+	server := httptest.NewServer(remoteHandler(t))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/", testAPIKey, nil, newTestTxnManager())
+
+	storedID := func(id int) *string {
+		s := strconv.Itoa(id)
+		return &s
+	}
+
+	date := "2021-01-02"
+	title := remoteTitle
+	details := remoteDetails
+	performerName := remotePerformerName
+	expected := &models.ScrapedScene{
+		Title:   &title,
+		Details: &details,
+		Date:    &date,
+		Studio: &models.ScrapedStudio{
+			StoredID: storedID(studioID),
+			Name:     remoteStudioName,
+		},
+		Tags: []*models.ScrapedTag{
+			{StoredID: storedID(tagID), Name: remoteTagName},
+		},
+		Performers: []*models.ScrapedPerformer{
+			{StoredID: storedID(performerID), Name: &performerName},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		scene *models.Scene
+		want  *models.ScrapedScene
+	}{
+		{
+			"hash match",
+			&models.Scene{
+				OSHash: sql.NullString{String: hashMatchOSHash, Valid: true},
+			},
+			expected,
+		},
+		{
+			"phash match",
+			&models.Scene{
+				OSHash: sql.NullString{String: noMatchOSHash, Valid: true},
+				Phash:  sql.NullInt64{Int64: phashMatch, Valid: true},
+			},
+			expected,
+		},
+		{
+			"ambiguous phash",
+			&models.Scene{
+				OSHash: sql.NullString{String: noMatchOSHash, Valid: true},
+				Phash:  sql.NullInt64{Int64: phashAmbiguous, Valid: true},
+			},
+			nil,
+		},
+		{
+			"no match",
+			&models.Scene{
+				OSHash: sql.NullString{String: noMatchOSHash, Valid: true},
+			},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.FindScene(context.Background(), tt.scene)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestClientAuthentication(t *testing.T) {
+	server := httptest.NewServer(remoteHandler(t))
+	defer server.Close()
+
+	client := NewClient(server.URL, "invalid", nil, newTestTxnManager())
+
+	_, err := client.FindScene(context.Background(), &models.Scene{
+		OSHash: sql.NullString{String: hashMatchOSHash, Valid: true},
+	})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,107 @@
+package scraper
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// processFields applies the configured field mappings, followed by the
+// configured transforms, to content scraped by the scraper.
+func (c Cache) processFields(scraperID string, content models.ScrapedContent) models.ScrapedContent {
+	content = mapScrapedFields(c.globalConfig.GetScraperFieldMappings(), scraperID, content)
+	return transformScrapedFields(c.globalConfig.GetScraperTransforms(), scraperID, content)
+}
+
+type fieldTransform interface {
+	apply(value string) string
+}
+
+type regexReplaceTransform struct {
+	re   *regexp.Regexp
+	with string
+}
+
+func (t regexReplaceTransform) apply(value string) string {
+	return t.re.ReplaceAllString(value, t.with)
+}
+
+type trimTransform struct{}
+
+func (trimTransform) apply(value string) string {
+	return strings.TrimSpace(value)
+}
+
+type titleCaseTransform struct{}
+
+func (titleCaseTransform) apply(value string) string {
+	runes := []rune(value)
+	wordStart := true
+	for i, r := range runes {
+		switch {
+		case unicode.IsSpace(r):
+			wordStart = true
+		case wordStart:
+			runes[i] = unicode.ToTitle(r)
+			wordStart = false
+		default:
+			runes[i] = unicode.ToLower(r)
+		}
+	}
+
+	return string(runes)
+}
+
+func newFieldTransform(t models.ScraperTransform) (fieldTransform, error) {
+	switch t.Type {
+	case models.ScraperTransformRegexReplace:
+		re, err := regexp.Compile(t.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %w", t.Regex, err)
+		}
+		return regexReplaceTransform{re: re, with: t.With}, nil
+	case models.ScraperTransformTrim:
+		return trimTransform{}, nil
+	case models.ScraperTransformTitleCase:
+		return titleCaseTransform{}, nil
+	}
+
+	return nil, fmt.Errorf("unknown transform type %q", t.Type)
+}
+
+// transformScrapedFields applies the transforms for the scraper to the
+// content in order. Transforms of fields that the content does not have, or
+// that are not strings, are ignored. Invalid transforms are logged and
+// ignored.
+func transformScrapedFields(transforms []*models.ScraperTransform, scraperID string, content models.ScrapedContent) models.ScrapedContent {
+	if len(transforms) == 0 {
+		return content
+	}
+
+	return modifyScrapedContent(content, func(s reflect.Value) {
+		for _, t := range transforms {
+			if t == nil || (t.Scraper != "" && t.Scraper != scraperID) {
+				continue
+			}
+
+			field := scrapedField(s, t.Field)
+			if !isStringPtr(field) || field.IsNil() {
+				continue
+			}
+
+			transform, err := newFieldTransform(*t)
+			if err != nil {
+				logger.Warnf("Ignoring scraper transform of field %s: %v", t.Field, err)
+				continue
+			}
+
+			value := transform.apply(field.Elem().String())
+			field.Set(reflect.ValueOf(&value))
+		}
+	})
+}
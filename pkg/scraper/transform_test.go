@@ -0,0 +1,86 @@
+package scraper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func TestTransformScrapedFields(t *testing.T) {
+	str := func(s string) *string { return &s }
+
+	stripSuffix := &models.ScraperTransform{
+		Field: "title",
+		Type:  models.ScraperTransformRegexReplace,
+		Regex: `\s*\|\s*Example\.com$`,
+	}
+
+	tests := []struct {
+		name       string
+		transforms []*models.ScraperTransform
+		content    models.ScrapedContent
+		want       models.ScrapedContent
+	}{
+		{
+			"regex replace",
+			[]*models.ScraperTransform{stripSuffix},
+			&models.ScrapedScene{Title: str("Scene Title | Example.com")},
+			&models.ScrapedScene{Title: str("Scene Title")},
+		},
+		{
+			"regex replace with groups",
+			[]*models.ScraperTransform{{
+				Field: "title",
+				Type:  models.ScraperTransformRegexReplace,
+				Regex: `^(\w+) - (\w+)$`,
+				With:  "$2 - $1",
+			}},
+			models.ScrapedScene{Title: str("first - second")},
+			models.ScrapedScene{Title: str("second - first")},
+		},
+		{
+			"ordered",
+			[]*models.ScraperTransform{
+				stripSuffix,
+				{Field: "title", Type: models.ScraperTransformTrim},
+				{Field: "title", Type: models.ScraperTransformTitleCase},
+			},
+			&models.ScrapedScene{Title: str("  sCENE title | Example.com")},
+			&models.ScrapedScene{Title: str("Scene Title")},
+		},
+		{
+			"other scraper",
+			[]*models.ScraperTransform{{Scraper: "other", Field: "title", Type: models.ScraperTransformTrim}},
+			&models.ScrapedScene{Title: str(" title ")},
+			&models.ScrapedScene{Title: str(" title ")},
+		},
+		{
+			"invalid regex",
+			[]*models.ScraperTransform{{Field: "title", Type: models.ScraperTransformRegexReplace, Regex: "("}},
+			&models.ScrapedScene{Title: str("title")},
+			&models.ScrapedScene{Title: str("title")},
+		},
+		{
+			"unknown type",
+			[]*models.ScraperTransform{{Field: "title", Type: "upper"}},
+			&models.ScrapedScene{Title: str("title")},
+			&models.ScrapedScene{Title: str("title")},
+		},
+		{
+			"missing field",
+			[]*models.ScraperTransform{{Field: "details", Type: models.ScraperTransformTrim}},
+			&models.ScrapedScene{Title: str(" title ")},
+			&models.ScrapedScene{Title: str(" title ")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := transformScrapedFields(tt.transforms, "scraper", tt.content)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("transformScrapedFields() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -40,16 +40,14 @@ func loadURL(ctx context.Context, loadURL string, client *http.Client, scraperCo
 		return nil, fmt.Errorf("error creating cookie jar: %w", err)
 	}
 
-	u, err := url.Parse(loadURL)
-	if err != nil {
-		return nil, fmt.Errorf("error parsing url %s: %w", loadURL, err)
-	}
-
-	// Fetch relevant cookies from the jar for url u and add them to the request
-	cookies := jar.Cookies(u)
-	for _, cookie := range cookies {
-		req.AddCookie(cookie)
+	// the client sends the cookies of the jar with the request and any
+	// redirects, and stores the cookies set by each response
+	reqJar := &requestJar{
+		jar:        jar,
+		persistent: scraperCookieJars.get(globalConfig.GetScraperCookiesPath(), scraperConfig.ID),
 	}
+	jarClient := *client
+	jarClient.Jar = reqJar
 
 	userAgent := globalConfig.GetScraperUserAgent()
 	if userAgent != "" {
@@ -65,7 +63,12 @@ func loadURL(ctx context.Context, loadURL string, client *http.Client, scraperCo
 		}
 	}
 
-	resp, err := client.Do(req)
+	resp, err := jarClient.Do(req)
+	if reqJar.changed {
+		if err := reqJar.persistent.save(time.Now()); err != nil {
+			logger.Warnf("error saving cookies of scraper %s: %v", scraperConfig.ID, err)
+		}
+	}
 	if err != nil {
 		return nil, err
 	}
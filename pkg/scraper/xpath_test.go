@@ -826,6 +826,18 @@ func (mockGlobalConfig) GetScraperCertCheck() bool {
 	return false
 }
 
+func (mockGlobalConfig) GetScraperCookiesPath() string {
+	return ""
+}
+
+func (mockGlobalConfig) GetScraperFieldMappings() []*models.ScraperFieldMapping {
+	return nil
+}
+
+func (mockGlobalConfig) GetScraperTransforms() []*models.ScraperTransform {
+	return nil
+}
+
 func TestSubScrape(t *testing.T) {
 	retHTML := `
 	<div>
@@ -18,20 +18,14 @@ func (e ExternalAccessError) Error() string {
 
 func CheckAllowPublicWithoutAuth(c *config.Instance, r *http.Request) error {
 	if !c.HasCredentials() && !c.GetDangerousAllowPublicWithoutAuth() && !c.IsNewSystem() {
-		requestIPString, _, err := net.SplitHostPort(r.RemoteAddr)
-		if err != nil {
-			return fmt.Errorf("error parsing remote host (%s): %w", r.RemoteAddr, err)
-		}
-
-		// presence of scope ID in IPv6 addresses prevents parsing. Remove if present
-		scopeIDIndex := strings.Index(requestIPString, "%")
-		if scopeIDIndex != -1 {
-			requestIPString = requestIPString[0:scopeIDIndex]
+		// the user was authenticated by a trusted reverse proxy
+		if _, ok := trustedHeaderUser(c, r); ok {
+			return nil
 		}
 
-		requestIP := net.ParseIP(requestIPString)
-		if requestIP == nil {
-			return fmt.Errorf("unable to parse remote host (%s)", requestIPString)
+		requestIP, err := remoteIP(r)
+		if err != nil {
+			return err
 		}
 
 		if r.Header.Get("X-FORWARDED-FOR") != "" {
@@ -60,6 +54,27 @@ func CheckAllowPublicWithoutAuth(c *config.Instance, r *http.Request) error {
 	return nil
 }
 
+// remoteIP returns the IP address that the request was received from.
+func remoteIP(r *http.Request) (net.IP, error) {
+	requestIPString, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing remote host (%s): %w", r.RemoteAddr, err)
+	}
+
+	// presence of scope ID in IPv6 addresses prevents parsing. Remove if present
+	scopeIDIndex := strings.Index(requestIPString, "%")
+	if scopeIDIndex != -1 {
+		requestIPString = requestIPString[0:scopeIDIndex]
+	}
+
+	requestIP := net.ParseIP(requestIPString)
+	if requestIP == nil {
+		return nil, fmt.Errorf("unable to parse remote host (%s)", requestIPString)
+	}
+
+	return requestIP, nil
+}
+
 func CheckExternalAccessTripwire(c *config.Instance) *ExternalAccessError {
 	if !c.HasCredentials() && !c.GetDangerousAllowPublicWithoutAuth() {
 		if remoteIP := c.GetSecurityTripwireAccessedFromPublicInternet(); remoteIP != "" {
@@ -0,0 +1,257 @@
+package session
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
+)
+
+const (
+	PasswordHashArgon2id = "argon2id"
+	PasswordHashBcrypt   = "bcrypt"
+)
+
+var errUnknownPasswordHash = errors.New("unknown password hash format")
+
+// PasswordHasher hashes passwords using a hashing scheme.
+type PasswordHasher interface {
+	// Hash returns the hash of the password.
+	Hash(password string) (string, error)
+	// Handles returns true if the hash uses the scheme of the hasher.
+	Handles(hash string) bool
+	// Verify returns true if the password matches the hash.
+	Verify(hash string, password string) (bool, error)
+	// NeedsRehash returns true if the hash uses a different scheme, or
+	// weaker parameters, than the hasher.
+	NeedsRehash(hash string) bool
+}
+
+// BcryptHasher hashes passwords with bcrypt.
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+func (h BcryptHasher) Handles(hash string) bool {
+	_, err := bcrypt.Cost([]byte(hash))
+	return err == nil
+}
+
+func (h BcryptHasher) Verify(hash string, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func (h BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost < h.Cost
+}
+
+// Argon2idHasher hashes passwords with argon2id. Hashes are encoded in the
+// PHC string format, with the parameters used to create them.
+type Argon2idHasher struct {
+	// Memory is the memory used in KiB.
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  int
+	KeyLength   uint32
+}
+
+type argon2idHash struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+const argon2idPrefix = "$argon2id$"
+
+func parseArgon2idHash(hash string) (*argon2idHash, error) {
+	// $argon2id$v=19$m=65536,t=1,p=4$<salt>$<key>
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != PasswordHashArgon2id {
+		return nil, errUnknownPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, fmt.Errorf("unsupported argon2id version %q", parts[2])
+	}
+
+	ret := &argon2idHash{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &ret.memory, &ret.iterations, &ret.parallelism); err != nil {
+		return nil, fmt.Errorf("invalid argon2id parameters %q: %w", parts[3], err)
+	}
+
+	var err error
+	if ret.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	if ret.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, fmt.Errorf("invalid argon2id key: %w", err)
+	}
+
+	return ret, nil
+}
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h Argon2idHasher) Handles(hash string) bool {
+	return strings.HasPrefix(hash, argon2idPrefix)
+}
+
+func (h Argon2idHasher) Verify(hash string, password string) (bool, error) {
+	parsed, err := parseArgon2idHash(hash)
+	if err != nil {
+		return false, err
+	}
+
+	// use the parameters of the hash, which may differ from the hasher
+	key := argon2.IDKey([]byte(password), parsed.salt, parsed.iterations, parsed.memory, parsed.parallelism, uint32(len(parsed.key)))
+
+	return subtle.ConstantTimeCompare(key, parsed.key) == 1, nil
+}
+
+func (h Argon2idHasher) NeedsRehash(hash string) bool {
+	parsed, err := parseArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+
+	return parsed.memory < h.Memory || parsed.iterations < h.Iterations || len(parsed.key) < int(h.KeyLength)
+}
+
+// passwordHashers are the hashers of the supported schemes, which are used
+// to verify existing hashes.
+var passwordHashers = []PasswordHasher{
+	Argon2idHasher{},
+	BcryptHasher{},
+}
+
+// NewPasswordHasher returns the hasher of the configured scheme and cost.
+func NewPasswordHasher(c *config.Instance) PasswordHasher {
+	algorithm := c.GetPasswordHashAlgorithm()
+	switch algorithm {
+	case PasswordHashBcrypt:
+		return BcryptHasher{
+			Cost: c.GetPasswordHashBcryptCost(),
+		}
+	case PasswordHashArgon2id:
+	default:
+		logger.Warnf("unknown password hash algorithm %q, using %s", algorithm, PasswordHashArgon2id)
+	}
+
+	return Argon2idHasher{
+		Memory:      uint32(c.GetPasswordHashArgon2Memory()),
+		Iterations:  uint32(c.GetPasswordHashArgon2Iterations()),
+		Parallelism: 4,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// VerifyPassword returns true if the password matches the hash, which may
+// use any supported scheme.
+func VerifyPassword(hash string, password string) (bool, error) {
+	for _, h := range passwordHashers {
+		if h.Handles(hash) {
+			return h.Verify(hash, password)
+		}
+	}
+
+	return false, errUnknownPasswordHash
+}
+
+// SetPassword sets the password of the configured user, hashing it with the
+// configured scheme. The password is removed if it is empty.
+func SetPassword(c *config.Instance, password string) error {
+	// if blank, don't bother hashing; we want it to be blank
+	if password == "" {
+		c.Set(config.Password, "")
+		return nil
+	}
+
+	hash, err := NewPasswordHasher(c).Hash(password)
+	if err != nil {
+		return fmt.Errorf("hashing password: %w", err)
+	}
+
+	c.Set(config.Password, hash)
+	return nil
+}
+
+// ValidateCredentials returns true if the username and password match the
+// configured credentials, or if no credentials are configured. If the
+// stored hash uses an older scheme or weaker parameters than configured,
+// the password is hashed again and the configuration is written.
+func ValidateCredentials(c *config.Instance, username string, password string) bool {
+	if !c.HasCredentials() {
+		// don't need to authenticate if no credentials saved
+		return true
+	}
+
+	authUser, authPWHash := c.GetCredentials()
+
+	valid, err := VerifyPassword(authPWHash, password)
+	if err != nil {
+		logger.Errorf("error verifying password: %v", err)
+		return false
+	}
+
+	if username != authUser || !valid {
+		return false
+	}
+
+	if NewPasswordHasher(c).NeedsRehash(authPWHash) {
+		upgradePasswordHash(c, password)
+	}
+
+	return true
+}
+
+func upgradePasswordHash(c *config.Instance, password string) {
+	if err := SetPassword(c, password); err != nil {
+		logger.Warnf("could not upgrade password hash: %v", err)
+		return
+	}
+
+	if err := c.Write(); err != nil {
+		logger.Warnf("could not write upgraded password hash: %v", err)
+		return
+	}
+
+	logger.Info("Upgraded password hash to the configured algorithm")
+}
@@ -0,0 +1,166 @@
+package session
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+)
+
+const testPassword = "password"
+
+func testHashers() []PasswordHasher {
+	return []PasswordHasher{
+		BcryptHasher{Cost: bcrypt.MinCost},
+		Argon2idHasher{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32},
+	}
+}
+
+func TestPasswordHasherVerify(t *testing.T) {
+	for _, h := range testHashers() {
+		hash, err := h.Hash(testPassword)
+		if err != nil {
+			t.Fatalf("%T.Hash() error = %v", h, err)
+		}
+
+		if !h.Handles(hash) {
+			t.Errorf("%T.Handles(%q) = false", h, hash)
+		}
+
+		for password, want := range map[string]bool{
+			testPassword: true,
+			"wrong":      false,
+			"":           false,
+		} {
+			got, err := VerifyPassword(hash, password)
+			if err != nil {
+				t.Errorf("VerifyPassword(%q, %q) error = %v", hash, password, err)
+			}
+			if got != want {
+				t.Errorf("VerifyPassword(%q, %q) = %v, want %v", hash, password, got, want)
+			}
+		}
+
+		if h.NeedsRehash(hash) {
+			t.Errorf("%T.NeedsRehash(%q) = true for own hash", h, hash)
+		}
+	}
+}
+
+func TestPasswordHasherNeedsRehash(t *testing.T) {
+	bcryptHash, _ := BcryptHasher{Cost: bcrypt.MinCost}.Hash(testPassword)
+	weakArgon2Hash, _ := Argon2idHasher{Memory: 512, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}.Hash(testPassword)
+
+	tests := []struct {
+		name   string
+		hasher PasswordHasher
+		hash   string
+		want   bool
+	}{
+		{"bcrypt higher cost", BcryptHasher{Cost: bcrypt.MinCost + 1}, bcryptHash, true},
+		{"bcrypt same cost", BcryptHasher{Cost: bcrypt.MinCost}, bcryptHash, false},
+		{"bcrypt to argon2id", Argon2idHasher{Memory: 1024, Iterations: 1, KeyLength: 32}, bcryptHash, true},
+		{"argon2id more memory", Argon2idHasher{Memory: 1024, Iterations: 1, KeyLength: 32}, weakArgon2Hash, true},
+		{"argon2id more iterations", Argon2idHasher{Memory: 512, Iterations: 2, KeyLength: 32}, weakArgon2Hash, true},
+		{"argon2id same parameters", Argon2idHasher{Memory: 512, Iterations: 1, KeyLength: 32}, weakArgon2Hash, false},
+		{"argon2id to bcrypt", BcryptHasher{Cost: bcrypt.MinCost}, weakArgon2Hash, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hasher.NeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("NeedsRehash() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyPasswordInvalidHash(t *testing.T) {
+	for _, hash := range []string{
+		"",
+		testPassword,
+		"$argon2id$v=19$m=1024,t=1,p=1$invalid",
+		"$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5",
+	} {
+		if ok, err := VerifyPassword(hash, testPassword); ok || err == nil {
+			t.Errorf("VerifyPassword(%q) = %v, %v; want false and an error", hash, ok, err)
+		}
+	}
+}
+
+func TestValidateCredentialsUpgrade(t *testing.T) {
+	c := config.GetInstance()
+	_ = c.SetInitialMemoryConfig()
+
+	c.Set(config.PasswordHashAlgorithm, PasswordHashArgon2id)
+	c.Set(config.PasswordHashArgon2Memory, 1024)
+	c.Set(config.Username, "user")
+	defer func() {
+		c.Set(config.PasswordHashAlgorithm, "")
+		c.Set(config.PasswordHashArgon2Memory, 0)
+		c.Set(config.Username, "")
+		c.Set(config.Password, "")
+	}()
+
+	// a legacy bcrypt hash
+	legacyHash, _ := BcryptHasher{Cost: bcrypt.MinCost}.Hash(testPassword)
+	c.Set(config.Password, legacyHash)
+
+	if ValidateCredentials(c, "user", "wrong") {
+		t.Error("ValidateCredentials() = true for wrong password")
+	}
+	if c.GetPasswordHash() != legacyHash {
+		t.Error("hash was upgraded after failed login")
+	}
+
+	if ValidateCredentials(c, "other", testPassword) {
+		t.Error("ValidateCredentials() = true for wrong username")
+	}
+
+	if !ValidateCredentials(c, "user", testPassword) {
+		t.Fatal("ValidateCredentials() = false for legacy hash")
+	}
+
+	upgraded := c.GetPasswordHash()
+	if !strings.HasPrefix(upgraded, argon2idPrefix) {
+		t.Fatalf("hash was not upgraded to argon2id: %q", upgraded)
+	}
+
+	// the upgraded hash is used for subsequent logins, and is not upgraded again
+	if !ValidateCredentials(c, "user", testPassword) {
+		t.Error("ValidateCredentials() = false for upgraded hash")
+	}
+	if c.GetPasswordHash() != upgraded {
+		t.Error("upgraded hash was hashed again")
+	}
+}
+
+func TestSetPassword(t *testing.T) {
+	c := config.GetInstance()
+	_ = c.SetInitialMemoryConfig()
+
+	c.Set(config.PasswordHashAlgorithm, PasswordHashBcrypt)
+	c.Set(config.PasswordHashBcryptCost, bcrypt.MinCost)
+	defer func() {
+		c.Set(config.PasswordHashAlgorithm, "")
+		c.Set(config.Password, "")
+	}()
+
+	if err := SetPassword(c, testPassword); err != nil {
+		t.Fatalf("SetPassword() error = %v", err)
+	}
+
+	hash := c.GetPasswordHash()
+	if !(BcryptHasher{}).Handles(hash) {
+		t.Errorf("SetPassword() hash %q is not bcrypt", hash)
+	}
+
+	if err := SetPassword(c, ""); err != nil {
+		t.Fatalf("SetPassword() error = %v", err)
+	}
+	if c.GetPasswordHash() != "" {
+		t.Error("SetPassword(\"\") did not remove the password")
+	}
+}
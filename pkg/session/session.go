@@ -4,9 +4,11 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/securecookie"
 	"github.com/gorilla/sessions"
+	"github.com/stashapp/stash/pkg/audit"
 	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/manager/config"
 	"github.com/stashapp/stash/pkg/utils"
@@ -17,11 +19,19 @@ type key int
 const (
 	contextUser key = iota
 	contextVisitedPlugins
+	contextRemoteAddr
 )
 
 const (
 	userIDKey         = "userID"
 	visitedPluginsKey = "visitedPlugins"
+	// lastActiveKey is the server time, in unix seconds, of the last
+	// request of the session
+	lastActiveKey = "lastActive"
+	// trustedHeaderKey is set in sessions established by the trusted
+	// authentication header. These sessions only authenticate requests that
+	// carry the header.
+	trustedHeaderKey = "trustedHeader"
 )
 
 const (
@@ -33,6 +43,7 @@ const (
 	cookieName      = "session"
 	usernameFormKey = "username"
 	passwordFormKey = "password"
+	codeFormKey     = "code"
 )
 
 var ErrInvalidCredentials = errors.New("invalid username or password")
@@ -41,20 +52,35 @@ var ErrUnauthorized = errors.New("unauthorized")
 type Store struct {
 	sessionStore *sessions.CookieStore
 	config       *config.Instance
+	auditLog     *audit.Log
+
+	// now returns the server time, against which sessions expire
+	now func() time.Time
 }
 
-func NewStore(c *config.Instance) *Store {
+// NewStore returns a session store. Logins and logouts are recorded in
+// auditLog, which may be nil.
+func NewStore(c *config.Instance, auditLog *audit.Log) *Store {
 	ret := &Store{
 		sessionStore: sessions.NewCookieStore(config.GetInstance().GetSessionStoreKey()),
 		config:       c,
+		auditLog:     auditLog,
+		now:          time.Now,
 	}
 
-	ret.sessionStore.MaxAge(config.GetInstance().GetMaxSessionAge())
+	// the cookie is kept for the clock skew past the maximum age, so that
+	// expiry is decided by sessionExpired
+	ret.sessionStore.MaxAge(c.GetMaxSessionAge() + int(c.GetSessionClockSkew()/time.Second))
 
 	return ret
 }
 
-func (s *Store) Login(w http.ResponseWriter, r *http.Request) error {
+func (s *Store) Login(w http.ResponseWriter, r *http.Request) (err error) {
+	start := time.Now()
+	defer func() {
+		s.delayAuthentication(r, start, err == nil)
+	}()
+
 	// ignore error - we want a new session regardless
 	newSession, _ := s.sessionStore.Get(r, cookieName)
 
@@ -62,26 +88,78 @@ func (s *Store) Login(w http.ResponseWriter, r *http.Request) error {
 	password := r.FormValue(passwordFormKey)
 
 	// authenticate the user
-	if !config.GetInstance().ValidateCredentials(username, password) {
+	if !ValidateCredentials(config.GetInstance(), username, password) {
+		s.auditLogin(r, username, false, "invalid username or password")
+		return ErrInvalidCredentials
+	}
+
+	// the same error is returned for an invalid code, so that the
+	// validity of the password is not revealed
+	if !ValidateSecondFactor(config.GetInstance(), r.FormValue(codeFormKey)) {
+		s.auditLogin(r, username, false, "invalid authentication code")
 		return ErrInvalidCredentials
 	}
 
 	newSession.Values[userIDKey] = username
+	newSession.Values[lastActiveKey] = s.now().Unix()
+	delete(newSession.Values, trustedHeaderKey)
 
-	err := newSession.Save(r, w)
+	err = newSession.Save(r, w)
 	if err != nil {
 		return err
 	}
 
+	s.auditLogin(r, username, true, "")
+
 	return nil
 }
 
+// delayAuthentication waits until the login delay has passed since start.
+// Failed authentications are padded to the same minimum duration, so that
+// the time taken does not reveal whether the username was valid.
+func (s *Store) delayAuthentication(r *http.Request, start time.Time, success bool) {
+	if success && !s.config.GetLoginDelaySuccess() {
+		return
+	}
+
+	remaining := s.config.GetLoginDelay() - time.Since(start)
+	if remaining <= 0 {
+		return
+	}
+
+	t := time.NewTimer(remaining)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-r.Context().Done():
+	}
+}
+
+func (s *Store) auditLogin(r *http.Request, username string, success bool, reason string) {
+	e := audit.Entry{
+		Type:       audit.EventLogin,
+		User:       username,
+		RemoteAddr: r.RemoteAddr,
+		Message:    "login succeeded",
+	}
+
+	if !success {
+		e.Type = audit.EventLoginFailed
+		e.Message = "login failed: " + reason
+	}
+
+	s.auditLog.Record(e)
+}
+
 func (s *Store) Logout(w http.ResponseWriter, r *http.Request) error {
 	session, err := s.sessionStore.Get(r, cookieName)
 	if err != nil {
 		return err
 	}
 
+	userID, _ := session.Values[userIDKey].(string)
+
 	delete(session.Values, userIDKey)
 	session.Options.MaxAge = -1
 
@@ -90,6 +168,15 @@ func (s *Store) Logout(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
+	if userID != "" {
+		s.auditLog.Record(audit.Entry{
+			Type:       audit.EventLogout,
+			User:       userID,
+			RemoteAddr: r.RemoteAddr,
+			Message:    "logged out",
+		})
+	}
+
 	return nil
 }
 
@@ -102,9 +189,19 @@ func (s *Store) GetSessionUserID(w http.ResponseWriter, r *http.Request) (string
 	}
 
 	if !session.IsNew {
+		if s.sessionExpired(session) {
+			return "", nil
+		}
+
+		// the proxy no longer asserts the user of the session
+		if trusted, _ := session.Values[trustedHeaderKey].(bool); trusted {
+			return "", nil
+		}
+
 		val := session.Values[userIDKey]
 
 		// refresh the cookie
+		session.Values[lastActiveKey] = s.now().Unix()
 		err = session.Save(r, w)
 		if err != nil {
 			return "", err
@@ -118,6 +215,28 @@ func (s *Store) GetSessionUserID(w http.ResponseWriter, r *http.Request) (string
 	return "", nil
 }
 
+// sessionExpired returns true if the session has not been active within
+// the maximum session age, plus the clock skew. Only the server time, stored
+// in the signed session, is compared, so that the clock of the client does
+// not affect expiry. A last activity in the future, such as after the server
+// clock is set back, does not expire the session.
+func (s *Store) sessionExpired(session *sessions.Session) bool {
+	lastActive, ok := session.Values[lastActiveKey].(int64)
+	if !ok {
+		// sessions created before the last activity was recorded expire
+		// by the age of the cookie
+		return false
+	}
+
+	maxAge := time.Duration(s.config.GetMaxSessionAge()) * time.Second
+	if maxAge <= 0 {
+		return false
+	}
+
+	idle := s.now().Sub(time.Unix(lastActive, 0))
+	return idle > maxAge+s.config.GetSessionClockSkew()
+}
+
 func SetCurrentUserID(ctx context.Context, userID string) context.Context {
 	return context.WithValue(ctx, contextUser, userID)
 }
@@ -133,6 +252,36 @@ func GetCurrentUserID(ctx context.Context) *string {
 	return nil
 }
 
+// SetRemoteAddr sets the address of the client that made the request in the
+// provided context.
+func SetRemoteAddr(ctx context.Context, remoteAddr string) context.Context {
+	return context.WithValue(ctx, contextRemoteAddr, remoteAddr)
+}
+
+// GetRemoteAddr gets the address of the client that made the request from
+// the provided context. Returns an empty string if it is not set.
+func GetRemoteAddr(ctx context.Context) string {
+	remoteAddr, _ := ctx.Value(contextRemoteAddr).(string)
+	return remoteAddr
+}
+
+// NewAuditEntry returns an audit log entry attributed to the current user
+// and client address of the context.
+func NewAuditEntry(ctx context.Context, eventType audit.EventType, message string, data map[string]string) audit.Entry {
+	e := audit.Entry{
+		Type:       eventType,
+		RemoteAddr: GetRemoteAddr(ctx),
+		Message:    message,
+		Data:       data,
+	}
+
+	if userID := GetCurrentUserID(ctx); userID != nil {
+		e.User = *userID
+	}
+
+	return e
+}
+
 func (s *Store) VisitedPluginHandler() func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -195,6 +344,7 @@ func (s *Store) MakePluginCookie(ctx context.Context) *http.Cookie {
 }
 
 func (s *Store) Authenticate(w http.ResponseWriter, r *http.Request) (userID string, err error) {
+	start := time.Now()
 	c := s.config
 
 	// translate api key into current user, if present
@@ -210,10 +360,15 @@ func (s *Store) Authenticate(w http.ResponseWriter, r *http.Request) (userID str
 		// configured username. In future, we'll want to
 		// get the username from the key.
 		if c.GetAPIKey() != apiKey {
+			s.delayAuthentication(r, start, false)
 			return "", ErrUnauthorized
 		}
 
 		userID = c.GetUsername()
+	} else if user, ok := trustedHeaderUser(c, r); ok {
+		// the user was authenticated by a trusted reverse proxy
+		userID = user
+		err = s.loginTrustedHeader(w, r, user)
 	} else {
 		// handle session
 		userID, err = s.GetSessionUserID(w, r)
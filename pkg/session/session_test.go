@@ -0,0 +1,233 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/stashapp/stash/pkg/audit"
+	"github.com/stashapp/stash/pkg/manager/config"
+)
+
+func newLoginRequest(username, password string) *http.Request {
+	form := url.Values{}
+	form.Set(usernameFormKey, username)
+	form.Set(passwordFormKey, password)
+
+	r := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	r.RemoteAddr = "192.168.1.2:1234"
+
+	return r
+}
+
+func TestLoginAudit(t *testing.T) {
+	c := config.GetInstance()
+	_ = c.SetInitialMemoryConfig()
+
+	testHash, _ := testHashers()[0].Hash(testPassword)
+	c.Set(config.Username, "user")
+	c.Set(config.Password, testHash)
+	c.Set(config.SessionStoreKey, "test session store key")
+	c.Set(config.LoginDelay, 0)
+	defer func() {
+		c.Set(config.Username, "")
+		c.Set(config.Password, "")
+		c.Set(config.SessionStoreKey, "")
+		c.Set(config.LoginDelay, nil)
+	}()
+
+	auditLog := audit.NewLog(filepath.Join(t.TempDir(), "audit.log"))
+	store := NewStore(c, auditLog)
+
+	login := func(username, password string) error {
+		return store.Login(httptest.NewRecorder(), newLoginRequest(username, password))
+	}
+
+	if err := login("user", "wrong"); err != ErrInvalidCredentials {
+		t.Fatalf("Login() error = %v, want %v", err, ErrInvalidCredentials)
+	}
+
+	entries, _, err := auditLog.Query(audit.Query{PerPage: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d audit entries after failed login, want 1", len(entries))
+	}
+	if e := entries[0]; e.Type != audit.EventLoginFailed || e.User != "user" || e.RemoteAddr != "192.168.1.2:1234" {
+		t.Errorf("unexpected audit entry for failed login: %+v", e)
+	}
+	if strings.Contains(entries[0].Message, "wrong") {
+		t.Error("audit entry contains the attempted password")
+	}
+
+	if err := login("user", testPassword); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+
+	entries, _, _ = auditLog.Query(audit.Query{Types: []audit.EventType{audit.EventLogin}, PerPage: -1})
+	if len(entries) != 1 || entries[0].User != "user" {
+		t.Errorf("unexpected audit entries for login: %+v", entries)
+	}
+}
+
+func TestLoginDelay(t *testing.T) {
+	const delay = 100 * time.Millisecond
+
+	c := config.GetInstance()
+	_ = c.SetInitialMemoryConfig()
+
+	testHash, _ := testHashers()[0].Hash(testPassword)
+	c.Set(config.Username, "user")
+	c.Set(config.Password, testHash)
+	c.Set(config.SessionStoreKey, "test session store key")
+	c.Set(config.ApiKey, "api key")
+	c.Set(config.LoginDelay, int(delay/time.Millisecond))
+	// prevent the hash from being upgraded to a slower algorithm on login
+	c.Set(config.PasswordHashAlgorithm, PasswordHashBcrypt)
+	c.Set(config.PasswordHashBcryptCost, bcrypt.MinCost)
+	defer func() {
+		c.Set(config.PasswordHashAlgorithm, "")
+		c.Set(config.PasswordHashBcryptCost, 0)
+		c.Set(config.Username, "")
+		c.Set(config.Password, "")
+		c.Set(config.SessionStoreKey, "")
+		c.Set(config.ApiKey, "")
+		c.Set(config.LoginDelay, nil)
+		c.Set(config.LoginDelaySuccess, false)
+	}()
+
+	store := NewStore(c, nil)
+
+	timed := func(f func() error) (time.Duration, error) {
+		start := time.Now()
+		err := f()
+		return time.Since(start), err
+	}
+
+	login := func(username, password string) func() error {
+		return func() error {
+			return store.Login(httptest.NewRecorder(), newLoginRequest(username, password))
+		}
+	}
+
+	for _, tt := range []struct {
+		name     string
+		username string
+	}{
+		{"invalid username", "other"},
+		{"invalid password", "user"},
+	} {
+		elapsed, err := timed(login(tt.username, "wrong"))
+		if err != ErrInvalidCredentials {
+			t.Fatalf("%s: Login() error = %v, want %v", tt.name, err, ErrInvalidCredentials)
+		}
+		if elapsed < delay {
+			t.Errorf("%s: failed login took %v, want at least %v", tt.name, elapsed, delay)
+		}
+	}
+
+	elapsed, err := timed(func() error {
+		r := httptest.NewRequest(http.MethodGet, "/graphql", nil)
+		r.Header.Set(ApiKeyHeader, "wrong")
+		_, err := store.Authenticate(httptest.NewRecorder(), r)
+		return err
+	})
+	if err != ErrUnauthorized {
+		t.Fatalf("Authenticate() error = %v, want %v", err, ErrUnauthorized)
+	}
+	if elapsed < delay {
+		t.Errorf("invalid API key took %v, want at least %v", elapsed, delay)
+	}
+
+	// successful logins are not delayed unless configured
+	elapsed, err = timed(login("user", testPassword))
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if elapsed >= delay {
+		t.Errorf("successful login took %v, want less than %v", elapsed, delay)
+	}
+
+	c.Set(config.LoginDelaySuccess, true)
+	elapsed, err = timed(login("user", testPassword))
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if elapsed < delay {
+		t.Errorf("successful login took %v, want at least %v", elapsed, delay)
+	}
+}
+
+func TestSessionExpiryClockSkew(t *testing.T) {
+	const (
+		maxAge = time.Hour
+		skew   = time.Minute
+	)
+
+	c := config.GetInstance()
+	_ = c.SetInitialMemoryConfig()
+
+	testHash, _ := testHashers()[0].Hash(testPassword)
+	c.Set(config.Username, "user")
+	c.Set(config.Password, testHash)
+	c.Set(config.SessionStoreKey, "test session store key")
+	c.Set(config.LoginDelay, 0)
+	c.Set(config.MaxSessionAge, int(maxAge/time.Second))
+	c.Set(config.SessionClockSkew, int(skew/time.Second))
+	defer func() {
+		c.Set(config.Username, "")
+		c.Set(config.Password, "")
+		c.Set(config.SessionStoreKey, "")
+		c.Set(config.LoginDelay, nil)
+		c.Set(config.MaxSessionAge, nil)
+		c.Set(config.SessionClockSkew, nil)
+	}()
+
+	loginTime := time.Date(2022, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		elapsed time.Duration
+		want    string
+	}{
+		{"active", maxAge / 2, "user"},
+		{"past max age within skew", maxAge + skew/2, "user"},
+		{"past max age and skew", maxAge + 2*skew, ""},
+		{"server clock set back", -time.Hour, "user"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewStore(c, nil)
+			store.now = func() time.Time { return loginTime }
+
+			w := httptest.NewRecorder()
+			if err := store.Login(w, newLoginRequest("user", testPassword)); err != nil {
+				t.Fatalf("Login() error = %v", err)
+			}
+
+			store.now = func() time.Time { return loginTime.Add(tt.elapsed) }
+
+			r := httptest.NewRequest(http.MethodGet, "/graphql", nil)
+			for _, cookie := range w.Result().Cookies() {
+				r.AddCookie(cookie)
+			}
+
+			got, err := store.GetSessionUserID(httptest.NewRecorder(), r)
+			if err != nil {
+				t.Fatalf("GetSessionUserID() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("GetSessionUserID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
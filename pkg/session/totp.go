@@ -0,0 +1,261 @@
+package session
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
+)
+
+const (
+	totpIssuer = "Stash"
+	totpDigits = 6
+	totpPeriod = 30 * time.Second
+	// totpSkew is the number of periods before and after the current period
+	// in which codes are accepted, to allow for clock differences.
+	totpSkew = 1
+
+	totpSecretLength = 20
+
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// recoveryCodeMutex prevents concurrent logins from using the same recovery
+// code.
+var recoveryCodeMutex sync.Mutex
+
+// totpMutex prevents concurrent logins from using the same TOTP code.
+var totpMutex sync.Mutex
+
+// GenerateTOTPSecret returns a random base32 encoded TOTP secret.
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, totpSecretLength)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPURI returns the otpauth URI of the secret, which authenticator apps
+// read from a QR code.
+func TOTPURI(secret string, accountName string) string {
+	label := url.PathEscape(totpIssuer + ":" + accountName)
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", totpIssuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(int(totpPeriod.Seconds())))
+
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	return totpEncoding.DecodeString(strings.TrimRight(secret, "="))
+}
+
+// totpCode returns the code of the counter, as specified by RFC 4226.
+func totpCode(secret []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", totpDigits, value%mod)
+}
+
+// ValidateTOTP returns true if the code is valid for the secret at time t,
+// or at the periods before and after t.
+func ValidateTOTP(secret string, code string, t time.Time) (bool, error) {
+	_, valid, err := matchTOTP(secret, code, t)
+	return valid, err
+}
+
+// matchTOTP returns the time-step of the code if it is valid for the secret
+// at time t, or at the periods before and after t.
+func matchTOTP(secret string, code string, t time.Time) (step int64, valid bool, err error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid TOTP secret: %w", err)
+	}
+
+	code = strings.ReplaceAll(code, " ", "")
+	if len(code) != totpDigits {
+		return 0, false, nil
+	}
+
+	counter := t.Unix() / int64(totpPeriod.Seconds())
+	for i := -totpSkew; i <= totpSkew; i++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, uint64(counter+int64(i)))), []byte(code)) == 1 {
+			step = counter + int64(i)
+			valid = true
+		}
+	}
+
+	return step, valid, nil
+}
+
+// GenerateRecoveryCodes returns new recovery codes, and the hashes of the
+// codes to store in the configuration.
+func GenerateRecoveryCodes() (codes []string, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, recoveryCodeLength)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+
+		code := strings.ToLower(totpEncoding.EncodeToString(b))[:recoveryCodeLength]
+		code = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode returns the hash of a recovery code. Recovery codes are
+// random, so a fast hash is sufficient.
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// EnableTOTP verifies that the code is valid for the secret, then enables
+// two-factor authentication with the secret. Returns the recovery codes,
+// which replace any existing recovery codes. The configuration is not
+// written.
+func EnableTOTP(c *config.Instance, secret string, code string) ([]string, error) {
+	step, valid, err := matchTOTP(secret, code, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if !valid {
+		return nil, ErrInvalidCredentials
+	}
+
+	codes, hashes, err := GenerateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	c.Set(config.TOTPSecret, secret)
+	c.Set(config.TOTPRecoveryCodes, hashes)
+	c.Set(config.TOTPLastTimeStep, step)
+	c.Set(config.TOTPEnabled, true)
+
+	return codes, nil
+}
+
+// DisableTOTP disables two-factor authentication, removing the secret and
+// recovery codes. The configuration is not written.
+func DisableTOTP(c *config.Instance) {
+	c.Set(config.TOTPEnabled, false)
+	c.Set(config.TOTPSecret, "")
+	c.Set(config.TOTPRecoveryCodes, []string{})
+	c.Set(config.TOTPLastTimeStep, 0)
+}
+
+// ValidateSecondFactor returns true if two-factor authentication is not
+// enabled, or if code is a valid TOTP code or an unused recovery code. TOTP
+// codes are rejected if a code of the same or a later time-step has been
+// accepted. The time-step of an accepted TOTP code is stored, and a used
+// recovery code is removed, and the configuration is written.
+func ValidateSecondFactor(c *config.Instance, code string) bool {
+	if !c.IsTOTPEnabled() {
+		return true
+	}
+
+	if code == "" {
+		return false
+	}
+
+	if useTOTP(c, code) {
+		return true
+	}
+
+	return useRecoveryCode(c, code)
+}
+
+func useTOTP(c *config.Instance, code string) bool {
+	totpMutex.Lock()
+	defer totpMutex.Unlock()
+
+	step, valid, err := matchTOTP(c.GetTOTPSecret(), code, time.Now())
+	if err != nil {
+		logger.Errorf("error validating TOTP code: %v", err)
+	}
+	if !valid {
+		return false
+	}
+
+	if step <= c.GetTOTPLastTimeStep() {
+		logger.Warn("Rejected reused TOTP code")
+		return false
+	}
+
+	c.Set(config.TOTPLastTimeStep, step)
+	if err := c.Write(); err != nil {
+		// the time-step is stored in memory, so the code cannot be reused
+		// until stash is restarted
+		logger.Warnf("could not write configuration after using TOTP code: %v", err)
+	}
+
+	return true
+}
+
+func useRecoveryCode(c *config.Instance, code string) bool {
+	recoveryCodeMutex.Lock()
+	defer recoveryCodeMutex.Unlock()
+
+	hash := hashRecoveryCode(code)
+
+	hashes := c.GetTOTPRecoveryCodes()
+	for i, h := range hashes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) != 1 {
+			continue
+		}
+
+		remaining := append(hashes[:i:i], hashes[i+1:]...)
+		c.Set(config.TOTPRecoveryCodes, remaining)
+		if err := c.Write(); err != nil {
+			// the code is removed from memory, so it cannot be reused until
+			// stash is restarted
+			logger.Warnf("could not write configuration after using recovery code: %v", err)
+		}
+
+		logger.Infof("Recovery code used for login. %d recovery codes remaining", len(remaining))
+		return true
+	}
+
+	return false
+}
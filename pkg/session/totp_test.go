@@ -0,0 +1,177 @@
+package session
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/manager/config"
+)
+
+// the secret of the RFC 6238 test vectors
+var rfcSecret = totpEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode(t *testing.T) {
+	key, _ := decodeTOTPSecret(rfcSecret)
+
+	// the last 6 digits of the RFC 6238 SHA1 test vectors
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+
+	for _, tt := range tests {
+		counter := uint64(tt.unix / int64(totpPeriod.Seconds()))
+		if got := totpCode(key, counter); got != tt.want {
+			t.Errorf("totpCode(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	tests := []struct {
+		name string
+		code string
+		at   time.Time
+		want bool
+	}{
+		{"current", "050471", now, true},
+		{"with space", "050 471", now, true},
+		{"wrong code", "123456", now, false},
+		{"empty", "", now, false},
+		{"too long", "0504710", now, false},
+		{"previous period", "050471", now.Add(totpPeriod), true},
+		{"next period", "050471", now.Add(-totpPeriod), true},
+		{"outside window", "050471", now.Add(2 * totpPeriod), false},
+		{"outside window before", "050471", now.Add(-2 * totpPeriod), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidateTOTP(rfcSecret, tt.code, tt.at)
+			if err != nil {
+				t.Fatalf("ValidateTOTP() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("ValidateTOTP(%q) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ValidateTOTP("not base32!", "050471", now); err == nil {
+		t.Error("ValidateTOTP() expected error for invalid secret")
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		t.Fatalf("decoding generated secret: %v", err)
+	}
+	if len(key) != totpSecretLength {
+		t.Errorf("secret length = %d, want %d", len(key), totpSecretLength)
+	}
+
+	uri, err := url.Parse(TOTPURI(secret, "user name"))
+	if err != nil {
+		t.Fatalf("parsing URI: %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/Stash:user name" {
+		t.Errorf("unexpected URI %s", uri)
+	}
+	if uri.Query().Get("secret") != secret {
+		t.Errorf("URI secret = %s, want %s", uri.Query().Get("secret"), secret)
+	}
+}
+
+func TestValidateSecondFactor(t *testing.T) {
+	c := config.GetInstance()
+	_ = c.SetInitialMemoryConfig()
+
+	testHash, _ := testHashers()[0].Hash(testPassword)
+	c.Set(config.Username, "user")
+	c.Set(config.Password, testHash)
+	defer func() {
+		DisableTOTP(c)
+		c.Set(config.Username, "")
+		c.Set(config.Password, "")
+	}()
+
+	if !ValidateSecondFactor(c, "") {
+		t.Error("ValidateSecondFactor() = false with TOTP disabled")
+	}
+
+	key, _ := decodeTOTPSecret(rfcSecret)
+	currentCode := func() string {
+		return totpCode(key, uint64(time.Now().Unix()/int64(totpPeriod.Seconds())))
+	}
+
+	if _, err := EnableTOTP(c, rfcSecret, "000000x"); err == nil {
+		t.Fatal("EnableTOTP() expected error for invalid code")
+	}
+	if c.IsTOTPEnabled() {
+		t.Fatal("TOTP enabled after invalid code")
+	}
+
+	enableCode := currentCode()
+	recoveryCodes, err := EnableTOTP(c, rfcSecret, enableCode)
+	if err != nil {
+		t.Fatalf("EnableTOTP() error = %v", err)
+	}
+	if len(recoveryCodes) != recoveryCodeCount {
+		t.Fatalf("EnableTOTP() returned %d recovery codes, want %d", len(recoveryCodes), recoveryCodeCount)
+	}
+
+	if ValidateSecondFactor(c, "") {
+		t.Error("ValidateSecondFactor() = true for missing code")
+	}
+	if ValidateSecondFactor(c, "abcdef") {
+		t.Error("ValidateSecondFactor() = true for invalid code")
+	}
+	// the code used to enable TOTP cannot be used to log in
+	if ValidateSecondFactor(c, enableCode) {
+		t.Error("ValidateSecondFactor() = true for code used to enable TOTP")
+	}
+
+	nextCode := totpCode(key, uint64(c.GetTOTPLastTimeStep()+1))
+	if !ValidateSecondFactor(c, nextCode) {
+		t.Error("ValidateSecondFactor() = false for next code")
+	}
+
+	// codes cannot be replayed, and earlier codes are rejected
+	if ValidateSecondFactor(c, nextCode) {
+		t.Error("ValidateSecondFactor() = true for reused code")
+	}
+	if ValidateSecondFactor(c, enableCode) {
+		t.Error("ValidateSecondFactor() = true for code before the last accepted code")
+	}
+
+	// recovery codes are single use, and accepted without the separator
+	recoveryCode := recoveryCodes[0]
+	if !ValidateSecondFactor(c, strings.ToUpper(strings.ReplaceAll(recoveryCode, "-", ""))) {
+		t.Error("ValidateSecondFactor() = false for recovery code")
+	}
+	if ValidateSecondFactor(c, recoveryCode) {
+		t.Error("ValidateSecondFactor() = true for used recovery code")
+	}
+	if got := len(c.GetTOTPRecoveryCodes()); got != recoveryCodeCount-1 {
+		t.Errorf("%d recovery codes remaining, want %d", got, recoveryCodeCount-1)
+	}
+	if !ValidateSecondFactor(c, recoveryCodes[1]) {
+		t.Error("ValidateSecondFactor() = false for unused recovery code")
+	}
+}
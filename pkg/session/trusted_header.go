@@ -0,0 +1,102 @@
+package session
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/audit"
+	"github.com/stashapp/stash/pkg/logger"
+	"github.com/stashapp/stash/pkg/manager/config"
+)
+
+// trustedHeaderUser returns the user asserted by the trusted authentication
+// header of the request. The header is only honoured if the request was
+// received directly from one of the trusted proxies. Returns false if
+// trusted header authentication is disabled, or the header is missing or
+// not trusted.
+func trustedHeaderUser(c *config.Instance, r *http.Request) (string, bool) {
+	header := c.GetTrustedHeaderAuth()
+	if header == "" {
+		return "", false
+	}
+
+	user := strings.TrimSpace(r.Header.Get(header))
+	if user == "" {
+		return "", false
+	}
+
+	ip, err := remoteIP(r)
+	if err != nil || !isTrustedProxy(c.GetTrustedProxies(), ip) {
+		logger.Debugf("ignoring %s header from untrusted address %s", header, r.RemoteAddr)
+		return "", false
+	}
+
+	return user, true
+}
+
+// isTrustedProxy returns true if ip matches one of the IP addresses or CIDR
+// ranges in proxies. Invalid entries are ignored.
+func isTrustedProxy(proxies []string, ip net.IP) bool {
+	for _, p := range proxies {
+		p = strings.TrimSpace(p)
+
+		if strings.Contains(p, "/") {
+			_, network, err := net.ParseCIDR(p)
+			if err != nil {
+				logger.Warnf("invalid trusted proxy range %q: %v", p, err)
+				continue
+			}
+
+			if network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		proxyIP := net.ParseIP(p)
+		if proxyIP == nil {
+			logger.Warnf("invalid trusted proxy address %q", p)
+			continue
+		}
+
+		if proxyIP.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// loginTrustedHeader establishes a session for the user asserted by a
+// trusted proxy. A login is recorded when the session changes user. The
+// session does not authenticate requests without the header, so that access
+// ends as soon as the proxy stops asserting the user.
+func (s *Store) loginTrustedHeader(w http.ResponseWriter, r *http.Request, userID string) error {
+	// ignore error - an invalid or expired session is replaced
+	session, _ := s.sessionStore.Get(r, cookieName)
+
+	previous, _ := session.Values[userIDKey].(string)
+	if s.sessionExpired(session) {
+		previous = ""
+	}
+
+	session.Values[userIDKey] = userID
+	session.Values[lastActiveKey] = s.now().Unix()
+	session.Values[trustedHeaderKey] = true
+
+	if err := session.Save(r, w); err != nil {
+		return err
+	}
+
+	if previous != userID {
+		s.auditLog.Record(audit.Entry{
+			Type:       audit.EventLogin,
+			User:       userID,
+			RemoteAddr: r.RemoteAddr,
+			Message:    "login succeeded by trusted proxy header",
+		})
+	}
+
+	return nil
+}
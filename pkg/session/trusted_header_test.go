@@ -0,0 +1,164 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stashapp/stash/pkg/audit"
+	"github.com/stashapp/stash/pkg/manager/config"
+)
+
+const testTrustedHeader = "X-Remote-User"
+
+func setTrustedHeaderConfig(t *testing.T, c *config.Instance, proxies []string) {
+	t.Helper()
+
+	c.Set(config.SessionStoreKey, "test session store key")
+	c.Set(config.TrustedHeaderAuth, testTrustedHeader)
+	c.Set(config.TrustedProxies, proxies)
+	t.Cleanup(func() {
+		c.Set(config.SessionStoreKey, "")
+		c.Set(config.TrustedHeaderAuth, "")
+		c.Set(config.TrustedProxies, nil)
+	})
+}
+
+func newTrustedHeaderRequest(remoteAddr string, user string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.RemoteAddr = remoteAddr
+	if user != "" {
+		r.Header.Set(testTrustedHeader, user)
+	}
+	return r
+}
+
+func TestTrustedHeaderUser(t *testing.T) {
+	c := config.GetInstance()
+	_ = c.SetInitialMemoryConfig()
+	setTrustedHeaderConfig(t, c, []string{"10.0.0.1", "172.16.0.0/12", "fd00::1", "invalid"})
+
+	tests := []struct {
+		name         string
+		remoteAddr   string
+		user         string
+		forwardedFor string
+		want         string
+		wantHonoured bool
+	}{
+		{"trusted address", "10.0.0.1:1234", "user", "", "user", true},
+		{"trusted range", "172.20.1.2:1234", "user", "", "user", true},
+		{"trusted ipv6 address", "[fd00::1]:1234", "user", "", "user", true},
+		{"untrusted address", "10.0.0.2:1234", "user", "", "", false},
+		{"untrusted public address", "203.0.113.1:1234", "user", "", "", false},
+		{"forwarded for trusted address", "203.0.113.1:1234", "user", "10.0.0.1", "", false},
+		{"missing header", "10.0.0.1:1234", "", "", "", false},
+		{"invalid remote address", "invalid", "user", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTrustedHeaderRequest(tt.remoteAddr, tt.user)
+			if tt.forwardedFor != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+
+			got, honoured := trustedHeaderUser(c, r)
+			if got != tt.want || honoured != tt.wantHonoured {
+				t.Errorf("trustedHeaderUser() = %q, %v, want %q, %v", got, honoured, tt.want, tt.wantHonoured)
+			}
+		})
+	}
+}
+
+func TestTrustedHeaderUserDisabled(t *testing.T) {
+	c := config.GetInstance()
+	_ = c.SetInitialMemoryConfig()
+	setTrustedHeaderConfig(t, c, []string{"10.0.0.1"})
+	c.Set(config.TrustedHeaderAuth, "")
+
+	if user, honoured := trustedHeaderUser(c, newTrustedHeaderRequest("10.0.0.1:1234", "user")); honoured {
+		t.Errorf("trustedHeaderUser() = %q, want header ignored when disabled", user)
+	}
+}
+
+func TestAuthenticateTrustedHeader(t *testing.T) {
+	c := config.GetInstance()
+	_ = c.SetInitialMemoryConfig()
+	setTrustedHeaderConfig(t, c, []string{"10.0.0.1"})
+
+	auditLog := audit.NewLog(filepath.Join(t.TempDir(), "audit.log"))
+	store := NewStore(c, auditLog)
+
+	// the header is ignored from an untrusted address
+	w := httptest.NewRecorder()
+	userID, err := store.Authenticate(w, newTrustedHeaderRequest("10.0.0.2:1234", "user"))
+	if err != nil || userID != "" {
+		t.Fatalf("Authenticate() from untrusted address = %q, %v, want no user", userID, err)
+	}
+	if cookies := w.Result().Cookies(); len(cookies) != 0 {
+		t.Errorf("session established for untrusted address: %v", cookies)
+	}
+
+	// the header is honoured from a trusted proxy, establishing a session
+	w = httptest.NewRecorder()
+	userID, err = store.Authenticate(w, newTrustedHeaderRequest("10.0.0.1:1234", "user"))
+	if err != nil || userID != "user" {
+		t.Fatalf("Authenticate() from trusted proxy = %q, %v, want %q", userID, err, "user")
+	}
+
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("got %d cookies, want session cookie", len(cookies))
+	}
+
+	// the session does not authenticate later requests without the header
+	r := newTrustedHeaderRequest("10.0.0.1:1234", "")
+	r.AddCookie(cookies[0])
+	userID, err = store.Authenticate(httptest.NewRecorder(), r)
+	if err != nil || userID != "" {
+		t.Fatalf("Authenticate() with session and no header = %q, %v, want no user", userID, err)
+	}
+
+	// the same user asserted again is not a new login
+	r = newTrustedHeaderRequest("10.0.0.1:1234", "user")
+	r.AddCookie(cookies[0])
+	if _, err := store.Authenticate(httptest.NewRecorder(), r); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _, err := auditLog.Query(audit.Query{Types: []audit.EventType{audit.EventLogin}, PerPage: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].User != "user" || entries[0].RemoteAddr != "10.0.0.1:1234" {
+		t.Errorf("unexpected audit entries for trusted header login: %+v", entries)
+	}
+}
+
+func TestCheckAllowPublicWithoutAuthTrustedHeader(t *testing.T) {
+	c := config.GetInstance()
+	_ = c.SetInitialMemoryConfig()
+	setTrustedHeaderConfig(t, c, []string{"193.168.1.1"})
+
+	// a public client authenticated by the trusted proxy is allowed
+	r := newTrustedHeaderRequest("193.168.1.1:8080", "user")
+	r.Header.Set("X-FORWARDED-FOR", "193.168.1.2")
+	if err := CheckAllowPublicWithoutAuth(c, r); err != nil {
+		t.Errorf("CheckAllowPublicWithoutAuth() with trusted header: unexpected error: %v", err)
+	}
+
+	// without the header the request is public and unauthenticated
+	r = newTrustedHeaderRequest("193.168.1.1:8080", "")
+	r.Header.Set("X-FORWARDED-FOR", "193.168.1.2")
+	if err := CheckAllowPublicWithoutAuth(c, r); err == nil {
+		t.Error("CheckAllowPublicWithoutAuth() without trusted header: expected error")
+	}
+
+	// the header is not trusted from other addresses
+	r = newTrustedHeaderRequest("193.168.1.3:8080", "user")
+	if err := CheckAllowPublicWithoutAuth(c, r); err == nil {
+		t.Error("CheckAllowPublicWithoutAuth() with untrusted header: expected error")
+	}
+}
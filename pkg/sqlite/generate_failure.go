@@ -0,0 +1,83 @@
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const generateFailureTable = "generate_failures"
+
+type generateFailureQueryBuilder struct {
+	repository
+}
+
+func NewGenerateFailureReaderWriter(tx dbi) *generateFailureQueryBuilder {
+	return &generateFailureQueryBuilder{
+		repository{
+			tx:        tx,
+			tableName: generateFailureTable,
+			idColumn:  idColumn,
+		},
+	}
+}
+
+func (qb *generateFailureQueryBuilder) Create(newObject models.GenerateFailure) (*models.GenerateFailure, error) {
+	var ret models.GenerateFailure
+	if err := qb.insertObject(newObject, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (qb *generateFailureQueryBuilder) Update(updatedObject models.GenerateFailure) (*models.GenerateFailure, error) {
+	if err := qb.update(updatedObject.ID, updatedObject, false); err != nil {
+		return nil, err
+	}
+
+	var ret models.GenerateFailure
+	if err := qb.get(updatedObject.ID, &ret); err != nil {
+		return nil, err
+	}
+
+	return &ret, nil
+}
+
+func (qb *generateFailureQueryBuilder) Destroy(id int) error {
+	return qb.destroyExisting([]int{id})
+}
+
+func (qb *generateFailureQueryBuilder) DestroyAll() error {
+	_, err := qb.tx.Exec(fmt.Sprintf("DELETE FROM %s", generateFailureTable))
+	return err
+}
+
+// FindByItem returns the failure of the artifact of the scene or scene
+// marker. Returns nil if the item has not failed.
+func (qb *generateFailureQueryBuilder) FindByItem(artifact models.GenerateArtifact, sceneID sql.NullInt64, sceneMarkerID sql.NullInt64) (*models.GenerateFailure, error) {
+	query := fmt.Sprintf(`SELECT * FROM %s WHERE artifact = ? AND scene_id IS ? AND scene_marker_id IS ?`, generateFailureTable)
+
+	var ret models.GenerateFailures
+	if err := qb.query(query, []interface{}{artifact, sceneID, sceneMarkerID}, &ret); err != nil {
+		return nil, err
+	}
+
+	if len(ret) > 0 {
+		return ret[0], nil
+	}
+
+	return nil, nil
+}
+
+func (qb *generateFailureQueryBuilder) All() ([]*models.GenerateFailure, error) {
+	query := fmt.Sprintf(`SELECT * FROM %s ORDER BY id ASC`, generateFailureTable)
+
+	var ret models.GenerateFailures
+	if err := qb.query(query, nil, &ret); err != nil {
+		return nil, err
+	}
+
+	return []*models.GenerateFailure(ret), nil
+}
@@ -0,0 +1,105 @@
+//go:build integration
+// +build integration
+
+package sqlite_test
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateFailure(t *testing.T) {
+	withRollbackTxn(func(r models.Repository) error {
+		qb := r.GenerateFailure()
+		now := models.SQLiteTimestamp{Timestamp: time.Now()}
+
+		sceneID := sql.NullInt64{Int64: int64(sceneIDs[sceneIdxWithGallery]), Valid: true}
+		markerID := sql.NullInt64{Int64: int64(markerIDs[markerIdxWithScene]), Valid: true}
+
+		sceneFailure, err := qb.Create(models.GenerateFailure{
+			Artifact:  models.GenerateArtifactSprites,
+			SceneID:   sceneID,
+			Error:     "error",
+			Attempts:  1,
+			RetryAt:   now,
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+		if err != nil {
+			t.Errorf("Error creating generate failure: %s", err.Error())
+			return nil
+		}
+
+		if _, err := qb.Create(models.GenerateFailure{
+			Artifact:      models.GenerateArtifactMarkers,
+			SceneMarkerID: markerID,
+			Error:         "error",
+			Attempts:      1,
+			RetryAt:       now,
+			CreatedAt:     now,
+			UpdatedAt:     now,
+		}); err != nil {
+			t.Errorf("Error creating generate failure: %s", err.Error())
+			return nil
+		}
+
+		found, err := qb.FindByItem(models.GenerateArtifactSprites, sceneID, sql.NullInt64{})
+		if err != nil {
+			t.Errorf("Error finding generate failure: %s", err.Error())
+		}
+		if assert.NotNil(t, found) {
+			assert.Equal(t, sceneFailure.ID, found.ID)
+		}
+
+		// the same scene with a different artifact has not failed
+		found, err = qb.FindByItem(models.GenerateArtifactPreviews, sceneID, sql.NullInt64{})
+		if err != nil {
+			t.Errorf("Error finding generate failure: %s", err.Error())
+		}
+		assert.Nil(t, found)
+
+		sceneFailure.Attempts = 2
+		sceneFailure.Error = "error 2"
+		updated, err := qb.Update(*sceneFailure)
+		if err != nil {
+			t.Errorf("Error updating generate failure: %s", err.Error())
+			return nil
+		}
+		assert.Equal(t, 2, updated.Attempts)
+		assert.Equal(t, "error 2", updated.Error)
+
+		all, err := qb.All()
+		if err != nil {
+			t.Errorf("Error finding generate failures: %s", err.Error())
+		}
+		assert.Len(t, all, 2)
+
+		if err := qb.Destroy(sceneFailure.ID); err != nil {
+			t.Errorf("Error destroying generate failure: %s", err.Error())
+		}
+
+		all, err = qb.All()
+		if err != nil {
+			t.Errorf("Error finding generate failures: %s", err.Error())
+		}
+		if assert.Len(t, all, 1) {
+			assert.Equal(t, markerID, all[0].SceneMarkerID)
+		}
+
+		if err := qb.DestroyAll(); err != nil {
+			t.Errorf("Error destroying generate failures: %s", err.Error())
+		}
+
+		all, err = qb.All()
+		if err != nil {
+			t.Errorf("Error finding generate failures: %s", err.Error())
+		}
+		assert.Len(t, all, 0)
+
+		return nil
+	})
+}
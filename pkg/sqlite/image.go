@@ -497,7 +497,7 @@ INNER JOIN (` + valuesClause + `) t ON t.column2 = pt.tag_id
 
 func (qb *imageQueryBuilder) getImageSort(findFilter *models.FindFilterType) string {
 	if findFilter == nil {
-		return " ORDER BY images.path ASC "
+		return " ORDER BY images.path ASC" + getIDSort("images") + " "
 	}
 	sort := findFilter.GetSort("title")
 	direction := findFilter.GetDirection()
@@ -265,9 +265,9 @@ func (qb *movieQueryBuilder) getMovieSort(findFilter *models.FindFilterType) str
 
 	switch sort {
 	case "name": // #943 - override name sorting to use natural sort
-		return " ORDER BY " + getColumn("movies", sort) + " COLLATE NATURAL_CS " + direction
+		return " ORDER BY " + getColumn("movies", sort) + " COLLATE NATURAL_CS " + direction + getIDSort("movies")
 	case "scenes_count": // generic getSort won't work for this
-		return getCountSort(movieTable, moviesScenesTable, movieIDColumn, direction)
+		return getSceneCountSort(movieTable, moviesScenesTable, movieIDColumn, direction)
 	default:
 		return getSort(sort, direction, "movies")
 	}
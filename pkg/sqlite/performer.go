@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/stashapp/stash/pkg/models"
 	"github.com/stashapp/stash/pkg/utils"
@@ -72,6 +73,18 @@ func (qb *performerQueryBuilder) UpdateFull(updatedObject models.Performer) (*mo
 	return &ret, nil
 }
 
+// SetFavorite sets whether each of the performers is a favorite. Returns the
+// number of performers changed.
+func (qb *performerQueryBuilder) SetFavorite(performerIDs []int, favorite bool) (int, error) {
+	where := "favorite = 1"
+	if favorite {
+		where = "favorite = 0"
+	}
+
+	updatedAt := models.SQLiteTimestamp{Timestamp: time.Now()}
+	return qb.updateAll(performerIDs, "favorite = ?, updated_at = ?", []interface{}{favorite, updatedAt}, where)
+}
+
 func (qb *performerQueryBuilder) Destroy(id int) error {
 	// TODO - add on delete cascade to performers_scenes
 	_, err := qb.tx.Exec("DELETE FROM performers_scenes WHERE performer_id = ?", id)
@@ -405,13 +418,13 @@ func performerTagCountCriterionHandler(qb *performerQueryBuilder, count *models.
 }
 
 func performerSceneCountCriterionHandler(qb *performerQueryBuilder, count *models.IntCriterionInput) criterionHandlerFunc {
-	h := countCriterionHandlerBuilder{
-		primaryTable: performerTable,
-		joinTable:    performersScenesTable,
-		primaryFK:    performerIDColumn,
-	}
+	return func(f *filterBuilder) {
+		if count != nil {
+			clause, args := getIntCriterionWhereClause(sceneCountSubquery(performerTable, performersScenesTable, performerIDColumn), *count)
 
-	return h.handler(count)
+			f.addWhere(clause, args...)
+		}
+	}
 }
 
 func performerImageCountCriterionHandler(qb *performerQueryBuilder, count *models.IntCriterionInput) criterionHandlerFunc {
@@ -526,7 +539,7 @@ func (qb *performerQueryBuilder) getPerformerSort(findFilter *models.FindFilterT
 		return getCountSort(performerTable, performersTagsTable, performerIDColumn, direction)
 	}
 	if sort == "scenes_count" {
-		return getCountSort(performerTable, performersScenesTable, performerIDColumn, direction)
+		return getSceneCountSort(performerTable, performersScenesTable, performerIDColumn, direction)
 	}
 	if sort == "images_count" {
 		return getCountSort(performerTable, performersImagesTable, performerIDColumn, direction)
@@ -590,6 +603,164 @@ func (qb *performerQueryBuilder) DestroyImage(performerID int) error {
 	return qb.imageRepository().destroy([]int{performerID})
 }
 
+// MergeCounts returns the number of objects that would be reassigned by
+// merging the source performers into the destination performer.
+func (qb *performerQueryBuilder) MergeCounts(source []int, destination int) (*models.MergeCounts, error) {
+	ret := &models.MergeCounts{}
+	if len(source) == 0 {
+		return ret, nil
+	}
+
+	for _, id := range source {
+		if id == destination {
+			return nil, errors.New("cannot merge where source == destination")
+		}
+	}
+
+	var err error
+	if ret.Scenes, err = qb.countDistinctIn(performersScenesTable, sceneIDColumn, performerIDColumn, source); err != nil {
+		return nil, err
+	}
+	if ret.Images, err = qb.countDistinctIn(performersImagesTable, imageIDColumn, performerIDColumn, source); err != nil {
+		return nil, err
+	}
+	if ret.Galleries, err = qb.countDistinctIn(performersGalleriesTable, galleryIDColumn, performerIDColumn, source); err != nil {
+		return nil, err
+	}
+
+	dest, sources, err := qb.findMergePerformers(source, destination)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := splitPerformerAliases(dest.Aliases.String)
+	ret.Aliases = len(mergePerformerAliases(dest, sources)) - len(existing)
+
+	return ret, nil
+}
+
+// Merge reassigns the scenes, images and galleries of the source performers
+// to the destination performer, adds the source names and aliases to the
+// destination aliases and then destroys the source performers.
+func (qb *performerQueryBuilder) Merge(source []int, destination int) error {
+	if len(source) == 0 {
+		return nil
+	}
+
+	inBinding := getInBinding(len(source))
+
+	args := []interface{}{destination}
+	for _, id := range source {
+		if id == destination {
+			return errors.New("cannot merge where source == destination")
+		}
+		args = append(args, id)
+	}
+
+	dest, sources, err := qb.findMergePerformers(source, destination)
+	if err != nil {
+		return err
+	}
+
+	joinTables := map[string]string{
+		performersScenesTable:    sceneIDColumn,
+		performersImagesTable:    imageIDColumn,
+		performersGalleriesTable: galleryIDColumn,
+	}
+
+	// objects may be joined to more than one source performer, so distinct
+	// joins are added to the destination, and the source joins are removed
+	// with the source performers
+	args = append(args, destination)
+	for table, idColumn := range joinTables {
+		_, err := qb.tx.Exec(`INSERT INTO `+table+` (performer_id, `+idColumn+`)
+SELECT DISTINCT ?, `+idColumn+` FROM `+table+`
+WHERE performer_id IN `+inBinding+`
+AND `+idColumn+` NOT IN (SELECT `+idColumn+` FROM `+table+` WHERE performer_id = ?)`,
+			args...,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	aliases := strings.Join(mergePerformerAliases(dest, sources), ", ")
+	if err := qb.updateMap(destination, map[string]interface{}{
+		"aliases": sql.NullString{String: aliases, Valid: aliases != ""},
+	}); err != nil {
+		return err
+	}
+
+	for _, id := range source {
+		if err := qb.Destroy(id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (qb *performerQueryBuilder) findMergePerformers(source []int, destination int) (*models.Performer, []*models.Performer, error) {
+	dest, err := qb.Find(destination)
+	if err != nil {
+		return nil, nil, err
+	}
+	if dest == nil {
+		return nil, nil, fmt.Errorf("performer with id %d not found", destination)
+	}
+
+	sources, err := qb.FindMany(source)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dest, sources, nil
+}
+
+func splitPerformerAliases(aliases string) []string {
+	var ret []string
+	for _, a := range strings.Split(aliases, ",") {
+		a = strings.TrimSpace(a)
+		if a != "" {
+			ret = append(ret, a)
+		}
+	}
+
+	return ret
+}
+
+// mergePerformerAliases returns the aliases of dest with the names and
+// aliases of sources appended. Values matching the destination name or an
+// existing alias are ignored.
+func mergePerformerAliases(dest *models.Performer, sources []*models.Performer) []string {
+	ret := splitPerformerAliases(dest.Aliases.String)
+
+	seen := map[string]bool{
+		strings.ToLower(dest.Name.String): true,
+	}
+	for _, a := range ret {
+		seen[strings.ToLower(a)] = true
+	}
+
+	add := func(v string) {
+		v = strings.TrimSpace(v)
+		if v == "" || seen[strings.ToLower(v)] {
+			return
+		}
+		seen[strings.ToLower(v)] = true
+		ret = append(ret, v)
+	}
+
+	for _, s := range sources {
+		add(s.Name.String)
+		for _, a := range splitPerformerAliases(s.Aliases.String) {
+			add(a)
+		}
+	}
+
+	return ret
+}
+
 func (qb *performerQueryBuilder) stashIDRepository() *stashIDRepository {
 	return &stashIDRepository{
 		repository{
@@ -14,6 +14,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/performer"
 	"github.com/stashapp/stash/pkg/utils"
 )
 
@@ -971,8 +972,230 @@ func TestPerformerQuerySortScenesCount(t *testing.T) {
 
 // TODO Update
 // TODO Destroy
+func TestPerformerMergeSharedJoins(t *testing.T) {
+	assert := assert.New(t)
+
+	if err := withRollbackTxn(func(r models.Repository) error {
+		qb := r.Performer()
+
+		var ids []int
+		for _, name := range []string{"merge dest", "merge source 1", "merge source 2"} {
+			p, err := qb.Create(models.Performer{
+				Name:     sql.NullString{String: name, Valid: true},
+				Checksum: utils.MD5FromString(name),
+				Favorite: sql.NullBool{Bool: false, Valid: true},
+			})
+			if err != nil {
+				return err
+			}
+			ids = append(ids, p.ID)
+		}
+
+		destID := ids[0]
+		srcIDs := ids[1:]
+
+		// both sources are joined to the same scene, image and gallery
+		sceneID := sceneIDs[sceneIdxWithGallery]
+		imageID := imageIDs[imageIdxWithGallery]
+		galleryID := galleryIDs[galleryIdxWithImage]
+		if err := r.Scene().UpdatePerformers(sceneID, srcIDs); err != nil {
+			return err
+		}
+		if err := r.Image().UpdatePerformers(imageID, srcIDs); err != nil {
+			return err
+		}
+		if err := r.Gallery().UpdatePerformers(galleryID, srcIDs); err != nil {
+			return err
+		}
+
+		if err := qb.Merge(srcIDs, destID); err != nil {
+			return err
+		}
+
+		scenePerformerIDs, err := r.Scene().GetPerformerIDs(sceneID)
+		if err != nil {
+			return err
+		}
+		assert.Equal([]int{destID}, scenePerformerIDs)
+
+		imagePerformerIDs, err := r.Image().GetPerformerIDs(imageID)
+		if err != nil {
+			return err
+		}
+		assert.Equal([]int{destID}, imagePerformerIDs)
+
+		galleryPerformerIDs, err := r.Gallery().GetPerformerIDs(galleryID)
+		if err != nil {
+			return err
+		}
+		assert.Equal([]int{destID}, galleryPerformerIDs)
+
+		return nil
+	}); err != nil {
+		t.Error(err.Error())
+	}
+}
+
 // TODO Find
 // TODO Count
 // TODO All
 // TODO AllSlim
 // TODO Query
+
+func TestPerformerMerge(t *testing.T) {
+	assert := assert.New(t)
+
+	// merge tests - perform these in a transaction that we'll rollback
+	if err := withRollbackTxn(func(r models.Repository) error {
+		qb := r.Performer()
+
+		// try merging into same performer
+		err := qb.Merge([]int{performerIDs[performerIdx1WithScene]}, performerIDs[performerIdx1WithScene])
+		assert.NotNil(err)
+		_, err = qb.MergeCounts([]int{performerIDs[performerIdx1WithScene]}, performerIDs[performerIdx1WithScene])
+		assert.NotNil(err)
+
+		srcIdxs := []int{
+			performerIdx2WithScene,
+			performerIdxWithTwoImages,
+			performerIdxWithGallery,
+		}
+		var srcIDs []int
+		for _, idx := range srcIdxs {
+			srcIDs = append(srcIDs, performerIDs[idx])
+		}
+
+		destID := performerIDs[performerIdx1WithScene]
+
+		counts, err := qb.MergeCounts(srcIDs, destID)
+		if err != nil {
+			return err
+		}
+		assert.Equal(&models.MergeCounts{
+			Scenes:    1,
+			Images:    2,
+			Galleries: 1,
+			Aliases:   len(srcIdxs),
+		}, counts)
+
+		if err = qb.Merge(srcIDs, destID); err != nil {
+			return err
+		}
+
+		// ensure other performers are deleted
+		for _, id := range srcIDs {
+			p, err := qb.Find(id)
+			if err != nil {
+				return err
+			}
+
+			assert.Nil(p)
+		}
+
+		// ensure aliases are set on the destination
+		dest, err := qb.Find(destID)
+		if err != nil {
+			return err
+		}
+		for _, idx := range srcIdxs {
+			assert.Contains(dest.Aliases.String, getPerformerStringValue(idx, "Name"))
+		}
+
+		// scene had both the source and destination - ensure no duplicate
+		scenePerformerIDs, err := r.Scene().GetPerformerIDs(sceneIDs[sceneIdxWithTwoPerformers])
+		if err != nil {
+			return err
+		}
+
+		assert.Equal([]int{destID}, scenePerformerIDs)
+
+		// ensure images and gallery point to the destination
+		for _, idx := range []int{imageIdx1WithPerformer, imageIdx2WithPerformer} {
+			imagePerformerIDs, err := r.Image().GetPerformerIDs(imageIDs[idx])
+			if err != nil {
+				return err
+			}
+
+			assert.Contains(imagePerformerIDs, destID)
+		}
+
+		galleryPerformerIDs, err := r.Gallery().GetPerformerIDs(galleryIDs[galleryIdxWithPerformer])
+		if err != nil {
+			return err
+		}
+
+		assert.Contains(galleryPerformerIDs, destID)
+
+		return nil
+	}); err != nil {
+		t.Error(err.Error())
+	}
+}
+
+func TestPerformerBulkSetFavorite(t *testing.T) {
+	if err := withRollbackTxn(func(r models.Repository) error {
+		qb := r.Performer()
+
+		const prefix = "TestPerformerBulkSetFavorite"
+		var ids []int
+		for i := 0; i < 3; i++ {
+			created, err := qb.Create(*models.NewPerformer(fmt.Sprintf("%s_%d", prefix, i)))
+			if err != nil {
+				return fmt.Errorf("Error creating performer: %s", err.Error())
+			}
+			ids = append(ids, created.ID)
+		}
+
+		// favorite one performer up front so that only the others are changed
+		if _, err := qb.SetFavorite(ids[:1], true); err != nil {
+			return err
+		}
+
+		performerFilter := &models.PerformerFilterType{
+			Name: &models.StringCriterionInput{
+				Value:    prefix + "%",
+				Modifier: models.CriterionModifierEquals,
+			},
+		}
+
+		changed, err := performer.BulkSetFavorite(qb, nil, performerFilter, true)
+		if err != nil {
+			return fmt.Errorf("Error setting favorite: %s", err.Error())
+		}
+		assert.Equal(t, 2, changed)
+
+		// setting again should be a no-op
+		changed, err = performer.BulkSetFavorite(qb, nil, performerFilter, true)
+		if err != nil {
+			return fmt.Errorf("Error setting favorite: %s", err.Error())
+		}
+		assert.Equal(t, 0, changed)
+
+		favorite := true
+		performerFilter.FilterFavorites = &favorite
+		perPage := models.PerPageAll
+		performers, _, err := qb.Query(performerFilter, &models.FindFilterType{PerPage: &perPage})
+		if err != nil {
+			return err
+		}
+		assert.Len(t, performers, 3)
+
+		changed, err = performer.BulkSetFavorite(qb, ids[1:], nil, false)
+		if err != nil {
+			return fmt.Errorf("Error clearing favorite: %s", err.Error())
+		}
+		assert.Equal(t, 2, changed)
+
+		performers, _, err = qb.Query(performerFilter, &models.FindFilterType{PerPage: &perPage})
+		if err != nil {
+			return err
+		}
+		if assert.Len(t, performers, 1) {
+			assert.Equal(t, ids[0], performers[0].ID)
+		}
+
+		return nil
+	}); err != nil {
+		t.Error(err.Error())
+	}
+}
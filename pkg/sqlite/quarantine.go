@@ -0,0 +1,70 @@
+package sqlite
+
+import (
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const quarantineTable = "quarantined_files"
+
+type quarantineQueryBuilder struct {
+	repository
+}
+
+func NewQuarantineReaderWriter(tx dbi) *quarantineQueryBuilder {
+	return &quarantineQueryBuilder{
+		repository{
+			tx:        tx,
+			tableName: quarantineTable,
+			idColumn:  idColumn,
+		},
+	}
+}
+
+// Create adds the file to the quarantine list. If the path is already
+// quarantined, then the reason is updated.
+func (qb *quarantineQueryBuilder) Create(newObject models.QuarantinedFile) (*models.QuarantinedFile, error) {
+	query := fmt.Sprintf(`INSERT INTO %s (path, reason, created_at) VALUES (?, ?, ?)
+ON CONFLICT (path) DO UPDATE SET reason = excluded.reason, created_at = excluded.created_at`, quarantineTable)
+	if _, err := qb.tx.Exec(query, newObject.Path, newObject.Reason, newObject.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return qb.FindByPath(newObject.Path)
+}
+
+func (qb *quarantineQueryBuilder) Destroy(id int) error {
+	return qb.destroyExisting([]int{id})
+}
+
+func (qb *quarantineQueryBuilder) DestroyAll() error {
+	_, err := qb.tx.Exec(fmt.Sprintf("DELETE FROM %s", quarantineTable))
+	return err
+}
+
+func (qb *quarantineQueryBuilder) FindByPath(path string) (*models.QuarantinedFile, error) {
+	query := fmt.Sprintf(`SELECT * FROM %s WHERE path = ?`, quarantineTable)
+
+	var ret models.QuarantinedFiles
+	if err := qb.query(query, []interface{}{path}, &ret); err != nil {
+		return nil, err
+	}
+
+	if len(ret) > 0 {
+		return ret[0], nil
+	}
+
+	return nil, nil
+}
+
+func (qb *quarantineQueryBuilder) All() ([]*models.QuarantinedFile, error) {
+	query := fmt.Sprintf(`SELECT * FROM %s ORDER BY path ASC`, quarantineTable)
+
+	var ret models.QuarantinedFiles
+	if err := qb.query(query, nil, &ret); err != nil {
+		return nil, err
+	}
+
+	return []*models.QuarantinedFile(ret), nil
+}
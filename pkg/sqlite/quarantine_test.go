@@ -0,0 +1,78 @@
+//go:build integration
+// +build integration
+
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuarantine(t *testing.T) {
+	const (
+		path1 = "quarantined1.mp4"
+		path2 = "quarantined2.mp4"
+	)
+
+	withRollbackTxn(func(r models.Repository) error {
+		qb := r.Quarantine()
+		now := models.SQLiteTimestamp{Timestamp: time.Now()}
+
+		q1, err := qb.Create(models.QuarantinedFile{Path: path1, Reason: "reason", CreatedAt: now})
+		if err != nil {
+			t.Errorf("Error quarantining file: %s", err.Error())
+			return nil
+		}
+		assert.Equal(t, path1, q1.Path)
+
+		// quarantining again updates the reason
+		q1, err = qb.Create(models.QuarantinedFile{Path: path1, Reason: "new reason", CreatedAt: now})
+		if err != nil {
+			t.Errorf("Error quarantining file: %s", err.Error())
+			return nil
+		}
+		assert.Equal(t, "new reason", q1.Reason)
+
+		if _, err := qb.Create(models.QuarantinedFile{Path: path2, Reason: "reason", CreatedAt: now}); err != nil {
+			t.Errorf("Error quarantining file: %s", err.Error())
+			return nil
+		}
+
+		all, err := qb.All()
+		if err != nil {
+			t.Errorf("Error finding quarantined files: %s", err.Error())
+		}
+		assert.Len(t, all, 2)
+
+		found, err := qb.FindByPath(path1)
+		if err != nil {
+			t.Errorf("Error finding quarantined file: %s", err.Error())
+		}
+		assert.Equal(t, q1.ID, found.ID)
+
+		if err := qb.Destroy(q1.ID); err != nil {
+			t.Errorf("Error clearing quarantined file: %s", err.Error())
+		}
+
+		found, err = qb.FindByPath(path1)
+		if err != nil {
+			t.Errorf("Error finding quarantined file: %s", err.Error())
+		}
+		assert.Nil(t, found)
+
+		if err := qb.DestroyAll(); err != nil {
+			t.Errorf("Error clearing quarantined files: %s", err.Error())
+		}
+
+		all, err = qb.All()
+		if err != nil {
+			t.Errorf("Error finding quarantined files: %s", err.Error())
+		}
+		assert.Len(t, all, 0)
+
+		return nil
+	})
+}
@@ -0,0 +1,221 @@
+package sqlite
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+// QueryStats records the number of times each shape of query is executed,
+// and the time taken. Queries of the same shape differ only in their
+// literal values. Queries are not recorded while disabled.
+type QueryStats struct {
+	enabled int32
+
+	mutex   sync.Mutex
+	queries map[string]*queryStat
+}
+
+type queryStat struct {
+	count int
+	total time.Duration
+	max   time.Duration
+}
+
+func NewQueryStats() *QueryStats {
+	return &QueryStats{
+		queries: make(map[string]*queryStat),
+	}
+}
+
+// SetEnabled sets whether queries are recorded. The recorded stats are kept
+// when disabled.
+func (s *QueryStats) SetEnabled(enabled bool) {
+	var v int32
+	if enabled {
+		v = 1
+	}
+	atomic.StoreInt32(&s.enabled, v)
+}
+
+// Enabled returns true if queries are recorded. A nil QueryStats is never
+// enabled.
+func (s *QueryStats) Enabled() bool {
+	return s != nil && atomic.LoadInt32(&s.enabled) == 1
+}
+
+// Reset removes the recorded stats.
+func (s *QueryStats) Reset() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.queries = make(map[string]*queryStat)
+}
+
+func (s *QueryStats) record(query string, d time.Duration) {
+	shape := queryShape(query)
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stat := s.queries[shape]
+	if stat == nil {
+		stat = &queryStat{}
+		s.queries[shape] = stat
+	}
+
+	stat.count++
+	stat.total += d
+	if d > stat.max {
+		stat.max = d
+	}
+}
+
+// Stats returns the recorded stats of each query shape, in descending
+// order of total time.
+func (s *QueryStats) Stats() []*models.QueryStat {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ret := make([]*models.QueryStat, 0, len(s.queries))
+	for shape, stat := range s.queries {
+		ret = append(ret, &models.QueryStat{
+			Query:   shape,
+			Count:   stat.count,
+			Total:   durationMilliseconds(stat.total),
+			Max:     durationMilliseconds(stat.max),
+			Average: durationMilliseconds(stat.total / time.Duration(stat.count)),
+		})
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Total != ret[j].Total {
+			return ret[i].Total > ret[j].Total
+		}
+		return ret[i].Query < ret[j].Query
+	})
+
+	return ret
+}
+
+func durationMilliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// queryShape returns the query with its whitespace collapsed, its numeric
+// and string literals replaced with placeholders, and its lists of
+// placeholders replaced with a single placeholder, so that queries that
+// differ only in their values have the same shape.
+func queryShape(query string) string {
+	var b strings.Builder
+	runes := []rune(query)
+	space := false
+	// last is the last rune written
+	var last rune
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		case r == '\'':
+			// skip the string literal, including escaped quotes
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			r = '?'
+		case unicode.IsDigit(r) && (space || !isIdentifierRune(last)):
+			for i+1 < len(runes) && (unicode.IsDigit(runes[i+1]) || runes[i+1] == '.') {
+				i++
+			}
+			r = '?'
+		}
+
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+		b.WriteRune(r)
+		last = r
+	}
+
+	return collapsePlaceholders(b.String())
+}
+
+// isIdentifierRune returns true if r is a character of an identifier, so
+// that a digit following it is part of the identifier.
+func isIdentifierRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// collapsePlaceholders replaces lists of placeholders with a single
+// placeholder.
+func collapsePlaceholders(s string) string {
+	const list = "?, ?"
+	const listNoSpace = "?,?"
+	for strings.Contains(s, list) || strings.Contains(s, listNoSpace) {
+		s = strings.ReplaceAll(s, list, "?")
+		s = strings.ReplaceAll(s, listNoSpace, "?")
+	}
+	return s
+}
+
+// statsDBI records the time taken by the queries of a dbi. The time taken
+// by Queryx does not include reading the rows.
+type statsDBI struct {
+	dbi
+	stats *QueryStats
+}
+
+func (d statsDBI) record(query string, start time.Time) {
+	d.stats.record(query, time.Since(start))
+}
+
+func (d statsDBI) Get(dest interface{}, query string, args ...interface{}) error {
+	defer d.record(query, time.Now())
+	return d.dbi.Get(dest, query, args...)
+}
+
+func (d statsDBI) Select(dest interface{}, query string, args ...interface{}) error {
+	defer d.record(query, time.Now())
+	return d.dbi.Select(dest, query, args...)
+}
+
+func (d statsDBI) Queryx(query string, args ...interface{}) (*sqlx.Rows, error) {
+	defer d.record(query, time.Now())
+	return d.dbi.Queryx(query, args...)
+}
+
+func (d statsDBI) NamedExec(query string, arg interface{}) (sql.Result, error) {
+	defer d.record(query, time.Now())
+	return d.dbi.NamedExec(query, arg)
+}
+
+func (d statsDBI) Exec(query string, args ...interface{}) (sql.Result, error) {
+	defer d.record(query, time.Now())
+	return d.dbi.Exec(query, args...)
+}
+
+// withQueryStats returns db, recording its queries in stats if stats is
+// enabled.
+func withQueryStats(db dbi, stats *QueryStats) dbi {
+	if !stats.Enabled() {
+		return db
+	}
+	return statsDBI{dbi: db, stats: stats}
+}
@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryShape(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{
+			"SELECT * FROM scenes WHERE id = ?",
+			"SELECT * FROM scenes WHERE id = ?",
+		},
+		{
+			"SELECT *\n\tFROM scenes\n\tWHERE id = 12 LIMIT 25 OFFSET 50",
+			"SELECT * FROM scenes WHERE id = ? LIMIT ? OFFSET ?",
+		},
+		{
+			"SELECT * FROM scenes WHERE id IN (1, 2, 3)",
+			"SELECT * FROM scenes WHERE id IN (?)",
+		},
+		{
+			"SELECT * FROM scenes WHERE id IN (?,?,?,?)",
+			"SELECT * FROM scenes WHERE id IN (?)",
+		},
+		{
+			"UPDATE scenes SET title = 'it''s 4' WHERE rating > 3.5",
+			"UPDATE scenes SET title = ? WHERE rating > ?",
+		},
+		{
+			"SELECT t1.id FROM tags AS t1 WHERE t1.o_counter = 0",
+			"SELECT t1.id FROM tags AS t1 WHERE t1.o_counter = ?",
+		},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, queryShape(tt.query))
+	}
+}
+
+func TestQueryStats(t *testing.T) {
+	s := NewQueryStats()
+
+	s.record("SELECT * FROM scenes WHERE id = 1", 10*time.Millisecond)
+	s.record("SELECT * FROM scenes WHERE id = 2", 30*time.Millisecond)
+	s.record("SELECT * FROM tags", 50*time.Millisecond)
+
+	stats := s.Stats()
+	if assert.Len(t, stats, 2) {
+		assert.Equal(t, "SELECT * FROM tags", stats[0].Query)
+
+		scenes := stats[1]
+		assert.Equal(t, "SELECT * FROM scenes WHERE id = ?", scenes.Query)
+		assert.Equal(t, 2, scenes.Count)
+		assert.Equal(t, float64(40), scenes.Total)
+		assert.Equal(t, float64(30), scenes.Max)
+		assert.Equal(t, float64(20), scenes.Average)
+	}
+
+	s.Reset()
+	assert.Empty(t, s.Stats())
+}
+
+func TestWithQueryStats(t *testing.T) {
+	var db dbi = &sqlx.DB{}
+
+	// a nil or disabled QueryStats does not wrap the dbi
+	assert.Equal(t, db, withQueryStats(db, nil))
+
+	s := NewQueryStats()
+	assert.Equal(t, db, withQueryStats(db, s))
+
+	s.SetEnabled(true)
+	assert.IsType(t, statsDBI{}, withQueryStats(db, s))
+
+	// stats are kept when disabled
+	s.record("SELECT 1", time.Millisecond)
+	s.SetEnabled(false)
+	assert.False(t, s.Enabled())
+	assert.Len(t, s.Stats(), 1)
+}
@@ -79,8 +79,14 @@ func (r *repository) updateMap(id int, m map[string]interface{}) error {
 		return fmt.Errorf("%s %d does not exist in %s", r.idColumn, id, r.tableName)
 	}
 
+	// id is bound by name in the WHERE clause
+	args := map[string]interface{}{"id": id}
+	for k, v := range m {
+		args[k] = v
+	}
+
 	stmt := fmt.Sprintf("UPDATE %s SET %s WHERE %s.%s = :id", r.tableName, updateSetMap(m), r.tableName, r.idColumn)
-	_, err = r.tx.NamedExec(stmt, m)
+	_, err = r.tx.NamedExec(stmt, args)
 
 	return err
 }
@@ -140,6 +146,19 @@ func (r *repository) runCountQuery(query string, args []interface{}) (int, error
 	return result.Int, nil
 }
 
+// countDistinctIn returns the number of distinct values of column in table
+// for the rows where inColumn is one of ids.
+func (r *repository) countDistinctIn(table, column, inColumn string, ids []int) (int, error) {
+	query := fmt.Sprintf("SELECT COUNT(DISTINCT %s) as count FROM %s WHERE %s IN %s", column, table, inColumn, getInBinding(len(ids)))
+
+	var args []interface{}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+
+	return r.runCountQuery(query, args)
+}
+
 func (r *repository) runIdsQuery(query string, args []interface{}) ([]int, error) {
 	var result []struct {
 		Int int `db:"id"`
@@ -340,6 +359,118 @@ func (r *joinRepository) replace(id int, foreignIDs []int) error {
 	return nil
 }
 
+// joinBatchSize is the maximum number of ids bound in a single bulk join
+// statement, keeping well under the sqlite variable limit.
+const joinBatchSize = 500
+
+// addAll creates joins between each of ids and each of foreignIDs, skipping
+// any joins that already exist. Returns the number of joins created. Both
+// lists are batched, so that each statement binds at most joinBatchSize
+// ids.
+func (r *joinRepository) addAll(ids []int, foreignIDs []int) (int, error) {
+	total := 0
+	for _, batch := range batchInts(ids, joinBatchSize/2) {
+		idValues := strings.TrimRight(strings.Repeat("(?), ", len(batch)), ", ")
+
+		for _, fkBatch := range batchInts(foreignIDs, joinBatchSize/2) {
+			fkValues := strings.TrimRight(strings.Repeat("(?), ", len(fkBatch)), ", ")
+			stmt := fmt.Sprintf(`WITH ids(id) AS (VALUES %[3]s), fks(id) AS (VALUES %[4]s)
+INSERT INTO %[1]s (%[2]s, %[5]s)
+SELECT DISTINCT ids.id, fks.id FROM ids, fks
+WHERE NOT EXISTS (SELECT 1 FROM %[1]s j WHERE j.%[2]s = ids.id AND j.%[5]s = fks.id)`,
+				r.tableName, r.idColumn, idValues, fkValues, r.fkColumn)
+
+			n, err := r.execRowsAffected(stmt, batchArgs(batch, fkBatch))
+			if err != nil {
+				return total, err
+			}
+			total += n
+		}
+	}
+
+	return total, nil
+}
+
+// removeAll removes any joins between ids and foreignIDs. Returns the number
+// of joins removed. Both lists are batched, so that each statement binds at
+// most joinBatchSize ids.
+func (r *joinRepository) removeAll(ids []int, foreignIDs []int) (int, error) {
+	total := 0
+	for _, batch := range batchInts(ids, joinBatchSize/2) {
+		for _, fkBatch := range batchInts(foreignIDs, joinBatchSize/2) {
+			stmt := fmt.Sprintf("DELETE FROM %s WHERE %s IN %s AND %s IN %s", r.tableName, r.idColumn, getInBinding(len(batch)), r.fkColumn, getInBinding(len(fkBatch)))
+
+			n, err := r.execRowsAffected(stmt, batchArgs(batch, fkBatch))
+			if err != nil {
+				return total, err
+			}
+			total += n
+		}
+	}
+
+	return total, nil
+}
+
+// batchArgs returns the ids followed by the foreign ids as statement
+// arguments.
+func batchArgs(ids []int, foreignIDs []int) []interface{} {
+	var args []interface{}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	for _, fk := range foreignIDs {
+		args = append(args, fk)
+	}
+
+	return args
+}
+
+// updateAll sets the columns in set, with setArgs bound, on the rows with
+// the ids for which where holds. Returns the number of rows updated.
+func (r *repository) updateAll(ids []int, set string, setArgs []interface{}, where string) (int, error) {
+	total := 0
+	for _, batch := range batchInts(ids, joinBatchSize) {
+		stmt := fmt.Sprintf("UPDATE %s SET %s WHERE %s IN %s AND (%s)", r.tableName, set, r.idColumn, getInBinding(len(batch)), where)
+
+		args := append([]interface{}{}, setArgs...)
+		for _, id := range batch {
+			args = append(args, id)
+		}
+
+		n, err := r.execRowsAffected(stmt, args)
+		if err != nil {
+			return total, err
+		}
+		total += n
+	}
+
+	return total, nil
+}
+
+func (r *repository) execRowsAffected(stmt string, args []interface{}) (int, error) {
+	result, err := r.tx.Exec(stmt, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+func batchInts(ids []int, size int) [][]int {
+	var ret [][]int
+	for len(ids) > size {
+		ret = append(ret, ids[:size])
+		ids = ids[size:]
+	}
+
+	if len(ids) > 0 {
+		ret = append(ret, ids)
+	}
+
+	return ret
+}
+
 type imageRepository struct {
 	repository
 	imageColumn string
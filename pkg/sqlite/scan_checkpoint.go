@@ -0,0 +1,55 @@
+package sqlite
+
+import (
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const scanCheckpointTable = "scan_checkpoints"
+
+type scanCheckpointQueryBuilder struct {
+	repository
+}
+
+func NewScanCheckpointReaderWriter(tx dbi) *scanCheckpointQueryBuilder {
+	return &scanCheckpointQueryBuilder{
+		repository{
+			tx:        tx,
+			tableName: scanCheckpointTable,
+			idColumn:  idColumn,
+		},
+	}
+}
+
+// Save stores the checkpoint, replacing any existing checkpoint for the
+// same paths.
+func (qb *scanCheckpointQueryBuilder) Save(newObject models.ScanCheckpoint) (*models.ScanCheckpoint, error) {
+	query := fmt.Sprintf(`INSERT INTO %s (paths, last_path, updated_at) VALUES (?, ?, ?)
+ON CONFLICT (paths) DO UPDATE SET last_path = excluded.last_path, updated_at = excluded.updated_at`, scanCheckpointTable)
+	if _, err := qb.tx.Exec(query, newObject.Paths, newObject.LastPath, newObject.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	return qb.FindByPaths(newObject.Paths)
+}
+
+func (qb *scanCheckpointQueryBuilder) DestroyByPaths(paths string) error {
+	_, err := qb.tx.Exec(fmt.Sprintf("DELETE FROM %s WHERE paths = ?", scanCheckpointTable), paths)
+	return err
+}
+
+func (qb *scanCheckpointQueryBuilder) FindByPaths(paths string) (*models.ScanCheckpoint, error) {
+	query := fmt.Sprintf(`SELECT * FROM %s WHERE paths = ?`, scanCheckpointTable)
+
+	var ret models.ScanCheckpoints
+	if err := qb.query(query, []interface{}{paths}, &ret); err != nil {
+		return nil, err
+	}
+
+	if len(ret) > 0 {
+		return ret[0], nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,58 @@
+//go:build integration
+// +build integration
+
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanCheckpoint(t *testing.T) {
+	const (
+		paths      = "/library1\n/library2"
+		otherPaths = "/library1"
+	)
+
+	withRollbackTxn(func(r models.Repository) error {
+		qb := r.ScanCheckpoint()
+		now := models.SQLiteTimestamp{Timestamp: time.Now()}
+
+		c, err := qb.Save(models.ScanCheckpoint{Paths: paths, LastPath: "/library1/a.mp4", UpdatedAt: now})
+		if err != nil {
+			t.Errorf("Error saving scan checkpoint: %s", err.Error())
+			return nil
+		}
+		assert.Equal(t, "/library1/a.mp4", c.LastPath)
+
+		// saving again replaces the checkpoint
+		c2, err := qb.Save(models.ScanCheckpoint{Paths: paths, LastPath: "/library2/b.mp4", UpdatedAt: now})
+		if err != nil {
+			t.Errorf("Error saving scan checkpoint: %s", err.Error())
+			return nil
+		}
+		assert.Equal(t, c.ID, c2.ID)
+		assert.Equal(t, "/library2/b.mp4", c2.LastPath)
+
+		found, err := qb.FindByPaths(otherPaths)
+		if err != nil {
+			t.Errorf("Error finding scan checkpoint: %s", err.Error())
+		}
+		assert.Nil(t, found)
+
+		if err := qb.DestroyByPaths(paths); err != nil {
+			t.Errorf("Error clearing scan checkpoint: %s", err.Error())
+		}
+
+		found, err = qb.FindByPaths(paths)
+		if err != nil {
+			t.Errorf("Error finding scan checkpoint: %s", err.Error())
+		}
+		assert.Nil(t, found)
+
+		return nil
+	})
+}
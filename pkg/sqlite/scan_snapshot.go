@@ -0,0 +1,58 @@
+package sqlite
+
+import (
+	"fmt"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+const scanSnapshotTable = "scan_snapshots"
+
+type scanSnapshotQueryBuilder struct {
+	repository
+}
+
+func NewScanSnapshotReaderWriter(tx dbi) *scanSnapshotQueryBuilder {
+	return &scanSnapshotQueryBuilder{
+		repository{
+			tx:        tx,
+			tableName: scanSnapshotTable,
+			idColumn:  idColumn,
+		},
+	}
+}
+
+// Save stores the snapshot, replacing the data of any existing snapshot of
+// the same path. The creation time of an existing snapshot is kept, so
+// that the snapshot becomes stale even if it is replaced by each scan.
+func (qb *scanSnapshotQueryBuilder) Save(newObject models.ScanSnapshot) (*models.ScanSnapshot, error) {
+	query := fmt.Sprintf(`INSERT INTO %s (path, data, created_at) VALUES (?, ?, ?)
+ON CONFLICT (path) DO UPDATE SET data = excluded.data`, scanSnapshotTable)
+	if _, err := qb.tx.Exec(query, newObject.Path, newObject.Data, newObject.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	return qb.FindByPath(newObject.Path)
+}
+
+// DestroyByPath removes the snapshot of the path, if it exists.
+func (qb *scanSnapshotQueryBuilder) DestroyByPath(path string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE path = ?`, scanSnapshotTable)
+	_, err := qb.tx.Exec(query, path)
+	return err
+}
+
+func (qb *scanSnapshotQueryBuilder) FindByPath(path string) (*models.ScanSnapshot, error) {
+	query := fmt.Sprintf(`SELECT * FROM %s WHERE path = ?`, scanSnapshotTable)
+
+	var ret models.ScanSnapshots
+	if err := qb.query(query, []interface{}{path}, &ret); err != nil {
+		return nil, err
+	}
+
+	if len(ret) > 0 {
+		return ret[0], nil
+	}
+
+	return nil, nil
+}
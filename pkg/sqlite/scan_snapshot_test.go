@@ -0,0 +1,58 @@
+//go:build integration
+// +build integration
+
+package sqlite_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanSnapshot(t *testing.T) {
+	const path = "/library"
+
+	withRollbackTxn(func(r models.Repository) error {
+		qb := r.ScanSnapshot()
+		now := models.SQLiteTimestamp{Timestamp: time.Now()}
+
+		s, err := qb.Save(models.ScanSnapshot{Path: path, Data: []byte("first"), CreatedAt: now})
+		if err != nil {
+			t.Errorf("Error saving scan snapshot: %s", err.Error())
+			return nil
+		}
+		assert.Equal(t, []byte("first"), s.Data)
+
+		// saving again replaces the snapshot, keeping the creation time
+		later := models.SQLiteTimestamp{Timestamp: now.Timestamp.Add(time.Hour)}
+		s2, err := qb.Save(models.ScanSnapshot{Path: path, Data: []byte("second"), CreatedAt: later})
+		if err != nil {
+			t.Errorf("Error saving scan snapshot: %s", err.Error())
+			return nil
+		}
+		assert.Equal(t, s.ID, s2.ID)
+		assert.Equal(t, []byte("second"), s2.Data)
+		assert.True(t, s.CreatedAt.Timestamp.Equal(s2.CreatedAt.Timestamp))
+
+		if err := qb.DestroyByPath(path); err != nil {
+			t.Errorf("Error destroying scan snapshot: %s", err.Error())
+			return nil
+		}
+
+		found, err := qb.FindByPath(path)
+		if err != nil {
+			t.Errorf("Error finding scan snapshot: %s", err.Error())
+		}
+		assert.Nil(t, found)
+
+		found, err = qb.FindByPath("/other")
+		if err != nil {
+			t.Errorf("Error finding scan snapshot: %s", err.Error())
+		}
+		assert.Nil(t, found)
+
+		return nil
+	})
+}
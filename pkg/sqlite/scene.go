@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/stashapp/stash/pkg/models"
@@ -18,33 +19,52 @@ const performersScenesTable = "performers_scenes"
 const scenesTagsTable = "scenes_tags"
 const scenesGalleriesTable = "scenes_galleries"
 const moviesScenesTable = "movies_scenes"
+const sceneSubtitlesTable = "scene_subtitles"
+
+// sceneNotDeletedClause excludes soft-deleted scenes from a query.
+const sceneNotDeletedClause = "scenes.deleted_at IS NULL"
 
 var scenesForPerformerQuery = selectAll(sceneTable) + `
 LEFT JOIN performers_scenes as performers_join on performers_join.scene_id = scenes.id
-WHERE performers_join.performer_id = ?
+WHERE performers_join.performer_id = ? AND ` + sceneNotDeletedClause + `
 GROUP BY scenes.id
 `
 
 var countScenesForPerformerQuery = `
 SELECT performer_id FROM performers_scenes as performers_join
-WHERE performer_id = ?
+JOIN scenes ON scenes.id = performers_join.scene_id
+WHERE performer_id = ? AND ` + sceneNotDeletedClause + `
 GROUP BY scene_id
 `
 
 var scenesForStudioQuery = selectAll(sceneTable) + `
 JOIN studios ON studios.id = scenes.studio_id
-WHERE studios.id = ?
+WHERE studios.id = ? AND ` + sceneNotDeletedClause + `
 GROUP BY scenes.id
 `
+
+var countScenesForStudioQuery = `
+SELECT scenes.id FROM scenes
+WHERE scenes.studio_id = ? AND ` + sceneNotDeletedClause + `
+`
+
 var scenesForMovieQuery = selectAll(sceneTable) + `
 LEFT JOIN movies_scenes as movies_join on movies_join.scene_id = scenes.id
-WHERE movies_join.movie_id = ?
+WHERE movies_join.movie_id = ? AND ` + sceneNotDeletedClause + `
+GROUP BY scenes.id
+`
+
+var countScenesForMovieQuery = `
+SELECT scenes.id FROM scenes
+JOIN movies_scenes as movies_join on movies_join.scene_id = scenes.id
+WHERE movies_join.movie_id = ? AND ` + sceneNotDeletedClause + `
 GROUP BY scenes.id
 `
 
 var countScenesForTagQuery = `
 SELECT tag_id AS id FROM scenes_tags
-WHERE scenes_tags.tag_id = ?
+JOIN scenes ON scenes.id = scenes_tags.scene_id
+WHERE scenes_tags.tag_id = ? AND ` + sceneNotDeletedClause + `
 GROUP BY scenes_tags.scene_id
 `
 
@@ -56,27 +76,35 @@ GROUP BY scenes.id
 
 var countScenesForMissingChecksumQuery = `
 SELECT id FROM scenes
-WHERE scenes.checksum is null
+WHERE scenes.checksum is null AND ` + sceneNotDeletedClause + `
 `
 
 var countScenesForMissingOSHashQuery = `
 SELECT id FROM scenes
-WHERE scenes.oshash is null
+WHERE scenes.oshash is null AND ` + sceneNotDeletedClause + `
 `
 
 var findExactDuplicateQuery = `
 SELECT GROUP_CONCAT(id) as ids
 FROM scenes
-WHERE phash IS NOT NULL
+WHERE phash IS NOT NULL AND ` + sceneNotDeletedClause + `
 GROUP BY phash
 HAVING COUNT(phash) > 1
 ORDER BY SUM(size) DESC;
 `
 
+var findSharedStashIDsQuery = `
+SELECT GROUP_CONCAT(DISTINCT scene_id) as ids
+FROM scene_stash_ids
+GROUP BY stash_id
+HAVING COUNT(DISTINCT scene_id) > 1
+ORDER BY MIN(scene_id);
+`
+
 var findAllPhashesQuery = `
 SELECT id, phash
 FROM scenes
-WHERE phash IS NOT NULL
+WHERE phash IS NOT NULL AND ` + sceneNotDeletedClause + `
 ORDER BY size DESC
 `
 
@@ -178,6 +206,54 @@ func (qb *sceneQueryBuilder) ResetOCounter(id int) (int, error) {
 	return scene.OCounter, nil
 }
 
+// ResetCounters resets the counters of each of the scenes to zero. Returns
+// the number of scenes with a counter reset.
+func (qb *sceneQueryBuilder) ResetCounters(sceneIDs []int, counters []models.SceneCounter) (int, error) {
+	var set []string
+	var where []string
+	seen := make(map[models.SceneCounter]bool)
+	for _, c := range counters {
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+
+		switch c {
+		case models.SceneCounterOCounter:
+			set = append(set, "o_counter = 0")
+			where = append(where, "o_counter != 0")
+		case models.SceneCounterPlayCount:
+			set = append(set, "play_count = 0", "last_played_at = NULL")
+			where = append(where, "play_count != 0", "last_played_at IS NOT NULL")
+		default:
+			return 0, fmt.Errorf("invalid scene counter %q", c)
+		}
+	}
+
+	if len(set) == 0 {
+		return 0, nil
+	}
+
+	return qb.updateAll(sceneIDs, strings.Join(set, ", "), nil, strings.Join(where, " OR "))
+}
+
+func (qb *sceneQueryBuilder) IncrementPlayCount(id int, playedAt time.Time) (int, error) {
+	_, err := qb.tx.Exec(
+		`UPDATE scenes SET play_count = play_count + 1, last_played_at = ? WHERE scenes.id = ?`,
+		models.SQLiteTimestamp{Timestamp: playedAt}, id,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	scene, err := qb.find(id)
+	if err != nil {
+		return 0, err
+	}
+
+	return scene.PlayCount, nil
+}
+
 func (qb *sceneQueryBuilder) Destroy(id int) error {
 	// delete all related table rows
 	// TODO - this should be handled by a delete cascade
@@ -191,6 +267,37 @@ func (qb *sceneQueryBuilder) Destroy(id int) error {
 	return qb.destroyExisting([]int{id})
 }
 
+// SoftDestroy marks the scene as deleted without removing it from the
+// database. Soft-deleted scenes are excluded from queries by default. The
+// delete options are stored, to be applied when the scene is purged.
+func (qb *sceneQueryBuilder) SoftDestroy(id int, deleteFile bool, deleteGenerated bool) error {
+	return qb.updateMap(id, map[string]interface{}{
+		"deleted_at": models.NullSQLiteTimestamp{
+			Timestamp: time.Now(),
+			Valid:     true,
+		},
+		"delete_file":      deleteFile,
+		"delete_generated": deleteGenerated,
+	})
+}
+
+// Restore clears the deleted state of a soft-deleted scene.
+func (qb *sceneQueryBuilder) Restore(id int) error {
+	return qb.updateMap(id, map[string]interface{}{
+		"deleted_at":       models.NullSQLiteTimestamp{},
+		"delete_file":      false,
+		"delete_generated": false,
+	})
+}
+
+// FindDeletedBefore returns the soft-deleted scenes that were deleted before
+// the provided time.
+func (qb *sceneQueryBuilder) FindDeletedBefore(t time.Time) ([]*models.Scene, error) {
+	query := selectAll(sceneTable) + "WHERE scenes.deleted_at IS NOT NULL AND datetime(scenes.deleted_at) < datetime(?) ORDER BY scenes.deleted_at ASC"
+	args := []interface{}{models.SQLiteTimestamp{Timestamp: t}}
+	return qb.queryScenes(query, args)
+}
+
 func (qb *sceneQueryBuilder) Find(id int) (*models.Scene, error) {
 	return qb.find(id)
 }
@@ -264,24 +371,41 @@ func (qb *sceneQueryBuilder) FindByMovieID(movieID int) ([]*models.Scene, error)
 
 func (qb *sceneQueryBuilder) CountByMovieID(movieID int) (int, error) {
 	args := []interface{}{movieID}
-	return qb.runCountQuery(qb.buildCountQuery(scenesForMovieQuery), args)
+	return qb.runCountQuery(qb.buildCountQuery(countScenesForMovieQuery), args)
 }
 
 func (qb *sceneQueryBuilder) Count() (int, error) {
-	return qb.runCountQuery(qb.buildCountQuery("SELECT scenes.id FROM scenes"), nil)
+	return qb.runCountQuery(qb.buildCountQuery("SELECT scenes.id FROM scenes WHERE "+sceneNotDeletedClause), nil)
 }
 
 func (qb *sceneQueryBuilder) Size() (float64, error) {
-	return qb.runSumQuery("SELECT SUM(cast(size as double)) as sum FROM scenes", nil)
+	return qb.runSumQuery("SELECT SUM(cast(size as double)) as sum FROM scenes WHERE "+sceneNotDeletedClause, nil)
 }
 
 func (qb *sceneQueryBuilder) Duration() (float64, error) {
-	return qb.runSumQuery("SELECT SUM(cast(duration as double)) as sum FROM scenes", nil)
+	return qb.runSumQuery("SELECT SUM(cast(duration as double)) as sum FROM scenes WHERE "+sceneNotDeletedClause, nil)
+}
+
+func (qb *sceneQueryBuilder) Years() ([]int, error) {
+	var years []string
+	query := "SELECT DISTINCT substr(date, 1, 4) FROM scenes WHERE date IS NOT NULL AND date != '' AND " + sceneNotDeletedClause + " ORDER BY 1"
+	if err := qb.tx.Select(&years, query); err != nil {
+		return nil, err
+	}
+
+	var ret []int
+	for _, y := range years {
+		if year, err := strconv.Atoi(y); err == nil {
+			ret = append(ret, year)
+		}
+	}
+
+	return ret, nil
 }
 
 func (qb *sceneQueryBuilder) CountByStudioID(studioID int) (int, error) {
 	args := []interface{}{studioID}
-	return qb.runCountQuery(qb.buildCountQuery(scenesForStudioQuery), args)
+	return qb.runCountQuery(qb.buildCountQuery(countScenesForStudioQuery), args)
 }
 
 func (qb *sceneQueryBuilder) CountByTagID(tagID int) (int, error) {
@@ -304,12 +428,12 @@ func (qb *sceneQueryBuilder) Wall(q *string) ([]*models.Scene, error) {
 	if q != nil {
 		s = *q
 	}
-	query := selectAll(sceneTable) + "WHERE scenes.details LIKE '%" + s + "%' ORDER BY RANDOM() LIMIT 80"
+	query := selectAll(sceneTable) + "WHERE scenes.details LIKE '%" + s + "%' AND " + sceneNotDeletedClause + " ORDER BY RANDOM() LIMIT 80"
 	return qb.queryScenes(query, nil)
 }
 
 func (qb *sceneQueryBuilder) All() ([]*models.Scene, error) {
-	return qb.queryScenes(selectAll(sceneTable)+qb.getDefaultSceneSort(), nil)
+	return qb.queryScenes(selectAll(sceneTable)+"WHERE "+sceneNotDeletedClause+qb.getDefaultSceneSort(), nil)
 }
 
 func illegalFilterCombination(type1, type2 string) error {
@@ -374,6 +498,7 @@ func (qb *sceneQueryBuilder) makeFilter(sceneFilter *models.SceneFilterType) *fi
 	query.handleCriterion(hasMarkersCriterionHandler(sceneFilter.HasMarkers))
 	query.handleCriterion(sceneIsMissingCriterionHandler(qb, sceneFilter.IsMissing))
 	query.handleCriterion(stringCriterionHandler(sceneFilter.URL, "scenes.url"))
+	query.handleCriterion(stringCriterionHandler(sceneFilter.Date, "scenes.date"))
 
 	query.handleCriterion(criterionHandlerFunc(func(f *filterBuilder) {
 		if sceneFilter.StashID != nil {
@@ -423,6 +548,15 @@ func (qb *sceneQueryBuilder) Query(options models.SceneQueryOptions) (*models.Sc
 
 	query.addFilter(filter)
 
+	// soft-deleted scenes are only returned when explicitly requested
+	switch {
+	case sceneFilter.Deleted != nil && *sceneFilter.Deleted:
+		query.addWhere("scenes.deleted_at IS NOT NULL")
+	case sceneFilter.IncludeDeleted != nil && *sceneFilter.IncludeDeleted:
+	default:
+		query.addWhere(sceneNotDeletedClause)
+	}
+
 	qb.setSceneSort(&query, findFilter)
 	query.sortAndPagination += getPagination(findFilter)
 
@@ -571,6 +705,8 @@ func sceneIsMissingCriterionHandler(qb *sceneQueryBuilder, isMissing *string) cr
 			case "stash_id":
 				qb.stashIDRepository().join(f, "scene_stash_ids", "scenes.id")
 				f.addWhere("scene_stash_ids.scene_id IS NULL")
+			case "file":
+				f.addWhere("scenes.missing_at IS NOT NULL")
 			default:
 				f.addWhere("(scenes." + *isMissing + " IS NULL OR TRIM(scenes." + *isMissing + ") = '')")
 			}
@@ -701,7 +837,7 @@ INNER JOIN (` + valuesClause + `) t ON t.column2 = pt.tag_id
 }
 
 func (qb *sceneQueryBuilder) getDefaultSceneSort() string {
-	return " ORDER BY scenes.path, scenes.date ASC "
+	return " ORDER BY scenes.path, scenes.date ASC" + getIDSort("scenes") + " "
 }
 
 func (qb *sceneQueryBuilder) setSceneSort(query *queryBuilder, findFilter *models.FindFilterType) {
@@ -714,7 +850,7 @@ func (qb *sceneQueryBuilder) setSceneSort(query *queryBuilder, findFilter *model
 	switch sort {
 	case "movie_scene_number":
 		query.join(moviesScenesTable, "movies_join", "scenes.id = movies_join.scene_id")
-		query.sortAndPagination += fmt.Sprintf(" ORDER BY movies_join.scene_index %s", getSortDirection(direction))
+		query.sortAndPagination += fmt.Sprintf(" ORDER BY movies_join.scene_index %s", getSortDirection(direction)) + getIDSort("scenes")
 	case "tag_count":
 		query.sortAndPagination += getCountSort(sceneTable, scenesTagsTable, sceneIDColumn, direction)
 	case "performer_count":
@@ -845,6 +981,18 @@ func (qb *sceneQueryBuilder) UpdateTags(id int, tagIDs []int) error {
 	return qb.tagsRepository().replace(id, tagIDs)
 }
 
+// AddTags adds each of the tags to each of the scenes, ignoring tags that
+// are already present. Returns the number of scene tags added.
+func (qb *sceneQueryBuilder) AddTags(sceneIDs []int, tagIDs []int) (int, error) {
+	return qb.tagsRepository().addAll(sceneIDs, tagIDs)
+}
+
+// RemoveTags removes each of the tags from each of the scenes. Returns the
+// number of scene tags removed.
+func (qb *sceneQueryBuilder) RemoveTags(sceneIDs []int, tagIDs []int) (int, error) {
+	return qb.tagsRepository().removeAll(sceneIDs, tagIDs)
+}
+
 func (qb *sceneQueryBuilder) galleriesRepository() *joinRepository {
 	return &joinRepository{
 		repository: repository{
@@ -879,10 +1027,81 @@ func (qb *sceneQueryBuilder) GetStashIDs(sceneID int) ([]*models.StashID, error)
 	return qb.stashIDRepository().get(sceneID)
 }
 
+// UpdateStashIDs replaces the stash ids of the scene. A scene may only have
+// one stash id for each endpoint.
 func (qb *sceneQueryBuilder) UpdateStashIDs(sceneID int, stashIDs []models.StashID) error {
+	endpoints := make(map[string]bool)
+	for _, id := range stashIDs {
+		if endpoints[id.Endpoint] {
+			return fmt.Errorf("scene %d has more than one stash id for endpoint %s", sceneID, id.Endpoint)
+		}
+		endpoints[id.Endpoint] = true
+	}
+
 	return qb.stashIDRepository().replace(sceneID, stashIDs)
 }
 
+func (qb *sceneQueryBuilder) FindByStashID(stashID models.StashID) ([]*models.Scene, error) {
+	query := selectAll(sceneTable) + `INNER JOIN scene_stash_ids ON scene_stash_ids.scene_id = scenes.id
+WHERE scene_stash_ids.endpoint = ? AND scene_stash_ids.stash_id = ?`
+	args := []interface{}{stashID.Endpoint, stashID.StashID}
+	return qb.queryScenes(query, args)
+}
+
+func (qb *sceneQueryBuilder) FindSharedStashIDs() ([][]int, error) {
+	var ids []string
+	if err := qb.tx.Select(&ids, findSharedStashIDsQuery); err != nil {
+		return nil, err
+	}
+
+	var ret [][]int
+	for _, id := range ids {
+		var sceneIDs []int
+		for _, strID := range strings.Split(id, ",") {
+			if intID, err := strconv.Atoi(strID); err == nil {
+				sceneIDs = append(sceneIDs, intID)
+			}
+		}
+		ret = append(ret, sceneIDs)
+	}
+
+	return ret, nil
+}
+
+func (qb *sceneQueryBuilder) subtitlesRepository() *repository {
+	return &repository{
+		tx:        qb.tx,
+		tableName: sceneSubtitlesTable,
+		idColumn:  sceneIDColumn,
+	}
+}
+
+func (qb *sceneQueryBuilder) GetSubtitles(sceneID int) ([]*models.SceneSubtitle, error) {
+	query := fmt.Sprintf("SELECT language_code, subtitle_type, path FROM %s WHERE %s = ? ORDER BY path", sceneSubtitlesTable, sceneIDColumn)
+	var ret models.SceneSubtitles
+	if err := qb.subtitlesRepository().query(query, []interface{}{sceneID}, &ret); err != nil {
+		return nil, err
+	}
+
+	return []*models.SceneSubtitle(ret), nil
+}
+
+func (qb *sceneQueryBuilder) UpdateSubtitles(sceneID int, subtitles []models.SceneSubtitle) error {
+	r := qb.subtitlesRepository()
+	if err := r.destroy([]int{sceneID}); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s, language_code, subtitle_type, path) VALUES (?, ?, ?, ?)", sceneSubtitlesTable, sceneIDColumn)
+	for _, s := range subtitles {
+		if _, err := r.tx.Exec(query, sceneID, s.LanguageCode, s.SubtitleType, s.Path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (qb *sceneQueryBuilder) FindDuplicates(distance int) ([][]*models.Scene, error) {
 	var dupeIds [][]int
 	if distance == 0 {
@@ -930,3 +1149,23 @@ func (qb *sceneQueryBuilder) FindDuplicates(distance int) ([][]*models.Scene, er
 
 	return duplicates, nil
 }
+
+// FindSimilar returns the scenes with a phash within distance of the
+// provided phash, ordered by increasing distance.
+func (qb *sceneQueryBuilder) FindSimilar(phash int64, distance int) ([]*models.Scene, error) {
+	var hashes []*utils.Phash
+
+	if err := qb.queryFunc(findAllPhashesQuery, nil, false, func(rows *sqlx.Rows) error {
+		var h utils.Phash
+		if err := rows.StructScan(&h); err != nil {
+			return err
+		}
+
+		hashes = append(hashes, &h)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	return qb.FindMany(utils.FindSimilar(hashes, phash, distance))
+}
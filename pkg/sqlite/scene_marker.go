@@ -283,8 +283,7 @@ func (qb *sceneMarkerQueryBuilder) getSceneMarkerSort(query *queryBuilder, findF
 	if sort == "scenes_updated_at" {
 		// ensure scene table is joined
 		query.join(sceneTable, "", "scenes.id = scene_markers.scene_id")
-		sort = "updated_at"
-		tableName = "scenes"
+		return getSort("updated_at", direction, "scenes") + getIDSort(tableName)
 	}
 	return getSort(sort, direction, tableName)
 }
@@ -8,11 +8,14 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/scene"
 	"github.com/stashapp/stash/pkg/utils"
 )
 
@@ -1385,6 +1388,76 @@ func TestSceneQueryPagination(t *testing.T) {
 	})
 }
 
+func TestSceneQueryStableSort(t *testing.T) {
+	perPage := models.PerPageAll
+	sort := "rating"
+	findFilter := models.FindFilterType{
+		PerPage: &perPage,
+		Sort:    &sort,
+	}
+
+	withTxn(func(r models.Repository) error {
+		sqb := r.Scene()
+		scenes := queryScene(t, sqb, nil, &findFilter)
+
+		// scenes with equal sort values are ordered by id
+		for i := 1; i < len(scenes); i++ {
+			prev, cur := scenes[i-1], scenes[i]
+			if prev.Rating == cur.Rating && prev.Bitrate == cur.Bitrate && prev.Framerate == cur.Framerate && prev.Duration == cur.Duration {
+				assert.Less(t, prev.ID, cur.ID)
+			}
+		}
+
+		// pages do not overlap
+		var paged []*models.Scene
+		perPage = 3
+		for page := 1; len(paged) < len(scenes); page++ {
+			page := page
+			findFilter.Page = &page
+			results := queryScene(t, sqb, nil, &findFilter)
+			if len(results) == 0 {
+				break
+			}
+			paged = append(paged, results...)
+		}
+		assert.Equal(t, scenes, paged)
+
+		perPage = models.PerPageAll
+		findFilter.Page = nil
+
+		// unsorted queries return the same order each time
+		findFilter.Sort = nil
+		first := queryScene(t, sqb, nil, &findFilter)
+		second := queryScene(t, sqb, nil, &findFilter)
+		assert.Equal(t, first, second)
+
+		return nil
+	})
+}
+
+func TestSceneQueryMaxPageSize(t *testing.T) {
+	models.SetMaxPerPage(2)
+	defer models.SetMaxPerPage(models.DefaultMaxPerPage)
+
+	perPage := 100
+	findFilter := models.FindFilterType{
+		PerPage: &perPage,
+	}
+
+	withTxn(func(r models.Repository) error {
+		scenes := queryScene(t, r.Scene(), nil, findFilter.CapPageSize())
+
+		// oversized pages of API filters are capped
+		assert.Len(t, scenes, 2)
+
+		// internal batch queries are not capped
+		scenes = queryScene(t, r.Scene(), nil, models.BatchFindFilter(3))
+		assert.Len(t, scenes, 3)
+
+		return nil
+	})
+}
+
 func TestSceneQueryTagCount(t *testing.T) {
 	const tagCount = 1
 	tagCountCriterion := models.IntCriterionInput{
@@ -1491,6 +1564,20 @@ func TestSceneCountByTagID(t *testing.T) {
 	})
 }
 
+func TestSceneYears(t *testing.T) {
+	withTxn(func(r models.Repository) error {
+		years, err := r.Scene().Years()
+		if err != nil {
+			t.Errorf("error calling Years: %s", err.Error())
+		}
+
+		// null and empty dates are excluded
+		assert.Equal(t, []int{1, 2001}, years)
+
+		return nil
+	})
+}
+
 func TestSceneCountByMovieID(t *testing.T) {
 	withTxn(func(r models.Repository) error {
 		sqb := r.Scene()
@@ -1589,6 +1676,176 @@ func TestFindByPerformerID(t *testing.T) {
 	})
 }
 
+func TestSceneUpdateResumeTime(t *testing.T) {
+	if err := withTxn(func(r models.Repository) error {
+		qb := r.Scene()
+
+		const name = "TestSceneUpdateResumeTime"
+		created, err := qb.Create(models.Scene{
+			Path:     name,
+			Checksum: sql.NullString{String: utils.MD5FromString(name), Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("Error creating scene: %s", err.Error())
+		}
+
+		resume := sql.NullFloat64{Float64: 123.5, Valid: true}
+		if _, err := qb.Update(models.ScenePartial{ID: created.ID, ResumeTime: &resume}); err != nil {
+			return fmt.Errorf("Error updating scene: %s", err.Error())
+		}
+
+		stored, err := qb.Find(created.ID)
+		if err != nil {
+			return fmt.Errorf("Error finding scene: %s", err.Error())
+		}
+		assert.Equal(t, resume, stored.ResumeTime)
+
+		// clear the resume time
+		if _, err := qb.Update(models.ScenePartial{ID: created.ID, ResumeTime: &sql.NullFloat64{}}); err != nil {
+			return fmt.Errorf("Error updating scene: %s", err.Error())
+		}
+
+		stored, err = qb.Find(created.ID)
+		if err != nil {
+			return fmt.Errorf("Error finding scene: %s", err.Error())
+		}
+		assert.False(t, stored.ResumeTime.Valid)
+
+		return nil
+	}); err != nil {
+		t.Error(err.Error())
+	}
+}
+
+func TestSceneIncrementPlayCount(t *testing.T) {
+	const name = "TestSceneIncrementPlayCount"
+	var sceneID int
+	if err := withTxn(func(r models.Repository) error {
+		created, err := r.Scene().Create(models.Scene{
+			Path:     name,
+			Checksum: sql.NullString{String: utils.MD5FromString(name), Valid: true},
+		})
+		if err != nil {
+			return fmt.Errorf("Error creating scene: %s", err.Error())
+		}
+		sceneID = created.ID
+		return nil
+	}); err != nil {
+		t.Fatal(err.Error())
+	}
+
+	const plays = 20
+	playedAt := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	var wg sync.WaitGroup
+	for i := 0; i < plays; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := withTxn(func(r models.Repository) error {
+				_, err := r.Scene().IncrementPlayCount(sceneID, playedAt)
+				return err
+			}); err != nil {
+				t.Errorf("Error incrementing play count: %s", err.Error())
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := withTxn(func(r models.Repository) error {
+		stored, err := r.Scene().Find(sceneID)
+		if err != nil {
+			return fmt.Errorf("Error finding scene: %s", err.Error())
+		}
+
+		// concurrent increments are not lost
+		assert.Equal(t, plays, stored.PlayCount)
+		assert.True(t, stored.LastPlayedAt.Valid)
+		assert.True(t, playedAt.Equal(stored.LastPlayedAt.Timestamp))
+
+		count, err := r.Scene().IncrementPlayCount(sceneID, playedAt)
+		if err != nil {
+			return fmt.Errorf("Error incrementing play count: %s", err.Error())
+		}
+		assert.Equal(t, plays+1, count)
+
+		return nil
+	}); err != nil {
+		t.Error(err.Error())
+	}
+}
+
+func TestSceneBulkResetCounters(t *testing.T) {
+	if err := withRollbackTxn(func(r models.Repository) error {
+		qb := r.Scene()
+
+		const prefix = "TestSceneBulkResetCounters"
+		playedAt := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+		var ids []int
+		for i := 0; i < 3; i++ {
+			name := fmt.Sprintf("%s_%d", prefix, i)
+			created, err := qb.Create(models.Scene{
+				Path:     name,
+				Checksum: sql.NullString{String: utils.MD5FromString(name), Valid: true},
+			})
+			if err != nil {
+				return fmt.Errorf("Error creating scene: %s", err.Error())
+			}
+			ids = append(ids, created.ID)
+		}
+
+		// leave the first scene unplayed so that only the others are reset
+		for _, id := range ids[1:] {
+			if _, err := qb.IncrementOCounter(id); err != nil {
+				return err
+			}
+			if _, err := qb.IncrementPlayCount(id, playedAt); err != nil {
+				return err
+			}
+		}
+
+		sceneFilter := &models.SceneFilterType{
+			Path: &models.StringCriterionInput{
+				Value:    prefix + "%",
+				Modifier: models.CriterionModifierEquals,
+			},
+		}
+
+		reset, err := scene.BulkResetCounters(qb, nil, sceneFilter, []models.SceneCounter{models.SceneCounterOCounter})
+		if err != nil {
+			return fmt.Errorf("Error resetting counters: %s", err.Error())
+		}
+		assert.Equal(t, 2, reset)
+
+		stored, err := qb.Find(ids[1])
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, 0, stored.OCounter)
+		assert.Equal(t, 1, stored.PlayCount)
+
+		reset, err = scene.BulkResetCounters(qb, ids[2:], nil, []models.SceneCounter{models.SceneCounterOCounter, models.SceneCounterPlayCount})
+		if err != nil {
+			return fmt.Errorf("Error resetting counters: %s", err.Error())
+		}
+		assert.Equal(t, 1, reset)
+
+		stored, err = qb.Find(ids[2])
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, 0, stored.PlayCount)
+		assert.False(t, stored.LastPlayedAt.Valid)
+
+		_, err = scene.BulkResetCounters(qb, ids, nil, []models.SceneCounter{"invalid"})
+		assert.NotNil(t, err)
+
+		return nil
+	}); err != nil {
+		t.Error(err.Error())
+	}
+}
+
 func TestSceneUpdateSceneCover(t *testing.T) {
 	if err := withTxn(func(r models.Repository) error {
 		qb := r.Scene()
@@ -1690,6 +1947,129 @@ func TestSceneStashIDs(t *testing.T) {
 	}
 }
 
+func TestSceneMultiEndpointStashIDs(t *testing.T) {
+	if err := withRollbackTxn(func(r models.Repository) error {
+		qb := r.Scene()
+
+		createScene := func(name string) (*models.Scene, error) {
+			return qb.Create(models.Scene{
+				Path:     name,
+				Checksum: sql.NullString{String: utils.MD5FromString(name), Valid: true},
+			})
+		}
+
+		scene1, err := createScene("TestSceneMultiEndpointStashIDs1")
+		if err != nil {
+			return err
+		}
+		scene2, err := createScene("TestSceneMultiEndpointStashIDs2")
+		if err != nil {
+			return err
+		}
+
+		const (
+			endpoint1 = "endpoint1"
+			endpoint2 = "endpoint2"
+			sharedID  = "sharedID"
+		)
+
+		// a scene may have a stash id for each endpoint
+		stashIDs := []models.StashID{
+			{StashID: "stashID1", Endpoint: endpoint1},
+			{StashID: sharedID, Endpoint: endpoint2},
+		}
+		if err := qb.UpdateStashIDs(scene1.ID, stashIDs); err != nil {
+			return err
+		}
+		testStashIDs(t, qb, scene1.ID, []*models.StashID{&stashIDs[0], &stashIDs[1]})
+
+		// but only one for each endpoint
+		err = qb.UpdateStashIDs(scene1.ID, []models.StashID{
+			{StashID: "stashID1", Endpoint: endpoint1},
+			{StashID: "stashID2", Endpoint: endpoint1},
+		})
+		assert.NotNil(t, err)
+
+		found, err := qb.FindByStashID(stashIDs[0])
+		if err != nil {
+			return err
+		}
+		assert.Len(t, found, 1)
+		assert.Equal(t, scene1.ID, found[0].ID)
+
+		// the stash id must match on the same endpoint
+		found, err = qb.FindByStashID(models.StashID{StashID: "stashID1", Endpoint: endpoint2})
+		if err != nil {
+			return err
+		}
+		assert.Len(t, found, 0)
+
+		// a stash id shared across endpoints makes the scenes duplicates
+		if err := qb.UpdateStashIDs(scene2.ID, []models.StashID{
+			{StashID: sharedID, Endpoint: endpoint1},
+		}); err != nil {
+			return err
+		}
+
+		shared, err := qb.FindSharedStashIDs()
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, [][]int{{scene1.ID, scene2.ID}}, shared)
+
+		return nil
+	}); err != nil {
+		t.Error(err.Error())
+	}
+}
+
+func TestSceneSubtitles(t *testing.T) {
+	if err := withTxn(func(r models.Repository) error {
+		qb := r.Scene()
+
+		// create scene to test against
+		const name = "TestSceneSubtitles"
+		scene := models.Scene{
+			Path:     name,
+			Checksum: sql.NullString{String: utils.MD5FromString(name), Valid: true},
+		}
+		created, err := qb.Create(scene)
+		if err != nil {
+			return fmt.Errorf("Error creating scene: %s", err.Error())
+		}
+
+		subtitles := []models.SceneSubtitle{
+			{LanguageCode: "en", SubtitleType: "srt", Path: name + ".en.srt"},
+			{SubtitleType: "vtt", Path: name + ".vtt"},
+		}
+
+		if err := qb.UpdateSubtitles(created.ID, subtitles); err != nil {
+			return fmt.Errorf("Error updating subtitles: %s", err.Error())
+		}
+
+		stored, err := qb.GetSubtitles(created.ID)
+		if err != nil {
+			return fmt.Errorf("Error getting subtitles: %s", err.Error())
+		}
+		assert.Equal(t, []*models.SceneSubtitle{&subtitles[0], &subtitles[1]}, stored)
+
+		// replace existing subtitles
+		if err := qb.UpdateSubtitles(created.ID, subtitles[1:]); err != nil {
+			return fmt.Errorf("Error updating subtitles: %s", err.Error())
+		}
+
+		stored, err = qb.GetSubtitles(created.ID)
+		if err != nil {
+			return fmt.Errorf("Error getting subtitles: %s", err.Error())
+		}
+		assert.Equal(t, []*models.SceneSubtitle{&subtitles[1]}, stored)
+
+		return nil
+	}); err != nil {
+		t.Error(err.Error())
+	}
+}
+
 func TestSceneQueryQTrim(t *testing.T) {
 	if err := withTxn(func(r models.Repository) error {
 		qb := r.Scene()
@@ -1742,3 +2122,332 @@ func TestSceneQueryQTrim(t *testing.T) {
 // TODO Count
 // TODO SizeCount
 // TODO All
+
+func TestSceneSoftDestroy(t *testing.T) {
+	if err := withTxn(func(r models.Repository) error {
+		qb := r.Scene()
+
+		// create scene to test against
+		const name = "TestSceneSoftDestroy"
+		studioID := studioIDs[studioIdxWithScene]
+		scene := models.Scene{
+			Path:     name,
+			Checksum: sql.NullString{String: utils.MD5FromString(name), Valid: true},
+			StudioID: sql.NullInt64{Int64: int64(studioID), Valid: true},
+		}
+		created, err := qb.Create(scene)
+		if err != nil {
+			return fmt.Errorf("Error creating scene: %s", err.Error())
+		}
+
+		countBefore, err := qb.Count()
+		if err != nil {
+			return err
+		}
+		studioCountBefore, err := qb.CountByStudioID(studioID)
+		if err != nil {
+			return err
+		}
+
+		if err := qb.SoftDestroy(created.ID, true, true); err != nil {
+			return fmt.Errorf("Error soft deleting scene: %s", err.Error())
+		}
+
+		// scene should still be found by id, with the delete options stored
+		found, err := qb.Find(created.ID)
+		if err != nil {
+			return err
+		}
+		assert.True(t, found.IsDeleted())
+		assert.True(t, found.DeleteFile)
+		assert.True(t, found.DeleteGenerated)
+
+		studioCount, err := qb.CountByStudioID(studioID)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, studioCountBefore-1, studioCount)
+
+		count, err := qb.Count()
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, countBefore-1, count)
+
+		// scene should be excluded from the default query
+		pathCriterion := models.StringCriterionInput{
+			Value:    name,
+			Modifier: models.CriterionModifierEquals,
+		}
+		sceneFilter := models.SceneFilterType{
+			Path: &pathCriterion,
+		}
+		scenes := queryScene(t, qb, &sceneFilter, nil)
+		assert.Len(t, scenes, 0)
+
+		includeDeleted := true
+		sceneFilter.IncludeDeleted = &includeDeleted
+		scenes = queryScene(t, qb, &sceneFilter, nil)
+		assert.Len(t, scenes, 1)
+		sceneFilter.IncludeDeleted = nil
+
+		deleted := true
+		sceneFilter.Deleted = &deleted
+		scenes = queryScene(t, qb, &sceneFilter, nil)
+		assert.Len(t, scenes, 1)
+
+		purgeable, err := qb.FindDeletedBefore(time.Now().Add(time.Hour))
+		if err != nil {
+			return err
+		}
+		assert.Len(t, purgeable, 1)
+
+		purgeable, err = qb.FindDeletedBefore(time.Now().Add(-time.Hour))
+		if err != nil {
+			return err
+		}
+		assert.Len(t, purgeable, 0)
+
+		if err := qb.Restore(created.ID); err != nil {
+			return fmt.Errorf("Error restoring scene: %s", err.Error())
+		}
+
+		found, err = qb.Find(created.ID)
+		if err != nil {
+			return err
+		}
+		assert.False(t, found.DeleteFile)
+		assert.False(t, found.DeleteGenerated)
+
+		sceneFilter.Deleted = nil
+		scenes = queryScene(t, qb, &sceneFilter, nil)
+		assert.Len(t, scenes, 1)
+
+		return qb.Destroy(created.ID)
+	}); err != nil {
+		t.Error(err.Error())
+	}
+}
+
+func TestSceneBulkTags(t *testing.T) {
+	if err := withRollbackTxn(func(r models.Repository) error {
+		qb := r.Scene()
+
+		const prefix = "TestSceneBulkTags"
+		var ids []int
+		for i := 0; i < 3; i++ {
+			name := fmt.Sprintf("%s_%d", prefix, i)
+			created, err := qb.Create(models.Scene{
+				Path:     name,
+				Checksum: sql.NullString{String: utils.MD5FromString(name), Valid: true},
+			})
+			if err != nil {
+				return fmt.Errorf("Error creating scene: %s", err.Error())
+			}
+			ids = append(ids, created.ID)
+		}
+
+		tag, err := r.Tag().Create(models.Tag{Name: prefix})
+		if err != nil {
+			return fmt.Errorf("Error creating tag: %s", err.Error())
+		}
+		tagIDs := []int{tag.ID}
+
+		// tag one scene up front so that only the others are added
+		if err := qb.UpdateTags(ids[0], tagIDs); err != nil {
+			return err
+		}
+
+		sceneFilter := &models.SceneFilterType{
+			Path: &models.StringCriterionInput{
+				Value:    prefix + "%",
+				Modifier: models.CriterionModifierEquals,
+			},
+		}
+
+		added, err := scene.BulkAddTags(qb, nil, sceneFilter, tagIDs)
+		if err != nil {
+			return fmt.Errorf("Error adding tags: %s", err.Error())
+		}
+		assert.Equal(t, 2, added)
+
+		// adding again should be a no-op
+		added, err = scene.BulkAddTags(qb, nil, sceneFilter, tagIDs)
+		if err != nil {
+			return fmt.Errorf("Error adding tags: %s", err.Error())
+		}
+		assert.Equal(t, 0, added)
+
+		count, err := qb.CountByTagID(tag.ID)
+		if err != nil {
+			return err
+		}
+		assert.Equal(t, 3, count)
+
+		removed, err := scene.BulkRemoveTags(qb, ids[:1], nil, tagIDs)
+		if err != nil {
+			return fmt.Errorf("Error removing tags: %s", err.Error())
+		}
+		assert.Equal(t, 1, removed)
+
+		removed, err = scene.BulkRemoveTags(qb, nil, sceneFilter, tagIDs)
+		if err != nil {
+			return fmt.Errorf("Error removing tags: %s", err.Error())
+		}
+		assert.Equal(t, 2, removed)
+
+		// removing again should be a no-op
+		removed, err = scene.BulkRemoveTags(qb, nil, sceneFilter, tagIDs)
+		if err != nil {
+			return fmt.Errorf("Error removing tags: %s", err.Error())
+		}
+		assert.Equal(t, 0, removed)
+
+		// lists longer than the sqlite variable limit are batched
+		var manyIDs []int
+		for i := 0; i < 40000; i++ {
+			manyIDs = append(manyIDs, ids[i%len(ids)])
+		}
+		added, err = qb.AddTags(manyIDs, tagIDs)
+		if err != nil {
+			return fmt.Errorf("Error adding tags: %s", err.Error())
+		}
+		assert.Equal(t, 3, added)
+
+		manyTagIDs := make([]int, 40000)
+		for i := range manyTagIDs {
+			manyTagIDs[i] = tag.ID
+		}
+		removed, err = qb.RemoveTags(ids, manyTagIDs)
+		if err != nil {
+			return fmt.Errorf("Error removing tags: %s", err.Error())
+		}
+		assert.Equal(t, 3, removed)
+
+		return nil
+	}); err != nil {
+		t.Error(err.Error())
+	}
+}
+
+func TestSceneFindSimilar(t *testing.T) {
+	if err := withRollbackTxn(func(r models.Repository) error {
+		qb := r.Scene()
+
+		const hash = int64(0x1234567890abcdef)
+		phashes := []int64{hash ^ 0x3, hash, hash ^ 0xffff}
+		var ids []int
+		for i, phash := range phashes {
+			name := fmt.Sprintf("TestSceneFindSimilar_%d", i)
+			created, err := qb.Create(models.Scene{
+				Path:     name,
+				Checksum: sql.NullString{String: utils.MD5FromString(name), Valid: true},
+				Phash:    sql.NullInt64{Int64: phash, Valid: true},
+			})
+			if err != nil {
+				return fmt.Errorf("Error creating scene: %s", err.Error())
+			}
+			ids = append(ids, created.ID)
+		}
+
+		scenes, err := qb.FindSimilar(hash, 2)
+		if err != nil {
+			return fmt.Errorf("Error finding similar scenes: %s", err.Error())
+		}
+
+		// closest first
+		if assert.Len(t, scenes, 2) {
+			assert.Equal(t, ids[1], scenes[0].ID)
+			assert.Equal(t, ids[0], scenes[1].ID)
+		}
+
+		return nil
+	}); err != nil {
+		t.Error(err.Error())
+	}
+}
+
+func TestSceneSoftDestroyExcludedFromLookups(t *testing.T) {
+	if err := withRollbackTxn(func(r models.Repository) error {
+		qb := r.Scene()
+
+		const hash = int64(0x0fedcba987654321)
+		performerID := performerIDs[performerIdx1WithScene]
+		movieID := movieIDs[movieIdxWithScene]
+
+		var ids []int
+		for i := 0; i < 3; i++ {
+			name := fmt.Sprintf("TestSceneSoftDestroyExcludedFromLookups_%d", i)
+			created, err := qb.Create(models.Scene{
+				Path:     name,
+				Checksum: sql.NullString{String: utils.MD5FromString(name), Valid: true},
+				Phash:    sql.NullInt64{Int64: hash, Valid: true},
+			})
+			if err != nil {
+				return fmt.Errorf("Error creating scene: %s", err.Error())
+			}
+			if err := qb.UpdatePerformers(created.ID, []int{performerID}); err != nil {
+				return err
+			}
+			if err := qb.UpdateMovies(created.ID, []models.MoviesScenes{{MovieID: movieID, SceneID: created.ID}}); err != nil {
+				return err
+			}
+			ids = append(ids, created.ID)
+		}
+
+		deletedID := ids[0]
+		if err := qb.SoftDestroy(deletedID, false, false); err != nil {
+			return fmt.Errorf("Error soft deleting scene: %s", err.Error())
+		}
+
+		sceneIDs := func(scenes []*models.Scene) []int {
+			var ret []int
+			for _, s := range scenes {
+				ret = append(ret, s.ID)
+			}
+			return ret
+		}
+
+		for _, distance := range []int{0, 1} {
+			duplicates, err := qb.FindDuplicates(distance)
+			if err != nil {
+				return err
+			}
+
+			found := false
+			for _, group := range duplicates {
+				groupIDs := sceneIDs(group)
+				assert.NotContains(t, groupIDs, deletedID)
+				if utils.IntInclude(groupIDs, ids[1]) {
+					found = true
+					assert.Contains(t, groupIDs, ids[2])
+				}
+			}
+			assert.True(t, found, "duplicates not found with distance %d", distance)
+		}
+
+		similar, err := qb.FindSimilar(hash, 0)
+		if err != nil {
+			return err
+		}
+		assert.ElementsMatch(t, ids[1:], sceneIDs(similar))
+
+		byPerformer, err := qb.FindByPerformerID(performerID)
+		if err != nil {
+			return err
+		}
+		assert.NotContains(t, sceneIDs(byPerformer), deletedID)
+		assert.Contains(t, sceneIDs(byPerformer), ids[1])
+
+		byMovie, err := qb.FindByMovieID(movieID)
+		if err != nil {
+			return err
+		}
+		assert.NotContains(t, sceneIDs(byMovie), deletedID)
+		assert.Contains(t, sceneIDs(byMovie), ids[1])
+
+		return nil
+	}); err != nil {
+		t.Error(err.Error())
+	}
+}
@@ -62,10 +62,10 @@ func getSort(sort string, direction string, tableName string) string {
 	case strings.HasSuffix(sort, "_count"):
 		var relationTableName = strings.TrimSuffix(sort, "_count") // TODO: pluralize?
 		colName := getColumn(relationTableName, "id")
-		return " ORDER BY COUNT(distinct " + colName + ") " + direction
+		return " ORDER BY COUNT(distinct " + colName + ") " + direction + getIDSort(tableName)
 	case strings.Compare(sort, "filesize") == 0:
 		colName := getColumn(tableName, "size")
-		return " ORDER BY cast(" + colName + " as integer) " + direction
+		return " ORDER BY cast(" + colName + " as integer) " + direction + getIDSort(tableName)
 	case strings.HasPrefix(sort, randomSeedPrefix):
 		// seed as a parameter from the UI
 		// turn the provided seed into a float
@@ -86,6 +86,9 @@ func getSort(sort string, direction string, tableName string) string {
 		} else if tableName == "scene_markers" {
 			additional = ", scene_markers.scene_id ASC, scene_markers.seconds ASC"
 		}
+		if sort != "id" {
+			additional += getIDSort(tableName)
+		}
 		if strings.Compare(sort, "name") == 0 {
 			return " ORDER BY " + colName + " COLLATE NOCASE " + direction + additional
 		}
@@ -97,15 +100,38 @@ func getSort(sort string, direction string, tableName string) string {
 	}
 }
 
+// getIDSort returns the sort term ordering by the id of tableName. It is
+// appended to sorts on other columns, so that rows with equal sort values are
+// returned in a stable order and pages do not overlap.
+func getIDSort(tableName string) string {
+	return ", " + getColumn(tableName, "id") + " ASC"
+}
+
 func getRandomSort(tableName string, direction string, seed float64) string {
 	// https://stackoverflow.com/a/24511461
 	colName := getColumn(tableName, "id")
 	randomSortString := strconv.FormatFloat(seed, 'f', 16, 32)
-	return " ORDER BY " + "(substr(" + colName + " * " + randomSortString + ", length(" + colName + ") + 2))" + " " + direction
+	return " ORDER BY " + "(substr(" + colName + " * " + randomSortString + ", length(" + colName + ") + 2))" + " " + direction + getIDSort(tableName)
 }
 
 func getCountSort(primaryTable, joinTable, primaryFK, direction string) string {
-	return fmt.Sprintf(" ORDER BY (SELECT COUNT(*) FROM %s WHERE %s = %s.id) %s", joinTable, primaryFK, primaryTable, getSortDirection(direction))
+	return fmt.Sprintf(" ORDER BY (SELECT COUNT(*) FROM %s WHERE %s = %s.id) %s", joinTable, primaryFK, primaryTable, getSortDirection(direction)) + getIDSort(primaryTable)
+}
+
+// getSceneCountSort is getCountSort for joinTable rows joined to scenes,
+// excluding soft-deleted scenes from the count.
+func getSceneCountSort(primaryTable, joinTable, primaryFK, direction string) string {
+	return fmt.Sprintf(" ORDER BY %s %s", sceneCountSubquery(primaryTable, joinTable, primaryFK), getSortDirection(direction)) + getIDSort(primaryTable)
+}
+
+// sceneCountSubquery returns a subquery counting the live scenes related to
+// the primary table through joinTable. joinTable may be the scenes table
+// itself.
+func sceneCountSubquery(primaryTable, joinTable, primaryFK string) string {
+	if joinTable == sceneTable {
+		return fmt.Sprintf("(SELECT COUNT(*) FROM %s WHERE %s.%s = %s.id AND %s)", sceneTable, sceneTable, primaryFK, primaryTable, sceneNotDeletedClause)
+	}
+	return fmt.Sprintf("(SELECT COUNT(*) FROM %s s JOIN %s ON %s.id = s.%s WHERE s.%s = %s.id AND %s)", joinTable, sceneTable, sceneTable, sceneIDColumn, primaryFK, primaryTable, sceneNotDeletedClause)
 }
 
 func getSearchBinding(columns []string, q string, not bool) (string, []interface{}) {
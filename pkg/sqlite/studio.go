@@ -66,6 +66,122 @@ func (qb *studioQueryBuilder) Destroy(id int) error {
 	return qb.destroyExisting([]int{id})
 }
 
+// MergeCounts returns the number of objects that would be reassigned by
+// merging the source studios into the destination studio.
+func (qb *studioQueryBuilder) MergeCounts(source []int, destination int) (*models.MergeCounts, error) {
+	ret := &models.MergeCounts{}
+	if len(source) == 0 {
+		return ret, nil
+	}
+
+	for _, id := range source {
+		if id == destination {
+			return nil, errors.New("cannot merge where source == destination")
+		}
+	}
+
+	var err error
+	if ret.Scenes, err = qb.countDistinctIn(sceneTable, idColumn, studioIDColumn, source); err != nil {
+		return nil, err
+	}
+	if ret.Images, err = qb.countDistinctIn(imageTable, idColumn, studioIDColumn, source); err != nil {
+		return nil, err
+	}
+	if ret.Galleries, err = qb.countDistinctIn(galleryTable, idColumn, studioIDColumn, source); err != nil {
+		return nil, err
+	}
+
+	// source names are added as aliases along with the source aliases
+	if ret.Aliases, err = qb.countMergeAliases(source, destination); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// countMergeAliases returns the number of distinct names and aliases of the
+// source studios that are not the name or an alias of the destination
+// studio.
+func (qb *studioQueryBuilder) countMergeAliases(source []int, destination int) (int, error) {
+	inBinding := getInBinding(len(source))
+
+	query := `SELECT COUNT(*) as count FROM (
+SELECT name AS alias FROM ` + studioTable + ` WHERE id IN ` + inBinding + `
+UNION
+SELECT ` + studioAliasColumn + ` AS alias FROM ` + studioAliasesTable + ` WHERE ` + studioIDColumn + ` IN ` + inBinding + `
+) WHERE alias NOT IN (
+SELECT name FROM ` + studioTable + ` WHERE id = ?
+UNION
+SELECT ` + studioAliasColumn + ` FROM ` + studioAliasesTable + ` WHERE ` + studioIDColumn + ` = ?
+)`
+
+	var args []interface{}
+	for i := 0; i < 2; i++ {
+		for _, id := range source {
+			args = append(args, id)
+		}
+	}
+	args = append(args, destination, destination)
+
+	return qb.runCountQuery(query, args)
+}
+
+// Merge reassigns the scenes, images, galleries, movies and child studios of
+// the source studios to the destination studio, adds the source names and
+// aliases to the destination aliases and then destroys the source studios.
+func (qb *studioQueryBuilder) Merge(source []int, destination int) error {
+	if len(source) == 0 {
+		return nil
+	}
+
+	inBinding := getInBinding(len(source))
+
+	args := []interface{}{destination}
+	for _, id := range source {
+		if id == destination {
+			return errors.New("cannot merge where source == destination")
+		}
+		args = append(args, id)
+	}
+
+	for _, table := range []string{sceneTable, imageTable, galleryTable, movieTable} {
+		_, err := qb.tx.Exec("UPDATE "+table+" SET studio_id = ? WHERE studio_id IN "+inBinding, args...)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the destination cannot become its own parent
+	_, err := qb.tx.Exec("UPDATE "+studioTable+" SET parent_id = NULL WHERE id = ? AND parent_id IN "+inBinding, args...)
+	if err != nil {
+		return err
+	}
+
+	_, err = qb.tx.Exec("UPDATE "+studioTable+" SET parent_id = ? WHERE parent_id IN "+inBinding, args...)
+	if err != nil {
+		return err
+	}
+
+	// source names that are the destination name are skipped
+	_, err = qb.tx.Exec("INSERT OR IGNORE INTO "+studioAliasesTable+" (studio_id, alias) SELECT ?, name FROM "+studioTable+" WHERE id IN "+inBinding+" AND name != (SELECT name FROM "+studioTable+" WHERE id = ?)", append(args, destination)...)
+	if err != nil {
+		return err
+	}
+
+	_, err = qb.tx.Exec("UPDATE OR IGNORE "+studioAliasesTable+" SET studio_id = ? WHERE studio_id IN "+inBinding, args...)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range source {
+		if err := qb.Destroy(id); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (qb *studioQueryBuilder) Find(id int) (*models.Studio, error) {
 	var ret models.Studio
 	if err := qb.get(id, &ret); err != nil {
@@ -293,7 +409,7 @@ func studioIsMissingCriterionHandler(qb *studioQueryBuilder, isMissing *string)
 func studioSceneCountCriterionHandler(qb *studioQueryBuilder, sceneCount *models.IntCriterionInput) criterionHandlerFunc {
 	return func(f *filterBuilder) {
 		if sceneCount != nil {
-			f.addLeftJoin("scenes", "", "scenes.studio_id = studios.id")
+			f.addLeftJoin("scenes", "", "scenes.studio_id = studios.id AND "+sceneNotDeletedClause)
 			clause, args := getIntCriterionWhereClause("count(distinct scenes.id)", *sceneCount)
 
 			f.addHaving(clause, args...)
@@ -363,7 +479,7 @@ func (qb *studioQueryBuilder) getStudioSort(findFilter *models.FindFilterType) s
 
 	switch sort {
 	case "scenes_count":
-		return getCountSort(studioTable, sceneTable, studioIDColumn, direction)
+		return getSceneCountSort(studioTable, sceneTable, studioIDColumn, direction)
 	case "images_count":
 		return getCountSort(studioTable, imageTable, studioIDColumn, direction)
 	case "galleries_count":
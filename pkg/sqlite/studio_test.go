@@ -926,3 +926,110 @@ func TestStudioQueryFast(t *testing.T) {
 // TODO All
 // TODO AllSlim
 // TODO Query
+
+func TestStudioMerge(t *testing.T) {
+	assert := assert.New(t)
+
+	// merge tests - perform these in a transaction that we'll rollback
+	if err := withRollbackTxn(func(r models.Repository) error {
+		qb := r.Studio()
+
+		// try merging into same studio
+		err := qb.Merge([]int{studioIDs[studioIdxWithScene]}, studioIDs[studioIdxWithScene])
+		assert.NotNil(err)
+
+		srcIdxs := []int{
+			studioIdxWithTwoScenes,
+			studioIdxWithImage,
+			studioIdxWithMovie,
+			studioIdxWithChildStudio,
+		}
+		var srcIDs []int
+		for _, idx := range srcIdxs {
+			srcIDs = append(srcIDs, studioIDs[idx])
+		}
+
+		destID := studioIDs[studioIdxWithScene]
+
+		_, err = qb.MergeCounts(srcIDs, srcIDs[0])
+		assert.NotNil(err)
+
+		// the name of a source is already an alias of the destination
+		if err := qb.UpdateAliases(destID, []string{
+			getStudioStringValue(studioIdxWithScene, "Alias"),
+			getStudioStringValue(srcIdxs[0], "Name"),
+		}); err != nil {
+			return err
+		}
+
+		counts, err := qb.MergeCounts(srcIDs, destID)
+		if err != nil {
+			return err
+		}
+		// each studio has a single alias
+		assert.Equal(&models.MergeCounts{
+			Scenes:    2,
+			Images:    1,
+			Galleries: 0,
+			Aliases:   len(srcIdxs)*2 - 1,
+		}, counts)
+
+		if err = qb.Merge(srcIDs, destID); err != nil {
+			return err
+		}
+
+		// ensure other studios are deleted
+		for _, id := range srcIDs {
+			s, err := qb.Find(id)
+			if err != nil {
+				return err
+			}
+
+			assert.Nil(s)
+		}
+
+		// ensure names and aliases are set on the destination
+		destAliases, err := qb.GetAliases(destID)
+		if err != nil {
+			return err
+		}
+		for _, idx := range srcIdxs {
+			assert.Contains(destAliases, getStudioStringValue(idx, "Name"))
+			assert.Contains(destAliases, getStudioStringValue(idx, "Alias"))
+		}
+		// the destination had an alias, which is kept
+		assert.Len(destAliases, counts.Aliases+2)
+
+		for _, idx := range []int{sceneIdx1WithStudio, sceneIdx2WithStudio} {
+			s, err := r.Scene().Find(sceneIDs[idx])
+			if err != nil {
+				return err
+			}
+
+			assert.Equal(int64(destID), s.StudioID.Int64)
+		}
+
+		i, err := r.Image().Find(imageIDs[imageIdxWithStudio])
+		if err != nil {
+			return err
+		}
+		assert.Equal(int64(destID), i.StudioID.Int64)
+
+		m, err := r.Movie().Find(movieIDs[movieIdxWithStudio])
+		if err != nil {
+			return err
+		}
+		assert.Equal(int64(destID), m.StudioID.Int64)
+
+		// ensure child studio is reparented
+		child, err := qb.Find(studioIDs[studioIdxWithParentStudio])
+		if err != nil {
+			return err
+		}
+		assert.Equal(int64(destID), child.ParentID.Int64)
+
+		return nil
+	}); err != nil {
+		t.Error(err.Error())
+	}
+}
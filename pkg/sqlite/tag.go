@@ -13,6 +13,7 @@ const tagTable = "tags"
 const tagIDColumn = "tag_id"
 const tagAliasesTable = "tag_aliases"
 const tagAliasColumn = "alias"
+const tagGeneratedAliasesTable = "tag_generated_aliases"
 
 type tagQueryBuilder struct {
 	repository
@@ -248,6 +249,10 @@ func (qb *tagQueryBuilder) QueryForAutoTag(words []string) ([]*models.Tag, error
 		// include aliases
 		whereClauses = append(whereClauses, "tag_aliases.alias like ?")
 		args = append(args, ww)
+
+		// include generated aliases of tags that generate aliases
+		whereClauses = append(whereClauses, "(tags.generate_aliases = 1 AND tags.id IN (SELECT tag_id FROM "+tagGeneratedAliasesTable+" WHERE alias like ?))")
+		args = append(args, ww)
 	}
 
 	where := strings.Join(whereClauses, " OR ")
@@ -387,7 +392,8 @@ func tagSceneCountCriterionHandler(qb *tagQueryBuilder, sceneCount *models.IntCr
 	return func(f *filterBuilder) {
 		if sceneCount != nil {
 			f.addLeftJoin("scenes_tags", "", "scenes_tags.tag_id = tags.id")
-			clause, args := getIntCriterionWhereClause("count(distinct scenes_tags.scene_id)", *sceneCount)
+			f.addLeftJoin("scenes", "", "scenes.id = scenes_tags.scene_id AND "+sceneNotDeletedClause)
+			clause, args := getIntCriterionWhereClause("count(distinct scenes.id)", *sceneCount)
 
 			f.addHaving(clause, args...)
 		}
@@ -577,7 +583,7 @@ func (qb *tagQueryBuilder) getTagSort(query *queryBuilder, findFilter *models.Fi
 	if findFilter.Sort != nil {
 		switch *findFilter.Sort {
 		case "scenes_count":
-			return getCountSort(tagTable, scenesTagsTable, tagIDColumn, direction)
+			return getSceneCountSort(tagTable, scenesTagsTable, tagIDColumn, direction)
 		case "scene_markers_count":
 			return getCountSort(tagTable, "scene_markers_tags", tagIDColumn, direction)
 		case "images_count":
@@ -655,6 +661,25 @@ func (qb *tagQueryBuilder) UpdateAliases(tagID int, aliases []string) error {
 	return qb.aliasRepository().replace(tagID, aliases)
 }
 
+func (qb *tagQueryBuilder) generatedAliasRepository() *stringRepository {
+	return &stringRepository{
+		repository: repository{
+			tx:        qb.tx,
+			tableName: tagGeneratedAliasesTable,
+			idColumn:  tagIDColumn,
+		},
+		stringColumn: tagAliasColumn,
+	}
+}
+
+func (qb *tagQueryBuilder) GetGeneratedAliases(tagID int) ([]string, error) {
+	return qb.generatedAliasRepository().get(tagID)
+}
+
+func (qb *tagQueryBuilder) UpdateGeneratedAliases(tagID int, aliases []string) error {
+	return qb.generatedAliasRepository().replace(tagID, aliases)
+}
+
 func (qb *tagQueryBuilder) Merge(source []int, destination int) error {
 	if len(source) == 0 {
 		return nil
@@ -696,7 +721,8 @@ AND NOT EXISTS(SELECT 1 FROM `+table+` o WHERE o.`+idColumn+` = `+table+`.`+idCo
 		return err
 	}
 
-	_, err = qb.tx.Exec("INSERT INTO "+tagAliasesTable+" (tag_id, alias) SELECT ?, name FROM "+tagTable+" WHERE id IN "+inBinding, args...)
+	// source names that are already aliases of the destination are skipped
+	_, err = qb.tx.Exec("INSERT INTO "+tagAliasesTable+" (tag_id, alias) SELECT ?, name FROM "+tagTable+" WHERE id IN "+inBinding+" AND name NOT IN (SELECT "+tagAliasColumn+" FROM "+tagAliasesTable+" WHERE tag_id = ?)", args...)
 	if err != nil {
 		return err
 	}
@@ -716,6 +742,66 @@ AND NOT EXISTS(SELECT 1 FROM `+table+` o WHERE o.`+idColumn+` = `+table+`.`+idCo
 	return nil
 }
 
+// MergeCounts returns the number of objects that would be reassigned by
+// merging the source tags into the destination tag. Aliases are counted
+// once, excluding those the destination already has.
+func (qb *tagQueryBuilder) MergeCounts(source []int, destination int) (*models.MergeCounts, error) {
+	ret := &models.MergeCounts{}
+	if len(source) == 0 {
+		return ret, nil
+	}
+
+	for _, id := range source {
+		if id == destination {
+			return nil, errors.New("cannot merge where source == destination")
+		}
+	}
+
+	var err error
+	if ret.Scenes, err = qb.countDistinctIn(scenesTagsTable, sceneIDColumn, tagIDColumn, source); err != nil {
+		return nil, err
+	}
+	if ret.Images, err = qb.countDistinctIn(imagesTagsTable, imageIDColumn, tagIDColumn, source); err != nil {
+		return nil, err
+	}
+	if ret.Galleries, err = qb.countDistinctIn(galleriesTagsTable, galleryIDColumn, tagIDColumn, source); err != nil {
+		return nil, err
+	}
+
+	// source names are added as aliases along with the source aliases
+	if ret.Aliases, err = qb.countMergeAliases(source, destination); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// countMergeAliases returns the number of distinct names and aliases of the
+// source tags that are not the name or an alias of the destination tag.
+func (qb *tagQueryBuilder) countMergeAliases(source []int, destination int) (int, error) {
+	inBinding := getInBinding(len(source))
+
+	query := `SELECT COUNT(*) as count FROM (
+SELECT name AS alias FROM ` + tagTable + ` WHERE id IN ` + inBinding + `
+UNION
+SELECT ` + tagAliasColumn + ` AS alias FROM ` + tagAliasesTable + ` WHERE ` + tagIDColumn + ` IN ` + inBinding + `
+) WHERE alias NOT IN (
+SELECT name FROM ` + tagTable + ` WHERE id = ?
+UNION
+SELECT ` + tagAliasColumn + ` FROM ` + tagAliasesTable + ` WHERE ` + tagIDColumn + ` = ?
+)`
+
+	var args []interface{}
+	for i := 0; i < 2; i++ {
+		for _, id := range source {
+			args = append(args, id)
+		}
+	}
+	args = append(args, destination, destination)
+
+	return qb.runCountQuery(query, args)
+}
+
 func (qb *tagQueryBuilder) UpdateParentTags(tagID int, parentIDs []int) error {
 	tx := qb.tx
 	if _, err := tx.Exec("DELETE FROM tags_relations WHERE child_id = ?", tagID); err != nil {
@@ -886,6 +886,8 @@ func TestTagMerge(t *testing.T) {
 		// try merging into same tag
 		err := qb.Merge([]int{tagIDs[tagIdx1WithScene]}, tagIDs[tagIdx1WithScene])
 		assert.NotNil(err)
+		_, err = qb.MergeCounts([]int{tagIDs[tagIdx1WithScene]}, tagIDs[tagIdx1WithScene])
+		assert.NotNil(err)
 
 		// merge everything into tagIdxWithScene
 		srcIdxs := []int{
@@ -910,6 +912,33 @@ func TestTagMerge(t *testing.T) {
 		}
 
 		destID := tagIDs[tagIdxWithScene]
+
+		// the name of a source tag that is already an alias of the
+		// destination is not counted again
+		sharedTag, err := qb.Find(srcIDs[0])
+		if err != nil {
+			return err
+		}
+		destAliases, err := qb.GetAliases(destID)
+		if err != nil {
+			return err
+		}
+		if err := qb.UpdateAliases(destID, append(destAliases, sharedTag.Name)); err != nil {
+			return err
+		}
+
+		counts, err := qb.MergeCounts(srcIDs, destID)
+		if err != nil {
+			return err
+		}
+		// each tag has a single alias
+		assert.Equal(&models.MergeCounts{
+			Scenes:    1,
+			Images:    2,
+			Galleries: 2,
+			Aliases:   len(srcIdxs)*2 - 1,
+		}, counts)
+
 		if err = qb.Merge(srcIDs, destID); err != nil {
 			return err
 		}
@@ -925,7 +954,7 @@ func TestTagMerge(t *testing.T) {
 		}
 
 		// ensure aliases are set on the destination
-		destAliases, err := qb.GetAliases(destID)
+		destAliases, err = qb.GetAliases(destID)
 		if err != nil {
 			return err
 		}
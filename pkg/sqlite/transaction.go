@@ -5,9 +5,13 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	sqlite3 "github.com/mattn/go-sqlite3"
 	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/logger"
 	"github.com/stashapp/stash/pkg/models"
 )
 
@@ -20,8 +24,11 @@ type dbi interface {
 }
 
 type transaction struct {
-	Ctx context.Context
-	tx  *sqlx.Tx
+	Ctx   context.Context
+	tx    *sqlx.Tx
+	stats *QueryStats
+	// db is tx, recording its queries in stats if enabled
+	db dbi
 }
 
 func (t *transaction) Begin() error {
@@ -36,8 +43,9 @@ func (t *transaction) Begin() error {
 	var err error
 	t.tx, err = database.DB.BeginTxx(t.Ctx, nil)
 	if err != nil {
-		return fmt.Errorf("error starting transaction: %v", err)
+		return fmt.Errorf("error starting transaction: %w", err)
 	}
+	t.db = withQueryStats(t.tx, t.stats)
 
 	return nil
 }
@@ -52,6 +60,7 @@ func (t *transaction) Rollback() error {
 		return fmt.Errorf("error rolling back transaction: %v", err)
 	}
 	t.tx = nil
+	t.db = nil
 
 	return nil
 }
@@ -66,6 +75,7 @@ func (t *transaction) Commit() error {
 		return fmt.Errorf("error committing transaction: %v", err)
 	}
 	t.tx = nil
+	t.db = nil
 
 	return nil
 }
@@ -82,61 +92,88 @@ func (t *transaction) ensureTx() {
 
 func (t *transaction) Gallery() models.GalleryReaderWriter {
 	t.ensureTx()
-	return NewGalleryReaderWriter(t.tx)
+	return NewGalleryReaderWriter(t.db)
 }
 
 func (t *transaction) Image() models.ImageReaderWriter {
 	t.ensureTx()
-	return NewImageReaderWriter(t.tx)
+	return NewImageReaderWriter(t.db)
 }
 
 func (t *transaction) Movie() models.MovieReaderWriter {
 	t.ensureTx()
-	return NewMovieReaderWriter(t.tx)
+	return NewMovieReaderWriter(t.db)
 }
 
 func (t *transaction) Performer() models.PerformerReaderWriter {
 	t.ensureTx()
-	return NewPerformerReaderWriter(t.tx)
+	return NewPerformerReaderWriter(t.db)
 }
 
 func (t *transaction) SceneMarker() models.SceneMarkerReaderWriter {
 	t.ensureTx()
-	return NewSceneMarkerReaderWriter(t.tx)
+	return NewSceneMarkerReaderWriter(t.db)
 }
 
 func (t *transaction) Scene() models.SceneReaderWriter {
 	t.ensureTx()
-	return NewSceneReaderWriter(t.tx)
+	return NewSceneReaderWriter(t.db)
 }
 
 func (t *transaction) ScrapedItem() models.ScrapedItemReaderWriter {
 	t.ensureTx()
-	return NewScrapedItemReaderWriter(t.tx)
+	return NewScrapedItemReaderWriter(t.db)
 }
 
 func (t *transaction) Studio() models.StudioReaderWriter {
 	t.ensureTx()
-	return NewStudioReaderWriter(t.tx)
+	return NewStudioReaderWriter(t.db)
 }
 
 func (t *transaction) Tag() models.TagReaderWriter {
 	t.ensureTx()
-	return NewTagReaderWriter(t.tx)
+	return NewTagReaderWriter(t.db)
 }
 
 func (t *transaction) SavedFilter() models.SavedFilterReaderWriter {
 	t.ensureTx()
-	return NewSavedFilterReaderWriter(t.tx)
+	return NewSavedFilterReaderWriter(t.db)
 }
 
-type ReadTransaction struct{}
+func (t *transaction) Quarantine() models.QuarantineReaderWriter {
+	t.ensureTx()
+	return NewQuarantineReaderWriter(t.db)
+}
+
+func (t *transaction) ScanCheckpoint() models.ScanCheckpointReaderWriter {
+	t.ensureTx()
+	return NewScanCheckpointReaderWriter(t.db)
+}
+
+func (t *transaction) ScanSnapshot() models.ScanSnapshotReaderWriter {
+	t.ensureTx()
+	return NewScanSnapshotReaderWriter(t.db)
+}
+
+func (t *transaction) GenerateFailure() models.GenerateFailureReaderWriter {
+	t.ensureTx()
+	return NewGenerateFailureReaderWriter(t.db)
+}
+
+// ReadTransaction provides read-only repositories backed by the read
+// connection pool. It does not take the write lock.
+type ReadTransaction struct {
+	stats *QueryStats
+	db    dbi
+}
 
 func (t *ReadTransaction) Begin() error {
 	if err := database.Ready(); err != nil {
 		return err
 	}
 
+	t.db = withQueryStats(database.ReadConn(), t.stats)
+
 	return nil
 }
 
@@ -153,46 +190,74 @@ func (t *ReadTransaction) Repository() models.ReaderRepository {
 }
 
 func (t *ReadTransaction) Gallery() models.GalleryReader {
-	return NewGalleryReaderWriter(database.DB)
+	return NewGalleryReaderWriter(t.db)
 }
 
 func (t *ReadTransaction) Image() models.ImageReader {
-	return NewImageReaderWriter(database.DB)
+	return NewImageReaderWriter(t.db)
 }
 
 func (t *ReadTransaction) Movie() models.MovieReader {
-	return NewMovieReaderWriter(database.DB)
+	return NewMovieReaderWriter(t.db)
 }
 
 func (t *ReadTransaction) Performer() models.PerformerReader {
-	return NewPerformerReaderWriter(database.DB)
+	return NewPerformerReaderWriter(t.db)
 }
 
 func (t *ReadTransaction) SceneMarker() models.SceneMarkerReader {
-	return NewSceneMarkerReaderWriter(database.DB)
+	return NewSceneMarkerReaderWriter(t.db)
 }
 
 func (t *ReadTransaction) Scene() models.SceneReader {
-	return NewSceneReaderWriter(database.DB)
+	return NewSceneReaderWriter(t.db)
 }
 
 func (t *ReadTransaction) ScrapedItem() models.ScrapedItemReader {
-	return NewScrapedItemReaderWriter(database.DB)
+	return NewScrapedItemReaderWriter(t.db)
 }
 
 func (t *ReadTransaction) Studio() models.StudioReader {
-	return NewStudioReaderWriter(database.DB)
+	return NewStudioReaderWriter(t.db)
 }
 
 func (t *ReadTransaction) Tag() models.TagReader {
-	return NewTagReaderWriter(database.DB)
+	return NewTagReaderWriter(t.db)
 }
 
 func (t *ReadTransaction) SavedFilter() models.SavedFilterReader {
-	return NewSavedFilterReaderWriter(database.DB)
+	return NewSavedFilterReaderWriter(t.db)
+}
+
+func (t *ReadTransaction) Quarantine() models.QuarantineReader {
+	return NewQuarantineReaderWriter(t.db)
+}
+
+func (t *ReadTransaction) ScanCheckpoint() models.ScanCheckpointReader {
+	return NewScanCheckpointReaderWriter(t.db)
+}
+
+func (t *ReadTransaction) ScanSnapshot() models.ScanSnapshotReader {
+	return NewScanSnapshotReaderWriter(t.db)
+}
+
+func (t *ReadTransaction) GenerateFailure() models.GenerateFailureReader {
+	return NewGenerateFailureReaderWriter(t.db)
+}
+
+// RetryConfig provides the settings used to retry transactions.
+type RetryConfig interface {
+	GetTransactionRetries() int
+	GetTransactionRetryBackoff() time.Duration
 }
 
 type TransactionManager struct {
+	// RetryConfig is used to retry transactions marked with
+	// models.WithRetryable. Transactions are not retried if nil.
+	RetryConfig RetryConfig
+	// QueryStats records the queries of transactions begun while it is
+	// enabled. Queries are not recorded if nil.
+	QueryStats *QueryStats
 }
 
 func NewTransactionManager() *TransactionManager {
@@ -200,11 +265,66 @@ func NewTransactionManager() *TransactionManager {
 }
 
 func (t *TransactionManager) WithTxn(ctx context.Context, fn func(r models.Repository) error) error {
-	database.WriteMu.Lock()
-	defer database.WriteMu.Unlock()
-	return models.WithTxn(&transaction{Ctx: ctx}, fn)
+	return t.withRetry(ctx, func() error {
+		database.WriteMu.Lock()
+		defer database.WriteMu.Unlock()
+		return models.WithTxn(&transaction{Ctx: ctx, stats: t.QueryStats}, fn)
+	})
 }
 
 func (t *TransactionManager) WithReadTxn(ctx context.Context, fn func(r models.ReaderRepository) error) error {
-	return models.WithROTxn(&ReadTransaction{}, fn)
+	return t.withRetry(ctx, func() error {
+		return models.WithROTxn(&ReadTransaction{stats: t.QueryStats}, fn)
+	})
+}
+
+// withRetry calls fn, calling it again with an exponential backoff if the
+// context is retryable and fn fails because the database is locked. The
+// write lock is not held between attempts.
+func (t *TransactionManager) withRetry(ctx context.Context, fn func() error) error {
+	retries := 0
+	var backoff time.Duration
+	if t.RetryConfig != nil && models.IsRetryable(ctx) {
+		retries = t.RetryConfig.GetTransactionRetries()
+		backoff = t.RetryConfig.GetTransactionRetryBackoff()
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		if err == nil || attempt >= retries || !isLockedError(err) {
+			return err
+		}
+
+		logger.Debugf("database is locked, retrying transaction in %v (attempt %d of %d)", backoff, attempt+1, retries)
+		if err := wait(ctx, backoff); err != nil {
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+func wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isLockedError returns true if err was caused by SQLITE_BUSY or
+// SQLITE_LOCKED.
+func isLockedError(err error) bool {
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code == sqlite3.ErrBusy || sqliteErr.Code == sqlite3.ErrLocked
+	}
+
+	// some query errors are wrapped without %w, so fall back to the
+	// messages of SQLITE_BUSY and SQLITE_LOCKED
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") || strings.Contains(msg, "database table is locked")
 }
@@ -0,0 +1,120 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+type testRetryConfig struct {
+	retries int
+}
+
+func (c testRetryConfig) GetTransactionRetries() int {
+	return c.retries
+}
+
+func (c testRetryConfig) GetTransactionRetryBackoff() time.Duration {
+	return time.Millisecond
+}
+
+var errBusy = sqlite3.Error{Code: sqlite3.ErrBusy}
+
+func TestIsLockedError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"busy", errBusy, true},
+		{"locked", sqlite3.Error{Code: sqlite3.ErrLocked}, true},
+		{"wrapped busy", fmt.Errorf("error starting transaction: %w", errBusy), true},
+		{"busy message", errors.New("error executing query: database is locked"), true},
+		{"constraint", sqlite3.Error{Code: sqlite3.ErrConstraint}, false},
+		{"other", errors.New("scene not found"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isLockedError(tt.err); got != tt.want {
+				t.Errorf("isLockedError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	errOther := errors.New("other error")
+
+	// returns the errors in order, then nil
+	failing := func(errs ...error) (func() error, *int) {
+		calls := 0
+		return func() error {
+			calls++
+			if calls <= len(errs) {
+				return errs[calls-1]
+			}
+			return nil
+		}, &calls
+	}
+
+	retryable := models.WithRetryable(context.Background())
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		retries   int
+		errs      []error
+		wantErr   error
+		wantCalls int
+	}{
+		{"succeeds on retry", retryable, 3, []error{errBusy, errBusy}, nil, 3},
+		{"retries exhausted", retryable, 2, []error{errBusy, errBusy, errBusy}, errBusy, 3},
+		{"not retryable", context.Background(), 3, []error{errBusy}, errBusy, 1},
+		{"other error", retryable, 3, []error{errOther}, errOther, 1},
+		{"other error on retry", retryable, 3, []error{errBusy, errOther}, errOther, 2},
+		{"no retries", retryable, 0, []error{errBusy}, errBusy, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tm := &TransactionManager{RetryConfig: testRetryConfig{retries: tt.retries}}
+			fn, calls := failing(tt.errs...)
+
+			err := tm.withRetry(tt.ctx, fn)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("withRetry() error = %v, want %v", err, tt.wantErr)
+			}
+			if *calls != tt.wantCalls {
+				t.Errorf("withRetry() called fn %d times, want %d", *calls, tt.wantCalls)
+			}
+		})
+	}
+
+	// without a retry configuration transactions are never retried
+	fn, calls := failing(errBusy)
+	if err := NewTransactionManager().withRetry(retryable, fn); !errors.Is(err, errBusy) || *calls != 1 {
+		t.Errorf("withRetry() without config = %v after %d calls", err, *calls)
+	}
+}
+
+func TestWithRetryCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(models.WithRetryable(context.Background()))
+	cancel()
+
+	tm := &TransactionManager{RetryConfig: testRetryConfig{retries: 3}}
+	calls := 0
+	err := tm.withRetry(ctx, func() error {
+		calls++
+		return errBusy
+	})
+
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("withRetry() = %v after %d calls, want context.Canceled after 1", err, calls)
+	}
+}
@@ -0,0 +1,155 @@
+//go:build integration
+// +build integration
+
+package sqlite_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	sqlite3 "github.com/mattn/go-sqlite3"
+	"github.com/stashapp/stash/pkg/database"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/sqlite"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadTxnDoesNotTakeWriteLock(t *testing.T) {
+	database.WriteMu.Lock()
+	defer database.WriteMu.Unlock()
+
+	done := make(chan error)
+	go func() {
+		done <- sqlite.NewTransactionManager().WithReadTxn(context.TODO(), func(r models.ReaderRepository) error {
+			_, err := r.Scene().Find(sceneIDs[sceneIdxWithGallery])
+			return err
+		})
+	}()
+
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Error("read transaction blocked on write lock")
+	}
+}
+
+func TestReadConnIsQueryOnly(t *testing.T) {
+	_, err := database.ReadConn().Exec("UPDATE scenes SET title = 'read only' WHERE id = ?", sceneIDs[sceneIdxWithGallery])
+	assert.NotNil(t, err)
+}
+
+type retryConfig struct{}
+
+func (retryConfig) GetTransactionRetries() int {
+	return 3
+}
+
+func (retryConfig) GetTransactionRetryBackoff() time.Duration {
+	return time.Millisecond
+}
+
+func TestRetryableTxnSucceedsOnRetry(t *testing.T) {
+	const title = "retried transaction"
+	sceneID := sceneIDs[sceneIdxWithGallery]
+
+	tm := &sqlite.TransactionManager{RetryConfig: retryConfig{}}
+	ctx := models.WithRetryable(context.TODO())
+
+	attempts := 0
+	err := tm.WithTxn(ctx, func(r models.Repository) error {
+		attempts++
+
+		if _, err := r.Scene().Update(models.ScenePartial{
+			ID:    sceneID,
+			Title: &sql.NullString{String: title, Valid: true},
+		}); err != nil {
+			return err
+		}
+
+		if attempts == 1 {
+			// simulate the database being locked by another connection
+			return sqlite3.Error{Code: sqlite3.ErrBusy}
+		}
+		return nil
+	})
+
+	assert.Nil(t, err)
+	assert.Equal(t, 2, attempts)
+
+	if err := tm.WithTxn(context.TODO(), func(r models.Repository) error {
+		s, err := r.Scene().Find(sceneID)
+		if err != nil {
+			return err
+		}
+
+		assert.Equal(t, title, s.Title.String)
+
+		// restore the original title
+		_, err = r.Scene().Update(models.ScenePartial{
+			ID:    sceneID,
+			Title: &sql.NullString{String: getSceneTitle(sceneIdxWithGallery), Valid: true},
+		})
+		return err
+	}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTxnQueryStats(t *testing.T) {
+	stats := sqlite.NewQueryStats()
+	tm := &sqlite.TransactionManager{QueryStats: stats}
+
+	findScenes := func() {
+		if err := tm.WithReadTxn(context.TODO(), func(r models.ReaderRepository) error {
+			for _, idx := range []int{sceneIdxWithGallery, sceneIdxWithMovie} {
+				if _, err := r.Scene().Find(sceneIDs[idx]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			t.Fatal(err.Error())
+		}
+	}
+
+	// queries are not recorded while disabled
+	findScenes()
+	assert.Empty(t, stats.Stats())
+
+	stats.SetEnabled(true)
+	findScenes()
+
+	queries := func() map[string]*models.QueryStat {
+		ret := make(map[string]*models.QueryStat)
+		for _, s := range stats.Stats() {
+			ret[s.Query] = s
+		}
+		return ret
+	}
+
+	// both finds have the same shape
+	find := queries()["SELECT * FROM scenes WHERE id = ? LIMIT ?"]
+	if assert.NotNil(t, find) {
+		assert.Equal(t, 2, find.Count)
+		assert.GreaterOrEqual(t, find.Total, find.Max)
+		assert.Greater(t, find.Max, float64(0))
+	}
+
+	// roll back so that the test data is unchanged
+	errRollback := errors.New("rollback")
+	err := tm.WithTxn(context.TODO(), func(r models.Repository) error {
+		if _, err := r.Scene().IncrementOCounter(sceneIDs[sceneIdxWithGallery]); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	assert.Equal(t, errRollback, err)
+	assert.NotNil(t, queries()["UPDATE scenes SET o_counter = o_counter + ? WHERE scenes.id = ?"])
+
+	stats.Reset()
+	assert.Empty(t, stats.Stats())
+}
@@ -0,0 +1,173 @@
+package tag
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/stashapp/stash/pkg/models"
+)
+
+var aliasWordSeparatorRE = regexp.MustCompile(`[\s\-_]+`)
+
+// GenerateAliases returns candidate aliases for the tag name. The candidates
+// are the singular or plural forms of the last word, the name joined with
+// hyphens, spaces or nothing instead of its separators, and the acronym of
+// names with at least three words. The name itself is not returned.
+func GenerateAliases(name string) []string {
+	name = strings.TrimSpace(name)
+	words := aliasWordSeparatorRE.Split(name, -1)
+	if name == "" || len(words) == 0 {
+		return nil
+	}
+
+	var candidates []string
+
+	// singular/plural of the last word
+	last := words[len(words)-1]
+	for _, v := range append(singulars(last), plural(last)) {
+		if v != "" {
+			candidates = append(candidates, strings.Join(append(words[:len(words)-1:len(words)-1], v), " "))
+		}
+	}
+
+	if len(words) > 1 {
+		candidates = append(candidates,
+			strings.Join(words, " "),
+			strings.Join(words, "-"),
+			strings.Join(words, ""),
+			acronym(words),
+		)
+	}
+
+	// remove duplicates and the name, ignoring case
+	seen := map[string]bool{
+		strings.ToLower(name): true,
+	}
+	var ret []string
+	for _, c := range candidates {
+		k := strings.ToLower(c)
+		if c == "" || seen[k] {
+			continue
+		}
+		seen[k] = true
+		ret = append(ret, c)
+	}
+
+	return ret
+}
+
+func hasAnySuffix(w string, suffixes ...string) bool {
+	for _, s := range suffixes {
+		if strings.HasSuffix(w, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasSibilantSuffix(w string) bool {
+	return hasAnySuffix(strings.ToLower(w), "s", "x", "z", "ch", "sh")
+}
+
+func isVowel(r byte) bool {
+	return strings.IndexByte("aeiou", r) != -1
+}
+
+// singulars returns the possible singular forms of w if it appears to be
+// plural.
+func singulars(w string) []string {
+	lw := strings.ToLower(w)
+	switch {
+	case len(lw) > 3 && strings.HasSuffix(lw, "ies"):
+		// "parties" or "movies"
+		return []string{w[:len(w)-3] + matchCase("y", w), w[:len(w)-1]}
+	case len(lw) > 3 && strings.HasSuffix(lw, "es") && hasSibilantSuffix(w[:len(w)-2]):
+		// "boxes" or "horses"
+		return []string{w[:len(w)-2], w[:len(w)-1]}
+	case len(lw) > 2 && strings.HasSuffix(lw, "s") && !hasAnySuffix(lw, "ss", "us", "is"):
+		return []string{w[:len(w)-1]}
+	}
+	return nil
+}
+
+// plural returns the plural form of w, or an empty string if w appears to
+// be plural.
+func plural(w string) string {
+	if len(singulars(w)) > 0 || len(w) < 2 {
+		return ""
+	}
+
+	lw := strings.ToLower(w)
+	switch {
+	case strings.HasSuffix(lw, "y") && !isVowel(lw[len(lw)-2]):
+		return w[:len(w)-1] + matchCase("ies", w)
+	case hasSibilantSuffix(w):
+		return w + matchCase("es", w)
+	}
+	return w + matchCase("s", w)
+}
+
+// matchCase returns suffix in upper case if w is all upper case.
+func matchCase(suffix string, w string) string {
+	if strings.ToUpper(w) == w && strings.ToLower(w) != w {
+		return strings.ToUpper(suffix)
+	}
+	return suffix
+}
+
+// minAcronymLength is the minimum number of letters of a generated acronym.
+// Shorter acronyms match too many unrelated words.
+const minAcronymLength = 3
+
+// acronym returns the upper case first letters of the words, or an empty
+// string if the acronym would be shorter than minAcronymLength.
+func acronym(words []string) string {
+	var b strings.Builder
+	for _, w := range words {
+		r, _ := utf8.DecodeRuneInString(w)
+		if r == utf8.RuneError || !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			continue
+		}
+		b.WriteRune(unicode.ToUpper(r))
+	}
+
+	if utf8.RuneCountInString(b.String()) < minAcronymLength {
+		return ""
+	}
+	return b.String()
+}
+
+// RegenerateAliases replaces the generated aliases of the tag with those
+// generated from its current name. Generated aliases are removed if the tag
+// does not generate aliases. Candidates that are the name or alias of a tag,
+// including the tag itself, are skipped.
+func RegenerateAliases(qb models.TagReaderWriter, t *models.Tag) error {
+	var aliases []string
+
+	if t.GenerateAliases {
+		for _, c := range GenerateAliases(t.Name) {
+			used, err := isNameOrAlias(qb, c)
+			if err != nil {
+				return err
+			}
+
+			if !used {
+				aliases = append(aliases, c)
+			}
+		}
+	}
+
+	return qb.UpdateGeneratedAliases(t.ID, aliases)
+}
+
+func isNameOrAlias(qb models.TagReader, name string) (bool, error) {
+	t, err := ByName(qb, name)
+	if err != nil || t != nil {
+		return t != nil, err
+	}
+
+	t, err = ByAlias(qb, name)
+	return t != nil, err
+}
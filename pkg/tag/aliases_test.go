@@ -0,0 +1,96 @@
+package tag
+
+import (
+	"testing"
+
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stashapp/stash/pkg/models/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGenerateAliases(t *testing.T) {
+	tests := []struct {
+		name string
+		want []string
+	}{
+		{"", nil},
+		{"  ", nil},
+		// plural of a singular word
+		{"car", []string{"cars"}},
+		{"box", []string{"boxes"}},
+		{"match", []string{"matches"}},
+		{"party", []string{"parties"}},
+		{"toy", []string{"toys"}},
+		{"glass", []string{"glasses"}},
+		// singular of a plural word
+		{"cars", []string{"car"}},
+		{"boxes", []string{"box", "boxe"}},
+		{"parties", []string{"party", "partie"}},
+		{"movies", []string{"movy", "movie"}},
+		// words that only look plural
+		{"status", []string{"statuses"}},
+		{"analysis", []string{"analysises"}},
+		// case of upper case words is kept
+		{"CAR", []string{"CARS"}},
+		{"PARTIES", []string{"PARTY", "PARTIE"}},
+		// spacing, hyphenation and acronym variants
+		{"point of view", []string{"point of views", "point-of-view", "pointofview", "POV"}},
+		{"x-ray", []string{"x rays", "x ray", "xray"}},
+		{"ice_cream", []string{"ice creams", "ice cream", "ice-cream", "icecream"}},
+		{"behind_the-scenes", []string{"behind the scene", "behind the scenes", "behind-the-scenes", "behindthescenes", "BTS"}},
+		// separators are collapsed
+		{"close  -  up", []string{"close ups", "close up", "close-up", "closeup"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, GenerateAliases(tt.name))
+		})
+	}
+}
+
+func TestGenerateAliasesExcludesName(t *testing.T) {
+	// the acronym of "a b c" is the same as the name ignoring case
+	for _, a := range GenerateAliases("a b c") {
+		assert.NotEqual(t, "a b c", a)
+	}
+	assert.NotContains(t, GenerateAliases("A B C"), "A B C")
+}
+
+func TestRegenerateAliases(t *testing.T) {
+	const tagID = 1
+
+	mockTagReader := &mocks.TagReaderWriter{}
+
+	// "POV" is already a tag
+	mockTagReader.On("Query", mock.MatchedBy(func(f *models.TagFilterType) bool {
+		return f.Name != nil && f.Name.Value == "POV"
+	}), mock.Anything).Return([]*models.Tag{{ID: 2, Name: "POV"}}, 1, nil)
+	mockTagReader.On("Query", mock.Anything, mock.Anything).Return(nil, 0, nil)
+	mockTagReader.On("UpdateGeneratedAliases", tagID, []string{"point of views", "point-of-view", "pointofview"}).Return(nil).Once()
+
+	err := RegenerateAliases(mockTagReader, &models.Tag{
+		ID:              tagID,
+		Name:            "point of view",
+		GenerateAliases: true,
+	})
+
+	assert.Nil(t, err)
+	mockTagReader.AssertExpectations(t)
+}
+
+func TestRegenerateAliasesDisabled(t *testing.T) {
+	const tagID = 1
+
+	mockTagReader := &mocks.TagReaderWriter{}
+	mockTagReader.On("UpdateGeneratedAliases", tagID, []string(nil)).Return(nil).Once()
+
+	err := RegenerateAliases(mockTagReader, &models.Tag{
+		ID:   tagID,
+		Name: "point of view",
+	})
+
+	assert.Nil(t, err)
+	mockTagReader.AssertExpectations(t)
+}
@@ -14,6 +14,8 @@ func ToJSON(reader models.TagReader, tag *models.Tag) (*jsonschema.Tag, error) {
 		Name:      tag.Name,
 		CreatedAt: models.JSONTime{Time: tag.CreatedAt.Timestamp},
 		UpdatedAt: models.JSONTime{Time: tag.UpdatedAt.Timestamp},
+
+		GenerateAliases: tag.GenerateAliases,
 	}
 
 	aliases, err := reader.GetAliases(tag.ID)
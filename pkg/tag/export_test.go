@@ -64,6 +64,16 @@ type testScenario struct {
 
 var scenarios []testScenario
 
+func withGenerateAliases(tag models.Tag) models.Tag {
+	tag.GenerateAliases = true
+	return tag
+}
+
+func withJSONGenerateAliases(tag *jsonschema.Tag) *jsonschema.Tag {
+	tag.GenerateAliases = true
+	return tag
+}
+
 func initTestTable() {
 	scenarios = []testScenario{
 		{
@@ -72,8 +82,8 @@ func initTestTable() {
 			false,
 		},
 		{
-			createTag(noImageID),
-			createJSONTag(nil, "", nil),
+			withGenerateAliases(createTag(noImageID)),
+			withJSONGenerateAliases(createJSONTag(nil, "", nil)),
 			false,
 		},
 		{
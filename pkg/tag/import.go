@@ -34,6 +34,8 @@ func (i *Importer) PreImport() error {
 		Name:      i.Input.Name,
 		CreatedAt: models.SQLiteTimestamp{Timestamp: i.Input.CreatedAt.GetTime()},
 		UpdatedAt: models.SQLiteTimestamp{Timestamp: i.Input.UpdatedAt.GetTime()},
+
+		GenerateAliases: i.Input.GenerateAliases,
 	}
 
 	var err error
@@ -58,6 +60,14 @@ func (i *Importer) PostImport(id int) error {
 		return fmt.Errorf("error setting tag aliases: %v", err)
 	}
 
+	if i.tag.GenerateAliases {
+		tag := i.tag
+		tag.ID = id
+		if err := RegenerateAliases(i.ReaderWriter, &tag); err != nil {
+			return fmt.Errorf("error generating tag aliases: %v", err)
+		}
+	}
+
 	parents, err := i.getParents()
 	if err != nil {
 		return err
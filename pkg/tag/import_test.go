@@ -50,6 +50,14 @@ func TestImporterPreImport(t *testing.T) {
 	err = i.PreImport()
 
 	assert.Nil(t, err)
+	assert.False(t, i.tag.GenerateAliases)
+
+	i.Input.GenerateAliases = true
+
+	err = i.PreImport()
+
+	assert.Nil(t, err)
+	assert.True(t, i.tag.GenerateAliases)
 }
 
 func TestImporterPostImport(t *testing.T) {
@@ -104,6 +112,32 @@ func TestImporterPostImport(t *testing.T) {
 	readerWriter.AssertExpectations(t)
 }
 
+func TestImporterPostImportGenerateAliases(t *testing.T) {
+	readerWriter := &mocks.TagReaderWriter{}
+
+	i := Importer{
+		ReaderWriter: readerWriter,
+		Input: jsonschema.Tag{
+			Name:            "point of view",
+			GenerateAliases: true,
+		},
+	}
+
+	err := i.PreImport()
+	assert.Nil(t, err)
+
+	var parentTags []int
+	readerWriter.On("UpdateAliases", tagID, []string(nil)).Return(nil).Once()
+	readerWriter.On("Query", mock.Anything, mock.Anything).Return(nil, 0, nil)
+	readerWriter.On("UpdateGeneratedAliases", tagID, []string{"point of views", "point-of-view", "pointofview", "POV"}).Return(nil).Once()
+	readerWriter.On("UpdateParentTags", tagID, parentTags).Return(nil).Once()
+
+	err = i.PostImport(tagID)
+	assert.Nil(t, err)
+
+	readerWriter.AssertExpectations(t)
+}
+
 func TestImporterPostImportParentMissing(t *testing.T) {
 	readerWriter := &mocks.TagReaderWriter{}
 
@@ -0,0 +1,259 @@
+// Package template renders text such as filenames from the fields of items.
+//
+// Templates contain placeholders of the form {field}, which are replaced with
+// the value of the field. The value may be passed through functions using
+// {field|function} or {field|function:argument}. The supported functions
+// are:
+//
+//	lower        lowercases the value
+//	upper        uppercases the value
+//	date:layout  formats a date using a Go time layout, such as 2006-01
+//	pad:width    pads the value with leading zeros to the width
+//
+// Field names are not case sensitive.
+package template
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Vars are the values of the fields of an item, keyed by lowercase field
+// name.
+type Vars map[string]string
+
+type templateFunc func(value string, arg string) (string, error)
+
+type funcDef struct {
+	fn      templateFunc
+	needArg bool
+	// validate validates the argument when the template is parsed
+	validate func(arg string) error
+}
+
+var funcs = map[string]funcDef{
+	"lower": {
+		fn: func(value string, _ string) (string, error) {
+			return strings.ToLower(value), nil
+		},
+	},
+	"upper": {
+		fn: func(value string, _ string) (string, error) {
+			return strings.ToUpper(value), nil
+		},
+	},
+	"date": {
+		fn:      formatDate,
+		needArg: true,
+	},
+	"pad": {
+		fn:      pad,
+		needArg: true,
+		validate: func(arg string) error {
+			if width, err := strconv.Atoi(arg); err != nil || width <= 0 {
+				return fmt.Errorf("invalid width %q", arg)
+			}
+			return nil
+		},
+	},
+}
+
+// dateLayouts are the layouts that date values are parsed with.
+var dateLayouts = []string{
+	"2006-01-02",
+	time.RFC3339,
+}
+
+func formatDate(value string, layout string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	for _, l := range dateLayouts {
+		if t, err := time.Parse(l, value); err == nil {
+			return t.Format(layout), nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid date %q", value)
+}
+
+func pad(value string, arg string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	width, _ := strconv.Atoi(arg)
+	if n := width - len([]rune(value)); n > 0 {
+		return strings.Repeat("0", n) + value, nil
+	}
+
+	return value, nil
+}
+
+type call struct {
+	name string
+	arg  string
+}
+
+type part struct {
+	literal string
+	// field is empty for literal parts
+	field string
+	calls []call
+}
+
+// Template is a parsed template.
+type Template struct {
+	parts []part
+}
+
+// Parse parses the template text. An error is returned if a placeholder is
+// not closed, or uses an unknown function or invalid function argument.
+func Parse(text string) (*Template, error) {
+	ret := &Template{}
+
+	for text != "" {
+		start := strings.IndexRune(text, '{')
+		if start == -1 {
+			ret.parts = append(ret.parts, part{literal: text})
+			break
+		}
+
+		if start > 0 {
+			ret.parts = append(ret.parts, part{literal: text[:start]})
+		}
+
+		end := strings.IndexRune(text[start:], '}')
+		if end == -1 {
+			return nil, fmt.Errorf("unclosed placeholder %q", text[start:])
+		}
+		end += start
+
+		p, err := parsePlaceholder(text[start+1 : end])
+		if err != nil {
+			return nil, fmt.Errorf("invalid placeholder %q: %w", text[start:end+1], err)
+		}
+		ret.parts = append(ret.parts, *p)
+
+		text = text[end+1:]
+	}
+
+	return ret, nil
+}
+
+func parsePlaceholder(s string) (*part, error) {
+	if strings.ContainsRune(s, '{') {
+		return nil, errors.New("nested placeholder")
+	}
+
+	elems := strings.Split(s, "|")
+	field := strings.ToLower(strings.TrimSpace(elems[0]))
+	if field == "" {
+		return nil, errors.New("missing field name")
+	}
+
+	ret := &part{field: field}
+	for _, e := range elems[1:] {
+		c := call{name: strings.TrimSpace(e)}
+		if i := strings.IndexRune(e, ':'); i != -1 {
+			c.name = strings.TrimSpace(e[:i])
+			c.arg = e[i+1:]
+		}
+		c.name = strings.ToLower(c.name)
+
+		def, found := funcs[c.name]
+		if !found {
+			return nil, fmt.Errorf("unknown function %q", c.name)
+		}
+		if def.needArg && c.arg == "" {
+			return nil, fmt.Errorf("function %q requires an argument", c.name)
+		}
+		if def.validate != nil {
+			if err := def.validate(c.arg); err != nil {
+				return nil, fmt.Errorf("function %q: %w", c.name, err)
+			}
+		}
+
+		ret.calls = append(ret.calls, c)
+	}
+
+	return ret, nil
+}
+
+// Fields returns the names of the fields used in the template, in order of
+// use.
+func (t *Template) Fields() []string {
+	var ret []string
+	seen := make(map[string]bool)
+	for _, p := range t.parts {
+		if p.field != "" && !seen[p.field] {
+			seen[p.field] = true
+			ret = append(ret, p.field)
+		}
+	}
+
+	return ret
+}
+
+// Execute renders the template using the values of vars. An error is
+// returned if the template uses a field that vars does not have, or a
+// function cannot be applied to a value.
+func (t *Template) Execute(vars Vars) (string, error) {
+	return t.execute(vars, nil)
+}
+
+// ExecuteFilename renders the template like Execute, replacing characters
+// in the field values that are not valid in filenames, so that values do
+// not add path separators. Literal text in the template is not changed.
+func (t *Template) ExecuteFilename(vars Vars) (string, error) {
+	return t.execute(vars, SanitizeFilename)
+}
+
+func (t *Template) execute(vars Vars, sanitize func(string) string) (string, error) {
+	var b strings.Builder
+	for _, p := range t.parts {
+		if p.field == "" {
+			b.WriteString(p.literal)
+			continue
+		}
+
+		v, found := vars[p.field]
+		if !found {
+			return "", fmt.Errorf("unknown field %q", p.field)
+		}
+
+		for _, c := range p.calls {
+			var err error
+			v, err = funcs[c.name].fn(v, c.arg)
+			if err != nil {
+				return "", fmt.Errorf("field %q: %s: %w", p.field, c.name, err)
+			}
+		}
+
+		if sanitize != nil {
+			v = sanitize(v)
+		}
+		b.WriteString(v)
+	}
+
+	return b.String(), nil
+}
+
+// invalidFilenameChars are the characters that are not valid in filenames
+// on at least one supported platform.
+const invalidFilenameChars = `/\:*?"<>|`
+
+// SanitizeFilename replaces characters that are not valid in filenames,
+// including path separators and control characters, with underscores.
+func SanitizeFilename(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < ' ' || r == 0x7f || strings.ContainsRune(invalidFilenameChars, r) {
+			return '_'
+		}
+		return r
+	}, s)
+}
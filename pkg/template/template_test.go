@@ -0,0 +1,190 @@
+package template
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		fields  []string
+		wantErr bool
+	}{
+		{"literal", "text", nil, false},
+		{"fields", "{Studio}/{date|date:2006} {title}.{ext}", []string{"studio", "date", "title", "ext"}, false},
+		{"repeated field", "{id}-{ID}", []string{"id"}, false},
+		{"unclosed", "{title", nil, true},
+		{"nested", "{ti{tle}}", nil, true},
+		{"empty field", "{|lower}", nil, true},
+		{"unknown function", "{title|reverse}", nil, true},
+		{"missing argument", "{date|date}", nil, true},
+		{"invalid pad width", "{id|pad:x}", nil, true},
+		{"zero pad width", "{id|pad:0}", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil {
+				assert.Equal(t, tt.fields, got.Fields())
+			}
+		})
+	}
+}
+
+func TestExecute(t *testing.T) {
+	vars := Vars{
+		"title": "My Title",
+		"date":  "2021-03-04",
+		"time":  "2021-03-04T05:06:07Z",
+		"id":    "12",
+		"empty": "",
+	}
+
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{"field", "{title}.mp4", "My Title.mp4", false},
+		{"lower", "{title|lower}", "my title", false},
+		{"upper", "{TITLE | upper}", "MY TITLE", false},
+		{"chained", "{title|upper|lower}", "my title", false},
+		{"date", "{date|date:2006}/{date|date:Jan 02, 2006}", "2021/Mar 04, 2021", false},
+		{"date with colon", "{time|date:15:04}", "05:06", false},
+		{"empty date", "[{empty|date:2006}]", "[]", false},
+		{"invalid date", "{title|date:2006}", "", true},
+		{"pad", "{id|pad:5}", "00012", false},
+		{"pad shorter", "{id|pad:1}", "12", false},
+		{"pad empty", "[{empty|pad:3}]", "[]", false},
+		{"unknown field", "{studio}", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := Parse(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := tmpl.Execute(vars)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want string
+	}{
+		{"valid", "Title (2021) - Part 1.mp4", "Title (2021) - Part 1.mp4"},
+		{"separators", `a/b\c`, "a_b_c"},
+		{"windows reserved", `a:b*c?d"e<f>g|h`, "a_b_c_d_e_f_g_h"},
+		{"control characters", "a\tb\nc\x00d\x7f", "a_b_c_d_"},
+		{"unicode", "タイトル é", "タイトル é"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SanitizeFilename(tt.s))
+		})
+	}
+}
+
+func TestExecuteFilename(t *testing.T) {
+	tmpl, err := Parse("{studio}/{date|date:2006/01} {title}.mp4")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := tmpl.ExecuteFilename(Vars{
+		"studio": "A/B",
+		"date":   "2021-03-04",
+		"title":  "Who? What: Why",
+	})
+	assert.Nil(t, err)
+
+	// only literal separators are kept
+	assert.Equal(t, "A_B/2021_03 Who_ What_ Why.mp4", got)
+}
+
+func TestSceneVars(t *testing.T) {
+	s := &models.Scene{
+		ID:     3,
+		Path:   filepath.Join("lib", "file.name.mp4"),
+		Date:   models.SQLiteDate{String: "2021-03-04", Valid: true},
+		Width:  models.NullInt64(1920),
+		Rating: models.NullInt64(5),
+	}
+
+	vars := SceneVars(s, "Studio")
+	assert.Equal(t, "3", vars["id"])
+	assert.Equal(t, "file.name", vars["title"])
+	assert.Equal(t, "file.name", vars["filename"])
+	assert.Equal(t, "mp4", vars["ext"])
+	assert.Equal(t, "2021", vars["year"])
+	assert.Equal(t, "Studio", vars["studio"])
+	assert.Equal(t, "1920", vars["width"])
+	assert.Equal(t, "", vars["height"])
+	assert.Equal(t, "5", vars["rating"])
+}
+
+func TestImageVars(t *testing.T) {
+	i := &models.Image{
+		ID:    4,
+		Path:  file.ZipFilename(filepath.Join("lib", "gallery.zip"), "sub/image.jpg"),
+		Title: models.NullString("Title"),
+		DateTaken: models.NullSQLiteTimestamp{
+			Timestamp: time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+			Valid:     true,
+		},
+	}
+
+	vars := ImageVars(i, "")
+	assert.Equal(t, "Title", vars["title"])
+	assert.Equal(t, "image", vars["filename"])
+	assert.Equal(t, "jpg", vars["ext"])
+	assert.Equal(t, "2020-01-02", vars["date"])
+	assert.Equal(t, "2020", vars["year"])
+}
+
+func TestGalleryVars(t *testing.T) {
+	folder := &models.Gallery{
+		ID:   5,
+		Path: models.NullString(filepath.Join("lib", "folder.name")),
+	}
+
+	vars := GalleryVars(folder, "")
+	assert.Equal(t, "folder.name", vars["title"])
+	assert.Equal(t, "folder.name", vars["filename"])
+	assert.Equal(t, "", vars["ext"])
+
+	zip := &models.Gallery{
+		ID:   6,
+		Path: models.NullString(filepath.Join("lib", "gallery.zip")),
+		Zip:  true,
+	}
+
+	vars = GalleryVars(zip, "")
+	assert.Equal(t, "gallery", vars["filename"])
+	assert.Equal(t, "zip", vars["ext"])
+
+	vars = GalleryVars(&models.Gallery{ID: 7, Title: models.NullString("Title")}, "")
+	assert.Equal(t, "Title", vars["title"])
+	assert.Equal(t, "", vars["filename"])
+}
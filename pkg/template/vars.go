@@ -0,0 +1,154 @@
+package template
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/stashapp/stash/pkg/file"
+	"github.com/stashapp/stash/pkg/models"
+)
+
+func nullInt64(v sql.NullInt64) string {
+	if !v.Valid {
+		return ""
+	}
+	return strconv.FormatInt(v.Int64, 10)
+}
+
+func sqliteDate(v models.SQLiteDate) string {
+	if !v.Valid {
+		return ""
+	}
+	return v.String
+}
+
+func year(date string) string {
+	if len(date) < 4 {
+		return ""
+	}
+	return date[:4]
+}
+
+// splitFilename returns the base name of the path without the extension,
+// and the extension without the leading dot. Files in zip files are named
+// by their name in the zip file.
+func splitFilename(path string) (string, string) {
+	_, path = file.ZipFilePath(path)
+	name := filepath.Base(filepath.FromSlash(path))
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext), strings.TrimPrefix(ext, ".")
+}
+
+// orFilename returns the title, or the filename if the title is empty.
+func orFilename(title string, filename string) string {
+	if title == "" {
+		return filename
+	}
+	return title
+}
+
+// SceneVars returns the variables of a scene with the studio name:
+//
+//	id, title, date, year, studio, filename, ext, checksum, oshash,
+//	width, height, rating
+//
+// The title is the filename without the extension if the scene has no
+// title.
+func SceneVars(s *models.Scene, studio string) Vars {
+	filename, ext := splitFilename(s.Path)
+	date := sqliteDate(s.Date)
+
+	return Vars{
+		"id":       strconv.Itoa(s.ID),
+		"title":    orFilename(s.Title.String, filename),
+		"date":     date,
+		"year":     year(date),
+		"studio":   studio,
+		"filename": filename,
+		"ext":      ext,
+		"checksum": s.Checksum.String,
+		"oshash":   s.OSHash.String,
+		"width":    nullInt64(s.Width),
+		"height":   nullInt64(s.Height),
+		"rating":   nullInt64(s.Rating),
+	}
+}
+
+// ImageVars returns the variables of an image with the studio name:
+//
+//	id, title, date, year, studio, filename, ext, checksum, width, height,
+//	rating
+//
+// The date is the date the image was taken. The title is the filename
+// without the extension if the image has no title.
+func ImageVars(i *models.Image, studio string) Vars {
+	filename, ext := splitFilename(i.Path)
+
+	date := ""
+	if i.DateTaken.Valid {
+		date = i.DateTaken.Timestamp.Format("2006-01-02")
+	}
+
+	return Vars{
+		"id":       strconv.Itoa(i.ID),
+		"title":    orFilename(i.Title.String, filename),
+		"date":     date,
+		"year":     year(date),
+		"studio":   studio,
+		"filename": filename,
+		"ext":      ext,
+		"checksum": i.Checksum,
+		"width":    nullInt64(i.Width),
+		"height":   nullInt64(i.Height),
+		"rating":   nullInt64(i.Rating),
+	}
+}
+
+// GalleryVars returns the variables of a gallery with the studio name:
+//
+//	id, title, date, year, studio, filename, ext, checksum, rating
+//
+// The filename is the name of the zip file or folder of the gallery, and is
+// empty for galleries without a path. The title is the filename if the
+// gallery has no title.
+func GalleryVars(g *models.Gallery, studio string) Vars {
+	filename, ext := "", ""
+	if g.Path.Valid {
+		filename, ext = splitFilename(g.Path.String)
+		if !g.Zip {
+			// folder names may contain dots
+			filename = filepath.Base(filepath.FromSlash(g.Path.String))
+			ext = ""
+		}
+	}
+	date := sqliteDate(g.Date)
+
+	return Vars{
+		"id":       strconv.Itoa(g.ID),
+		"title":    orFilename(g.Title.String, filename),
+		"date":     date,
+		"year":     year(date),
+		"studio":   studio,
+		"filename": filename,
+		"ext":      ext,
+		"checksum": g.Checksum,
+		"rating":   nullInt64(g.Rating),
+	}
+}
+
+// PerformerVars returns the variables of a performer:
+//
+//	id, name, gender, birthdate, country, ethnicity, rating
+func PerformerVars(p *models.Performer) Vars {
+	return Vars{
+		"id":        strconv.Itoa(p.ID),
+		"name":      p.Name.String,
+		"gender":    p.Gender.String,
+		"birthdate": sqliteDate(p.Birthdate),
+		"country":   p.Country.String,
+		"ethnicity": p.Ethnicity.String,
+		"rating":    nullInt64(p.Rating),
+	}
+}
@@ -1,9 +1,12 @@
 package utils
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -79,3 +82,57 @@ func TestDirExists(t *testing.T) {
 	}
 
 }
+
+func TestServeFileCached(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "screenshot.jpg")
+	if err := os.WriteFile(path, []byte("screenshot"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(maxAge int, header string, value string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/screenshot", nil)
+		if header != "" {
+			r.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		ServeFileCached(w, r, path, maxAge)
+		return w
+	}
+
+	assert := assert.New(t)
+
+	w := serve(0, "", "")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("screenshot", w.Body.String())
+	assert.Equal("no-cache", w.Header().Get("Cache-Control"))
+	assert.NotEmpty(w.Header().Get("Last-Modified"))
+
+	etag := w.Header().Get("Etag")
+	assert.NotEmpty(etag)
+
+	w = serve(3600, "", "")
+	assert.Equal("public, max-age=3600", w.Header().Get("Cache-Control"))
+
+	// matching ETag
+	w = serve(0, "If-None-Match", etag)
+	assert.Equal(http.StatusNotModified, w.Code)
+	assert.Empty(w.Body.String())
+
+	// mismatched ETag
+	w = serve(0, "If-None-Match", `"other"`)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("screenshot", w.Body.String())
+
+	// not modified since
+	w = serve(0, "If-Modified-Since", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	assert.Equal(http.StatusNotModified, w.Code)
+
+	// the ETag changes when the file is regenerated
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	w = serve(0, "If-None-Match", etag)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.NotEqual(etag, w.Header().Get("Etag"))
+}
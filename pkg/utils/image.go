@@ -108,6 +108,12 @@ func GetBase64StringFromData(data []byte) string {
 	return base64.StdEncoding.EncodeToString(data)
 }
 
+// isAVIF returns true if the image data is an AVIF image, which is not
+// detected by http.DetectContentType.
+func isAVIF(image []byte) bool {
+	return len(image) >= 12 && string(image[4:8]) == "ftyp" && (string(image[8:12]) == "avif" || string(image[8:12]) == "avis")
+}
+
 func ServeImage(image []byte, w http.ResponseWriter, r *http.Request) error {
 	etag := fmt.Sprintf("%x", md5.Sum(image))
 
@@ -122,6 +128,9 @@ func ServeImage(image []byte, w http.ResponseWriter, r *http.Request) error {
 	if contentType == "text/xml; charset=utf-8" || contentType == "text/plain; charset=utf-8" {
 		contentType = "image/svg+xml"
 	}
+	if isAVIF(image) {
+		contentType = "image/avif"
+	}
 
 	w.Header().Set("Content-Type", contentType)
 	w.Header().Add("Etag", etag)
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"math/bits"
+	"sort"
 	"strconv"
 
 	"github.com/corona10/goimagehash"
@@ -52,6 +54,39 @@ func findNeighbors(bucket int, neighbors []int, hashes []*Phash, scenes *[]int)
 	}
 }
 
+// PhashDistance returns the Hamming distance between two perceptual hashes.
+func PhashDistance(a, b int64) int {
+	return bits.OnesCount64(uint64(a) ^ uint64(b))
+}
+
+// FindSimilar returns the scene ids of the hashes within distance of the
+// provided hash, ordered by increasing distance.
+func FindSimilar(hashes []*Phash, hash int64, distance int) []int {
+	type match struct {
+		sceneID  int
+		distance int
+	}
+
+	var matches []match
+	for _, h := range hashes {
+		d := PhashDistance(hash, h.Hash)
+		if d <= distance {
+			matches = append(matches, match{sceneID: h.SceneID, distance: d})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].distance < matches[j].distance
+	})
+
+	ret := make([]int, len(matches))
+	for i, m := range matches {
+		ret[i] = m.sceneID
+	}
+
+	return ret
+}
+
 func PhashToString(phash int64) string {
 	return strconv.FormatUint(uint64(phash), 16)
 }
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"reflect"
+	"testing"
+
+	"github.com/corona10/goimagehash"
+	"github.com/disintegration/imaging"
+)
+
+// testPhashImage returns a deterministic image with enough structure to
+// produce a meaningful perceptual hash.
+func testPhashImage() image.Image {
+	const size = 256
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			c := color.RGBA{R: uint8(x), G: uint8(y), B: uint8((x * y) % 256), A: 255}
+			if x > 64 && x < 128 && y > 96 && y < 200 {
+				c = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	return img
+}
+
+func perceptionHash(t *testing.T, img image.Image) int64 {
+	t.Helper()
+
+	hash, err := goimagehash.PerceptionHash(img)
+	if err != nil {
+		t.Fatalf("error generating phash: %v", err)
+	}
+
+	return int64(hash.GetHash())
+}
+
+func TestPhashStability(t *testing.T) {
+	img := testPhashImage()
+	hash := perceptionHash(t, img)
+
+	// hashing the same image must always produce the same hash
+	if got := perceptionHash(t, img); got != hash {
+		t.Errorf("phash not stable: %s != %s", PhashToString(got), PhashToString(hash))
+	}
+
+	// a re-encoded and resized copy should hash to a nearby value
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, imaging.Resize(img, 160, 0, imaging.Lanczos), &jpeg.Options{Quality: 40}); err != nil {
+		t.Fatalf("error encoding image: %v", err)
+	}
+	reencoded, err := jpeg.Decode(&buf)
+	if err != nil {
+		t.Fatalf("error decoding image: %v", err)
+	}
+
+	const maxDistance = 4
+	if d := PhashDistance(hash, perceptionHash(t, reencoded)); d > maxDistance {
+		t.Errorf("re-encoded phash distance = %d, want <= %d", d, maxDistance)
+	}
+}
+
+func TestPhashDistance(t *testing.T) {
+	tests := []struct {
+		name string
+		a    int64
+		b    int64
+		want int
+	}{
+		{"same", 0x0f0f, 0x0f0f, 0},
+		{"one bit", 0x0f0f, 0x0f0e, 1},
+		{"all bits", 0, -1, 64},
+		{"sign bit", 0, -0x8000000000000000, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := PhashDistance(tt.a, tt.b); got != tt.want {
+				t.Errorf("PhashDistance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindSimilar(t *testing.T) {
+	const hash = int64(0xff00ff00)
+	hashes := []*Phash{
+		{SceneID: 1, Hash: hash ^ 0x7},  // distance 3
+		{SceneID: 2, Hash: hash},        // distance 0
+		{SceneID: 3, Hash: hash ^ 0xff}, // distance 8
+		{SceneID: 4, Hash: hash ^ 0x1},  // distance 1
+	}
+
+	tests := []struct {
+		name     string
+		distance int
+		want     []int
+	}{
+		{"exact", 0, []int{2}},
+		{"within one", 1, []int{2, 4}},
+		{"within three", 3, []int{2, 4, 1}},
+		{"within seven", 7, []int{2, 4, 1}},
+		{"within eight", 8, []int{2, 4, 1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FindSimilar(hashes, hash, tt.distance); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindSimilar() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
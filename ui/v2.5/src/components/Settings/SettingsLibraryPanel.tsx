@@ -115,6 +115,14 @@ export const SettingsLibraryPanel: React.FC = () => {
           onChange={(v) => saveGeneral({ imageExcludes: v })}
           defaultNewValue="sample\.jpg$"
         />
+
+        <BooleanSetting
+          id="scan-hidden-files"
+          headingID="config.general.scan_hidden_files_head"
+          subHeadingID="config.general.scan_hidden_files_desc"
+          checked={general.scanHiddenFiles ?? false}
+          onChange={(v) => saveGeneral({ scanHiddenFiles: v })}
+        />
       </SettingSection>
 
       <SettingSection headingID="config.library.gallery_and_image_options">
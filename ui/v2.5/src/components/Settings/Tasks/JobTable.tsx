@@ -30,7 +30,8 @@ const Task: React.FC<IJob> = ({ job }) => {
   useEffect(() => {
     if (
       job.status === GQL.JobStatus.Cancelled ||
-      job.status === GQL.JobStatus.Finished
+      job.status === GQL.JobStatus.Finished ||
+      job.status === GQL.JobStatus.Failed
     ) {
       // fade out around 10 seconds
       setTimeout(() => {
@@ -64,6 +65,8 @@ const Task: React.FC<IJob> = ({ job }) => {
         return "finished";
       case GQL.JobStatus.Cancelled:
         return "cancelled";
+      case GQL.JobStatus.Failed:
+        return "failed";
     }
   }
 
@@ -88,6 +91,9 @@ const Task: React.FC<IJob> = ({ job }) => {
       case GQL.JobStatus.Cancelled:
         icon = "ban";
         break;
+      case GQL.JobStatus.Failed:
+        icon = "exclamation-circle";
+        break;
     }
 
     return <Icon icon={icon} className={`fa-fw ${iconClass}`} />;
@@ -147,6 +153,7 @@ const Task: React.FC<IJob> = ({ job }) => {
           </div>
           <div>{maybeRenderProgress()}</div>
           {maybeRenderSubTasks()}
+          {job.error && <div className="job-error">{job.error}</div>}
         </div>
       </div>
     </li>
@@ -29,6 +29,9 @@ export const Setup: React.FC = () => {
   const [databaseFile, setDatabaseFile] = useState("");
   const [loading, setLoading] = useState(false);
   const [setupError, setSetupError] = useState("");
+  const [selfTestResults, setSelfTestResults] = useState<
+    GQL.SetupMutation["setup"]
+  >([]);
 
   const intl = useIntl();
 
@@ -388,12 +391,13 @@ export const Setup: React.FC = () => {
   async function onSave() {
     try {
       setLoading(true);
-      await mutateSetup({
+      const result = await mutateSetup({
         configLocation,
         databaseFile,
         generatedLocation,
         stashes,
       });
+      setSelfTestResults(result.data?.setup ?? []);
     } catch (e) {
       if (e instanceof Error) setSetupError(e.message ?? e.toString());
     } finally {
@@ -498,6 +502,31 @@ export const Setup: React.FC = () => {
     );
   }
 
+  function renderSelfTestFailures() {
+    const failed = selfTestResults.filter((r) => !r.passed);
+    if (failed.length === 0) {
+      return;
+    }
+
+    return (
+      <section>
+        <h3>
+          <FormattedMessage id="setup.success.self_test_failed" />
+        </h3>
+        <p>
+          <FormattedMessage id="setup.success.self_test_failed_description" />
+        </p>
+        <ul>
+          {failed.map((r) => (
+            <li key={r.name}>
+              <code>{r.name}</code>: {r.error}
+            </li>
+          ))}
+        </ul>
+      </section>
+    );
+  }
+
   function renderSuccess() {
     return (
       <>
@@ -521,6 +550,7 @@ export const Setup: React.FC = () => {
             />
           </p>
         </section>
+        {renderSelfTestFailures()}
         <section>
           <h3>
             <FormattedMessage id="setup.success.getting_help" />
@@ -24,6 +24,8 @@ const defaultSortBy = "date";
 const sortByOptions = [
   "organized",
   "o_counter",
+  "play_count",
+  "last_played_at",
   "date",
   "filesize",
   "duration",